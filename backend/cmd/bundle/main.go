@@ -0,0 +1,95 @@
+// Command bundle exports a game with its catalog to a portable JSON bundle,
+// or imports such a bundle into a database.
+//
+//	bundle -db tierforge.db -export dos2 -out dos2.tierforge.json
+//	bundle -db tierforge.db -import dos2.tierforge.json [-replace]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/meur/tierforge/internal/interchange"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	exportID := flag.String("export", "", "Game ID to export")
+	out := flag.String("out", "", "Export destination (default stdout)")
+	importPath := flag.String("import", "", "Bundle file to import")
+	replace := flag.Bool("replace", false, "On import, remove catalog items missing from the bundle")
+	flag.Parse()
+
+	if (*exportID == "") == (*importPath == "") {
+		log.Fatal("Specify exactly one of -export or -import")
+	}
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if *exportID != "" {
+		exportBundle(store, *exportID, *out)
+	} else {
+		importBundle(store, *importPath, *replace)
+	}
+}
+
+func exportBundle(store *storage.Store, gameID, out string) {
+	bundle, err := store.ExportGameBundle(gameID)
+	if err != nil {
+		log.Fatalf("Failed to export %s: %v", gameID, err)
+	}
+	if bundle == nil {
+		log.Fatalf("Game %s not found", gameID)
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode bundle: %v", err)
+	}
+	if out == "" {
+		os.Stdout.Write(append(data, '\n'))
+		return
+	}
+	if err := os.WriteFile(out, append(data, '\n'), 0o644); err != nil {
+		log.Fatalf("Failed to write %s: %v", out, err)
+	}
+	log.Printf("✓ Exported %s: %d items, %d relations → %s", gameID, len(bundle.Items), len(bundle.Relations), out)
+}
+
+func importBundle(store *storage.Store, path string, replace bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", path, err)
+	}
+	bundle, err := interchange.DecodeGameBundle(data)
+	if err != nil {
+		log.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	if err := bundle.Validate(); err != nil {
+		log.Fatalf("Invalid bundle: %v", err)
+	}
+
+	existing, err := store.GetGame(bundle.Game.ID)
+	if err != nil {
+		log.Fatalf("Failed to look up game: %v", err)
+	}
+	if existing != nil {
+		snap, err := store.SnapshotItems(bundle.Game.ID, "", "cmd:bundle")
+		if err != nil {
+			log.Fatalf("Failed to snapshot current items: %v", err)
+		}
+		log.Printf("Saved current catalog as snapshot #%d", snap.ID)
+	}
+
+	if err := store.ImportGameBundle(bundle, replace); err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	log.Printf("✓ Imported %s: %d items, %d relations", bundle.Game.ID, len(bundle.Items), len(bundle.Relations))
+}
@@ -0,0 +1,81 @@
+// Command check_links requests the wiki and icon links of catalog items and
+// reports those that no longer answer 200. With -clear it also removes the
+// links that answer 404 or 410; a snapshot is taken first, so
+// cmd/rollback_import can undo it.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/meur/tierforge/internal/linkcheck"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// ANSI color codes
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	gameID := flag.String("game", "", "Game ID (default: every game)")
+	clear := flag.Bool("clear", false, "Remove links that answer 404 or 410")
+	timeout := flag.Duration("timeout", 30*time.Second, "Timeout of each request")
+	jsonOut := flag.Bool("json", false, "Print the dead links as JSON")
+	flag.Parse()
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to connect to database: %v%s", colorRed, err, colorReset)
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dead, checked, err := linkcheck.New(*timeout).Dead(ctx, store, *gameID)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to check links: %v%s", colorRed, err, colorReset)
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(dead); err != nil {
+			log.Fatalf("%s✗ Failed to write report: %v%s", colorRed, err, colorReset)
+		}
+	} else {
+		for _, l := range dead {
+			reason := fmt.Sprintf("HTTP %d", l.Status)
+			if l.Error != "" {
+				reason = l.Error
+			}
+			color := colorYellow
+			if l.Gone() {
+				color = colorRed
+			}
+			fmt.Printf("%s  %s/%s %s (%s): %s — %s%s\n", color, l.GameID, l.ItemID, l.Field, l.Name, l.URL, reason, colorReset)
+		}
+		fmt.Printf("%s🔍 Checked %d links, %d dead%s\n", colorCyan, checked, len(dead), colorReset)
+	}
+
+	if !*clear {
+		return
+	}
+	n, err := linkcheck.Clear(store, dead, "cmd:check_links")
+	if err != nil {
+		log.Fatalf("%s✗ Failed to clear dead links: %v%s", colorRed, err, colorReset)
+	}
+	fmt.Fprintf(os.Stderr, "%s✓ Cleared %d dead links%s\n", colorGreen, n, colorReset)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// ANSI color codes
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// normalize lowercases a name and strips everything but letters and digits,
+// so "Fireball!" and "fire ball" compare equal
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// nameKeys returns the normalized names an item can be matched on. Both the
+// display name and the Russian name are used so RU-only imports match their
+// English counterparts.
+func nameKeys(item models.Item) []string {
+	keys := []string{}
+	for _, name := range []string{item.Name, item.NameRu} {
+		if key := normalize(name); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// score ranks which item of a duplicate group should be kept
+func score(item models.Item) int {
+	s := 0
+	if item.NameRu != "" && item.Name != item.NameRu {
+		s += 4 // has both an English and a Russian name
+	}
+	if item.Icon != "" {
+		s += 2
+	}
+	for _, v := range item.Data {
+		if v != nil && v != "" && v != false {
+			s++
+		}
+	}
+	return s
+}
+
+// findGroups clusters items of one sheet whose names match exactly after
+// normalization, or within maxDistance edits for names of six or more characters
+func findGroups(items []models.Item, maxDistance int) [][]models.Item {
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) { parent[find(a)] = find(b) }
+
+	byKey := make(map[string]int)
+	keys := make([][]string, len(items))
+	for i, item := range items {
+		keys[i] = nameKeys(item)
+		for _, key := range keys[i] {
+			if j, ok := byKey[key]; ok {
+				union(i, j)
+			} else {
+				byKey[key] = i
+			}
+		}
+	}
+
+	if maxDistance > 0 {
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if find(i) == find(j) {
+					continue
+				}
+			pairs:
+				for _, a := range keys[i] {
+					for _, b := range keys[j] {
+						if len([]rune(a)) >= 6 && len([]rune(b)) >= 6 && levenshtein(a, b) <= maxDistance {
+							union(i, j)
+							break pairs
+						}
+					}
+				}
+			}
+		}
+	}
+
+	clusters := make(map[int][]models.Item)
+	for i, item := range items {
+		root := find(i)
+		clusters[root] = append(clusters[root], item)
+	}
+
+	groups := make([][]models.Item, 0)
+	for _, group := range clusters {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(a, b int) bool {
+			sa, sb := score(group[a]), score(group[b])
+			if sa != sb {
+				return sa > sb
+			}
+			return group[a].ID < group[b].ID
+		})
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(a, b int) bool { return groups[a][0].ID < groups[b][0].ID })
+	return groups
+}
+
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	gameID := flag.String("game", "dos2", "Game ID")
+	sheetID := flag.String("sheet", "", "Only check one sheet (default: all sheets)")
+	distance := flag.Int("distance", 1, "Maximum edit distance for near-identical names (0 = exact only)")
+	apply := flag.Bool("apply", false, "Merge the proposed duplicates instead of only listing them")
+	flag.Parse()
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to connect to database: %v%s", colorRed, err, colorReset)
+	}
+	defer store.Close()
+
+	items, err := store.GetItems(*gameID, *sheetID)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to read items: %v%s", colorRed, err, colorReset)
+	}
+
+	// Tier lists are per sheet, so only items of the same sheet can be merged
+	bySheet := make(map[string][]models.Item)
+	sheets := []string{}
+	for _, item := range items {
+		if _, ok := bySheet[item.SheetID]; !ok {
+			sheets = append(sheets, item.SheetID)
+		}
+		bySheet[item.SheetID] = append(bySheet[item.SheetID], item)
+	}
+	sort.Strings(sheets)
+
+	var proposals [][]models.Item
+	for _, sheet := range sheets {
+		proposals = append(proposals, findGroups(bySheet[sheet], *distance)...)
+	}
+
+	if len(proposals) == 0 {
+		fmt.Printf("%s✓ No duplicates found in %d items%s\n", colorGreen, len(items), colorReset)
+		return
+	}
+
+	for _, group := range proposals {
+		keep := group[0]
+		fmt.Printf("%s%s/%s%s keep %s%s%s (%s)\n", colorCyan, *gameID, keep.SheetID, colorReset,
+			colorGreen, keep.ID, colorReset, keep.Name)
+		for _, drop := range group[1:] {
+			fmt.Printf("    merge %s%s%s (%s / %s)\n", colorYellow, drop.ID, colorReset, drop.Name, drop.NameRu)
+		}
+	}
+
+	if !*apply {
+		fmt.Printf("\n%s%d duplicate groups found. Re-run with -apply to merge them.%s\n", colorYellow, len(proposals), colorReset)
+		return
+	}
+
+	snap, err := store.SnapshotItems(*gameID, *sheetID, "cmd:dedupe")
+	if err != nil {
+		log.Fatalf("%s✗ Failed to snapshot items: %v%s", colorRed, err, colorReset)
+	}
+	fmt.Printf("%s📸 Saved snapshot #%d of %d items%s\n", colorCyan, snap.ID, snap.ItemCount, colorReset)
+
+	merged := 0
+	rewritten := 0
+	for _, group := range proposals {
+		keep := group[0]
+		for _, drop := range group[1:] {
+			n, err := store.MergeItems(*gameID, keep.ID, drop.ID)
+			if err != nil {
+				log.Fatalf("%s✗ Failed to merge %s into %s: %v%s", colorRed, drop.ID, keep.ID, err, colorReset)
+			}
+			if err := store.AddAuditEntry(&models.AuditEntry{
+				Actor:      "cmd:dedupe",
+				Action:     "item.merge",
+				TargetType: "item",
+				TargetID:   keep.ID,
+				GameID:     *gameID,
+				Details:    fmt.Sprintf("merged %s, rewrote %d tier lists", drop.ID, n),
+			}); err != nil {
+				log.Printf("%s⚠ Warning: failed to write audit entry: %v%s", colorYellow, err, colorReset)
+			}
+			merged++
+			rewritten += n
+		}
+	}
+
+	fmt.Printf("%s✓ Merged %d items, rewrote %d tier lists%s\n", colorGreen, merged, rewritten, colorReset)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// export_events writes recorded product analytics events as NDJSON or CSV
+// for offline analysis. Events are anonymized when recorded, so the output
+// can be shared without further scrubbing.
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	since := flag.String("since", "", "Only events on or after this date (YYYY-MM-DD)")
+	until := flag.String("until", "", "Only events before this date (YYYY-MM-DD)")
+	format := flag.String("format", "ndjson", "Output format: ndjson or csv")
+	outPath := flag.String("out", "", "Output file (default stdout)")
+	flag.Parse()
+
+	from, to := time.Time{}, time.Now().Add(time.Minute)
+	var err error
+	if *since != "" {
+		if from, err = time.Parse("2006-01-02", *since); err != nil {
+			log.Fatalf("Invalid -since: %v", err)
+		}
+	}
+	if *until != "" {
+		if to, err = time.Parse("2006-01-02", *until); err != nil {
+			log.Fatalf("Invalid -until: %v", err)
+		}
+	}
+	if *format != "ndjson" && *format != "csv" {
+		log.Fatalf("Unknown format %q; use ndjson or csv", *format)
+	}
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *outPath, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	buf := bufio.NewWriter(out)
+
+	n := 0
+	if *format == "csv" {
+		err = writeCSV(store, buf, from, to, &n)
+	} else {
+		enc := json.NewEncoder(buf)
+		err = store.EachEvent(from, to, func(e models.Event) error {
+			n++
+			return enc.Encode(e)
+		})
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err != nil {
+		log.Fatalf("Failed to export events: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d events\n", n)
+}
+
+// writeCSV writes one row per event with props as a JSON column
+func writeCSV(store *storage.Store, out io.Writer, from, to time.Time, n *int) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"id", "name", "game_id", "props", "created_at"}); err != nil {
+		return err
+	}
+	err := store.EachEvent(from, to, func(e models.Event) error {
+		*n++
+		props := ""
+		if len(e.Props) > 0 {
+			b, _ := json.Marshal(e.Props)
+			props = string(b)
+		}
+		return w.Write([]string{strconv.FormatInt(e.ID, 10), e.Name, e.GameID, props, e.CreatedAt.UTC().Format(time.RFC3339)})
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,223 @@
+// Command gdpr answers data subject requests for one account, found by user
+// ID or email address.
+//
+//	gdpr export -db tierforge.db -user alice@example.com -out alice.json
+//	gdpr purge -db tierforge.db -user alice@example.com -report purge.json [-dry-run]
+//	gdpr verify -report purge.json
+//
+// export writes everything tied to the account. purge deletes it, keeps
+// the audit log with the user's ID replaced, and writes a report of what
+// was removed, signed with HMAC-SHA256 under GDPR_SIGNING_KEY so the
+// report can later be shown to be unaltered. verify checks that signature.
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// export is everything tied to an account: its takeout, plus what only
+// admins see
+type export struct {
+	*models.Takeout
+	Sessions      []models.Session      `json:"sessions"`
+	QuotaOverride *models.QuotaOverride `json:"quota_override,omitempty"`
+	AuditActor    []models.AuditEntry   `json:"audit_as_actor"`
+	AuditTarget   []models.AuditEntry   `json:"audit_as_target"`
+}
+
+// report records a purge. Signature covers the report encoded without it.
+type report struct {
+	Action      string             `json:"action"`
+	UserID      string             `json:"user_id"`
+	EmailSHA256 string             `json:"email_sha256"`
+	DryRun      bool               `json:"dry_run"`
+	PerformedAt time.Time          `json:"performed_at"`
+	Result      models.PurgeResult `json:"result"`
+	Signature   string             `json:"signature,omitempty"`
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("Usage: gdpr export|purge|verify [flags]")
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	dbPath := fs.String("db", "./tierforge.db", "SQLite database path")
+	who := fs.String("user", "", "User ID or email address")
+	out := fs.String("out", "", "Export destination (default stdout)")
+	reportPath := fs.String("report", "", "Purge report destination (default stdout), or the report to verify")
+	dryRun := fs.Bool("dry-run", false, "Count what a purge would remove without changing anything")
+	fs.Parse(args)
+
+	key := os.Getenv("GDPR_SIGNING_KEY")
+	switch cmd {
+	case "export", "purge":
+		if *who == "" {
+			log.Fatal("Specify -user")
+		}
+		if cmd == "purge" && key == "" {
+			log.Fatal("Set GDPR_SIGNING_KEY to sign the purge report")
+		}
+	case "verify":
+		if *reportPath == "" || key == "" {
+			log.Fatal("Specify -report and set GDPR_SIGNING_KEY")
+		}
+		verify(*reportPath, key)
+		return
+	default:
+		log.Fatalf("Unknown command %q; use export, purge or verify", cmd)
+	}
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	user := findUser(store, *who)
+	if cmd == "export" {
+		exportUser(store, user, *out)
+	} else {
+		purgeUser(store, user, *dryRun, key, *reportPath)
+	}
+}
+
+// findUser looks up an account by ID or, when who has an @, by email
+func findUser(store *storage.Store, who string) *models.User {
+	var user *models.User
+	var err error
+	if strings.Contains(who, "@") {
+		user, _, err = store.GetUserByEmail(who)
+	} else {
+		user, err = store.GetUser(who)
+	}
+	if err != nil {
+		log.Fatalf("Failed to look up %s: %v", who, err)
+	}
+	if user == nil {
+		log.Fatalf("No account matches %s", who)
+	}
+	return user
+}
+
+func exportUser(store *storage.Store, user *models.User, out string) {
+	takeout, err := store.GetTakeout(user)
+	if err != nil {
+		log.Fatalf("Failed to export account data: %v", err)
+	}
+	e := export{Takeout: takeout}
+	if e.Sessions, err = store.GetSessions(user.ID); err == nil {
+		e.QuotaOverride, err = store.GetQuotaOverride(user.ID)
+	}
+	if err == nil {
+		e.AuditActor, err = store.GetAuditEntries(models.AuditFilter{Actor: user.ID, Limit: math.MaxInt32})
+	}
+	if err == nil {
+		e.AuditTarget, err = store.GetAuditEntries(models.AuditFilter{TargetType: "user", TargetID: user.ID, Limit: math.MaxInt32})
+	}
+	if err != nil {
+		log.Fatalf("Failed to export account data: %v", err)
+	}
+
+	write(out, e)
+	log.Printf("✓ Exported %s: %d tier lists, %d matchups, %d brackets, %d poll votes, %d audit entries",
+		user.ID, len(e.TierLists), len(e.Matchups), len(e.Brackets), len(e.PollVotes), len(e.AuditActor)+len(e.AuditTarget))
+}
+
+func purgeUser(store *storage.Store, user *models.User, dryRun bool, key, out string) {
+	result, err := store.PurgeUser(user.ID, dryRun)
+	if err != nil {
+		log.Fatalf("Failed to purge %s: %v", user.ID, err)
+	}
+	sum := sha256.Sum256([]byte(user.Email))
+	rep := report{
+		Action:      "purge",
+		UserID:      user.ID,
+		EmailSHA256: hex.EncodeToString(sum[:]),
+		DryRun:      dryRun,
+		PerformedAt: time.Now().UTC(),
+		Result:      *result,
+	}
+	rep.Signature = sign(rep, key)
+
+	if !dryRun {
+		entry := &models.AuditEntry{
+			Actor:      "system",
+			Action:     "user.purge",
+			TargetType: "user",
+			TargetID:   models.DeletedUserID,
+			Details:    "Purged on request; report " + rep.Signature,
+		}
+		if err := store.AddAuditEntry(entry); err != nil {
+			log.Printf("ERROR: Failed to audit purge: %v", err)
+		}
+	}
+	write(out, rep)
+
+	var removed int64
+	for _, n := range result.Removed {
+		removed += n
+	}
+	if dryRun {
+		log.Printf("Dry run: would remove %d rows of %s", removed, user.ID)
+	} else {
+		log.Printf("✓ Purged %s: removed %d rows", user.ID, removed)
+	}
+}
+
+// sign returns the HMAC-SHA256 of a report encoded without its signature
+func sign(rep report, key string) string {
+	rep.Signature = ""
+	data, err := json.Marshal(rep)
+	if err != nil {
+		log.Fatalf("Failed to encode report: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func verify(path, key string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", path, err)
+	}
+	var rep report
+	if err := json.Unmarshal(data, &rep); err != nil {
+		log.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	if !hmac.Equal([]byte(rep.Signature), []byte(sign(rep, key))) {
+		log.Fatalf("✗ %s does not match its signature", path)
+	}
+	fmt.Printf("✓ %s is a genuine %s report of %s from %s\n", path, rep.Action, rep.UserID, rep.PerformedAt.Format(time.RFC3339))
+}
+
+// write writes v as indented JSON to path, or to stdout when path is empty
+func write(path string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode output: %v", err)
+	}
+	data = append(data, '\n')
+	if path == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		log.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+	_ "github.com/meur/tierforge/internal/plugins/games"
+	"github.com/meur/tierforge/internal/plugins/mods"
+	"github.com/meur/tierforge/internal/plugins/script"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// ANSI color codes
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	name := flag.String("importer", "", "Importer to run, see -list")
+	gameFlag := flag.String("game", "", "Game whose script or mod imports the data, instead of -importer")
+	sheetFlag := flag.String("sheet", "", "Sheet the game's script imports into")
+	modFlag := flag.String("mod", "", "Mod whose content -in holds, imported into a sheet of its own")
+	locaPath := flag.String("loca", "", "Larian localization XML naming the mod's stats entries")
+	replace := flag.Bool("replace", false, "Replace the sheet's items rather than update them, for script and mod imports")
+	scriptPath := flag.String("script", "", "Lua script to run instead of the one attached to the game")
+	inPath := flag.String("in", "", "Scraped data file to import, or for mods a directory of stats files")
+	seedsDir := flag.String("seeds", "./seeds", "Seeds directory; the game is created from <game>_game.json there when present")
+	dryRun := flag.Bool("dry-run", false, "Print summary without writing to the database")
+	list := flag.Bool("list", false, "List the importers and exit")
+	flag.Parse()
+
+	if *list {
+		for _, imp := range plugins.Importers() {
+			fmt.Printf("%-16s %s/%s\n", imp.Name(), imp.GameID(), imp.SheetID())
+		}
+		return
+	}
+
+	var imp plugins.ImporterPlugin
+	gameID := *gameFlag
+	switch {
+	case *name != "":
+		if imp = plugins.Importer(*name); imp == nil {
+			log.Fatalf("%s✗ Unknown importer %q, see -list%s", colorRed, *name, colorReset)
+		}
+		gameID = imp.GameID()
+	case *gameFlag != "" && *modFlag != "":
+		var names map[string]string
+		if *locaPath != "" {
+			loca, err := os.ReadFile(*locaPath)
+			if err == nil {
+				names, err = mods.ParseLocalization(loca)
+			}
+			if err != nil {
+				log.Fatalf("%s✗ Failed to read %s: %v%s", colorRed, *locaPath, err, colorReset)
+			}
+		}
+		imp = mods.Importer(*gameFlag, *modFlag, *replace, names)
+	case *gameFlag == "" || *sheetFlag == "":
+		log.Fatalf("%s✗ -importer, -game and -mod for a mod, or -game and -sheet for a script import, is required%s", colorRed, colorReset)
+	}
+	if *inPath == "" {
+		log.Fatalf("%s✗ -in is required%s", colorRed, colorReset)
+	}
+
+	data, err := readInput(*inPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to read %s: %v%s", colorRed, *inPath, err, colorReset)
+	}
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to connect to database: %v%s", colorRed, err, colorReset)
+	}
+	defer store.Close()
+
+	sc, err := loadScript(store, gameID, *scriptPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to load script: %v%s", colorRed, err, colorReset)
+	}
+	if sc != nil {
+		defer sc.Close()
+	}
+	if imp == nil {
+		if sc == nil || !sc.HasImport() {
+			log.Fatalf("%s✗ Game %s has no script with an import function%s", colorRed, gameID, colorReset)
+		}
+		imp = sc.Importer(*sheetFlag, *replace)
+	}
+	sheetID := imp.SheetID()
+	actor := "cmd:import:" + imp.Name()
+
+	existing, err := store.GetItems(gameID, sheetID)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to read existing items: %v%s", colorRed, err, colorReset)
+	}
+
+	items, err := imp.Import(data, existing)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to import %s: %v%s", colorRed, *inPath, err, colorReset)
+	}
+	plugins.EnrichAll(items)
+	if sc != nil {
+		if err := sc.EnrichAll(items); err != nil {
+			log.Fatalf("%s✗ Script failed: %v%s", colorRed, err, colorReset)
+		}
+	}
+
+	existingIDs := make(map[string]bool, len(existing))
+	for _, item := range existing {
+		existingIDs[item.ID] = true
+	}
+	updated := 0
+	for _, item := range items {
+		if existingIDs[item.ID] {
+			updated++
+		}
+	}
+	created := len(items) - updated
+	fmt.Printf("%s📦 Loaded %d items for %s/%s (created %d, updated %d)%s\n", colorCyan, len(items), gameID, sheetID, created, updated, colorReset)
+
+	if *dryRun {
+		removed := 0
+		if imp.Replace() {
+			removed = len(existing) - updated
+		}
+		log.Printf("Dry run: would import %d items (created %d, updated %d, removed %d). Existing items: %d",
+			len(items), created, updated, removed, len(existing))
+		return
+	}
+
+	if err := seedGame(store, filepath.Join(*seedsDir, gameID+"_game.json")); err != nil {
+		log.Fatalf("%s✗ Failed to create game record: %v%s", colorRed, err, colorReset)
+	}
+	if *modFlag != "" {
+		if err := addModSheet(store, gameID, sheetID, strings.TrimSpace(*modFlag)); err != nil {
+			log.Fatalf("%s✗ Failed to add the mod's sheet: %v%s", colorRed, err, colorReset)
+		}
+	}
+
+	snap, err := store.SnapshotItems(gameID, sheetID, actor)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to snapshot existing items: %v%s", colorRed, err, colorReset)
+	}
+	fmt.Printf("%s📸 Saved snapshot #%d of %d items (undo with cmd/rollback_import)%s\n", colorCyan, snap.ID, snap.ItemCount, colorReset)
+
+	details := fmt.Sprintf("imported %d items from %s (created %d, updated %d)", len(items), *inPath, created, updated)
+	if imp.Replace() {
+		details = fmt.Sprintf("replaced sheet with %d items from %s", len(items), *inPath)
+	}
+	err = store.WithTx(context.Background(), func(tx *storage.Store) error {
+		if imp.Replace() {
+			// IDs may have changed, so stale items are removed rather than kept
+			if err := tx.DeleteItemsBySheet(gameID, sheetID); err != nil {
+				return err
+			}
+		}
+		if err := tx.BulkCreateItems(items); err != nil {
+			return err
+		}
+		return tx.AddAuditEntry(&models.AuditEntry{
+			Actor:      actor,
+			Action:     "items.import",
+			TargetType: "sheet",
+			TargetID:   sheetID,
+			GameID:     gameID,
+			Details:    details,
+		})
+	})
+	if err != nil {
+		log.Fatalf("%s✗ Failed to import items: %v%s", colorRed, err, colorReset)
+	}
+
+	fmt.Printf("%s✓ Imported %d items (created %d, updated %d)%s\n", colorGreen, len(items), created, updated, colorReset)
+}
+
+// loadScript compiles the script at path, or else the one attached to the
+// game. It returns nil when there is neither.
+func loadScript(store *storage.Store, gameID, path string) (*script.Script, error) {
+	var source string
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		source = string(data)
+	} else {
+		gs, err := store.GetGameScript(gameID)
+		if err != nil || gs == nil {
+			return nil, err
+		}
+		source = gs.Source
+	}
+	sc, err := script.Compile(gameID, source)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("%s📜 Loaded the %s script%s\n", colorCyan, gameID, colorReset)
+	return sc, nil
+}
+
+// readInput reads the data file at path. A directory is read as the stats
+// files of a mod: every .txt file under it, joined in path order.
+func readInput(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return os.ReadFile(path)
+	}
+	var data []byte
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".txt") {
+			return err
+		}
+		file, err := os.ReadFile(p)
+		data = append(append(data, file...), '\n')
+		return err
+	})
+	return data, err
+}
+
+// addModSheet adds a mod's sheet to its game unless the game has it. The
+// game must exist, from its seed file or the admin API.
+func addModSheet(store *storage.Store, gameID, sheetID, mod string) error {
+	game, err := store.GetGame(gameID)
+	if err != nil {
+		return err
+	}
+	if game == nil {
+		return fmt.Errorf("game %s does not exist", gameID)
+	}
+	if game.Sheet(sheetID) != nil {
+		return nil
+	}
+	game.Sheets = append(game.Sheets, models.SheetConfig{
+		ID:          sheetID,
+		Name:        mod,
+		Description: "Content of the " + mod + " mod",
+	})
+	if err := store.CreateGame(game); err != nil {
+		return err
+	}
+	fmt.Printf("%s🧩 Added sheet %s for the %s mod%s\n", colorCyan, sheetID, mod, colorReset)
+	return nil
+}
+
+// seedGame creates or updates the game from its seed file, if there is one
+func seedGame(store *storage.Store, path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var game models.Game
+	if err := json.Unmarshal(data, &game); err != nil {
+		return err
+	}
+	if err := store.CreateGame(&game); err != nil {
+		return err
+	}
+	fmt.Printf("%s🎮 Game '%s' created/updated.%s\n", colorCyan, game.Name, colorReset)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// integrity checks that every JSON column in the database decodes into its
+// model type, listing the rows that do not. Reads of a corrupt row fail with
+// an error naming it; use this to find all of them at once, for example after
+// editing the database by hand. It exits with status 1 when problems are found.
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	flag.Parse()
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	problems, err := store.CheckJSON()
+	if err != nil {
+		log.Fatalf("Failed to check database: %v", err)
+	}
+	for _, p := range problems {
+		fmt.Printf("%s %s: %s: %v\n", p.Table, p.ID, p.Column, p.Err)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("%d corrupt JSON values\n", len(problems))
+		store.Close()
+		os.Exit(1)
+	}
+	fmt.Println("All JSON columns decode")
+}
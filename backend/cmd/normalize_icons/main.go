@@ -0,0 +1,140 @@
+// Command normalize_icons downloads item icons, scales each to a 64px PNG and
+// points the items at the copy the app serves from /api/icons/. Identical
+// images are stored once. A snapshot is taken before items are changed, so
+// cmd/rollback_import can undo it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/meur/tierforge/internal/icons"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// ANSI color codes
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	gameID := flag.String("game", "", "Game ID (default: every game)")
+	sheetID := flag.String("sheet", "", "Sheet ID (default: every sheet)")
+	baseURL := flag.String("base", "", "Base URL of site-relative icons such as /icons/... (default: skip them)")
+	dryRun := flag.Bool("dry-run", false, "Download and convert icons without changing items")
+	flag.Parse()
+
+	var base *url.URL
+	if *baseURL != "" {
+		var err error
+		if base, err = url.Parse(*baseURL + "/"); err != nil || base.Host == "" {
+			log.Fatalf("%s✗ Invalid -base URL %q%s", colorRed, *baseURL, colorReset)
+		}
+	}
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to connect to database: %v%s", colorRed, err, colorReset)
+	}
+	defer store.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	games := []models.Game{{ID: *gameID}}
+	if *gameID == "" {
+		if games, err = store.GetGames(); err != nil {
+			log.Fatalf("%s✗ Failed to read games: %v%s", colorRed, err, colorReset)
+		}
+	}
+
+	iconStore := store
+	if *dryRun {
+		iconStore = nil
+	}
+	normalizer := icons.NewNormalizer(iconStore, &http.Client{Timeout: 30 * time.Second}, base)
+	stored := make(map[string]bool)
+	changed, skipped, failed := 0, 0, 0
+	for _, game := range games {
+		items, err := store.GetItems(game.ID, *sheetID)
+		if err != nil {
+			log.Fatalf("%s✗ Failed to read items of %s: %v%s", colorRed, game.ID, err, colorReset)
+		}
+		snapshotted := false
+		for _, item := range items {
+			if ctx.Err() != nil {
+				log.Fatalf("%s✗ Interrupted after %d items%s", colorRed, changed, colorReset)
+			}
+			if item.Icon == "" || icons.Normalized(item.Icon) {
+				continue
+			}
+			if base == nil && !isAbsolute(item.Icon) {
+				skipped++
+				continue
+			}
+
+			path, err := normalizer.Normalize(ctx, item.Icon)
+			if err != nil {
+				fmt.Printf("%s⚠ %s/%s: %v%s\n", colorYellow, game.ID, item.ID, err, colorReset)
+				failed++
+				continue
+			}
+			stored[path] = true
+			changed++
+			if *dryRun {
+				fmt.Printf("  %s/%s: %s → %s\n", game.ID, item.ID, item.Icon, path)
+				continue
+			}
+
+			if !snapshotted {
+				snap, err := store.SnapshotItems(game.ID, *sheetID, "cmd:normalize_icons")
+				if err != nil {
+					log.Fatalf("%s✗ Failed to snapshot items of %s: %v%s", colorRed, game.ID, err, colorReset)
+				}
+				fmt.Printf("%s📸 Saved snapshot #%d of %d items (undo with cmd/rollback_import)%s\n", colorCyan, snap.ID, snap.ItemCount, colorReset)
+				snapshotted = true
+			}
+			item.Icon = path
+			if err := store.UpdateItem(&item); err != nil {
+				log.Fatalf("%s✗ Failed to update %s: %v%s", colorRed, item.ID, err, colorReset)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d items now share %d icons, %d failed, %d site-relative skipped", changed, len(stored), failed, skipped)
+	if *dryRun {
+		fmt.Printf("%s🔍 Dry run: %s%s\n", colorCyan, summary, colorReset)
+		return
+	}
+	fmt.Printf("%s✓ Normalized icons: %s%s\n", colorGreen, summary, colorReset)
+
+	if changed > 0 {
+		if err := store.AddAuditEntry(&models.AuditEntry{
+			Actor:      "cmd:normalize_icons",
+			Action:     "items.normalize_icons",
+			TargetType: "catalog",
+			TargetID:   "*",
+			Details:    summary,
+		}); err != nil {
+			log.Printf("%s⚠ Warning: failed to write audit entry: %v%s", colorYellow, err, colorReset)
+		}
+	}
+}
+
+func isAbsolute(icon string) bool {
+	u, err := url.Parse(icon)
+	return err == nil && u.IsAbs()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// reindex rebuilds the item and tier list search indexes. The server keeps
+// the index current on its own; use this after restoring a backup or editing
+// the database by hand.
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	flag.Parse()
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+
+	if !store.SearchEnabled() {
+		log.Fatal("This build has no FTS5 support; rebuild with -tags sqlite_fts5")
+	}
+	n, err := store.RebuildSearchIndex()
+	if err != nil {
+		log.Fatalf("Failed to rebuild search index: %v", err)
+	}
+	fmt.Printf("Indexed %d items\n", n)
+
+	n, err = store.RebuildTierListSearchIndex()
+	if err != nil {
+		log.Fatalf("Failed to rebuild tier list search index: %v", err)
+	}
+	fmt.Printf("Indexed %d tier lists\n", n)
+}
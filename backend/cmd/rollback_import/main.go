@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// ANSI color codes
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	gameID := flag.String("game", "dos2", "Game ID")
+	snapshotID := flag.Int64("id", 0, "Snapshot ID to restore (default: the latest unrestored snapshot)")
+	list := flag.Bool("list", false, "List snapshots for the game and exit")
+	flag.Parse()
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to connect to database: %v%s", colorRed, err, colorReset)
+	}
+	defer store.Close()
+
+	snaps, err := store.GetSnapshots(*gameID)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to read snapshots: %v%s", colorRed, err, colorReset)
+	}
+
+	if *list {
+		for _, snap := range snaps {
+			status := ""
+			if snap.RestoredAt != nil {
+				status = fmt.Sprintf(" (restored %s)", snap.RestoredAt.Format("2006-01-02 15:04"))
+			}
+			sheet := snap.SheetID
+			if sheet == "" {
+				sheet = "*"
+			}
+			fmt.Printf("#%d  %s  %s/%s  %d items  %s%s\n", snap.ID, snap.CreatedAt.Format("2006-01-02 15:04"),
+				snap.GameID, sheet, snap.ItemCount, snap.Source, status)
+		}
+		return
+	}
+
+	id := *snapshotID
+	if id == 0 {
+		for _, snap := range snaps {
+			if snap.RestoredAt == nil {
+				id = snap.ID
+				break
+			}
+		}
+	}
+	if id == 0 {
+		log.Fatalf("%s✗ No snapshot to restore for %s%s", colorRed, *gameID, colorReset)
+	}
+
+	snap, err := store.GetSnapshot(id)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to read snapshot #%d: %v%s", colorRed, id, err, colorReset)
+	}
+	if snap == nil || snap.GameID != *gameID {
+		log.Fatalf("%s✗ Snapshot #%d not found for %s%s", colorRed, id, *gameID, colorReset)
+	}
+
+	// Snapshot the current state first so the rollback itself can be undone
+	backup, err := store.SnapshotItems(snap.GameID, snap.SheetID, "cmd:rollback_import")
+	if err != nil {
+		log.Fatalf("%s✗ Failed to snapshot current items: %v%s", colorRed, err, colorReset)
+	}
+	fmt.Printf("%s📸 Saved current state as snapshot #%d%s\n", colorCyan, backup.ID, colorReset)
+
+	if err := store.RestoreSnapshot(snap); err != nil {
+		log.Fatalf("%s✗ Failed to restore snapshot #%d: %v%s", colorRed, id, err, colorReset)
+	}
+
+	if err := store.AddAuditEntry(&models.AuditEntry{
+		Actor:      "cmd:rollback_import",
+		Action:     "items.rollback",
+		TargetType: "snapshot",
+		TargetID:   fmt.Sprint(snap.ID),
+		GameID:     snap.GameID,
+		Details:    fmt.Sprintf("restored %d items from %s", snap.ItemCount, snap.Source),
+	}); err != nil {
+		log.Printf("%s⚠ Warning: failed to write audit entry: %v%s", colorYellow, err, colorReset)
+	}
+
+	fmt.Printf("%s✓ Restored %d items from snapshot #%d%s\n", colorGreen, snap.ItemCount, snap.ID, colorReset)
+}
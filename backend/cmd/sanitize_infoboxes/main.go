@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/sanitize"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// ANSI color codes
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+func main() {
+	dbPath := flag.String("db", "./tierforge.db", "SQLite database path")
+	dryRun := flag.Bool("dry-run", false, "Report items that would change without writing")
+	flag.Parse()
+
+	store, err := storage.New(*dbPath)
+	if err != nil {
+		log.Fatalf("%s✗ Failed to connect to database: %v%s", colorRed, err, colorReset)
+	}
+	defer store.Close()
+
+	games, err := store.GetGames()
+	if err != nil {
+		log.Fatalf("%s✗ Failed to read games: %v%s", colorRed, err, colorReset)
+	}
+
+	scanned := 0
+	changed := 0
+	for _, game := range games {
+		items, err := store.GetStoredItems(game.ID)
+		if err != nil {
+			log.Fatalf("%s✗ Failed to read items for %s: %v%s", colorRed, game.ID, err, colorReset)
+		}
+
+		if !*dryRun {
+			if _, err := store.SnapshotItems(game.ID, "", "cmd:sanitize_infoboxes"); err != nil {
+				log.Fatalf("%s✗ Failed to snapshot items for %s: %v%s", colorRed, game.ID, err, colorReset)
+			}
+		}
+
+		for _, item := range items {
+			scanned++
+			if item.Data == nil || !sanitize.ItemData(item.Data) {
+				continue
+			}
+			changed++
+			if *dryRun {
+				fmt.Printf("%s  ~ %s/%s (%s)%s\n", colorYellow, game.ID, item.ID, item.Name, colorReset)
+				continue
+			}
+			if err := store.UpdateItem(&item); err != nil {
+				log.Printf("%s✗ Failed to update %s: %v%s", colorRed, item.ID, err, colorReset)
+			}
+		}
+	}
+
+	fmt.Printf("%s📦 Scanned %d items%s\n", colorCyan, scanned, colorReset)
+	if *dryRun {
+		fmt.Printf("%s⚠ Dry run: %d items need sanitizing%s\n", colorYellow, changed, colorReset)
+		return
+	}
+	fmt.Printf("%s✓ Sanitized %d items%s\n", colorGreen, changed, colorReset)
+
+	if err := store.AddAuditEntry(&models.AuditEntry{
+		Actor:      "cmd:sanitize_infoboxes",
+		Action:     "items.sanitize",
+		TargetType: "catalog",
+		TargetID:   "*",
+		Details:    fmt.Sprintf("sanitized %d of %d items", changed, scanned),
+	}); err != nil {
+		log.Printf("%s⚠ Warning: failed to write audit entry: %v%s", colorYellow, err, colorReset)
+	}
+}
@@ -1,35 +1,93 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/meur/tierforge/internal/api"
+	"github.com/meur/tierforge/internal/buildinfo"
+	"github.com/meur/tierforge/internal/config"
+	"github.com/meur/tierforge/internal/email"
+	"github.com/meur/tierforge/internal/jobs"
+	_ "github.com/meur/tierforge/internal/plugins/games"
+	"github.com/meur/tierforge/internal/render"
 	"github.com/meur/tierforge/internal/storage"
+	"github.com/meur/tierforge/internal/tracing"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
 	// Parse flags
-	port := flag.String("port", getEnv("PORT", "8080"), "Server port")
-	dbPath := flag.String("db", getEnv("DB_PATH", "./tierforge.db"), "SQLite database path")
+	port := flag.String("port", cfg.Port, "Server port")
+	dbPath := flag.String("db", cfg.DBPath, "SQLite database path")
 	flag.Parse()
+	cfg.Port, cfg.DBPath = *port, *dbPath
 
 	// Initialize storage
-	store, err := storage.New(*dbPath)
+	store, err := storage.Open(*dbPath, storage.Options{
+		BusyTimeout:     time.Duration(cfg.DBBusyTimeoutMS) * time.Millisecond,
+		CacheSizeKB:     cfg.DBCacheSizeKB,
+		Synchronous:     cfg.DBSynchronous,
+		MaxOpenConns:    cfg.DBMaxOpenConns,
+		SerializeWrites: cfg.DBSerializeWrites,
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 	defer store.Close()
 
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if cfg.TracingEndpoint != "" {
+		shutdown, err := tracing.Setup(tracing.Config{
+			Endpoint:       cfg.TracingEndpoint,
+			Headers:        cfg.TracingHeaders,
+			ServiceName:    cfg.TracingServiceName,
+			ServiceVersion: buildinfo.Version,
+			SampleRatio:    cfg.TracingSampleRatio,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set up tracing: %v", err)
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdown(shutdownCtx); err != nil {
+				log.Printf("ERROR: Failed to export remaining spans: %v", err)
+			}
+		}()
+		log.Printf("🔭 Exporting traces to %s", cfg.TracingEndpoint)
+	}
+
+	mailer := email.New(cfg)
+
+	// Background jobs
+	prerender := render.NewWorker(store, cfg.PrerenderQueue, cfg.PrerenderWorkers)
+	prerender.Start(ctx)
+	scheduler := jobs.NewScheduler(store)
+	jobs.RegisterDefaults(scheduler, store, cfg, mailer, prerender)
+	if cfg.JobsEnabled {
+		scheduler.Start(ctx)
+	}
+
 	// Create server
-	s := api.New(store)
+	s := api.New(ctx, store, cfg, scheduler, mailer, prerender)
 
 	// Serve frontend static files (for production deployment)
 	workDir, _ := os.Getwd()
@@ -43,16 +101,21 @@ func main() {
 	h2s := &http2.Server{}
 	handler := h2c.NewHandler(s, h2s)
 
-	if err := http.ListenAndServe(":"+*port, handler); err != nil {
-		log.Fatalf("Server failed: %v", err)
-	}
-}
+	srv := &http.Server{Addr: ":" + *port, Handler: handler}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
 
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+	<-ctx.Done()
+	log.Printf("Shutting down...")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("ERROR: Shutdown: %v", err)
 	}
-	return fallback
+	s.Close()
 }
 
 // FileServer conveniently sets up a http.FileServer handler to serve
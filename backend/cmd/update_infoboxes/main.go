@@ -6,7 +6,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
 
+	"github.com/meur/tierforge/internal/infobox"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+	_ "github.com/meur/tierforge/internal/plugins/games"
+	"github.com/meur/tierforge/internal/sanitize"
 	"github.com/meur/tierforge/internal/storage"
 )
 
@@ -51,6 +57,12 @@ func main() {
 		log.Fatalf("%s✗ Failed to connect to database: %v%s", colorRed, err, colorReset)
 	}
 
+	snap, err := store.SnapshotItems("dos2", "skills", "cmd:update_infoboxes")
+	if err != nil {
+		log.Fatalf("%s✗ Failed to snapshot existing items: %v%s", colorRed, err, colorReset)
+	}
+	fmt.Printf("%s📸 Saved snapshot #%d of %d items (undo with cmd/rollback_import)%s\n", colorCyan, snap.ID, snap.ItemCount, colorReset)
+
 	// Update items
 	updated := 0
 	notFoundList := []string{}
@@ -65,12 +77,15 @@ func main() {
 
 		found := false
 		for _, item := range items {
-			if item.Name == spellName || item.NameRu == spellName {
+			if item.Name == spellName || item.NameRu == spellName || slices.Contains(item.Aliases, spellName) {
 				// Update item with icon and infobox_html
 				if item.Data == nil {
 					item.Data = make(map[string]interface{})
 				}
-				item.Data["infobox_html"] = info.InfoboxHTML
+				item.Data["infobox_html"] = sanitize.HTML(info.InfoboxHTML)
+				parsed := infobox.Parse(info.InfoboxHTML)
+				item.Data[infobox.DataKey] = parsed
+				plugins.Enrich(&item)
 				item.Data["wiki_url"] = info.URL
 				
 				if info.Icon != "" {
@@ -93,6 +108,26 @@ func main() {
 	}
 
 	fmt.Printf("%s✓ Updated: %d items%s\n", colorGreen, updated, colorReset)
+
+	// Kept for the missing metadata report, where curators can place them
+	unmatched := make([]models.UnmatchedInfobox, 0, len(notFoundList))
+	for _, name := range notFoundList {
+		unmatched = append(unmatched, models.UnmatchedInfobox{Name: name, URL: infoboxes[name].URL})
+	}
+	if err := store.SetUnmatchedInfoboxes("dos2", "skills", unmatched); err != nil {
+		log.Printf("%s⚠ Warning: failed to record unmatched infoboxes: %v%s", colorYellow, err, colorReset)
+	}
+
+	if err := store.AddAuditEntry(&models.AuditEntry{
+		Actor:      "cmd:update_infoboxes",
+		Action:     "items.update_infoboxes",
+		TargetType: "sheet",
+		TargetID:   "skills",
+		GameID:     "dos2",
+		Details:    fmt.Sprintf("updated %d items, %d not found", updated, len(notFoundList)),
+	}); err != nil {
+		log.Printf("%s⚠ Warning: failed to write audit entry: %v%s", colorYellow, err, colorReset)
+	}
 	
 	if len(notFoundList) > 0 {
 		fmt.Printf("%s⚠ Not found: %d items%s\n", colorYellow, len(notFoundList), colorReset)
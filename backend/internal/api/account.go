@@ -0,0 +1,282 @@
+package api
+
+import (
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/auth"
+	"github.com/meur/tierforge/internal/email"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// resetTokenLifetime is how long a password reset link stays valid
+const resetTokenLifetime = time.Hour
+
+// accountUser returns the signed-in user for endpoints that manage a stored
+// account, rejecting the bootstrap admin which has none
+func accountUser(w http.ResponseWriter, r *http.Request) *models.User {
+	user := currentUser(r)
+	if user == bootstrapAdmin {
+		respondError(w, http.StatusBadRequest, "The bootstrap admin has no account")
+		return nil
+	}
+	return user
+}
+
+// checkPassword verifies the current user's password, writing an error
+// response and returning false on mismatch
+func (s *Server) checkPassword(w http.ResponseWriter, user *models.User, password string) bool {
+	_, hash, err := s.store.GetUserByEmail(user.Email)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to verify password")
+		return false
+	}
+	if !auth.CheckPassword(hash, password) {
+		respondError(w, http.StatusForbidden, "Password is incorrect")
+		return false
+	}
+	return true
+}
+
+// handleForgotPassword emails a password reset link. It responds the same way
+// whether or not the address has an account.
+func (s *Server) handleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordForgotRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !email.Enabled(s.mailer) {
+		respondError(w, http.StatusServiceUnavailable, "Email is not configured")
+		return
+	}
+
+	user, _, err := s.storeFor(r).GetUserByEmail(req.Email)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+	if user != nil {
+		link, err := s.issueEmailToken(user.ID, storage.EmailTokenReset, user.Email, resetTokenLifetime)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to request password reset")
+			return
+		}
+		s.sendEmail(email.PasswordResetMessage(user.Email, user.DisplayName, link))
+	}
+	respondJSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// handleResetPassword sets a new password from a reset link and signs out
+// every session
+func (s *Server) handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetRequest
+	if err := decodeJSON(r, &req); err != nil || req.Token == "" {
+		respondError(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		respondError(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	// The token is only spent when the new password is saved
+	valid := false
+	err = s.storeFor(r).WithTx(r.Context(), func(tx *storage.Store) error {
+		userID, address, err := tx.ConsumeEmailToken(auth.HashToken(req.Token), storage.EmailTokenReset)
+		if err != nil || userID == "" {
+			return err
+		}
+		valid = true
+		if err := tx.SetPassword(userID, hash); err != nil {
+			return err
+		}
+		// Following the link proves the user controls the address
+		_, err = tx.MarkEmailVerified(userID, address)
+		return err
+	})
+	if err != nil {
+		respondWriteError(w, err, "Failed to reset password")
+		return
+	}
+	if !valid {
+		respondError(w, http.StatusBadRequest, "Reset link is invalid or expired")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "password_reset"})
+}
+
+// handleChangeEmail sends a confirmation link to a new email address. The
+// account keeps its current address until the link is followed.
+func (s *Server) handleChangeEmail(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var req models.EmailChangeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	address := strings.ToLower(strings.TrimSpace(req.Email))
+	if _, err := mail.ParseAddress(address); err != nil {
+		respondError(w, http.StatusBadRequest, "A valid email is required")
+		return
+	}
+	if address == user.Email {
+		respondError(w, http.StatusBadRequest, "This is already your email")
+		return
+	}
+	if !email.Enabled(s.mailer) {
+		respondError(w, http.StatusServiceUnavailable, "Email is not configured")
+		return
+	}
+	if !s.checkPassword(w, user, req.Password) {
+		return
+	}
+
+	existing, _, err := s.storeFor(r).GetUserByEmail(address)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+	if existing != nil {
+		respondError(w, http.StatusConflict, "An account with this email already exists")
+		return
+	}
+
+	link, err := s.issueEmailToken(user.ID, storage.EmailTokenEmailChange, address, verifyTokenLifetime)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+	s.sendEmail(email.EmailChangeMessage(address, user.DisplayName, link))
+
+	respondJSON(w, http.StatusAccepted, map[string]string{"status": "confirmation_sent"})
+}
+
+// handleConfirmEmailChange applies a pending email change from its
+// confirmation link
+func (s *Server) handleConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := decodeJSON(r, &req); err != nil || req.Token == "" {
+		respondError(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	userID, address, err := s.storeFor(r).ConsumeEmailToken(auth.HashToken(req.Token), storage.EmailTokenEmailChange)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+	if userID == "" {
+		respondError(w, http.StatusBadRequest, "Confirmation link is invalid or expired")
+		return
+	}
+	if err := s.storeFor(r).ChangeEmail(userID, address); err != nil {
+		if err == storage.ErrDuplicate {
+			respondError(w, http.StatusConflict, "An account with this email already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to change email")
+		return
+	}
+
+	user, err := s.storeFor(r).GetUser(userID)
+	if err != nil || user == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	respondJSON(w, http.StatusOK, user)
+}
+
+// handleDeleteAccount permanently deletes the current account. Authored tier
+// lists stay available without any link to the account.
+func (s *Server) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var req models.AccountDeleteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !s.checkPassword(w, user, req.Password) {
+		return
+	}
+
+	if err := s.storeFor(r).DeleteUser(user.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete account")
+		return
+	}
+	s.audit(r, "user.delete", "user", user.ID, "")
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleGetSessions lists the current user's active sessions
+func (s *Server) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+
+	sessions, err := s.storeFor(r).GetSessions(user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+	current := auth.HashToken(bearerToken(r))
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	respondJSON(w, http.StatusOK, sessions)
+}
+
+// handleRevokeSession signs out one of the current user's sessions
+func (s *Server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+
+	found, err := s.storeFor(r).DeleteUserSession(user.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// handleRevokeOtherSessions signs out every session except the current one
+func (s *Server) handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+
+	n, err := s.storeFor(r).DeleteOtherSessions(user.ID, auth.HashToken(bearerToken(r)))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int{"revoked": n})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/buildinfo"
+	"github.com/meur/tierforge/internal/jobs"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/render"
+)
+
+// handleGetJobs returns all background jobs with their last run
+func (s *Server) handleGetJobs(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.scheduler.Status()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch jobs")
+		return
+	}
+	respondJSON(w, http.StatusOK, statuses)
+}
+
+// handleRunJob triggers a background job immediately
+func (s *Server) handleRunJob(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	switch err := s.scheduler.RunNow(s.ctx, name); err {
+	case nil:
+		respondJSON(w, http.StatusAccepted, map[string]string{"status": "started"})
+	case jobs.ErrUnknownJob:
+		respondError(w, http.StatusNotFound, "Job not found")
+	case jobs.ErrJobRunning:
+		respondError(w, http.StatusConflict, "Job is already running")
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to start job")
+	}
+}
+
+// handleGetRetention reports the anonymous tier lists the retention job
+// would delete now, without deleting anything. ?days= previews another
+// retention period.
+func (s *Server) handleGetRetention(w http.ResponseWriter, r *http.Request) {
+	days := s.config.ListRetentionDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "days must be a positive number")
+			return
+		}
+		days = n
+	}
+	if days <= 0 {
+		respondError(w, http.StatusBadRequest, "Anonymous list retention is off; pass days to preview it")
+		return
+	}
+
+	report, err := s.storeFor(r).GetAbandonedTierLists(time.Now().AddDate(0, 0, -days), 50)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to find abandoned tier lists")
+		return
+	}
+	report.DryRun = true
+	respondJSON(w, http.StatusOK, report)
+}
+
+// handleGetStats reports table sizes, cache hit rates and runtime memory so
+// operators can watch growth without a shell on the host
+func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	tables, err := s.storeFor(r).TableCounts()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count rows")
+		return
+	}
+	pool := s.storeFor(r).PoolStats()
+	sheetHits, sheetMisses := s.storeFor(r).SheetCacheStats()
+	renderHits, renderMisses := render.CacheStats()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	respondJSON(w, http.StatusOK, models.AdminStats{
+		Build:         buildinfo.Get(),
+		UptimeSeconds: int64(time.Since(s.started).Seconds()),
+		Database: models.DatabaseStats{
+			FileBytes:       s.storeFor(r).FileSize(),
+			WALBytes:        s.storeFor(r).WALSize(),
+			Tables:          tables,
+			OpenConnections: pool.OpenConnections,
+			InUse:           pool.InUse,
+			WaitCount:       pool.WaitCount,
+			WaitMS:          pool.WaitDuration.Milliseconds(),
+		},
+		Caches: map[string]models.CacheStats{
+			"virtual_sheets":  models.NewCacheStats(sheetHits, sheetMisses),
+			"rendered_images": models.NewCacheStats(renderHits, renderMisses),
+		},
+		PrerenderQueue: s.prerender.Pending(),
+		Runtime: models.RuntimeStats{
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapSysBytes:   mem.HeapSys,
+			SysBytes:       mem.Sys,
+			NumGC:          mem.NumGC,
+			GCPauseTotalMS: float64(mem.PauseTotalNs) / 1e6,
+		},
+	})
+}
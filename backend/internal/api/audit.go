@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// auditActor identifies the current user in audit entries
+func auditActor(r *http.Request) string {
+	if user := currentUser(r); user != nil {
+		return user.ID
+	}
+	return "anonymous"
+}
+
+// audit records an action by the current user, logging rather than failing on error
+func (s *Server) audit(r *http.Request, action, targetType, targetID, details string) {
+	s.writeAudit(&models.AuditEntry{
+		Actor:      auditActor(r),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	})
+}
+
+// writeAuditFor records a game-scoped action by the current user
+func (s *Server) writeAuditFor(r *http.Request, action, targetType, targetID, gameID, details string) {
+	s.writeAudit(&models.AuditEntry{
+		Actor:      auditActor(r),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		GameID:     gameID,
+		Details:    details,
+	})
+}
+
+// auditChange records a catalog change with before/after snapshots. Either
+// snapshot may be nil for creations and deletions.
+func (s *Server) auditChange(r *http.Request, action, targetType, targetID, gameID string, before, after interface{}) {
+	entry := &models.AuditEntry{
+		Actor:      auditActor(r),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		GameID:     gameID,
+	}
+	if before != nil {
+		entry.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		entry.After, _ = json.Marshal(after)
+	}
+	s.writeAudit(entry)
+}
+
+func (s *Server) writeAudit(entry *models.AuditEntry) {
+	if err := s.store.AddAuditEntry(entry); err != nil {
+		log.Printf("ERROR: Failed to write audit entry %s %s/%s: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+	}
+}
+
+// handleGetAudit returns audit log entries filtered by query parameters
+func (s *Server) handleGetAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Actor:      q.Get("actor"),
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+		TargetID:   q.Get("target_id"),
+		GameID:     q.Get("game_id"),
+	}
+	if since := q.Get("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		filter.Since = t
+	}
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, err := s.storeFor(r).GetAuditEntries(filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch audit log")
+		return
+	}
+	respondJSON(w, http.StatusOK, entries)
+}
+
+// handleRevertAudit restores the "before" snapshot of a catalog change
+func (s *Server) handleRevertAudit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid audit entry id")
+		return
+	}
+
+	entry, err := s.storeFor(r).GetAuditEntry(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch audit entry")
+		return
+	}
+	if entry == nil {
+		respondError(w, http.StatusNotFound, "Audit entry not found")
+		return
+	}
+
+	switch entry.TargetType {
+	case "item":
+		s.revertItem(w, r, entry)
+	case "game":
+		s.revertGame(w, r, entry)
+	default:
+		respondError(w, http.StatusUnprocessableEntity, "Only item and game changes can be reverted")
+	}
+}
+
+func (s *Server) revertItem(w http.ResponseWriter, r *http.Request, entry *models.AuditEntry) {
+	current, err := s.storeFor(r).GetItem(entry.GameID, entry.TargetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+		return
+	}
+
+	if len(entry.Before) == 0 {
+		// The change created the item, so reverting removes it
+		if current == nil {
+			respondError(w, http.StatusConflict, "Item no longer exists")
+			return
+		}
+		if err := s.storeFor(r).DeleteItem(entry.GameID, entry.TargetID); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to revert item")
+			return
+		}
+		s.auditChange(r, "item.revert", "item", entry.TargetID, entry.GameID, current, nil)
+		respondJSON(w, http.StatusOK, map[string]string{"status": "reverted"})
+		return
+	}
+
+	var before models.Item
+	if err := json.Unmarshal(entry.Before, &before); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, "Audit snapshot is corrupted")
+		return
+	}
+	if current == nil {
+		err = s.storeFor(r).CreateItem(&before)
+	} else {
+		err = s.storeFor(r).UpdateItem(&before)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revert item")
+		return
+	}
+	s.auditChange(r, "item.revert", "item", entry.TargetID, entry.GameID, current, before)
+
+	respondJSON(w, http.StatusOK, before)
+}
+
+func (s *Server) revertGame(w http.ResponseWriter, r *http.Request, entry *models.AuditEntry) {
+	if len(entry.Before) == 0 {
+		respondError(w, http.StatusUnprocessableEntity, "Game creation cannot be reverted")
+		return
+	}
+
+	var before models.Game
+	if err := json.Unmarshal(entry.Before, &before); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, "Audit snapshot is corrupted")
+		return
+	}
+	current, err := s.storeFor(r).GetGame(before.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if err := s.storeFor(r).CreateGame(&before); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revert game")
+		return
+	}
+	s.auditChange(r, "game.revert", "game", before.ID, before.ID, current, before)
+
+	respondJSON(w, http.StatusOK, before)
+}
@@ -0,0 +1,237 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/auth"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// sessionLifetime is how long a login stays valid
+const sessionLifetime = 30 * 24 * time.Hour
+
+// minPasswordLength is the shortest accepted password
+const minPasswordLength = 8
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota
+	workspaceContextKey
+	deviceContextKey
+)
+
+// bootstrapAdmin is the identity used for requests carrying ADMIN_TOKEN
+var bootstrapAdmin = &models.User{ID: "admin", DisplayName: "Admin", Role: models.RoleAdmin}
+
+// currentUser returns the authenticated user of the request, or nil
+func currentUser(r *http.Request) *models.User {
+	u, _ := r.Context().Value(userContextKey).(*models.User)
+	return u
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+}
+
+// authenticate resolves the bearer token to a user and stores it in the
+// request context. Requests without a valid token continue anonymously.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var user *models.User
+		if s.config.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.config.AdminToken)) == 1 {
+			user = bootstrapAdmin
+		} else {
+			u, err := s.storeFor(r).GetSessionUser(auth.HashToken(token))
+			if err != nil {
+				log.Printf("ERROR: Failed to resolve session: %v", err)
+			}
+			user = u
+		}
+		if user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireRole rejects requests from users without one of the given roles
+func (s *Server) requireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := currentUser(r)
+			if user == nil {
+				respondError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+			if !user.HasRole(roles...) {
+				respondError(w, http.StatusForbidden, "Insufficient permissions")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireAuth rejects anonymous requests
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return s.requireRole(models.RoleAdmin, models.RoleCurator, models.RoleUser)(next)
+}
+
+// canEditTierList reports whether the request may modify a tier list.
+// Anonymous lists stay editable by anyone holding their ID.
+func canEditTierList(r *http.Request, tl *models.TierList) bool {
+	if tl.AuthorID == nil {
+		return true
+	}
+	user := currentUser(r)
+	if user == nil {
+		return false
+	}
+	return user.ID == *tl.AuthorID || user.Role == models.RoleAdmin ||
+		(tl.WorkspaceID != "" && user.CanManageWorkspace(tl.WorkspaceID))
+}
+
+// isTierListOwner reports whether the request comes from the signed-in author
+// of a tier list or an admin. Anonymous lists have no owner.
+func isTierListOwner(r *http.Request, tl *models.TierList) bool {
+	user := currentUser(r)
+	if user == nil {
+		return false
+	}
+	return user.Role == models.RoleAdmin || (tl.AuthorID != nil && user.ID == *tl.AuthorID)
+}
+
+// hiddenFrom reports whether a tier list a moderator hid must be withheld
+// from the request. Its owner and admins still see it, so they can tell why
+// it disappeared from shares and searches.
+func hiddenFrom(r *http.Request, tl *models.TierList) bool {
+	return tl.Hidden && !isTierListOwner(r, tl)
+}
+
+// canViewTierList reports whether the request may read a tier list. Lists a
+// moderator hid are withheld from all but their owner, and drafts and
+// private lists from all but those who may edit them.
+func canViewTierList(r *http.Request, tl *models.TierList) bool {
+	if hiddenFrom(r, tl) {
+		return false
+	}
+	if tl.Status == models.TierListDraft || tl.Visibility == models.VisibilityPrivate {
+		return canEditTierList(r, tl)
+	}
+	return true
+}
+
+// handleRegister creates an account and signs it in
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		respondError(w, http.StatusBadRequest, "A valid email is required")
+		return
+	}
+	if len(req.Password) < minPasswordLength {
+		respondError(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+	displayName, err := s.cleanText("display_name", req.DisplayName, maxTierListNameLength)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create account")
+		return
+	}
+
+	user := &models.User{Email: req.Email, DisplayName: displayName}
+	if err := s.storeFor(r).CreateUser(user, hash); err != nil {
+		if err == storage.ErrDuplicate {
+			respondError(w, http.StatusConflict, "An account with this email already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to create account")
+		return
+	}
+
+	s.sendVerification(user)
+	s.startSession(w, r, user, http.StatusCreated)
+}
+
+// handleLogin exchanges credentials for a session token
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, hash, err := s.storeFor(r).GetUserByEmail(req.Email)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to sign in")
+		return
+	}
+	if user == nil || !auth.CheckPassword(hash, req.Password) {
+		respondError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+
+	s.startSession(w, r, user, http.StatusOK)
+}
+
+// startSession issues a session token for the user and writes the login response
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, user *models.User, status int) {
+	token, err := auth.NewToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	expiresAt := time.Now().Add(sessionLifetime)
+	if err := s.storeFor(r).CreateSession(auth.HashToken(token), user.ID, r.UserAgent(), expiresAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	respondJSON(w, status, models.LoginResponse{Token: token, ExpiresAt: expiresAt, User: user})
+}
+
+// handleLogout revokes the current session
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if err := s.storeFor(r).DeleteSession(auth.HashToken(bearerToken(r))); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to sign out")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "signed_out"})
+}
+
+// handleGetMe returns the authenticated user
+func (s *Server) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, currentUser(r))
+}
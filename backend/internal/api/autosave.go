@@ -0,0 +1,251 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// autosaveDebounce is how long a tier list must be idle before its latest
+// autosave is written to the database
+const autosaveDebounce = 2 * time.Second
+
+// autosaver coalesces rapid autosaves in memory and persists only the most
+// recent state once a tier list stops changing
+type autosaver struct {
+	store   *storage.Store
+	mu      sync.Mutex
+	pending map[string]*pendingAutosave
+}
+
+type pendingAutosave struct {
+	state models.TierListAutosave
+	timer *time.Timer
+}
+
+func newAutosaver(store *storage.Store) *autosaver {
+	return &autosaver{store: store, pending: make(map[string]*pendingAutosave)}
+}
+
+// Save queues state for a tier list, restarting its debounce timer
+func (a *autosaver) Save(id string, state models.TierListAutosave) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if p, ok := a.pending[id]; ok {
+		p.state = state
+		p.timer.Reset(autosaveDebounce)
+		return
+	}
+	a.pending[id] = &pendingAutosave{
+		state: state,
+		timer: time.AfterFunc(autosaveDebounce, func() { a.flush(id) }),
+	}
+}
+
+// Get returns the queued state of a tier list that has not been written yet
+func (a *autosaver) Get(id string) (*models.TierListAutosave, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	p, ok := a.pending[id]
+	if !ok {
+		return nil, false
+	}
+	state := p.state
+	return &state, true
+}
+
+// Discard drops any queued state of a tier list
+func (a *autosaver) Discard(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if p, ok := a.pending[id]; ok {
+		p.timer.Stop()
+		delete(a.pending, id)
+	}
+}
+
+// FlushAll writes every queued autosave immediately
+func (a *autosaver) FlushAll() {
+	a.mu.Lock()
+	ids := make([]string, 0, len(a.pending))
+	for id, p := range a.pending {
+		p.timer.Stop()
+		ids = append(ids, id)
+	}
+	a.mu.Unlock()
+
+	for _, id := range ids {
+		a.flush(id)
+	}
+}
+
+func (a *autosaver) flush(id string) {
+	a.mu.Lock()
+	p, ok := a.pending[id]
+	delete(a.pending, id)
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := a.store.SaveAutosave(id, &p.state); err != nil {
+		log.Printf("ERROR: Failed to persist autosave for %s: %v", id, err)
+	}
+}
+
+// editableTierList loads a tier list for an autosave request, writing the
+// error response itself when the list is missing or not editable
+func (s *Server) editableTierList(w http.ResponseWriter, r *http.Request) (*models.TierList, bool) {
+	id := chi.URLParam(r, "id")
+
+	tierList, err := s.storeFor(r).GetTierList(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return nil, false
+	}
+	if tierList == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return nil, false
+	}
+	if !canEditTierList(r, tierList) {
+		respondError(w, http.StatusForbidden, "You cannot edit this tier list")
+		return nil, false
+	}
+	return tierList, true
+}
+
+// handleAutosaveTierList records in-progress tier state without touching the
+// saved tier list or its updated_at
+func (s *Server) handleAutosaveTierList(w http.ResponseWriter, r *http.Request) {
+	tierList, ok := s.editableTierList(w, r)
+	if !ok || tierListLocked(w, tierList) {
+		return
+	}
+	if tierList.Status == models.TierListArchived {
+		respondError(w, http.StatusConflict, "Archived tier lists are read-only")
+		return
+	}
+
+	var state models.TierListAutosave
+	if err := decodeJSON(r, &state); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if state.Tiers != nil && pollLocksTiers(w, tierList) {
+		return
+	}
+	if state.Name == nil && state.Tiers == nil {
+		respondError(w, http.StatusBadRequest, "name or tiers is required")
+		return
+	}
+	state.SavedAt = time.Now()
+
+	s.autosaves.Save(tierList.ID, state)
+	respondJSON(w, http.StatusAccepted, map[string]time.Time{"saved_at": state.SavedAt})
+}
+
+// currentAutosave returns the newest autosave of a tier list, queued or stored
+func (s *Server) currentAutosave(id string) (*models.TierListAutosave, error) {
+	if state, ok := s.autosaves.Get(id); ok {
+		return state, nil
+	}
+	return s.store.GetAutosave(id)
+}
+
+// handleGetAutosave returns the unsaved state of a tier list, if any
+func (s *Server) handleGetAutosave(w http.ResponseWriter, r *http.Request) {
+	tierList, ok := s.editableTierList(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := s.currentAutosave(tierList.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch autosave")
+		return
+	}
+	if state == nil {
+		respondError(w, http.StatusNotFound, "No autosave for this tier list")
+		return
+	}
+	respondJSON(w, http.StatusOK, state)
+}
+
+// handleRecoverAutosave applies the unsaved state to the tier list
+func (s *Server) handleRecoverAutosave(w http.ResponseWriter, r *http.Request) {
+	tierList, ok := s.editableTierList(w, r)
+	if !ok || tierListLocked(w, tierList) {
+		return
+	}
+	if tierList.Status == models.TierListArchived {
+		respondError(w, http.StatusConflict, "Archived tier lists are read-only")
+		return
+	}
+
+	state, err := s.currentAutosave(tierList.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch autosave")
+		return
+	}
+	if state == nil {
+		respondError(w, http.StatusNotFound, "No autosave for this tier list")
+		return
+	}
+	if state.Tiers != nil && pollLocksTiers(w, tierList) {
+		return
+	}
+
+	update := models.TierListUpdate{Tiers: state.Tiers}
+	if state.Name != nil {
+		name, err := s.cleanText("name", *state.Name, maxTierListNameLength)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		update.Name = &name
+	}
+	var warnings []models.ColorIssue
+	if update.Tiers != nil {
+		result := *tierList
+		result.Tiers = update.Tiers
+		var ok bool
+		if warnings, ok = s.checkEditedTiers(w, &result); !ok {
+			return
+		}
+	}
+
+	s.autosaves.Discard(tierList.ID)
+	if err := s.updateTierList(r.Context(), tierList.ID, tierList.Tiers, &update); err != nil {
+		respondWriteError(w, err, "Failed to update tier list")
+		return
+	}
+
+	updated, _ := s.storeFor(r).GetTierList(tierList.ID)
+	if updated != nil {
+		updated.ColorWarnings = warnings
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// handleDiscardAutosave throws away the unsaved state of a tier list
+func (s *Server) handleDiscardAutosave(w http.ResponseWriter, r *http.Request) {
+	tierList, ok := s.editableTierList(w, r)
+	if !ok {
+		return
+	}
+
+	s.autosaves.Discard(tierList.ID)
+	if err := s.storeFor(r).ClearAutosave(tierList.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to discard autosave")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "discarded"})
+}
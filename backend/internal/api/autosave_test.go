@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestRecoverAutosaveValidatesTiers(t *testing.T) {
+	s, _ := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	points := 2e6
+
+	tests := []struct {
+		name  string
+		tiers []models.Tier
+		code  int
+	}{
+		{"within capacity", []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f", Max: 2, Items: []string{"a", "b"}}}, http.StatusOK},
+		{"over capacity", []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f", Max: 1, Items: []string{"a", "b"}}}, http.StatusUnprocessableEntity},
+		{"negative capacity", []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f", Max: -1}}, http.StatusBadRequest},
+		{"points out of range", []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f", Points: &points}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+				"game_id": "g", "sheet_id": "main", "name": "Draft",
+				"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f", Max: 1}},
+			}, nil)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create list: %d %s", w.Code, w.Body)
+			}
+			var tl models.TierList
+			decodeBody(t, w, &tl)
+
+			w = serve(s, "POST", "/api/tierlists/"+tl.ID+"/autosave", owner, models.TierListAutosave{Tiers: tt.tiers}, nil)
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("autosave: %d %s", w.Code, w.Body)
+			}
+			w = serve(s, "POST", "/api/tierlists/"+tl.ID+"/autosave/recover", owner, nil, nil)
+			if w.Code != tt.code {
+				t.Fatalf("recover: %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+
+			w = serve(s, "GET", "/api/tierlists/"+tl.ID, owner, nil, nil)
+			var stored models.TierList
+			decodeBody(t, w, &stored)
+			if recovered := len(stored.Tiers[0].Items) > 0 || stored.Tiers[0].Max != 1; recovered != (tt.code == http.StatusOK) {
+				t.Errorf("stored tiers %+v after a %d recovery", stored.Tiers, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// handleCreateBracket seeds a single-elimination bracket for a sheet
+func (s *Server) handleCreateBracket(w http.ResponseWriter, r *http.Request) {
+	var req models.BracketCreate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.GameID == "" || req.SheetID == "" {
+		respondError(w, http.StatusBadRequest, "game_id and sheet_id are required")
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(req.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusBadRequest, "Invalid game_id")
+		return
+	}
+	if req.GameVersion == "" {
+		req.GameVersion = game.CurrentVersion()
+	} else if !game.HasVersion(req.GameVersion) {
+		respondError(w, http.StatusBadRequest, "Invalid game_version")
+		return
+	}
+
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: req.GameID, SheetID: req.SheetID, Version: req.GameVersion})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+
+	var seeds []string
+	if len(req.ItemIDs) > 0 {
+		inSheet := make(map[string]bool, len(items))
+		for _, item := range items {
+			inSheet[item.ID] = true
+		}
+		seen := make(map[string]bool, len(req.ItemIDs))
+		for _, id := range req.ItemIDs {
+			if !inSheet[id] {
+				respondError(w, http.StatusBadRequest, "Unknown item in item_ids: "+id)
+				return
+			}
+			if !seen[id] {
+				seen[id] = true
+				seeds = append(seeds, id)
+			}
+		}
+	} else {
+		if req.Seed == 0 {
+			req.Seed = rand.Int63n(math.MaxInt32) + 1
+		} else if req.Seed < 0 || req.Seed > models.MaxPoolSeed {
+			respondError(w, http.StatusBadRequest, "seed must be between 1 and 2^53-1")
+			return
+		}
+		ranking.OrderPool(items, models.PoolRandom, req.Seed)
+		for _, item := range items {
+			seeds = append(seeds, item.ID)
+		}
+	}
+	if len(seeds) < 2 {
+		respondError(w, http.StatusBadRequest, "A bracket needs at least two items")
+		return
+	}
+	if len(seeds) > models.MaxBracketItems {
+		respondError(w, http.StatusBadRequest, "A bracket can seed at most 256 items; pick them with item_ids")
+		return
+	}
+
+	bracket := &models.Bracket{
+		GameID:      req.GameID,
+		SheetID:     req.SheetID,
+		GameVersion: req.GameVersion,
+		Seeds:       seeds,
+	}
+	if user := currentUser(r); user != nil {
+		bracket.AuthorID = &user.ID
+	}
+	bracket.Rounds, bracket.Matches = ranking.SeedBracket(seeds)
+	if err := s.storeFor(r).CreateBracket(bracket); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create bracket")
+		return
+	}
+	respondJSON(w, http.StatusCreated, bracket)
+}
+
+// bracket loads the bracket in the URL, writing the error response itself
+// when it is missing or belongs to another user
+func (s *Server) bracket(w http.ResponseWriter, r *http.Request) (*models.Bracket, bool) {
+	bracket, err := s.storeFor(r).GetBracket(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch bracket")
+		return nil, false
+	}
+	if bracket == nil {
+		respondError(w, http.StatusNotFound, "Bracket not found")
+		return nil, false
+	}
+	if bracket.AuthorID != nil {
+		user := currentUser(r)
+		if user == nil || user.ID != *bracket.AuthorID {
+			respondError(w, http.StatusForbidden, "This bracket belongs to another user")
+			return nil, false
+		}
+	}
+	return bracket, true
+}
+
+// respondBracket writes a bracket with the standings of the items that are out
+func respondBracket(w http.ResponseWriter, status int, bracket *models.Bracket) {
+	respondJSON(w, status, map[string]interface{}{
+		"bracket":   bracket,
+		"standings": ranking.BracketStandings(bracket),
+	})
+}
+
+// handleGetBracket returns a bracket with its matches and standings
+func (s *Server) handleGetBracket(w http.ResponseWriter, r *http.Request) {
+	bracket, ok := s.bracket(w, r)
+	if !ok {
+		return
+	}
+	respondBracket(w, http.StatusOK, bracket)
+}
+
+// handleGetBracketMatch serves the earliest match that still needs a winner
+func (s *Server) handleGetBracketMatch(w http.ResponseWriter, r *http.Request) {
+	bracket, ok := s.bracket(w, r)
+	if !ok {
+		return
+	}
+
+	for _, m := range bracket.Matches {
+		if !m.Ready() {
+			continue
+		}
+		a, err := s.storeFor(r).GetItem(bracket.GameID, *m.ItemA)
+		if err != nil || a == nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+			return
+		}
+		b, err := s.storeFor(r).GetItem(bracket.GameID, *m.ItemB)
+		if err != nil || b == nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+			return
+		}
+		respondJSON(w, http.StatusOK, models.BracketPairing{Match: m, A: *a, B: *b})
+		return
+	}
+	respondError(w, http.StatusConflict, "The bracket is finished")
+}
+
+// handlePostBracketResult records the winner of a match and advances it
+func (s *Server) handlePostBracketResult(w http.ResponseWriter, r *http.Request) {
+	bracket, ok := s.bracket(w, r)
+	if !ok {
+		return
+	}
+
+	var result models.BracketResult
+	if err := decodeJSON(r, &result); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var match *models.BracketMatch
+	for i := range bracket.Matches {
+		if bracket.Matches[i].ID == result.MatchID {
+			match = &bracket.Matches[i]
+			break
+		}
+	}
+	switch {
+	case match == nil:
+		respondError(w, http.StatusBadRequest, "Unknown match_id")
+		return
+	case match.WinnerID != nil:
+		respondError(w, http.StatusConflict, "This match is already decided")
+		return
+	case !match.Ready():
+		respondError(w, http.StatusConflict, "This match is waiting on an earlier round")
+		return
+	case !match.Has(result.WinnerID):
+		respondError(w, http.StatusBadRequest, "winner_id must be one of the match's items")
+		return
+	}
+
+	var next *models.BracketMatch
+	round, slot, sideA := ranking.NextBracketMatch(match.Round, match.Slot)
+	for i := range bracket.Matches {
+		if bracket.Matches[i].Round == round && bracket.Matches[i].Slot == slot {
+			next = &bracket.Matches[i]
+			break
+		}
+	}
+
+	err := s.storeFor(r).SetBracketWinner(bracket, match, result.WinnerID, next, sideA)
+	if errors.Is(err, storage.ErrMatchDecided) {
+		respondError(w, http.StatusConflict, "This match is already decided")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record result")
+		return
+	}
+
+	bracket, err = s.storeFor(r).GetBracket(bracket.ID)
+	if err != nil || bracket == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch bracket")
+		return
+	}
+	respondBracket(w, http.StatusCreated, bracket)
+}
+
+// handleCreateBracketTierList turns a finished bracket into a tier list using
+// the game's default tiers, placing items by how many rounds they survived
+func (s *Server) handleCreateBracketTierList(w http.ResponseWriter, r *http.Request) {
+	bracket, ok := s.bracket(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	name, err := s.cleanText("name", req.Name, maxTierListNameLength)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if bracket.Champion == nil {
+		respondError(w, http.StatusConflict, "Finish the bracket first")
+		return
+	}
+	game, err := s.storeFor(r).GetGame(bracket.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+
+	defaults := game.TierTemplate(bracket.SheetID)
+	template := make([]models.Tier, 0, len(defaults))
+	for _, t := range defaults {
+		template = append(template, models.Tier{ID: t.ID, Name: t.Name, Color: t.Color, Order: t.Order, Points: t.Points})
+	}
+
+	create := models.TierListCreate{
+		GameID:        bracket.GameID,
+		SheetID:       bracket.SheetID,
+		Name:          name,
+		Tiers:         ranking.Bucket(ranking.BracketScores(bracket), template),
+		GameVersion:   bracket.GameVersion,
+		Status:        models.TierListPublished,
+		AuthorID:      bracket.AuthorID,
+		CreatorIP:     clientIP(r),
+		CreatorDevice: deviceBanHash(r),
+	}
+	var tierList *models.TierList
+	err = s.storeFor(r).WithTx(r.Context(), func(tx *storage.Store) error {
+		var err error
+		if tierList, err = tx.CreateTierList(&create); err != nil {
+			return err
+		}
+		if err := tx.SetBracketTierList(bracket.ID, tierList.ID); err != nil {
+			return err
+		}
+		s.recordTierListCreated(tx, r, tierList, "bracket")
+		return nil
+	})
+	if err != nil {
+		respondWriteError(w, err, "Failed to create tier list")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, tierList)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/interchange"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxBundleSize caps an uploaded game bundle, in bytes
+const maxBundleSize = 64 << 20
+
+// errInvalidBundle wraps bundle problems the client can fix
+var errInvalidBundle = errors.New("invalid bundle")
+
+// handleExportGameBundle downloads a game with its catalog as a bundle
+func (s *Server) handleExportGameBundle(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	bundle, err := s.storeFor(r).ExportGameBundle(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to export game")
+		return
+	}
+	if bundle == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+
+	s.recordEvent(r, models.EventExportUsed, gameID, map[string]interface{}{"format": "bundle"})
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.tierforge.json"`, gameID))
+	respondJSON(w, http.StatusOK, bundle)
+}
+
+// installBundle validates a bundle and imports it, snapshotting the existing
+// catalog first so the import can be rolled back
+func (s *Server) installBundle(r *http.Request, bundle *models.GameBundle, replace bool, source string) (*models.BundleImportResult, error) {
+	if err := bundle.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	if err := validateGameTheme(bundle.Game.Theme); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	if err := validateSpoilerPolicy(bundle.Game.Spoilers); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	if err := validateSheetFilters(bundle.Game.Sheets); err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	schema, err := models.ParseItemSchema(bundle.Game.ItemSchema)
+	if err == nil {
+		err = schema.Check(bundle.Game.Sheets)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidBundle, err)
+	}
+	for i := range bundle.Items {
+		tags, err := cleanItemTags(bundle.Items[i].Tags)
+		if err != nil {
+			return nil, fmt.Errorf("%w: item %q: %v", errInvalidBundle, bundle.Items[i].ID, err)
+		}
+		bundle.Items[i].Tags = tags
+		if err := schema.ValidateData(bundle.Items[i].SheetID, bundle.Items[i].Data); err != nil {
+			return nil, fmt.Errorf("%w: item %q: %v", errInvalidBundle, bundle.Items[i].ID, err)
+		}
+	}
+
+	result := &models.BundleImportResult{
+		GameID:    bundle.Game.ID,
+		Items:     len(bundle.Items),
+		Relations: len(bundle.Relations),
+	}
+	existing, err := s.storeFor(r).GetGame(bundle.Game.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		result.Created = true
+	} else {
+		snap, err := s.storeFor(r).SnapshotItems(bundle.Game.ID, "", source)
+		if err != nil {
+			return nil, err
+		}
+		result.SnapshotID = snap.ID
+	}
+
+	if err := s.storeFor(r).ImportGameBundle(bundle, replace); err != nil {
+		return nil, err
+	}
+	s.auditChange(r, "game.import", "game", bundle.Game.ID, bundle.Game.ID, existing, bundle.Game)
+	s.rebuildSprites(bundle.Game.ID)
+	return result, nil
+}
+
+// handleImportGameBundle installs an uploaded game bundle. ?replace=true
+// removes catalog items missing from the bundle.
+func (s *Server) handleImportGameBundle(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBundleSize))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid bundle JSON")
+		return
+	}
+	bundle, err := interchange.DecodeGameBundle(data)
+	if errors.Is(err, interchange.ErrInvalidJSON) {
+		respondError(w, http.StatusBadRequest, "Invalid bundle JSON")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	result, err := s.installBundle(r, bundle, r.URL.Query().Get("replace") == "true", "api:bundle")
+	if errors.Is(err, errInvalidBundle) {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if errors.Is(err, storage.ErrItemIDConflict) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to import bundle")
+		return
+	}
+	respondJSON(w, http.StatusOK, result)
+}
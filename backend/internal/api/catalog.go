@@ -0,0 +1,356 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/itemfilter"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+	"github.com/meur/tierforge/internal/slug"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// requireGameAccess rejects users who may not manage the game in the URL
+func (s *Server) requireGameAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := currentUser(r)
+		if user == nil || !user.CanManageGame(chi.URLParam(r, "gameID")) {
+			respondError(w, http.StatusForbidden, "You cannot manage this game")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validateItemData checks an item's data against its sheet's schema
+func validateItemData(game *models.Game, item *models.Item) error {
+	schema, err := models.ParseItemSchema(game.ItemSchema)
+	if err != nil {
+		return err
+	}
+	return schema.ValidateData(item.SheetID, item.Data)
+}
+
+// validateItemSchema checks a game's item schema against its sheets
+func validateItemSchema(game *models.Game) error {
+	schema, err := models.ParseItemSchema(game.ItemSchema)
+	if err != nil {
+		return err
+	}
+	return schema.Check(game.Sheets)
+}
+
+// validateSheetFilters checks that virtual sheets have a valid item filter
+func validateSheetFilters(sheets []models.SheetConfig) error {
+	for _, sheet := range sheets {
+		if !sheet.Virtual {
+			continue
+		}
+		if _, err := itemfilter.Parse(sheet.ItemFilter); err != nil {
+			return fmt.Errorf("sheet %q: invalid item_filter: %v", sheet.ID, err)
+		}
+	}
+	return nil
+}
+
+// maxItemTagLength limits curator-defined item tags, in characters
+const maxItemTagLength = 32
+
+// cleanItemTags trims item tags and drops case-insensitive duplicates
+func cleanItemTags(tags []string) ([]string, error) {
+	cleaned := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		if len([]rune(tag)) > maxItemTagLength || strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("tag %q must be at most %d characters and contain no commas", tag, maxItemTagLength)
+		}
+		seen[key] = true
+		cleaned = append(cleaned, tag)
+	}
+	return cleaned, nil
+}
+
+// maxItemAliases caps the aliases of one item
+const maxItemAliases = 20
+
+// cleanItemAliases trims item aliases and drops case-insensitive duplicates
+// and aliases repeating the item's own names
+func cleanItemAliases(item *models.Item, aliases []string) ([]string, error) {
+	cleaned := make([]string, 0, len(aliases))
+	seen := map[string]bool{strings.ToLower(item.Name): true}
+	if item.NameRu != "" {
+		seen[strings.ToLower(item.NameRu)] = true
+	}
+	for _, alias := range aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		if len([]rune(alias)) > maxItemNameLength {
+			return nil, fmt.Errorf("alias %q must be at most %d characters", alias, maxItemNameLength)
+		}
+		seen[key] = true
+		cleaned = append(cleaned, alias)
+	}
+	if len(cleaned) > maxItemAliases {
+		return nil, fmt.Errorf("an item can have at most %d aliases", maxItemAliases)
+	}
+	return cleaned, nil
+}
+
+// handlePutGame creates or replaces a game definition
+func (s *Server) handlePutGame(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	var game models.Game
+	if err := decodeJSON(r, &game); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	game.ID = gameID
+	if game.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if err := validateGameTheme(game.Theme); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateSpoilerPolicy(game.Spoilers); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateSheetFilters(game.Sheets); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := validateItemSchema(&game); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	// Ownership only changes by creating the game inside a workspace
+	switch {
+	case currentWorkspace(r) != nil:
+		game.WorkspaceID = currentWorkspace(r).ID
+	case before != nil:
+		game.WorkspaceID = before.WorkspaceID
+	default:
+		game.WorkspaceID = ""
+	}
+
+	if err := s.storeFor(r).CreateGame(&game); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save game")
+		return
+	}
+
+	saved, _ := s.storeFor(r).GetGame(gameID)
+	if before == nil {
+		s.auditChange(r, "game.create", "game", gameID, gameID, nil, saved)
+	} else {
+		s.auditChange(r, "game.update", "game", gameID, gameID, before, saved)
+	}
+	respondJSON(w, http.StatusOK, saved)
+}
+
+// handleCreateItem adds an item to a game's catalog. Without an id, one is
+// derived from the game, sheet, and name.
+func (s *Server) handleCreateItem(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	var item models.Item
+	if err := decodeJSON(r, &item); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	item.GameID = gameID
+	if item.SheetID == "" || item.Name == "" {
+		respondError(w, http.StatusBadRequest, "sheet_id and name are required")
+		return
+	}
+	if item.ID == "" {
+		item.ID = slug.ItemID(gameID, item.SheetID, item.Name)
+	}
+	if item.ID == "" {
+		respondError(w, http.StatusBadRequest, "id is required when the name has no letters or digits")
+		return
+	}
+	tags, err := cleanItemTags(item.Tags)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	item.Tags = tags
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	if item.GameVersion != "" && !game.HasVersion(item.GameVersion) {
+		respondError(w, http.StatusBadRequest, "Invalid game_version")
+		return
+	}
+	plugins.Enrich(&item)
+	if err := validateItemData(game, &item); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.storeFor(r).CreateItem(&item); err != nil {
+		if err == storage.ErrDuplicate {
+			respondError(w, http.StatusConflict, "An item with this id already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to create item")
+		return
+	}
+	s.auditChange(r, "item.create", "item", item.ID, gameID, nil, item)
+
+	respondJSON(w, http.StatusCreated, item)
+}
+
+// handleUpdateItem replaces an existing catalog item
+func (s *Server) handleUpdateItem(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	itemID := chi.URLParam(r, "itemID")
+
+	existing, err := s.storeFor(r).GetItem(gameID, itemID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+	// The item may have been found by an ID it was renamed from
+	itemID = existing.ID
+
+	var item models.Item
+	if err := decodeJSON(r, &item); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	item.ID = itemID
+	item.GameID = gameID
+	if item.SheetID == "" || item.Name == "" {
+		respondError(w, http.StatusBadRequest, "sheet_id and name are required")
+		return
+	}
+	tags, err := cleanItemTags(item.Tags)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	item.Tags = tags
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if item.GameVersion != "" && !game.HasVersion(item.GameVersion) {
+		respondError(w, http.StatusBadRequest, "Invalid game_version")
+		return
+	}
+	plugins.Enrich(&item)
+	if err := validateItemData(game, &item); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.storeFor(r).UpdateItem(&item); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update item")
+		return
+	}
+	s.auditChange(r, "item.update", "item", itemID, gameID, existing, item)
+
+	respondJSON(w, http.StatusOK, item)
+}
+
+// handleDeleteItem removes an item from a game's catalog
+func (s *Server) handleDeleteItem(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	itemID := chi.URLParam(r, "itemID")
+
+	existing, err := s.storeFor(r).GetItem(gameID, itemID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+	// The item may have been found by an ID it was renamed from
+	itemID = existing.ID
+
+	if err := s.storeFor(r).DeleteItem(gameID, itemID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete item")
+		return
+	}
+	s.auditChange(r, "item.delete", "item", itemID, gameID, existing, nil)
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handlePutItemAliases replaces the nicknames an item is searched and
+// imported by
+func (s *Server) handlePutItemAliases(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	existing, err := s.storeFor(r).GetItem(gameID, chi.URLParam(r, "itemID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+
+	var req struct {
+		Aliases []string `json:"aliases"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	aliases, err := cleanItemAliases(existing, req.Aliases)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.storeFor(r).SetItemAliases(gameID, existing.ID, aliases); err != nil {
+		respondWriteError(w, err, "Failed to update aliases")
+		return
+	}
+	item, err := s.storeFor(r).GetItem(gameID, existing.ID)
+	if err != nil || item == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+		return
+	}
+	s.auditChange(r, "item.aliases", "item", item.ID, gameID, existing, item)
+
+	respondJSON(w, http.StatusOK, item)
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/meur/tierforge/internal/challenge"
+)
+
+// challengeHeader carries the response to a challenge
+const challengeHeader = "X-Challenge-Response"
+
+// requireChallenge makes anonymous requests pass the configured challenge.
+// Signed-in users are let through.
+func (s *Server) requireChallenge(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.challenge == nil || currentUser(r) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		err := s.challenge.Verify(r.Context(), r.Header.Get(challengeHeader), clientIP(r))
+		if errors.Is(err, challenge.ErrFailed) {
+			respondError(w, http.StatusForbidden, "Complete the challenge from /api/challenge and send the response in "+challengeHeader)
+			return
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to verify challenge: %v", err)
+			respondError(w, http.StatusServiceUnavailable, "Challenge verification is unavailable")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleGetChallenge returns a challenge for an anonymous write, or mode
+// "off" when none is needed
+func (s *Server) handleGetChallenge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if s.challenge == nil {
+		respondJSON(w, http.StatusOK, challenge.Challenge{Mode: challenge.ModeOff})
+		return
+	}
+	c, err := s.challenge.Challenge()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create challenge")
+		return
+	}
+	respondJSON(w, http.StatusOK, c)
+}
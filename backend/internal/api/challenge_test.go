@@ -0,0 +1,79 @@
+package api
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/meur/tierforge/internal/challenge"
+	"github.com/meur/tierforge/internal/config"
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestAnonymousWritesNeedAChallenge(t *testing.T) {
+	s, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.ChallengeMode = challenge.ModePoW
+		cfg.ChallengeDifficulty = 4
+	})
+	user := signUp(t, s, "user@example.com")
+
+	// solve answers a fresh challenge; the difficulty keeps the first byte zero
+	solve := func() string {
+		t.Helper()
+		var c challenge.Challenge
+		decodeBody(t, serve(s, "GET", "/api/challenge", "", nil, nil), &c)
+		if c.Mode != challenge.ModePoW || c.Difficulty != 4 {
+			t.Fatalf("challenge is %+v", c)
+		}
+		for nonce := 0; ; nonce++ {
+			response := c.Token + ":" + strconv.Itoa(nonce)
+			if sum := sha256.Sum256([]byte(response)); sum[0]>>4 == 0 {
+				return response
+			}
+		}
+	}
+	solved := solve()
+
+	tests := []struct {
+		name     string
+		token    string
+		response string
+		code     int
+	}{
+		{"no response", "", "", http.StatusForbidden},
+		{"wrong response", "", "abc.123.def:1", http.StatusForbidden},
+		{"solved", "", solved, http.StatusCreated},
+		{"reused", "", solved, http.StatusForbidden},
+		{"signed in", user, "", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		w := serve(s, "POST", "/api/tierlists", tt.token, map[string]interface{}{
+			"game_id": "g", "sheet_id": "main", "name": "List",
+			"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}},
+		}, func(r *http.Request) {
+			if tt.response != "" {
+				r.Header.Set(challengeHeader, tt.response)
+			}
+		})
+		if w.Code != tt.code {
+			t.Errorf("%s: create = %d %s, want %d", tt.name, w.Code, w.Body, tt.code)
+		}
+	}
+}
+
+func TestChallengeOff(t *testing.T) {
+	s, _ := newTestServer(t, nil)
+	var c challenge.Challenge
+	decodeBody(t, serve(s, "GET", "/api/challenge", "", nil, nil), &c)
+	if c != (challenge.Challenge{Mode: challenge.ModeOff}) {
+		t.Errorf("challenge is %+v, want mode off", c)
+	}
+	w := serve(s, "POST", "/api/tierlists", "", map[string]interface{}{
+		"game_id": "g", "sheet_id": "main", "name": "List",
+		"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}},
+	}, nil)
+	if w.Code != http.StatusCreated {
+		t.Errorf("anonymous create = %d %s, want 201", w.Code, w.Body)
+	}
+}
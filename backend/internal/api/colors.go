@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/meur/tierforge/internal/colors"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+)
+
+// maxCheckedColors caps the colors one check request may submit
+const maxCheckedColors = 50
+
+// tierColors returns tier colors in display order
+func tierColors(tiers []models.Tier) []string {
+	sorted := ranking.SortedTiers(tiers)
+	hexes := make([]string, len(sorted))
+	for i, t := range sorted {
+		hexes[i] = t.Color
+	}
+	return hexes
+}
+
+// checkColors runs the color check on colors being saved according to
+// COLOR_CHECK_MODE. In reject mode it writes a 422 response and returns false
+// when adjacent colors are indistinguishable; otherwise it returns the issues
+// to surface as warnings. Colors that are not #rrggbb are not checked.
+func (s *Server) checkColors(w http.ResponseWriter, hexes []string) ([]models.ColorIssue, bool) {
+	if s.config.ColorCheckMode == "off" {
+		return nil, true
+	}
+	issues, err := colors.Check(hexes)
+	if err != nil {
+		return nil, true
+	}
+	if s.config.ColorCheckMode == "reject" {
+		for _, issue := range issues {
+			if issue.Kind == models.ColorSimilar {
+				respondError(w, http.StatusUnprocessableEntity, issue.Message)
+				return nil, false
+			}
+		}
+	}
+	if len(issues) == 0 {
+		return nil, true
+	}
+	return issues, true
+}
+
+// handleCheckColors reports tier colors that are hard to distinguish or read
+func (s *Server) handleCheckColors(w http.ResponseWriter, r *http.Request) {
+	var req models.ColorCheckRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	hexes := req.Colors
+	if len(hexes) == 0 {
+		hexes = tierColors(req.Tiers)
+	}
+	if len(hexes) == 0 {
+		respondError(w, http.StatusBadRequest, "colors or tiers is required")
+		return
+	}
+	if len(hexes) > maxCheckedColors {
+		respondError(w, http.StatusBadRequest, "Too many colors")
+		return
+	}
+
+	issues, err := colors.Check(hexes)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ok := true
+	for _, issue := range issues {
+		if issue.Kind == models.ColorSimilar {
+			ok = false
+		}
+	}
+	respondJSON(w, http.StatusOK, models.ColorCheckResult{OK: ok, Issues: issues})
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+)
+
+// communityTierListID stands in for the community consensus in comparisons
+const communityTierListID = "community"
+
+// viewableTierList loads a tier list the requester may read, returning nil
+// for lists that are missing or that canViewTierList withholds
+func (s *Server) viewableTierList(r *http.Request, id string) (*models.TierList, error) {
+	tl, err := s.storeFor(r).GetTierList(id)
+	if err != nil || tl == nil || !canViewTierList(r, tl) {
+		return nil, err
+	}
+	return tl, nil
+}
+
+// communityTierList synthesizes a tier list from the community consensus of
+// a sheet, using the tiers of like as the layout
+func (s *Server) communityTierList(like *models.TierList) (*models.TierList, error) {
+	aggregates, err := s.store.GetCommunityAggregates(like.GameID, like.SheetID)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(aggregates))
+	for _, a := range aggregates {
+		scores[a.ItemID] = a.Score
+	}
+	return &models.TierList{
+		ID:      communityTierListID,
+		GameID:  like.GameID,
+		SheetID: like.SheetID,
+		Name:    "Community",
+		Tiers:   ranking.Bucket(scores, like.Tiers),
+		Status:  models.TierListPublished,
+	}, nil
+}
+
+// handleCompareTierLists diffs two tier lists of the same sheet. Passing
+// b=community compares a against the community consensus.
+func (s *Server) handleCompareTierLists(w http.ResponseWriter, r *http.Request) {
+	aID, bID := r.URL.Query().Get("a"), r.URL.Query().Get("b")
+	if aID == "" || bID == "" {
+		respondError(w, http.StatusBadRequest, "a and b are required")
+		return
+	}
+
+	a, err := s.viewableTierList(r, aID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if a == nil {
+		respondError(w, http.StatusNotFound, "Tier list a not found")
+		return
+	}
+
+	var b *models.TierList
+	if bID == communityTierListID {
+		b, err = s.communityTierList(a)
+	} else {
+		b, err = s.viewableTierList(r, bID)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if b == nil {
+		respondError(w, http.StatusNotFound, "Tier list b not found")
+		return
+	}
+
+	if a.GameID != b.GameID || a.SheetID != b.SheetID {
+		respondError(w, http.StatusBadRequest, "Tier lists must belong to the same game and sheet")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ranking.Compare(a, b))
+}
+
+// maxMergeSources caps how many tier lists one merge may combine
+const maxMergeSources = 20
+
+// handleMergeTierLists combines several tier lists of the same sheet into a
+// new tier list owned by the requester
+func (s *Server) handleMergeTierLists(w http.ResponseWriter, r *http.Request) {
+	var req models.TierListMergeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.IDs) < 2 || len(req.IDs) > maxMergeSources {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("ids must list between 2 and %d tier lists", maxMergeSources))
+		return
+	}
+	if req.Strategy == "" {
+		req.Strategy = models.MergeAverage
+	}
+	name, err := s.cleanText("name", req.Name, maxTierListNameLength)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lists := make([]*models.TierList, 0, len(req.IDs))
+	weights := make([]float64, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		tl, err := s.viewableTierList(r, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+			return
+		}
+		if tl == nil {
+			respondError(w, http.StatusNotFound, "Tier list not found: "+id)
+			return
+		}
+		if len(lists) > 0 && (tl.GameID != lists[0].GameID || tl.SheetID != lists[0].SheetID) {
+			respondError(w, http.StatusBadRequest, "Tier lists must belong to the same game and sheet")
+			return
+		}
+
+		weight := 1.0
+		if v, ok := req.Weights[id]; ok {
+			weight = v
+		}
+		if weight < 0 {
+			respondError(w, http.StatusBadRequest, "weights must not be negative")
+			return
+		}
+		lists = append(lists, tl)
+		weights = append(weights, weight)
+	}
+
+	tiers, err := ranking.Merge(lists, weights, req.Strategy, lists[0].Tiers)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "strategy must be one of average, majority, weighted")
+		return
+	}
+
+	create := models.TierListCreate{
+		GameID:        lists[0].GameID,
+		SheetID:       lists[0].SheetID,
+		Name:          name,
+		Tiers:         tiers,
+		GameVersion:   lists[0].GameVersion,
+		Status:        models.TierListPublished,
+		CreatorIP:     clientIP(r),
+		CreatorDevice: deviceBanHash(r),
+	}
+	if user := currentUser(r); user != nil {
+		create.AuthorID = &user.ID
+	}
+
+	tierList, err := s.storeFor(r).CreateTierList(&create)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create tier list")
+		return
+	}
+	s.recordTierListCreated(s.store, r, tierList, "merge")
+	respondJSON(w, http.StatusCreated, tierList)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+var errNegativeCapacity = errors.New("tier max must not be negative")
+
+// checkConstraints rejects tiers that break the tier capacities or list
+// constraints, listing every violation. The pool is only counted for
+// published lists that must rank every item.
+func (s *Server) checkConstraints(w http.ResponseWriter, tl *models.TierList) bool {
+	published := tl.Status == models.TierListPublished
+	unranked := 0
+	if tl.Constraints != nil && tl.Constraints.RequireAll && published {
+		items, err := s.store.QueryItems(storage.ItemQuery{GameID: tl.GameID, SheetID: tl.SheetID, Version: tl.GameVersion, TierListID: tl.ID})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+			return false
+		}
+		for _, item := range items {
+			if !item.Spoiler && !isRanked(tl.Tiers, item.ID) {
+				unranked++
+			}
+		}
+	}
+	if violations := tl.Constraints.Violations(tl.Tiers, unranked, published); len(violations) > 0 {
+		respondError(w, http.StatusUnprocessableEntity, "Tier list breaks its constraints: "+strings.Join(violations, "; "))
+		return false
+	}
+	return true
+}
+
+// checkEditedTiers validates the tiers of tl as an edit leaves them (names,
+// capacities, points and colors) and then tl against its constraints. Every
+// path that saves tiers of an existing list goes through it. It returns the
+// color warnings to show.
+func (s *Server) checkEditedTiers(w http.ResponseWriter, tl *models.TierList) ([]models.ColorIssue, bool) {
+	if err := s.cleanTiers(tl.Tiers); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if err := cleanConstraints(nil, tl.Tiers); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if !checkTierPoints(w, tl.Tiers) {
+		return nil, false
+	}
+	warnings, ok := s.checkColors(w, tierColors(tl.Tiers))
+	if !ok || !s.checkConstraints(w, tl) {
+		return nil, false
+	}
+	return warnings, true
+}
+
+// cleanConstraints validates constraints and tier capacities from a request
+func cleanConstraints(c *models.Constraints, tiers []models.Tier) error {
+	for _, tier := range tiers {
+		if tier.Max < 0 {
+			return errNegativeCapacity
+		}
+	}
+	if c == nil {
+		return nil
+	}
+	return c.Check()
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxContestDescription limits contest descriptions, in characters
+const maxContestDescription = 1000
+
+// maxContests limits the contests listed per game
+const maxContests = 100
+
+// handleCreateContest opens a contest on a sheet of the game
+func (s *Server) handleCreateContest(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	var req models.ContestCreate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	if game.Sheet(req.SheetID) == nil {
+		respondError(w, http.StatusBadRequest, "sheet_id must name a sheet of the game")
+		return
+	}
+	name, err := s.cleanText("name", req.Name, maxTierListNameLength)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Description != "" {
+		if req.Description, err = s.cleanText("description", req.Description, maxContestDescription); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if !req.Deadline.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, "deadline must be in the future")
+		return
+	}
+	if req.Constraints != nil {
+		if err := req.Constraints.Check(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	contest := models.Contest{
+		GameID:      gameID,
+		SheetID:     req.SheetID,
+		Name:        name,
+		Description: req.Description,
+		Constraints: req.Constraints,
+		Deadline:    req.Deadline,
+		CreatedBy:   auditActor(r),
+	}
+	if err := s.storeFor(r).CreateContest(&contest); err != nil {
+		respondWriteError(w, err, "Failed to create contest")
+		return
+	}
+	s.auditChange(r, "contest.create", "contest", contest.ID, gameID, nil, contest)
+
+	respondJSON(w, http.StatusCreated, contest)
+}
+
+// handleDeleteContest deletes a contest with its submissions; the submitted
+// tier lists stay
+func (s *Server) handleDeleteContest(w http.ResponseWriter, r *http.Request) {
+	contest, ok := s.lookupContest(w, r)
+	if !ok {
+		return
+	}
+	if contest.GameID != chi.URLParam(r, "gameID") {
+		respondError(w, http.StatusNotFound, "Contest not found")
+		return
+	}
+	if err := s.storeFor(r).DeleteContest(contest.ID); err != nil {
+		respondWriteError(w, err, "Failed to delete contest")
+		return
+	}
+	s.auditChange(r, "contest.delete", "contest", contest.ID, contest.GameID, contest, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetContests lists the contests of a game, latest deadline first
+func (s *Server) handleGetContests(w http.ResponseWriter, r *http.Request) {
+	contests, err := s.storeFor(r).GetContests(chi.URLParam(r, "gameID"), maxContests)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch contests")
+		return
+	}
+	respondJSON(w, http.StatusOK, contests)
+}
+
+// handleGetContest returns a contest with its submissions. Once the deadline
+// passes, each submission is scored by its agreement with the consensus of
+// all submissions and ranked on the leaderboard, best first.
+func (s *Server) handleGetContest(w http.ResponseWriter, r *http.Request) {
+	contest, ok := s.lookupContest(w, r)
+	if !ok {
+		return
+	}
+	subs, err := s.storeFor(r).GetContestSubmissions(contest.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch submissions")
+		return
+	}
+	game, err := s.storeFor(r).GetGame(contest.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+
+	standings := models.ContestStandings{Contest: *contest, Ended: contest.Ended(time.Now()), Submissions: subs}
+	if standings.Ended && len(subs) > 0 {
+		lists := make([]*models.TierList, len(subs))
+		for i := range subs {
+			lists[i] = &models.TierList{Tiers: subs[i].Tiers}
+		}
+		consensus, agreement := ranking.Agreement(lists)
+		for i := range subs {
+			subs[i].Agreement = &agreement[i]
+		}
+		sort.SliceStable(subs, func(a, b int) bool { return *subs[a].Agreement > *subs[b].Agreement })
+		for i := range subs {
+			// Equal agreement shares a place
+			subs[i].Rank = i + 1
+			if i > 0 && *subs[i].Agreement == *subs[i-1].Agreement {
+				subs[i].Rank = subs[i-1].Rank
+			}
+		}
+
+		defaults := game.TierTemplate(contest.SheetID)
+		template := make([]models.Tier, 0, len(defaults))
+		for _, t := range defaults {
+			template = append(template, models.Tier{ID: t.ID, Name: t.Name, Color: t.Color, Order: t.Order, Points: t.Points})
+		}
+		standings.Consensus = ranking.Bucket(consensus, template)
+	}
+
+	mode, ok := spoilerMode(w, r, game)
+	if !ok {
+		return
+	}
+	if mode == models.SpoilerExclude {
+		spoilers, err := s.storeFor(r).GetSpoilerItemIDs(game.ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+			return
+		}
+		for i := range subs {
+			dropTierItems(subs[i].Tiers, spoilers)
+		}
+		dropTierItems(standings.Consensus, spoilers)
+	}
+	respondJSON(w, http.StatusOK, standings)
+}
+
+// handleSubmitToContest enters one of the user's published tier lists into a
+// contest, replacing their earlier entry. The list must be of the contest's
+// sheet and meet its constraints. Its tiers are kept as submitted and the list
+// is locked as the user's final answer; unlocking it does not change the entry.
+func (s *Server) handleSubmitToContest(w http.ResponseWriter, r *http.Request) {
+	user := currentUser(r)
+	contest, ok := s.lookupContest(w, r)
+	if !ok {
+		return
+	}
+	if contest.Ended(time.Now()) {
+		respondError(w, http.StatusConflict, "This contest has ended")
+		return
+	}
+	var req models.ContestEntry
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tl, err := s.storeFor(r).GetTierList(req.TierListID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	if tl.AuthorID == nil || *tl.AuthorID != user.ID {
+		respondError(w, http.StatusForbidden, "Only your own tier lists can be submitted")
+		return
+	}
+	if tl.GameID != contest.GameID || tl.SheetID != contest.SheetID {
+		respondError(w, http.StatusBadRequest, "Tier list must rank the contest's sheet")
+		return
+	}
+	if tl.Status != models.TierListPublished || tl.Visibility == models.VisibilityPrivate || tl.Hidden {
+		respondError(w, http.StatusBadRequest, "Only published, non-private tier lists can be submitted")
+		return
+	}
+	if contest.Constraints != nil {
+		entry := *tl
+		entry.Constraints = contest.Constraints
+		if !s.checkConstraints(w, &entry) {
+			return
+		}
+	}
+
+	sub := models.ContestSubmission{
+		TierListID:  tl.ID,
+		UserID:      user.ID,
+		DisplayName: user.DisplayName,
+		Tiers:       tl.Tiers,
+		SubmittedAt: time.Now(),
+	}
+	err = s.storeFor(r).WithTx(r.Context(), func(tx *storage.Store) error {
+		if err := tx.SubmitToContest(contest.ID, &sub); err != nil {
+			return err
+		}
+		return tx.LockTierList(tl.ID)
+	})
+	if err != nil {
+		respondWriteError(w, err, "Failed to submit tier list")
+		return
+	}
+	// Shares show the list as locked
+	if locked, err := s.storeFor(r).GetTierList(tl.ID); err == nil {
+		s.prerenderTierList(locked)
+	}
+
+	respondJSON(w, http.StatusCreated, sub)
+}
+
+// lookupContest loads the contest named in the URL, writing the error
+// response itself
+func (s *Server) lookupContest(w http.ResponseWriter, r *http.Request) (*models.Contest, bool) {
+	contest, err := s.storeFor(r).GetContest(chi.URLParam(r, "contestID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch contest")
+		return nil, false
+	}
+	if contest == nil {
+		respondError(w, http.StatusNotFound, "Contest not found")
+		return nil, false
+	}
+	return contest, true
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+)
+
+// corsHandler serves the configured origins with credentials, and any origin
+// without them on the public read-only routes registered with publicGet, so
+// other sites can embed games and shared lists
+func (s *Server) corsHandler(next http.Handler) http.Handler {
+	app := cors.Handler(cors.Options{
+		AllowOriginFunc:  s.allowOrigin,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", challengeHeader},
+		ExposedHeaders:   []string{"Link"}, // Next page of paginated listings
+		AllowCredentials: true,
+		MaxAge:           300,
+	})(next)
+	public := cors.Handler(cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "HEAD"},
+		AllowedHeaders: []string{"Accept", "Content-Type"},
+		ExposedHeaders: []string{"Link"},
+		MaxAge:         3600,
+	})(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin != "" && !s.allowOrigin(r, origin) && s.isPublicRead(r) {
+			public.ServeHTTP(w, r)
+			return
+		}
+		app.ServeHTTP(w, r)
+	})
+}
+
+// allowOrigin reports whether origin may call the API with credentials
+func (s *Server) allowOrigin(r *http.Request, origin string) bool {
+	origin = strings.ToLower(origin)
+	if u, err := url.Parse(s.config.PublicURL); err == nil && u.Host != "" {
+		if origin == strings.ToLower(u.Scheme+"://"+u.Host) {
+			return true
+		}
+	}
+	for _, pattern := range s.config.CORSOrigins {
+		if matchOrigin(pattern, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOrigin matches origin against pattern, where * stands for any run of
+// characters other than "/"
+func matchOrigin(pattern, origin string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(origin, parts[0]) {
+		return false
+	}
+	rest := origin[len(parts[0]):]
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		var at int
+		if last {
+			if !strings.HasSuffix(rest, part) {
+				return false
+			}
+			at = len(rest) - len(part)
+		} else if at = strings.Index(rest, part); at < 0 {
+			return false
+		}
+		if strings.Contains(rest[:at], "/") {
+			return false
+		}
+		rest = rest[at+len(part):]
+	}
+	return len(parts) > 1 || rest == ""
+}
+
+// publicGet registers a read-only route of the /api router that any site may
+// call cross-origin
+func (s *Server) publicGet(r chi.Router, pattern string, h http.HandlerFunc) {
+	r.Get(pattern, h)
+	s.publicRoutes.Get("/api"+pattern, h)
+}
+
+// isPublicRead reports whether r, or the request it is a preflight for,
+// reads a route registered with publicGet
+func (s *Server) isPublicRead(r *http.Request) bool {
+	method := r.Method
+	if method == http.MethodOptions {
+		method = r.Header.Get("Access-Control-Request-Method")
+	}
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet {
+		return false
+	}
+	return s.publicRoutes.Match(chi.NewRouteContext(), method, r.URL.Path)
+}
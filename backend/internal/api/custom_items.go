@@ -0,0 +1,307 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/icons"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxUploadBatch caps the items added by one upload
+const maxUploadBatch = 50
+
+// upload is one item of an upload request; image is nil for name-only items
+type upload struct {
+	name     string
+	filename string
+	image    []byte
+}
+
+// handleGetCustomItems returns the items uploaded to a custom tier list.
+// Editors also get how many items the list may hold.
+func (s *Server) handleGetCustomItems(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.storeFor(r).GetTierList(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil || !canViewTierList(r, tl) {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+
+	items, err := s.storeFor(r).GetCustomItems(tl.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	resp := map[string]interface{}{"items": items}
+	if tl.GameID == models.CustomGameID && tl.AuthorID != nil && canEditTierList(r, tl) {
+		quota, err := s.quotaFor(s.storeFor(r), *tl.AuthorID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+			return
+		}
+		resp["list_limit"] = quota.Limits.CustomItemsPerList
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleUploadCustomItems adds items to a custom tier list from a multipart
+// form. Each image part becomes an item named by the name part at the same
+// position, or else by its file name; name parts beyond the images add items
+// without an image. Uploads count against the quota of the list's author.
+func (s *Server) handleUploadCustomItems(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.editableTierList(w, r)
+	if !ok || tierListLocked(w, tl) {
+		return
+	}
+	switch {
+	case tl.GameID != models.CustomGameID:
+		respondError(w, http.StatusBadRequest, "Only custom tier lists take uploaded items")
+		return
+	case tl.AuthorID == nil:
+		respondError(w, http.StatusForbidden, "Sign in to upload images")
+		return
+	case tl.Status == models.TierListArchived:
+		respondError(w, http.StatusConflict, "Archived tier lists are read-only; publish it again to edit")
+		return
+	}
+
+	uploads, ok := s.readUploads(w, r)
+	if !ok {
+		return
+	}
+	var size int64
+	for _, u := range uploads {
+		size += int64(len(u.image))
+	}
+	store := s.storeFor(r)
+	// Check before decoding anything; the check is repeated when saving
+	if err := s.checkUploadQuota(store, tl, len(uploads), size); err != nil {
+		var quotaErr quotaError
+		if errors.As(err, &quotaErr) {
+			respondError(w, http.StatusForbidden, err.Error())
+		} else {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+		}
+		return
+	}
+
+	items := make([]models.Item, 0, len(uploads))
+	for _, u := range uploads {
+		name, err := s.cleanText("name", u.name, maxItemNameLength)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		item := models.Item{GameID: tl.GameID, SheetID: tl.SheetID, TierListID: tl.ID, Name: name, Data: map[string]interface{}{}}
+		if u.image != nil {
+			img, err := icons.Decode(u.image)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, fmt.Sprintf("%s is not a PNG, JPEG or GIF image of at most %d pixels", u.filename, icons.MaxPixels))
+				return
+			}
+			data, hash, err := icons.Encode(img)
+			if err == nil {
+				err = store.SaveIcon(hash, data, "")
+			}
+			if err != nil {
+				log.Printf("ERROR: Failed to store uploaded image for tier list %s: %v", tl.ID, err)
+				respondWriteError(w, err, "Failed to store image")
+				return
+			}
+			item.Icon = icons.Path(hash)
+		}
+		items = append(items, item)
+	}
+
+	err := store.WithTx(r.Context(), func(tx *storage.Store) error {
+		if err := s.checkUploadQuota(tx, tl, len(uploads), size); err != nil {
+			return err
+		}
+		for i := range items {
+			if err := tx.CreateCustomItem(&items[i], int64(len(uploads[i].image))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var quotaErr quotaError
+	if errors.As(err, &quotaErr) {
+		respondError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to save uploaded items for tier list %s: %v", tl.ID, err)
+		respondWriteError(w, err, "Failed to save items")
+		return
+	}
+	respondJSON(w, http.StatusCreated, items)
+}
+
+// handleDeleteCustomItem deletes an item uploaded to a tier list and takes
+// it off the list's tiers
+func (s *Server) handleDeleteCustomItem(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.editableTierList(w, r)
+	if !ok || tierListLocked(w, tl) {
+		return
+	}
+	if tl.Status == models.TierListArchived {
+		respondError(w, http.StatusConflict, "Archived tier lists are read-only; publish it again to edit")
+		return
+	}
+	itemID := chi.URLParam(r, "itemID")
+	if isRanked(tl.Tiers, itemID) && pollLocksTiers(w, tl) {
+		return
+	}
+
+	found, err := s.storeFor(r).DeleteCustomItem(tl.ID, itemID)
+	if err != nil {
+		respondWriteError(w, err, "Failed to delete item")
+		return
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+	if isRanked(tl.Tiers, itemID) {
+		before := make([]models.Tier, len(tl.Tiers))
+		for i, tier := range tl.Tiers {
+			before[i] = tier
+			before[i].Items = append([]string(nil), tier.Items...)
+		}
+		for i := range tl.Tiers {
+			tl.Tiers[i].Items = removeString(tl.Tiers[i].Items, itemID)
+		}
+		s.autosaves.Discard(tl.ID)
+		if err := s.updateTierList(r.Context(), tl.ID, before, &models.TierListUpdate{Tiers: tl.Tiers}); err != nil {
+			respondWriteError(w, err, "Failed to update tier list")
+			return
+		}
+		s.prerenderTierList(tl)
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// readUploads reads the items of an upload request, writing the error
+// response itself
+func (s *Server) readUploads(w http.ResponseWriter, r *http.Request) ([]upload, bool) {
+	maxBytes := int64(s.config.CustomUploadMaxKB) << 10
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBatch*maxBytes+1<<20)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Expected a multipart/form-data body")
+		return nil, false
+	}
+
+	var uploads []upload
+	var names []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "Upload is too large")
+			return nil, false
+		}
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid multipart body")
+			return nil, false
+		}
+		switch part.FormName() {
+		case "image":
+			data, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid multipart body")
+				return nil, false
+			}
+			if int64(len(data)) > maxBytes {
+				respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("%s is larger than %d KB", part.FileName(), s.config.CustomUploadMaxKB))
+				return nil, false
+			}
+			uploads = append(uploads, upload{filename: part.FileName(), image: data})
+		case "name":
+			data, err := io.ReadAll(io.LimitReader(part, 4<<10))
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid multipart body")
+				return nil, false
+			}
+			names = append(names, string(data))
+		}
+		part.Close()
+		if len(uploads) > maxUploadBatch || len(names) > maxUploadBatch {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("At most %d items can be uploaded at once", maxUploadBatch))
+			return nil, false
+		}
+	}
+
+	for i := range uploads {
+		if i < len(names) && strings.TrimSpace(names[i]) != "" {
+			uploads[i].name = names[i]
+		} else {
+			uploads[i].name = strings.TrimSuffix(uploads[i].filename, path.Ext(uploads[i].filename))
+		}
+	}
+	for i := len(uploads); i < len(names); i++ {
+		uploads = append(uploads, upload{name: names[i]})
+	}
+	if len(uploads) == 0 {
+		respondError(w, http.StatusBadRequest, "Upload at least one image or name")
+		return nil, false
+	}
+	return uploads, true
+}
+
+// quotaError is a broken quota, as opposed to a failure to check it
+type quotaError struct{ error }
+
+// checkUploadQuota checks that n items of size bytes in all fit the quota
+// of tl's author
+func (s *Server) checkUploadQuota(store *storage.Store, tl *models.TierList, n int, size int64) error {
+	quota, err := s.quotaFor(store, *tl.AuthorID)
+	if err != nil {
+		return err
+	}
+	listUsed, err := store.CountCustomItems(tl.ID)
+	if err != nil {
+		return err
+	}
+	if err := quota.CheckUpload(listUsed, n, size); err != nil {
+		return quotaError{err}
+	}
+	return nil
+}
+
+// uploadedIcons returns the stored images of uploaded items
+func (s *Server) uploadedIcons(r *http.Request, items []models.Item) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	for _, item := range items {
+		name, ok := strings.CutPrefix(item.Icon, icons.PathPrefix)
+		if !ok {
+			continue
+		}
+		hash, ok := icons.ParseFile(name)
+		if !ok {
+			continue
+		}
+		data, err := s.storeFor(r).GetIcon(hash)
+		if err != nil {
+			return out, err
+		}
+		if data != nil {
+			out[item.ID] = data
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/infobox"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// handleGetItemDetails returns what an item's detail popover shows: the
+// item with its infobox parsed into stats instead of raw HTML
+func (s *Server) handleGetItemDetails(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	itemID := chi.URLParam(r, "itemID")
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	mode, ok := spoilerMode(w, r, game)
+	if !ok {
+		return
+	}
+
+	item, err := s.storeFor(r).GetItem(gameID, itemID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+		return
+	}
+	if item == nil || (item.Spoiler && mode == models.SpoilerExclude) {
+		respondError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+
+	details := models.ItemDetails{ID: item.ID, GameID: item.GameID, SheetID: item.SheetID, Spoiler: item.Spoiler}
+	if item.Spoiler && mode == models.SpoilerBlur {
+		item.Blur()
+		details.Name = item.Name
+		respondJSON(w, http.StatusOK, details)
+		return
+	}
+	details.Name, details.NameRu, details.Icon = item.Name, item.NameRu, item.Icon
+	details.Category, details.Tags = item.Category, item.Tags
+	details.WikiURL, _ = item.Data["wiki_url"].(string)
+	details.Infobox = itemInfobox(item)
+	respondJSON(w, http.StatusOK, details)
+}
+
+// itemInfobox returns the infobox parsed at import time, parsing the HTML of
+// items imported before infoboxes were parsed
+func itemInfobox(item *models.Item) *models.Infobox {
+	if ib := infobox.FromData(item.Data); ib != nil {
+		if ib.Stats == nil {
+			ib.Stats = []models.Stat{}
+		}
+		return ib
+	}
+	if html, _ := item.Data["infobox_html"].(string); html != "" {
+		return infobox.Parse(html)
+	}
+	return nil
+}
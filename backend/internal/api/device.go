@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/auth"
+)
+
+const (
+	deviceCookieName = "tf_device"
+	// deviceRefreshAfter is how old a token gets before it is re-signed,
+	// which also moves it to the newest secret
+	deviceRefreshAfter = 24 * time.Hour
+	// deviceCookieMaxAge expires tokens of devices that stop visiting
+	deviceCookieMaxAge = 30 * 24 * time.Hour
+	// deviceIDLifetime replaces device IDs periodically so no ID tracks a
+	// browser for long
+	deviceIDLifetime = 90 * 24 * time.Hour
+)
+
+// deviceIdentity reads the device cookie into the request context, issuing
+// a new cookie to browsers without a valid one and re-signing aging ones.
+// A freshly issued device only counts from the browser's next request, so
+// a client that drops cookies keeps being told apart by IP.
+func (s *Server) deviceIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.devices == nil || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		var dev auth.Device
+		presented, reissue := false, true
+		if c, err := r.Cookie(deviceCookieName); err == nil {
+			d, current, ok := s.devices.Parse(c.Value)
+			if ok && now.Sub(d.Issued) < deviceCookieMaxAge && now.Sub(d.Created) < deviceIDLifetime {
+				dev, presented = d, true
+				reissue = !current || now.Sub(d.Issued) > deviceRefreshAfter
+			}
+		}
+		if !presented {
+			if isBot(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			var err error
+			if dev, err = auth.NewDevice(now); err != nil {
+				log.Printf("ERROR: Failed to create device: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		if reissue {
+			dev.Issued = now
+			http.SetCookie(w, &http.Cookie{
+				Name:     deviceCookieName,
+				Value:    s.devices.Sign(dev),
+				Path:     "/api",
+				MaxAge:   int(deviceCookieMaxAge / time.Second),
+				HttpOnly: true,
+				Secure:   strings.HasPrefix(s.config.PublicURL, "https://"),
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		if presented {
+			r = r.WithContext(context.WithValue(r.Context(), deviceContextKey, dev))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// visitorKey identifies the anonymous visitor of r within scope, such as a
+// tier list ID: by device when the request carries a device cookie and by
+// hashed IP otherwise
+func (s *Server) visitorKey(r *http.Request, scope string) string {
+	if dev, ok := r.Context().Value(deviceContextKey).(auth.Device); ok {
+		return "device:" + auth.DeviceHash(dev.ID, scope)
+	}
+	return s.visitorHash(r)
+}
+
+// deviceBanHash returns the ban hash of r's device, or "" without one. Tier
+// lists record it so moderators can ban the device that created them.
+func deviceBanHash(r *http.Request) string {
+	if dev, ok := r.Context().Value(deviceContextKey).(auth.Device); ok {
+		return auth.DeviceHash(dev.ID, "ban")
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/meur/tierforge/internal/auth"
+	"github.com/meur/tierforge/internal/email"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// verifyTokenLifetime is how long an email verification link stays valid
+const verifyTokenLifetime = 48 * time.Hour
+
+// emailSendTimeout bounds a single background send
+const emailSendTimeout = 30 * time.Second
+
+// sendEmail delivers msg in the background so slow mail servers never block a
+// request. Failures are logged.
+func (s *Server) sendEmail(msg *email.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(s.ctx, emailSendTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("ERROR: Failed to send %q email: %v", msg.Subject, err)
+		}
+	}()
+}
+
+// issueEmailToken creates a single-use token for the user and returns the
+// frontend link carrying it
+func (s *Server) issueEmailToken(userID, purpose, address string, lifetime time.Duration) (string, error) {
+	token, err := auth.NewToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.CreateEmailToken(auth.HashToken(token), userID, purpose, address, time.Now().Add(lifetime)); err != nil {
+		return "", err
+	}
+	return s.config.PublicURL + "/?" + purpose + "=" + token, nil
+}
+
+// sendVerification emails a verification link to the user. It does nothing
+// when email is disabled.
+func (s *Server) sendVerification(user *models.User) error {
+	if !email.Enabled(s.mailer) {
+		return nil
+	}
+	link, err := s.issueEmailToken(user.ID, storage.EmailTokenVerify, user.Email, verifyTokenLifetime)
+	if err != nil {
+		log.Printf("ERROR: Failed to create verification token for user %s: %v", user.ID, err)
+		return err
+	}
+	s.sendEmail(email.VerificationMessage(user.Email, user.DisplayName, link))
+	return nil
+}
+
+// handleVerifyEmail confirms an email address from a verification link
+func (s *Server) handleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyEmailRequest
+	if err := decodeJSON(r, &req); err != nil || req.Token == "" {
+		respondError(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	userID, address, err := s.storeFor(r).ConsumeEmailToken(auth.HashToken(req.Token), storage.EmailTokenVerify)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+	if userID == "" {
+		respondError(w, http.StatusBadRequest, "Verification link is invalid or expired")
+		return
+	}
+	ok, err := s.storeFor(r).MarkEmailVerified(userID, address)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to verify email")
+		return
+	}
+	if !ok {
+		respondError(w, http.StatusConflict, "The account email has changed since this link was sent")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"status": "verified"})
+}
+
+// handleResendVerification sends a fresh verification link to the current user
+func (s *Server) handleResendVerification(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+	if !email.Enabled(s.mailer) {
+		respondError(w, http.StatusServiceUnavailable, "Email is not configured")
+		return
+	}
+	if user.EmailVerified {
+		respondError(w, http.StatusConflict, "Email is already verified")
+		return
+	}
+	if err := s.sendVerification(user); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+	respondJSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// handleSetPreferences updates account preferences such as the weekly digest
+func (s *Server) handleSetPreferences(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var req models.UserPreferences
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.WeeklyDigest != nil && *req.WeeklyDigest && !user.EmailVerified {
+		respondError(w, http.StatusUnprocessableEntity, "Verify your email before enabling the weekly digest")
+		return
+	}
+	if err := s.storeFor(r).SetUserPreferences(user.ID, req); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update preferences")
+		return
+	}
+
+	updated, err := s.storeFor(r).GetUser(user.ID)
+	if err != nil || updated == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// clientEventProps lists the events the frontend may report and the props
+// each accepts, so the endpoint cannot be used to store arbitrary data
+var clientEventProps = map[string]map[string]bool{
+	models.EventExportUsed: {"format": true, "target": true},
+}
+
+const maxEventPropLength = 32
+
+// analyticsAllowed reports whether events may be recorded for this request
+func (s *Server) analyticsAllowed(r *http.Request) bool {
+	if !s.config.AnalyticsEnabled {
+		return false
+	}
+	if r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1" {
+		return false
+	}
+	if user := currentUser(r); user != nil && user.AnalyticsOptOut {
+		return false
+	}
+	return true
+}
+
+// recordEvent stores an anonymized product event, logging rather than failing on error
+func (s *Server) recordEvent(r *http.Request, name, gameID string, props map[string]interface{}) {
+	s.recordEventIn(s.store, r, name, gameID, props)
+}
+
+// recordEventIn is recordEvent through store, such as a transaction of
+// WithTx so the event commits together with the change it describes
+func (s *Server) recordEventIn(store *storage.Store, r *http.Request, name, gameID string, props map[string]interface{}) {
+	if !s.analyticsAllowed(r) {
+		return
+	}
+	if err := store.AddEvent(&models.Event{Name: name, GameID: gameID, Props: props}); err != nil {
+		log.Printf("ERROR: Failed to record event %s: %v", name, err)
+	}
+}
+
+// recordTierListCreated records a tier list creation from the given source
+func (s *Server) recordTierListCreated(store *storage.Store, r *http.Request, tierList *models.TierList, source string) {
+	s.recordEventIn(store, r, models.EventTierListCreated, tierList.GameID, map[string]interface{}{
+		"sheet_id": tierList.SheetID,
+		"items":    rankedItemCount(tierList.Tiers),
+		"source":   source,
+	})
+}
+
+// rankedItemCount counts the items placed in tiers
+func rankedItemCount(tiers []models.Tier) int {
+	n := 0
+	for _, tier := range tiers {
+		n += len(tier.Items)
+	}
+	return n
+}
+
+// movedItemCount counts items whose tier differs between two rankings,
+// including items added to or removed from the list
+func movedItemCount(before, after []models.Tier) int {
+	tierOf := make(map[string]string)
+	for _, tier := range before {
+		for _, id := range tier.Items {
+			tierOf[id] = tier.ID
+		}
+	}
+	moved := 0
+	for _, tier := range after {
+		for _, id := range tier.Items {
+			if prev, ok := tierOf[id]; !ok || prev != tier.ID {
+				moved++
+			}
+			delete(tierOf, id)
+		}
+	}
+	return moved + len(tierOf)
+}
+
+// handleReportEvent accepts an event reported by the frontend. It always
+// answers 202 so clients cannot tell whether the event was kept.
+func (s *Server) handleReportEvent(w http.ResponseWriter, r *http.Request) {
+	var req models.EventReport
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	allowed, ok := clientEventProps[req.Name]
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Unknown event")
+		return
+	}
+	props := make(map[string]interface{}, len(req.Props))
+	for key, value := range req.Props {
+		if !allowed[key] || len(value) > maxEventPropLength {
+			respondError(w, http.StatusBadRequest, "Invalid event property: "+key)
+			return
+		}
+		props[key] = value
+	}
+	if req.GameID != "" {
+		game, err := s.storeFor(r).GetGame(req.GameID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+			return
+		}
+		if game == nil {
+			req.GameID = ""
+		}
+	}
+
+	s.recordEvent(r, req.Name, req.GameID, props)
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+	"github.com/meur/tierforge/internal/render"
+	"github.com/meur/tierforge/internal/sprites"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// handleExportTierListHTML downloads a tier list as one static HTML file.
+// Icons are cut from the sheet's sprite sheet, or are the uploaded images of
+// a custom list; items without one are exported without an icon.
+func (s *Server) handleExportTierListHTML(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.viewableTierList(r, chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	if !s.hideTierListSpoilers(w, r, tl) {
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(tl.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	all, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: tl.GameID, SheetID: tl.SheetID, TierListID: tl.ID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	items := make(map[string]models.Item, len(all))
+	labels := make(map[string]string)
+	for _, item := range all {
+		items[item.ID] = item
+		if label := plugins.ExportLabel(item); label != "" {
+			labels[item.ID] = label
+		}
+	}
+
+	var icons map[string][]byte
+	if tl.GameID == models.CustomGameID {
+		icons, err = s.uploadedIcons(r, all)
+	} else {
+		icons, err = s.sheetIcons(tl.GameID, tl.SheetID, all)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to read icons of tier list %s: %v", tl.ID, err)
+	}
+	page, err := render.HTML(tl, game.Name, items, icons, labels)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to render tier list")
+		return
+	}
+
+	s.recordEvent(r, models.EventExportUsed, tl.GameID, map[string]interface{}{"format": "html"})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tierlist-%s.html"`, tl.ShareCode))
+	w.Write(page)
+}
+
+// sheetIcons returns the sprite sheet icons of items, queueing a build when
+// the sheet has none yet or is out of date
+func (s *Server) sheetIcons(gameID, sheetID string, items []models.Item) (map[string][]byte, error) {
+	sheet, err := s.store.GetSpriteSheet(gameID, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	if sheet == nil {
+		s.sprites.Enqueue(gameID, sheetID)
+		return nil, nil
+	}
+	if sheet.Version != sprites.Version(sprites.Spritable(items)) {
+		s.sprites.Enqueue(gameID, sheetID)
+	}
+	data, _, err := s.store.GetSpriteImage(gameID, sheetID)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return sprites.Icons(sheet, data, items)
+}
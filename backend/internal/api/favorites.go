@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// handleGetFavorites returns the tier lists and items the user bookmarked
+func (s *Server) handleGetFavorites(w http.ResponseWriter, r *http.Request) {
+	userID := currentUser(r).ID
+
+	tierLists, err := s.storeFor(r).GetFavoriteTierLists(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch favorites")
+		return
+	}
+	items, err := s.storeFor(r).GetFavoriteItems(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch favorites")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.Favorites{TierLists: tierLists, Items: items})
+}
+
+// handleFavoriteTierList bookmarks a public tier list
+func (s *Server) handleFavoriteTierList(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.viewableTierList(r, chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil || (!tl.IsPublic && !isTierListOwner(r, tl)) {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+
+	fav := models.Favorite{TargetType: models.FavoriteTierList, TargetID: tl.ID}
+	if err := s.storeFor(r).AddFavorite(currentUser(r).ID, &fav); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save favorite")
+		return
+	}
+	respondJSON(w, http.StatusOK, fav)
+}
+
+// handleFavoriteItem bookmarks a catalog item
+func (s *Server) handleFavoriteItem(w http.ResponseWriter, r *http.Request) {
+	item, err := s.storeFor(r).GetItem(chi.URLParam(r, "gameID"), chi.URLParam(r, "itemID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+		return
+	}
+	if item == nil {
+		respondError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+
+	fav := models.Favorite{TargetType: models.FavoriteItem, TargetID: item.ID, GameID: item.GameID}
+	if err := s.storeFor(r).AddFavorite(currentUser(r).ID, &fav); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save favorite")
+		return
+	}
+	respondJSON(w, http.StatusOK, fav)
+}
+
+// handleUnfavorite returns a handler that removes a bookmark of the given type
+func (s *Server) handleUnfavorite(targetType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fav := models.Favorite{TargetType: targetType, TargetID: chi.URLParam(r, "id")}
+		if targetType == models.FavoriteItem {
+			fav.TargetID = chi.URLParam(r, "itemID")
+			fav.GameID = chi.URLParam(r, "gameID")
+		}
+
+		removed, err := s.storeFor(r).RemoveFavorite(currentUser(r).ID, &fav)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to remove favorite")
+			return
+		}
+		if !removed {
+			respondError(w, http.StatusNotFound, "Favorite not found")
+			return
+		}
+		respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestFavoriteToggleIsIdempotent(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	fan := signUp(t, s, "fan@example.com")
+	createRawItems(t, store, "a")
+
+	w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+		"game_id": "g", "sheet_id": "main", "name": "Public", "visibility": "public",
+		"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}},
+	}, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create list: %d %s", w.Code, w.Body)
+	}
+	var tl models.TierList
+	decodeBody(t, w, &tl)
+
+	favorites := func() models.Favorites {
+		t.Helper()
+		var favs models.Favorites
+		decodeBody(t, serve(s, "GET", "/api/me/favorites", fan, nil, nil), &favs)
+		return favs
+	}
+
+	tests := []struct {
+		name string
+		path string
+		// count returns how often the target shows in the favorites
+		count func(models.Favorites) int
+	}{
+		{"tier list", "/api/me/favorites/tierlists/" + tl.ID, func(f models.Favorites) int { return len(f.TierLists) }},
+		{"item", "/api/me/favorites/items/g/a", func(f models.Favorites) int { return len(f.Items) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := []struct {
+				method string
+				code   int
+				count  int
+			}{
+				{"PUT", http.StatusOK, 1},
+				{"PUT", http.StatusOK, 1},
+				{"DELETE", http.StatusOK, 0},
+				{"DELETE", http.StatusNotFound, 0},
+				{"PUT", http.StatusOK, 1},
+			}
+			for i, step := range steps {
+				if w := serve(s, step.method, tt.path, fan, nil, nil); w.Code != step.code {
+					t.Fatalf("step %d %s: %d %s, want %d", i, step.method, w.Code, w.Body, step.code)
+				}
+				if got := tt.count(favorites()); got != step.count {
+					t.Errorf("step %d %s: %d favorites, want %d", i, step.method, got, step.count)
+				}
+			}
+		})
+	}
+
+	// Repeated bookmarks by one user count once
+	if favs := favorites(); len(favs.TierLists) != 1 || favs.TierLists[0].FavoriteCount != 1 {
+		t.Errorf("favorite tier lists = %+v, want one bookmarked once", favs.TierLists)
+	}
+}
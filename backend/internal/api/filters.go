@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/meur/tierforge/internal/itemfilter"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxFilterLength caps ?filter= expressions, in bytes
+const maxFilterLength = 1000
+
+// queryItemFilter parses the ?filter= expression of the request. It returns
+// nil when there is none and writes the error response when it is invalid.
+func queryItemFilter(w http.ResponseWriter, r *http.Request) (*itemfilter.Filter, bool) {
+	expr := r.URL.Query().Get("filter")
+	if expr == "" {
+		return nil, true
+	}
+	if len(expr) > maxFilterLength {
+		respondError(w, http.StatusBadRequest, "filter is too long")
+		return nil, false
+	}
+	filter, err := itemfilter.Parse(expr)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid filter: "+err.Error())
+		return nil, false
+	}
+	return filter, true
+}
+
+// filterItems keeps the items matching filter; a nil filter keeps all
+func filterItems(items []models.Item, filter *itemfilter.Filter) []models.Item {
+	if filter == nil {
+		return items
+	}
+	kept := items[:0]
+	for i := range items {
+		if filter.Match(&items[i]) {
+			kept = append(kept, items[i])
+		}
+	}
+	return kept
+}
+
+// filterTierListItems narrows a tier list's tiers to the items matching the
+// request's ?filter=. It writes the error response and returns false on failure.
+func (s *Server) filterTierListItems(w http.ResponseWriter, r *http.Request, tl *models.TierList) bool {
+	filter, ok := queryItemFilter(w, r)
+	if !ok || filter == nil {
+		return ok
+	}
+
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: tl.GameID, TierListID: tl.ID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return false
+	}
+	matched := make(map[string]bool, len(items))
+	for i := range items {
+		if filter.Match(&items[i]) {
+			matched[items[i].ID] = true
+		}
+	}
+	for i := range tl.Tiers {
+		kept := make([]string, 0, len(tl.Tiers[i].Items))
+		for _, id := range tl.Tiers[i].Items {
+			if matched[id] {
+				kept = append(kept, id)
+			}
+		}
+		tl.Tiers[i].Items = kept
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/infobox"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+const (
+	// defaultStatsWeeks and maxStatsWeeks bound the weeks of list creation
+	// a game's stats cover
+	defaultStatsWeeks = 12
+	maxStatsWeeks     = 104
+	// gameStatsLimit caps the most-ranked items and unmatched imports
+	gameStatsLimit = 20
+)
+
+// handleGetGameStats returns the curator overview of a game: items per
+// sheet, lists created per week, the most-ranked items and the items of
+// imported lists that matched nothing. ?weeks= sets how far back the weekly
+// counts go.
+func (s *Server) handleGetGameStats(w http.ResponseWriter, r *http.Request) {
+	weeks := defaultStatsWeeks
+	if v := r.URL.Query().Get("weeks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxStatsWeeks {
+			respondError(w, http.StatusBadRequest, "weeks must be between 1 and "+strconv.Itoa(maxStatsWeeks))
+			return
+		}
+		weeks = n
+	}
+
+	game, err := s.storeFor(r).GetGame(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+
+	stats, err := s.storeFor(r).GetGameStats(game, weeks, gameStatsLimit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to compute game stats")
+		return
+	}
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// metadataKinds are the kinds of metadata the missing metadata report checks
+var metadataKinds = []string{models.MetadataIcon, models.MetadataDescription, models.MetadataLocalization}
+
+// handleGetMissingMetadata lists the catalog items of a game lacking an
+// icon, a description or infobox, or a localized name, grouped by sheet,
+// with the infoboxes the last infobox update could not place. ?missing=
+// takes a comma-separated subset of icon, description and localization.
+func (s *Server) handleGetMissingMetadata(w http.ResponseWriter, r *http.Request) {
+	kinds := metadataKinds
+	if v := r.URL.Query().Get("missing"); v != "" {
+		kinds = nil
+		for _, kind := range strings.Split(v, ",") {
+			kind = strings.TrimSpace(kind)
+			if !slices.Contains(metadataKinds, kind) {
+				respondError(w, http.StatusBadRequest, "missing takes icon, description and localization")
+				return
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	store := s.storeFor(r)
+	game, err := store.GetGame(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	items, err := store.QueryItems(storage.ItemQuery{GameID: game.ID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	unmatched, err := store.GetUnmatchedInfoboxes(game.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch unmatched infoboxes")
+		return
+	}
+
+	report := models.MetadataReport{GameID: game.ID, Sheets: []models.SheetMetadataGaps{}}
+	bySheet := make(map[string]*models.SheetMetadataGaps)
+	sheetFor := func(id string) *models.SheetMetadataGaps {
+		if sheet, ok := bySheet[id]; ok {
+			return sheet
+		}
+		sheet := &models.SheetMetadataGaps{
+			SheetID:            id,
+			Missing:            make(map[string]int, len(kinds)),
+			Gaps:               []models.ItemGaps{},
+			UnmatchedInfoboxes: []models.UnmatchedInfobox{},
+		}
+		if config := game.Sheet(id); config != nil {
+			sheet.Name = config.Name
+		}
+		for _, kind := range kinds {
+			sheet.Missing[kind] = 0
+		}
+		bySheet[id] = sheet
+		return sheet
+	}
+	for _, sheet := range game.Sheets {
+		if !sheet.Virtual {
+			sheetFor(sheet.ID)
+		}
+	}
+	for _, item := range items {
+		sheet := sheetFor(item.SheetID)
+		sheet.Items++
+		var missing []string
+		for _, kind := range kinds {
+			if !hasMetadata(&item, kind) {
+				missing = append(missing, kind)
+				sheet.Missing[kind]++
+			}
+		}
+		if len(missing) > 0 {
+			sheet.Gaps = append(sheet.Gaps, models.ItemGaps{ID: item.ID, Name: item.Name, Missing: missing})
+		}
+	}
+	for _, u := range unmatched {
+		sheet := sheetFor(u.SheetID)
+		sheet.UnmatchedInfoboxes = append(sheet.UnmatchedInfoboxes, u)
+	}
+
+	// Configured sheets come first, in the game's order
+	ids := make([]string, 0, len(bySheet))
+	for _, sheet := range game.Sheets {
+		if _, ok := bySheet[sheet.ID]; ok {
+			ids = append(ids, sheet.ID)
+		}
+	}
+	var rest []string
+	for id := range bySheet {
+		if !slices.Contains(ids, id) {
+			rest = append(rest, id)
+		}
+	}
+	sort.Strings(rest)
+	for _, id := range append(ids, rest...) {
+		gaps := bySheet[id].Gaps
+		sort.SliceStable(gaps, func(i, j int) bool { return len(gaps[i].Missing) > len(gaps[j].Missing) })
+		report.Sheets = append(report.Sheets, *bySheet[id])
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// hasMetadata reports whether an item has a kind of metadata
+func hasMetadata(item *models.Item, kind string) bool {
+	switch kind {
+	case models.MetadataIcon:
+		return item.Icon != ""
+	case models.MetadataDescription:
+		if desc, _ := item.Data["description"].(string); strings.TrimSpace(desc) != "" {
+			return true
+		}
+		return infobox.FromData(item.Data) != nil
+	case models.MetadataLocalization:
+		return item.NameRu != ""
+	}
+	return true
+}
@@ -1,14 +1,20 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
 )
 
 // handleGetGames returns all available games
 func (s *Server) handleGetGames(w http.ResponseWriter, r *http.Request) {
-	games, err := s.store.GetGames()
+	games, err := s.storeFor(r).GetGamesInWorkspace("")
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch games")
 		return
@@ -20,7 +26,7 @@ func (s *Server) handleGetGames(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetGame(w http.ResponseWriter, r *http.Request) {
 	gameID := chi.URLParam(r, "gameID")
 
-	game, err := s.store.GetGame(gameID)
+	game, err := s.storeFor(r).GetGame(gameID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
 		return
@@ -35,18 +41,190 @@ func (s *Server) handleGetGame(w http.ResponseWriter, r *http.Request) {
 
 // handleGetItems returns items for a game
 func (s *Server) handleGetItems(w http.ResponseWriter, r *http.Request) {
+	// ?tag= may be repeated or comma-separated; items must carry every tag
+	var tags []string
+	for _, v := range r.URL.Query()["tag"] {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	filter, ok := queryItemFilter(w, r)
+	if !ok {
+		return
+	}
+
+	// ?sort=name|name_ru|category|data.<key>&order=asc|desc
+	sort := r.URL.Query().Get("sort")
+	if !storage.ValidItemSort(sort) {
+		respondError(w, http.StatusBadRequest, "sort must be name, name_ru, category, or data.<field>")
+		return
+	}
+	order := r.URL.Query().Get("order")
+	if order != "" && order != "asc" && order != "desc" {
+		respondError(w, http.StatusBadRequest, "order must be asc or desc")
+		return
+	}
+
+	// ?view=compact returns a flat array of what the ranking UI draws first,
+	// without Data, which makes up most of a full listing
+	view := r.URL.Query().Get("view")
+	if view != "" && view != "full" && view != "compact" {
+		respondError(w, http.StatusBadRequest, "view must be full or compact")
+		return
+	}
+
+	// ?since= returns only the items changed after it, plus tombstones of the
+	// deleted ones. Clients pass the synced_at of their previous download.
+	syncedAt := time.Now().UTC()
+	var since time.Time
+	fullSync := false
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if view == "compact" {
+			respondError(w, http.StatusBadRequest, "since cannot be combined with view=compact")
+			return
+		}
+		if since, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			respondError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		// Tombstones this old are pruned, so deletions could be missed
+		if since.Before(syncedAt.Add(-storage.ItemTombstoneRetention)) {
+			since, fullSync = time.Time{}, true
+		}
+	}
+
 	gameID := chi.URLParam(r, "gameID")
-	sheetID := r.URL.Query().Get("sheet")
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	var mode string
+	if game != nil {
+		var ok bool
+		if mode, ok = spoilerMode(w, r, game); !ok {
+			return
+		}
+	}
 
-	items, err := s.store.GetItems(gameID, sheetID)
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{
+		GameID:  gameID,
+		SheetID: r.URL.Query().Get("sheet"),
+		Version: r.URL.Query().Get("version"),
+		Tags:    tags,
+		Sort:    sort,
+		Desc:    order == "desc",
+		Since:   since,
+	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
+	items = hideSpoilerItems(filterItems(items, filter), mode)
+	if view == "compact" {
+		compact := make([]models.CompactItem, len(items))
+		for i := range items {
+			compact[i] = items[i].Compact()
+		}
+		respondJSON(w, http.StatusOK, compact)
+		return
+	}
+
+	resp := map[string]interface{}{
 		"items":       items,
 		"total_count": len(items),
+		"synced_at":   syncedAt,
+	}
+	if !since.IsZero() {
+		deleted, err := s.storeFor(r).GetItemTombstones(gameID, r.URL.Query().Get("sheet"), since)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch deleted items")
+			return
+		}
+		resp["deleted"] = deleted
+	}
+	if fullSync {
+		resp["full_sync"] = true
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// handleGetItemTags returns the tags used in a game's catalog
+func (s *Server) handleGetItemTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.storeFor(r).GetItemTags(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tags")
+		return
+	}
+	respondJSON(w, http.StatusOK, tags)
+}
+
+// maxSuggestions caps the items one typeahead request returns
+const maxSuggestions = 50
+
+// handleSuggestItems returns the items a search box should offer for ?q=,
+// matched by the start of their names, aliases or words
+func (s *Server) handleSuggestItems(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSuggestions {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxSuggestions))
+			return
+		}
+		limit = n
+	}
+
+	game, err := s.storeFor(r).GetGame(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	// Spoiler items are left out rather than blurred, since a match would
+	// give them away anyway
+	mode, ok := spoilerMode(w, r, game)
+	if !ok {
+		return
+	}
+
+	items, err := s.storeFor(r).SuggestItems(game.ID, r.URL.Query().Get("q"), limit, mode == "")
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	respondJSON(w, http.StatusOK, items)
+}
+
+// handleGetVersions returns the versions a game's catalog and tier lists can target
+func (s *Server) handleGetVersions(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+
+	versions := game.Versions
+	if versions == nil {
+		versions = []models.GameVersion{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"versions": versions,
+		"current":  game.CurrentVersion(),
 	})
 }
 
@@ -54,7 +232,7 @@ func (s *Server) handleGetItems(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetSheets(w http.ResponseWriter, r *http.Request) {
 	gameID := chi.URLParam(r, "gameID")
 
-	game, err := s.store.GetGame(gameID)
+	game, err := s.storeFor(r).GetGame(gameID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
 		return
@@ -66,3 +244,41 @@ func (s *Server) handleGetSheets(w http.ResponseWriter, r *http.Request) {
 
 	respondJSON(w, http.StatusOK, game.Sheets)
 }
+
+// handleGetCommunityAggregates returns the community consensus for a sheet
+func (s *Server) handleGetCommunityAggregates(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	sheetID := r.URL.Query().Get("sheet")
+	if sheetID == "" {
+		respondError(w, http.StatusBadRequest, "sheet is required")
+		return
+	}
+
+	aggregates, err := s.storeFor(r).GetCommunityAggregates(gameID, sheetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch community rankings")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, aggregates)
+}
+
+// maxLeaderboardEntries limits the contributors listed per leaderboard board
+const maxLeaderboardEntries = 50
+
+// handleGetLeaderboard returns the contributor leaderboards of a game, as of
+// the last aggregates job run
+func (s *Server) handleGetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	leaderboard := models.Leaderboard{GameID: chi.URLParam(r, "gameID")}
+	for board, entries := range map[string]*[]models.LeaderboardEntry{
+		models.LeaderboardMostVoted:  &leaderboard.MostVoted,
+		models.LeaderboardMostForked: &leaderboard.MostForked,
+	} {
+		var err error
+		if *entries, err = s.storeFor(r).GetLeaderboard(leaderboard.GameID, board, maxLeaderboardEntries); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch leaderboard")
+			return
+		}
+	}
+	respondJSON(w, http.StatusOK, leaderboard)
+}
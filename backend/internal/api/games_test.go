@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestLeaderboardRanksForkedAuthors(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	popular := signUp(t, s, "popular@example.com")
+	quiet := signUp(t, s, "quiet@example.com")
+	fan := signUp(t, s, "fan@example.com")
+
+	create := func(token, name string) string {
+		t.Helper()
+		w := serve(s, "POST", "/api/tierlists", token, map[string]interface{}{
+			"game_id": "g", "sheet_id": "main", "name": name, "visibility": "public",
+		}, nil)
+		var tl models.TierList
+		decodeBody(t, w, &tl)
+		return tl.ID
+	}
+	fork := func(token, id string) {
+		t.Helper()
+		if w := serve(s, "POST", "/api/tierlists/"+id+"/fork", token, map[string]string{"name": "Fork"}, nil); w.Code != http.StatusCreated {
+			t.Fatalf("fork %s: %d %s", id, w.Code, w.Body)
+		}
+	}
+	first, second := create(popular, "First"), create(popular, "Second")
+	own := create(quiet, "Own")
+	fork(fan, first)
+	fork(quiet, first)
+	fork(fan, second)
+	fork(quiet, own) // Self-forks do not count
+
+	if _, err := store.RecomputeLeaderboards(); err != nil {
+		t.Fatal(err)
+	}
+	w := serve(s, "GET", "/api/games/g/leaderboard", "", nil, nil)
+	var board models.Leaderboard
+	decodeBody(t, w, &board)
+	if len(board.MostForked) != 1 {
+		t.Fatalf("most_forked = %+v, want only the popular author", board.MostForked)
+	}
+	if e := board.MostForked[0]; e.Rank != 1 || e.Score != 3 || e.ListCount != 2 {
+		t.Errorf("entry = %+v, want rank 1 with 3 forks of 2 lists", e)
+	}
+	if board.MostVoted == nil || len(board.MostVoted) != 0 {
+		t.Errorf("most_voted = %#v, want an empty board", board.MostVoted)
+	}
+}
+
+func TestItemReadsServeSanitizedHTML(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	user := signUp(t, s, "reader@example.com")
+	createRawItems(t, store, "a", "b")
+
+	// create posts body to path and returns the ID of what it made
+	create := func(path string, body interface{}) string {
+		t.Helper()
+		w := serve(s, "POST", path, user, body, nil)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("POST %s: %d %s", path, w.Code, w.Body)
+		}
+		var created struct{ ID string }
+		decodeBody(t, w, &created)
+		return created.ID
+	}
+	list := create("/api/tierlists", map[string]interface{}{
+		"game_id": "g", "sheet_id": "main", "name": "Queue",
+		"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}},
+	})
+	session := create("/api/matchups", models.MatchupSessionCreate{GameID: "g", SheetID: "main"})
+	bracket := create("/api/brackets", models.BracketCreate{GameID: "g", SheetID: "main"})
+	if w := serve(s, "PUT", "/api/me/favorites/items/g/a", user, nil, nil); w.Code != http.StatusOK {
+		t.Fatalf("favorite item: %d %s", w.Code, w.Body)
+	}
+
+	tests := []struct {
+		name, path string
+	}{
+		{"catalog", "/api/games/g/items"},
+		{"ranking queue", "/api/tierlists/" + list + "/next-unranked?count=2"},
+		{"pool", "/api/tierlists/" + list + "/pool"},
+		{"matchup pair", "/api/matchups/" + session + "/pair"},
+		{"bracket match", "/api/brackets/" + bracket + "/match"},
+		{"favorites", "/api/me/favorites"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertSanitized(t, serve(s, "GET", tt.path, user, nil, nil))
+		})
+	}
+
+	// Details parse the infobox rather than serving its HTML
+	w := serve(s, "GET", "/api/games/g/items/a/details", "", nil, nil)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "onclick") {
+		t.Errorf("details: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/meur/tierforge/internal/buildinfo"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// healthTimeout bounds the database probes of a health check
+const healthTimeout = 5 * time.Second
+
+// handleHealth reports the build, uptime and database state. It answers 503
+// when the database cannot be reached.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := s.health(r.Context())
+	respondHealth(w, &health)
+}
+
+// handleDeepHealth extends the health report with write latency, an
+// integrity check, background jobs and the prerender queue. It reads the
+// whole database, so it is limited to admins and HEALTH_TOKEN.
+func (s *Server) handleDeepHealth(w http.ResponseWriter, r *http.Request) {
+	health := s.health(r.Context())
+	if health.Status == models.HealthUnavailable {
+		respondHealth(w, &health)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), healthTimeout)
+	defer cancel()
+	latency, err := s.storeFor(r).PingWriter()
+	if err != nil {
+		health.Status = models.HealthDegraded
+		health.Database.Error = err.Error()
+	} else {
+		ms := milliseconds(latency)
+		health.Database.WriteLatencyMS = &ms
+	}
+	integrity, err := s.storeFor(r).QuickCheck(ctx)
+	if err != nil {
+		integrity = err.Error()
+	}
+	health.Database.Integrity = integrity
+	if integrity != "ok" {
+		health.Status = models.HealthDegraded
+	}
+
+	jobs, err := s.scheduler.Status()
+	if err != nil {
+		health.Status = models.HealthDegraded
+	}
+	for _, job := range jobs {
+		if job.LastError != "" {
+			health.Status = models.HealthDegraded
+		}
+	}
+	health.Jobs = jobs
+	pending := s.prerender.Pending()
+	health.PrerenderQueue = &pending
+
+	respondHealth(w, &health)
+}
+
+// health runs the checks shared by both health endpoints
+func (s *Server) health(ctx context.Context) models.Health {
+	health := models.Health{
+		Status:        models.HealthOK,
+		Build:         buildinfo.Get(),
+		UptimeSeconds: int64(time.Since(s.started).Seconds()),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	latency, err := s.store.Ping(ctx)
+	if err != nil {
+		health.Status = models.HealthUnavailable
+		health.Database.Error = err.Error()
+		return health
+	}
+	health.Database.OK = true
+	health.Database.LatencyMS = milliseconds(latency)
+	health.Database.WALBytes = s.store.WALSize()
+
+	applied, expected, err := s.store.SchemaVersion()
+	if err != nil {
+		health.Status = models.HealthDegraded
+		health.Database.Error = err.Error()
+		return health
+	}
+	health.Database.SchemaVersion, health.Database.ExpectedSchemaVersion = applied, expected
+	switch {
+	case applied < expected:
+		health.Database.Migrations = models.MigrationsBehind
+		health.Status = models.HealthDegraded
+	case applied > expected:
+		health.Database.Migrations = models.MigrationsAhead
+		health.Status = models.HealthDegraded
+	default:
+		health.Database.Migrations = models.MigrationsCurrent
+	}
+	return health
+}
+
+func respondHealth(w http.ResponseWriter, health *models.Health) {
+	w.Header().Set("Cache-Control", "no-store")
+	status := http.StatusOK
+	if health.Status == models.HealthUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	respondJSON(w, status, health)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// requireMonitoring admits admins and requests carrying HEALTH_TOKEN
+func (s *Server) requireMonitoring(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if s.config.HealthToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.config.HealthToken)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		s.requireRole(models.RoleAdmin)(next).ServeHTTP(w, r)
+	})
+}
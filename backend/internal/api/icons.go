@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/icons"
+)
+
+// handleGetIcon serves a normalized icon, optionally resized or re-encoded.
+// Icons are addressed by the hash of their content, so they never change
+// and can be cached for good.
+func (s *Server) handleGetIcon(w http.ResponseWriter, r *http.Request) {
+	hash, ok := icons.ParseFile(chi.URLParam(r, "file"))
+	if !ok {
+		respondError(w, http.StatusNotFound, "Icon not found")
+		return
+	}
+	data, err := s.storeFor(r).GetIcon(hash)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch icon")
+		return
+	}
+	if data == nil {
+		respondError(w, http.StatusNotFound, "Icon not found")
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	s.serveImage(w, r, data, "image/png", "icon/"+hash, hash, false)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+const (
+	// idempotencyTTL is how long a create can be replayed by its key
+	idempotencyTTL = 24 * time.Hour
+	// maxIdempotencyKeyLength bounds the Idempotency-Key header
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentRequestSize bounds the bodies of requests sent with a key
+	maxIdempotentRequestSize = 1 << 20
+)
+
+// idempotencyRecorder keeps a copy of the response written by a handler
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// idempotent lets clients retry a create safely by sending an
+// Idempotency-Key header: a successful response is stored for
+// idempotencyTTL and replayed to later requests with the same key, while
+// failed requests release the key so a retry runs again. Keys are scoped to
+// the signed-in user, or to the visitor's hashed IP.
+func (s *Server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestSize))
+		if err != nil {
+			respondError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := s.visitorHash(r)
+		if user := currentUser(r); user != nil {
+			scope = models.UserVoter(user.ID)
+		}
+		h := sha256.New()
+		io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+		h.Write(body)
+		fingerprint := hex.EncodeToString(h.Sum(nil))
+
+		stored, err := s.storeFor(r).ReserveIdempotencyKey(scope, key, fingerprint, time.Now().Add(idempotencyTTL))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to check Idempotency-Key")
+			return
+		}
+		switch {
+		case stored == nil:
+		case stored.Fingerprint != fingerprint:
+			respondError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			return
+		case stored.Status == 0:
+			respondError(w, http.StatusConflict, "A request with this Idempotency-Key is still in progress")
+			return
+		default:
+			w.Header().Set("Content-Type", stored.ContentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Body)
+			return
+		}
+
+		rec := &idempotencyRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := s.storeFor(r).ReleaseIdempotencyKey(scope, key); err != nil {
+					log.Printf("ERROR: Failed to release idempotency key: %v", err)
+				}
+			}
+		}()
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= 200 && rec.status < 300 {
+			err := s.storeFor(r).CompleteIdempotencyKey(scope, key, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+			if err != nil {
+				log.Printf("ERROR: Failed to store idempotent response: %v", err)
+				return
+			}
+			completed = true
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/imaging"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/render"
+)
+
+// imageKind reads the ?kind= parameter of an image request
+func imageKind(w http.ResponseWriter, r *http.Request) (string, bool) {
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		return render.KindShare, true
+	}
+	if !render.ValidKind(kind) {
+		respondError(w, http.StatusBadRequest, "kind must be share, og or thumb")
+		return "", false
+	}
+	return kind, true
+}
+
+// imageStyle reads the ?theme= and ?background= parameters of an image
+// request. Themed images take their colors from the game's theme.
+func (s *Server) imageStyle(w http.ResponseWriter, r *http.Request, gameID string) (render.Style, bool) {
+	theme := r.URL.Query().Get("theme")
+	if theme != "" && !render.ValidTheme(theme) {
+		respondError(w, http.StatusBadRequest, "theme must be dark or light")
+		return render.Style{}, false
+	}
+	transparent := false
+	switch r.URL.Query().Get("background") {
+	case "":
+	case "transparent":
+		transparent = true
+	default:
+		respondError(w, http.StatusBadRequest, "background must be transparent")
+		return render.Style{}, false
+	}
+	if theme == "" && !transparent {
+		return render.DefaultStyle, true
+	}
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return render.Style{}, false
+	}
+	var gameTheme *models.GameTheme
+	if game != nil {
+		gameTheme = game.Theme
+	}
+	return render.ThemeStyle(gameTheme, theme, transparent), true
+}
+
+// serveTierListImage writes the cached image of tl, rendering it if needed
+func (s *Server) serveTierListImage(w http.ResponseWriter, r *http.Request, tl *models.TierList) {
+	kind, ok := imageKind(w, r)
+	if !ok {
+		return
+	}
+	if tl == nil || !render.Shareable(tl) {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	style, ok := s.imageStyle(w, r, tl.GameID)
+	if !ok {
+		return
+	}
+	data, err := render.Cached(r.Context(), s.store, tl, kind, style)
+	if err != nil {
+		log.Printf("ERROR: Failed to render tier list %s: %v", tl.ID, err)
+		respondError(w, http.StatusInternalServerError, "Failed to render tier list")
+		return
+	}
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	key := "tierlist/" + tl.ID + "/" + style.CacheKind(kind) + "/" + strconv.FormatInt(tl.UpdatedAt.UnixNano(), 10)
+	s.serveImage(w, r, data, "image/png", key, "", !style.Transparent)
+}
+
+// serveImage writes the image data, or the variant asked for with ?w= and
+// ?format=. key identifies the source image in the variant cache and must
+// change whenever data does. etag, when set, tags the source image; opaque
+// images may be sent as JPEG.
+func (s *Server) serveImage(w http.ResponseWriter, r *http.Request, data []byte, contentType, key, etag string, opaque bool) {
+	v, err := imaging.ParseVariant(r, opaque)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.EqualFold(r.URL.Query().Get("format"), imaging.Auto) {
+		w.Header().Add("Vary", "Accept")
+	}
+	if v.Original() {
+		if etag != "" {
+			w.Header().Set("ETag", `"`+etag+`"`)
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(data)
+		return
+	}
+
+	cacheKey := key + "@" + v.Key()
+	variant := s.variants.Get(cacheKey)
+	if variant == nil {
+		if variant, err = imaging.Encode(data, v); err != nil {
+			log.Printf("ERROR: Failed to encode %s as %s: %v", key, v.Key(), err)
+			respondError(w, http.StatusInternalServerError, "Failed to encode image")
+			return
+		}
+		if err := s.variants.Put(cacheKey, variant); err != nil {
+			log.Printf("WARNING: Failed to cache image variant: %v", err)
+		}
+	}
+	if etag != "" {
+		w.Header().Set("ETag", `"`+etag+"-"+v.Key()+`"`)
+	}
+	contentType = v.ContentType()
+	if contentType == "" {
+		contentType = http.DetectContentType(variant)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(variant)
+}
+
+// handleGetTierListImage returns a PNG of a published tier list
+func (s *Server) handleGetTierListImage(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.storeFor(r).GetTierList(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	s.serveTierListImage(w, r, tl)
+}
+
+// handleGetTierListImageByCode returns a PNG of a tier list by share code,
+// the URL link unfurls use
+func (s *Server) handleGetTierListImageByCode(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.storeFor(r).GetTierListByShareCode(chi.URLParam(r, "code"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil && s.redirectShareCode(w, r) {
+		return
+	}
+	s.serveTierListImage(w, r, tl)
+}
+
+// prerenderTierList queues share images of tl for background rendering
+func (s *Server) prerenderTierList(tl *models.TierList) {
+	if tl != nil && render.Shareable(tl) {
+		s.prerender.Enqueue(tl.ID)
+	}
+}
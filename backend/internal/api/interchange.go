@@ -0,0 +1,210 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/interchange"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+	"github.com/meur/tierforge/internal/slug"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxTierListDocumentSize caps an uploaded tier list document, in bytes
+const maxTierListDocumentSize = 1 << 20
+
+// handleExportTierListDocument downloads a tier list in the interchange
+// format, referring to items by name and slug
+func (s *Server) handleExportTierListDocument(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.viewableTierList(r, chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	if !s.hideTierListSpoilers(w, r, tl) {
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(tl.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: tl.GameID, SheetID: tl.SheetID, TierListID: tl.ID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	names := make(map[string]string, len(items))
+	for _, item := range items {
+		names[item.ID] = item.Name
+	}
+
+	doc := models.TierListDocument{
+		Format:        models.TierListFormat,
+		FormatVersion: models.TierListFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Game:          models.GameRef{Slug: game.ID, Name: game.Name},
+		SheetID:       tl.SheetID,
+		GameVersion:   tl.GameVersion,
+		Name:          tl.Name,
+		Tiers:         make([]models.DocumentTier, 0, len(tl.Tiers)),
+	}
+	for _, t := range ranking.SortedTiers(tl.Tiers) {
+		tier := models.DocumentTier{ID: t.ID, Name: t.Name, Color: t.Color, Order: t.Order, Max: t.Max, Points: t.Points, Items: []models.ItemRef{}}
+		for _, id := range t.Items {
+			// Items deleted from the catalog cannot be referenced by name
+			if name, ok := names[id]; ok {
+				tier.Items = append(tier.Items, models.ItemRef{Slug: slug.Make(name), Name: name})
+			}
+		}
+		doc.Tiers = append(doc.Tiers, tier)
+	}
+
+	s.recordEvent(r, models.EventExportUsed, tl.GameID, map[string]interface{}{"format": "tierlist"})
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tierlist-%s.tierforge.json"`, tl.ShareCode))
+	respondJSON(w, http.StatusOK, doc)
+}
+
+// handleImportTierList creates a tier list from an interchange document of
+// any supported version. Items are matched by slug, then by name, with
+// aliases tried after real names; items matching nothing are reported and
+// left out.
+func (s *Server) handleImportTierList(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTierListDocumentSize))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	doc, err := interchange.DecodeTierList(data)
+	if errors.Is(err, interchange.ErrInvalidJSON) {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	game, err := s.resolveGameRef(doc.Game)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusUnprocessableEntity, "Unknown game: "+doc.Game.Slug)
+		return
+	}
+	if game.Sheet(doc.SheetID) == nil {
+		respondError(w, http.StatusUnprocessableEntity, "Unknown sheet: "+doc.SheetID)
+		return
+	}
+	version := doc.GameVersion
+	if !game.HasVersion(version) {
+		version = game.CurrentVersion()
+	}
+
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: game.ID, SheetID: doc.SheetID, Version: version})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	bySlug := make(map[string]string, len(items))
+	byName := make(map[string]string, len(items))
+	for _, item := range items {
+		if key := slug.Make(item.Name); bySlug[key] == "" {
+			bySlug[key] = item.ID
+		}
+		if name := strings.ToLower(item.Name); byName[name] == "" {
+			byName[name] = item.ID
+		}
+	}
+	// Aliases match what other sites call an item, after every real name
+	for _, item := range items {
+		for _, alias := range item.Aliases {
+			if key := slug.Make(alias); bySlug[key] == "" {
+				bySlug[key] = item.ID
+			}
+			if name := strings.ToLower(alias); byName[name] == "" {
+				byName[name] = item.ID
+			}
+		}
+	}
+
+	result := models.TierListImportResult{Unresolved: []models.ItemRef{}}
+	req := models.TierListCreate{
+		GameID:      game.ID,
+		SheetID:     doc.SheetID,
+		Name:        doc.Name,
+		GameVersion: version,
+		Tiers:       make([]models.Tier, 0, len(doc.Tiers)),
+	}
+	placed := make(map[string]bool)
+	for i, t := range doc.Tiers {
+		tier := models.Tier{ID: t.ID, Name: t.Name, Color: t.Color, Order: t.Order, Max: t.Max, Points: t.Points, Items: []string{}}
+		if tier.ID == "" {
+			tier.ID = fmt.Sprintf("tier-%d", i+1)
+		}
+		for _, ref := range t.Items {
+			id := bySlug[ref.Slug]
+			if id == "" {
+				id = byName[strings.ToLower(ref.Name)]
+			}
+			if id == "" {
+				result.Unresolved = append(result.Unresolved, ref)
+				continue
+			}
+			if !placed[id] {
+				placed[id] = true
+				tier.Items = append(tier.Items, id)
+			}
+		}
+		req.Tiers = append(req.Tiers, tier)
+	}
+
+	tierList, ok := s.createTierList(w, r, &req, "import")
+	if !ok {
+		return
+	}
+	if len(result.Unresolved) > 0 {
+		// Counted for the curators' stats, as items the catalog may lack
+		if err := s.storeFor(r).RecordUnmatchedImports(game.ID, doc.SheetID, result.Unresolved); err != nil {
+			log.Printf("ERROR: Failed to record unmatched imports for %s: %v", game.ID, err)
+		}
+	}
+	result.TierList = tierList
+	respondJSON(w, http.StatusCreated, result)
+}
+
+// resolveGameRef finds the game a document refers to, by slug and then by
+// the slug of its name, or returns nil
+func (s *Server) resolveGameRef(ref models.GameRef) (*models.Game, error) {
+	if ref.Slug != "" {
+		game, err := s.store.GetGame(ref.Slug)
+		if err != nil || game != nil {
+			return game, err
+		}
+	}
+	games, err := s.store.GetGames()
+	if err != nil {
+		return nil, err
+	}
+	want := slug.Make(ref.Name)
+	for i := range games {
+		if want != "" && slug.Make(games[i].Name) == want {
+			return &games[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// tierListLocked rejects edits to a tier list its owner has locked, which
+// stays read-only for everyone until unlocked. It writes the error response
+// itself.
+func tierListLocked(w http.ResponseWriter, tl *models.TierList) bool {
+	if tl.LockedAt == nil {
+		return false
+	}
+	respondError(w, http.StatusConflict, "This tier list is locked; unlock it to edit")
+	return true
+}
+
+// ownedTierList loads a tier list for its owner, writing the error response
+// itself
+func (s *Server) ownedTierList(w http.ResponseWriter, r *http.Request) (*models.TierList, bool) {
+	tl, err := s.storeFor(r).GetTierList(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return nil, false
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return nil, false
+	}
+	if !isTierListOwner(r, tl) {
+		respondError(w, http.StatusForbidden, "Only the owner can lock or unlock this tier list")
+		return nil, false
+	}
+	return tl, true
+}
+
+// handleLockTierList makes a tier list read-only, such as a final answer
+// submitted to a contest or community vote. Shares show when it was locked.
+func (s *Server) handleLockTierList(w http.ResponseWriter, r *http.Request) {
+	s.setTierListLock(w, r, true)
+}
+
+// handleUnlockTierList makes a locked tier list editable again
+func (s *Server) handleUnlockTierList(w http.ResponseWriter, r *http.Request) {
+	s.setTierListLock(w, r, false)
+}
+
+func (s *Server) setTierListLock(w http.ResponseWriter, r *http.Request, locked bool) {
+	tl, ok := s.ownedTierList(w, r)
+	if !ok {
+		return
+	}
+	var err error
+	if locked {
+		err = s.storeFor(r).LockTierList(tl.ID)
+	} else {
+		err = s.storeFor(r).UnlockTierList(tl.ID)
+	}
+	if err != nil {
+		respondWriteError(w, err, "Failed to update tier list")
+		return
+	}
+
+	updated, err := s.storeFor(r).GetTierList(tl.ID)
+	if err != nil || updated == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	setScore(updated)
+	s.prerenderTierList(updated)
+	respondJSON(w, http.StatusOK, updated)
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// handleCreateMatchupSession starts a head-to-head ranking session for a sheet
+func (s *Server) handleCreateMatchupSession(w http.ResponseWriter, r *http.Request) {
+	var req models.MatchupSessionCreate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.GameID == "" || req.SheetID == "" {
+		respondError(w, http.StatusBadRequest, "game_id and sheet_id are required")
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(req.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusBadRequest, "Invalid game_id")
+		return
+	}
+	if req.GameVersion == "" {
+		req.GameVersion = game.CurrentVersion()
+	} else if !game.HasVersion(req.GameVersion) {
+		respondError(w, http.StatusBadRequest, "Invalid game_version")
+		return
+	}
+
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: req.GameID, SheetID: req.SheetID, Version: req.GameVersion})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	if len(items) < 2 {
+		respondError(w, http.StatusBadRequest, "The sheet needs at least two items")
+		return
+	}
+
+	var authorID *string
+	if user := currentUser(r); user != nil {
+		authorID = &user.ID
+	}
+	session, err := s.storeFor(r).CreateMatchupSession(&req, authorID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to start matchup session")
+		return
+	}
+	respondJSON(w, http.StatusCreated, session)
+}
+
+// matchupSession loads the session in the URL, writing the error response
+// itself when it is missing or belongs to another user
+func (s *Server) matchupSession(w http.ResponseWriter, r *http.Request) (*models.MatchupSession, bool) {
+	session, err := s.storeFor(r).GetMatchupSession(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch matchup session")
+		return nil, false
+	}
+	if session == nil {
+		respondError(w, http.StatusNotFound, "Matchup session not found")
+		return nil, false
+	}
+	if session.AuthorID != nil {
+		user := currentUser(r)
+		if user == nil || user.ID != *session.AuthorID {
+			respondError(w, http.StatusForbidden, "This matchup session belongs to another user")
+			return nil, false
+		}
+	}
+	return session, true
+}
+
+// sessionItems returns the items a session ranks, keyed by ID
+func (s *Server) sessionItems(session *models.MatchupSession) (map[string]models.Item, error) {
+	items, err := s.store.QueryItems(storage.ItemQuery{
+		GameID:  session.GameID,
+		SheetID: session.SheetID,
+		Version: session.GameVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]models.Item, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	return byID, nil
+}
+
+// handleGetMatchupSession returns a session with the current standings
+func (s *Server) handleGetMatchupSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.matchupSession(w, r)
+	if !ok {
+		return
+	}
+
+	results, err := s.storeFor(r).GetMatchupResults(session.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch results")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"session":   session,
+		"standings": ranking.BradleyTerry(results),
+	})
+}
+
+// handleGetMatchupPair serves the next two items to choose between
+func (s *Server) handleGetMatchupPair(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.matchupSession(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := s.sessionItems(session)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	results, err := s.storeFor(r).GetMatchupResults(session.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch results")
+		return
+	}
+
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	a, b, found := ranking.NextPair(ids, results, rand.IntN)
+	if !found {
+		respondError(w, http.StatusConflict, "The sheet needs at least two items")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.MatchupPair{A: items[a], B: items[b]})
+}
+
+// handlePostMatchupResult records which item of a pair the user preferred
+func (s *Server) handlePostMatchupResult(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.matchupSession(w, r)
+	if !ok {
+		return
+	}
+
+	var result models.MatchupResult
+	if err := decodeJSON(r, &result); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if result.WinnerID == "" || result.LoserID == "" || result.WinnerID == result.LoserID {
+		respondError(w, http.StatusBadRequest, "winner_id and loser_id must be two different items")
+		return
+	}
+
+	items, err := s.sessionItems(session)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	if _, ok := items[result.WinnerID]; !ok {
+		respondError(w, http.StatusBadRequest, "Unknown winner_id")
+		return
+	}
+	if _, ok := items[result.LoserID]; !ok {
+		respondError(w, http.StatusBadRequest, "Unknown loser_id")
+		return
+	}
+
+	if err := s.storeFor(r).AddMatchupResult(session.ID, &result); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record result")
+		return
+	}
+	respondJSON(w, http.StatusCreated, map[string]int{"comparisons": session.Comparisons + 1})
+}
+
+// handleCreateMatchupTierList turns the session standings into a tier list
+// using the game's default tiers
+func (s *Server) handleCreateMatchupTierList(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.matchupSession(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	name, err := s.cleanText("name", req.Name, maxTierListNameLength)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := s.storeFor(r).GetMatchupResults(session.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch results")
+		return
+	}
+	if len(results) == 0 {
+		respondError(w, http.StatusConflict, "Pick at least one matchup first")
+		return
+	}
+	game, err := s.storeFor(r).GetGame(session.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+
+	scores := make(map[string]float64)
+	for _, st := range ranking.BradleyTerry(results) {
+		scores[st.ItemID] = st.Score
+	}
+	defaults := game.TierTemplate(session.SheetID)
+	template := make([]models.Tier, 0, len(defaults))
+	for _, t := range defaults {
+		template = append(template, models.Tier{ID: t.ID, Name: t.Name, Color: t.Color, Order: t.Order})
+	}
+
+	create := models.TierListCreate{
+		GameID:        session.GameID,
+		SheetID:       session.SheetID,
+		Name:          name,
+		Tiers:         ranking.Bucket(scores, template),
+		GameVersion:   session.GameVersion,
+		Status:        models.TierListPublished,
+		AuthorID:      session.AuthorID,
+		CreatorIP:     clientIP(r),
+		CreatorDevice: deviceBanHash(r),
+	}
+	var tierList *models.TierList
+	err = s.storeFor(r).WithTx(r.Context(), func(tx *storage.Store) error {
+		var err error
+		if tierList, err = tx.CreateTierList(&create); err != nil {
+			return err
+		}
+		if err := tx.SetMatchupTierList(session.ID, tierList.ID); err != nil {
+			return err
+		}
+		s.recordTierListCreated(tx, r, tierList, "matchups")
+		return nil
+	})
+	if err != nil {
+		respondWriteError(w, err, "Failed to create tier list")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, tierList)
+}
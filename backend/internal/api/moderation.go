@@ -0,0 +1,322 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+var validReportReasons = map[string]bool{
+	models.ReportReasonSpam:      true,
+	models.ReportReasonOffensive: true,
+	models.ReportReasonOther:     true,
+}
+
+// rejectBanned blocks write requests from banned IP addresses, accounts and
+// devices. The admin API is exempt so moderators can always lift bans. IPs
+// come from clientIP, which only honors forwarding headers from trusted
+// proxies, so a banned client cannot dodge the ban with a forged header.
+func (s *Server) rejectBanned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/api/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID := ""
+		if user := currentUser(r); user != nil {
+			userID = user.ID
+		}
+		ban, err := s.storeFor(r).MatchBan(clientIP(r), userID, deviceBanHash(r))
+		if err != nil {
+			log.Printf("ERROR: Failed to check bans: %v", err)
+		}
+		if ban != nil {
+			msg := "You are banned from making changes"
+			if ban.ExpiresAt != nil {
+				msg += " until " + ban.ExpiresAt.UTC().Format(time.RFC3339)
+			}
+			respondError(w, http.StatusForbidden, msg)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleReportTierList lets a visitor flag a tier list for moderation
+func (s *Server) handleReportTierList(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.ReportCreate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Reason = strings.ToLower(strings.TrimSpace(req.Reason))
+	if !validReportReasons[req.Reason] {
+		respondError(w, http.StatusBadRequest, "reason must be one of spam, offensive, other")
+		return
+	}
+	if strings.TrimSpace(req.Details) != "" {
+		details, err := s.cleanText("details", req.Details, maxReportDetails)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		req.Details = details
+	}
+
+	tierList, err := s.storeFor(r).GetTierList(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tierList == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+
+	if _, err := s.storeFor(r).CreateReport(id, s.visitorKey(r, id), &req); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to submit report")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]string{"status": "reported"})
+}
+
+// handleGetReports returns the moderation queue
+func (s *Server) handleGetReports(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "open"
+	}
+
+	reports, err := s.storeFor(r).GetReports(status)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch reports")
+		return
+	}
+	respondJSON(w, http.StatusOK, reports)
+}
+
+// handleSetTierListHidden returns a handler that hides or restores a tier list
+func (s *Server) handleSetTierListHidden(hidden bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+
+		existing, err := s.storeFor(r).GetTierList(id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+			return
+		}
+		if existing == nil {
+			respondError(w, http.StatusNotFound, "Tier list not found")
+			return
+		}
+
+		if err := s.storeFor(r).SetTierListHidden(id, hidden); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to update tier list")
+			return
+		}
+
+		action := "tierlist.unhide"
+		if hidden {
+			action = "tierlist.hide"
+			if err := s.storeFor(r).ResolveReports(id); err != nil {
+				log.Printf("ERROR: Failed to resolve reports for %s: %v", id, err)
+			}
+		}
+		s.audit(r, action, "tierlist", id, existing.Name)
+
+		respondJSON(w, http.StatusOK, map[string]bool{"is_hidden": hidden})
+	}
+}
+
+// handleAdminDeleteTierList removes a tier list as a moderation action
+func (s *Server) handleAdminDeleteTierList(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	existing, err := s.storeFor(r).GetTierList(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+
+	if err := s.storeFor(r).DeleteTierList(id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete tier list")
+		return
+	}
+	s.audit(r, "tierlist.delete", "tierlist", id, existing.Name)
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleBanTierListAuthor bans the author, creator IP and creator device of
+// a tier list
+func (s *Server) handleBanTierListAuthor(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		Reason    string     `json:"reason"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if r.ContentLength > 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	existing, err := s.storeFor(r).GetTierList(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+
+	bans := make([]models.Ban, 0, 3)
+	if existing.AuthorID != nil {
+		bans = append(bans, models.Ban{Kind: models.BanKindAuthor, Value: *existing.AuthorID})
+	}
+	ip, device, err := s.storeFor(r).GetTierListCreator(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if ip != "" {
+		bans = append(bans, models.Ban{Kind: models.BanKindIP, Value: ip})
+	}
+	if device != "" {
+		bans = append(bans, models.Ban{Kind: models.BanKindDevice, Value: device})
+	}
+	if len(bans) == 0 {
+		respondError(w, http.StatusUnprocessableEntity, "Tier list has no known author, IP or device to ban")
+		return
+	}
+
+	for i := range bans {
+		bans[i].Reason, bans[i].ExpiresAt, bans[i].CreatedBy = req.Reason, req.ExpiresAt, auditActor(r)
+		if err := s.storeFor(r).CreateBan(&bans[i]); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to create ban")
+			return
+		}
+		s.audit(r, "ban.create", bans[i].Kind, bans[i].Value, banDetails(&bans[i], "tierlist "+id+": "))
+	}
+	if err := s.storeFor(r).ResolveReports(id); err != nil {
+		log.Printf("ERROR: Failed to resolve reports for %s: %v", id, err)
+	}
+
+	respondJSON(w, http.StatusCreated, bans)
+}
+
+// banDetails describes a ban for the audit log
+func banDetails(ban *models.Ban, prefix string) string {
+	details := prefix + ban.Reason
+	if ban.ExpiresAt != nil {
+		details += " (until " + ban.ExpiresAt.UTC().Format(time.RFC3339) + ")"
+	}
+	return details
+}
+
+// handleGetBans returns all bans and allow list entries
+func (s *Server) handleGetBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := s.storeFor(r).GetBans()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch bans")
+		return
+	}
+	respondJSON(w, http.StatusOK, bans)
+}
+
+// handleCreateBan bans an IP address or range, account or device directly,
+// or exempts addresses from IP bans. Creating an existing ban updates it.
+func (s *Server) handleCreateBan(w http.ResponseWriter, r *http.Request) {
+	var ban models.Ban
+	if err := decodeJSON(r, &ban); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	ban.Value = strings.TrimSpace(ban.Value)
+	if ban.Value == "" {
+		respondError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+	switch ban.Kind {
+	case models.BanKindAuthor, models.BanKindDevice:
+	case models.BanKindIP, models.BanKindAllowIP:
+		value, ok := normalizeBanIP(ban.Value)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "value must be an IP address or CIDR range")
+			return
+		}
+		ban.Value = value
+	default:
+		respondError(w, http.StatusBadRequest, "kind must be ip, author, device or allow_ip")
+		return
+	}
+	if ban.ExpiresAt != nil && !ban.ExpiresAt.After(time.Now()) {
+		respondError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+	ban.ID = ""
+	ban.CreatedBy = auditActor(r)
+
+	if err := s.storeFor(r).CreateBan(&ban); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create ban")
+		return
+	}
+	s.audit(r, "ban.create", ban.Kind, ban.Value, banDetails(&ban, ""))
+
+	respondJSON(w, http.StatusCreated, ban)
+}
+
+// normalizeBanIP returns the canonical form of an address or CIDR range,
+// masking host bits off ranges
+func normalizeBanIP(value string) (string, bool) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return "", false
+		}
+		return prefix.Masked().String(), true
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return "", false
+	}
+	return addr.Unmap().String(), true
+}
+
+// handleDeleteBan lifts a ban
+func (s *Server) handleDeleteBan(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := s.storeFor(r).DeleteBan(id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete ban")
+		return
+	}
+	s.audit(r, "ban.delete", "ban", id, "")
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
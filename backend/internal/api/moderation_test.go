@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/meur/tierforge/internal/config"
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestIPBanIgnoresForgedForwardingHeaders(t *testing.T) {
+	s, store := newTestServer(t, func(cfg *config.Config) {
+		cfg.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	})
+	if err := store.CreateBan(&models.Ban{Kind: models.BanKindIP, Value: "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+	create := map[string]interface{}{"game_id": "g", "sheet_id": "main", "name": "L"}
+
+	tests := []struct {
+		name       string
+		peer       string
+		forwarded  string
+		wantBanned bool
+	}{
+		{"banned peer", "192.0.2.1:1234", "", true},
+		{"banned peer forging a forwarded address", "192.0.2.1:1234", "198.51.100.9", true},
+		{"banned peer forging a real ip", "192.0.2.1:1234", "", true},
+		{"trusted proxy forwarding the banned client", "10.0.0.2:1234", "192.0.2.1", true},
+		{"trusted proxy forwarding another client", "10.0.0.2:1234", "198.51.100.9", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, "POST", "/api/tierlists", "", create, func(r *http.Request) {
+				r.RemoteAddr = tt.peer
+				if tt.forwarded != "" {
+					r.Header.Set("X-Forwarded-For", tt.forwarded)
+				}
+				r.Header.Set("X-Real-IP", "198.51.100.10")
+			})
+			if banned := w.Code == http.StatusForbidden; banned != tt.wantBanned {
+				t.Errorf("status %d %s, want banned = %v", w.Code, w.Body, tt.wantBanned)
+			}
+		})
+	}
+}
+
+func TestHiddenTierListIsNotServedByID(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	other := signUp(t, s, "other@example.com")
+
+	w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+		"game_id": "g", "sheet_id": "main", "name": "L", "status": "published", "visibility": "public",
+	}, nil)
+	var tl models.TierList
+	decodeBody(t, w, &tl)
+	if err := store.SetTierListHidden(tl.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"anonymous", "", http.StatusNotFound},
+		{"another user", other, http.StatusNotFound},
+		{"owner", owner, http.StatusOK},
+	} {
+		if w := serve(s, "GET", "/api/tierlists/"+tl.ID, tt.token, nil, nil); w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
+
+func TestHiddenTierListPresenceIsWithheld(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+
+	w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+		"game_id": "g", "sheet_id": "main", "name": "L", "status": "published", "visibility": "public",
+	}, nil)
+	var tl models.TierList
+	decodeBody(t, w, &tl)
+	if err := store.SetTierListHidden(tl.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := serve(s, "GET", "/api/tierlists/"+tl.ID+"/presence", "", nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("anonymous: status %d, want 404", w.Code)
+	}
+	if w := serve(s, "GET", "/api/tierlists/"+tl.ID+"/presence", owner, nil, nil); w.Code != http.StatusOK {
+		t.Errorf("owner: status %d, want 200", w.Code)
+	}
+}
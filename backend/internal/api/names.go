@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// How createTierList handles a name the author already uses on the sheet,
+// chosen by the duplicates query parameter
+const (
+	duplicatesAllow  = "allow"  // Keep the name as is (default)
+	duplicatesSuffix = "suffix" // Rename to the next free "Name (2)"
+	duplicatesReject = "reject" // Answer 409 with suggested names
+)
+
+// maxNameSuggestions bounds the names offered with a duplicate-name conflict
+const maxNameSuggestions = 3
+
+// nameCounterPattern matches a " (2)" style counter at the end of a name
+var nameCounterPattern = regexp.MustCompile(` \((\d+)\)$`)
+
+// checkDuplicateName applies the duplicates mode of r to a new list of a
+// signed-in user, renaming req or rejecting it when the author already has a
+// list with that name on the sheet. Anonymous lists have no owner dashboard
+// and are left alone. It writes the error response itself.
+func (s *Server) checkDuplicateName(w http.ResponseWriter, r *http.Request, req *models.TierListCreate) bool {
+	mode := r.URL.Query().Get("duplicates")
+	switch mode {
+	case "", duplicatesAllow:
+		return true
+	case duplicatesSuffix, duplicatesReject:
+	default:
+		respondError(w, http.StatusBadRequest, "duplicates must be allow, suffix, or reject")
+		return false
+	}
+	if req.AuthorID == nil {
+		return true
+	}
+
+	names, err := s.storeFor(r).TierListNames(*req.AuthorID, req.GameID, req.SheetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check tier list names")
+		return false
+	}
+	taken := make(map[string]bool, len(names))
+	for _, name := range names {
+		taken[strings.ToLower(name)] = true
+	}
+	if !taken[strings.ToLower(req.Name)] {
+		return true
+	}
+
+	suggestions := freeNames(req.Name, taken, maxNameSuggestions)
+	if mode == duplicatesSuffix {
+		req.Name = suggestions[0]
+		return true
+	}
+	respondJSON(w, http.StatusConflict, map[string]interface{}{
+		"error":       "You already have a tier list with this name",
+		"suggestions": suggestions,
+	})
+	return false
+}
+
+// freeNames returns the first n "Name (k)" variants of name not in taken,
+// which holds lowercased names. A counter already ending name is continued.
+func freeNames(name string, taken map[string]bool, n int) []string {
+	base, next := name, 2
+	if m := nameCounterPattern.FindStringSubmatchIndex(name); m != nil {
+		if k, err := strconv.Atoi(name[m[2]:m[3]]); err == nil {
+			base, next = name[:m[0]], k+1
+		}
+	}
+
+	names := make([]string, 0, n)
+	for k := next; len(names) < n; k++ {
+		suffix := fmt.Sprintf(" (%d)", k)
+		candidate := truncateRunes(base, maxTierListNameLength-len(suffix)) + suffix
+		if !taken[strings.ToLower(candidate)] {
+			names = append(names, candidate)
+		}
+	}
+	return names
+}
+
+// truncateRunes cuts s to at most n characters
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:n]))
+}
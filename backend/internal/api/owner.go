@@ -0,0 +1,147 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxBulkTierLists caps how many tier lists one bulk request may touch
+const maxBulkTierLists = 200
+
+// handleGetMyTierLists returns the current user's tier lists, including drafts
+func (s *Server) handleGetMyTierLists(w http.ResponseWriter, r *http.Request) {
+	summaries, err := s.storeFor(r).GetTierListsByAuthor(currentUser(r).ID, r.URL.Query().Get("game"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier lists")
+		return
+	}
+	respondJSON(w, http.StatusOK, summaries)
+}
+
+// decodeBulkRequest reads and validates a bulk request body, writing the
+// error response itself when it fails
+func decodeBulkRequest(w http.ResponseWriter, r *http.Request) (*models.TierListBulkRequest, bool) {
+	var req models.TierListBulkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+	if len(req.IDs) == 0 {
+		respondError(w, http.StatusBadRequest, "ids is required")
+		return nil, false
+	}
+	if len(req.IDs) > maxBulkTierLists {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("At most %d tier lists can be changed at once", maxBulkTierLists))
+		return nil, false
+	}
+	return &req, true
+}
+
+// handleBulkDeleteTierLists deletes several of the current user's tier lists
+func (s *Server) handleBulkDeleteTierLists(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeBulkRequest(w, r)
+	if !ok {
+		return
+	}
+
+	n, err := s.storeFor(r).BulkDeleteTierLists(currentUser(r).ID, req.IDs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete tier lists")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int64{"deleted": n})
+}
+
+// handleBulkSetTierListsVisibility changes the visibility of several of the
+// current user's tier lists. is_public is accepted as public/unlisted.
+func (s *Server) handleBulkSetTierListsVisibility(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeBulkRequest(w, r)
+	if !ok {
+		return
+	}
+	visibility := req.Visibility
+	switch {
+	case visibility != "":
+		if !models.ValidVisibility(visibility) {
+			respondError(w, http.StatusBadRequest, "visibility must be public, unlisted, or private")
+			return
+		}
+	case req.IsPublic == nil:
+		respondError(w, http.StatusBadRequest, "visibility or is_public is required")
+		return
+	case *req.IsPublic:
+		visibility = models.VisibilityPublic
+	default:
+		visibility = models.VisibilityUnlisted
+	}
+
+	n, err := s.storeFor(r).BulkSetTierListsVisibility(currentUser(r).ID, req.IDs, visibility)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update tier lists")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int64{"updated": n})
+}
+
+// handleBulkRetagTierLists adds and removes tags on several of the current
+// user's tier lists
+func (s *Server) handleBulkRetagTierLists(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeBulkRequest(w, r)
+	if !ok {
+		return
+	}
+
+	add, err := s.cleanTags(req.AddTags)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	remove, err := s.cleanTags(req.RemoveTags)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		respondError(w, http.StatusBadRequest, "add_tags or remove_tags is required")
+		return
+	}
+
+	n, err := s.storeFor(r).BulkRetagTierLists(currentUser(r).ID, req.IDs, add, remove, maxTagsPerTierList)
+	if err == storage.ErrTooManyTags {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Tier lists can have at most %d tags", maxTagsPerTierList))
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update tags")
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int64{"updated": n})
+}
+
+// handleClaimTierLists moves tier lists created anonymously, identified by
+// the IDs the client kept locally, to the current account. Only lists created
+// from the same device, or the same IP for lists without one, are claimed.
+// Every ID asked for counts against the account's tier list quota.
+func (s *Server) handleClaimTierLists(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+	req, ok := decodeBulkRequest(w, r)
+	if !ok || !s.checkTierListQuota(w, r, len(req.IDs)) {
+		return
+	}
+
+	n, err := s.storeFor(r).ClaimTierLists(user.ID, req.IDs, deviceBanHash(r), clientIP(r))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to claim tier lists")
+		return
+	}
+	if n > 0 {
+		s.audit(r, "tierlist.claim", "user", user.ID, fmt.Sprintf("%d tier lists", n))
+	}
+	respondJSON(w, http.StatusOK, map[string]int64{"claimed": n})
+}
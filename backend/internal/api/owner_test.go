@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestClaimTierListsNeedsTheCreatingDevice(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	from := func(ip string, cookie *http.Cookie) func(*http.Request) {
+		return func(r *http.Request) {
+			r.RemoteAddr = ip + ":1234"
+			if cookie != nil {
+				r.AddCookie(cookie)
+			}
+		}
+	}
+	deviceCookie := func() *http.Cookie {
+		t.Helper()
+		w := serve(s, "GET", "/api/games", "", nil, nil)
+		for _, c := range w.Result().Cookies() {
+			if c.Name == deviceCookieName {
+				return c
+			}
+		}
+		t.Fatal("no device cookie issued")
+		return nil
+	}
+	create := func(adjust func(*http.Request)) string {
+		t.Helper()
+		w := serve(s, "POST", "/api/tierlists", "", map[string]interface{}{
+			"game_id": "g", "sheet_id": "main", "name": "Anonymous",
+			"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}},
+		}, adjust)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create list: %d %s", w.Code, w.Body)
+		}
+		var tl models.TierList
+		decodeBody(t, w, &tl)
+		return tl.ID
+	}
+	creator, stranger := deviceCookie(), deviceCookie()
+	byDevice := create(from("203.0.113.5", creator))
+	byIP := create(from("203.0.113.5", nil))
+	stored, err := store.GetTierList(byDevice)
+	if err != nil || stored == nil {
+		t.Fatalf("GetTierList = %v, %v", stored, err)
+	}
+
+	tests := []struct {
+		name    string
+		adjust  func(*http.Request)
+		ids     []string
+		claimed int64
+	}{
+		{"stranger", from("198.51.100.7", stranger), []string{byDevice, byIP}, 0},
+		{"other device on the creator's IP", from("203.0.113.5", stranger), []string{byDevice}, 0},
+		{"creating device from another IP", from("198.51.100.7", creator), []string{byDevice, byIP}, 1},
+		{"creating IP for a list without device", from("203.0.113.5", nil), []string{byIP}, 1},
+		{"already claimed", from("203.0.113.5", creator), []string{byDevice, byIP}, 0},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signUp(t, s, fmt.Sprintf("claimer%d@example.com", i))
+			w := serve(s, "POST", "/api/me/claim", token, map[string]interface{}{"ids": tt.ids}, tt.adjust)
+			var resp struct {
+				Claimed int64 `json:"claimed"`
+			}
+			decodeBody(t, w, &resp)
+			if w.Code != http.StatusOK || resp.Claimed != tt.claimed {
+				t.Errorf("claim: %d %s, want %d claimed", w.Code, w.Body, tt.claimed)
+			}
+		})
+	}
+
+	// The stranger's attempt left the list editable by its creator alone
+	w := serve(s, "PUT", "/api/tierlists/"+byDevice, signUp(t, s, "late@example.com"), map[string]string{"name": "Taken"}, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("editing a claimed list as someone else: %d %s, want 403", w.Code, w.Body)
+	}
+}
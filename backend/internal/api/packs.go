@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// requirePacks rejects pack requests when no registry is configured
+func (s *Server) requirePacks(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.packs == nil {
+			respondError(w, http.StatusServiceUnavailable, "Pack registry is not configured")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleGetPacks lists the registry's packs with their install state here
+func (s *Server) handleGetPacks(w http.ResponseWriter, r *http.Request) {
+	index, err := s.packs.Index(r.Context())
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		respondError(w, http.StatusBadGateway, "Failed to fetch pack index")
+		return
+	}
+	installed, err := s.storeFor(r).GetInstalledPacks()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch installed packs")
+		return
+	}
+
+	packs := make([]models.Pack, len(index.Packs))
+	for i, p := range index.Packs {
+		p.Installed = installed[p.ID]
+		packs[i] = p
+	}
+	respondJSON(w, http.StatusOK, packs)
+}
+
+// handleGetPack returns one pack from the registry
+func (s *Server) handleGetPack(w http.ResponseWriter, r *http.Request) {
+	pack, ok := s.lookupPack(w, r)
+	if !ok {
+		return
+	}
+	installed, err := s.storeFor(r).GetInstalledPacks()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch installed packs")
+		return
+	}
+	pack.Installed = installed[pack.ID]
+	respondJSON(w, http.StatusOK, pack)
+}
+
+// lookupPack resolves the pack in the URL, writing the error response itself
+func (s *Server) lookupPack(w http.ResponseWriter, r *http.Request) (*models.Pack, bool) {
+	pack, err := s.packs.Pack(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		respondError(w, http.StatusBadGateway, "Failed to fetch pack index")
+		return nil, false
+	}
+	if pack == nil {
+		respondError(w, http.StatusNotFound, "Pack not found")
+		return nil, false
+	}
+	return pack, true
+}
+
+// handleInstallPack downloads a pack release, verifies it and installs it
+// like an uploaded game bundle
+func (s *Server) handleInstallPack(w http.ResponseWriter, r *http.Request) {
+	pack, ok := s.lookupPack(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.PackInstallRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	version := pack.Latest()
+	if req.Version != "" {
+		version = pack.Version(req.Version)
+	}
+	if version == nil {
+		respondError(w, http.StatusNotFound, "Pack version not found")
+		return
+	}
+
+	bundle, err := s.packs.Download(r.Context(), version)
+	if err != nil {
+		log.Printf("ERROR: Pack %s %s: %v", pack.ID, version.Version, err)
+		respondError(w, http.StatusBadGateway, "Failed to download pack: "+err.Error())
+		return
+	}
+	if bundle.Game.ID != pack.GameID {
+		respondError(w, http.StatusBadGateway, "Pack bundle contains game "+bundle.Game.ID+", expected "+pack.GameID)
+		return
+	}
+
+	result, err := s.installBundle(r, bundle, req.Replace, "pack:"+pack.ID+"@"+version.Version)
+	if errors.Is(err, errInvalidBundle) {
+		respondError(w, http.StatusBadGateway, "Pack bundle is invalid: "+err.Error())
+		return
+	}
+	if errors.Is(err, storage.ErrItemIDConflict) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to install pack")
+		return
+	}
+
+	record := &models.InstalledPack{
+		PackID:      pack.ID,
+		GameID:      pack.GameID,
+		Version:     version.Version,
+		SHA256:      version.SHA256,
+		InstalledAt: time.Now(),
+	}
+	if err := s.storeFor(r).RecordPackInstall(record); err != nil {
+		log.Printf("ERROR: Failed to record install of pack %s: %v", pack.ID, err)
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pack":   record,
+		"import": result,
+	})
+}
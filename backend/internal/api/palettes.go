@@ -0,0 +1,253 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// maxPaletteColors caps the number of colors in a custom palette
+const maxPaletteColors = 12
+
+var paletteIDPattern = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+
+// handleGetPalettes returns all tier color palettes
+func (s *Server) handleGetPalettes(w http.ResponseWriter, r *http.Request) {
+	palettes, err := s.storeFor(r).GetPalettes()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch palettes")
+		return
+	}
+	respondJSON(w, http.StatusOK, palettes)
+}
+
+// handleGetPalette returns a palette by ID
+func (s *Server) handleGetPalette(w http.ResponseWriter, r *http.Request) {
+	palette, err := s.storeFor(r).GetPalette(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch palette")
+		return
+	}
+	if palette == nil {
+		respondError(w, http.StatusNotFound, "Palette not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, palette)
+}
+
+// lookupPalette resolves a palette named in a request, writing the error
+// response itself when it is unknown
+func (s *Server) lookupPalette(w http.ResponseWriter, id string) (*models.Palette, bool) {
+	palette, err := s.store.GetPalette(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch palette")
+		return nil, false
+	}
+	if palette == nil {
+		respondError(w, http.StatusBadRequest, "Unknown palette")
+		return nil, false
+	}
+	return palette, true
+}
+
+// validatePalette checks a custom palette and normalizes its colors to lowercase
+func validatePalette(p *models.Palette) error {
+	if !paletteIDPattern.MatchString(p.ID) {
+		return fmt.Errorf("id must be 1-32 lowercase letters, digits or hyphens")
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(p.Colors) < 2 || len(p.Colors) > maxPaletteColors {
+		return fmt.Errorf("a palette needs between 2 and %d colors", maxPaletteColors)
+	}
+	for i, c := range p.Colors {
+		if !hexColorPattern.MatchString(c) {
+			return fmt.Errorf("colors[%d] must be a #rrggbb color", i)
+		}
+		p.Colors[i] = strings.ToLower(c)
+	}
+	return nil
+}
+
+// handlePutPalette creates or replaces a custom palette
+func (s *Server) handlePutPalette(w http.ResponseWriter, r *http.Request) {
+	var palette models.Palette
+	if err := decodeJSON(r, &palette); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	palette.ID = chi.URLParam(r, "id")
+	if err := validatePalette(&palette); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, ok := s.checkColors(w, palette.Colors); !ok {
+		return
+	}
+
+	before, err := s.storeFor(r).GetPalette(palette.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch palette")
+		return
+	}
+	saved, err := s.storeFor(r).SavePalette(&palette)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save palette")
+		return
+	}
+	if !saved {
+		respondError(w, http.StatusConflict, "Built-in palettes cannot be changed")
+		return
+	}
+
+	after, _ := s.storeFor(r).GetPalette(palette.ID)
+	s.auditChange(r, "palette.save", "palette", palette.ID, "", before, after)
+	respondJSON(w, http.StatusOK, after)
+}
+
+// handleDeletePalette removes a custom palette
+func (s *Server) handleDeletePalette(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	before, err := s.storeFor(r).GetPalette(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch palette")
+		return
+	}
+	if before == nil {
+		respondError(w, http.StatusNotFound, "Palette not found")
+		return
+	}
+	if before.Builtin {
+		respondError(w, http.StatusConflict, "Built-in palettes cannot be deleted")
+		return
+	}
+
+	if _, err := s.storeFor(r).DeletePalette(id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete palette")
+		return
+	}
+	s.auditChange(r, "palette.delete", "palette", id, "", before, nil)
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handlePutDefaultTiers replaces the tiers new tier lists of a game start
+// with, optionally recolored from a palette
+func (s *Server) handlePutDefaultTiers(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	var req models.DefaultTiersUpdate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Tiers) == 0 {
+		req.Tiers = models.DefaultTiers()
+	}
+	if !s.checkDefaultTiers(w, &req) {
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	before := *game
+	game.DefaultTiers = req.Tiers
+	if err := s.storeFor(r).CreateGame(game); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save game")
+		return
+	}
+	s.auditChange(r, "game.update", "game", gameID, gameID, before, game)
+
+	respondJSON(w, http.StatusOK, game)
+}
+
+// handlePutSheetDefaultTiers overrides the game's default tiers for lists of
+// one sheet. An empty list of tiers removes the override.
+func (s *Server) handlePutSheetDefaultTiers(w http.ResponseWriter, r *http.Request) {
+	gameID, sheetID := chi.URLParam(r, "gameID"), chi.URLParam(r, "sheetID")
+
+	var req models.DefaultTiersUpdate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Tiers) > 0 && !s.checkDefaultTiers(w, &req) {
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	before := *game
+	before.Sheets = append([]models.SheetConfig(nil), game.Sheets...)
+	sheet := game.Sheet(sheetID)
+	if sheet == nil {
+		respondError(w, http.StatusNotFound, "Sheet not found")
+		return
+	}
+	sheet.DefaultTiers = req.Tiers
+	if err := s.storeFor(r).CreateGame(game); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save game")
+		return
+	}
+	s.auditChange(r, "game.update", "game", gameID, gameID, before, game)
+
+	respondJSON(w, http.StatusOK, game)
+}
+
+// checkDefaultTiers validates and normalizes default tiers, recoloring them
+// from the requested palette. It writes the error response itself.
+func (s *Server) checkDefaultTiers(w http.ResponseWriter, req *models.DefaultTiersUpdate) bool {
+	seen := make(map[string]bool, len(req.Tiers))
+	for i, t := range req.Tiers {
+		if t.ID == "" || t.Name == "" || seen[t.ID] {
+			respondError(w, http.StatusBadRequest, "Each tier needs a unique id and a name")
+			return false
+		}
+		if t.Max < 0 {
+			respondError(w, http.StatusBadRequest, errNegativeCapacity.Error())
+			return false
+		}
+		if !pointsInRange(t.Points) {
+			respondError(w, http.StatusBadRequest, errTierPoints)
+			return false
+		}
+		seen[t.ID] = true
+		req.Tiers[i].Order = i
+	}
+	if req.Palette != "" {
+		palette, ok := s.lookupPalette(w, req.Palette)
+		if !ok {
+			return false
+		}
+		palette.ApplyDefaults(req.Tiers)
+	}
+	hexes := make([]string, len(req.Tiers))
+	for i, t := range req.Tiers {
+		if !hexColorPattern.MatchString(t.Color) {
+			respondError(w, http.StatusBadRequest, "Tier colors must be #rrggbb colors")
+			return false
+		}
+		hexes[i] = t.Color
+	}
+	_, ok := s.checkColors(w, hexes)
+	return ok
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// pollLocksTiers rejects edits to the tiers of a poll, which only votes may
+// fill. It writes the error response itself.
+func pollLocksTiers(w http.ResponseWriter, tl *models.TierList) bool {
+	if tl.Poll == "" {
+		return false
+	}
+	respondError(w, http.StatusConflict, "The tiers of a poll are set by votes")
+	return true
+}
+
+// handleOpenPoll opens a tier list for voting through its share code
+func (s *Server) handleOpenPoll(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.rankableTierList(w, r)
+	if !ok || tierListLocked(w, tl) {
+		return
+	}
+	switch {
+	case tl.Poll == models.PollOpen:
+		respondError(w, http.StatusConflict, "Voting is already open")
+		return
+	case tl.Poll == models.PollClosed:
+		respondError(w, http.StatusConflict, "Voting on this tier list is closed")
+		return
+	case tl.Status != models.TierListPublished:
+		respondError(w, http.StatusBadRequest, "Publish the tier list before opening it for voting")
+		return
+	case tl.Visibility == models.VisibilityPrivate:
+		respondError(w, http.StatusBadRequest, "Private tier lists cannot be opened for voting")
+		return
+	}
+
+	if err := s.storeFor(r).SetTierListPoll(tl.ID, models.PollOpen, nil); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to open voting")
+		return
+	}
+	s.autosaves.Discard(tl.ID)
+	tl.Poll = models.PollOpen
+	respondJSON(w, http.StatusOK, tl)
+}
+
+// handleClosePoll stops voting and freezes the vote results into the tiers.
+// Capacities and constraints are not enforced on the results.
+func (s *Server) handleClosePoll(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.rankableTierList(w, r)
+	if !ok || tierListLocked(w, tl) {
+		return
+	}
+	if tl.Poll != models.PollOpen {
+		respondError(w, http.StatusConflict, "Voting is not open")
+		return
+	}
+
+	votes, _, err := s.storeFor(r).GetPollVotes(tl.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch votes")
+		return
+	}
+	tiers, _ := ranking.PollResults(tl.Tiers, votes)
+	if err := s.storeFor(r).SetTierListPoll(tl.ID, models.PollClosed, tiers); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to close voting")
+		return
+	}
+
+	updated, _ := s.storeFor(r).GetTierList(tl.ID)
+	if updated != nil {
+		setScore(updated)
+		s.prerenderTierList(updated)
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+// sharedPoll loads the poll behind the share code in the URL, writing the
+// error response itself when there is none
+func (s *Server) sharedPoll(w http.ResponseWriter, r *http.Request) (*models.TierList, bool) {
+	tl, err := s.storeFor(r).GetTierListByShareCode(chi.URLParam(r, "code"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return nil, false
+	}
+	if tl == nil && s.redirectShareCode(w, r) {
+		return nil, false
+	}
+	if tl == nil || tl.Hidden || tl.Status == models.TierListDraft {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return nil, false
+	}
+	if tl.Poll == "" {
+		respondError(w, http.StatusNotFound, "This tier list is not a poll")
+		return nil, false
+	}
+	return tl, true
+}
+
+// handleGetPollResults returns the current outcome of a poll
+func (s *Server) handleGetPollResults(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.sharedPoll(w, r)
+	if !ok {
+		return
+	}
+
+	votes, voters, err := s.storeFor(r).GetPollVotes(tl.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch votes")
+		return
+	}
+	var tallies []models.PollTally
+	tl.Tiers, tallies = ranking.PollResults(tl.Tiers, votes)
+	if !s.hideTierListSpoilers(w, r, tl) {
+		return
+	}
+
+	shown := make(map[string]bool)
+	for _, t := range tl.Tiers {
+		for _, id := range t.Items {
+			shown[id] = true
+		}
+	}
+	result := models.PollResult{Status: tl.Poll, Voters: voters, Tiers: tl.Tiers, Items: make([]models.PollTally, 0, len(tallies))}
+	for _, t := range tallies {
+		if shown[t.ItemID] {
+			result.Items = append(result.Items, t)
+		}
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// handleVotePoll records a visitor's votes. Visitors are told apart by
+// account when signed in, then by device token and then by hashed IP.
+func (s *Server) handleVotePoll(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.sharedPoll(w, r)
+	if !ok {
+		return
+	}
+	if tl.Poll != models.PollOpen || tl.LockedAt != nil {
+		respondError(w, http.StatusConflict, "Voting on this tier list is closed")
+		return
+	}
+	if isBot(r) {
+		respondError(w, http.StatusForbidden, "Automated clients cannot vote")
+		return
+	}
+
+	var ballot models.PollBallot
+	if err := decodeJSON(r, &ballot); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: tl.GameID, SheetID: tl.SheetID, Version: tl.GameVersion, TierListID: tl.ID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	if len(ballot.Votes) == 0 || len(ballot.Votes) > len(items) {
+		respondError(w, http.StatusBadRequest, "votes must hold between one vote and one vote per item")
+		return
+	}
+
+	inSheet := make(map[string]bool, len(items))
+	for _, item := range items {
+		inSheet[item.ID] = true
+	}
+	tierIDs := make(map[string]bool, len(tl.Tiers))
+	for _, t := range tl.Tiers {
+		tierIDs[t.ID] = true
+	}
+	for _, v := range ballot.Votes {
+		if !inSheet[v.ItemID] {
+			respondError(w, http.StatusBadRequest, "Unknown item_id: "+v.ItemID)
+			return
+		}
+		if !tierIDs[v.TierID] {
+			respondError(w, http.StatusBadRequest, "Unknown tier_id: "+v.TierID)
+			return
+		}
+	}
+
+	voter := s.visitorKey(r, tl.ID)
+	if user := currentUser(r); user != nil {
+		voter = models.UserVoter(user.ID)
+	}
+	if err := s.storeFor(r).AddPollVotes(tl.ID, voter, ballot.Votes); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record votes")
+		return
+	}
+	respondJSON(w, http.StatusCreated, map[string]int{"votes": len(ballot.Votes)})
+}
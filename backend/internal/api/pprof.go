@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// profilingRates holds the block and mutex profiling rates last set, which
+// the runtime does not report back
+var profilingRates struct {
+	sync.Mutex
+	BlockRate     int `json:"block_rate"`
+	MutexFraction int `json:"mutex_fraction"`
+}
+
+// setProfilingRates applies block and mutex profiling rates; 0 turns the
+// profile off
+func setProfilingRates(blockRate, mutexFraction int) {
+	profilingRates.Lock()
+	defer profilingRates.Unlock()
+	runtime.SetBlockProfileRate(blockRate)
+	runtime.SetMutexProfileFraction(mutexFraction)
+	profilingRates.BlockRate, profilingRates.MutexFraction = blockRate, mutexFraction
+}
+
+// profilingRoutes serves net/http/pprof. The standard handlers expect to be
+// mounted at /debug/pprof/, so named profiles are routed explicitly.
+func (s *Server) profilingRoutes(r chi.Router) {
+	r.Get("/", pprof.Index)
+	r.Get("/cmdline", pprof.Cmdline)
+	r.Get("/profile", pprof.Profile)
+	r.Get("/symbol", pprof.Symbol)
+	r.Post("/symbol", pprof.Symbol)
+	r.Get("/trace", pprof.Trace)
+	r.Get("/rates", s.handleGetProfilingRates)
+	r.Put("/rates", s.handleSetProfilingRates)
+	r.Get("/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+}
+
+// handleGetProfilingRates returns the current block and mutex profiling rates
+func (s *Server) handleGetProfilingRates(w http.ResponseWriter, r *http.Request) {
+	profilingRates.Lock()
+	defer profilingRates.Unlock()
+	respondJSON(w, http.StatusOK, &profilingRates)
+}
+
+// handleSetProfilingRates turns block and mutex profiling on or off without
+// a restart, for capturing contention while a problem is happening
+func (s *Server) handleSetProfilingRates(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		BlockRate     int `json:"block_rate"`
+		MutexFraction int `json:"mutex_fraction"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.BlockRate < 0 || req.MutexFraction < 0 {
+		respondError(w, http.StatusBadRequest, "block_rate and mutex_fraction must not be negative")
+		return
+	}
+	setProfilingRates(req.BlockRate, req.MutexFraction)
+	s.handleGetProfilingRates(w, r)
+}
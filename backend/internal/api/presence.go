@@ -0,0 +1,302 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// presenceTTL is how long a heartbeat keeps someone present. Clients beat
+// about every 15 seconds, so a missed beat or two is forgiven.
+const presenceTTL = 45 * time.Second
+
+// tierLockTTL is how long a tier lock outlives its last claim. Editors claim
+// the tier again while they keep reordering it.
+const tierLockTTL = 30 * time.Second
+
+// presenceTracker counts who has each tier list open from the heartbeats of
+// open share pages and editors, and which tiers editors have locked. It is
+// kept in memory only; after a restart everyone reappears with their next
+// beat.
+type presenceTracker struct {
+	mu    sync.Mutex
+	lists map[string]map[string]presenceEntry // By tier list ID, then viewer
+	locks map[string]map[string]tierLock      // By tier list ID, then tier ID
+	swept time.Time
+}
+
+type presenceEntry struct {
+	editor models.PresenceEditor
+	edit   bool
+	seen   time.Time
+}
+
+type tierLock struct {
+	viewer string
+	lock   models.TierLock
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{
+		lists: make(map[string]map[string]presenceEntry),
+		locks: make(map[string]map[string]tierLock),
+	}
+}
+
+// Beat marks viewer as having a tier list open and returns its presence
+func (p *presenceTracker) Beat(id, viewer string, entry presenceEntry) models.Presence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sweep()
+	if p.lists[id] == nil {
+		p.lists[id] = make(map[string]presenceEntry)
+	}
+	p.lists[id][viewer] = entry
+	return p.get(id)
+}
+
+// Leave drops viewer from a tier list at once, such as when a page closes,
+// releasing their tier locks
+func (p *presenceTracker) Leave(id, viewer string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.lists[id], viewer)
+	if len(p.lists[id]) == 0 {
+		delete(p.lists, id)
+	}
+	for tierID, held := range p.locks[id] {
+		if held.viewer == viewer {
+			delete(p.locks[id], tierID)
+		}
+	}
+	if len(p.locks[id]) == 0 {
+		delete(p.locks, id)
+	}
+}
+
+// Claim locks a tier for viewer, or extends their lock. ok is false when
+// someone else holds it, and the returned lock is theirs.
+func (p *presenceTracker) Claim(id, tierID, viewer string, editor models.PresenceEditor) (lock models.TierLock, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.sweep()
+	p.prune(id, time.Now())
+	if held, found := p.locks[id][tierID]; found && held.viewer != viewer {
+		return held.lock, false
+	}
+	if p.locks[id] == nil {
+		p.locks[id] = make(map[string]tierLock)
+	}
+	lock = models.TierLock{TierID: tierID, Editor: editor, ExpiresAt: time.Now().Add(tierLockTTL)}
+	p.locks[id][tierID] = tierLock{viewer: viewer, lock: lock}
+	return lock, true
+}
+
+// Release unlocks a tier viewer holds
+func (p *presenceTracker) Release(id, tierID, viewer string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if held, found := p.locks[id][tierID]; found && held.viewer == viewer {
+		delete(p.locks[id], tierID)
+	}
+	if len(p.locks[id]) == 0 {
+		delete(p.locks, id)
+	}
+}
+
+// HeldByOther returns a tier lock on a tier list that someone other than
+// viewer holds. ok is false when there is none.
+func (p *presenceTracker) HeldByOther(id, viewer string) (lock models.TierLock, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prune(id, time.Now())
+	for _, held := range p.locks[id] {
+		if held.viewer != viewer {
+			return held.lock, true
+		}
+	}
+	return models.TierLock{}, false
+}
+
+// Get returns who has a tier list open
+func (p *presenceTracker) Get(id string) models.Presence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.get(id)
+}
+
+func (p *presenceTracker) get(id string) models.Presence {
+	p.prune(id, time.Now())
+	presence := models.Presence{
+		Viewers: len(p.lists[id]),
+		Editors: []models.PresenceEditor{},
+		Locks:   []models.TierLock{},
+	}
+	for _, entry := range p.lists[id] {
+		if entry.edit {
+			presence.Editors = append(presence.Editors, entry.editor)
+		}
+	}
+	for _, held := range p.locks[id] {
+		presence.Locks = append(presence.Locks, held.lock)
+	}
+	sort.Slice(presence.Editors, func(i, j int) bool {
+		return presence.Editors[i].DisplayName < presence.Editors[j].DisplayName
+	})
+	sort.Slice(presence.Locks, func(i, j int) bool {
+		return presence.Locks[i].TierID < presence.Locks[j].TierID
+	})
+	return presence
+}
+
+// sweep prunes every tier list now and then, since lists nobody asks about
+// again would otherwise keep their viewers and locks
+func (p *presenceTracker) sweep() {
+	now := time.Now()
+	if now.Sub(p.swept) <= presenceTTL {
+		return
+	}
+	for id := range p.lists {
+		p.prune(id, now)
+	}
+	for id := range p.locks {
+		p.prune(id, now)
+	}
+	p.swept = now
+}
+
+// prune drops the viewers of a tier list whose last beat has expired, and
+// its expired tier locks
+func (p *presenceTracker) prune(id string, now time.Time) {
+	for viewer, entry := range p.lists[id] {
+		if now.Sub(entry.seen) > presenceTTL {
+			delete(p.lists[id], viewer)
+		}
+	}
+	if len(p.lists[id]) == 0 {
+		delete(p.lists, id)
+	}
+	for tierID, held := range p.locks[id] {
+		if now.After(held.lock.ExpiresAt) {
+			delete(p.locks[id], tierID)
+		}
+	}
+	if len(p.locks[id]) == 0 {
+		delete(p.locks, id)
+	}
+}
+
+// presenceViewer identifies the requester among a tier list's viewers:
+// signed-in users by account, anyone else by salted IP
+func (s *Server) presenceViewer(r *http.Request) string {
+	if user := currentUser(r); user != nil {
+		return "user:" + user.ID
+	}
+	return "visitor:" + s.visitorHash(r)
+}
+
+// presenceEditor names the requester to other editors
+func presenceEditor(r *http.Request) models.PresenceEditor {
+	if user := currentUser(r); user != nil {
+		return models.PresenceEditor{ID: user.ID, DisplayName: user.DisplayName}
+	}
+	return models.PresenceEditor{DisplayName: "Anonymous"}
+}
+
+// visibleTierList loads the tier list in the URL like viewableTierList,
+// writing the error response itself
+func (s *Server) visibleTierList(w http.ResponseWriter, r *http.Request) (*models.TierList, bool) {
+	tl, err := s.viewableTierList(r, chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return nil, false
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return nil, false
+	}
+	return tl, true
+}
+
+// handleGetPresence returns how many people have a tier list open and who
+// is editing it, with the tiers locked, for share pages showing "12 people
+// viewing"
+func (s *Server) handleGetPresence(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.visibleTierList(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, s.presence.Get(tl.ID))
+}
+
+// handlePresenceBeat records that the requester has a tier list open, and
+// whether they are editing it, and returns its presence. Only those who may
+// edit the list are shown as editing, and nobody while it is locked.
+func (s *Server) handlePresenceBeat(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.visibleTierList(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Editing bool `json:"editing"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	entry := presenceEntry{
+		editor: presenceEditor(r),
+		edit:   req.Editing && canEditTierList(r, tl) && tl.LockedAt == nil,
+		seen:   time.Now(),
+	}
+	respondJSON(w, http.StatusOK, s.presence.Beat(tl.ID, s.presenceViewer(r), entry))
+}
+
+// handlePresenceLeave drops the requester from a tier list's viewers
+func (s *Server) handlePresenceLeave(w http.ResponseWriter, r *http.Request) {
+	s.presence.Leave(chi.URLParam(r, "id"), s.presenceViewer(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleClaimTierLock locks a tier for the requester while they reorder it,
+// or extends their lock. A tier someone else holds gives 409.
+func (s *Server) handleClaimTierLock(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.editableTierList(w, r)
+	if !ok || tierListLocked(w, tl) {
+		return
+	}
+	tierID := chi.URLParam(r, "tierID")
+	found := false
+	for _, tier := range tl.Tiers {
+		found = found || tier.ID == tierID
+	}
+	if !found {
+		respondError(w, http.StatusNotFound, "Tier not found")
+		return
+	}
+
+	lock, ok := s.presence.Claim(tl.ID, tierID, s.presenceViewer(r), presenceEditor(r))
+	if !ok {
+		respondError(w, http.StatusConflict, lock.Editor.DisplayName+" is reordering this tier")
+		return
+	}
+	respondJSON(w, http.StatusOK, lock)
+}
+
+// handleReleaseTierLock unlocks a tier the requester holds
+func (s *Server) handleReleaseTierLock(w http.ResponseWriter, r *http.Request) {
+	s.presence.Release(chi.URLParam(r, "id"), chi.URLParam(r, "tierID"), s.presenceViewer(r))
+	w.WriteHeader(http.StatusNoContent)
+}
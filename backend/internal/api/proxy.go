@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// forwardedClient identifies requests relayed by a trusted proxy by the
+// client the proxy names, rewriting r.RemoteAddr for clientIP. Forwarding
+// headers from any other peer are ignored, since clients can send anything.
+func (s *Server) forwardedClient(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := forwardedIP(r, s.config.TrustedProxies); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedIP returns the client address a trusted proxy forwarded r for, or
+// "" when the peer is not a trusted proxy or names no valid address. Proxies
+// append to X-Forwarded-For, so it is read from the right, skipping trusted
+// hops; entries left of the first untrusted one may be forged. X-Real-IP is
+// only read when there is no X-Forwarded-For.
+func forwardedIP(r *http.Request, trusted []netip.Prefix) string {
+	peer, ok := parseIP(r.RemoteAddr)
+	if !ok || !isTrustedProxy(peer, trusted) {
+		return ""
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, ok := parseIP(strings.TrimSpace(hops[i]))
+		if !ok {
+			break
+		}
+		client = addr.String()
+		if !isTrustedProxy(addr, trusted) {
+			break
+		}
+	}
+	if len(hops) > 0 {
+		return client
+	}
+	if addr, ok := parseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ok {
+		return addr.String()
+	}
+	return ""
+}
+
+// parseIP parses an IP address with or without a port
+func parseIP(s string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(s)
+	return addr.Unmap(), err == nil
+}
+
+func isTrustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
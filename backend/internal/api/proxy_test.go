@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/meur/tierforge/internal/config"
+)
+
+func TestForwardedIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("::1/128"),
+	}
+	tests := []struct {
+		name     string
+		peer     string
+		forwards []string
+		realIP   string
+		want     string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.5:4000", []string{"198.51.100.7"}, "198.51.100.8", ""},
+		{"trusted peer", "10.0.0.2:4000", []string{"198.51.100.7"}, "", "198.51.100.7"},
+		{"ipv6 loopback peer", "[::1]:4000", []string{"198.51.100.7"}, "", "198.51.100.7"},
+		{"forged entries left of the client", "10.0.0.2:4000", []string{"1.2.3.4, 198.51.100.7"}, "", "198.51.100.7"},
+		{"trusted hops are skipped", "10.0.0.2:4000", []string{"198.51.100.7, 10.1.1.1", "10.0.0.3"}, "", "198.51.100.7"},
+		{"only trusted hops", "10.0.0.2:4000", []string{"10.1.1.1"}, "", "10.1.1.1"},
+		{"invalid hop stops the walk", "10.0.0.2:4000", []string{"198.51.100.7, garbage"}, "198.51.100.8", ""},
+		{"real ip without forwarded for", "10.0.0.2:4000", nil, "198.51.100.8", "198.51.100.8"},
+		{"forwarded for wins over real ip", "10.0.0.2:4000", []string{"198.51.100.7"}, "198.51.100.8", "198.51.100.7"},
+		{"ipv4-mapped hop", "10.0.0.2:4000", []string{"::ffff:198.51.100.7"}, "", "198.51.100.7"},
+		{"no headers", "10.0.0.2:4000", nil, "", ""},
+		{"unparseable peer", "pipe", []string{"198.51.100.7"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.peer
+			for _, f := range tt.forwards {
+				r.Header.Add("X-Forwarded-For", f)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := forwardedIP(r, trusted); got != tt.want {
+				t.Errorf("forwardedIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPIgnoresSpoofedHeaders(t *testing.T) {
+	s := &Server{config: &config.Config{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}}
+	for _, tt := range []struct{ peer, want string }{
+		{"203.0.113.5:4000", "203.0.113.5"},
+		{"10.0.0.2:4000", "198.51.100.7"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.peer
+		r.Header.Set("X-Forwarded-For", "198.51.100.7")
+		r.Header.Set("X-Real-IP", "198.51.100.8")
+
+		var got string
+		s.forwardedClient(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = clientIP(r)
+		})).ServeHTTP(httptest.NewRecorder(), r)
+		if got != tt.want {
+			t.Errorf("clientIP from %s = %q, want %q", tt.peer, got, tt.want)
+		}
+	}
+}
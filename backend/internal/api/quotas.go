@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// quotaLimits returns the configured default quotas of each account
+func (s *Server) quotaLimits() models.QuotaLimits {
+	bytes := int64(s.config.UploadMBPerUser) << 20
+	if s.config.UploadMBPerUser < 0 {
+		bytes = models.Unlimited
+	}
+	return models.QuotaLimits{
+		TierLists:          s.config.TierListsPerUser,
+		CustomItemsPerList: s.config.CustomItemsPerList,
+		CustomItems:        s.config.CustomItemsPerUser,
+		UploadBytes:        bytes,
+	}
+}
+
+// quotaFor returns a user's limits, with any admin override applied, and
+// what they store
+func (s *Server) quotaFor(store *storage.Store, userID string) (*models.Quota, error) {
+	override, err := store.GetQuotaOverride(userID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := store.GetQuotaUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Quota{
+		Limits:    override.Apply(s.quotaLimits()),
+		Usage:     usage,
+		Override:  override,
+		MaxUpload: int64(s.config.CustomUploadMaxKB) << 10,
+	}, nil
+}
+
+// checkTierListQuota checks that the signed-in user may author n more tier
+// lists, writing the error response itself. Anonymous requests pass.
+func (s *Server) checkTierListQuota(w http.ResponseWriter, r *http.Request, n int) bool {
+	user := currentUser(r)
+	if user == nil {
+		return true
+	}
+	quota, err := s.quotaFor(s.storeFor(r), user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+		return false
+	}
+	if err := quota.CheckTierLists(n); err != nil {
+		respondError(w, http.StatusForbidden, err.Error())
+		return false
+	}
+	return true
+}
+
+// handleGetMyQuota returns the current user's limits and usage
+func (s *Server) handleGetMyQuota(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+	quota, err := s.quotaFor(s.storeFor(r), user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+		return
+	}
+	respondJSON(w, http.StatusOK, quota)
+}
+
+// handleGetUserQuota returns a user's limits and usage to admins
+func (s *Server) handleGetUserQuota(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	quota, err := s.quotaFor(s.storeFor(r), user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+		return
+	}
+	respondJSON(w, http.StatusOK, quota)
+}
+
+// handlePutUserQuota overrides a user's limits. Limits left out keep the
+// site defaults; a negative limit lifts it.
+func (s *Server) handlePutUserQuota(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	var override models.QuotaOverride
+	if err := decodeJSON(r, &override); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	for _, limit := range []*int{override.TierLists, override.CustomItemsPerList, override.CustomItems} {
+		if limit != nil && *limit < 0 {
+			*limit = models.Unlimited
+		}
+	}
+	if override.UploadBytes != nil && *override.UploadBytes < 0 {
+		*override.UploadBytes = models.Unlimited
+	}
+	if override.Note != "" {
+		note, err := s.cleanText("note", override.Note, maxReportDetails)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		override.Note = note
+	}
+	override.UserID = user.ID
+	override.UpdatedBy = auditActor(r)
+
+	before, err := s.storeFor(r).GetQuotaOverride(user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+		return
+	}
+	if err := s.storeFor(r).SetQuotaOverride(&override); err != nil {
+		respondWriteError(w, err, "Failed to save quota")
+		return
+	}
+	s.auditChange(r, "user.quota", "user", user.ID, "", before, override)
+
+	quota, err := s.quotaFor(s.storeFor(r), user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+		return
+	}
+	respondJSON(w, http.StatusOK, quota)
+}
+
+// handleDeleteUserQuota restores a user's default limits
+func (s *Server) handleDeleteUserQuota(w http.ResponseWriter, r *http.Request) {
+	user, ok := s.lookupUser(w, r)
+	if !ok {
+		return
+	}
+	before, err := s.storeFor(r).GetQuotaOverride(user.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch quota")
+		return
+	}
+	if before == nil {
+		respondError(w, http.StatusNotFound, "User has no quota override")
+		return
+	}
+	if _, err := s.storeFor(r).DeleteQuotaOverride(user.ID); err != nil {
+		respondWriteError(w, err, "Failed to delete quota override")
+		return
+	}
+	s.auditChange(r, "user.quota", "user", user.ID, "", before, nil)
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// lookupUser resolves the user in the URL, writing the error response
+// itself
+func (s *Server) lookupUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, err := s.storeFor(r).GetUser(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return nil, false
+	}
+	if user == nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return nil, false
+	}
+	return user, true
+}
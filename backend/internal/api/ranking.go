@@ -0,0 +1,238 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxNextUnranked caps ?count= on the next-unranked endpoint
+const maxNextUnranked = 50
+
+// rankableTierList loads a tier list the current user may rank
+func (s *Server) rankableTierList(w http.ResponseWriter, r *http.Request) (*models.TierList, bool) {
+	tl, err := s.storeFor(r).GetTierList(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return nil, false
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return nil, false
+	}
+	if !canEditTierList(r, tl) {
+		respondError(w, http.StatusForbidden, "You cannot edit this tier list")
+		return nil, false
+	}
+	return tl, true
+}
+
+// unrankedPool returns the sheet items not placed in any tier, in pool order
+func (s *Server) unrankedPool(w http.ResponseWriter, r *http.Request, tl *models.TierList) ([]models.Item, bool) {
+	game, err := s.storeFor(r).GetGame(tl.GameID)
+	if err != nil || game == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return nil, false
+	}
+	mode, ok := spoilerMode(w, r, game)
+	if !ok {
+		return nil, false
+	}
+	items, err := s.storeFor(r).QueryItems(storage.ItemQuery{GameID: tl.GameID, SheetID: tl.SheetID, Version: tl.GameVersion, TierListID: tl.ID})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return nil, false
+	}
+	// Order the whole sheet before dropping ranked items so a random pool
+	// keeps its order as items are ranked
+	ranking.OrderPool(items, tl.PoolOrder, tl.PoolSeed)
+
+	ranked := make(map[string]bool)
+	for _, tier := range tl.Tiers {
+		for _, id := range tier.Items {
+			ranked[id] = true
+		}
+	}
+	pool := items[:0]
+	for _, item := range items {
+		if !ranked[item.ID] {
+			pool = append(pool, item)
+		}
+	}
+	return hideSpoilerItems(pool, mode), true
+}
+
+// rankingQueue builds the queue response from the pool
+func rankingQueue(tl *models.TierList, pool []models.Item, count int) models.RankingQueue {
+	next := pool
+	if next == nil {
+		next = []models.Item{}
+	}
+	if len(next) > count {
+		next = next[:count]
+	}
+	return models.RankingQueue{Next: next, Remaining: len(pool), Ranked: rankedItemCount(tl.Tiers)}
+}
+
+// queueCount reads ?count=, defaulting to one item
+func queueCount(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("count")
+	if v == "" {
+		return 1, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxNextUnranked {
+		respondError(w, http.StatusBadRequest, "count must be between 1 and "+strconv.Itoa(maxNextUnranked))
+		return 0, false
+	}
+	return n, true
+}
+
+// handleGetNextUnranked returns the next items of a tier list's unranked pool
+func (s *Server) handleGetNextUnranked(w http.ResponseWriter, r *http.Request) {
+	count, ok := queueCount(w, r)
+	if !ok {
+		return
+	}
+	tl, ok := s.rankableTierList(w, r)
+	if !ok {
+		return
+	}
+	pool, ok := s.unrankedPool(w, r, tl)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rankingQueue(tl, pool, count))
+}
+
+// handleGetPool returns the whole unranked pool of a tier list in its pool
+// order, so a shared blind-ranking challenge starts from the same order
+func (s *Server) handleGetPool(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.viewableTierList(r, chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	pool, ok := s.unrankedPool(w, r, tl)
+	if !ok {
+		return
+	}
+
+	respondJSON(w, http.StatusOK, rankingQueue(tl, pool, len(pool)))
+}
+
+// handleAssignTier moves one item into a tier, or back to the pool, and
+// returns the updated queue so clients can show the next item right away
+func (s *Server) handleAssignTier(w http.ResponseWriter, r *http.Request) {
+	count, ok := queueCount(w, r)
+	if !ok {
+		return
+	}
+	tl, ok := s.rankableTierList(w, r)
+	if !ok || tierListLocked(w, tl) || pollLocksTiers(w, tl) {
+		return
+	}
+	if tl.Status == models.TierListArchived {
+		respondError(w, http.StatusConflict, "Archived tier lists are read-only; publish it again to edit")
+		return
+	}
+
+	var req models.TierAssignment
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.ItemID == "" {
+		respondError(w, http.StatusBadRequest, "item_id is required")
+		return
+	}
+	pool, ok := s.unrankedPool(w, r, tl)
+	if !ok {
+		return
+	}
+	if !inPool(pool, req.ItemID) && !isRanked(tl.Tiers, req.ItemID) {
+		respondError(w, http.StatusBadRequest, "item_id is not part of this tier list's sheet")
+		return
+	}
+
+	target := -1
+	for i := range tl.Tiers {
+		if tl.Tiers[i].ID == req.TierID {
+			target = i
+		}
+	}
+	if req.TierID != "" && target < 0 {
+		respondError(w, http.StatusBadRequest, "Unknown tier_id")
+		return
+	}
+
+	before := make([]models.Tier, len(tl.Tiers))
+	for i, tier := range tl.Tiers {
+		before[i] = tier
+		before[i].Items = append([]string(nil), tier.Items...)
+	}
+	for i := range tl.Tiers {
+		tl.Tiers[i].Items = removeString(tl.Tiers[i].Items, req.ItemID)
+	}
+	if target >= 0 {
+		tl.Tiers[target].Items = append(tl.Tiers[target].Items, req.ItemID)
+	}
+
+	if !s.checkConstraints(w, tl) {
+		return
+	}
+
+	s.autosaves.Discard(tl.ID)
+	if err := s.updateTierList(r.Context(), tl.ID, before, &models.TierListUpdate{Tiers: tl.Tiers}); err != nil {
+		respondWriteError(w, err, "Failed to update tier list")
+		return
+	}
+	if moved := movedItemCount(before, tl.Tiers); moved > 0 {
+		s.recordEvent(r, models.EventItemsMoved, tl.GameID, map[string]interface{}{"moved": moved})
+	}
+	s.prerenderTierList(tl)
+
+	if pool, ok = s.unrankedPool(w, r, tl); !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, rankingQueue(tl, pool, count))
+}
+
+func inPool(pool []models.Item, id string) bool {
+	for _, item := range pool {
+		if item.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func isRanked(tiers []models.Tier, id string) bool {
+	for _, tier := range tiers {
+		for _, v := range tier.Items {
+			if v == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
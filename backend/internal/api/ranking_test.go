@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestRankingQueueTransitions(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	createRawItems(t, store, "a", "b", "c")
+
+	w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+		"game_id": "g", "sheet_id": "main", "name": "Queue",
+		"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}, {ID: "t", Name: "T", Color: "#ffbf7f"}},
+	}, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create list: %d %s", w.Code, w.Body)
+	}
+	var tl models.TierList
+	decodeBody(t, w, &tl)
+	base := "/api/tierlists/" + tl.ID
+
+	steps := []struct {
+		name      string
+		method    string
+		path      string
+		body      interface{}
+		code      int
+		next      []string
+		remaining int
+		ranked    int
+	}{
+		{"fresh queue", "GET", "/next-unranked?count=2", nil, http.StatusOK, []string{"a", "b"}, 3, 0},
+		{"rank the first item", "POST", "/assign", models.TierAssignment{ItemID: "a", TierID: "s"}, http.StatusOK, []string{"b"}, 2, 1},
+		{"move it between tiers", "POST", "/assign?count=3", models.TierAssignment{ItemID: "a", TierID: "t"}, http.StatusOK, []string{"b", "c"}, 2, 1},
+		{"return it to the pool", "POST", "/assign", models.TierAssignment{ItemID: "a"}, http.StatusOK, []string{"a"}, 3, 0},
+		{"rank out of order", "POST", "/assign", models.TierAssignment{ItemID: "c", TierID: "s"}, http.StatusOK, []string{"a"}, 2, 1},
+		{"rank another", "POST", "/assign", models.TierAssignment{ItemID: "a", TierID: "s"}, http.StatusOK, []string{"b"}, 1, 2},
+		{"empty the pool", "POST", "/assign", models.TierAssignment{ItemID: "b", TierID: "t"}, http.StatusOK, []string{}, 0, 3},
+		{"unknown tier", "POST", "/assign", models.TierAssignment{ItemID: "b", TierID: "x"}, http.StatusBadRequest, nil, 0, 0},
+		{"missing item", "POST", "/assign", models.TierAssignment{TierID: "s"}, http.StatusBadRequest, nil, 0, 0},
+		{"item of another sheet", "POST", "/assign", models.TierAssignment{ItemID: "nope", TierID: "s"}, http.StatusBadRequest, nil, 0, 0},
+		{"count too low", "GET", "/next-unranked?count=0", nil, http.StatusBadRequest, nil, 0, 0},
+		{"count too high", "GET", "/next-unranked?count=51", nil, http.StatusBadRequest, nil, 0, 0},
+	}
+	for _, step := range steps {
+		w := serve(s, step.method, base+step.path, owner, step.body, nil)
+		if w.Code != step.code {
+			t.Fatalf("%s: %d %s, want %d", step.name, w.Code, w.Body, step.code)
+		}
+		if step.code != http.StatusOK {
+			continue
+		}
+		var queue models.RankingQueue
+		decodeBody(t, w, &queue)
+		next := make([]string, len(queue.Next))
+		for i, item := range queue.Next {
+			next[i] = item.ID
+		}
+		if !reflect.DeepEqual(next, step.next) || queue.Remaining != step.remaining || queue.Ranked != step.ranked {
+			t.Errorf("%s: next %v, %d remaining, %d ranked; want %v, %d, %d",
+				step.name, next, queue.Remaining, queue.Ranked, step.next, step.remaining, step.ranked)
+		}
+	}
+
+	stored, err := store.GetTierList(tl.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetTierList = %v, %v", stored, err)
+	}
+	if got := [][]string{stored.Tiers[0].Items, stored.Tiers[1].Items}; !reflect.DeepEqual(got, [][]string{{"c", "a"}, {"b"}}) {
+		t.Errorf("stored tiers = %v, want items appended in ranking order", got)
+	}
+
+	// A stranger can neither read the queue nor rank
+	stranger := signUp(t, s, "stranger@example.com")
+	if w := serve(s, "POST", base+"/assign", stranger, models.TierAssignment{ItemID: "a"}, nil); w.Code != http.StatusForbidden {
+		t.Errorf("stranger assign: %d %s, want 403", w.Code, w.Body)
+	}
+	if w := serve(s, "GET", base+"/next-unranked", stranger, nil, nil); w.Code != http.StatusForbidden {
+		t.Errorf("stranger queue: %d %s, want 403", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// redirectCodePattern matches the old codes redirects may be made from,
+// which include slugs and codes of other instances as well as our own
+var redirectCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// redirectShareCode answers a read of a share code no tier list has with a
+// permanent redirect to the code it was mapped to. It reports whether it
+// responded.
+func (s *Server) redirectShareCode(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	code := chi.URLParam(r, "code")
+	rd, err := s.storeFor(r).GetURLRedirect(code)
+	if err != nil || rd == nil {
+		return false
+	}
+	u := *r.URL
+	u.Path = strings.Replace(u.Path, "/s/"+code, "/s/"+rd.To, 1)
+	u.RawPath = ""
+	http.Redirect(w, r, u.RequestURI(), http.StatusMovedPermanently)
+	return true
+}
+
+// handleGetRedirects returns every share code redirect
+func (s *Server) handleGetRedirects(w http.ResponseWriter, r *http.Request) {
+	redirects, err := s.storeFor(r).GetURLRedirects()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch redirects")
+		return
+	}
+	respondJSON(w, http.StatusOK, redirects)
+}
+
+// handlePutRedirect maps an old share code to the share code of a current
+// tier list
+func (s *Server) handlePutRedirect(w http.ResponseWriter, r *http.Request) {
+	from := chi.URLParam(r, "code")
+	if !redirectCodePattern.MatchString(from) {
+		respondError(w, http.StatusBadRequest, "code must be 1-64 letters, digits, hyphens or underscores")
+		return
+	}
+	var req struct {
+		To string `json:"to"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.To == "" {
+		respondError(w, http.StatusBadRequest, "to is required")
+		return
+	}
+	if req.To == from {
+		respondError(w, http.StatusBadRequest, "A code cannot redirect to itself")
+		return
+	}
+
+	// A live code always wins over a redirect, so one would never be used
+	taken, err := s.storeFor(r).ShareCodeTaken(from)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check share code")
+		return
+	}
+	if taken {
+		respondError(w, http.StatusConflict, "A tier list already has this share code")
+		return
+	}
+	// Targets must be current so redirects never chain
+	target, err := s.storeFor(r).ShareCodeTaken(req.To)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check share code")
+		return
+	}
+	if !target {
+		respondError(w, http.StatusUnprocessableEntity, "No tier list has the share code "+req.To)
+		return
+	}
+
+	rd := &models.URLRedirect{From: from, To: req.To, CreatedBy: auditActor(r)}
+	if err := s.storeFor(r).SaveURLRedirect(rd); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save redirect")
+		return
+	}
+	s.audit(r, "redirect.save", "redirect", from, "to "+req.To)
+
+	respondJSON(w, http.StatusOK, rd)
+}
+
+// handleDeleteRedirect removes a share code redirect
+func (s *Server) handleDeleteRedirect(w http.ResponseWriter, r *http.Request) {
+	from := chi.URLParam(r, "code")
+
+	deleted, err := s.storeFor(r).DeleteURLRedirect(from)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete redirect")
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "Redirect not found")
+		return
+	}
+	s.audit(r, "redirect.delete", "redirect", from, "")
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// handleGetItemRelations returns the edges of a game's catalog, or only those
+// touching one item when called on an item route
+func (s *Server) handleGetItemRelations(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	itemID := chi.URLParam(r, "itemID")
+
+	relations, err := s.storeFor(r).GetItemRelations(gameID, itemID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch relations")
+		return
+	}
+
+	if kind := r.URL.Query().Get("kind"); kind != "" {
+		filtered := relations[:0]
+		for _, rel := range relations {
+			if rel.Kind == kind {
+				filtered = append(filtered, rel)
+			}
+		}
+		relations = filtered
+	}
+	respondJSON(w, http.StatusOK, relations)
+}
+
+// handleCreateItemRelation links two items of a game
+func (s *Server) handleCreateItemRelation(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	var rel models.ItemRelation
+	if err := decodeJSON(r, &rel); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rel.GameID = gameID
+	if rel.ItemID == "" || rel.RelatedID == "" {
+		respondError(w, http.StatusBadRequest, "item_id and related_id are required")
+		return
+	}
+	if rel.ItemID == rel.RelatedID {
+		respondError(w, http.StatusBadRequest, "An item cannot relate to itself")
+		return
+	}
+	if !models.ValidRelationKind(rel.Kind) {
+		respondError(w, http.StatusBadRequest, "kind must be one of requires, upgrades_to, combo_with, related")
+		return
+	}
+
+	for _, id := range []string{rel.ItemID, rel.RelatedID} {
+		item, err := s.storeFor(r).GetItem(gameID, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch item")
+			return
+		}
+		if item == nil {
+			respondError(w, http.StatusBadRequest, "Unknown item: "+id)
+			return
+		}
+	}
+
+	if err := s.storeFor(r).CreateItemRelation(&rel); err != nil {
+		if err == storage.ErrDuplicate {
+			respondError(w, http.StatusConflict, "This relation already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to create relation")
+		return
+	}
+	s.writeAuditFor(r, "relation.create", "item", rel.ItemID, gameID, rel.Kind+" "+rel.RelatedID)
+
+	respondJSON(w, http.StatusCreated, rel)
+}
+
+// handleDeleteItemRelation removes a link between two items
+func (s *Server) handleDeleteItemRelation(w http.ResponseWriter, r *http.Request) {
+	rel := models.ItemRelation{
+		GameID:    chi.URLParam(r, "gameID"),
+		ItemID:    chi.URLParam(r, "itemID"),
+		RelatedID: chi.URLParam(r, "relatedID"),
+		Kind:      chi.URLParam(r, "kind"),
+	}
+
+	deleted, err := s.storeFor(r).DeleteItemRelation(&rel)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete relation")
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "Relation not found")
+		return
+	}
+	s.writeAuditFor(r, "relation.delete", "item", rel.ItemID, rel.GameID, rel.Kind+" "+rel.RelatedID)
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
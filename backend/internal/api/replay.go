@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+)
+
+// handleGetReplay returns the recorded moves of a tier list, oldest first,
+// for share pages replaying how it was built. Lists that do not record
+// return no moves. Spoiler items are left out when spoilers are hidden.
+func (s *Server) handleGetReplay(w http.ResponseWriter, r *http.Request) {
+	tl, ok := s.visibleTierList(w, r)
+	if !ok {
+		return
+	}
+	replay := models.TierListReplay{Recording: tl.RecordReplay, Moves: []models.ReplayMove{}}
+	if !tl.RecordReplay {
+		respondJSON(w, http.StatusOK, replay)
+		return
+	}
+
+	var err error
+	if replay.Moves, replay.Truncated, err = s.storeFor(r).GetReplay(tl.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch replay")
+		return
+	}
+	game, err := s.storeFor(r).GetGame(tl.GameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game != nil {
+		mode, ok := spoilerMode(w, r, game)
+		if !ok {
+			return
+		}
+		if mode == models.SpoilerExclude {
+			spoilers, err := s.storeFor(r).GetSpoilerItemIDs(game.ID)
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+				return
+			}
+			replay.Moves = ranking.HideReplayMoves(replay.Moves, spoilers)
+		}
+	}
+	respondJSON(w, http.StatusOK, replay)
+}
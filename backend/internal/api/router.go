@@ -1,36 +1,141 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/cors"
+	"github.com/meur/tierforge/internal/auth"
+	"github.com/meur/tierforge/internal/challenge"
+	"github.com/meur/tierforge/internal/config"
+	"github.com/meur/tierforge/internal/email"
+	"github.com/meur/tierforge/internal/imaging"
+	"github.com/meur/tierforge/internal/jobs"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/packs"
+	"github.com/meur/tierforge/internal/render"
+	"github.com/meur/tierforge/internal/sprites"
 	"github.com/meur/tierforge/internal/storage"
+	"github.com/meur/tierforge/internal/textfilter"
 )
 
 // Server holds the HTTP server dependencies
 type Server struct {
-	store  *storage.Store
-	router chi.Router
+	ctx         context.Context
+	store       *storage.Store
+	config      *config.Config
+	scheduler   *jobs.Scheduler
+	textFilter  *textfilter.Filter
+	router      chi.Router
+	shortlinks  chi.Router // nil when SHORT_DOMAIN is unset
+	visitorSalt []byte
+	devices     *auth.DeviceSigner // nil when DEVICE_COOKIES is off
+	challenge   *challenge.Gate    // nil when CHALLENGE_MODE is off
+	autosaves   *autosaver
+	presence    *presenceTracker
+	mailer      email.Sender
+	packs       *packs.Registry // nil when PACK_INDEX_URL is unset
+	prerender   *render.Worker
+	sprites     *sprites.Builder
+	variants    *imaging.Cache // nil when IMAGE_CACHE_DIR is off
+	started     time.Time
+	// publicRoutes matches the routes registered with publicGet
+	publicRoutes *chi.Mux
 }
 
-// New creates a new API server
-func New(store *storage.Store) *Server {
+// New creates a new API server. ctx bounds background work started by
+// handlers, such as manually triggered jobs.
+func New(ctx context.Context, store *storage.Store, cfg *config.Config, scheduler *jobs.Scheduler, mailer email.Sender, prerender *render.Worker) *Server {
 	s := &Server{
-		store:  store,
-		router: chi.NewRouter(),
+		ctx:          ctx,
+		store:        store,
+		config:       cfg,
+		scheduler:    scheduler,
+		router:       chi.NewRouter(),
+		publicRoutes: chi.NewRouter(),
+		visitorSalt:  make([]byte, 16),
+		autosaves:    newAutosaver(store),
+		presence:     newPresenceTracker(),
+		mailer:       mailer,
+		prerender:    prerender,
+		sprites:      sprites.NewBuilder(store, cfg.PublicURL),
+		started:      time.Now(),
 	}
+	rand.Read(s.visitorSalt)
+
+	words := cfg.ContentFilterWords
+	if len(words) == 0 {
+		words = textfilter.DefaultWords
+	}
+	s.textFilter = textfilter.New(words, textfilter.Mode(cfg.ContentFilterMode))
+
+	if cfg.PackIndexURL != "" {
+		registry, err := packs.New(cfg.PackIndexURL)
+		if err != nil {
+			log.Printf("WARNING: Pack registry disabled: %v", err)
+		} else {
+			s.packs = registry
+		}
+	}
+
+	if cfg.DeviceCookies {
+		devices, err := auth.NewDeviceSigner(cfg.DeviceSecrets)
+		if err != nil {
+			log.Printf("WARNING: Device cookies disabled: %v", err)
+		} else {
+			s.devices = devices
+			if len(cfg.DeviceSecrets) == 0 {
+				log.Printf("WARNING: DEVICE_SECRET is unset; device cookies reset on every restart")
+			}
+		}
+	}
+
+	gate, err := challenge.New(cfg.ChallengeMode, cfg.ChallengeSiteKey, cfg.ChallengeSecret, cfg.ChallengeDifficulty)
+	if err != nil {
+		log.Printf("WARNING: Challenges disabled: %v", err)
+	} else {
+		s.challenge = gate
+	}
+
+	if variants, err := imaging.NewCache(cfg.ImageCacheDir); err != nil {
+		log.Printf("WARNING: Image variant cache disabled: %v", err)
+	} else {
+		s.variants = variants
+	}
+
+	if cfg.PprofEnabled {
+		setProfilingRates(cfg.PprofBlockRate, cfg.PprofMutexFraction)
+	}
+
+	s.sprites.Start(ctx)
 
 	s.setupMiddleware()
 	s.setupRoutes()
+	if cfg.ShortDomain != "" {
+		s.setupShortlinks()
+	}
 
 	return s
 }
 
-// ServeHTTP implements http.Handler
+// Close persists pending autosaves. Call it after the HTTP server has stopped.
+func (s *Server) Close() {
+	s.autosaves.FlushAll()
+}
+
+// ServeHTTP implements http.Handler. Requests to the shortlink domain only
+// reach the shortlink routes.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.shortlinks != nil && s.isShortHost(r) {
+		s.shortlinks.ServeHTTP(w, r)
+		return
+	}
 	s.router.ServeHTTP(w, r)
 }
 
@@ -40,41 +145,287 @@ func (s *Server) Router() chi.Router {
 }
 
 func (s *Server) setupMiddleware() {
+	s.router.Use(middleware.RequestID)
+	s.router.Use(s.forwardedClient)
+	s.router.Use(s.traceRequests)
 	s.router.Use(middleware.Logger)
 	s.router.Use(middleware.Recoverer)
 	s.router.Use(middleware.Compress(5))
-	s.router.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:*", "https://*.tierforge.app"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	s.router.Use(s.authenticate)
+	s.router.Use(s.corsHandler)
 }
 
 func (s *Server) setupRoutes() {
 	s.router.Route("/api", func(r chi.Router) {
+		r.Use(s.deviceIdentity)
+		r.Use(s.rejectBanned)
+
 		// Games
-		r.Get("/games", s.handleGetGames)
-		r.Get("/games/{gameID}", s.handleGetGame)
-		r.Get("/games/{gameID}/items", s.handleGetItems)
-		r.Get("/games/{gameID}/sheets", s.handleGetSheets)
+		s.publicGet(r, "/games", s.handleGetGames)
+		s.publicGet(r, "/games/{gameID}", s.handleGetGame)
+		s.publicGet(r, "/games/{gameID}/items", s.handleGetItems)
+		s.publicGet(r, "/games/{gameID}/items/tags", s.handleGetItemTags)
+		s.publicGet(r, "/games/{gameID}/items/suggest", s.handleSuggestItems)
+		s.publicGet(r, "/games/{gameID}/items/{itemID}/details", s.handleGetItemDetails)
+		s.publicGet(r, "/games/{gameID}/items/{itemID}/relations", s.handleGetItemRelations)
+		s.publicGet(r, "/games/{gameID}/relations", s.handleGetItemRelations)
+		s.publicGet(r, "/games/{gameID}/sheets", s.handleGetSheets)
+		s.publicGet(r, "/games/{gameID}/schema", s.handleGetItemSchema)
+		s.publicGet(r, "/games/{gameID}/sheets/{sheetID}/sprite", s.handleGetSpriteSheet)
+		s.publicGet(r, "/games/{gameID}/sheets/{sheetID}/sprite.png", s.handleGetSpriteImage)
+		s.publicGet(r, "/icons/{file}", s.handleGetIcon)
+		s.publicGet(r, "/games/{gameID}/versions", s.handleGetVersions)
+		s.publicGet(r, "/games/{gameID}/tags", s.handleGetTagCloud)
+		s.publicGet(r, "/games/{gameID}/tierlists", s.handleGetPublicTierLists)
+		s.publicGet(r, "/games/{gameID}/community", s.handleGetCommunityAggregates)
+		s.publicGet(r, "/games/{gameID}/leaderboard", s.handleGetLeaderboard)
 
 		// TierLists
-		r.Post("/tierlists", s.handleCreateTierList)
+		r.With(s.idempotent, s.requireChallenge).Post("/tierlists", s.handleCreateTierList)
+		s.publicGet(r, "/tierlists/search", s.handleSearchTierLists)
+		r.Get("/tierlists/compare", s.handleCompareTierLists)
+		r.With(s.idempotent, s.requireChallenge).Post("/tierlists/merge", s.handleMergeTierLists)
+		r.With(s.idempotent, s.requireChallenge).Post("/tierlists/import", s.handleImportTierList)
 		r.Get("/tierlists/{id}", s.handleGetTierList)
 		r.Put("/tierlists/{id}", s.handleUpdateTierList)
+		r.Post("/tierlists/{id}/sync", s.handleSyncTierList)
 		r.Delete("/tierlists/{id}", s.handleDeleteTierList)
+		r.With(s.idempotent, s.requireChallenge).Post("/tierlists/{id}/fork", s.handleForkTierList)
+		r.Get("/tierlists/{id}/image", s.handleGetTierListImage)
+		r.Get("/tierlists/{id}/html", s.handleExportTierListHTML)
+		r.Get("/tierlists/{id}/export", s.handleExportTierListDocument)
+		r.Get("/tierlists/{id}/score", s.handleGetTierListScore)
+		r.Get("/tierlists/{id}/pool", s.handleGetPool)
+		r.Get("/tierlists/{id}/next-unranked", s.handleGetNextUnranked)
+		r.Get("/tierlists/{id}/items", s.handleGetCustomItems)
+		r.Post("/tierlists/{id}/items", s.handleUploadCustomItems)
+		r.Delete("/tierlists/{id}/items/{itemID}", s.handleDeleteCustomItem)
+		r.Post("/tierlists/{id}/assign", s.handleAssignTier)
+		r.Post("/tierlists/{id}/poll/open", s.handleOpenPoll)
+		r.Post("/tierlists/{id}/poll/close", s.handleClosePoll)
+		r.With(s.requireChallenge).Post("/tierlists/{id}/report", s.handleReportTierList)
+		r.Post("/tierlists/{id}/autosave", s.handleAutosaveTierList)
+		r.Get("/tierlists/{id}/autosave", s.handleGetAutosave)
+		r.Delete("/tierlists/{id}/autosave", s.handleDiscardAutosave)
+		r.Post("/tierlists/{id}/autosave/recover", s.handleRecoverAutosave)
+		r.Put("/tierlists/{id}/lock", s.handleLockTierList)
+		r.Delete("/tierlists/{id}/lock", s.handleUnlockTierList)
+		r.Get("/tierlists/{id}/replay", s.handleGetReplay)
+		r.Get("/tierlists/{id}/presence", s.handleGetPresence)
+		r.Post("/tierlists/{id}/presence", s.handlePresenceBeat)
+		r.Delete("/tierlists/{id}/presence", s.handlePresenceLeave)
+		r.Put("/tierlists/{id}/tiers/{tierID}/lock", s.handleClaimTierLock)
+		r.Delete("/tierlists/{id}/tiers/{tierID}/lock", s.handleReleaseTierLock)
+
+		// Workspaces
+		r.With(s.requireAuth, s.idempotent).Post("/workspaces", s.handleCreateWorkspace)
+		r.Route("/workspaces/{slug}", func(r chi.Router) {
+			r.Use(s.loadWorkspace)
+			r.Get("/", s.handleGetWorkspace)
+			r.Get("/games", s.handleGetWorkspaceGames)
+			r.Get("/tierlists", s.handleGetWorkspaceTierLists)
+			r.Route("/members", func(r chi.Router) {
+				r.Use(s.requireAuth)
+				r.With(s.requireWorkspaceRole(models.WorkspaceOwner, models.WorkspaceEditor, models.WorkspaceMember)).Get("/", s.handleGetWorkspaceMembers)
+				r.With(s.requireWorkspaceRole(models.WorkspaceOwner)).Post("/", s.handleAddWorkspaceMember)
+				r.With(s.requireWorkspaceRole(models.WorkspaceOwner)).Put("/{userID}", s.handleSetWorkspaceMemberRole)
+				r.Delete("/{userID}", s.handleRemoveWorkspaceMember)
+			})
+
+			// Catalog of the workspace's own games
+			r.Route("/games/{gameID}", func(r chi.Router) {
+				r.Use(s.requireWorkspaceRole(models.WorkspaceOwner, models.WorkspaceEditor))
+				r.Use(s.requireWorkspaceGame)
+				r.Put("/", s.handlePutGame)
+				r.Put("/default-tiers", s.handlePutDefaultTiers)
+				r.Put("/sheets/{sheetID}/default-tiers", s.handlePutSheetDefaultTiers)
+				r.Get("/bundle", s.handleExportGameBundle)
+				r.Post("/items", s.handleCreateItem)
+				r.Put("/items/{itemID}", s.handleUpdateItem)
+				r.Delete("/items/{itemID}", s.handleDeleteItem)
+				r.Put("/items/{itemID}/aliases", s.handlePutItemAliases)
+				r.Post("/relations", s.handleCreateItemRelation)
+				r.Delete("/items/{itemID}/relations/{kind}/{relatedID}", s.handleDeleteItemRelation)
+				r.Get("/snapshots", s.handleGetSnapshots)
+				r.Post("/snapshots/{id}/restore", s.handleRestoreSnapshot)
+			})
+		})
+
+		// Palettes
+		s.publicGet(r, "/palettes", s.handleGetPalettes)
+		s.publicGet(r, "/palettes/{id}", s.handleGetPalette)
+		r.Post("/colors/check", s.handleCheckColors)
 
 		// Share links
-		r.Get("/s/{code}", s.handleGetTierListByCode)
-	})
+		s.publicGet(r, "/s/{code}", s.handleGetTierListByCode)
+		s.publicGet(r, "/s/{code}/image", s.handleGetTierListImageByCode)
+		s.publicGet(r, "/s/{code}/poll", s.handleGetPollResults)
+		r.Post("/s/{code}/votes", s.handleVotePoll)
 
-	// Health check
-	s.router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+		// Product analytics
+		r.Post("/events", s.handleReportEvent)
+
+		// Head-to-head ranking
+		r.With(s.idempotent).Post("/matchups", s.handleCreateMatchupSession)
+		r.Get("/matchups/{id}", s.handleGetMatchupSession)
+		r.Get("/matchups/{id}/pair", s.handleGetMatchupPair)
+		r.Post("/matchups/{id}/results", s.handlePostMatchupResult)
+		r.With(s.idempotent, s.requireChallenge).Post("/matchups/{id}/tierlist", s.handleCreateMatchupTierList)
+
+		// Contests
+		s.publicGet(r, "/games/{gameID}/contests", s.handleGetContests)
+		s.publicGet(r, "/contests/{contestID}", s.handleGetContest)
+		r.With(s.requireAuth).Post("/contests/{contestID}/submissions", s.handleSubmitToContest)
+
+		// Single-elimination brackets
+		r.With(s.idempotent).Post("/brackets", s.handleCreateBracket)
+		r.Get("/brackets/{id}", s.handleGetBracket)
+		r.Get("/brackets/{id}/match", s.handleGetBracketMatch)
+		r.Post("/brackets/{id}/results", s.handlePostBracketResult)
+		r.With(s.idempotent, s.requireChallenge).Post("/brackets/{id}/tierlist", s.handleCreateBracketTierList)
+
+		// Challenges
+		r.Get("/challenge", s.handleGetChallenge)
+
+		// Accounts
+		r.Post("/auth/register", s.handleRegister)
+		r.Post("/auth/login", s.handleLogin)
+		r.With(s.requireAuth).Post("/auth/logout", s.handleLogout)
+		r.Post("/auth/verify", s.handleVerifyEmail)
+		r.Post("/auth/password/forgot", s.handleForgotPassword)
+		r.Post("/auth/password/reset", s.handleResetPassword)
+		r.Post("/auth/email/confirm", s.handleConfirmEmailChange)
+		r.With(s.requireAuth).Get("/me", s.handleGetMe)
+		r.With(s.requireAuth).Delete("/me", s.handleDeleteAccount)
+		r.With(s.requireAuth).Put("/me/email", s.handleChangeEmail)
+		r.With(s.requireAuth).Post("/me/verify/resend", s.handleResendVerification)
+		r.With(s.requireAuth).Put("/me/preferences", s.handleSetPreferences)
+		r.With(s.requireAuth).Get("/me/export", s.handleExportAccount)
+		r.Route("/me/sessions", func(r chi.Router) {
+			r.Use(s.requireAuth)
+			r.Get("/", s.handleGetSessions)
+			r.Delete("/", s.handleRevokeOtherSessions)
+			r.Delete("/{id}", s.handleRevokeSession)
+		})
+		r.Route("/me/tierlists", func(r chi.Router) {
+			r.Use(s.requireAuth)
+			r.Get("/", s.handleGetMyTierLists)
+			r.Post("/bulk/delete", s.handleBulkDeleteTierLists)
+			r.Post("/bulk/visibility", s.handleBulkSetTierListsVisibility)
+			r.Post("/bulk/tags", s.handleBulkRetagTierLists)
+		})
+		r.With(s.requireAuth).Post("/me/claim", s.handleClaimTierLists)
+		r.With(s.requireAuth).Get("/me/workspaces", s.handleGetMyWorkspaces)
+		r.With(s.requireAuth).Get("/me/quota", s.handleGetMyQuota)
+		r.Route("/me/favorites", func(r chi.Router) {
+			r.Use(s.requireAuth)
+			r.Get("/", s.handleGetFavorites)
+			r.Put("/tierlists/{id}", s.handleFavoriteTierList)
+			r.Delete("/tierlists/{id}", s.handleUnfavorite(models.FavoriteTierList))
+			r.Put("/items/{gameID}/{itemID}", s.handleFavoriteItem)
+			r.Delete("/items/{gameID}/{itemID}", s.handleUnfavorite(models.FavoriteItem))
+		})
+
+		// Admin
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(s.requireRole(models.RoleAdmin, models.RoleCurator))
+
+			// Catalog, scoped per game for curators
+			r.Route("/games/{gameID}", func(r chi.Router) {
+				r.Use(s.requireGameAccess)
+				r.Put("/", s.handlePutGame)
+				r.Get("/stats", s.handleGetGameStats)
+				r.Get("/missing-metadata", s.handleGetMissingMetadata)
+				r.Put("/default-tiers", s.handlePutDefaultTiers)
+				r.Put("/sheets/{sheetID}/default-tiers", s.handlePutSheetDefaultTiers)
+				r.Get("/bundle", s.handleExportGameBundle)
+				r.Post("/items", s.handleCreateItem)
+				r.Put("/items/{itemID}", s.handleUpdateItem)
+				r.Delete("/items/{itemID}", s.handleDeleteItem)
+				r.Put("/items/{itemID}/aliases", s.handlePutItemAliases)
+				r.Post("/relations", s.handleCreateItemRelation)
+				r.Delete("/items/{itemID}/relations/{kind}/{relatedID}", s.handleDeleteItemRelation)
+				r.Get("/snapshots", s.handleGetSnapshots)
+				r.Post("/snapshots/{id}/restore", s.handleRestoreSnapshot)
+				r.Post("/contests", s.handleCreateContest)
+				r.Delete("/contests/{contestID}", s.handleDeleteContest)
+
+				// Import scripts, run by cmd/import on the server host, and
+				// item sources, which fetch URLs from the server
+				r.Group(func(r chi.Router) {
+					r.Use(s.requireRole(models.RoleAdmin))
+					r.Get("/script", s.handleGetGameScript)
+					r.Put("/script", s.handlePutGameScript)
+					r.Delete("/script", s.handleDeleteGameScript)
+
+					// Upstream APIs synced into sheets by the item_sources job
+					r.Get("/sources", s.handleGetItemSources)
+					r.Post("/sources", s.handleCreateItemSource)
+					r.Put("/sources/{id}", s.handleUpdateItemSource)
+					r.Delete("/sources/{id}", s.handleDeleteItemSource)
+					r.Post("/sources/{id}/sync", s.handleSyncItemSource)
+				})
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(s.requireRole(models.RoleAdmin))
+
+				// Game bundles
+				r.Post("/bundles", s.handleImportGameBundle)
+				r.Route("/packs", func(r chi.Router) {
+					r.Use(s.requirePacks)
+					r.Get("/", s.handleGetPacks)
+					r.Get("/{id}", s.handleGetPack)
+					r.Post("/{id}/install", s.handleInstallPack)
+				})
+
+				// Palettes
+				r.Put("/palettes/{id}", s.handlePutPalette)
+				r.Delete("/palettes/{id}", s.handleDeletePalette)
+
+				// Jobs
+				r.Get("/jobs", s.handleGetJobs)
+				r.Post("/jobs/{name}/run", s.handleRunJob)
+				r.Get("/retention", s.handleGetRetention)
+
+				// Runtime stats
+				r.Get("/stats", s.handleGetStats)
+				if s.config.PprofEnabled {
+					r.Route("/debug/pprof", s.profilingRoutes)
+				}
+
+				// Audit
+				r.Get("/audit", s.handleGetAudit)
+				r.Post("/audit/{id}/revert", s.handleRevertAudit)
+
+				// Users
+				r.Get("/users", s.handleGetUsers)
+				r.Put("/users/{id}/role", s.handleSetUserRole)
+				r.Get("/users/{id}/quota", s.handleGetUserQuota)
+				r.Put("/users/{id}/quota", s.handlePutUserQuota)
+				r.Delete("/users/{id}/quota", s.handleDeleteUserQuota)
+
+				// Moderation
+				r.Get("/reports", s.handleGetReports)
+				r.Post("/tierlists/{id}/hide", s.handleSetTierListHidden(true))
+				r.Post("/tierlists/{id}/unhide", s.handleSetTierListHidden(false))
+				r.Post("/tierlists/{id}/ban", s.handleBanTierListAuthor)
+				r.Delete("/tierlists/{id}", s.handleAdminDeleteTierList)
+				r.Get("/bans", s.handleGetBans)
+				r.Post("/bans", s.handleCreateBan)
+				r.Delete("/bans/{id}", s.handleDeleteBan)
+
+				// Share code redirects
+				r.Get("/redirects", s.handleGetRedirects)
+				r.Put("/redirects/{code}", s.handlePutRedirect)
+				r.Delete("/redirects/{code}", s.handleDeleteRedirect)
+			})
+		})
 	})
+
+	// Health checks
+	s.router.Get("/health", s.handleHealth)
+	s.router.With(s.requireMonitoring).Get("/health/deep", s.handleDeepHealth)
 }
 
 // --- Response helpers ---
@@ -89,6 +440,17 @@ func respondError(w http.ResponseWriter, status int, message string) {
 	respondJSON(w, status, map[string]string{"error": message})
 }
 
+// respondWriteError reports a failed write, as 503 with Retry-After when the
+// database stayed busy so clients retry instead of giving up
+func respondWriteError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, storage.ErrBusy) {
+		w.Header().Set("Retry-After", "1")
+		respondError(w, http.StatusServiceUnavailable, "The server is busy, please retry")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, message)
+}
+
 func decodeJSON(r *http.Request, v interface{}) error {
 	return json.NewDecoder(r.Body).Decode(v)
 }
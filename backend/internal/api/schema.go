@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// handleGetItemSchema documents the item data fields of each of a game's
+// sheets, as JSON or, with ?format=markdown, as a Markdown reference
+func (s *Server) handleGetItemSchema(w http.ResponseWriter, r *http.Request) {
+	game, err := s.storeFor(r).GetGame(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	schema, err := models.ParseItemSchema(game.ItemSchema)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Game has an invalid item schema")
+		return
+	}
+	sheets := schema.Describe(game.Sheets)
+	if sheetID := r.URL.Query().Get("sheet"); sheetID != "" {
+		if game.Sheet(sheetID) == nil {
+			respondError(w, http.StatusNotFound, "Sheet not found")
+			return
+		}
+		sheets = schema.Describe([]models.SheetConfig{*game.Sheet(sheetID)})
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		respondJSON(w, http.StatusOK, sheets)
+	case "markdown":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(schemaMarkdown(game, sheets)))
+	default:
+		respondError(w, http.StatusBadRequest, "format must be json or markdown")
+	}
+}
+
+// schemaMarkdown renders one table of item data fields per sheet
+func schemaMarkdown(game *models.Game, sheets []models.SheetSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s item data\n", game.Name)
+	for _, sheet := range sheets {
+		fmt.Fprintf(&b, "\n## %s (`%s`)\n\n", sheet.Name, sheet.SheetID)
+		if len(sheet.Fields) == 0 {
+			b.WriteString("No documented fields.\n")
+			continue
+		}
+		b.WriteString("| Field | Type | Label | Required | Values |\n|---|---|---|---|---|\n")
+		for _, f := range sheet.Fields {
+			required := ""
+			if f.Required {
+				required = "yes"
+			}
+			fmt.Fprintf(&b, "| `data.%s` | %s | %s | %s | %s |\n",
+				f.Name, f.Type, markdownCell(f.Label), required, markdownCell(strings.Join(f.Options, ", ")))
+		}
+	}
+	return b.String()
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// maxTierPoints bounds tier point values
+const maxTierPoints = 1e6
+
+const errTierPoints = "tier points must be between -1000000 and 1000000"
+
+// pointsInRange reports whether optional tier points are within bounds
+func pointsInRange(points *float64) bool {
+	return points == nil || math.Abs(*points) <= maxTierPoints
+}
+
+// setScore fills in the build score of a tier list's current items
+func setScore(tl *models.TierList) {
+	if score := models.ScoreTiers(tl.Tiers); score != nil {
+		tl.Score = &score.Total
+	}
+}
+
+// checkTierPoints rejects point values outside ±maxTierPoints
+func checkTierPoints(w http.ResponseWriter, tiers []models.Tier) bool {
+	for _, tier := range tiers {
+		if !pointsInRange(tier.Points) {
+			respondError(w, http.StatusBadRequest, errTierPoints)
+			return false
+		}
+	}
+	return true
+}
+
+// handleGetTierListScore returns a per-tier breakdown of a tier list's build
+// score. ?filter= scores only the matching items, for theorycrafting one
+// subset of a build.
+func (s *Server) handleGetTierListScore(w http.ResponseWriter, r *http.Request) {
+	tl, err := s.viewableTierList(r, chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if tl == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	if !s.hideTierListSpoilers(w, r, tl) || !s.filterTierListItems(w, r, tl) {
+		return
+	}
+	score := models.ScoreTiers(tl.Tiers)
+	if score == nil {
+		respondError(w, http.StatusNotFound, "This tier list has no tier points")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, score)
+}
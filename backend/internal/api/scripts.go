@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins/script"
+)
+
+// handleGetGameScript returns the Lua script attached to a game
+func (s *Server) handleGetGameScript(w http.ResponseWriter, r *http.Request) {
+	gs, err := s.storeFor(r).GetGameScript(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch script")
+		return
+	}
+	if gs == nil {
+		respondError(w, http.StatusNotFound, "Game has no script")
+		return
+	}
+	respondJSON(w, http.StatusOK, gs)
+}
+
+// handlePutGameScript attaches a Lua script to a game for cmd/import to run.
+// The script is compiled and its top level run first, so scripts that do not
+// load are rejected here rather than at import time.
+func (s *Server) handlePutGameScript(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	game, err := s.storeFor(r).GetGame(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return
+	}
+	sc, err := script.Compile(gameID, req.Source)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid script: "+err.Error())
+		return
+	}
+	sc.Close()
+
+	before, err := s.storeFor(r).GetGameScript(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch script")
+		return
+	}
+	gs := &models.GameScript{GameID: gameID, Source: req.Source, UpdatedBy: auditActor(r)}
+	if err := s.storeFor(r).SaveGameScript(gs); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save script")
+		return
+	}
+	s.auditChange(r, "game.script.update", "game_script", gameID, gameID, before, gs)
+
+	respondJSON(w, http.StatusOK, gs)
+}
+
+// handleDeleteGameScript detaches a game's script
+func (s *Server) handleDeleteGameScript(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	deleted, err := s.storeFor(r).DeleteGameScript(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete script")
+		return
+	}
+	if !deleted {
+		respondError(w, http.StatusNotFound, "Game has no script")
+		return
+	}
+	s.audit(r, "game.script.delete", "game_script", gameID, "")
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meur/tierforge/internal/config"
+	"github.com/meur/tierforge/internal/email"
+	"github.com/meur/tierforge/internal/jobs"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// newTestServer returns a server over a fresh database holding game "g"
+// with sheet "main". configure, when given, adjusts the loaded config.
+func newTestServer(t *testing.T, configure func(*config.Config)) (*Server, *storage.Store) {
+	t.Helper()
+	store, err := storage.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if err := store.CreateGame(&models.Game{ID: "g", Name: "G", Sheets: []models.SheetConfig{{ID: "main", Name: "Main"}}}); err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.ImageCacheDir = ""
+	if configure != nil {
+		configure(cfg)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s := New(ctx, store, cfg, jobs.NewScheduler(store), email.New(cfg), nil)
+	// Registered after the store so queued autosaves flush before it closes
+	t.Cleanup(s.Close)
+	return s, store
+}
+
+// serve sends a request with a browser user agent and returns the recorded
+// response. token, when set, signs the request in.
+func serve(s *Server, method, path, token string, body interface{}, adjust func(*http.Request)) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	r := httptest.NewRequest(method, path, bytes.NewReader(data))
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	r.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	if adjust != nil {
+		adjust(r)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+// signUp registers an account and returns its session token
+func signUp(t *testing.T, s *Server, emailAddr string) string {
+	t.Helper()
+	w := serve(s, "POST", "/api/auth/register", "", map[string]string{
+		"email": emailAddr, "password": "password123!", "display_name": "Tester",
+	}, nil)
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Token == "" {
+		t.Fatalf("register %s: %d %s", emailAddr, w.Code, w.Body)
+	}
+	return resp.Token
+}
+
+// decodeBody unmarshals a recorded JSON response into v
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %d response %q: %v", w.Code, w.Body, err)
+	}
+}
+
+// rawInfobox is infobox HTML as imports stored it before it was sanitized
+const rawInfobox = `<b onclick="steal()">Bold</b><script>alert(1)</script>`
+
+// createRawItems adds items to game "g" sheet "main" whose data still holds
+// unsanitized infobox HTML
+func createRawItems(t *testing.T, store *storage.Store, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		item := &models.Item{ID: id, GameID: "g", SheetID: "main", Name: "Item " + id,
+			Data: map[string]interface{}{"infobox_html": rawInfobox}}
+		if err := store.CreateItem(item); err != nil {
+			t.Fatalf("create item %s: %v", id, err)
+		}
+	}
+}
+
+// assertSanitized fails the test if a response body still carries the raw
+// infobox markup
+func assertSanitized(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	body := w.Body.String()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d %s", w.Code, body)
+	}
+	if strings.Contains(body, "onclick") || strings.Contains(body, "script") {
+		t.Errorf("response serves unsanitized HTML: %s", body)
+	}
+	if !strings.Contains(body, `\u003cb\u003eBold\u003c/b\u003e`) {
+		t.Errorf("response lacks the sanitized infobox: %s", body)
+	}
+}
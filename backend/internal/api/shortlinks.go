@@ -0,0 +1,61 @@
+package api
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// setupShortlinks builds the router of the shortlink domain. It resolves
+// share codes and nothing else, so the API and app stay off the domain.
+func (s *Server) setupShortlinks() {
+	r := chi.NewRouter()
+	r.Use(s.forwardedClient)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, s.config.PublicURL+"/", http.StatusFound)
+	})
+	r.Get("/{code}", s.handleShortlink)
+	s.shortlinks = r
+}
+
+// isShortHost reports whether r was made to the shortlink domain
+func (s *Server) isShortHost(r *http.Request) bool {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.EqualFold(host, s.config.ShortDomain)
+}
+
+// handleShortlink redirects a share code, or a legacy code mapped to one, to
+// the tier list's page in the app. Lists are looked up on every request, so
+// the redirect is temporary and stops once a list is hidden.
+func (s *Server) handleShortlink(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	tl, err := s.storeFor(r).GetTierListByShareCode(code)
+	if err == nil && tl == nil {
+		var rd *models.URLRedirect
+		if rd, err = s.storeFor(r).GetURLRedirect(code); err == nil && rd != nil {
+			code = rd.To
+			tl, err = s.storeFor(r).GetTierListByShareCode(code)
+		}
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to resolve shortlink %s: %v", code, err)
+		http.Error(w, "Failed to resolve link", http.StatusInternalServerError)
+		return
+	}
+	if tl == nil || tl.Hidden || tl.Status == models.TierListDraft {
+		http.NotFound(w, r)
+		return
+	}
+	http.Redirect(w, r, s.config.PublicURL+"/?s="+url.QueryEscape(code), http.StatusFound)
+}
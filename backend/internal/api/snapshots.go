@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handleGetSnapshots lists import snapshots of a game
+func (s *Server) handleGetSnapshots(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+
+	snaps, err := s.storeFor(r).GetSnapshots(gameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch snapshots")
+		return
+	}
+	respondJSON(w, http.StatusOK, snaps)
+}
+
+// handleRestoreSnapshot rolls a game's catalog back to a snapshot, saving the
+// current state as a new snapshot first
+func (s *Server) handleRestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid snapshot id")
+		return
+	}
+
+	snap, err := s.storeFor(r).GetSnapshot(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch snapshot")
+		return
+	}
+	if snap == nil || snap.GameID != gameID {
+		respondError(w, http.StatusNotFound, "Snapshot not found")
+		return
+	}
+
+	backup, err := s.storeFor(r).SnapshotItems(snap.GameID, snap.SheetID, "api:restore")
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to snapshot current items")
+		return
+	}
+	if err := s.storeFor(r).RestoreSnapshot(snap); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to restore snapshot")
+		return
+	}
+	s.writeAuditFor(r, "items.rollback", "snapshot", fmt.Sprint(snap.ID), gameID,
+		fmt.Sprintf("restored %d items from %s; previous state saved as #%d", snap.ItemCount, snap.Source, backup.ID))
+	s.rebuildSprites(gameID)
+
+	snap.Items = nil
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"restored": snap,
+		"backup":   backup,
+	})
+}
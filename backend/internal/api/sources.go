@@ -0,0 +1,173 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/connector"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// handleGetItemSources lists the upstream APIs a game's sheets sync from
+func (s *Server) handleGetItemSources(w http.ResponseWriter, r *http.Request) {
+	sources, err := s.storeFor(r).GetItemSources(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item sources")
+		return
+	}
+	for i := range sources {
+		sources[i].Redact()
+	}
+	respondJSON(w, http.StatusOK, sources)
+}
+
+// handleCreateItemSource adds an upstream API a sheet is synced from. The
+// first sync runs with the next item_sources job, or on demand.
+func (s *Server) handleCreateItemSource(w http.ResponseWriter, r *http.Request) {
+	gameID := chi.URLParam(r, "gameID")
+	var src models.ItemSource
+	if err := decodeJSON(r, &src); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	src.GameID = gameID
+	src.CreatedBy = auditActor(r)
+	if !s.validateItemSource(w, r, &src) {
+		return
+	}
+
+	if err := s.storeFor(r).CreateItemSource(&src); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save item source")
+		return
+	}
+	src.Redact()
+	s.auditChange(r, "item_source.create", "item_source", fmt.Sprint(src.ID), gameID, nil, src)
+
+	respondJSON(w, http.StatusCreated, src)
+}
+
+// handleUpdateItemSource replaces a source's settings. An empty auth_value
+// keeps the stored one while the auth_header stays set.
+func (s *Server) handleUpdateItemSource(w http.ResponseWriter, r *http.Request) {
+	before, ok := s.lookupItemSource(w, r)
+	if !ok {
+		return
+	}
+	var src models.ItemSource
+	if err := decodeJSON(r, &src); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	src.ID, src.GameID = before.ID, before.GameID
+	if src.AuthValue == "" && src.AuthHeader != "" {
+		src.AuthValue = before.AuthValue
+	}
+	if !s.validateItemSource(w, r, &src) {
+		return
+	}
+
+	if err := s.storeFor(r).UpdateItemSource(&src); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save item source")
+		return
+	}
+	saved, err := s.storeFor(r).GetItemSource(src.GameID, src.ID)
+	if err != nil || saved == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item source")
+		return
+	}
+	before.Redact()
+	saved.Redact()
+	s.auditChange(r, "item_source.update", "item_source", fmt.Sprint(src.ID), src.GameID, before, saved)
+
+	respondJSON(w, http.StatusOK, saved)
+}
+
+// handleDeleteItemSource stops syncing a source; its items stay
+func (s *Server) handleDeleteItemSource(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.lookupItemSource(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.storeFor(r).DeleteItemSource(src.GameID, src.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete item source")
+		return
+	}
+	src.Redact()
+	s.auditChange(r, "item_source.delete", "item_source", fmt.Sprint(src.ID), src.GameID, src, nil)
+
+	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleSyncItemSource syncs a source now. With ?dry_run=true it only
+// reports what would change, which helps when writing a field mapping.
+func (s *Server) handleSyncItemSource(w http.ResponseWriter, r *http.Request) {
+	src, ok := s.lookupItemSource(w, r)
+	if !ok {
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	start := time.Now()
+	result, err := connector.New(time.Minute).Sync(r.Context(), s.storeFor(r), src, dryRun)
+	if !dryRun {
+		if recErr := s.storeFor(r).RecordItemSourceSync(src.ID, start, result, err); recErr != nil {
+			log.Printf("ERROR: Failed to record sync of item source %d: %v", src.ID, recErr)
+		}
+	}
+	if err != nil {
+		log.Printf("ERROR: Sync of item source %d failed: %v", src.ID, err)
+		respondError(w, http.StatusBadGateway, "Sync failed: "+err.Error())
+		return
+	}
+	if !dryRun && len(result.Changed) > 0 {
+		s.rebuildSprites(src.GameID)
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// lookupItemSource resolves the source in the URL, writing the error
+// response itself
+func (s *Server) lookupItemSource(w http.ResponseWriter, r *http.Request) (*models.ItemSource, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid item source id")
+		return nil, false
+	}
+	src, err := s.storeFor(r).GetItemSource(chi.URLParam(r, "gameID"), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch item source")
+		return nil, false
+	}
+	if src == nil {
+		respondError(w, http.StatusNotFound, "Item source not found")
+		return nil, false
+	}
+	return src, true
+}
+
+// validateItemSource checks a source's settings and that it targets a real,
+// non-virtual sheet, writing the error response itself
+func (s *Server) validateItemSource(w http.ResponseWriter, r *http.Request, src *models.ItemSource) bool {
+	if err := connector.Validate(src); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	game, err := s.storeFor(r).GetGame(src.GameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return false
+	}
+	if game == nil {
+		respondError(w, http.StatusNotFound, "Game not found")
+		return false
+	}
+	if sheet := game.Sheet(src.SheetID); sheet == nil || sheet.Virtual {
+		respondError(w, http.StatusBadRequest, "Invalid sheet_id")
+		return false
+	}
+	return true
+}
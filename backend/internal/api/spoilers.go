@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// validateSpoilerPolicy checks a game's spoiler policy; nil is allowed
+func validateSpoilerPolicy(p *models.SpoilerPolicy) error {
+	if p == nil {
+		return nil
+	}
+	switch p.Mode {
+	case "":
+		p.Mode = models.SpoilerBlur
+	case models.SpoilerBlur, models.SpoilerExclude:
+	default:
+		return fmt.Errorf("spoilers.mode must be blur or exclude")
+	}
+	return nil
+}
+
+// spoilerMode returns how spoiler items of the game are shown to this request:
+// "" when they are shown, otherwise the game's blur or exclude mode.
+// ?spoilers=hide and ?spoilers=show override the game's default.
+func spoilerMode(w http.ResponseWriter, r *http.Request, game *models.Game) (string, bool) {
+	hide := game.Spoilers != nil && game.Spoilers.HideByDefault
+	switch r.URL.Query().Get("spoilers") {
+	case "":
+	case "hide":
+		hide = true
+	case "show":
+		hide = false
+	default:
+		respondError(w, http.StatusBadRequest, "spoilers must be hide or show")
+		return "", false
+	}
+	if !hide {
+		return "", true
+	}
+	if game.Spoilers != nil && game.Spoilers.Mode == models.SpoilerExclude {
+		return models.SpoilerExclude, true
+	}
+	return models.SpoilerBlur, true
+}
+
+// hideSpoilerItems blurs or drops spoiler items from a catalog listing
+func hideSpoilerItems(items []models.Item, mode string) []models.Item {
+	if mode == "" {
+		return items
+	}
+	kept := items[:0]
+	for _, item := range items {
+		if item.Spoiler {
+			if mode == models.SpoilerExclude {
+				continue
+			}
+			item.Blur()
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+// hideTierListSpoilers removes spoiler items from a tier list's tiers when the
+// request excludes them. Blurred items keep their place; the frontend blurs
+// them using the catalog. It writes the error response and returns false on
+// failure.
+func (s *Server) hideTierListSpoilers(w http.ResponseWriter, r *http.Request, tl *models.TierList) bool {
+	game, err := s.storeFor(r).GetGame(tl.GameID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return false
+	}
+	if game == nil {
+		return true
+	}
+	mode, ok := spoilerMode(w, r, game)
+	if !ok {
+		return false
+	}
+	if mode != models.SpoilerExclude {
+		return true
+	}
+
+	spoilers, err := s.storeFor(r).GetSpoilerItemIDs(game.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return false
+	}
+	dropTierItems(tl.Tiers, spoilers)
+	return true
+}
+
+// dropTierItems removes the dropped items from tiers in place
+func dropTierItems(tiers []models.Tier, drop map[string]bool) {
+	for i := range tiers {
+		kept := make([]string, 0, len(tiers[i].Items))
+		for _, id := range tiers[i].Items {
+			if !drop[id] {
+				kept = append(kept, id)
+			}
+		}
+		tiers[i].Items = kept
+	}
+}
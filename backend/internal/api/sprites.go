@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/sprites"
+)
+
+// spriteSheetGame loads the game and sheet of a sprite sheet request
+func (s *Server) spriteSheetGame(w http.ResponseWriter, r *http.Request) (*models.Game, string, bool) {
+	game, err := s.storeFor(r).GetGame(chi.URLParam(r, "gameID"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+		return nil, "", false
+	}
+	sheetID := chi.URLParam(r, "sheetID")
+	if game == nil || game.Sheet(sheetID) == nil {
+		respondError(w, http.StatusNotFound, "Sheet not found")
+		return nil, "", false
+	}
+	return game, sheetID, true
+}
+
+// handleGetSpriteSheet returns the coordinate map of a sheet's sprite sheet.
+// A sheet whose icons changed since the last build is rebuilt in the
+// background; meanwhile the old map is returned without the changed items.
+func (s *Server) handleGetSpriteSheet(w http.ResponseWriter, r *http.Request) {
+	game, sheetID, ok := s.spriteSheetGame(w, r)
+	if !ok {
+		return
+	}
+	sheet, err := s.storeFor(r).GetSpriteSheet(game.ID, sheetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch sprite sheet")
+		return
+	}
+	if sheet == nil {
+		s.sprites.Enqueue(game.ID, sheetID)
+		w.Header().Set("Retry-After", "30")
+		respondError(w, http.StatusNotFound, "Sprite sheet is being generated")
+		return
+	}
+	all, err := s.storeFor(r).GetItems(game.ID, sheetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch items")
+		return
+	}
+	items := sprites.Spritable(all)
+	if sprites.Version(items) != sheet.Version {
+		s.sprites.Enqueue(game.ID, sheetID)
+		sheet.Stale = true
+		current := make(map[string]string, len(items))
+		for _, item := range items {
+			current[item.ID] = item.Icon
+		}
+		for id, cell := range sheet.Cells {
+			if current[id] != cell.Icon {
+				delete(sheet.Cells, id)
+			}
+		}
+	}
+	sheet.ImageURL = fmt.Sprintf("/api/games/%s/sheets/%s/sprite.png?v=%s", game.ID, sheetID, sheet.Version)
+
+	respondJSON(w, http.StatusOK, sheet)
+}
+
+// handleGetSpriteImage returns a sprite sheet PNG. Requests carrying the
+// current ?v= may cache it forever since a rebuild changes the version.
+func (s *Server) handleGetSpriteImage(w http.ResponseWriter, r *http.Request) {
+	game, sheetID, ok := s.spriteSheetGame(w, r)
+	if !ok {
+		return
+	}
+	data, version, err := s.storeFor(r).GetSpriteImage(game.ID, sheetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch sprite sheet")
+		return
+	}
+	if data == nil {
+		respondError(w, http.StatusNotFound, "Sprite sheet not found")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	if r.URL.Query().Get("v") == version {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.Header().Set("ETag", `"`+version+`"`)
+	w.Write(data)
+}
+
+// rebuildSprites schedules sprite sheet rebuilds after a catalog import
+func (s *Server) rebuildSprites(gameID string) {
+	game, err := s.store.GetGame(gameID)
+	if err != nil {
+		log.Printf("ERROR: Failed to schedule sprite sheets for %s: %v", gameID, err)
+		return
+	}
+	if game != nil {
+		s.sprites.EnqueueGame(game)
+	}
+}
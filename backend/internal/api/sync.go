@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+const (
+	// maxSyncChanges bounds the tier changes of one sync request
+	maxSyncChanges = 100
+	// maxReplicaLength bounds client replica IDs
+	maxReplicaLength = 64
+)
+
+// handleSyncTierList merges tier changes a client made, possibly offline,
+// into a tier list and returns the merged state with the sync clocks of its
+// tiers. Each change wins when its vector clock has seen the server's edits
+// to the tier; concurrent edits are resolved by the latest modified_at.
+func (s *Server) handleSyncTierList(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	existing, err := s.storeFor(r).GetTierList(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if existing == nil {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	if !canEditTierList(r, existing) {
+		respondError(w, http.StatusForbidden, "You cannot edit this tier list")
+		return
+	}
+
+	var req models.TierListSync
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Replica == "" || len(req.Replica) > maxReplicaLength || req.Replica == models.ServerReplica {
+		respondError(w, http.StatusBadRequest, "replica must be a device ID of at most 64 characters")
+		return
+	}
+	if len(req.Changes) > maxSyncChanges {
+		respondError(w, http.StatusBadRequest, "A sync can carry at most 100 tier changes")
+		return
+	}
+	for _, c := range req.Changes {
+		if c.Tier.ID == "" {
+			respondError(w, http.StatusBadRequest, "Every change needs a tier id")
+			return
+		}
+		for _, n := range c.Clock.Vector {
+			if n < 0 {
+				respondError(w, http.StatusBadRequest, "Clock counters must not be negative")
+				return
+			}
+		}
+	}
+	if len(req.Moves) > maxSyncChanges {
+		respondError(w, http.StatusBadRequest, "A sync can carry at most 100 item moves")
+		return
+	}
+	for _, m := range req.Moves {
+		if m.ItemID == "" {
+			respondError(w, http.StatusBadRequest, "Every move needs an item id")
+			return
+		}
+		if m.TierID != "" && !ranking.ValidPosition(m.Position) {
+			respondError(w, http.StatusBadRequest, "Moves into a tier need a position key of base-62 digits not ending in 0")
+			return
+		}
+	}
+	if len(req.Changes) > 0 || len(req.Moves) > 0 {
+		if tierListLocked(w, existing) {
+			return
+		}
+		if existing.Status == models.TierListArchived {
+			respondError(w, http.StatusConflict, "Archived tier lists are read-only; publish it again to edit")
+			return
+		}
+		if pollLocksTiers(w, existing) {
+			return
+		}
+	}
+
+	clocks, err := s.storeFor(r).GetTierClocks(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch sync state")
+		return
+	}
+	stored, err := s.storeFor(r).GetItemPositions(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch sync state")
+		return
+	}
+	result := models.TierListSyncResult{
+		TierList:      existing,
+		Clocks:        clocks,
+		Applied:       []string{},
+		Rejected:      []string{},
+		MovesApplied:  []string{},
+		MovesRejected: []string{},
+	}
+	if len(req.Changes) == 0 && len(req.Moves) == 0 {
+		// Reconciling is deterministic, so the keys hold until the next write
+		result.Positions = ranking.ReconcilePositions(existing.Tiers, stored, clocks)
+		setScore(existing)
+		respondJSON(w, http.StatusOK, result)
+		return
+	}
+
+	now := time.Now()
+	tiers, changed := existing.Tiers, make(map[string]models.TierClock)
+	if len(req.Changes) > 0 {
+		tiers, changed = mergeTierChanges(existing.Tiers, clocks, req.Replica, req.Changes, now, &result)
+	}
+	positions := ranking.ReconcilePositions(tiers, stored, clocks)
+	if len(req.Moves) > 0 {
+		tiers = applyItemMoves(tiers, positions, clocks, changed, req.Replica, req.Moves, now, &result)
+	}
+	merged := *existing
+	merged.Tiers = tiers
+	warnings, ok := s.checkEditedTiers(w, &merged)
+	if !ok {
+		return
+	}
+
+	s.autosaves.Discard(id)
+	err = s.storeFor(r).WithTx(r.Context(), func(tx *storage.Store) error {
+		if err := tx.UpdateTierList(id, &models.TierListUpdate{Tiers: tiers}); err != nil {
+			return err
+		}
+		if err := tx.SaveTierClocks(id, changed); err != nil {
+			return err
+		}
+		return tx.SaveItemPositions(id, positions)
+	})
+	if err != nil {
+		respondWriteError(w, err, "Failed to sync tier list")
+		return
+	}
+	if moved := movedItemCount(existing.Tiers, tiers); moved > 0 {
+		s.recordEvent(r, models.EventItemsMoved, existing.GameID, map[string]interface{}{"moved": moved})
+	}
+
+	if result.TierList, err = s.storeFor(r).GetTierList(id); err != nil || result.TierList == nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	result.TierList.ColorWarnings = warnings
+	result.Positions = positions
+	setScore(result.TierList)
+	s.prerenderTierList(result.TierList)
+	respondJSON(w, http.StatusOK, result)
+}
+
+// mergeTierChanges applies the changes replica made to tiers. clocks is
+// updated in place and the clocks that changed are returned along with the
+// merged tiers. Changes dated in the future count as made at now. An item
+// left in several tiers stays in the one edited last.
+func mergeTierChanges(tiers []models.Tier, clocks map[string]models.TierClock, replica string,
+	changes []models.TierChange, now time.Time, result *models.TierListSyncResult) ([]models.Tier, map[string]models.TierClock) {
+	byID := make(map[string]models.Tier, len(tiers))
+	order := make([]string, 0, len(tiers))
+	for _, t := range tiers {
+		byID[t.ID] = t
+		order = append(order, t.ID)
+	}
+
+	changed := make(map[string]models.TierClock)
+	for _, c := range changes {
+		id := c.Tier.ID
+		if c.Clock.ModifiedAt.After(now) {
+			c.Clock.ModifiedAt = now
+		}
+		current := clocks[id]
+
+		// A retried sync resends the edit that already won
+		retried := current.Replica == replica && c.Clock.Vector[replica] > 0 &&
+			c.Clock.Vector[replica] == current.Vector[replica]
+		wins := false
+		switch c.Clock.Vector.Compare(current.Vector) {
+		case models.ClockAfter:
+			wins = true
+		case models.ClockEqual:
+			retried = true
+		case models.ClockConcurrent:
+			wins = c.Clock.ModifiedAt.After(current.ModifiedAt) ||
+				(c.Clock.ModifiedAt.Equal(current.ModifiedAt) && replica > current.Replica)
+		}
+		if retried && !wins {
+			result.Applied = append(result.Applied, id)
+			continue
+		}
+
+		if !wins {
+			result.Rejected = append(result.Rejected, id)
+			// Having seen the losing edit, the server's state now supersedes it
+			current.Vector = current.Vector.Merge(c.Clock.Vector)
+			clocks[id], changed[id] = current, current
+			continue
+		}
+		if c.Deleted {
+			delete(byID, id)
+		} else {
+			if c.Tier.Items == nil {
+				c.Tier.Items = []string{}
+			}
+			if _, ok := byID[id]; !ok {
+				order = append(order, id)
+			}
+			byID[id] = c.Tier
+		}
+		clock := models.TierClock{
+			Vector:     current.Vector.Merge(c.Clock.Vector),
+			ModifiedAt: c.Clock.ModifiedAt,
+			Replica:    replica,
+			Deleted:    c.Deleted,
+		}
+		clocks[id], changed[id] = clock, clock
+		result.Applied = append(result.Applied, id)
+	}
+
+	merged := make([]models.Tier, 0, len(byID))
+	for _, id := range order {
+		if t, ok := byID[id]; ok {
+			merged = append(merged, t)
+			delete(byID, id)
+		}
+	}
+	merged = ranking.SortedTiers(merged)
+
+	// Place each item only in the most recently edited tier holding it
+	latest := make([]int, len(merged))
+	for i := range latest {
+		latest[i] = i
+	}
+	sort.SliceStable(latest, func(a, b int) bool {
+		return clocks[merged[latest[a]].ID].ModifiedAt.After(clocks[merged[latest[b]].ID].ModifiedAt)
+	})
+	seen := make(map[string]bool)
+	for _, i := range latest {
+		items := make([]string, 0, len(merged[i].Items))
+		for _, item := range merged[i].Items {
+			if !seen[item] {
+				seen[item] = true
+				items = append(items, item)
+			}
+		}
+		merged[i].Items = items
+	}
+	return merged, changed
+}
+
+// applyItemMoves places the items replica moved and reorders the tiers they
+// left or entered by position. Each of those tiers counts as edited by
+// replica at the time of its latest move, updating clocks and changed in
+// place.
+func applyItemMoves(tiers []models.Tier, positions map[string]models.ItemPosition, clocks, changed map[string]models.TierClock,
+	replica string, moves []models.ItemMove, now time.Time, result *models.TierListSyncResult) []models.Tier {
+	applied, rejected, touched := ranking.ApplyItemMoves(tiers, positions, replica, moves, now)
+	result.MovesApplied = append(result.MovesApplied, applied...)
+	result.MovesRejected = append(result.MovesRejected, rejected...)
+	if len(touched) == 0 {
+		return tiers
+	}
+
+	for id, at := range touched {
+		clock := clocks[id]
+		clock.Vector = clock.Vector.Merge(models.VectorClock{replica: clock.Vector[replica] + 1})
+		if at.After(clock.ModifiedAt) {
+			clock.ModifiedAt = at
+		}
+		clock.Replica = replica
+		clocks[id], changed[id] = clock, clock
+	}
+	return ranking.OrderTiers(tiers, positions)
+}
+
+// updateTierList saves an edit made through the regular endpoints. Tiers it
+// changes are counted as edits by the server replica, so offline changes made
+// without seeing them are merged as concurrent rather than overwriting them.
+// The item positions are reconciled with the new tiers in the same write.
+func (s *Server) updateTierList(ctx context.Context, id string, before []models.Tier, update *models.TierListUpdate) error {
+	if update.Tiers == nil {
+		return s.store.WithContext(ctx).UpdateTierList(id, update)
+	}
+	ids, deleted := changedTiers(before, update.Tiers)
+	return s.store.WithTx(ctx, func(tx *storage.Store) error {
+		if err := tx.UpdateTierList(id, update); err != nil {
+			return err
+		}
+		if err := tx.AdvanceTierClocks(id, ids, models.ServerReplica, deleted); err != nil {
+			return err
+		}
+		clocks, err := tx.GetTierClocks(id)
+		if err != nil {
+			return err
+		}
+		stored, err := tx.GetItemPositions(id)
+		if err != nil {
+			return err
+		}
+		return tx.SaveItemPositions(id, ranking.ReconcilePositions(update.Tiers, stored, clocks))
+	})
+}
+
+// changedTiers returns the IDs of the tiers that differ between before and
+// after, marking those after no longer has as deleted
+func changedTiers(before, after []models.Tier) ([]string, map[string]bool) {
+	old := make(map[string]models.Tier, len(before))
+	for _, t := range before {
+		old[t.ID] = t
+	}
+	var ids []string
+	deleted := make(map[string]bool)
+	for _, t := range after {
+		if prev, ok := old[t.ID]; !ok || !reflect.DeepEqual(prev, t) {
+			ids = append(ids, t.ID)
+		}
+		delete(old, t.ID)
+	}
+	for id := range old {
+		ids = append(ids, id)
+		deleted[id] = true
+	}
+	return ids, deleted
+}
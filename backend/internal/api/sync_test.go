@@ -0,0 +1,220 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestMergeTierChanges(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	t0 := now.Add(-time.Hour)
+	later := t0.Add(time.Minute)
+	serverClock := models.TierClock{Vector: models.VectorClock{"server": 1}, ModifiedAt: t0, Replica: models.ServerReplica}
+	change := func(tier models.Tier, vector models.VectorClock, at time.Time) models.TierChange {
+		return models.TierChange{Tier: tier, Clock: models.TierClock{Vector: vector, ModifiedAt: at}}
+	}
+	tierS := func(items ...string) models.Tier { return models.Tier{ID: "S", Order: 0, Items: items} }
+
+	tests := []struct {
+		name     string
+		replica  string
+		current  models.TierClock // Clock of tier S before the sync
+		changes  []models.TierChange
+		want     []string // Merged tiers as id:items
+		applied  []string
+		rejected []string
+		clockS   *models.TierClock // Changed clock of S, nil when unchanged
+	}{
+		{
+			name:    "change that saw the server edit wins",
+			current: serverClock,
+			changes: []models.TierChange{change(tierS("z"), models.VectorClock{"server": 1, "phone": 1}, later)},
+			want:    []string{"S:z", "A:y"},
+			applied: []string{"S"},
+			clockS:  &models.TierClock{Vector: models.VectorClock{"server": 1, "phone": 1}, ModifiedAt: later, Replica: "phone"},
+		},
+		{
+			name:     "stale change loses",
+			current:  serverClock,
+			changes:  []models.TierChange{change(tierS("z"), nil, later)},
+			want:     []string{"S:x", "A:y"},
+			rejected: []string{"S"},
+			clockS:   &serverClock,
+		},
+		{
+			name:    "later concurrent change wins",
+			current: serverClock,
+			changes: []models.TierChange{change(tierS("z"), models.VectorClock{"phone": 1}, later)},
+			want:    []string{"S:z", "A:y"},
+			applied: []string{"S"},
+			clockS:  &models.TierClock{Vector: models.VectorClock{"server": 1, "phone": 1}, ModifiedAt: later, Replica: "phone"},
+		},
+		{
+			name:     "earlier concurrent change loses but is seen",
+			current:  serverClock,
+			changes:  []models.TierChange{change(tierS("z"), models.VectorClock{"phone": 1}, t0.Add(-time.Minute))},
+			want:     []string{"S:x", "A:y"},
+			rejected: []string{"S"},
+			clockS:   &models.TierClock{Vector: models.VectorClock{"server": 1, "phone": 1}, ModifiedAt: t0, Replica: models.ServerReplica},
+		},
+		{
+			name:    "simultaneous edits go to the greater replica",
+			replica: "tablet",
+			current: serverClock,
+			changes: []models.TierChange{change(tierS("z"), models.VectorClock{"tablet": 1}, t0)},
+			want:    []string{"S:z", "A:y"},
+			applied: []string{"S"},
+			clockS:  &models.TierClock{Vector: models.VectorClock{"server": 1, "tablet": 1}, ModifiedAt: t0, Replica: "tablet"},
+		},
+		{
+			name:    "retried change is acknowledged again",
+			current: models.TierClock{Vector: models.VectorClock{"server": 1, "phone": 2}, ModifiedAt: t0, Replica: "phone"},
+			changes: []models.TierChange{change(tierS("z"), models.VectorClock{"server": 1, "phone": 2}, t0)},
+			want:    []string{"S:x", "A:y"},
+			applied: []string{"S"},
+		},
+		{
+			name:    "future edits count as made now",
+			current: serverClock,
+			changes: []models.TierChange{change(tierS("z"), models.VectorClock{"phone": 1}, now.Add(time.Hour))},
+			want:    []string{"S:z", "A:y"},
+			applied: []string{"S"},
+			clockS:  &models.TierClock{Vector: models.VectorClock{"server": 1, "phone": 1}, ModifiedAt: now, Replica: "phone"},
+		},
+		{
+			name:    "deletion",
+			current: serverClock,
+			changes: []models.TierChange{{Tier: models.Tier{ID: "S"}, Deleted: true,
+				Clock: models.TierClock{Vector: models.VectorClock{"server": 1, "phone": 1}, ModifiedAt: later}}},
+			want:    []string{"A:y"},
+			applied: []string{"S"},
+			clockS: &models.TierClock{Vector: models.VectorClock{"server": 1, "phone": 1}, ModifiedAt: later,
+				Replica: "phone", Deleted: true},
+		},
+		{
+			name:    "new tier is added",
+			current: serverClock,
+			changes: []models.TierChange{change(models.Tier{ID: "B", Order: 2}, models.VectorClock{"phone": 1}, later)},
+			want:    []string{"S:x", "A:y", "B:"},
+			applied: []string{"B"},
+		},
+		{
+			name:    "item stays in the tier edited last",
+			current: serverClock,
+			changes: []models.TierChange{change(models.Tier{ID: "A", Order: 1, Items: []string{"y", "x"}},
+				models.VectorClock{"server": 1, "phone": 1}, later)},
+			want:    []string{"S:", "A:y,x"},
+			applied: []string{"A"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replica := tt.replica
+			if replica == "" {
+				replica = "phone"
+			}
+			tiers := []models.Tier{tierS("x"), {ID: "A", Order: 1, Items: []string{"y"}}}
+			clocks := map[string]models.TierClock{"S": tt.current, "A": serverClock}
+			var result models.TierListSyncResult
+
+			merged, changed := mergeTierChanges(tiers, clocks, replica, tt.changes, now, &result)
+			got := make([]string, len(merged))
+			for i, tier := range merged {
+				got[i] = tier.ID + ":" + strings.Join(tier.Items, ",")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tiers = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(result.Applied, tt.applied) || !reflect.DeepEqual(result.Rejected, tt.rejected) {
+				t.Errorf("applied %q, rejected %q; want %q and %q", result.Applied, result.Rejected, tt.applied, tt.rejected)
+			}
+			clock, ok := changed["S"]
+			if tt.clockS == nil {
+				if ok {
+					t.Errorf("clock of S changed to %+v", clock)
+				}
+			} else if !reflect.DeepEqual(clock, *tt.clockS) || !reflect.DeepEqual(clocks["S"], *tt.clockS) {
+				t.Errorf("clock of S = %+v, want %+v", clock, *tt.clockS)
+			}
+		})
+	}
+}
+
+func TestChangedTiers(t *testing.T) {
+	s := models.Tier{ID: "S", Items: []string{"x"}}
+	a := models.Tier{ID: "A", Items: []string{"y"}}
+	tests := []struct {
+		name          string
+		before, after []models.Tier
+		ids           []string
+		deleted       map[string]bool
+	}{
+		{"unchanged", []models.Tier{s, a}, []models.Tier{s, a}, nil, map[string]bool{}},
+		{"reordered items", []models.Tier{s}, []models.Tier{{ID: "S", Items: []string{"x", "y"}}}, []string{"S"}, map[string]bool{}},
+		{"renamed", []models.Tier{s}, []models.Tier{{ID: "S", Name: "Top", Items: []string{"x"}}}, []string{"S"}, map[string]bool{}},
+		{"added", []models.Tier{s}, []models.Tier{s, a}, []string{"A"}, map[string]bool{}},
+		{"removed", []models.Tier{s, a}, []models.Tier{a}, []string{"S"}, map[string]bool{"S": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, deleted := changedTiers(tt.before, tt.after)
+			if !reflect.DeepEqual(ids, tt.ids) || !reflect.DeepEqual(deleted, tt.deleted) {
+				t.Errorf("changedTiers = %q, %v; want %q, %v", ids, deleted, tt.ids, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestUpdateTierListReconcilesPositions(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+
+	w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+		"game_id": "g", "sheet_id": "main", "name": "List",
+		"tiers": []models.Tier{
+			{ID: "s", Name: "S", Color: "#ff7f7f", Items: []string{"a", "b"}},
+			{ID: "a", Name: "A", Color: "#ffbf7f", Items: []string{"c"}},
+		},
+	}, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create list: %d %s", w.Code, w.Body)
+	}
+	var tl models.TierList
+	decodeBody(t, w, &tl)
+
+	edits := [][]models.Tier{
+		{
+			{ID: "s", Name: "S", Color: "#ff7f7f", Items: []string{"c", "a"}},
+			{ID: "a", Name: "A", Color: "#ffbf7f", Items: []string{"b"}},
+		},
+		{
+			{ID: "s", Name: "S", Color: "#ff7f7f", Items: []string{"a", "b", "c"}},
+			{ID: "a", Name: "A", Color: "#ffbf7f"},
+		},
+	}
+	for i, tiers := range edits {
+		w := serve(s, "PUT", "/api/tierlists/"+tl.ID, owner, models.TierListUpdate{Tiers: tiers}, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("edit %d: %d %s", i, w.Code, w.Body)
+		}
+		positions, err := store.GetItemPositions(tl.ID)
+		if err != nil {
+			t.Fatalf("GetItemPositions: %v", err)
+		}
+		for _, tier := range tiers {
+			prev := ""
+			for _, item := range tier.Items {
+				p := positions[item]
+				if p.TierID != tier.ID || p.Position <= prev {
+					t.Errorf("edit %d: %s at %+v after %q, want tier %s after its neighbour", i, item, p, prev, tier.ID)
+				}
+				prev = p.Position
+			}
+		}
+	}
+}
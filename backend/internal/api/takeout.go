@@ -0,0 +1,81 @@
+package api
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// handleExportAccount downloads everything the current user created or
+// saved, as one JSON document or, with ?format=zip, a zip of JSON files
+func (s *Server) handleExportAccount(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		respondError(w, http.StatusBadRequest, "format must be json or zip")
+		return
+	}
+
+	takeout, err := s.takeout(currentUser(r))
+	if err != nil {
+		log.Printf("ERROR: Failed to export account data: %v", err)
+		respondError(w, http.StatusInternalServerError, "Failed to export account data")
+		return
+	}
+
+	name := "tierforge-export-" + takeout.ExportedAt.Format("2006-01-02")
+	if format != "zip" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, name))
+		respondJSON(w, http.StatusOK, takeout)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, name))
+	zw := zip.NewWriter(w)
+	files := map[string]interface{}{
+		"user.json":       takeout.User,
+		"favorites.json":  takeout.Favorites,
+		"workspaces.json": takeout.Workspaces,
+		"matchups.json":   takeout.Matchups,
+		"brackets.json":   takeout.Brackets,
+		"poll_votes.json": takeout.PollVotes,
+	}
+	for _, tl := range takeout.TierLists {
+		files["tierlists/"+tl.ID+".json"] = tl
+	}
+	for path, v := range files {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: path, Method: zip.Deflate, Modified: takeout.ExportedAt})
+		if err != nil {
+			log.Printf("ERROR: Failed to write account export: %v", err)
+			return
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			log.Printf("ERROR: Failed to write account export: %v", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("ERROR: Failed to write account export: %v", err)
+	}
+}
+
+// takeout collects a user's data for export, with the unsaved changes
+// still held in memory
+func (s *Server) takeout(user *models.User) (*models.Takeout, error) {
+	t, err := s.store.GetTakeout(user)
+	if err != nil {
+		return nil, err
+	}
+	for i, tl := range t.TierLists {
+		if state, ok := s.autosaves.Get(tl.ID); ok {
+			t.TierLists[i].Autosave = state
+		}
+	}
+	return t, nil
+}
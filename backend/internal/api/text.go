@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/textfilter"
+)
+
+// Length limits for user-provided text, in characters
+const (
+	maxTierListNameLength = 100
+	maxTierNameLength     = 32
+	maxItemNameLength     = 100
+	maxReportDetails      = 1000
+	maxTagLength          = 32
+	maxTagsPerTierList    = 10
+)
+
+// cleanText runs a user-provided string through the content filter and
+// returns a client-facing error naming the offending field
+func (s *Server) cleanText(field, value string, maxLen int) (string, error) {
+	cleaned, err := s.textFilter.Clean(value, maxLen)
+	switch {
+	case err == nil:
+		return cleaned, nil
+	case errors.Is(err, textfilter.ErrEmpty):
+		return "", fmt.Errorf("%s must not be empty", field)
+	case errors.Is(err, textfilter.ErrTooLong):
+		return "", fmt.Errorf("%s must be at most %d characters", field, maxLen)
+	case errors.Is(err, textfilter.ErrDisallowed):
+		return "", fmt.Errorf("%s contains disallowed language", field)
+	default:
+		return "", err
+	}
+}
+
+// cleanTiers filters the display names of all tiers in place
+func (s *Server) cleanTiers(tiers []models.Tier) error {
+	for i := range tiers {
+		name, err := s.cleanText("tier name", tiers[i].Name, maxTierNameLength)
+		if err != nil {
+			return err
+		}
+		tiers[i].Name = name
+	}
+	return nil
+}
+
+// cleanTags normalizes tags to lowercase, hyphen-separated slugs, drops
+// duplicates and runs each through the content filter
+func (s *Server) cleanTags(tags []string) ([]string, error) {
+	cleaned := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, raw := range tags {
+		tag := strings.Join(strings.Fields(strings.ToLower(raw)), "-")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		for _, r := range tag {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return nil, fmt.Errorf("tag %q may only contain letters, digits and hyphens", raw)
+			}
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tags must be at most %d characters", maxTagLength)
+		}
+		filtered, err := s.cleanText("tag", tag, maxTagLength)
+		if err != nil {
+			return nil, err
+		}
+		if filtered != tag {
+			return nil, fmt.Errorf("tag contains disallowed language")
+		}
+		seen[tag] = true
+		cleaned = append(cleaned, tag)
+	}
+	if len(cleaned) > maxTagsPerTierList {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTagsPerTierList)
+	}
+	return cleaned, nil
+}
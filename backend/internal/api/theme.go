@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/sanitize"
+)
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	fontPattern     = regexp.MustCompile(`^[A-Za-z0-9 '-]{1,64}$`)
+)
+
+// validateGameTheme checks a game's branding. A nil theme is valid.
+func validateGameTheme(t *models.GameTheme) error {
+	if t == nil {
+		return nil
+	}
+	colors := map[string]string{
+		"accent_color":     t.AccentColor,
+		"background_color": t.BackgroundColor,
+		"text_color":       t.TextColor,
+	}
+	if t.Light != nil {
+		colors["light.accent_color"] = t.Light.AccentColor
+		colors["light.background_color"] = t.Light.BackgroundColor
+		colors["light.text_color"] = t.Light.TextColor
+	}
+	for field, color := range colors {
+		if color != "" && !hexColorPattern.MatchString(color) {
+			return fmt.Errorf("theme.%s must be a #rrggbb color", field)
+		}
+	}
+	if t.BackgroundImage != "" && !sanitize.URL(t.BackgroundImage) {
+		return fmt.Errorf("theme.background_image must be an http(s) URL or a relative path")
+	}
+	switch t.CardShape {
+	case "", models.CardSquare, models.CardRounded, models.CardCircle:
+	default:
+		return fmt.Errorf("theme.card_shape must be square, rounded or circle")
+	}
+	if t.Font != "" && !fontPattern.MatchString(t.Font) {
+		return fmt.Errorf("theme.font must be a font family name")
+	}
+	return nil
+}
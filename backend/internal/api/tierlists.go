@@ -2,11 +2,18 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
+	"math"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
 )
 
 // handleCreateTierList creates a new tier list
@@ -17,55 +24,242 @@ func (s *Server) handleCreateTierList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tierList, ok := s.createTierList(w, r, &req, "editor")
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusCreated, tierList)
+}
+
+// handleForkTierList copies a tier list the caller can see into a new list of
+// their own, remembering which list it came from. The copy starts as a draft.
+func (s *Server) handleForkTierList(w http.ResponseWriter, r *http.Request) {
+	var req models.TierListFork
+	if err := decodeJSON(r, &req); err != nil && err != io.EOF {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	original, err := s.storeFor(r).GetTierList(chi.URLParam(r, "id"))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
+		return
+	}
+	if original == nil || !canViewTierList(r, original) {
+		respondError(w, http.StatusNotFound, "Tier list not found")
+		return
+	}
+	// Uploaded items belong to the list they were uploaded to
+	if original.GameID == models.CustomGameID {
+		respondError(w, http.StatusBadRequest, "Custom tier lists cannot be forked")
+		return
+	}
+	if !s.hideTierListSpoilers(w, r, original) {
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = original.Name
+	}
+	tierList, ok := s.createTierList(w, r, &models.TierListCreate{
+		GameID:      original.GameID,
+		SheetID:     original.SheetID,
+		Name:        req.Name,
+		Tiers:       original.Tiers,
+		GameVersion: original.GameVersion,
+		Status:      models.TierListDraft,
+		Tags:        original.Tags,
+		PoolOrder:   original.PoolOrder,
+		PoolSeed:    original.PoolSeed,
+		Constraints: original.Constraints,
+		ForkedFrom:  original.ID,
+	}, "fork")
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusCreated, tierList)
+}
+
+// createTierList validates and stores a new tier list, recording source as
+// where it was created. It writes the error response itself when it fails.
+func (s *Server) createTierList(w http.ResponseWriter, r *http.Request, req *models.TierListCreate, source string) (*models.TierList, bool) {
+	// Custom lists have one sheet, which their uploads go to
+	if req.GameID == models.CustomGameID && req.SheetID == "" {
+		req.SheetID = models.CustomSheetID
+	}
+	if req.GameID == models.CustomGameID && req.SheetID != models.CustomSheetID {
+		respondError(w, http.StatusBadRequest, "Custom tier lists must use sheet_id "+models.CustomSheetID)
+		return nil, false
+	}
 	if req.GameID == "" || req.SheetID == "" || req.Name == "" {
 		respondError(w, http.StatusBadRequest, "game_id, sheet_id, and name are required")
-		return
+		return nil, false
+	}
+
+	name, err := s.cleanText("name", req.Name, maxTierListNameLength)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	req.Name = name
+	if err := s.cleanTiers(req.Tiers); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	tags, err := s.cleanTags(req.Tags)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	req.Tags = tags
+
+	switch {
+	case req.Visibility == "":
+		req.Visibility = models.VisibilityUnlisted
+	case !models.ValidVisibility(req.Visibility):
+		respondError(w, http.StatusBadRequest, "visibility must be public, unlisted, or private")
+		return nil, false
+	case req.Visibility == models.VisibilityPrivate && currentUser(r) == nil:
+		respondError(w, http.StatusBadRequest, "Sign in to create private tier lists")
+		return nil, false
+	}
+
+	switch {
+	case req.PoolOrder == "":
+		req.PoolOrder = models.PoolAlphabetical
+	case !models.ValidPoolOrder(req.PoolOrder):
+		respondError(w, http.StatusBadRequest, "pool_order must be alphabetical, category, or random")
+		return nil, false
+	}
+	if req.PoolOrder != models.PoolRandom {
+		req.PoolSeed = 0
+	} else if req.PoolSeed == 0 {
+		req.PoolSeed = rand.Int63n(math.MaxInt32) + 1
+	} else if req.PoolSeed < 0 || req.PoolSeed > models.MaxPoolSeed {
+		respondError(w, http.StatusBadRequest, "pool_seed must be between 1 and 2^53-1")
+		return nil, false
+	}
+
+	switch req.Status {
+	case "":
+		req.Status = models.TierListPublished
+	case models.TierListDraft, models.TierListPublished:
+	default:
+		respondError(w, http.StatusBadRequest, "status must be draft or published")
+		return nil, false
+	}
+	if err := cleanConstraints(req.Constraints, req.Tiers); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if !checkTierPoints(w, req.Tiers) {
+		return nil, false
+	}
+	if req.Constraints != nil && *req.Constraints == (models.Constraints{}) {
+		req.Constraints = nil
+	}
+
+	req.CreatorIP = clientIP(r)
+	req.CreatorDevice = deviceBanHash(r)
+	if user := currentUser(r); user != nil {
+		req.AuthorID = &user.ID
+	}
+	if !s.checkTierListQuota(w, r, 1) {
+		return nil, false
 	}
 
 	// Validate game exists
-	game, err := s.store.GetGame(req.GameID)
+	game, err := s.storeFor(r).GetGame(req.GameID)
 	if err != nil || game == nil {
 		respondError(w, http.StatusBadRequest, "Invalid game_id")
-		return
+		return nil, false
+	}
+
+	if !canUseWorkspace(r, req, game) {
+		respondError(w, http.StatusForbidden, "You are not a member of this workspace")
+		return nil, false
+	}
+	if !s.checkDuplicateName(w, r, req) {
+		return nil, false
+	}
+
+	if req.GameVersion == "" {
+		req.GameVersion = game.CurrentVersion()
+	} else if !game.HasVersion(req.GameVersion) {
+		respondError(w, http.StatusBadRequest, "Invalid game_version")
+		return nil, false
 	}
 
 	// Use default tiers if none provided
 	if len(req.Tiers) == 0 {
-		for _, t := range game.DefaultTiers {
+		for _, t := range game.TierTemplate(req.SheetID) {
 			req.Tiers = append(req.Tiers, models.Tier{
-				ID:    t.ID,
-				Name:  t.Name,
-				Color: t.Color,
-				Order: t.Order,
-				Items: []string{},
+				ID:     t.ID,
+				Name:   t.Name,
+				Color:  t.Color,
+				Order:  t.Order,
+				Max:    t.Max,
+				Points: t.Points,
+				Items:  []string{},
 			})
 		}
 	}
+	if req.Palette != "" {
+		palette, ok := s.lookupPalette(w, req.Palette)
+		if !ok {
+			return nil, false
+		}
+		palette.Apply(req.Tiers)
+	}
+	warnings, ok := s.checkColors(w, tierColors(req.Tiers))
+	if !ok {
+		return nil, false
+	}
+	if !s.checkConstraints(w, &models.TierList{
+		GameID: req.GameID, SheetID: req.SheetID, GameVersion: req.GameVersion,
+		Status: req.Status, Tiers: req.Tiers, Constraints: req.Constraints,
+	}) {
+		return nil, false
+	}
 
-	tierList, err := s.store.CreateTierList(&req)
+	var tierList *models.TierList
+	err = s.storeFor(r).WithTx(r.Context(), func(tx *storage.Store) error {
+		var err error
+		if tierList, err = tx.CreateTierList(req); err != nil {
+			return err
+		}
+		s.recordTierListCreated(tx, r, tierList, source)
+		return nil
+	})
 	if err != nil {
 		reqJSON, _ := json.Marshal(req)
 		log.Printf("ERROR: Failed to create tier list: %v. Request: %s", err, string(reqJSON))
-		respondError(w, http.StatusInternalServerError, "Failed to create tier list: "+err.Error())
-		return
+		respondWriteError(w, err, "Failed to create tier list: "+err.Error())
+		return nil, false
 	}
-
-	respondJSON(w, http.StatusCreated, tierList)
+	tierList.ColorWarnings = warnings
+	setScore(tierList)
+	s.prerenderTierList(tierList)
+	return tierList, true
 }
 
 // handleGetTierList returns a tier list by ID
 func (s *Server) handleGetTierList(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	tierList, err := s.store.GetTierList(id)
+	tierList, err := s.storeFor(r).GetTierList(id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
 		return
 	}
-	if tierList == nil {
+	if tierList == nil || !canViewTierList(r, tierList) {
 		respondError(w, http.StatusNotFound, "Tier list not found")
 		return
 	}
+	if !s.hideTierListSpoilers(w, r, tierList) || !s.filterTierListItems(w, r, tierList) {
+		return
+	}
+	setScore(tierList)
 
 	respondJSON(w, http.StatusOK, tierList)
 }
@@ -75,7 +269,7 @@ func (s *Server) handleUpdateTierList(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	// Check if tier list exists
-	existing, err := s.store.GetTierList(id)
+	existing, err := s.storeFor(r).GetTierList(id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
 		return
@@ -84,6 +278,14 @@ func (s *Server) handleUpdateTierList(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "Tier list not found")
 		return
 	}
+	if !canEditTierList(r, existing) {
+		respondError(w, http.StatusForbidden, "You cannot edit this tier list")
+		return
+	}
+
+	if tierListLocked(w, existing) {
+		return
+	}
 
 	var update models.TierListUpdate
 	if err := decodeJSON(r, &update); err != nil {
@@ -91,13 +293,109 @@ func (s *Server) handleUpdateTierList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.store.UpdateTierList(id, &update); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to update tier list")
+	if update.Status != nil && *update.Status != existing.Status {
+		if !models.ValidTierListStatus(*update.Status) {
+			respondError(w, http.StatusBadRequest, "status must be draft, published or archived")
+			return
+		}
+		if !models.CanTransition(existing.Status, *update.Status) {
+			respondError(w, http.StatusConflict, "Cannot change status from "+existing.Status+" to "+*update.Status)
+			return
+		}
+	} else if existing.Status == models.TierListArchived &&
+		(update.Name != nil || update.Tiers != nil || update.IsPublic != nil || update.Visibility != nil || update.Tags != nil || update.Palette != nil || update.Constraints != nil) {
+		respondError(w, http.StatusConflict, "Archived tier lists are read-only; publish it again to edit")
 		return
 	}
 
+	if update.Tiers != nil && pollLocksTiers(w, existing) {
+		return
+	}
+	if update.Visibility != nil {
+		if !models.ValidVisibility(*update.Visibility) {
+			respondError(w, http.StatusBadRequest, "visibility must be public, unlisted, or private")
+			return
+		}
+		if *update.Visibility == models.VisibilityPrivate && existing.AuthorID == nil {
+			respondError(w, http.StatusBadRequest, "Anonymous tier lists cannot be private")
+			return
+		}
+	}
+	if update.Name != nil {
+		name, err := s.cleanText("name", *update.Name, maxTierListNameLength)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		update.Name = &name
+	}
+	if update.Palette != nil && *update.Palette != "" {
+		palette, ok := s.lookupPalette(w, *update.Palette)
+		if !ok {
+			return
+		}
+		if update.Tiers == nil {
+			update.Tiers = existing.Tiers
+		}
+		palette.Apply(update.Tiers)
+	}
+	if update.Tags != nil {
+		tags, err := s.cleanTags(*update.Tags)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		update.Tags = &tags
+	}
+	if err := cleanConstraints(update.Constraints, nil); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Check the list as it will be after the update. Saves that touch none
+	// of the constrained parts pass even if new catalog items now leave a
+	// require_all list incomplete.
+	var warnings []models.ColorIssue
+	if update.Tiers != nil || update.Status != nil || update.Constraints != nil {
+		result := *existing
+		if update.Tiers != nil {
+			result.Tiers = update.Tiers
+		}
+		if update.Status != nil {
+			result.Status = *update.Status
+		}
+		if update.Constraints != nil {
+			result.Constraints = update.Constraints
+		}
+		var ok bool
+		if update.Tiers != nil {
+			warnings, ok = s.checkEditedTiers(w, &result)
+		} else {
+			ok = s.checkConstraints(w, &result)
+		}
+		if !ok {
+			return
+		}
+	}
+
+	s.autosaves.Discard(id)
+	if err := s.updateTierList(r.Context(), id, existing.Tiers, &update); err != nil {
+		respondWriteError(w, err, "Failed to update tier list")
+		return
+	}
+	if update.Tiers != nil {
+		if moved := movedItemCount(existing.Tiers, update.Tiers); moved > 0 {
+			s.recordEvent(r, models.EventItemsMoved, existing.GameID, map[string]interface{}{"moved": moved})
+		}
+	}
+
 	// Return updated tier list
-	updated, _ := s.store.GetTierList(id)
+	updated, _ := s.storeFor(r).GetTierList(id)
+	if updated != nil {
+		updated.ColorWarnings = warnings
+		setScore(updated)
+		s.prerenderTierList(updated)
+	}
 	respondJSON(w, http.StatusOK, updated)
 }
 
@@ -105,24 +403,43 @@ func (s *Server) handleUpdateTierList(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleGetTierListByCode(w http.ResponseWriter, r *http.Request) {
 	code := chi.URLParam(r, "code")
 
-	tierList, err := s.store.GetTierListByShareCode(code)
+	tierList, err := s.storeFor(r).GetTierListByShareCode(code)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
 		return
 	}
-	if tierList == nil {
+	if tierList == nil && s.redirectShareCode(w, r) {
+		return
+	}
+	// Drafts must not leak through share links
+	if tierList == nil || !canViewTierList(r, tierList) {
 		respondError(w, http.StatusNotFound, "Tier list not found")
 		return
 	}
 
+	if !s.hideTierListSpoilers(w, r, tierList) || !s.filterTierListItems(w, r, tierList) {
+		return
+	}
+	setScore(tierList)
+
+	if !isBot(r) {
+		counted, err := s.storeFor(r).RecordView(tierList.ID, s.visitorKey(r, tierList.ID), viewDebounceWindow)
+		if err != nil {
+			log.Printf("ERROR: Failed to record view for %s: %v", tierList.ID, err)
+		} else if counted {
+			tierList.ViewCount++
+		}
+	}
+
 	respondJSON(w, http.StatusOK, tierList)
 }
 
-// handleDeleteTierList deletes a tier list by ID
+// handleDeleteTierList deletes a tier list by ID. Like edits, it is refused
+// while the list is locked or another editor holds one of its tiers.
 func (s *Server) handleDeleteTierList(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	existing, err := s.store.GetTierList(id)
+	existing, err := s.storeFor(r).GetTierList(id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch tier list")
 		return
@@ -131,11 +448,110 @@ func (s *Server) handleDeleteTierList(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "Tier list not found")
 		return
 	}
+	if !canEditTierList(r, existing) {
+		respondError(w, http.StatusForbidden, "You cannot delete this tier list")
+		return
+	}
+	if tierListLocked(w, existing) {
+		return
+	}
+	if lock, held := s.presence.HeldByOther(id, s.presenceViewer(r)); held {
+		respondError(w, http.StatusConflict, lock.Editor.DisplayName+" is reordering this tier list")
+		return
+	}
 
-	if err := s.store.DeleteTierList(id); err != nil {
+	s.autosaves.Discard(id)
+	if err := s.storeFor(r).DeleteTierList(id); err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to delete tier list")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
+
+// maxGalleryPage caps the tier lists on one page of a public gallery
+const maxGalleryPage = 100
+
+// handleGetPublicTierLists returns a page of summaries of public tier lists
+// for a game. When more follow, a Link header with rel="next" points to the
+// next page, which continues from a ?cursor= rather than an offset.
+func (s *Server) handleGetPublicTierLists(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxGalleryPage {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxGalleryPage))
+			return
+		}
+		limit = n
+	}
+	var after *storage.Cursor
+	if v := q.Get("cursor"); v != "" {
+		var err error
+		if after, err = storage.ParseCursor(v); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+	}
+
+	summaries, next, err := s.storeFor(r).GetPublicTierLists(storage.TierListQuery{
+		GameID:  chi.URLParam(r, "gameID"),
+		SheetID: q.Get("sheet"),
+		Version: q.Get("version"),
+		Tag:     strings.ToLower(q.Get("tag")),
+		Limit:   limit,
+		After:   after,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier lists")
+		return
+	}
+
+	if next != nil {
+		q.Set("cursor", next.String())
+		w.Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, r.URL.Path, q.Encode()))
+	}
+	respondJSON(w, http.StatusOK, summaries)
+}
+
+// handleSearchTierLists searches public tier lists of every game by name,
+// tag and author name
+func (s *Server) handleSearchTierLists(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if len(storage.SearchTerms(q)) == 0 {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = storage.SearchSortRelevance
+	}
+	if sort != storage.SearchSortRelevance && sort != storage.SearchSortVotes {
+		respondError(w, http.StatusBadRequest, "sort must be relevance or votes")
+		return
+	}
+
+	summaries, err := s.storeFor(r).SearchTierLists(storage.TierListSearch{
+		Query:   q,
+		GameID:  r.URL.Query().Get("game"),
+		SheetID: r.URL.Query().Get("sheet"),
+		Sort:    sort,
+		Limit:   50,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to search tier lists")
+		return
+	}
+	respondJSON(w, http.StatusOK, summaries)
+}
+
+// handleGetTagCloud returns the most used tags on a game's public tier lists
+func (s *Server) handleGetTagCloud(w http.ResponseWriter, r *http.Request) {
+	cloud, err := s.storeFor(r).GetTagCloud(chi.URLParam(r, "gameID"), 100)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tags")
+		return
+	}
+	respondJSON(w, http.StatusOK, cloud)
+}
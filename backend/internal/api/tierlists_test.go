@@ -0,0 +1,295 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/meur/tierforge/internal/config"
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestForkTierList(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	forker := signUp(t, s, "forker@example.com")
+
+	create := func(visibility string) models.TierList {
+		t.Helper()
+		w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+			"game_id": "g", "sheet_id": "main", "name": "List " + visibility, "visibility": visibility,
+			"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f", Items: []string{"a", "b"}}},
+		}, nil)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create %s list: %d %s", visibility, w.Code, w.Body)
+		}
+		var tl models.TierList
+		decodeBody(t, w, &tl)
+		return tl
+	}
+	public := create(models.VisibilityPublic)
+	private := create(models.VisibilityPrivate)
+	hidden := create(models.VisibilityUnlisted)
+	if err := store.SetTierListHidden(hidden.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	draft := create(models.VisibilityPublic)
+	status := models.TierListDraft
+	if err := store.UpdateTierList(draft.ID, &models.TierListUpdate{Status: &status}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(s, "POST", "/api/tierlists/"+public.ID+"/fork", forker, map[string]string{"name": "My take"}, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("fork: %d %s", w.Code, w.Body)
+	}
+	var fork models.TierList
+	decodeBody(t, w, &fork)
+	if fork.ID == public.ID || fork.ForkedFrom != public.ID || fork.Name != "My take" {
+		t.Errorf("fork is %+v, want a new list named My take forked from %s", fork, public.ID)
+	}
+	if fork.AuthorID == nil || fork.Status != models.TierListDraft || len(fork.Tiers) != 1 || len(fork.Tiers[0].Items) != 2 {
+		t.Errorf("fork is %+v, want the forker's draft with the original tiers", fork)
+	}
+	if stored, err := store.GetTierList(fork.ID); err != nil || stored.ForkedFrom != public.ID {
+		t.Errorf("stored fork = %+v, %v; want forked_from %s", stored, err, public.ID)
+	}
+
+	// Forking without a body keeps the name
+	w = serve(s, "POST", "/api/tierlists/"+public.ID+"/fork", "", nil, nil)
+	decodeBody(t, w, &fork)
+	if w.Code != http.StatusCreated || fork.Name != public.Name {
+		t.Errorf("anonymous fork: %d, name %q; want %q", w.Code, fork.Name, public.Name)
+	}
+
+	// The owner may fork their own draft
+	if w := serve(s, "POST", "/api/tierlists/"+draft.ID+"/fork", owner, nil, nil); w.Code != http.StatusCreated {
+		t.Errorf("forking one's own draft: %d %s", w.Code, w.Body)
+	}
+	for name, id := range map[string]string{"private": private.ID, "hidden": hidden.ID, "draft": draft.ID, "missing": "nope"} {
+		if w := serve(s, "POST", "/api/tierlists/"+id+"/fork", forker, nil, nil); w.Code != http.StatusNotFound {
+			t.Errorf("forking a %s list: %d %s, want 404", name, w.Code, w.Body)
+		}
+	}
+}
+
+func TestTierListReadsShareVisibility(t *testing.T) {
+	s, store := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	stranger := signUp(t, s, "stranger@example.com")
+
+	points := 1.0
+	create := func(fields map[string]interface{}) string {
+		t.Helper()
+		body := map[string]interface{}{
+			"game_id": "g", "sheet_id": "main", "name": "List",
+			"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f", Points: &points}},
+		}
+		for k, v := range fields {
+			body[k] = v
+		}
+		w := serve(s, "POST", "/api/tierlists", owner, body, nil)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create list: %d %s", w.Code, w.Body)
+		}
+		var tl models.TierList
+		decodeBody(t, w, &tl)
+		return tl.ID
+	}
+	hidden := create(nil)
+	if err := store.SetTierListHidden(hidden, true); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		token   string
+		visible bool
+	}{
+		{"published to a stranger", create(nil), stranger, true},
+		{"draft to its owner", create(map[string]interface{}{"status": models.TierListDraft}), owner, true},
+		{"draft to a stranger", create(map[string]interface{}{"status": models.TierListDraft}), stranger, false},
+		{"draft signed out", create(map[string]interface{}{"status": models.TierListDraft}), "", false},
+		{"private to its owner", create(map[string]interface{}{"visibility": models.VisibilityPrivate}), owner, true},
+		{"private to a stranger", create(map[string]interface{}{"visibility": models.VisibilityPrivate}), stranger, false},
+		{"hidden to its owner", hidden, owner, true},
+		{"hidden to a stranger", hidden, stranger, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := http.StatusNotFound
+			if tt.visible {
+				want = http.StatusOK
+			}
+			for _, path := range []string{"", "/score", "/presence", "/items", "/replay"} {
+				if w := serve(s, "GET", "/api/tierlists/"+tt.id+path, tt.token, nil, nil); w.Code != want {
+					t.Errorf("GET %s: %d %s, want %d", path, w.Code, w.Body, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTierListTextIsFiltered(t *testing.T) {
+	tests := []struct {
+		mode     string
+		name     string
+		tierName string
+		code     int
+		want     string
+		wantTier string
+	}{
+		{"mask", "<b>Best</b>   builds", "S", http.StatusCreated, "Best builds", "S"},
+		{"mask", "shitty builds", "S", http.StatusCreated, "s***** builds", "S"},
+		{"mask", "Builds", "shit", http.StatusCreated, "Builds", "s***"},
+		{"mask", "<p> </p>", "S", http.StatusBadRequest, "name must not be empty", ""},
+		{"mask", strings.Repeat("a", maxTierListNameLength+1), "S", http.StatusBadRequest, "name must be at most 100 characters", ""},
+		{"mask", "Builds", strings.Repeat("a", maxTierNameLength+1), http.StatusBadRequest, "tier name must be at most 32 characters", ""},
+		{"reject", "Best builds", "S", http.StatusCreated, "Best builds", "S"},
+		{"reject", "shitty builds", "S", http.StatusBadRequest, "name contains disallowed language", ""},
+		{"reject", "Builds", "shit", http.StatusBadRequest, "tier name contains disallowed language", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.want, func(t *testing.T) {
+			s, _ := newTestServer(t, func(cfg *config.Config) { cfg.ContentFilterMode = tt.mode })
+			owner := signUp(t, s, "owner@example.com")
+			w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+				"game_id": "g", "sheet_id": "main", "name": tt.name,
+				"tiers": []models.Tier{{ID: "s", Name: tt.tierName, Color: "#ff7f7f"}},
+			}, nil)
+			if w.Code != tt.code {
+				t.Fatalf("create: %d %s, want %d", w.Code, w.Body, tt.code)
+			}
+			if tt.code != http.StatusCreated {
+				if !strings.Contains(w.Body.String(), tt.want) {
+					t.Errorf("create error is %s, want %q", w.Body, tt.want)
+				}
+				return
+			}
+			var tl models.TierList
+			decodeBody(t, w, &tl)
+			if tl.Name != tt.want || tl.Tiers[0].Name != tt.wantTier {
+				t.Errorf("created %q with tier %q, want %q with tier %q", tl.Name, tl.Tiers[0].Name, tt.want, tt.wantTier)
+			}
+
+			// Renames go through the same filter
+			w = serve(s, "PUT", "/api/tierlists/"+tl.ID, owner, map[string]string{"name": tt.name}, nil)
+			decodeBody(t, w, &tl)
+			if w.Code != http.StatusOK || tl.Name != tt.want {
+				t.Errorf("rename: %d, name %q; want %q", w.Code, tl.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublicGalleryPages(t *testing.T) {
+	s, _ := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	for i := range 3 {
+		w := serve(s, "POST", "/api/tierlists", owner, map[string]interface{}{
+			"game_id": "g", "sheet_id": "main", "name": "List " + strconv.Itoa(i), "visibility": models.VisibilityPublic,
+			"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}},
+		}, nil)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create list: %d %s", w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		query string
+		code  int
+		err   string
+	}{
+		{"?limit=0", http.StatusBadRequest, "limit must be between 1 and 100"},
+		{"?limit=101", http.StatusBadRequest, "limit must be between 1 and 100"},
+		{"?limit=two", http.StatusBadRequest, "limit must be between 1 and 100"},
+		{"?cursor=nope", http.StatusBadRequest, "Invalid cursor"},
+		{"?limit=100", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		w := serve(s, "GET", "/api/games/g/tierlists"+tt.query, "", nil, nil)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.err) {
+			t.Errorf("GET %s = %d %s, want %d %q", tt.query, w.Code, w.Body, tt.code, tt.err)
+		}
+	}
+
+	// Following rel="next" walks every list once, keeping the other parameters
+	link := regexp.MustCompile(`^<(/api/games/g/tierlists\?[^>]+)>; rel="next"$`)
+	path := "/api/games/g/tierlists?limit=2&sheet=main"
+	seen := map[string]bool{}
+	for pages := 1; ; pages++ {
+		w := serve(s, "GET", path, "", nil, nil)
+		var page []models.TierListSummary
+		decodeBody(t, w, &page)
+		for _, summary := range page {
+			if seen[summary.ID] {
+				t.Errorf("page %d repeats %s", pages, summary.ID)
+			}
+			seen[summary.ID] = true
+		}
+		header := w.Header().Get("Link")
+		if header == "" {
+			if pages != 2 || len(seen) != 3 {
+				t.Errorf("saw %d lists over %d pages, want 3 over 2", len(seen), pages)
+			}
+			break
+		}
+		m := link.FindStringSubmatch(header)
+		if m == nil || !strings.Contains(m[1], "limit=2") || !strings.Contains(m[1], "sheet=main") || pages > 2 {
+			t.Fatalf("page %d Link is %q", pages, header)
+		}
+		path = m[1]
+	}
+}
+
+func TestDeleteTierListRespectsLocks(t *testing.T) {
+	s, _ := newTestServer(t, nil)
+	owner := signUp(t, s, "owner@example.com")
+	from := func(ip string) func(*http.Request) {
+		return func(r *http.Request) { r.RemoteAddr = ip + ":1234" }
+	}
+	create := func(token string) string {
+		t.Helper()
+		w := serve(s, "POST", "/api/tierlists", token, map[string]interface{}{
+			"game_id": "g", "sheet_id": "main", "name": "List",
+			"tiers": []models.Tier{{ID: "s", Name: "S", Color: "#ff7f7f"}},
+		}, from("203.0.113.1"))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create list: %d %s", w.Code, w.Body)
+		}
+		var tl models.TierList
+		decodeBody(t, w, &tl)
+		return tl.ID
+	}
+	owned, anonymous := create(owner), create("")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		ip     string
+		code   int
+	}{
+		{"lock", "PUT", "/api/tierlists/" + owned + "/lock", owner, "203.0.113.1", http.StatusOK},
+		{"delete locked", "DELETE", "/api/tierlists/" + owned, owner, "203.0.113.1", http.StatusConflict},
+		{"still there", "GET", "/api/tierlists/" + owned, owner, "203.0.113.1", http.StatusOK},
+		{"unlock", "DELETE", "/api/tierlists/" + owned + "/lock", owner, "203.0.113.1", http.StatusOK},
+		// The requester's own tier locks do not stand in the way
+		{"claim own tier", "PUT", "/api/tierlists/" + owned + "/tiers/s/lock", owner, "203.0.113.1", http.StatusOK},
+		{"delete unlocked", "DELETE", "/api/tierlists/" + owned, owner, "203.0.113.1", http.StatusOK},
+
+		{"claim tier", "PUT", "/api/tierlists/" + anonymous + "/tiers/s/lock", "", "203.0.113.1", http.StatusOK},
+		{"delete while another edits", "DELETE", "/api/tierlists/" + anonymous, "", "203.0.113.2", http.StatusConflict},
+		{"release tier", "DELETE", "/api/tierlists/" + anonymous + "/tiers/s/lock", "", "203.0.113.1", http.StatusNoContent},
+		{"delete released", "DELETE", "/api/tierlists/" + anonymous, "", "203.0.113.2", http.StatusOK},
+	}
+	for _, tt := range tests {
+		if w := serve(s, tt.method, tt.path, tt.token, nil, from(tt.ip)); w.Code != tt.code {
+			t.Fatalf("%s: %s %s = %d %s, want %d", tt.name, tt.method, tt.path, w.Code, w.Body, tt.code)
+		}
+	}
+}
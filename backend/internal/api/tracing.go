@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/meur/tierforge/internal/storage"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequests records a server span for each request through otelhttp,
+// continuing the trace of a traceparent header. The span is named after the
+// matched route once routing is done and carries the request ID, which is
+// also returned in X-Request-Id, so a request's logs and trace can be found
+// from either.
+func (s *Server) traceRequests(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := middleware.GetReqID(r.Context())
+		w.Header().Set(middleware.RequestIDHeader, reqID)
+
+		span := trace.SpanFromContext(r.Context())
+		if !span.IsRecording() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		span.SetAttributes(attribute.String("request.id", reqID))
+		next.ServeHTTP(w, r)
+		if route := chi.RouteContext(r.Context()).RoutePattern(); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+	})
+	return otelhttp.NewHandler(named, "", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method
+	}))
+}
+
+// storeFor returns the store bound to r's context, so its statements are
+// traced as part of the request
+func (s *Server) storeFor(r *http.Request) *storage.Store {
+	return s.store.WithContext(r.Context())
+}
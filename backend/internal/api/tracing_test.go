@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceRequestsNamesSpansByRoute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(t.Context())
+		otel.SetTracerProvider(sdktrace.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+	s, _ := newTestServer(t, nil)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	w := serve(s, "GET", "/api/tierlists/missing", "", nil, func(r *http.Request) {
+		r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	})
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("GET: %d %s", w.Code, w.Body)
+	}
+	reqID := w.Header().Get("X-Request-Id")
+	if reqID == "" {
+		t.Error("X-Request-Id is not set")
+	}
+
+	var server sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Parent().IsRemote() {
+			server = span
+		}
+	}
+	if server == nil {
+		t.Fatalf("no span continues the traceparent among %d ended spans", len(recorder.Ended()))
+	}
+	if got := server.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want %s", got, traceID)
+	}
+	if got := server.Name(); got != "GET /api/tierlists/{id}" {
+		t.Errorf("span name = %q, want the route", got)
+	}
+	attrs := make(map[string]string)
+	for _, a := range server.Attributes() {
+		attrs[string(a.Key)] = a.Value.Emit()
+	}
+	if attrs["request.id"] != reqID || attrs["http.route"] != "/api/tierlists/{id}" || attrs["http.response.status_code"] != "404" {
+		t.Errorf("attributes = %v, want the request ID %s, route and status", attrs, reqID)
+	}
+}
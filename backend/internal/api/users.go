@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+)
+
+var validRoles = map[string]bool{
+	models.RoleAdmin:   true,
+	models.RoleCurator: true,
+	models.RoleUser:    true,
+}
+
+// handleGetUsers returns all accounts
+func (s *Server) handleGetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := s.storeFor(r).GetUsers()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch users")
+		return
+	}
+	respondJSON(w, http.StatusOK, users)
+}
+
+// handleSetUserRole changes a user's role and curated games
+func (s *Server) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.RoleUpdate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validRoles[req.Role] {
+		respondError(w, http.StatusBadRequest, "role must be admin, curator, or user")
+		return
+	}
+	if req.Role == models.RoleCurator && len(req.GameIDs) == 0 {
+		respondError(w, http.StatusBadRequest, "curators need at least one game_id")
+		return
+	}
+
+	user, err := s.storeFor(r).GetUser(id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if user == nil {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := s.storeFor(r).SetUserRole(id, req.Role, req.GameIDs); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+	s.audit(r, "user.role", "user", id, req.Role)
+
+	updated, _ := s.storeFor(r).GetUser(id)
+	respondJSON(w, http.StatusOK, updated)
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// viewDebounceWindow is how long repeat views from the same visitor are ignored
+const viewDebounceWindow = 30 * time.Minute
+
+// botUserAgentMarkers are lowercase substrings identifying crawlers and link unfurlers
+var botUserAgentMarkers = []string{
+	"bot", "crawler", "spider", "slurp", "preview", "facebookexternalhit",
+	"embedly", "curl", "wget", "python-requests", "go-http-client", "headless",
+}
+
+// isBot reports whether the request looks like it comes from an automated client
+func isBot(r *http.Request) bool {
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return true
+	}
+	for _, marker := range botUserAgentMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the remote IP of the request without the port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// visitorHash derives an opaque, salted identifier for the requesting IP so
+// raw addresses are never stored
+func (s *Server) visitorHash(r *http.Request) string {
+	h := sha256.New()
+	h.Write(s.visitorSalt)
+	h.Write([]byte(clientIP(r)))
+	return hex.EncodeToString(h.Sum(nil))
+}
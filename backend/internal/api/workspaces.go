@@ -0,0 +1,327 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// maxWorkspaceDescription limits workspace descriptions, in characters
+const maxWorkspaceDescription = 500
+
+var workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,31}$`)
+
+// currentWorkspace returns the workspace resolved by loadWorkspace, or nil
+func currentWorkspace(r *http.Request) *models.Workspace {
+	ws, _ := r.Context().Value(workspaceContextKey).(*models.Workspace)
+	return ws
+}
+
+// isWorkspaceMember reports whether the request comes from a member of the
+// workspace or an admin
+func isWorkspaceMember(r *http.Request, ws *models.Workspace) bool {
+	user := currentUser(r)
+	return user != nil && (user.Role == models.RoleAdmin || user.WorkspaceRole(ws.ID) != "")
+}
+
+// canUseWorkspace checks the workspace a new tier list is filed under. Lists
+// on a workspace game default to that workspace when the author is a member.
+func canUseWorkspace(r *http.Request, req *models.TierListCreate, game *models.Game) bool {
+	user := currentUser(r)
+	if req.WorkspaceID == "" {
+		if user != nil && game.WorkspaceID != "" && user.WorkspaceRole(game.WorkspaceID) != "" {
+			req.WorkspaceID = game.WorkspaceID
+		}
+		return true
+	}
+	return user != nil && user.WorkspaceRole(req.WorkspaceID) != ""
+}
+
+// loadWorkspace resolves the {slug} URL parameter and stores the workspace
+// in the request context
+func (s *Server) loadWorkspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := s.storeFor(r).GetWorkspaceBySlug(chi.URLParam(r, "slug"))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch workspace")
+			return
+		}
+		if ws == nil {
+			respondError(w, http.StatusNotFound, "Workspace not found")
+			return
+		}
+		if user := currentUser(r); user != nil {
+			ws.Role = user.WorkspaceRole(ws.ID)
+		}
+		ctx := context.WithValue(r.Context(), workspaceContextKey, ws)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireWorkspaceRole rejects requests from users without one of the given
+// roles in the current workspace. Admins always pass.
+func (s *Server) requireWorkspaceRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := currentUser(r)
+			if user == nil {
+				respondError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+			if user.Role != models.RoleAdmin {
+				role := user.WorkspaceRole(currentWorkspace(r).ID)
+				allowed := false
+				for _, want := range roles {
+					allowed = allowed || role == want
+				}
+				if !allowed {
+					respondError(w, http.StatusForbidden, "Insufficient workspace permissions")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireWorkspaceGame rejects catalog requests for a game that belongs to
+// another workspace or to the site. Unknown games pass so they can be created.
+func (s *Server) requireWorkspaceGame(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		game, err := s.storeFor(r).GetGame(chi.URLParam(r, "gameID"))
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch game")
+			return
+		}
+		if game != nil && game.WorkspaceID != currentWorkspace(r).ID {
+			respondError(w, http.StatusNotFound, "Game not found in this workspace")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleCreateWorkspace creates a workspace owned by the current user
+func (s *Server) handleCreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	user := accountUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var req models.WorkspaceCreate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !workspaceSlugPattern.MatchString(req.Slug) {
+		respondError(w, http.StatusBadRequest, "slug must be 2-32 lowercase letters, digits or hyphens")
+		return
+	}
+	name, err := s.cleanText("name", req.Name, maxTierListNameLength)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.Name = name
+	if req.Description != "" {
+		description, err := s.cleanText("description", req.Description, maxWorkspaceDescription)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		req.Description = description
+	}
+
+	ws, err := s.storeFor(r).CreateWorkspace(&req, user.ID)
+	if err == storage.ErrDuplicate {
+		respondError(w, http.StatusConflict, "A workspace with this slug already exists")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create workspace")
+		return
+	}
+	s.audit(r, "workspace.create", "workspace", ws.ID, ws.Slug)
+
+	respondJSON(w, http.StatusCreated, ws)
+}
+
+// handleGetWorkspace returns a workspace with the caller's role
+func (s *Server) handleGetWorkspace(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, currentWorkspace(r))
+}
+
+// handleGetMyWorkspaces returns the workspaces the current user belongs to
+func (s *Server) handleGetMyWorkspaces(w http.ResponseWriter, r *http.Request) {
+	workspaces, err := s.storeFor(r).GetUserWorkspaces(currentUser(r).ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch workspaces")
+		return
+	}
+	respondJSON(w, http.StatusOK, workspaces)
+}
+
+// handleGetWorkspaceMembers lists a workspace's members
+func (s *Server) handleGetWorkspaceMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := s.storeFor(r).GetWorkspaceMembers(currentWorkspace(r).ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch members")
+		return
+	}
+	respondJSON(w, http.StatusOK, members)
+}
+
+// handleAddWorkspaceMember adds a registered user, found by email, to a
+// workspace and returns the updated member list
+func (s *Server) handleAddWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	ws := currentWorkspace(r)
+
+	var req models.WorkspaceMemberUpdate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.WorkspaceMember
+	}
+	if !models.ValidWorkspaceRole(req.Role) {
+		respondError(w, http.StatusBadRequest, "role must be owner, editor, or member")
+		return
+	}
+
+	user, _, err := s.storeFor(r).GetUserByEmail(req.Email)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if user == nil {
+		respondError(w, http.StatusNotFound, "No account with this email")
+		return
+	}
+	if user.WorkspaceRole(ws.ID) != "" {
+		respondError(w, http.StatusConflict, "User is already a member")
+		return
+	}
+
+	if err := s.storeFor(r).SetWorkspaceMember(ws.ID, user.ID, req.Role); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to add member")
+		return
+	}
+	s.audit(r, "workspace.member.add", "workspace", ws.ID, user.ID+" "+req.Role)
+
+	s.handleGetWorkspaceMembers(w, r)
+}
+
+// handleSetWorkspaceMemberRole changes a member's role and returns the
+// updated member list
+func (s *Server) handleSetWorkspaceMemberRole(w http.ResponseWriter, r *http.Request) {
+	ws := currentWorkspace(r)
+	userID := chi.URLParam(r, "userID")
+
+	var req models.WorkspaceMemberUpdate
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !models.ValidWorkspaceRole(req.Role) {
+		respondError(w, http.StatusBadRequest, "role must be owner, editor, or member")
+		return
+	}
+
+	member, err := s.storeFor(r).GetUser(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if member == nil || member.WorkspaceRole(ws.ID) == "" {
+		respondError(w, http.StatusNotFound, "Member not found")
+		return
+	}
+	if member.WorkspaceRole(ws.ID) == models.WorkspaceOwner && req.Role != models.WorkspaceOwner {
+		if !s.keepsAnOwner(w, ws) {
+			return
+		}
+	}
+
+	if err := s.storeFor(r).SetWorkspaceMember(ws.ID, userID, req.Role); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update member")
+		return
+	}
+	s.audit(r, "workspace.member.role", "workspace", ws.ID, userID+" "+req.Role)
+
+	s.handleGetWorkspaceMembers(w, r)
+}
+
+// handleRemoveWorkspaceMember removes a member. Owners may remove anyone and
+// every member may remove themselves.
+func (s *Server) handleRemoveWorkspaceMember(w http.ResponseWriter, r *http.Request) {
+	ws := currentWorkspace(r)
+	userID := chi.URLParam(r, "userID")
+	user := currentUser(r)
+	if user.ID != userID && user.Role != models.RoleAdmin && ws.Role != models.WorkspaceOwner {
+		respondError(w, http.StatusForbidden, "Insufficient workspace permissions")
+		return
+	}
+
+	member, err := s.storeFor(r).GetUser(userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if member == nil || member.WorkspaceRole(ws.ID) == "" {
+		respondError(w, http.StatusNotFound, "Member not found")
+		return
+	}
+	if member.WorkspaceRole(ws.ID) == models.WorkspaceOwner && !s.keepsAnOwner(w, ws) {
+		return
+	}
+
+	if _, err := s.storeFor(r).RemoveWorkspaceMember(ws.ID, userID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to remove member")
+		return
+	}
+	s.audit(r, "workspace.member.remove", "workspace", ws.ID, userID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// keepsAnOwner reports whether the workspace has an owner besides the one
+// being demoted or removed, writing the error response when it does not
+func (s *Server) keepsAnOwner(w http.ResponseWriter, ws *models.Workspace) bool {
+	owners, err := s.store.CountWorkspaceOwners(ws.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch members")
+		return false
+	}
+	if owners <= 1 {
+		respondError(w, http.StatusConflict, "A workspace needs at least one owner")
+		return false
+	}
+	return true
+}
+
+// handleGetWorkspaceGames returns the games a workspace owns
+func (s *Server) handleGetWorkspaceGames(w http.ResponseWriter, r *http.Request) {
+	games, err := s.storeFor(r).GetGamesInWorkspace(currentWorkspace(r).ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch games")
+		return
+	}
+	respondJSON(w, http.StatusOK, games)
+}
+
+// handleGetWorkspaceTierLists returns a workspace's tier lists. Members also
+// see lists that are not public.
+func (s *Server) handleGetWorkspaceTierLists(w http.ResponseWriter, r *http.Request) {
+	ws := currentWorkspace(r)
+	summaries, err := s.storeFor(r).GetWorkspaceTierLists(ws.ID, isWorkspaceMember(r, ws), 50)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch tier lists")
+		return
+	}
+	respondJSON(w, http.StatusOK, summaries)
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPassword(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 of "password" with salt "salt" (RFC 7914 vectors)
+	const (
+		oneRound  = "pbkdf2-sha256$1$c2FsdA$Eg+2z/z4syxD5yJSVsT4N6hlSMkszDVICAWYfLcL4Xs"
+		twoRounds = "pbkdf2-sha256$2$c2FsdA$rk0Mla9rRtMtCt/5KPBt0CowP47zwlHf1uLYWpVHTEM"
+	)
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+	}{
+		{"one iteration", oneRound, "password", true},
+		{"iteration count from the hash", twoRounds, "password", true},
+		{"wrong password", oneRound, "Password", false},
+		{"empty password", oneRound, "", false},
+		{"iterations swapped", strings.Replace(oneRound, "$1$", "$2$", 1), "password", false},
+		{"other algorithm", strings.Replace(oneRound, "pbkdf2-sha256", "bcrypt", 1), "password", false},
+		{"missing part", "pbkdf2-sha256$1$c2FsdA", "password", false},
+		{"extra part", oneRound + "$x", "password", false},
+		{"bad iterations", "pbkdf2-sha256$many$c2FsdA$Eg+2z/z4syxD5yJSVsT4N6hlSMkszDVICAWYfLcL4Xs", "password", false},
+		{"zero iterations", "pbkdf2-sha256$0$c2FsdA$Eg+2z/z4syxD5yJSVsT4N6hlSMkszDVICAWYfLcL4Xs", "password", false},
+		{"negative iterations", "pbkdf2-sha256$-1$c2FsdA$Eg+2z/z4syxD5yJSVsT4N6hlSMkszDVICAWYfLcL4Xs", "password", false},
+		{"bad salt", "pbkdf2-sha256$1$!!$Eg+2z/z4syxD5yJSVsT4N6hlSMkszDVICAWYfLcL4Xs", "password", false},
+		{"bad key", "pbkdf2-sha256$1$c2FsdA$!!", "password", false},
+		{"empty key", "pbkdf2-sha256$1$c2FsdA$", "password", false},
+		{"empty hash", "", "password", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPassword(tt.hash, tt.password); got != tt.want {
+				t.Errorf("CheckPassword(%q, %q) = %v, want %v", tt.hash, tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	a, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	b, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if a == b {
+		t.Error("two hashes of one password share a salt")
+	}
+	if !strings.HasPrefix(a, "pbkdf2-sha256$210000$") {
+		t.Errorf("hash %q does not record the algorithm and iterations", a)
+	}
+	if !CheckPassword(a, "correct horse") || CheckPassword(a, "correct horse ") {
+		t.Errorf("hash %q does not check against its password alone", a)
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	tests := []struct {
+		token, want string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"token", "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0"},
+	}
+	for _, tt := range tests {
+		if got := HashToken(tt.token); got != tt.want {
+			t.Errorf("HashToken(%q) = %s, want %s", tt.token, got, tt.want)
+		}
+	}
+
+	a, err := NewToken()
+	if err != nil {
+		t.Fatalf("NewToken: %v", err)
+	}
+	b, _ := NewToken()
+	if a == b || len(a) != 43 || strings.ContainsAny(a, "+/=") {
+		t.Errorf("NewToken gave %q and %q, want distinct 43-character URL-safe tokens", a, b)
+	}
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Device tokens tell anonymous browsers apart without an account. A token
+// carries a random device ID and when the ID was created and the token
+// signed, and is signed with HMAC-SHA256.
+const (
+	deviceIDLength  = 16
+	devicePayload   = deviceIDLength + 16
+	deviceSigLength = 16
+)
+
+// Device is the identity a device token carries
+type Device struct {
+	ID      string    // Hex-encoded random ID
+	Created time.Time // When the ID was first issued
+	Issued  time.Time // When this token was signed
+}
+
+// NewDevice creates a device with a fresh random ID
+func NewDevice(now time.Time) (Device, error) {
+	id := make([]byte, deviceIDLength)
+	if _, err := rand.Read(id); err != nil {
+		return Device{}, err
+	}
+	return Device{ID: hex.EncodeToString(id), Created: now, Issued: now}, nil
+}
+
+// DeviceHash derives the identifier stored for a device's activity in
+// scope, such as a tier list ID. Hashes of one device differ between
+// scopes, so stored activity cannot be linked across them.
+func DeviceHash(id, scope string) string {
+	sum := sha256.Sum256([]byte("device\x00" + scope + "\x00" + id))
+	return hex.EncodeToString(sum[:16])
+}
+
+// DeviceSigner signs and verifies device tokens. The first key signs; every
+// key verifies, so keys can be rotated without dropping existing devices.
+type DeviceSigner struct {
+	keys [][]byte
+}
+
+// NewDeviceSigner creates a signer from secrets, newest first. Without
+// secrets it uses a random key, and tokens do not survive a restart.
+func NewDeviceSigner(secrets []string) (*DeviceSigner, error) {
+	d := &DeviceSigner{}
+	for _, secret := range secrets {
+		d.keys = append(d.keys, []byte(secret))
+	}
+	if len(d.keys) == 0 {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		d.keys = append(d.keys, key)
+	}
+	return d, nil
+}
+
+// Sign encodes dev as a token
+func (d *DeviceSigner) Sign(dev Device) string {
+	id, _ := hex.DecodeString(dev.ID)
+	payload := make([]byte, 0, devicePayload)
+	payload = append(payload, id...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(dev.Created.Unix()))
+	payload = binary.BigEndian.AppendUint64(payload, uint64(dev.Issued.Unix()))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(deviceSignature(d.keys[0], payload))
+}
+
+// Parse verifies a token and returns its device. current reports whether it
+// was signed with the newest key.
+func (d *DeviceSigner) Parse(token string) (dev Device, current, ok bool) {
+	encoded, sig, found := strings.Cut(token, ".")
+	if !found {
+		return dev, false, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != devicePayload {
+		return dev, false, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return dev, false, false
+	}
+	for i, key := range d.keys {
+		if hmac.Equal(mac, deviceSignature(key, payload)) {
+			dev.ID = hex.EncodeToString(payload[:deviceIDLength])
+			dev.Created = time.Unix(int64(binary.BigEndian.Uint64(payload[deviceIDLength:])), 0)
+			dev.Issued = time.Unix(int64(binary.BigEndian.Uint64(payload[deviceIDLength+8:])), 0)
+			return dev, i == 0, true
+		}
+	}
+	return dev, false, false
+}
+
+func deviceSignature(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("device-token\x00"))
+	mac.Write(payload)
+	return mac.Sum(nil)[:deviceSigLength]
+}
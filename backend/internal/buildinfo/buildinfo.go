@@ -0,0 +1,36 @@
+// Package buildinfo describes the running binary. The version is stamped at
+// build time with
+//
+//	-ldflags "-X github.com/meur/tierforge/internal/buildinfo.Version=v1.2.3"
+//
+// while the commit is read from the VCS information Go embeds in builds made
+// inside a git checkout.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Version is the release of this build, "dev" unless stamped
+var Version = "dev"
+
+// Get returns the version, commit and toolchain of the running binary
+func Get() models.BuildInfo {
+	info := models.BuildInfo{Version: Version, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				info.CommitTime = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,131 @@
+// Package challenge checks that anonymous writes come from a person, or at
+// least from a client willing to spend some CPU. A deployment picks one of
+// Cloudflare Turnstile, hCaptcha or a built-in proof of work.
+package challenge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Modes
+const (
+	ModeOff       = "off"
+	ModePoW       = "pow"
+	ModeTurnstile = "turnstile"
+	ModeHCaptcha  = "hcaptcha"
+)
+
+// verifyURLs are the siteverify endpoints of the CAPTCHA providers
+var verifyURLs = map[string]string{
+	ModeTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	ModeHCaptcha:  "https://api.hcaptcha.com/siteverify",
+}
+
+// ErrFailed is returned for missing, invalid, expired or reused responses
+var ErrFailed = errors.New("challenge failed")
+
+// Challenge is what a client needs to produce a response
+type Challenge struct {
+	Mode       string `json:"mode"`
+	SiteKey    string `json:"site_key,omitempty"`   // CAPTCHA widget key
+	Token      string `json:"token,omitempty"`      // Proof of work challenge
+	Difficulty int    `json:"difficulty,omitempty"` // Leading zero bits the proof of work needs
+}
+
+// Gate issues and verifies challenges in one mode
+type Gate struct {
+	mode    string
+	siteKey string
+	secret  string
+	client  *http.Client
+	pow     *proofOfWork
+}
+
+// New creates a gate. secret is the CAPTCHA provider's secret key; difficulty
+// applies to proof of work. It returns nil for ModeOff.
+func New(mode, siteKey, secret string, difficulty int) (*Gate, error) {
+	switch {
+	case mode == ModeOff:
+		return nil, nil
+	case mode == ModePoW:
+		pow, err := newProofOfWork(difficulty)
+		if err != nil {
+			return nil, err
+		}
+		return &Gate{mode: mode, pow: pow}, nil
+	case verifyURLs[mode] != "":
+		if secret == "" {
+			return nil, fmt.Errorf("%s needs a secret key", mode)
+		}
+		return &Gate{mode: mode, siteKey: siteKey, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	}
+	return nil, fmt.Errorf("unknown challenge mode %q", mode)
+}
+
+// Mode returns the gate's mode
+func (g *Gate) Mode() string {
+	return g.mode
+}
+
+// Challenge returns a new challenge for a client
+func (g *Gate) Challenge() (Challenge, error) {
+	c := Challenge{Mode: g.mode, SiteKey: g.siteKey}
+	if g.pow != nil {
+		token, err := g.pow.issue(time.Now())
+		if err != nil {
+			return c, err
+		}
+		c.Token, c.Difficulty = token, g.pow.difficulty
+	}
+	return c, nil
+}
+
+// Verify checks a client's response, returning ErrFailed when it does not
+// pass. remoteIP is passed on to CAPTCHA providers.
+func (g *Gate) Verify(ctx context.Context, response, remoteIP string) error {
+	if response == "" {
+		return ErrFailed
+	}
+	if g.pow != nil {
+		return g.pow.verify(response, time.Now())
+	}
+	return g.siteverify(ctx, response, remoteIP)
+}
+
+// siteverify asks the CAPTCHA provider whether response is valid
+func (g *Gate) siteverify(ctx context.Context, response, remoteIP string) error {
+	form := url.Values{"secret": {g.secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, verifyURLs[g.mode], strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s siteverify: %w", g.mode, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s siteverify: %s", g.mode, resp.Status)
+	}
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("%s siteverify: %w", g.mode, err)
+	}
+	if !result.Success {
+		return ErrFailed
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package challenge
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// solve finds a response to a proof of work token
+func solve(token string, difficulty int) string {
+	for nonce := 0; ; nonce++ {
+		response := token + ":" + strconv.Itoa(nonce)
+		if leadingZeroBits(sha256.Sum256([]byte(response))) >= difficulty {
+			return response
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		mode       string
+		secret     string
+		difficulty int
+		err        string
+	}{
+		{ModeOff, "", 0, ""},
+		{ModePoW, "", 1, ""},
+		{ModePoW, "", 32, ""},
+		{ModePoW, "", 0, "difficulty must be between 1 and 32, got 0"},
+		{ModePoW, "", 33, "difficulty must be between 1 and 32, got 33"},
+		{ModeTurnstile, "secret", 0, ""},
+		{ModeHCaptcha, "secret", 0, ""},
+		{ModeTurnstile, "", 0, "turnstile needs a secret key"},
+		{ModeHCaptcha, "", 0, "hcaptcha needs a secret key"},
+		{"recaptcha", "secret", 20, `unknown challenge mode "recaptcha"`},
+		{"", "", 20, `unknown challenge mode ""`},
+	}
+	for _, tt := range tests {
+		g, err := New(tt.mode, "site", tt.secret, tt.difficulty)
+		switch {
+		case tt.err != "":
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("New(%q, %q, %d) = %v, want error %q", tt.mode, tt.secret, tt.difficulty, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("New(%q, %q, %d): %v", tt.mode, tt.secret, tt.difficulty, err)
+		case tt.mode == ModeOff && g != nil:
+			t.Errorf("New(off) = %+v, want nil", g)
+		case tt.mode != ModeOff && g.Mode() != tt.mode:
+			t.Errorf("New(%q).Mode() = %q", tt.mode, g.Mode())
+		}
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	pow, err := New(ModePoW, "ignored", "", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := pow.Challenge()
+	if err != nil || c.Mode != ModePoW || c.SiteKey != "" || c.Difficulty != 12 || strings.Count(c.Token, ".") != 2 {
+		t.Errorf("pow Challenge() = %+v, %v", c, err)
+	}
+	if next, _ := pow.Challenge(); next.Token == c.Token {
+		t.Errorf("pow Challenge() repeated token %s", c.Token)
+	}
+
+	captcha, err := New(ModeTurnstile, "site", "secret", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = captcha.Challenge()
+	if err != nil || c != (Challenge{Mode: ModeTurnstile, SiteKey: "site"}) {
+		t.Errorf("turnstile Challenge() = %+v, %v", c, err)
+	}
+}
+
+func TestProofOfWork(t *testing.T) {
+	p, err := newProofOfWork(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	token, err := p.issue(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + strconv.FormatInt(now.Add(time.Hour).Unix(), 10) + "." + parts[2]
+	var weak string
+	for nonce := 0; weak == ""; nonce++ {
+		response := token + ":" + strconv.Itoa(nonce)
+		if leadingZeroBits(sha256.Sum256([]byte(response))) < 8 {
+			weak = response
+		}
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		at       time.Time
+		ok       bool
+	}{
+		{"no nonce", token, now, false},
+		{"malformed token", "abc.def:1", now, false},
+		{"forged expiry", solve(forged, 8), now, false},
+		{"other key", solve(otherToken(t, now), 8), now, false},
+		{"too little work", weak, now, false},
+		{"expired", solve(token, 8), now.Add(powLifetime + time.Second), false},
+		{"solved", solve(token, 8), now, true},
+		{"reused", solve(token, 8), now, false},
+		{"reused with another nonce", token + ":" + strconv.Itoa(nonceAfter(token, solve(token, 8))), now, false},
+	}
+	for _, tt := range tests {
+		err := p.verify(tt.response, tt.at)
+		if tt.ok && err != nil {
+			t.Errorf("%s: verify = %v", tt.name, err)
+		} else if !tt.ok && !errors.Is(err, ErrFailed) {
+			t.Errorf("%s: verify = %v, want ErrFailed", tt.name, err)
+		}
+	}
+}
+
+// otherToken issues a token signed with a different key
+func otherToken(t *testing.T, now time.Time) string {
+	t.Helper()
+	other, err := newProofOfWork(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := other.issue(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// nonceAfter finds another nonce that solves token after the one in response
+func nonceAfter(token, response string) int {
+	_, n, _ := strings.Cut(response, ":")
+	nonce, _ := strconv.Atoi(n)
+	for nonce++; ; nonce++ {
+		if leadingZeroBits(sha256.Sum256([]byte(token+":"+strconv.Itoa(nonce)))) >= 8 {
+			return nonce
+		}
+	}
+}
+
+func TestProofOfWorkForgetsExpiredTokens(t *testing.T) {
+	p, err := newProofOfWork(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	old, _ := p.issue(now)
+	if err := p.verify(solve(old, 1), now); err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(powLifetime + time.Minute)
+	fresh, _ := p.issue(later)
+	if err := p.verify(solve(fresh, 1), later); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.used) != 1 {
+		t.Errorf("%d tokens remembered, want only the unexpired one", len(p.used))
+	}
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	tests := []struct {
+		prefix []byte
+		want   int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x40}, 9},
+		{[]byte{0x00, 0x00, 0x0f}, 20},
+	}
+	for _, tt := range tests {
+		var sum [sha256.Size]byte
+		copy(sum[:], tt.prefix)
+		if got := leadingZeroBits(sum); got != tt.want {
+			t.Errorf("leadingZeroBits(%x) = %d, want %d", tt.prefix, got, tt.want)
+		}
+	}
+	if got := leadingZeroBits([sha256.Size]byte{}); got != 256 {
+		t.Errorf("leadingZeroBits(zero) = %d, want 256", got)
+	}
+}
+
+func TestSiteverify(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		// failed is whether the error is ErrFailed rather than an outage
+		failed bool
+		err    bool
+	}{
+		{"success", http.StatusOK, `{"success": true}`, false, false},
+		{"rejected", http.StatusOK, `{"success": false, "error-codes": ["invalid-input-response"]}`, true, true},
+		{"provider error", http.StatusInternalServerError, `{}`, false, true},
+		{"bad body", http.StatusOK, `<html>`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form map[string]string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				form = map[string]string{"secret": r.PostForm.Get("secret"), "response": r.PostForm.Get("response"), "remoteip": r.PostForm.Get("remoteip")}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			defer func(old string) { verifyURLs[ModeHCaptcha] = old }(verifyURLs[ModeHCaptcha])
+			verifyURLs[ModeHCaptcha] = srv.URL
+
+			g, err := New(ModeHCaptcha, "site", "secret", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = g.Verify(context.Background(), "answer", "203.0.113.7")
+			if tt.err != (err != nil) || tt.failed != errors.Is(err, ErrFailed) {
+				t.Errorf("Verify = %v", err)
+			}
+			if form["secret"] != "secret" || form["response"] != "answer" || form["remoteip"] != "203.0.113.7" {
+				t.Errorf("siteverify got %v", form)
+			}
+		})
+	}
+}
+
+func TestVerifyNeedsAResponse(t *testing.T) {
+	for _, mode := range []string{ModePoW, ModeTurnstile} {
+		g, err := New(mode, "site", "secret", 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// An empty response fails before any provider is asked
+		if err := g.Verify(context.Background(), "", ""); !errors.Is(err, ErrFailed) {
+			t.Errorf("%s: Verify(\"\") = %v, want ErrFailed", mode, err)
+		}
+	}
+}
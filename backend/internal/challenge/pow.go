@@ -0,0 +1,98 @@
+package challenge
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// powLifetime is how long a proof of work challenge can be answered
+const powLifetime = 5 * time.Minute
+
+// proofOfWork issues signed challenges that need no storage until answered.
+// A client answers a challenge with "<token>:<nonce>" such that the SHA-256
+// of that string starts with difficulty zero bits. Answered challenges are
+// remembered until they expire so each is used once.
+type proofOfWork struct {
+	difficulty int
+	key        []byte
+
+	mu   sync.Mutex
+	used map[string]time.Time // Token -> expiry
+}
+
+func newProofOfWork(difficulty int) (*proofOfWork, error) {
+	if difficulty < 1 || difficulty > 32 {
+		return nil, fmt.Errorf("proof of work difficulty must be between 1 and 32, got %d", difficulty)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &proofOfWork{difficulty: difficulty, key: key, used: make(map[string]time.Time)}, nil
+}
+
+// issue returns a challenge token: "<random>.<expiry>.<signature>"
+func (p *proofOfWork) issue(now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	payload := hex.EncodeToString(nonce) + "." + strconv.FormatInt(now.Add(powLifetime).Unix(), 10)
+	return payload + "." + p.sign(payload), nil
+}
+
+func (p *proofOfWork) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+func (p *proofOfWork) verify(response string, now time.Time) error {
+	token, _, ok := strings.Cut(response, ":")
+	if !ok {
+		return ErrFailed
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || !hmac.Equal([]byte(parts[2]), []byte(p.sign(parts[0]+"."+parts[1]))) {
+		return ErrFailed
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > expiry {
+		return ErrFailed
+	}
+	if leadingZeroBits(sha256.Sum256([]byte(response))) < p.difficulty {
+		return ErrFailed
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, seen := p.used[token]; seen {
+		return ErrFailed
+	}
+	for t, exp := range p.used {
+		if now.After(exp) {
+			delete(p.used, t)
+		}
+	}
+	p.used[token] = time.Unix(expiry, 0)
+	return nil
+}
+
+func leadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
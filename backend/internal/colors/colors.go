@@ -0,0 +1,174 @@
+// Package colors checks tier colors for legibility and for pairs that look
+// alike under common color-vision deficiencies.
+package colors
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+const (
+	// MinDistance is the smallest CIELAB distance (ΔE*76) at which two
+	// adjacent tier colors are considered distinguishable
+	MinDistance = 12.0
+	// MinContrast is the WCAG contrast ratio tier labels should reach
+	// against their tier color
+	MinContrast = 4.5
+)
+
+// rgb is a color in linear-light sRGB, each channel in 0..1
+type rgb [3]float64
+
+// Color-vision deficiency simulations for full dichromacy, from Machado,
+// Oliveira and Fernandes (2009). They apply to linear RGB.
+var visions = []struct {
+	name   string
+	matrix [3][3]float64
+}{
+	{models.VisionNormal, [3][3]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}},
+	{models.VisionProtanopia, [3][3]float64{
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	}},
+	{models.VisionDeuteranopia, [3][3]float64{
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	}},
+	{models.VisionTritanopia, [3][3]float64{
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	}},
+}
+
+// parse reads a #rrggbb color
+func parse(hex string) (color.RGBA, error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("color %q must be #rrggbb", hex)
+	}
+	v, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("color %q must be #rrggbb", hex)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// linear converts an sRGB color to linear RGB
+func linear(c color.RGBA) rgb {
+	var out rgb
+	for i, v := range [3]uint8{c.R, c.G, c.B} {
+		s := float64(v) / 255
+		if s <= 0.04045 {
+			out[i] = s / 12.92
+		} else {
+			out[i] = math.Pow((s+0.055)/1.055, 2.4)
+		}
+	}
+	return out
+}
+
+// LabelColor is the color tier labels are drawn in on c: near-black on
+// light colors and white on dark ones, split by perceived brightness
+func LabelColor(c color.RGBA) color.RGBA {
+	if 299*int(c.R)+587*int(c.G)+114*int(c.B) > 150000 {
+		return color.RGBA{0x11, 0x11, 0x11, 0xff}
+	}
+	return color.RGBA{0xff, 0xff, 0xff, 0xff}
+}
+
+func (c rgb) simulate(m [3][3]float64) rgb {
+	var out rgb
+	for i := range out {
+		v := m[i][0]*c[0] + m[i][1]*c[1] + m[i][2]*c[2]
+		out[i] = math.Min(1, math.Max(0, v))
+	}
+	return out
+}
+
+// luminance returns the WCAG relative luminance
+func (c rgb) luminance() float64 {
+	return 0.2126*c[0] + 0.7152*c[1] + 0.0722*c[2]
+}
+
+// lab converts to CIELAB under a D65 white point
+func (c rgb) lab() [3]float64 {
+	x := (0.4124*c[0] + 0.3576*c[1] + 0.1805*c[2]) / 0.95047
+	y := 0.2126*c[0] + 0.7152*c[1] + 0.0722*c[2]
+	z := (0.0193*c[0] + 0.1192*c[1] + 0.9505*c[2]) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// distance is the CIE76 color difference
+func distance(a, b rgb) float64 {
+	la, lb := a.lab(), b.lab()
+	return math.Sqrt((la[0]-lb[0])*(la[0]-lb[0]) + (la[1]-lb[1])*(la[1]-lb[1]) + (la[2]-lb[2])*(la[2]-lb[2]))
+}
+
+// contrast is the WCAG contrast ratio between two luminances
+func contrast(l1, l2 float64) float64 {
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// round2 keeps reported values readable
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// Check reports adjacent colors that are hard to tell apart under normal
+// vision or a simulated deficiency, and colors on which their LabelColor
+// does not reach MinContrast. Colors are in tier order.
+func Check(hexes []string) ([]models.ColorIssue, error) {
+	parsed := make([]rgb, len(hexes))
+	issues := make([]models.ColorIssue, 0)
+	for i, h := range hexes {
+		c, err := parse(h)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = linear(c)
+		ratio := contrast(parsed[i].luminance(), linear(LabelColor(c)).luminance())
+		if ratio < MinContrast {
+			issues = append(issues, models.ColorIssue{
+				Kind:    models.ColorLowContrast,
+				Tiers:   []int{i},
+				Value:   round2(ratio),
+				Message: fmt.Sprintf("Tier %d labels reach only %.1f:1 contrast on %s", i+1, ratio, hexes[i]),
+			})
+		}
+	}
+
+	for i := 1; i < len(parsed); i++ {
+		for _, v := range visions {
+			d := distance(parsed[i-1].simulate(v.matrix), parsed[i].simulate(v.matrix))
+			if d >= MinDistance {
+				continue
+			}
+			issues = append(issues, models.ColorIssue{
+				Kind:    models.ColorSimilar,
+				Vision:  v.name,
+				Tiers:   []int{i - 1, i},
+				Value:   round2(d),
+				Message: fmt.Sprintf("Tiers %d and %d (%s, %s) look alike with %s vision", i, i+1, hexes[i-1], hexes[i], v.name),
+			})
+			// One report per pair is enough; normal vision is checked first
+			break
+		}
+	}
+	return issues, nil
+}
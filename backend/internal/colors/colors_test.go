@@ -0,0 +1,94 @@
+package colors
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestCheck(t *testing.T) {
+	// An issue is summarized as kind/vision/tiers
+	tests := []struct {
+		name   string
+		colors []string
+		want   []string
+	}{
+		{"empty", nil, nil},
+		{"black and white", []string{"#000000", "#ffffff"}, nil},
+		{"distinct", []string{"#3366cc", "#33cc99"}, nil},
+		// White labels only reach 4.0:1 on pure red
+		{"low contrast", []string{"#008800", "#ff0000"}, []string{"low_contrast//[1]"}},
+		{"similar", []string{"#ff7f7f", "#ff7f80"}, []string{"similar/normal/[0 1]"}},
+		{"similar to protanopes", []string{"#ffff7f", "#bfff7f"}, []string{"similar/protanopia/[0 1]"}},
+		{"similar to deuteranopes", []string{"#ffbf7f", "#ffdf7f"}, []string{"similar/deuteranopia/[0 1]"}},
+		// Only adjacent tiers are compared
+		{"repeats apart", []string{"#000000", "#ffffff", "#000000"}, nil},
+		{"runs", []string{"#ff7f7f", "#ff7f7f", "#ff7f7f"}, []string{"similar/normal/[0 1]", "similar/normal/[1 2]"}},
+	}
+	for _, tt := range tests {
+		issues, err := Check(tt.colors)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var got []string
+		for _, issue := range issues {
+			got = append(got, summarize(issue))
+		}
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("%s: Check(%v) = %v, want %v", tt.name, tt.colors, got, tt.want)
+		}
+	}
+}
+
+func summarize(issue models.ColorIssue) string {
+	return fmt.Sprintf("%s/%s/%v", issue.Kind, issue.Vision, issue.Tiers)
+}
+
+func TestCheckReportsValues(t *testing.T) {
+	issues, err := Check([]string{"#ff0000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Value != 4 || issues[0].Message != "Tier 1 labels reach only 4.0:1 contrast on #ff0000" {
+		t.Errorf("Check(#ff0000) = %+v", issues)
+	}
+}
+
+func TestCheckErrors(t *testing.T) {
+	for _, hex := range []string{"", "ff0000", "#ff00", "#ff00001", "#gg0000", "#FF00 0"} {
+		if _, err := Check([]string{"#ffffff", hex}); err == nil || !strings.Contains(err.Error(), "must be #rrggbb") {
+			t.Errorf("Check(%q) = %v, want a format error", hex, err)
+		}
+	}
+	if _, err := Check([]string{"#FFAA00"}); err != nil {
+		t.Errorf("Check(#FFAA00): %v", err)
+	}
+}
+
+func TestLabelColor(t *testing.T) {
+	dark := color.RGBA{0x11, 0x11, 0x11, 0xff}
+	light := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	tests := []struct {
+		c    color.RGBA
+		want color.RGBA
+	}{
+		{color.RGBA{0, 0, 0, 0xff}, light},
+		{color.RGBA{0xff, 0xff, 0xff, 0xff}, dark},
+		{color.RGBA{0xff, 0, 0, 0xff}, light},
+		{color.RGBA{0, 0xff, 0, 0xff}, light},
+		{color.RGBA{0, 0xff, 0x7f, 0xff}, dark},
+		{color.RGBA{0xff, 0xff, 0x7f, 0xff}, dark},
+		// Perceived brightness of exactly 150000 still gets white labels
+		{color.RGBA{150, 150, 150, 0xff}, light},
+		{color.RGBA{151, 150, 150, 0xff}, dark},
+	}
+	for _, tt := range tests {
+		if got := LabelColor(tt.c); got != tt.want {
+			t.Errorf("LabelColor(%v) = %v, want %v", tt.c, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Config holds runtime settings for the server, read from the environment
+type Config struct {
+	Port        string
+	DBPath      string
+	AdminToken  string // Bearer token for /api/admin; empty disables the admin API
+	HealthToken string // Bearer token for /health/deep besides admin sessions
+	JobsEnabled bool
+	BackupDir   string
+	BackupKeep  int
+
+	// ContentFilterMode is "mask" or "reject"
+	ContentFilterMode string
+	// ContentFilterWords overrides the built-in word list when non-empty
+	ContentFilterWords []string
+	// ColorCheckMode is "off", "warn" or "reject" for saved tier colors that
+	// are indistinguishable under common color-vision deficiencies
+	ColorCheckMode string
+
+	// EmailProvider is "smtp", "log" (print messages instead of sending) or
+	// empty to disable email entirely
+	EmailProvider string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+	EmailFrom     string
+	// PublicURL is the frontend base URL used to build links in emails
+	PublicURL string
+	// CORSOrigins lists the origins allowed to call the API with credentials;
+	// * matches any run of characters, as in https://*.example.com. The
+	// origin of PublicURL is always allowed.
+	CORSOrigins []string
+	// TrustedProxies are the addresses and CIDR ranges of reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers name the client. Requests
+	// from anywhere else are identified by their peer address, so clients
+	// cannot pick the IP that views, bans and rate limits see.
+	TrustedProxies []netip.Prefix
+	// ShortDomain is the host of a shortlink domain, such as tfrg.app, whose
+	// /{code} paths redirect to shared tier lists; empty disables it
+	ShortDomain string
+
+	// DeviceCookies issues anonymous visitors a signed device cookie that
+	// views, poll votes and reports are deduplicated by. DeviceSecrets sign
+	// it, newest first; older ones still verify, so secrets can be rotated.
+	// Without secrets a random key is used and devices reset on restart.
+	DeviceCookies bool
+	DeviceSecrets []string
+
+	// ChallengeMode asks anonymous visitors creating tier lists or reporting
+	// them to pass a challenge: off, pow, turnstile or hcaptcha.
+	// ChallengeSiteKey and ChallengeSecret are the CAPTCHA provider's keys;
+	// ChallengeDifficulty is the proof of work's leading zero bits.
+	ChallengeMode       string
+	ChallengeSiteKey    string
+	ChallengeSecret     string
+	ChallengeDifficulty int
+
+	// PackIndexURL points at a game pack registry index; empty disables
+	// installing packs
+	PackIndexURL string
+
+	// AnalyticsEnabled records anonymized product events; users and
+	// browsers sending DNT or GPC can still opt out individually
+	AnalyticsEnabled bool
+	// AnalyticsRetentionDays prunes older events; 0 keeps them forever
+	AnalyticsRetentionDays int
+
+	// ListRetentionDays deletes anonymous tier lists nobody has changed for
+	// that many days and nobody has ever viewed; 0 keeps them forever. With
+	// ListRetentionDryRun the job only logs what it would delete.
+	ListRetentionDays   int
+	ListRetentionDryRun bool
+
+	// PrerenderWorkers renders share images of updated lists in the
+	// background; 0 renders only on first request
+	PrerenderWorkers int
+	// PrerenderQueue bounds pending renders; lists beyond it are skipped
+	// until the next sweep
+	PrerenderQueue int
+
+	// ImageCacheDir holds resized and re-encoded image variants; "off"
+	// encodes them on every request
+	ImageCacheDir string
+
+	// Default quotas of each account, which admins can override per user.
+	// TierListsPerUser caps the lists a user authors. CustomItemsPerList and
+	// CustomItemsPerUser cap the images uploaded to one custom tier list and
+	// to all lists of one user, and UploadMBPerUser their total size. Zero
+	// allows nothing and a negative limit is unlimited. CustomUploadMaxKB
+	// caps each image file.
+	TierListsPerUser   int
+	CustomItemsPerList int
+	CustomItemsPerUser int
+	UploadMBPerUser    int
+	CustomUploadMaxKB  int
+
+	// LinkCheckEnabled checks the catalog's wiki and icon links weekly and
+	// logs the dead ones; LinkCheckClear also removes links that answer 404
+	// or 410
+	LinkCheckEnabled bool
+	LinkCheckClear   bool
+
+	// PprofEnabled serves net/http/pprof to admins at /api/admin/debug/pprof/
+	PprofEnabled bool
+	// PprofBlockRate and PprofMutexFraction start block and mutex profiling,
+	// see runtime.SetBlockProfileRate and runtime.SetMutexProfileFraction;
+	// 0 leaves them off
+	PprofBlockRate     int
+	PprofMutexFraction int
+
+	// TracingEndpoint is the OTLP/HTTP traces URL spans are exported to;
+	// empty disables tracing. TracingHeaders are sent with each export and
+	// TracingSampleRatio is the share of new traces recorded.
+	TracingEndpoint    string
+	TracingHeaders     map[string]string
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	// SQLite tuning, see storage.Options
+	DBBusyTimeoutMS   int
+	DBCacheSizeKB     int
+	DBSynchronous     string
+	DBMaxOpenConns    int
+	DBSerializeWrites bool
+}
+
+// Load reads configuration from environment variables, applying defaults
+func Load() (*Config, error) {
+	cfg := &Config{
+		Port:                   getEnv("PORT", "8080"),
+		DBPath:                 getEnv("DB_PATH", "./tierforge.db"),
+		AdminToken:             os.Getenv("ADMIN_TOKEN"),
+		HealthToken:            os.Getenv("HEALTH_TOKEN"),
+		JobsEnabled:            getBool("JOBS_ENABLED", true),
+		BackupDir:              getEnv("BACKUP_DIR", "./backups"),
+		BackupKeep:             getInt("BACKUP_KEEP", 7),
+		ContentFilterMode:      getEnv("CONTENT_FILTER_MODE", "mask"),
+		ColorCheckMode:         getEnv("COLOR_CHECK_MODE", "warn"),
+		EmailProvider:          os.Getenv("EMAIL_PROVIDER"),
+		SMTPHost:               os.Getenv("SMTP_HOST"),
+		SMTPPort:               getInt("SMTP_PORT", 587),
+		SMTPUsername:           os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:           os.Getenv("SMTP_PASSWORD"),
+		EmailFrom:              getEnv("EMAIL_FROM", "TierForge <noreply@tierforge.app>"),
+		PublicURL:              strings.TrimSuffix(getEnv("PUBLIC_URL", "http://localhost:3000"), "/"),
+		ShortDomain:            strings.ToLower(strings.TrimSpace(os.Getenv("SHORT_DOMAIN"))),
+		DeviceCookies:          getBool("DEVICE_COOKIES", true),
+		ChallengeMode:          strings.ToLower(getEnv("CHALLENGE_MODE", "off")),
+		ChallengeSiteKey:       os.Getenv("CHALLENGE_SITE_KEY"),
+		ChallengeSecret:        os.Getenv("CHALLENGE_SECRET"),
+		ChallengeDifficulty:    getInt("CHALLENGE_DIFFICULTY", 20),
+		PackIndexURL:           os.Getenv("PACK_INDEX_URL"),
+		AnalyticsEnabled:       getBool("ANALYTICS_ENABLED", true),
+		AnalyticsRetentionDays: getInt("ANALYTICS_RETENTION_DAYS", 180),
+		ListRetentionDays:      getInt("ANONYMOUS_LIST_RETENTION_DAYS", 180),
+		ListRetentionDryRun:    getBool("ANONYMOUS_LIST_RETENTION_DRY_RUN", true),
+		PrerenderWorkers:       getInt("PRERENDER_WORKERS", 2),
+		PrerenderQueue:         getInt("PRERENDER_QUEUE", 256),
+		ImageCacheDir:          getEnv("IMAGE_CACHE_DIR", "./cache/images"),
+		TierListsPerUser:       getInt("TIERLISTS_PER_USER", 1000),
+		CustomItemsPerList:     getInt("CUSTOM_ITEMS_PER_LIST", 100),
+		CustomItemsPerUser:     getInt("CUSTOM_ITEMS_PER_USER", 1000),
+		UploadMBPerUser:        getInt("UPLOAD_MB_PER_USER", 200),
+		CustomUploadMaxKB:      getInt("CUSTOM_UPLOAD_MAX_KB", 2048),
+		LinkCheckEnabled:       getBool("LINK_CHECK_ENABLED", false),
+		LinkCheckClear:         getBool("LINK_CHECK_CLEAR", false),
+		PprofEnabled:           getBool("PPROF_ENABLED", false),
+		PprofBlockRate:         getInt("PPROF_BLOCK_RATE", 0),
+		PprofMutexFraction:     getInt("PPROF_MUTEX_FRACTION", 0),
+		TracingServiceName:     getEnv("OTEL_SERVICE_NAME", "tierforge"),
+		TracingSampleRatio:     getFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+		DBBusyTimeoutMS:        getInt("DB_BUSY_TIMEOUT_MS", 5000),
+		DBCacheSizeKB:          getInt("DB_CACHE_SIZE_KB", 0),
+		DBSynchronous:          strings.ToUpper(os.Getenv("DB_SYNCHRONOUS")),
+		DBMaxOpenConns:         getInt("DB_MAX_OPEN_CONNS", 0),
+		DBSerializeWrites:      getBool("DB_SERIALIZE_WRITES", true),
+	}
+
+	if cfg.ContentFilterMode != "mask" && cfg.ContentFilterMode != "reject" {
+		return nil, fmt.Errorf("CONTENT_FILTER_MODE must be mask or reject, got %q", cfg.ContentFilterMode)
+	}
+	switch cfg.ColorCheckMode {
+	case "off", "warn", "reject":
+	default:
+		return nil, fmt.Errorf("COLOR_CHECK_MODE must be off, warn or reject, got %q", cfg.ColorCheckMode)
+	}
+	switch cfg.EmailProvider {
+	case "", "log":
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required when EMAIL_PROVIDER=smtp")
+		}
+	default:
+		return nil, fmt.Errorf("EMAIL_PROVIDER must be smtp, log or empty, got %q", cfg.EmailProvider)
+	}
+	switch cfg.DBSynchronous {
+	case "", "OFF", "NORMAL", "FULL", "EXTRA":
+	default:
+		return nil, fmt.Errorf("DB_SYNCHRONOUS must be OFF, NORMAL, FULL or EXTRA, got %q", cfg.DBSynchronous)
+	}
+	if cfg.DBBusyTimeoutMS < 0 || cfg.DBCacheSizeKB < 0 || cfg.DBMaxOpenConns < 0 {
+		return nil, fmt.Errorf("DB_BUSY_TIMEOUT_MS, DB_CACHE_SIZE_KB and DB_MAX_OPEN_CONNS must not be negative")
+	}
+	if cfg.PrerenderWorkers < 0 || cfg.PrerenderQueue < 1 {
+		return nil, fmt.Errorf("PRERENDER_WORKERS must not be negative and PRERENDER_QUEUE must be positive")
+	}
+	for _, origin := range strings.Split(getEnv("CORS_ORIGINS", "http://localhost:*,https://*.tierforge.app"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.CORSOrigins = append(cfg.CORSOrigins, strings.ToLower(strings.TrimSuffix(origin, "/")))
+		}
+	}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES must be comma-separated IPs or CIDR ranges, got %q", proxy)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, prefix.Masked())
+	}
+	for _, secret := range strings.Split(os.Getenv("DEVICE_SECRET"), ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			cfg.DeviceSecrets = append(cfg.DeviceSecrets, secret)
+		}
+	}
+	switch cfg.ChallengeMode {
+	case "off":
+	case "pow":
+		if cfg.ChallengeDifficulty < 1 || cfg.ChallengeDifficulty > 32 {
+			return nil, fmt.Errorf("CHALLENGE_DIFFICULTY must be between 1 and 32, got %d", cfg.ChallengeDifficulty)
+		}
+	case "turnstile", "hcaptcha":
+		if cfg.ChallengeSecret == "" {
+			return nil, fmt.Errorf("CHALLENGE_SECRET is required when CHALLENGE_MODE=%s", cfg.ChallengeMode)
+		}
+	default:
+		return nil, fmt.Errorf("CHALLENGE_MODE must be off, pow, turnstile or hcaptcha, got %q", cfg.ChallengeMode)
+	}
+	if strings.ContainsAny(cfg.ShortDomain, "/:") {
+		return nil, fmt.Errorf("SHORT_DOMAIN must be a host name without scheme or port, got %q", cfg.ShortDomain)
+	}
+	if cfg.CustomUploadMaxKB < 1 {
+		return nil, fmt.Errorf("CUSTOM_UPLOAD_MAX_KB must be positive, got %d", cfg.CustomUploadMaxKB)
+	}
+	if cfg.ImageCacheDir == "off" {
+		cfg.ImageCacheDir = ""
+	}
+	if cfg.PprofBlockRate < 0 || cfg.PprofMutexFraction < 0 {
+		return nil, fmt.Errorf("PPROF_BLOCK_RATE and PPROF_MUTEX_FRACTION must not be negative")
+	}
+	// As with OpenTelemetry SDKs, the traces URL is the general endpoint
+	// with /v1/traces appended unless given on its own
+	cfg.TracingEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); cfg.TracingEndpoint == "" && endpoint != "" {
+		cfg.TracingEndpoint = strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	if cfg.TracingEndpoint != "" && !strings.HasPrefix(cfg.TracingEndpoint, "http://") && !strings.HasPrefix(cfg.TracingEndpoint, "https://") {
+		return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT must be an http or https URL, got %q", cfg.TracingEndpoint)
+	}
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		return nil, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1, got %v", cfg.TracingSampleRatio)
+	}
+	for _, header := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if strings.TrimSpace(header) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(header, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS must be comma-separated key=value pairs")
+		}
+		if cfg.TracingHeaders == nil {
+			cfg.TracingHeaders = make(map[string]string)
+		}
+		// Values may be percent-encoded
+		if unescaped, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = unescaped
+		}
+		cfg.TracingHeaders[strings.TrimSpace(key)] = value
+	}
+	if words := os.Getenv("CONTENT_FILTER_WORDS"); words != "" {
+		cfg.ContentFilterWords = strings.Split(words, ",")
+	}
+	if path := os.Getenv("CONTENT_FILTER_WORDS_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read content filter words: %w", err)
+		}
+		cfg.ContentFilterWords = append(cfg.ContentFilterWords, strings.Split(string(data), "\n")...)
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getBool(key string, fallback bool) bool {
+	if b, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return b
+	}
+	return fallback
+}
+
+func getFloat(key string, fallback float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return f
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return n
+	}
+	return fallback
+}
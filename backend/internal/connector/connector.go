@@ -0,0 +1,469 @@
+// Package connector keeps a sheet in sync with an upstream JSON API, such as
+// a community database of a game's skills. Each item source names a URL,
+// where the records are in the response and which JSONPath of a record fills
+// each item field. A sync fetches the records, diffs them against the sheet
+// and writes only what changed, so catalogs follow the upstream without
+// manual imports.
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+	"github.com/meur/tierforge/internal/plugins/script"
+	"github.com/meur/tierforge/internal/slug"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+const (
+	// DefaultInterval and MinInterval bound how often a source is synced,
+	// in minutes
+	DefaultInterval = 24 * 60
+	MinInterval     = 15
+	// maxResponseSize caps an upstream response, in bytes
+	maxResponseSize = 32 << 20
+	userAgent       = "TierForge item connector"
+)
+
+// itemFields are the mappable item fields besides data.<key>
+var itemFields = map[string]bool{
+	"id": true, "name": true, "name_ru": true, "icon": true, "category": true,
+	"game_version": true, "spoiler": true, "tags": true,
+}
+
+// Validate checks a source's settings, filling in the default interval
+func Validate(src *models.ItemSource) error {
+	u, err := url.Parse(src.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http or https URL")
+	}
+	if src.SheetID == "" {
+		return errors.New("sheet_id is required")
+	}
+	if strings.ContainsAny(src.AuthHeader, ": \t\r\n") || strings.ContainsAny(src.AuthValue, "\r\n") {
+		return errors.New("invalid auth_header or auth_value")
+	}
+	if src.AuthValue != "" && src.AuthHeader == "" {
+		return errors.New("auth_header is required with auth_value")
+	}
+	if src.RecordsPath != "" {
+		if _, err := ParsePath(src.RecordsPath); err != nil {
+			return fmt.Errorf("records_path: %w", err)
+		}
+	}
+	if src.Fields["name"] == "" {
+		return errors.New("fields must map name")
+	}
+	for field, path := range src.Fields {
+		key, isData := strings.CutPrefix(field, "data.")
+		if !itemFields[field] && (!isData || key == "") {
+			return fmt.Errorf("unknown field %q; use %s or data.<key>", field, strings.Join(mappableFields(), ", "))
+		}
+		if _, err := ParsePath(path); err != nil {
+			return fmt.Errorf("fields.%s: %w", field, err)
+		}
+	}
+	if src.IntervalMinutes == 0 {
+		src.IntervalMinutes = DefaultInterval
+	}
+	if src.IntervalMinutes < MinInterval {
+		return fmt.Errorf("interval_minutes must be at least %d", MinInterval)
+	}
+	return nil
+}
+
+func mappableFields() []string {
+	fields := make([]string, 0, len(itemFields))
+	for f := range itemFields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Connector fetches item sources over HTTP
+type Connector struct {
+	client *http.Client
+}
+
+// New creates a connector; each request times out after timeout
+func New(timeout time.Duration) *Connector {
+	return &Connector{client: &http.Client{Timeout: timeout}}
+}
+
+// Fetch reads the response of a source, failing on non-200 responses and
+// bodies over maxResponseSize
+func (c *Connector) Fetch(ctx context.Context, src *models.ItemSource) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	if src.AuthHeader != "" {
+		req.Header.Set(src.AuthHeader, src.AuthValue)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", src.URL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", src.URL, maxResponseSize)
+	}
+	return data, nil
+}
+
+// Map turns a source's response into items of its sheet. Records match
+// existing items by ID, then by name; matched items keep the fields the
+// mapping leaves out, such as curated tags. Records without a name, or
+// repeating an earlier record's item, are skipped and counted.
+func Map(src *models.ItemSource, data []byte, existing []models.Item) ([]models.Item, int, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, fmt.Errorf("parse response: %w", err)
+	}
+	var records []interface{}
+	if src.RecordsPath == "" {
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return nil, 0, errors.New("response is not an array; set records_path")
+		}
+		records = arr
+	} else {
+		path, err := ParsePath(src.RecordsPath)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = path.Select(raw)
+		// A path to the array itself rather than its elements
+		if len(records) == 1 {
+			if arr, ok := records[0].([]interface{}); ok {
+				records = arr
+			}
+		}
+	}
+
+	paths := make(map[string]Path, len(src.Fields))
+	for field, p := range src.Fields {
+		path, err := ParsePath(p)
+		if err != nil {
+			return nil, 0, err
+		}
+		paths[field] = path
+	}
+
+	byID := make(map[string]models.Item, len(existing))
+	byName := make(map[string]models.Item, len(existing))
+	for _, item := range existing {
+		byID[item.ID] = item
+		if _, dup := byName[item.Name]; !dup {
+			byName[item.Name] = item
+		}
+	}
+
+	items := make([]models.Item, 0, len(records))
+	seen := make(map[string]bool, len(records))
+	skipped := 0
+	for _, rec := range records {
+		values := make(map[string]interface{}, len(paths))
+		for field, path := range paths {
+			if v, ok := path.First(rec); ok {
+				values[field] = v
+			}
+		}
+		name := strings.TrimSpace(text(values["name"]))
+		if name == "" {
+			skipped++
+			continue
+		}
+		key := name
+		if id := strings.TrimSpace(text(values["id"])); id != "" {
+			key = id
+		}
+		id := slug.ItemID(src.GameID, src.SheetID, key)
+
+		item, ok := byID[id]
+		if !ok {
+			item, ok = byName[name]
+		}
+		if ok {
+			data := make(map[string]interface{}, len(item.Data))
+			for k, v := range item.Data {
+				data[k] = v
+			}
+			item.Data = data
+			item.UpdatedAt = nil
+		} else {
+			item = models.Item{ID: id, GameID: src.GameID, SheetID: src.SheetID}
+		}
+		if item.ID == "" || seen[item.ID] {
+			skipped++
+			continue
+		}
+		seen[item.ID] = true
+
+		item.Name = name
+		if item.Data == nil {
+			item.Data = make(map[string]interface{})
+		}
+		for field, v := range values {
+			switch field {
+			case "id", "name":
+			case "name_ru":
+				item.NameRu = text(v)
+			case "icon":
+				item.Icon = text(v)
+			case "category":
+				item.Category = text(v)
+			case "game_version":
+				item.GameVersion = text(v)
+			case "spoiler":
+				item.Spoiler = truthy(v)
+			case "tags":
+				item.Tags = tags(v)
+			default:
+				item.Data[strings.TrimPrefix(field, "data.")] = v
+			}
+		}
+		items = append(items, item)
+	}
+	return items, skipped, nil
+}
+
+// text renders a JSON scalar as a string; objects and arrays give ""
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// tags reads an array of tags, or a comma-separated string of them
+func tags(v interface{}) []string {
+	var list []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, t := range v {
+			list = append(list, text(t))
+		}
+	case string:
+		list = strings.Split(v, ",")
+	}
+	out := make([]string, 0, len(list))
+	for _, t := range list {
+		if t = strings.TrimSpace(t); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Sync fetches a source and writes the items that changed, snapshotting the
+// sheet first so the sync can be rolled back. With dryRun nothing is
+// written and the result says what would change.
+func (c *Connector) Sync(ctx context.Context, store *storage.Store, src *models.ItemSource, dryRun bool) (*models.SourceSyncResult, error) {
+	data, err := c.Fetch(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := store.GetItems(src.GameID, src.SheetID)
+	if err != nil {
+		return nil, err
+	}
+	items, skipped, err := Map(src, data, existing)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		// Most likely a broken upstream or mapping; never empty the sheet
+		return nil, fmt.Errorf("no items in response (%d records skipped)", skipped)
+	}
+	plugins.EnrichAll(items)
+	if err := enrichWithScript(store, src.GameID, items); err != nil {
+		return nil, err
+	}
+
+	result := &models.SourceSyncResult{Skipped: skipped, DryRun: dryRun}
+	current := make(map[string]models.Item, len(existing))
+	for _, item := range existing {
+		current[item.ID] = item
+	}
+	var created, updated []models.Item
+	for _, item := range items {
+		before, ok := current[item.ID]
+		delete(current, item.ID)
+		switch {
+		case !ok:
+			created = append(created, item)
+		case !sameItem(before, item):
+			updated = append(updated, item)
+		default:
+			result.Unchanged++
+		}
+	}
+	var removed []string
+	if src.RemoveMissing {
+		for id := range current {
+			removed = append(removed, id)
+		}
+		sort.Strings(removed)
+	}
+	result.Created, result.Updated, result.Removed = len(created), len(updated), len(removed)
+	for _, list := range [][]models.Item{created, updated} {
+		for _, item := range list {
+			result.Changed = append(result.Changed, item.ID)
+		}
+	}
+	result.Changed = append(result.Changed, removed...)
+	if dryRun || len(result.Changed) == 0 {
+		return result, nil
+	}
+
+	actor := "source:" + strconv.FormatInt(src.ID, 10)
+	if _, err := store.SnapshotItems(src.GameID, src.SheetID, actor); err != nil {
+		return nil, fmt.Errorf("snapshot items: %w", err)
+	}
+	err = store.WithTx(ctx, func(tx *storage.Store) error {
+		for i := range created {
+			if err := tx.CreateItem(&created[i]); err != nil {
+				return fmt.Errorf("create %s: %w", created[i].ID, err)
+			}
+		}
+		for i := range updated {
+			if err := tx.UpdateItem(&updated[i]); err != nil {
+				return fmt.Errorf("update %s: %w", updated[i].ID, err)
+			}
+		}
+		for _, id := range removed {
+			if err := tx.DeleteItem(src.GameID, id); err != nil {
+				return fmt.Errorf("delete %s: %w", id, err)
+			}
+		}
+		return tx.AddAuditEntry(&models.AuditEntry{
+			Actor:      actor,
+			Action:     "items.import",
+			TargetType: "sheet",
+			TargetID:   src.SheetID,
+			GameID:     src.GameID,
+			Details: fmt.Sprintf("synced from %s (created %d, updated %d, removed %d)",
+				src.URL, result.Created, result.Updated, result.Removed),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// enrichWithScript runs the enrich function of the game's script, if it has
+// one, as cmd/import does
+func enrichWithScript(store *storage.Store, gameID string, items []models.Item) error {
+	gs, err := store.GetGameScript(gameID)
+	if err != nil || gs == nil {
+		return err
+	}
+	sc, err := script.Compile(gameID, gs.Source)
+	if err != nil {
+		return fmt.Errorf("game script: %w", err)
+	}
+	defer sc.Close()
+	if err := sc.EnrichAll(items); err != nil {
+		return fmt.Errorf("game script: %w", err)
+	}
+	return nil
+}
+
+// sameItem reports whether two versions of an item store the same content.
+// They are compared as JSON, as the database holds them, so a number the
+// upstream sends as 5 equals a stored 5.0, and tags in any order.
+func sameItem(a, b models.Item) bool {
+	a.UpdatedAt, b.UpdatedAt = nil, nil
+	for _, item := range []*models.Item{&a, &b} {
+		if len(item.Data) == 0 {
+			item.Data = nil
+		}
+		if len(item.Tags) == 0 {
+			item.Tags = nil
+		} else {
+			item.Tags = append([]string(nil), item.Tags...)
+			sort.Strings(item.Tags)
+		}
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// SyncDue syncs every enabled source whose interval has passed, recording
+// each outcome. Failing sources don't stop the others; their errors are
+// joined into the one returned.
+func (c *Connector) SyncDue(ctx context.Context, store *storage.Store) error {
+	sources, err := store.GetItemSources("")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range sources {
+		src := &sources[i]
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		interval := time.Duration(src.IntervalMinutes) * time.Minute
+		if !src.Enabled || (src.LastSyncAt != nil && time.Since(*src.LastSyncAt) < interval) {
+			continue
+		}
+		start := time.Now()
+		result, err := c.Sync(ctx, store, src, false)
+		if recErr := store.RecordItemSourceSync(src.ID, start, result, err); recErr != nil {
+			log.Printf("ERROR: Failed to record sync of item source %d: %v", src.ID, recErr)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("item source %d: %w", src.ID, err))
+			continue
+		}
+		if len(result.Changed) > 0 {
+			log.Printf("Synced item source %d into %s/%s: created %d, updated %d, removed %d",
+				src.ID, src.GameID, src.SheetID, result.Created, result.Updated, result.Removed)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,135 @@
+package connector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Path is a compiled JSONPath. The supported subset covers what field
+// mappings need: $ for the root, .name and ['name'] for members, [n] for
+// array elements (negative from the end) and .* or [*] for every member.
+type Path []step
+
+type step struct {
+	key   string
+	index int
+	kind  stepKind
+}
+
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+// ParsePath compiles a JSONPath. The leading $ may be left out, so "name"
+// and "$.name" are the same path.
+func ParsePath(s string) (Path, error) {
+	s = strings.TrimSpace(s)
+	rest := strings.TrimPrefix(s, "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+
+	var path Path
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			switch name {
+			case "":
+				return nil, fmt.Errorf("invalid path %q: empty member name", s)
+			case "*":
+				path = append(path, step{kind: stepWildcard})
+			default:
+				path = append(path, step{kind: stepKey, key: name})
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed [", s)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				path = append(path, step{kind: stepWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				path = append(path, step{kind: stepKey, key: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: bad index %q", s, inner)
+				}
+				path = append(path, step{kind: stepIndex, index: n})
+			}
+		default:
+			return nil, fmt.Errorf("invalid path %q", s)
+		}
+	}
+	return path, nil
+}
+
+// Select returns the values the path matches in v, a value decoded by
+// encoding/json. Wildcards visit object members in key order.
+func (p Path) Select(v interface{}) []interface{} {
+	values := []interface{}{v}
+	for _, st := range p {
+		var next []interface{}
+		for _, v := range values {
+			switch st.kind {
+			case stepKey:
+				if obj, ok := v.(map[string]interface{}); ok {
+					if child, ok := obj[st.key]; ok {
+						next = append(next, child)
+					}
+				}
+			case stepIndex:
+				if arr, ok := v.([]interface{}); ok {
+					i := st.index
+					if i < 0 {
+						i += len(arr)
+					}
+					if i >= 0 && i < len(arr) {
+						next = append(next, arr[i])
+					}
+				}
+			case stepWildcard:
+				switch c := v.(type) {
+				case []interface{}:
+					next = append(next, c...)
+				case map[string]interface{}:
+					keys := make([]string, 0, len(c))
+					for k := range c {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, c[k])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// First returns the first value the path matches in v
+func (p Path) First(v interface{}) (interface{}, bool) {
+	values := p.Select(v)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
@@ -0,0 +1,123 @@
+// Package email sends transactional and digest mail through a pluggable
+// provider. Email is disabled unless EMAIL_PROVIDER is set.
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/config"
+)
+
+// ErrDisabled is returned when sending while no provider is configured
+var ErrDisabled = errors.New("email is disabled")
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New returns the sender selected by cfg.EmailProvider
+func New(cfg *config.Config) Sender {
+	switch cfg.EmailProvider {
+	case "smtp":
+		return &smtpSender{
+			addr: fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort),
+			host: cfg.SMTPHost,
+			user: cfg.SMTPUsername,
+			pass: cfg.SMTPPassword,
+			from: cfg.EmailFrom,
+		}
+	case "log":
+		return logSender{}
+	}
+	return disabledSender{}
+}
+
+// Enabled reports whether s actually delivers mail
+func Enabled(s Sender) bool {
+	_, disabled := s.(disabledSender)
+	return !disabled
+}
+
+type disabledSender struct{}
+
+func (disabledSender) Send(ctx context.Context, msg *Message) error {
+	return ErrDisabled
+}
+
+// logSender prints messages to the server log, for local development
+type logSender struct{}
+
+func (logSender) Send(ctx context.Context, msg *Message) error {
+	log.Printf("EMAIL to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+type smtpSender struct {
+	addr, host string
+	user, pass string
+	from       string
+}
+
+// Send delivers msg over SMTP, upgrading to TLS when the server offers STARTTLS
+func (s *smtpSender) Send(ctx context.Context, msg *Message) error {
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid EMAIL_FROM: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.user != "" {
+		auth = smtp.PlainAuth("", s.user, s.pass, s.host)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(s.addr, auth, from.Address, []string{msg.To}, s.compose(msg))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// compose renders msg as an RFC 5322 message
+func (s *smtpSender) compose(msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mimeHeader(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// mimeHeader encodes non-ASCII header values
+func mimeHeader(s string) string {
+	for _, r := range s {
+		if r > 127 {
+			return mime.QEncoding.Encode("utf-8", s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package email
+
+import (
+	"strings"
+	"text/template"
+)
+
+var templates = template.Must(template.New("").Parse(`
+{{define "verify"}}Hi {{.Name}},
+
+Please confirm your email address for TierForge by opening this link:
+
+{{.Link}}
+
+The link expires in 48 hours. If you did not create an account, ignore this email.
+{{end}}
+
+{{define "reset"}}Hi {{.Name}},
+
+Someone asked to reset the password of your TierForge account. To choose a new password, open:
+
+{{.Link}}
+
+The link expires in 1 hour. If you did not ask for this, ignore this email; your password stays unchanged.
+{{end}}
+
+{{define "email_change"}}Hi {{.Name}},
+
+To confirm {{.Email}} as the new email address of your TierForge account, open:
+
+{{.Link}}
+
+The link expires in 48 hours.
+{{end}}
+
+{{define "digest"}}Hi {{.Name}},
+
+Here is what happened on your tier lists this week:
+{{range .Lists}}
+* {{.Name}}: {{.NewViews}} new views, {{.NewFavorites}} new favorites
+  {{.Link}}
+{{end}}
+You get this email because you enabled the weekly digest. Turn it off in your account settings.
+{{end}}
+`))
+
+func render(name string, data interface{}) string {
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, name, data); err != nil {
+		// Templates are static, so this only fails on programming errors
+		panic(err)
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// VerificationMessage asks a new user to confirm their email address
+func VerificationMessage(to, name, link string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Confirm your TierForge email",
+		Body:    render("verify", map[string]string{"Name": name, "Link": link}),
+	}
+}
+
+// PasswordResetMessage carries a password reset link
+func PasswordResetMessage(to, name, link string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Reset your TierForge password",
+		Body:    render("reset", map[string]string{"Name": name, "Link": link}),
+	}
+}
+
+// EmailChangeMessage asks a user to confirm a new email address; it is sent
+// to the new address
+func EmailChangeMessage(to, name, link string) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Confirm your new TierForge email",
+		Body:    render("email_change", map[string]string{"Name": name, "Email": to, "Link": link}),
+	}
+}
+
+// DigestList is one tier list's activity in a weekly digest
+type DigestList struct {
+	Name         string
+	Link         string
+	NewViews     int
+	NewFavorites int
+}
+
+// DigestMessage summarizes a week of activity on a user's tier lists
+func DigestMessage(to, name string, lists []DigestList) *Message {
+	return &Message{
+		To:      to,
+		Subject: "Your week on TierForge",
+		Body:    render("digest", map[string]interface{}{"Name": name, "Lists": lists}),
+	}
+}
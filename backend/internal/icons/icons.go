@@ -0,0 +1,172 @@
+// Package icons downloads item icons and normalizes them to one size and
+// format, storing each distinct image once under its content hash. Wiki icons
+// come in many sizes; normalized ones give the item grid a consistent look
+// and are served by the app itself.
+package icons
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"  // Icon formats
+	_ "image/jpeg" // Icon formats
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/meur/tierforge/internal/imaging"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+const (
+	// Size is the edge length of normalized icons, in pixels
+	Size = 64
+	// PathPrefix is where normalized icons are served
+	PathPrefix = "/api/icons/"
+	// MaxDownload caps each icon download, in bytes
+	MaxDownload = 1 << 20
+	// MaxPixels caps the size of decoded uploads, so a small file cannot
+	// unpack into a huge image
+	MaxPixels = 4096 * 4096
+)
+
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Path is the served path of the icon stored under hash
+func Path(hash string) string {
+	return PathPrefix + hash + ".png"
+}
+
+// ParseFile returns the hash of a served icon file name such as "<hash>.png"
+func ParseFile(name string) (string, bool) {
+	hash := strings.TrimSuffix(name, ".png")
+	return hash, hashPattern.MatchString(hash)
+}
+
+// Normalized reports whether icon already points at a normalized icon
+func Normalized(icon string) bool {
+	name, ok := strings.CutPrefix(icon, PathPrefix)
+	if !ok {
+		return false
+	}
+	_, ok = ParseFile(name)
+	return ok
+}
+
+// Fetch downloads and decodes one icon. Site-relative icons are resolved
+// against base, when given.
+func Fetch(ctx context.Context, client *http.Client, base *url.URL, icon string) (image.Image, error) {
+	u, err := url.Parse(icon)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported icon URL %q", icon)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+	img, _, err := image.Decode(io.LimitReader(resp.Body, MaxDownload))
+	return img, err
+}
+
+// Decode reads an uploaded PNG, JPEG or GIF image
+func Decode(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("image is %dx%d pixels, more than %d in all", cfg.Width, cfg.Height, MaxPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Encode scales img to fit a transparent Size×Size square, keeping its aspect
+// ratio, and encodes it as a PNG. It returns the image and its content hash.
+func Encode(img image.Image) ([]byte, string, error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, scale(img, Size)); err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return buf.Bytes(), hex.EncodeToString(sum[:16]), nil
+}
+
+// Normalizer downloads icons and stores their normalized versions. It is not
+// safe for concurrent use.
+type Normalizer struct {
+	store  *storage.Store
+	client *http.Client
+	base   *url.URL
+	done   map[string]string // Source URL -> served path, so shared icons are downloaded once
+}
+
+// NewNormalizer creates a normalizer; site-relative icons are resolved
+// against base and fail to load when it is nil. With a nil store icons are
+// converted but not stored.
+func NewNormalizer(store *storage.Store, client *http.Client, base *url.URL) *Normalizer {
+	return &Normalizer{store: store, client: client, base: base, done: make(map[string]string)}
+}
+
+// Normalize stores the normalized version of icon and returns the path it is
+// served at. Identical images share one stored icon.
+func (n *Normalizer) Normalize(ctx context.Context, icon string) (string, error) {
+	if path, ok := n.done[icon]; ok {
+		return path, nil
+	}
+	img, err := Fetch(ctx, n.client, n.base, icon)
+	if err != nil {
+		return "", err
+	}
+	data, hash, err := Encode(img)
+	if err != nil {
+		return "", err
+	}
+	if n.store != nil {
+		if err := n.store.SaveIcon(hash, data, icon); err != nil {
+			return "", err
+		}
+	}
+	n.done[icon] = Path(hash)
+	return n.done[icon], nil
+}
+
+// scale fits src into a transparent size×size square, centered
+func scale(src image.Image, size int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	sb := src.Bounds()
+	if sb.Dx() == 0 || sb.Dy() == 0 {
+		return dst
+	}
+	w, h := size, size
+	if sb.Dx() > sb.Dy() {
+		h = max(size*sb.Dy()/sb.Dx(), 1)
+	} else {
+		w = max(size*sb.Dx()/sb.Dy(), 1)
+	}
+	ox, oy := (size-w)/2, (size-h)/2
+	draw.Draw(dst, image.Rect(ox, oy, ox+w, oy+h), imaging.Resize(src, w, h), image.Point{}, draw.Src)
+	return dst
+}
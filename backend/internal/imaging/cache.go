@@ -0,0 +1,99 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps encoded variants in a directory, one file per key. A nil
+// *Cache caches nothing.
+type Cache struct {
+	dir string
+}
+
+// NewCache creates a cache in dir; an empty dir disables caching
+func NewCache(dir string) (*Cache, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// path maps a key to a file, spreading files over 256 subdirectories
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+// Get returns the variant cached under key, or nil. Hits refresh the file's
+// modification time, which Prune goes by.
+func (c *Cache) Get(key string) []byte {
+	if c == nil {
+		return nil
+	}
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data
+}
+
+// Put stores a variant under key. The file is written in place atomically,
+// so concurrent readers never see a partial image.
+func (c *Cache) Put(key string, data []byte) error {
+	if c == nil {
+		return nil
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Prune deletes variants not used since cutoff and returns how many
+func (c *Cache) Prune(cutoff time.Time) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	removed := 0
+	err := filepath.WalkDir(c.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
@@ -0,0 +1,262 @@
+// Package imaging produces resized and re-encoded variants of the images the
+// API serves, such as icons and rendered tier lists, so mobile clients can
+// ask for smaller files. Variants are cached on disk.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format names
+const (
+	PNG  = "png"
+	JPEG = "jpeg"
+	// WebP variants are lossless. There is no AVIF format: no AV1 encoder
+	// is available to build in.
+	WebP = "webp"
+	// Auto picks a format the client accepts from its Accept header. As a
+	// variant format it means the smallest of the negotiated candidates.
+	Auto = "auto"
+)
+
+const (
+	// MinWidth and MaxWidth bound requested variant widths
+	MinWidth = 16
+	MaxWidth = 2048
+
+	jpegQuality = 82
+)
+
+// Background is what transparent images are flattened onto for formats
+// without alpha; it matches the app's dark theme
+var Background = color.RGBA{0x1a, 0x1a, 0x1f, 0xff}
+
+// ErrUnsupportedFormat is returned for formats no encoder is built in for
+var ErrUnsupportedFormat = errors.New("format must be png, jpeg, webp or auto")
+
+// ErrInvalidWidth is returned for widths outside MinWidth and MaxWidth
+var ErrInvalidWidth = errors.New("w must be between 16 and 2048")
+
+// contentTypes maps the formats that can be encoded to their media types
+var contentTypes = map[string]string{
+	PNG:  "image/png",
+	JPEG: "image/jpeg",
+	WebP: "image/webp",
+}
+
+// Variant is a requested width and format. A zero Width keeps the source
+// width; an empty Format keeps the source format. Candidates lists the
+// formats an Auto variant chooses between.
+type Variant struct {
+	Width      int
+	Format     string
+	Candidates []string
+}
+
+// Original reports whether v asks for the source image unchanged
+func (v Variant) Original() bool {
+	return v.Width == 0 && v.Format == ""
+}
+
+// Key identifies v in cache keys
+func (v Variant) Key() string {
+	if v.Format == Auto {
+		return strconv.Itoa(v.Width) + "." + strings.Join(v.Candidates, "+")
+	}
+	return strconv.Itoa(v.Width) + "." + v.Format
+}
+
+// ContentType is the media type of the variant's format, or empty for Auto,
+// whose encoded data must be sniffed
+func (v Variant) ContentType() string {
+	return contentTypes[v.Format]
+}
+
+// ParseVariant reads the ?w= and ?format= parameters of r. A format of auto
+// is resolved against the Accept header: images are sent in whichever of
+// PNG, WebP if the client accepts it, and JPEG if the image is opaque and the
+// client accepts it, is smallest. Photos shrink a lot as JPEG while flat
+// drawings do not, and lossless WebP usually beats PNG.
+// The returned variant has no format when neither parameter changes it.
+func ParseVariant(r *http.Request, opaque bool) (Variant, error) {
+	var v Variant
+	q := r.URL.Query()
+	if w := q.Get("w"); w != "" {
+		n, err := strconv.Atoi(w)
+		if err != nil || n < MinWidth || n > MaxWidth {
+			return v, ErrInvalidWidth
+		}
+		v.Width = n
+	}
+	switch format := strings.ToLower(q.Get("format")); format {
+	case "":
+	case "jpg":
+		v.Format = JPEG
+	case Auto:
+		v.Candidates = negotiate(r.Header.Get("Accept"), opaque)
+		v.Format = Auto
+		if len(v.Candidates) == 1 {
+			v.Format, v.Candidates = v.Candidates[0], nil
+		}
+	default:
+		if _, ok := contentTypes[format]; !ok {
+			return v, ErrUnsupportedFormat
+		}
+		v.Format = format
+	}
+	if v.Width != 0 && v.Format == "" {
+		v.Format = PNG
+	}
+	return v, nil
+}
+
+// negotiate lists the formats worth trying for a client with the given
+// Accept header. PNG is always among them as every client takes it.
+func negotiate(accept string, opaque bool) []string {
+	formats := []string{PNG}
+	if accepts(accept, contentTypes[WebP]) {
+		formats = append(formats, WebP)
+	}
+	if opaque && accepts(accept, contentTypes[JPEG]) {
+		formats = append(formats, JPEG)
+	}
+	return formats
+}
+
+// accepts reports whether an Accept header allows mediaType with a nonzero
+// quality. The most specific matching range decides, so image/webp;q=0
+// refuses WebP even alongside image/*. An empty header accepts anything.
+func accepts(accept, mediaType string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	ranges := []string{"*/*", strings.SplitN(mediaType, "/", 2)[0] + "/*", mediaType}
+	best, ok := -1, false
+	for _, part := range strings.Split(accept, ",") {
+		t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		specificity := slices.Index(ranges, t)
+		if specificity <= best {
+			continue
+		}
+		best, ok = specificity, true
+		if q, found := params["q"]; found {
+			if f, err := strconv.ParseFloat(q, 64); err == nil && f <= 0 {
+				ok = false
+			}
+		}
+	}
+	return ok
+}
+
+// Encode produces variant v of the encoded image src. Images are never
+// scaled up.
+func Encode(src []byte, v Variant) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if v.Width != 0 && v.Width < b.Dx() {
+		img = Resize(img, v.Width, max(b.Dy()*v.Width/b.Dx(), 1))
+	}
+
+	if v.Format != Auto {
+		return encode(img, v.Format)
+	}
+	// A candidate that fails, such as WebP for images taller than it allows,
+	// just drops out
+	var smallest []byte
+	err = ErrUnsupportedFormat
+	for _, format := range v.Candidates {
+		data, encErr := encode(img, format)
+		if encErr != nil {
+			err = encErr
+			continue
+		}
+		if smallest == nil || len(data) < len(smallest) {
+			smallest = data
+		}
+	}
+	if smallest == nil {
+		return nil, err
+	}
+	return smallest, nil
+}
+
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case JPEG:
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: jpegQuality})
+	case PNG:
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	case WebP:
+		err = encodeWebP(&buf, img)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	return buf.Bytes(), err
+}
+
+// Resize scales src to w×h. Each destination pixel averages the source
+// pixels it covers, so downscaled images stay smooth.
+func Resize(src image.Image, w, h int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	sb := src.Bounds()
+	if sb.Dx() == 0 || sb.Dy() == 0 {
+		return dst
+	}
+	for y := 0; y < h; y++ {
+		y0 := sb.Min.Y + y*sb.Dy()/h
+		y1 := max(sb.Min.Y+(y+1)*sb.Dy()/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := sb.Min.X + x*sb.Dx()/w
+			x1 := max(sb.Min.X+(x+1)*sb.Dx()/w, x0+1)
+
+			// Average in premultiplied alpha so transparent pixels do not
+			// darken the edges
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			a /= count
+			if a == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / count * 0xffff / a >> 8),
+				G: uint8(g / count * 0xffff / a >> 8),
+				B: uint8(b / count * 0xffff / a >> 8),
+				A: uint8(a >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// flatten draws img over Background
+func flatten(img image.Image) image.Image {
+	out := image.NewRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), image.NewUniform(Background), image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Over)
+	return out
+}
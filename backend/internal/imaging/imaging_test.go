@@ -0,0 +1,92 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseVariantFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		opaque bool
+		want   Variant
+		err    error
+	}{
+		{"original", "", "", true, Variant{}, nil},
+		{"width only", "?w=64", "", true, Variant{Width: 64, Format: PNG}, nil},
+		{"webp", "?format=webp", "", false, Variant{Format: WebP}, nil},
+		{"jpg alias", "?format=jpg", "", true, Variant{Format: JPEG}, nil},
+		{"avif is not offered", "?format=avif", "image/avif", true, Variant{}, ErrUnsupportedFormat},
+		{"auto with any accept", "?format=auto", "", true,
+			Variant{Format: Auto, Candidates: []string{PNG, WebP, JPEG}}, nil},
+		{"auto for browsers with webp", "?format=auto", "image/avif,image/webp,image/*;q=0.8", true,
+			Variant{Format: Auto, Candidates: []string{PNG, WebP, JPEG}}, nil},
+		{"auto transparent", "?format=auto", "image/webp,image/png", false,
+			Variant{Format: Auto, Candidates: []string{PNG, WebP}}, nil},
+		{"auto without webp", "?format=auto", "image/png,image/jpeg", true,
+			Variant{Format: Auto, Candidates: []string{PNG, JPEG}}, nil},
+		{"auto webp refused", "?format=auto", "image/*,image/webp;q=0", false,
+			Variant{Format: PNG}, nil},
+		{"auto png only", "?format=auto", "image/png", true, Variant{Format: PNG}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/icon.png"+tt.query, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			got, err := ParseVariant(r, tt.opaque)
+			if err != tt.err {
+				t.Fatalf("ParseVariant error = %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseVariant = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeWebPVariant(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 120, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 120; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), 0x40, 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := Encode(buf.Bytes(), Variant{Width: 60, Format: WebP})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if ct := http.DetectContentType(data); ct != "image/webp" {
+		t.Fatalf("variant sniffed as %s", ct)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != WebP || cfg.Width != 60 || cfg.Height != 40 {
+		t.Errorf("variant is %s %dx%d (%v), want webp 60x40", format, cfg.Width, cfg.Height, err)
+	}
+
+	asPNG, err := Encode(buf.Bytes(), Variant{Width: 60, Format: PNG})
+	if err != nil {
+		t.Fatalf("Encode png: %v", err)
+	}
+	auto, err := Encode(buf.Bytes(), Variant{Width: 60, Format: Auto, Candidates: []string{PNG, WebP}})
+	if err != nil {
+		t.Fatalf("Encode auto: %v", err)
+	}
+	if len(auto) != min(len(asPNG), len(data)) {
+		t.Errorf("auto variant is %d bytes; png is %d and webp %d", len(auto), len(asPNG), len(data))
+	}
+}
@@ -0,0 +1,645 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+// This file is a lossless WebP (VP8L) encoder. The standard library and
+// x/image only decode WebP, so variants are encoded here. It applies the
+// subtract-green and predictor transforms and LZ77 backward references,
+// which covers most of what makes lossless WebP smaller than PNG; the
+// color cache, cross-color transform and palettes are left out.
+
+const (
+	// webpMaxSize is the largest width or height VP8L can describe
+	webpMaxSize = 1 << 14
+
+	// predictorBits is the log2 of the predictor transform's tile size
+	predictorBits = 4
+
+	minCopyLength  = 3
+	maxCopyLength  = 4096
+	hashBits       = 16
+	hashChainDepth = 16
+	// maxCopyDistance keeps distance codes inside the 40-symbol alphabet
+	maxCopyDistance = 1<<20 - 120
+
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+
+	nLiteralCodes = 256
+	nLengthCodes  = 24
+	nDistCodes    = 40
+)
+
+var errWebPSize = errors.New("webp: images must be between 1 and 16384 pixels on each side")
+
+// codeLengthCodeOrder is the order code length code lengths are written in
+var codeLengthCodeOrder = [19]uint8{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// distanceMapTable holds the short (dy, dx) offsets that the first 120
+// distance codes stand for, packed as dy<<4 | (8-dx)
+var distanceMapTable = [120]uint8{
+	0x18, 0x07, 0x17, 0x19, 0x28, 0x06, 0x27, 0x29, 0x16, 0x1a,
+	0x26, 0x2a, 0x38, 0x05, 0x37, 0x39, 0x15, 0x1b, 0x36, 0x3a,
+	0x25, 0x2b, 0x48, 0x04, 0x47, 0x49, 0x14, 0x1c, 0x35, 0x3b,
+	0x46, 0x4a, 0x24, 0x2c, 0x58, 0x45, 0x4b, 0x34, 0x3c, 0x03,
+	0x57, 0x59, 0x13, 0x1d, 0x56, 0x5a, 0x23, 0x2d, 0x44, 0x4c,
+	0x55, 0x5b, 0x33, 0x3d, 0x68, 0x02, 0x67, 0x69, 0x12, 0x1e,
+	0x66, 0x6a, 0x22, 0x2e, 0x54, 0x5c, 0x43, 0x4d, 0x65, 0x6b,
+	0x32, 0x3e, 0x78, 0x01, 0x77, 0x79, 0x53, 0x5d, 0x11, 0x1f,
+	0x64, 0x6c, 0x42, 0x4e, 0x76, 0x7a, 0x21, 0x2f, 0x75, 0x7b,
+	0x31, 0x3f, 0x63, 0x6d, 0x52, 0x5e, 0x00, 0x74, 0x7c, 0x41,
+	0x4f, 0x10, 0x20, 0x62, 0x6e, 0x30, 0x73, 0x7d, 0x51, 0x5f,
+	0x40, 0x72, 0x7e, 0x61, 0x6f, 0x50, 0x71, 0x7f, 0x60, 0x70,
+}
+
+// encodeWebP writes img to w as a lossless WebP file
+func encodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > webpMaxSize || height > webpMaxSize {
+		return errWebPSize
+	}
+	src := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	pix := src.Pix
+
+	alpha := uint32(0)
+	for p := 3; p < len(pix); p += 4 {
+		if pix[p] != 0xff {
+			alpha = 1
+			break
+		}
+	}
+	subtractGreen(pix)
+	modes, residuals := predict(pix, width, height)
+
+	var bw bitWriter
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.write(alpha, 1)
+	bw.write(0, 3)
+	// Transforms are undone in reverse, so subtract-green is listed first
+	bw.write(1, 1)
+	bw.write(transformSubtractGreen, 2)
+	bw.write(1, 1)
+	bw.write(transformPredictor, 2)
+	bw.write(predictorBits-2, 3)
+	writePixels(&bw, modes, tiles(width), false)
+	bw.write(0, 1)
+	writePixels(&bw, residuals, width, true)
+	data := bw.bytes()
+
+	chunk := len(data) + len(data)&1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+chunk))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if len(data)&1 != 0 {
+		data = append(data, 0)
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// tiles is the number of predictor tiles covering n pixels
+func tiles(n int) int {
+	return (n + 1<<predictorBits - 1) >> predictorBits
+}
+
+// subtractGreen subtracts each pixel's green from its red and blue
+func subtractGreen(pix []byte) {
+	for p := 0; p < len(pix); p += 4 {
+		pix[p+0] -= pix[p+1]
+		pix[p+2] -= pix[p+1]
+	}
+}
+
+// predict picks a predictor mode for every tile of pix and returns the
+// modes, as an image one pixel per tile with the mode in green, along with
+// the residuals left after prediction
+func predict(pix []byte, w, h int) (modes, residuals []byte) {
+	tw, th := tiles(w), tiles(h)
+	modes = make([]byte, 4*tw*th)
+	for ty := 0; ty < th; ty++ {
+		for tx := 0; tx < tw; tx++ {
+			best, bestCost := 0, -1
+			for mode := 0; mode < 14; mode++ {
+				cost := 0
+				for y := max(ty<<predictorBits, 1); y < min((ty+1)<<predictorBits, h); y++ {
+					for x := max(tx<<predictorBits, 1); x < min((tx+1)<<predictorBits, w); x++ {
+						p := 4 * (y*w + x)
+						pred := prediction(pix, mode, p, p-4*w)
+						for c := 0; c < 4; c++ {
+							cost += abs8(pix[p+c] - pred[c])
+						}
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+			q := 4 * (ty*tw + tx)
+			modes[q+1], modes[q+3] = byte(best), 0xff
+		}
+	}
+
+	// The first pixel is predicted as opaque black, the rest of the first
+	// row from the left and the first column from the top
+	residuals = make([]byte, len(pix))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := 4 * (y*w + x)
+			mode := 0
+			switch {
+			case x == 0 && y == 0:
+			case y == 0:
+				mode = 1
+			case x == 0:
+				mode = 2
+			default:
+				mode = int(modes[4*((y>>predictorBits)*tw+x>>predictorBits)+1])
+			}
+			pred := prediction(pix, mode, p, p-4*w)
+			for c := 0; c < 4; c++ {
+				residuals[p+c] = pix[p+c] - pred[c]
+			}
+		}
+	}
+	return modes, residuals
+}
+
+// prediction is what predictor mode guesses for the pixel at p, whose top
+// neighbor is at top. The top-right neighbor of the last column is the first
+// pixel of the current row, as the format specifies.
+func prediction(pix []byte, mode, p, top int) [4]byte {
+	var out [4]byte
+	switch mode {
+	case 0:
+		out[3] = 0xff
+		return out
+	case 1:
+		copy(out[:], pix[p-4:p])
+		return out
+	case 2:
+		copy(out[:], pix[top:top+4])
+		return out
+	}
+	if mode == 11 {
+		var l, t int
+		for c := 0; c < 4; c++ {
+			l += absDiff(pix[top-4+c], pix[top+c])
+			t += absDiff(pix[top-4+c], pix[p-4+c])
+		}
+		from := top
+		if l < t {
+			from = p - 4
+		}
+		copy(out[:], pix[from:from+4])
+		return out
+	}
+	for c := 0; c < 4; c++ {
+		l, t, tl, tr := pix[p-4+c], pix[top+c], pix[top-4+c], pix[top+4+c]
+		switch mode {
+		case 3:
+			out[c] = tr
+		case 4:
+			out[c] = tl
+		case 5:
+			out[c] = avg2(avg2(l, tr), t)
+		case 6:
+			out[c] = avg2(l, tl)
+		case 7:
+			out[c] = avg2(l, t)
+		case 8:
+			out[c] = avg2(tl, t)
+		case 9:
+			out[c] = avg2(t, tr)
+		case 10:
+			out[c] = avg2(avg2(l, tl), avg2(t, tr))
+		case 12:
+			out[c] = clamp255(int(l) + int(t) - int(tl))
+		case 13:
+			a := avg2(l, t)
+			out[c] = clamp255(int(a) + (int(a)-int(tl))/2)
+		}
+	}
+	return out
+}
+
+func avg2(a, b byte) byte {
+	return byte((int(a) + int(b)) / 2)
+}
+
+func clamp255(x int) byte {
+	return byte(min(max(x, 0), 255))
+}
+
+func absDiff(a, b byte) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// abs8 is the magnitude of a residual read as a signed byte
+func abs8(b byte) int {
+	if v := int(int8(b)); v >= 0 {
+		return v
+	}
+	return -int(int8(b))
+}
+
+// token is a literal pixel or, when length is nonzero, a copy of length
+// pixels from the given distance code back
+type token struct {
+	pixel    int
+	length   int
+	distCode int
+}
+
+// writePixels entropy-codes pix, w pixels wide, with a single group of
+// prefix codes. Only the main image may carry meta prefix codes, so only it
+// writes the bit saying there are none.
+func writePixels(bw *bitWriter, pix []byte, w int, main bool) {
+	tokens := backwardRefs(pix, w)
+
+	bw.write(0, 1) // no color cache
+	if main {
+		bw.write(0, 1) // no meta prefix codes
+	}
+	hist := [5][]int{
+		make([]int, nLiteralCodes+nLengthCodes),
+		make([]int, 256),
+		make([]int, 256),
+		make([]int, 256),
+		make([]int, nDistCodes),
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			p := 4 * t.pixel
+			hist[0][pix[p+1]]++
+			hist[1][pix[p+0]]++
+			hist[2][pix[p+2]]++
+			hist[3][pix[p+3]]++
+			continue
+		}
+		sym, _, _ := prefixEncode(t.length)
+		hist[0][nLiteralCodes+sym]++
+		sym, _, _ = prefixEncode(t.distCode)
+		hist[4][sym]++
+	}
+	var codes [5]prefixCode
+	for i := range hist {
+		codes[i] = writePrefixCode(bw, hist[i])
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			p := 4 * t.pixel
+			codes[0].write(bw, int(pix[p+1]))
+			codes[1].write(bw, int(pix[p+0]))
+			codes[2].write(bw, int(pix[p+2]))
+			codes[3].write(bw, int(pix[p+3]))
+			continue
+		}
+		sym, n, extra := prefixEncode(t.length)
+		codes[0].write(bw, nLiteralCodes+sym)
+		bw.write(uint32(extra), uint(n))
+		sym, n, extra = prefixEncode(t.distCode)
+		codes[4].write(bw, sym)
+		bw.write(uint32(extra), uint(n))
+	}
+}
+
+// backwardRefs greedily splits pix into literals and copies of earlier
+// pixels, found through a hash chain plus the pixels directly left and above
+func backwardRefs(pix []byte, w int) []token {
+	n := len(pix) / 4
+	vals := make([]uint32, n)
+	for i := range vals {
+		vals[i] = binary.LittleEndian.Uint32(pix[4*i:])
+	}
+	planeCodes := make(map[int]int, len(distanceMapTable))
+	for k := len(distanceMapTable); k >= 1; k-- {
+		dc := int(distanceMapTable[k-1])
+		if d := (dc>>4)*w + 8 - dc&0xf; d >= 1 {
+			planeCodes[d] = k
+		}
+	}
+
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, n)
+	hash := func(i int) uint32 {
+		return (vals[i]*0x1e35a7bd ^ vals[i+1]*0x9e3779b1 ^ vals[i+2]*0x85ebca6b) >> (32 - hashBits)
+	}
+	insert := func(i int) {
+		if i+minCopyLength <= n {
+			h := hash(i)
+			prev[i], head[h] = head[h], int32(i)
+		}
+	}
+
+	var tokens []token
+	for i := 0; i < n; {
+		limit := min(n-i, maxCopyLength)
+		bestLen, bestDist := 0, 0
+		try := func(d int) {
+			// A match can only beat the best so far if it reaches one
+			// pixel further
+			if d < 1 || d > i || d > maxCopyDistance || bestLen == limit || vals[i+bestLen] != vals[i+bestLen-d] {
+				return
+			}
+			l := 0
+			for l < limit && vals[i+l] == vals[i+l-d] {
+				l++
+			}
+			if l > bestLen {
+				bestLen, bestDist = l, d
+			}
+		}
+		try(1)
+		try(w)
+		if i+minCopyLength <= n {
+			for j, depth := head[hash(i)], 0; j >= 0 && depth < hashChainDepth && bestLen < limit; j, depth = prev[j], depth+1 {
+				try(i - int(j))
+			}
+		}
+
+		if bestLen < minCopyLength {
+			tokens = append(tokens, token{pixel: i})
+			insert(i)
+			i++
+			continue
+		}
+		code, ok := planeCodes[bestDist]
+		if !ok {
+			code = bestDist + len(distanceMapTable)
+		}
+		tokens = append(tokens, token{length: bestLen, distCode: code})
+		for end := i + bestLen; i < end; i++ {
+			insert(i)
+		}
+	}
+	return tokens
+}
+
+// prefixEncode splits a copy length or distance code into the symbol that
+// is entropy-coded and the extra bits written after it
+func prefixEncode(v int) (symbol, nExtra, extra int) {
+	v--
+	if v < 4 {
+		return v, 0, 0
+	}
+	h := bits.Len(uint(v)) - 1
+	nExtra = h - 1
+	return 2*h + (v>>nExtra)&1, nExtra, v & (1<<nExtra - 1)
+}
+
+// prefixCode holds the canonical prefix code of every symbol in an alphabet
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+}
+
+func (c prefixCode) write(bw *bitWriter, symbol int) {
+	n := uint(c.lengths[symbol])
+	bw.write(uint32(bits.Reverse16(c.codes[symbol])>>(16-n)), n)
+}
+
+// writePrefixCode builds a prefix code for a symbol histogram and writes it.
+// One or two symbols below 256 are written as a simple code, everything else
+// as code lengths that are themselves prefix coded.
+func writePrefixCode(bw *bitWriter, hist []int) prefixCode {
+	var used []int
+	for s, f := range hist {
+		if f > 0 {
+			used = append(used, s)
+		}
+	}
+	code := prefixCode{lengths: make([]uint8, len(hist)), codes: make([]uint16, len(hist))}
+
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		bw.write(1, 1)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+			code.lengths[used[0]], code.lengths[used[1]] = 1, 1
+			code.codes[used[1]] = 1
+		}
+		return code
+	}
+
+	code.lengths = huffmanLengths(hist, 15)
+	code.codes = canonicalCodes(code.lengths)
+
+	// Run-length code the lengths with 16 (repeat the previous length),
+	// 17 and 18 (runs of zeros)
+	type clToken struct{ symbol, nExtra, extra int }
+	var clTokens []clToken
+	clHist := make([]int, len(codeLengthCodeOrder))
+	emit := func(symbol, nExtra, extra int) {
+		clTokens = append(clTokens, clToken{symbol, nExtra, extra})
+		clHist[symbol]++
+	}
+	for i := 0; i < len(code.lengths); {
+		l := code.lengths[i]
+		run := 1
+		for i+run < len(code.lengths) && code.lengths[i+run] == l {
+			run++
+		}
+		i += run
+		if l == 0 {
+			for ; run >= 11; run -= min(run, 138) {
+				emit(18, 7, min(run, 138)-11)
+			}
+			if run >= 3 {
+				emit(17, 3, run-3)
+				run = 0
+			}
+		} else {
+			emit(int(l), 0, 0)
+			run--
+			for ; run >= 3; run -= min(run, 6) {
+				emit(16, 2, min(run, 6)-3)
+			}
+		}
+		for ; run > 0; run-- {
+			emit(int(l), 0, 0)
+		}
+	}
+	clCode := prefixCode{lengths: huffmanLengths(clHist, 7)}
+	clCode.codes = canonicalCodes(clCode.lengths)
+
+	nCodes := len(codeLengthCodeOrder)
+	for nCodes > 4 && clCode.lengths[codeLengthCodeOrder[nCodes-1]] == 0 {
+		nCodes--
+	}
+	bw.write(0, 1)
+	bw.write(uint32(nCodes-4), 4)
+	for _, s := range codeLengthCodeOrder[:nCodes] {
+		bw.write(uint32(clCode.lengths[s]), 3)
+	}
+	bw.write(0, 1) // code lengths run to the end of the alphabet
+	for _, t := range clTokens {
+		clCode.write(bw, t.symbol)
+		bw.write(uint32(t.extra), uint(t.nExtra))
+	}
+	return code
+}
+
+// huffmanLengths returns Huffman code lengths for a histogram, no longer
+// than limit. Decoders need a complete code, so a lone symbol is paired
+// with an unused one.
+func huffmanLengths(hist []int, limit int) []uint8 {
+	lengths := make([]uint8, len(hist))
+	var symbols, weights []int
+	for s, f := range hist {
+		if f > 0 {
+			symbols = append(symbols, s)
+			weights = append(weights, f)
+		}
+	}
+	switch len(symbols) {
+	case 0:
+		return lengths
+	case 1:
+		lengths[symbols[0]] = 1
+		if symbols[0] == 0 {
+			lengths[1] = 1
+		} else {
+			lengths[0] = 1
+		}
+		return lengths
+	}
+	// Flattening the weights shortens the longest codes; it ends at worst
+	// with equal weights and a balanced tree
+	for !buildHuffman(symbols, weights, lengths, limit) {
+		for i := range weights {
+			weights[i] = max(weights[i]>>1, 1)
+		}
+	}
+	return lengths
+}
+
+// buildHuffman sets the Huffman code lengths of symbols with the given
+// weights, reporting false if any is longer than limit
+func buildHuffman(symbols, weights []int, lengths []uint8, limit int) bool {
+	n := len(symbols)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if wa, wb := weights[order[a]], weights[order[b]]; wa != wb {
+			return wa < wb
+		}
+		return order[a] < order[b]
+	})
+
+	// Leaves come first in weight order, then merged nodes in the order
+	// they are made, which is also by weight
+	weight := make([]int, 2*n-1)
+	parent := make([]int, 2*n-1)
+	for i, o := range order {
+		weight[i] = weights[o]
+	}
+	leaf, merged := 0, n
+	for next := n; next < 2*n-1; next++ {
+		var pair [2]int
+		for k := range pair {
+			if leaf < n && (merged >= next || weight[leaf] <= weight[merged]) {
+				pair[k], leaf = leaf, leaf+1
+			} else {
+				pair[k], merged = merged, merged+1
+			}
+		}
+		weight[next] = weight[pair[0]] + weight[pair[1]]
+		parent[pair[0]], parent[pair[1]] = next, next
+	}
+
+	depth := make([]int, 2*n-1)
+	for i := 2*n - 3; i >= 0; i-- {
+		depth[i] = depth[parent[i]] + 1
+	}
+	for i := 0; i < n; i++ {
+		if depth[i] > limit {
+			return false
+		}
+	}
+	for i, o := range order {
+		lengths[symbols[o]] = uint8(depth[i])
+	}
+	return true
+}
+
+// canonicalCodes assigns codes to lengths in order of length, then symbol
+func canonicalCodes(lengths []uint8) []uint16 {
+	var count, next [16]uint16
+	for _, l := range lengths {
+		if l > 0 {
+			count[l]++
+		}
+	}
+	for l := 1; l < len(next); l++ {
+		next[l] = (next[l-1] + count[l-1]) << 1
+	}
+	codes := make([]uint16, len(lengths))
+	for s, l := range lengths {
+		if l > 0 {
+			codes[s] = next[l]
+			next[l]++
+		}
+	}
+	return codes
+}
+
+// bitWriter packs values least significant bit first, as VP8L reads them
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	nAcc uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nAcc
+	w.nAcc += n
+	for w.nAcc >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nAcc -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nAcc > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nAcc = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,295 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math/bits"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name string
+		w, h int
+		at   func(x, y int) color.NRGBA
+	}{
+		{"single pixel", 1, 1, func(x, y int) color.NRGBA {
+			return color.NRGBA{0x12, 0x34, 0x56, 0x78}
+		}},
+		{"one row", 37, 1, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 7), uint8(x), 0, 0xff}
+		}},
+		{"one column", 1, 29, func(x, y int) color.NRGBA {
+			return color.NRGBA{0, uint8(y * 9), uint8(y), 0xff}
+		}},
+		{"flat", 300, 20, func(x, y int) color.NRGBA {
+			return color.NRGBA(Background)
+		}},
+		{"gradient", 67, 45, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 3), uint8(y * 5), uint8(x + y), 0xff}
+		}},
+		{"stripes with transparency", 50, 50, func(x, y int) color.NRGBA {
+			if (x/5+y/7)%2 == 0 {
+				return color.NRGBA{}
+			}
+			return color.NRGBA{0xe0, 0x40, 0x40, uint8(128 + x)}
+		}},
+		{"noise", 41, 33, func(x, y int) color.NRGBA {
+			return color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256))}
+		}},
+		{"repeating pattern", 160, 90, func(x, y int) color.NRGBA {
+			v := uint8((x % 13) * (y % 11))
+			return color.NRGBA{v, v ^ 0x55, 0x80, 0xff}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewNRGBA(image.Rect(0, 0, tt.w, tt.h))
+			for y := 0; y < tt.h; y++ {
+				for x := 0; x < tt.w; x++ {
+					src.SetNRGBA(x, y, tt.at(x, y))
+				}
+			}
+			roundTripWebP(t, src)
+		})
+	}
+}
+
+// roundTripWebP encodes img and checks that it decodes to the pixels of img
+// converted to NRGBA, returning the encoded file
+func roundTripWebP(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	want := image.NewNRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(want, want.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, img); err != nil {
+		t.Fatalf("encodeWebP: %v", err)
+	}
+	got, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decoding encoded image: %v", err)
+	}
+	nrgba, ok := got.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("decoded %T, want *image.NRGBA", got)
+	}
+	if nrgba.Bounds() != want.Bounds() {
+		t.Fatalf("decoded bounds %v, want %v", nrgba.Bounds(), want.Bounds())
+	}
+	if !bytes.Equal(nrgba.Pix, want.Pix) {
+		t.Fatal("decoded pixels differ from the source")
+	}
+	return buf.Bytes()
+}
+
+// noise returns a w by h image of random opaque pixels
+func noise(rng *rand.Rand, w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	rng.Read(img.Pix)
+	for p := 3; p < len(img.Pix); p += 4 {
+		img.Pix[p] = 0xff
+	}
+	return img
+}
+
+func TestEncodeWebPOddSizes(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	// Sizes on either side of the predictor tiles' edges, and primes
+	sizes := []struct{ w, h int }{
+		{15, 17}, {16, 16}, {17, 15}, {1, 33}, {33, 1},
+		{2, 2}, {3, 5}, {127, 129}, {255, 3}, {257, 2},
+	}
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("%dx%d", size.w, size.h), func(t *testing.T) {
+			roundTripWebP(t, noise(rng, size.w, size.h))
+		})
+	}
+
+	// An image whose bounds do not start at the origin, as SubImage makes
+	sub := noise(rng, 40, 40).SubImage(image.Rect(7, 11, 30, 24))
+	t.Run("sub-image", func(t *testing.T) {
+		roundTripWebP(t, sub)
+	})
+}
+
+func TestEncodeWebPAlpha(t *testing.T) {
+	premultiplied := image.NewRGBA(image.Rect(0, 0, 19, 23))
+	for y := 0; y < 23; y++ {
+		for x := 0; x < 19; x++ {
+			a := uint8(x * 13)
+			premultiplied.SetRGBA(x, y, color.RGBA{a / 2, a / 3, a, a})
+		}
+	}
+	paletted := image.NewPaletted(image.Rect(0, 0, 21, 9), color.Palette{
+		color.NRGBA{}, color.NRGBA{0xff, 0, 0, 0x80}, color.NRGBA{0, 0xff, 0, 0xff},
+	})
+	for i := range paletted.Pix {
+		paletted.Pix[i] = uint8(i % 3)
+	}
+	opaque := noise(rand.New(rand.NewSource(3)), 18, 18)
+
+	tests := []struct {
+		name  string
+		img   image.Image
+		alpha bool
+	}{
+		{"fully transparent", image.NewNRGBA(image.Rect(0, 0, 31, 7)), true},
+		{"premultiplied", premultiplied, true},
+		{"paletted with transparent entries", paletted, true},
+		{"opaque", opaque, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := roundTripWebP(t, tt.img)
+			// The alpha hint follows the 8-bit signature and two 14-bit sizes
+			hint := binary.LittleEndian.Uint32(data[21:25])>>28&1 == 1
+			if hint != tt.alpha {
+				t.Errorf("alpha hint = %v, want %v", hint, tt.alpha)
+			}
+		})
+	}
+}
+
+func TestEncodeWebPLargePalettes(t *testing.T) {
+	// Every red and green value, so each literal alphabet is full
+	full := image.NewNRGBA(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			full.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(y), uint8(x ^ y), uint8(255 - x&y)})
+		}
+	}
+
+	palette := make(color.Palette, 256)
+	for i := range palette {
+		palette[i] = color.NRGBA{uint8(i * 37), uint8(i * 91), uint8(i * 53), uint8(i)}
+	}
+	paletted := image.NewPaletted(image.Rect(0, 0, 97, 61), palette)
+	rng := rand.New(rand.NewSource(4))
+	for i := range paletted.Pix {
+		paletted.Pix[i] = uint8(rng.Intn(256))
+	}
+
+	// Skewed counts give some colors very long prefix codes
+	skewed := image.NewNRGBA(image.Rect(0, 0, 200, 150))
+	for i := 0; i < len(skewed.Pix); i += 4 {
+		c := uint8(bits.TrailingZeros(uint(i/4+1)) * 17)
+		copy(skewed.Pix[i:], []byte{c, c ^ 0x3c, uint8(i / 4), 0xff})
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"every channel value", full},
+		{"256-color palette", paletted},
+		{"skewed color counts", skewed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roundTripWebP(t, tt.img)
+		})
+	}
+}
+
+func BenchmarkEncodeWebP(b *testing.B) {
+	rng := rand.New(rand.NewSource(5))
+	// A rendered tier list is mostly flat rows with icons pasted in
+	list := image.NewNRGBA(image.Rect(0, 0, 1200, 800))
+	draw.Draw(list, list.Bounds(), image.NewUniform(Background), image.Point{}, draw.Src)
+	for y := 0; y+64 <= 800; y += 100 {
+		for x := 120; x+64 <= 1200; x += 70 {
+			draw.Draw(list, image.Rect(x, y, x+64, y+64), noise(rng, 64, 64), image.Point{}, draw.Src)
+		}
+	}
+
+	benchmarks := []struct {
+		name string
+		img  image.Image
+	}{
+		{"icon", noise(rng, 128, 128)},
+		{"tier list", list},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := encodeWebP(io.Discard, bm.img); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebPShrinksFlatImages(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 512, 512))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+	var buf bytes.Buffer
+	if err := encodeWebP(&buf, src); err != nil {
+		t.Fatalf("encodeWebP: %v", err)
+	}
+	if buf.Len() > 200 {
+		t.Errorf("flat 512x512 image encoded to %d bytes", buf.Len())
+	}
+}
+
+func TestEncodeWebPRejectsOversizedImages(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1, webpMaxSize+1))
+	if err := encodeWebP(&bytes.Buffer{}, src); err != errWebPSize {
+		t.Errorf("encodeWebP = %v, want %v", err, errWebPSize)
+	}
+}
+
+func TestHuffmanLengthsRespectLimit(t *testing.T) {
+	// Fibonacci weights give the deepest possible unlimited tree
+	hist := make([]int, 30)
+	a, b := 1, 1
+	for i := range hist {
+		hist[i] = a
+		a, b = b, a+b
+	}
+	lengths := huffmanLengths(hist, 7)
+	kraft := 0.0
+	for s, l := range lengths {
+		if l == 0 || l > 7 {
+			t.Fatalf("symbol %d has length %d", s, l)
+		}
+		kraft += 1 / float64(uint(1)<<l)
+	}
+	if kraft != 1 {
+		t.Errorf("code is not complete: Kraft sum %v", kraft)
+	}
+}
+
+func TestPrefixEncode(t *testing.T) {
+	tests := []struct {
+		v, symbol, nExtra, extra int
+	}{
+		{1, 0, 0, 0},
+		{4, 3, 0, 0},
+		{5, 4, 1, 0},
+		{6, 4, 1, 1},
+		{7, 5, 1, 0},
+		{9, 6, 2, 0},
+		{4096, 23, 10, 1023},
+	}
+	for _, tt := range tests {
+		symbol, nExtra, extra := prefixEncode(tt.v)
+		if symbol != tt.symbol || nExtra != tt.nExtra || extra != tt.extra {
+			t.Errorf("prefixEncode(%d) = %d, %d, %d; want %d, %d, %d",
+				tt.v, symbol, nExtra, extra, tt.symbol, tt.nExtra, tt.extra)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package infobox extracts structured stats from the wiki infoboxes the
+// importers scrape, so clients can render stat cards without the raw HTML.
+package infobox
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/meur/tierforge/internal/models"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Stat keys, shared by every game
+const (
+	KeyAPCost        = "ap_cost"
+	KeySourceCost    = "source_cost"
+	KeyCooldown      = "cooldown"
+	KeyMemoryCost    = "memory_cost"
+	KeyRange         = "range"
+	KeyRequires      = "requires"
+	KeyDuration      = "duration"
+	KeyExplodeRadius = "explode_radius"
+	KeyScaling       = "scaling"
+	KeyResistedBy    = "resisted_by"
+	KeyScrollValue   = "scroll_value"
+	KeySchool        = "school"
+)
+
+// DataKey is the item Data key parsed infoboxes are stored under
+const DataKey = "infobox"
+
+// FromData returns the parsed infobox in an item's Data, whether it was just
+// set or read back from the database as JSON, or nil when there is none
+func FromData(data map[string]interface{}) *models.Infobox {
+	switch v := data[DataKey].(type) {
+	case nil:
+		return nil
+	case *models.Infobox:
+		return v
+	default:
+		var ib models.Infobox
+		raw, err := json.Marshal(v)
+		if err != nil || json.Unmarshal(raw, &ib) != nil {
+			return nil
+		}
+		return &ib
+	}
+}
+
+var labels = map[string]string{
+	KeyAPCost:        "AP",
+	KeySourceCost:    "SP",
+	KeyCooldown:      "Cooldown",
+	KeyMemoryCost:    "Memory",
+	KeyRange:         "Range",
+	KeyRequires:      "Requires",
+	KeyDuration:      "Duration",
+	KeyExplodeRadius: "Explode Radius",
+	KeyScaling:       "Scaling",
+	KeyResistedBy:    "Resisted by",
+	KeyScrollValue:   "Scroll Value",
+	KeySchool:        "School",
+}
+
+// Stat icons carry their value in the alt text: "AP2", "SP1", "cldwn3".
+// A bare "AP" or "SP" icon stands for a cost of 1.
+var iconPattern = regexp.MustCompile(`^(AP|SP|cldwn)\s*(\d*)$`)
+
+// columnKeys maps the headings of stat table columns to stat keys
+var columnKeys = map[string]string{
+	"AP":       KeyAPCost,
+	"SP":       KeySourceCost,
+	"COOLDOWN": KeyCooldown,
+	"RESIST":   KeyResistedBy,
+}
+
+// linePatterns match the property lines below a description. The first
+// submatch is the value.
+var linePatterns = []struct {
+	key     string
+	pattern *regexp.Regexp
+	list    bool // Later matches are appended to the value
+}{
+	{KeyMemoryCost, regexp.MustCompile(`(?i)^costs? (\d+) memory`), false},
+	{KeyMemoryCost, regexp.MustCompile(`(?i)^(\d+) memory slots?$`), false},
+	{KeyMemoryCost, regexp.MustCompile(`(?i)^memory slots?:? (\d+)$`), false},
+	{KeyCooldown, regexp.MustCompile(`(?i)^(\d+) turns?(?:\(s\))? cooldown$`), false},
+	{KeyRange, regexp.MustCompile(`(?i)^range:? (\d+(?:[.,]\d+)?\s*m)$`), false},
+	{KeyRequires, regexp.MustCompile(`(?i)^requires:? (.+)$`), true},
+	{KeyDuration, regexp.MustCompile(`(?i)^duration:? (\d+) turns?(?:\(s\))?$`), false},
+	{KeyExplodeRadius, regexp.MustCompile(`(?i)^(\d+(?:[.,]\d+)?\s*m) explode radius$`), false},
+	{KeyScaling, regexp.MustCompile(`(?i)^damage is based on .+ and receives (?:a )?bonus from (.+?)\.?$`), false},
+	{KeyScrollValue, regexp.MustCompile(`(?i)^scroll value:? (\d+)$`), false},
+}
+
+// Parse extracts the title, description and stats of a scraped infobox.
+// Text that matches no known stat is kept as description.
+func Parse(s string) *models.Infobox {
+	root, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return nil
+	}
+	p := &parser{ib: &models.Infobox{Stats: []models.Stat{}}}
+	p.walk(root)
+	return p.ib
+}
+
+type parser struct {
+	ib      *models.Infobox
+	columns []string // Stat keys of the last table heading row
+}
+
+func (p *parser) set(key, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "-" {
+		return
+	}
+	if _, ok := p.ib.Stat(key); ok {
+		return
+	}
+	p.ib.Stats = append(p.ib.Stats, models.Stat{Key: key, Label: labels[key], Value: value})
+}
+
+// add appends value to the list held by the stat key
+func (p *parser) add(key, value string) {
+	for i := range p.ib.Stats {
+		if p.ib.Stats[i].Key == key {
+			p.ib.Stats[i].Value += ", " + strings.TrimSpace(value)
+			return
+		}
+	}
+}
+
+func (p *parser) walk(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Tr:
+			p.row(n)
+			return
+		case atom.H3:
+			if p.ib.Title == "" {
+				p.ib.Title = text(n)
+			}
+			return
+		case atom.P, atom.Li:
+			p.block(n)
+			return
+		case atom.Td:
+			// Cells of stat tables hold their lines without paragraphs
+			if find(n, atom.P) == nil && find(n, atom.Li) == nil {
+				p.block(n)
+				return
+			}
+		case atom.Img:
+			p.icon(n)
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.walk(c)
+	}
+}
+
+// row reads a table row as stat headings, the values below them or, for a
+// lone heading after the description, the skill school
+func (p *parser) row(tr *html.Node) {
+	var cells []*html.Node
+	headings := true
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (c.DataAtom == atom.Th || c.DataAtom == atom.Td) {
+			cells = append(cells, c)
+			headings = headings && c.DataAtom == atom.Th
+		}
+	}
+
+	if headings && len(cells) > 1 {
+		keys := make([]string, len(cells))
+		found := false
+		for i, c := range cells {
+			keys[i] = columnKeys[strings.ToUpper(text(c))]
+			found = found || keys[i] != ""
+		}
+		if found {
+			p.columns = keys
+			return
+		}
+	}
+	if !headings && p.columns != nil && len(cells) == len(p.columns) {
+		keys := p.columns
+		p.columns = nil
+		for i, c := range cells {
+			if keys[i] == "" {
+				continue
+			}
+			value := text(c)
+			if value == "" {
+				value = iconName(c)
+			}
+			p.set(keys[i], value)
+		}
+		return
+	}
+	p.columns = nil
+	if headings && len(cells) == 1 && p.ib.Title != "" && find(cells[0], atom.H3) == nil {
+		if school := text(cells[0]); school != "" {
+			p.set(KeySchool, school)
+			return
+		}
+	}
+	for _, c := range cells {
+		p.walk(c)
+	}
+}
+
+// block splits a paragraph at its line breaks and reads each line
+func (p *parser) block(n *html.Node) {
+	var lines []string
+	var line strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			line.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			lines = append(lines, line.String())
+			line.Reset()
+		case n.Type == html.ElementNode && n.DataAtom == atom.Img:
+			p.icon(n)
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				visit(c)
+			}
+		}
+	}
+	visit(n)
+	lines = append(lines, line.String())
+
+	for _, l := range lines {
+		p.line(collapse(l))
+	}
+}
+
+func (p *parser) line(l string) {
+	if l == "" {
+		return
+	}
+	for _, lp := range linePatterns {
+		if m := lp.pattern.FindStringSubmatch(l); m != nil {
+			if _, ok := p.ib.Stat(lp.key); !ok {
+				p.set(lp.key, m[1])
+				return
+			}
+			if lp.list {
+				p.add(lp.key, m[1])
+				return
+			}
+		}
+	}
+	p.ib.Description = append(p.ib.Description, l)
+}
+
+// icon reads the stat an icon stands for. Some cooldown icons only name
+// their value in the title.
+func (p *parser) icon(n *html.Node) {
+	for _, name := range []string{attr(n, "alt"), attr(n, "title")} {
+		m := iconPattern.FindStringSubmatch(strings.TrimSpace(name))
+		if m == nil {
+			continue
+		}
+		value := m[2]
+		switch m[1] {
+		case "AP":
+			if value == "" {
+				value = "1"
+			}
+			p.set(KeyAPCost, value)
+		case "SP":
+			if value == "" {
+				value = "1"
+			}
+			p.set(KeySourceCost, value)
+		case "cldwn":
+			if value == "" {
+				continue
+			}
+			p.set(KeyCooldown, value)
+		}
+		return
+	}
+}
+
+// iconName is the name of the first icon in n: "physical_armour-icon"
+// becomes "Physical Armour"
+func iconName(n *html.Node) string {
+	img := find(n, atom.Img)
+	if img == nil {
+		return ""
+	}
+	name := strings.NewReplacer("_", " ", "-", " ").Replace(attr(img, "alt"))
+	name = strings.TrimSuffix(strings.TrimSpace(name), " icon")
+	words := strings.Fields(name)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// text is the whitespace-collapsed text content of n
+func text(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Br {
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return collapse(b.String())
+}
+
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
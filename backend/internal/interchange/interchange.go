@@ -0,0 +1,97 @@
+// Package interchange reads TierForge's versioned export formats. Every
+// export carries a format name and format_version; documents written by an
+// older release are upgraded one version at a time by converters working on
+// the raw JSON before they are decoded into the current models, so exports
+// stay importable as the layout evolves.
+package interchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// ErrInvalidJSON is returned when a document is not a JSON object
+var ErrInvalidJSON = errors.New("invalid JSON")
+
+// converter rewrites a raw document from one format version to the next
+type converter func(doc map[string]interface{}) error
+
+// formats lists the current version of each format and its converters;
+// converters[i] upgrades version i+1 to version i+2
+var formats = map[string]struct {
+	version    int
+	converters []converter
+}{
+	models.GameBundleFormat: {models.GameBundleVersion, []converter{gameBundleV1}},
+	models.TierListFormat:   {models.TierListFormatVersion, nil},
+}
+
+// Decode reads a document of the given format into v, upgrading it to the
+// current version first
+func Decode(data []byte, format string, v interface{}) error {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	if doc["format"] != format {
+		return fmt.Errorf("not a %s document (format %v)", format, doc["format"])
+	}
+
+	f := formats[format]
+	version := formatVersion(doc)
+	if version < 1 || version > f.version {
+		return fmt.Errorf("unsupported %s version %d; this server reads up to %d", format, version, f.version)
+	}
+	for ; version < f.version; version++ {
+		if err := f.converters[version-1](doc); err != nil {
+			return fmt.Errorf("upgrade from version %d: %w", version, err)
+		}
+		doc["format_version"] = version + 1
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
+
+// formatVersion reads the version of a raw document, or 0 if it has none
+func formatVersion(doc map[string]interface{}) int {
+	v, ok := doc["format_version"].(float64)
+	if !ok {
+		// Game bundles before version 2 called the field "version"
+		v, _ = doc["version"].(float64)
+	}
+	if v != float64(int(v)) {
+		return 0
+	}
+	return int(v)
+}
+
+// DecodeGameBundle reads a game bundle of any supported version
+func DecodeGameBundle(data []byte) (*models.GameBundle, error) {
+	var bundle models.GameBundle
+	if err := Decode(data, models.GameBundleFormat, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+// DecodeTierList reads a tier list document of any supported version
+func DecodeTierList(data []byte) (*models.TierListDocument, error) {
+	var doc models.TierListDocument
+	if err := Decode(data, models.TierListFormat, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// gameBundleV1 renames "version" to "format_version"
+func gameBundleV1(doc map[string]interface{}) error {
+	delete(doc, "version")
+	return nil
+}
@@ -0,0 +1,243 @@
+// Package itemfilter implements the expression language used to select
+// catalog items: virtual sheets, the ?filter= query parameter of the items
+// endpoint, and item subsets of tier lists.
+//
+// An expression combines comparisons with boolean operators:
+//
+//	data.tier == "S" && category in ["Pyrokinetic", "Hydrosophist"]
+//	(tag == "AoE" || tag == "CC") and not data.source_cost > 0
+//	sheet_id = 'skills' AND data.ap_cost <= 2
+//
+// Fields are id, sheet_id, name, category, game_version, tag and data.<key>.
+// Keys may be nested with dots, as in data.stats.damage. tag and list-valued
+// data fields match when any of their values matches.
+//
+// Comparisons use ==, !=, <, <=, > or >= against a quoted string, a number or
+// true/false; = is accepted for ==. field in [a, b] tests membership and
+// field not in [...] its negation. A bare field is true when it is present
+// and not empty, zero or false. Numbers and strings that look like numbers
+// are compared numerically, other values as case-sensitive strings.
+//
+// Comparisons combine with && (and), || (or) and ! (not), in that order of
+// precedence, and parentheses group them. Keywords are case-insensitive. An
+// empty expression matches every item.
+package itemfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Filter is a parsed item filter expression
+type Filter struct {
+	root node
+}
+
+// Parse parses a filter expression
+func Parse(expr string) (*Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return &Filter{root: trueNode{}}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "expected && or ||")
+	}
+	return &Filter{root: root}, nil
+}
+
+// Match reports whether the item satisfies the expression
+func (f *Filter) Match(item *models.Item) bool {
+	return f.root.eval(fields{item})
+}
+
+func validField(field string) bool {
+	switch field {
+	case "id", "sheet_id", "name", "category", "game_version", "tag":
+		return true
+	}
+	if !strings.HasPrefix(field, "data.") {
+		return false
+	}
+	for _, key := range strings.Split(strings.TrimPrefix(field, "data."), ".") {
+		if key == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// fields resolves field names against an item
+type fields struct {
+	item *models.Item
+}
+
+// values returns the values of a field; missing fields have none
+func (f fields) values(field string) []interface{} {
+	item := f.item
+	switch field {
+	case "id":
+		return []interface{}{item.ID}
+	case "sheet_id":
+		return []interface{}{item.SheetID}
+	case "name":
+		return []interface{}{item.Name}
+	case "category":
+		return []interface{}{item.Category}
+	case "game_version":
+		return []interface{}{item.GameVersion}
+	case "tag":
+		values := make([]interface{}, len(item.Tags))
+		for i, tag := range item.Tags {
+			values[i] = tag
+		}
+		return values
+	}
+
+	var v interface{} = item.Data
+	for _, key := range strings.Split(strings.TrimPrefix(field, "data."), ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if v, ok = m[key]; !ok {
+			return nil
+		}
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	}
+	return []interface{}{v}
+}
+
+func (n andNode) eval(f fields) bool { return n.left.eval(f) && n.right.eval(f) }
+func (n orNode) eval(f fields) bool  { return n.left.eval(f) || n.right.eval(f) }
+func (n notNode) eval(f fields) bool { return !n.inner.eval(f) }
+func (trueNode) eval(fields) bool    { return true }
+
+func (n compareNode) eval(f fields) bool {
+	values := f.values(n.field)
+	if n.op == "!=" {
+		// A field differs unless one of its values is equal
+		for _, v := range values {
+			if equal(v, n.value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range values {
+		if compare(v, n.op, n.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n inNode) eval(f fields) bool {
+	for _, v := range f.values(n.field) {
+		for _, want := range n.values {
+			if equal(v, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (n truthyNode) eval(f fields) bool {
+	for _, v := range f.values(n.field) {
+		switch v := v.(type) {
+		case bool:
+			if v {
+				return true
+			}
+		case float64:
+			if v != 0 {
+				return true
+			}
+		case string:
+			if v != "" {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// number converts v to a float if it is a number or a numeric string
+func number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			return x == y
+		}
+	}
+	if x, ok := a.(bool); ok {
+		y, ok := b.(bool)
+		return ok && x == y
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func compare(a interface{}, op string, b interface{}) bool {
+	if op == "==" {
+		return equal(a, b)
+	}
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			switch op {
+			case "<":
+				return x < y
+			case "<=":
+				return x <= y
+			case ">":
+				return x > y
+			case ">=":
+				return x >= y
+			}
+			return false
+		}
+	}
+	x, xok := a.(string)
+	y, yok := b.(string)
+	if !xok || !yok {
+		return false
+	}
+	switch op {
+	case "<":
+		return x < y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	case ">=":
+		return x >= y
+	}
+	return false
+}
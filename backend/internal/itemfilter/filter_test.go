@@ -0,0 +1,155 @@
+package itemfilter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestMatch(t *testing.T) {
+	item := &models.Item{
+		ID:          "fireball",
+		SheetID:     "skills",
+		Name:        "Fireball",
+		Category:    "Pyrokinetic",
+		GameVersion: "1.1",
+		Tags:        []string{"AoE", "Fire"},
+		Data: map[string]interface{}{
+			"tier":        "S",
+			"ap_cost":     float64(2),
+			"source_cost": float64(0),
+			"range":       "13",
+			"unique":      true,
+			"hidden":      false,
+			"empty":       "",
+			"elements":    []interface{}{"fire", "air"},
+			"stats":       map[string]interface{}{"damage": "12.5"},
+		},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"", true},
+		{"   ", true},
+		{`name == "Fireball"`, true},
+		{`name = 'Fireball'`, true},
+		{`name == "fireball"`, false},
+		{`id != "fireball"`, false},
+		{`sheet_id == "skills" AND data.ap_cost <= 2`, true},
+		{`game_version == 1.1`, true},
+		{`category in ["Pyrokinetic", "Hydrosophist"]`, true},
+		{`category not in ["Pyrokinetic"]`, false},
+		{`category in []`, false},
+		{`tag == "AoE"`, true},
+		{`tag != "AoE"`, false},
+		{`tag != "CC"`, true},
+		{`tag in ["CC", "Fire"]`, true},
+		{`data.elements == "air"`, true},
+		{`data.elements != "fire"`, false},
+		// Numeric strings compare as numbers
+		{`data.range > 9`, true},
+		{`data.range == "13.0"`, true},
+		{`data.stats.damage >= 12.5`, true},
+		{`data.stats.damage < 12.5`, false},
+		// Other strings compare as strings
+		{`data.tier < "T"`, true},
+		{`data.tier > "A"`, true},
+		{`data.tier >= 1`, false},
+		{`data.unique == true`, true},
+		{`data.unique != false`, true},
+		{`data.hidden == false`, true},
+		{`data.unique == 1`, false},
+		// Bare fields test presence
+		{`data.unique`, true},
+		{`data.hidden`, false},
+		{`data.source_cost`, false},
+		{`data.empty`, false},
+		{`data.missing`, false},
+		{`data.stats`, true},
+		{`data.tier.nested`, false},
+		{`data.missing != "x"`, true},
+		{`data.missing == "x"`, false},
+		// Precedence and grouping
+		{`data.tier == "A" || data.unique && tag == "CC"`, false},
+		{`(data.tier == "A" || data.unique) && tag == "AoE"`, true},
+		{`not data.source_cost > 0`, true},
+		{`!(tag == "AoE" or tag == "CC")`, false},
+		{`!!data.unique`, true},
+		{`NOT data.hidden AND data.ap_cost < -1`, false},
+		{`data.ap_cost > -1`, true},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := f.Match(item); got != tt.want {
+			t.Errorf("Parse(%q).Match = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{`name == "Fireball`, "unterminated string at offset 8"},
+		{`name @ 1`, `unexpected '@' at offset 5`},
+		{`power > 1`, "unknown field at offset 0"},
+		{`data..x`, "unknown field"},
+		{`data.`, "unknown field"},
+		{`name ==`, "expected a string, number, true or false at offset 7, found end of expression"},
+		{`name == category`, `expected a string, number, true or false at offset 8, found "category"`},
+		{`data.unique < true`, "< cannot be applied to a boolean"},
+		{`(name == "a"`, "expected ) at offset 12"},
+		{`name == "a" name`, "expected && or ||"},
+		{`name not ["a"]`, "expected in after not"},
+		{`name in "a"`, "expected ["},
+		{`name in ["a" "b"]`, "expected ]"},
+		{`name in ["a",]`, "expected a string, number, true or false"},
+		{`&& name`, "expected a field, ( or not"},
+		{`name == "a" ||`, "expected a field, ( or not at offset 14, found end of expression"},
+		{strings.Repeat("(", maxDepth+1) + "name" + strings.Repeat(")", maxDepth+1), "nested too deeply"},
+		{strings.Repeat("!", maxDepth+1) + "name", "nested too deeply"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.expr); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) = %v, want an error containing %q", tt.expr, err, tt.err)
+		}
+	}
+
+	// Nesting up to the limit parses
+	deep := strings.Repeat("(", maxDepth-1) + "name" + strings.Repeat(")", maxDepth-1)
+	if _, err := Parse(deep); err != nil {
+		t.Errorf("Parse of %d nested groups: %v", maxDepth-1, err)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`data.x-y >= -1.5 && 'it\'s' != "a" || !(tag In [1,2])`)
+	if err != nil {
+		t.Fatalf("tokenize: %v", err)
+	}
+	want := []struct {
+		kind tokenKind
+		text string
+	}{
+		{tokenIdent, "data.x-y"}, {tokenOp, ">="}, {tokenNumber, "-1.5"}, {tokenAnd, "&&"},
+		{tokenString, "it's"}, {tokenOp, "!="}, {tokenString, "a"}, {tokenOr, "||"},
+		{tokenNot, "!"}, {tokenLParen, "("}, {tokenIdent, "tag"}, {tokenIn, "In"},
+		{tokenLBrack, "["}, {tokenNumber, "1"}, {tokenComma, ","}, {tokenNumber, "2"},
+		{tokenRBrack, "]"}, {tokenRParen, ")"}, {tokenEOF, ""},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens %v, want %d", len(tokens), tokens, len(want))
+	}
+	for i, w := range want {
+		if tokens[i].kind != w.kind || tokens[i].text != w.text {
+			t.Errorf("token %d = %v %q, want %v %q", i, tokens[i].kind, tokens[i].text, w.kind, w.text)
+		}
+	}
+}
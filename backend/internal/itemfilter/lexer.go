@@ -0,0 +1,139 @@
+package itemfilter
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp     // == != < <= > >=
+	tokenAnd    // && and
+	tokenOr     // || or
+	tokenNot    // ! not
+	tokenIn     // in
+	tokenLParen // (
+	tokenRParen // )
+	tokenLBrack // [
+	tokenRBrack // ]
+	tokenComma  // ,
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '.' || c == '-' || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// keywords maps word operators to their token kinds, case-insensitively
+var keywords = map[string]tokenKind{
+	"and": tokenAnd,
+	"or":  tokenOr,
+	"not": tokenNot,
+	"in":  tokenIn,
+}
+
+// tokenize splits an expression into tokens, ending with tokenEOF
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '\'' || c == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(expr) && expr[i] != c; i++ {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+				}
+				b.WriteByte(expr[i])
+			}
+			if i == len(expr) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			i++
+			tokens = append(tokens, token{tokenString, b.String(), start})
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && isDigit(expr[i+1])):
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || expr[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, expr[start:i], start})
+		case isIdentStart(c):
+			for i < len(expr) && isIdentChar(expr[i]) {
+				i++
+			}
+			word := expr[start:i]
+			kind, ok := keywords[strings.ToLower(word)]
+			if !ok {
+				kind = tokenIdent
+			}
+			tokens = append(tokens, token{kind, word, start})
+		default:
+			two := ""
+			if i+1 < len(expr) {
+				two = expr[i : i+2]
+			}
+			switch {
+			case two == "==" || two == "!=" || two == "<=" || two == ">=":
+				tokens = append(tokens, token{tokenOp, two, start})
+				i += 2
+			case two == "&&":
+				tokens = append(tokens, token{tokenAnd, two, start})
+				i += 2
+			case two == "||":
+				tokens = append(tokens, token{tokenOr, two, start})
+				i += 2
+			case c == '=':
+				// A single = is accepted as equality
+				tokens = append(tokens, token{tokenOp, "==", start})
+				i++
+			case c == '<' || c == '>':
+				tokens = append(tokens, token{tokenOp, string(c), start})
+				i++
+			case c == '!':
+				tokens = append(tokens, token{tokenNot, "!", start})
+				i++
+			case c == '(':
+				tokens = append(tokens, token{tokenLParen, "(", start})
+				i++
+			case c == ')':
+				tokens = append(tokens, token{tokenRParen, ")", start})
+				i++
+			case c == '[':
+				tokens = append(tokens, token{tokenLBrack, "[", start})
+				i++
+			case c == ']':
+				tokens = append(tokens, token{tokenRBrack, "]", start})
+				i++
+			case c == ',':
+				tokens = append(tokens, token{tokenComma, ",", start})
+				i++
+			default:
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(expr)}), nil
+}
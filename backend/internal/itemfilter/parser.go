@@ -0,0 +1,224 @@
+package itemfilter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds nesting so hostile expressions cannot exhaust the stack
+const maxDepth = 32
+
+// node is a parsed expression that can be evaluated against an item
+type node interface {
+	eval(f fields) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+type trueNode struct{}
+
+// compareNode compares a field against a literal with ==, !=, <, <=, > or >=
+type compareNode struct {
+	field string
+	op    string
+	value interface{} // string, float64 or bool
+}
+
+// inNode tests a field against a list of literals
+type inNode struct {
+	field  string
+	values []interface{}
+}
+
+// truthyNode tests that a field is present and not empty, zero or false
+type truthyNode struct {
+	field string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if t := p.next(); t.kind != kind {
+		return p.errorf(t, "expected %s", what)
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	found := fmt.Sprintf("%q", t.text)
+	if t.kind == tokenEOF {
+		found = "end of expression"
+	}
+	return fmt.Errorf("%s at offset %d, found %s", fmt.Sprintf(format, args...), t.pos, found)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorf(p.peek(), "expression nested too deeply")
+	}
+
+	switch t := p.peek(); t.kind {
+	case tokenNot:
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	case tokenLParen:
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case tokenIdent:
+		return p.parseComparison()
+	default:
+		return nil, p.errorf(t, "expected a field, ( or not")
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	t := p.next()
+	if !validField(t.text) {
+		return nil, p.errorf(t, "unknown field")
+	}
+	field := t.text
+
+	switch p.peek().kind {
+	case tokenOp:
+		op := p.next().text
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		if _, isBool := value.(bool); isBool && op != "==" && op != "!=" {
+			return nil, fmt.Errorf("%s cannot be applied to a boolean", op)
+		}
+		return compareNode{field, op, value}, nil
+	case tokenIn:
+		p.next()
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return inNode{field, values}, nil
+	case tokenNot:
+		// field not in [...]
+		p.next()
+		if err := p.expect(tokenIn, "in after not"); err != nil {
+			return nil, err
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inNode{field, values}}, nil
+	default:
+		return truthyNode{field}, nil
+	}
+}
+
+func (p *parser) parseList() ([]interface{}, error) {
+	if err := p.expect(tokenLBrack, "["); err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	if p.peek().kind == tokenRBrack {
+		p.next()
+		return values, nil
+	}
+	for {
+		v, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(tokenRBrack, "]"); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (p *parser) parseLiteral() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number")
+		}
+		return n, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return nil, p.errorf(t, "expected a string, number, true or false")
+}
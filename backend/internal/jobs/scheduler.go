@@ -0,0 +1,180 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+	"github.com/meur/tierforge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrUnknownJob is returned when triggering a job that is not registered
+var ErrUnknownJob = errors.New("unknown job")
+
+// ErrJobRunning is returned when triggering a job that is already running
+var ErrJobRunning = errors.New("job already running")
+
+// Job is a periodic background task
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs registered jobs on their intervals, persisting the last run
+// of each job so restarts don't reset the schedule
+type Scheduler struct {
+	store   *storage.Store
+	mu      sync.Mutex
+	jobs    map[string]Job
+	running map[string]bool
+}
+
+// NewScheduler creates an empty scheduler
+func NewScheduler(store *storage.Store) *Scheduler {
+	return &Scheduler{
+		store:   store,
+		jobs:    make(map[string]Job),
+		running: make(map[string]bool),
+	}
+}
+
+// Register adds a job to the scheduler. Jobs must be registered before Start.
+func (s *Scheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.Name] = job
+}
+
+// Start launches a goroutine per job that runs until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	wait := job.Interval
+	if last, err := s.store.GetJobRun(job.Name); err != nil {
+		log.Printf("ERROR: job %s: failed to load last run: %v", job.Name, err)
+	} else if last == nil {
+		wait = 0
+	} else if elapsed := time.Since(last.LastRun); elapsed < job.Interval {
+		wait = job.Interval - elapsed
+	} else {
+		wait = 0
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := s.execute(ctx, job); err != nil && err != ErrJobRunning {
+				log.Printf("ERROR: job %s failed: %v", job.Name, err)
+			}
+			timer.Reset(job.Interval)
+		}
+	}
+}
+
+// RunNow triggers a job immediately in the background
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	busy := s.running[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrUnknownJob
+	}
+	if busy {
+		return ErrJobRunning
+	}
+
+	go func() {
+		if err := s.execute(ctx, job); err != nil && err != ErrJobRunning {
+			log.Printf("ERROR: job %s failed: %v", job.Name, err)
+		}
+	}()
+	return nil
+}
+
+// execute runs a job once, guarding against overlapping runs, and records the outcome
+func (s *Scheduler) execute(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	if s.running[job.Name] {
+		s.mu.Unlock()
+		return ErrJobRunning
+	}
+	s.running[job.Name] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running[job.Name] = false
+		s.mu.Unlock()
+	}()
+
+	ctx, span := tracing.Start(ctx, "job "+job.Name, trace.SpanKindInternal, attribute.String("job.name", job.Name))
+	start := time.Now()
+	err := job.Run(ctx)
+	tracing.RecordError(span, err)
+	span.End()
+	run := &storage.JobRun{Name: job.Name, LastRun: start, Duration: time.Since(start)}
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		log.Printf("Job %s finished in %s", job.Name, run.Duration)
+	}
+	if recErr := s.store.RecordJobRun(run); recErr != nil {
+		log.Printf("ERROR: job %s: failed to record run: %v", job.Name, recErr)
+	}
+	return err
+}
+
+// Status returns every registered job with its last run, sorted by name
+func (s *Scheduler) Status() ([]models.JobStatus, error) {
+	s.mu.Lock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	running := make(map[string]bool, len(s.running))
+	for name, r := range s.running {
+		running[name] = r
+	}
+	s.mu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+
+	statuses := make([]models.JobStatus, 0, len(jobs))
+	for _, job := range jobs {
+		status := models.JobStatus{
+			Name:     job.Name,
+			Interval: job.Interval.String(),
+			Running:  running[job.Name],
+		}
+		last, err := s.store.GetJobRun(job.Name)
+		if err != nil {
+			return nil, err
+		}
+		if last != nil {
+			status.LastRunAt = &last.LastRun
+			status.LastDuration = last.Duration.String()
+			status.LastError = last.Error
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
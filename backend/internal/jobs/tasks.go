@@ -0,0 +1,316 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/meur/tierforge/internal/config"
+	"github.com/meur/tierforge/internal/connector"
+	"github.com/meur/tierforge/internal/email"
+	"github.com/meur/tierforge/internal/imaging"
+	"github.com/meur/tierforge/internal/linkcheck"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/render"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// RegisterDefaults registers the built-in maintenance jobs
+func RegisterDefaults(s *Scheduler, store *storage.Store, cfg *config.Config, mailer email.Sender, prerender *render.Worker) {
+	s.Register(Job{
+		Name:     "aggregates",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			n, err := store.WithContext(ctx).RecomputeCommunityAggregates()
+			if err != nil {
+				return err
+			}
+			log.Printf("Recomputed %d community aggregates", n)
+
+			entries, err := store.WithContext(ctx).RecomputeLeaderboards()
+			if err == nil {
+				log.Printf("Recomputed %d leaderboard entries", entries)
+			}
+			return err
+		},
+	})
+
+	s.Register(Job{
+		Name:     "trending",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := store.WithContext(ctx).RefreshTrendingScores(time.Now().AddDate(0, 0, -7))
+			return err
+		},
+	})
+
+	s.Register(Job{
+		Name:     "orphan_cleanup",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			n, err := store.WithContext(ctx).CleanupOrphans(time.Now().AddDate(0, 0, -30))
+			if err == nil && n > 0 {
+				log.Printf("Removed %d orphaned rows", n)
+			}
+			return err
+		},
+	})
+
+	s.Register(Job{
+		Name:     "idempotency_keys",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := store.WithContext(ctx).PruneIdempotencyKeys(time.Now())
+			return err
+		},
+	})
+
+	s.Register(Job{
+		Name:     "expired_bans",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			pruned, err := store.WithContext(ctx).PruneExpiredBans(time.Now())
+			for _, ban := range pruned {
+				entry := &models.AuditEntry{Actor: "system", Action: "ban.expire", TargetType: ban.Kind, TargetID: ban.Value, Details: ban.Reason}
+				if err := store.WithContext(ctx).AddAuditEntry(entry); err != nil {
+					log.Printf("ERROR: Failed to audit expired ban %s: %v", ban.ID, err)
+				}
+			}
+			return err
+		},
+	})
+
+	s.Register(Job{
+		Name:     "item_tombstones",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := store.WithContext(ctx).PruneItemTombstones(time.Now().Add(-storage.ItemTombstoneRetention))
+			return err
+		},
+	})
+
+	// Each source has its own interval; this only checks which are due
+	s.Register(Job{
+		Name:     "item_sources",
+		Interval: connector.MinInterval * time.Minute,
+		Run: func(ctx context.Context) error {
+			return connector.New(time.Minute).SyncDue(ctx, store.WithContext(ctx))
+		},
+	})
+
+	if cfg.ImageCacheDir != "" {
+		s.Register(Job{
+			Name:     "image_variants",
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				cache, err := imaging.NewCache(cfg.ImageCacheDir)
+				if err != nil {
+					return err
+				}
+				n, err := cache.Prune(time.Now().AddDate(0, 0, -30))
+				if err == nil && n > 0 {
+					log.Printf("Pruned %d cached image variants", n)
+				}
+				return err
+			},
+		})
+	}
+
+	s.Register(Job{
+		Name:     "backup",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			return backup(store.WithContext(ctx), cfg.BackupDir, cfg.BackupKeep)
+		},
+	})
+
+	if cfg.PrerenderWorkers > 0 {
+		// Catches lists changed outside the editor, such as by autosave
+		// recovery or moderation; the worker skips images already fresh
+		s.Register(Job{
+			Name:     "prerender",
+			Interval: 15 * time.Minute,
+			Run: func(ctx context.Context) error {
+				ids, err := store.WithContext(ctx).GetRecentlySharedTierListIDs(time.Now().Add(-time.Hour), cfg.PrerenderQueue)
+				if err != nil {
+					return err
+				}
+				for _, id := range ids {
+					prerender.Enqueue(id)
+				}
+				return nil
+			},
+		})
+	}
+
+	if cfg.AnalyticsRetentionDays > 0 {
+		s.Register(Job{
+			Name:     "event_retention",
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				n, err := store.WithContext(ctx).PruneEvents(time.Now().AddDate(0, 0, -cfg.AnalyticsRetentionDays))
+				if err == nil && n > 0 {
+					log.Printf("Pruned %d analytics events", n)
+				}
+				return err
+			},
+		})
+	}
+
+	if cfg.ListRetentionDays > 0 {
+		s.Register(Job{
+			Name:     "anonymous_list_retention",
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				return retainAnonymousLists(store.WithContext(ctx), cfg.ListRetentionDays, cfg.ListRetentionDryRun)
+			},
+		})
+	}
+
+	if cfg.LinkCheckEnabled {
+		s.Register(Job{
+			Name:     "link_check",
+			Interval: 7 * 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				return checkLinks(ctx, store.WithContext(ctx), cfg.LinkCheckClear)
+			},
+		})
+	}
+
+	if email.Enabled(mailer) {
+		s.Register(Job{
+			Name:     "email_digest",
+			Interval: 24 * time.Hour,
+			Run: func(ctx context.Context) error {
+				return sendDigests(ctx, store.WithContext(ctx), mailer, cfg.PublicURL)
+			},
+		})
+	}
+}
+
+// retainAnonymousLists deletes the anonymous tier lists unchanged for days
+// and never viewed, or with dryRun only logs how many it would delete
+func retainAnonymousLists(store *storage.Store, days int, dryRun bool) error {
+	cutoff := time.Now().AddDate(0, 0, -days)
+	report, err := store.GetAbandonedTierLists(cutoff, 10)
+	if err != nil || report.Count == 0 {
+		return err
+	}
+	if dryRun {
+		log.Printf("Retention dry run: would delete %d anonymous tier lists unchanged since %s and never viewed (by game: %v; oldest: %v)",
+			report.Count, cutoff.Format("2006-01-02"), report.ByGame, report.Sample)
+		return nil
+	}
+
+	n, err := store.DeleteAbandonedTierLists(cutoff)
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted %d anonymous tier lists unchanged since %s and never viewed", n, cutoff.Format("2006-01-02"))
+	entry := &models.AuditEntry{
+		Actor:      "system",
+		Action:     "tierlist.retention",
+		TargetType: "tierlist",
+		Details:    fmt.Sprintf("Deleted %d anonymous tier lists unchanged since %s and never viewed", n, cutoff.Format("2006-01-02")),
+	}
+	if err := store.AddAuditEntry(entry); err != nil {
+		log.Printf("ERROR: Failed to audit tier list retention: %v", err)
+	}
+	return nil
+}
+
+// checkLinks logs the catalog links that no longer answer 200 and, when
+// clear is set, removes those that are gone
+func checkLinks(ctx context.Context, store *storage.Store, clear bool) error {
+	dead, checked, err := linkcheck.New(30*time.Second).Dead(ctx, store, "")
+	if err != nil {
+		return err
+	}
+	for _, l := range dead {
+		log.Printf("Dead link %s/%s %s: %s (status %d) %s", l.GameID, l.ItemID, l.Field, l.URL, l.Status, l.Error)
+	}
+	log.Printf("Checked %d links, %d dead", checked, len(dead))
+	if !clear {
+		return nil
+	}
+	n, err := linkcheck.Clear(store, dead, "job:link_check")
+	if n > 0 {
+		log.Printf("Cleared %d dead links", n)
+	}
+	return err
+}
+
+// sendDigests emails each opted-in user a summary of the past week's activity
+// on their tier lists. The job runs daily; each user gets at most one digest
+// per week and nothing when there was no activity.
+func sendDigests(ctx context.Context, store *storage.Store, mailer email.Sender, publicURL string) error {
+	now := time.Now()
+	weekAgo := now.AddDate(0, 0, -7)
+	users, err := store.GetDigestRecipients(weekAgo)
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for _, u := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		activity, err := store.GetDigestActivity(u.ID, weekAgo)
+		if err != nil {
+			return err
+		}
+		if len(activity) > 0 {
+			lists := make([]email.DigestList, len(activity))
+			for i, a := range activity {
+				lists[i] = email.DigestList{
+					Name:         a.Name,
+					Link:         publicURL + "/?s=" + a.ShareCode,
+					NewViews:     a.NewViews,
+					NewFavorites: a.NewFavorites,
+				}
+			}
+			if err := mailer.Send(ctx, email.DigestMessage(u.Email, u.DisplayName, lists)); err != nil {
+				log.Printf("ERROR: Failed to send digest to user %s: %v", u.ID, err)
+				continue
+			}
+			sent++
+		}
+		if err := store.MarkDigestSent(u.ID, now); err != nil {
+			return err
+		}
+	}
+	if sent > 0 {
+		log.Printf("Sent %d weekly digests", sent)
+	}
+	return nil
+}
+
+// backup snapshots the database into dir and prunes all but the newest keep files
+func backup(store *storage.Store, dir string, keep int) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create backup dir: %w", err)
+	}
+
+	name := fmt.Sprintf("tierforge-%s.db", time.Now().UTC().Format("20060102-150405"))
+	if err := store.Backup(filepath.Join(dir, name)); err != nil {
+		return fmt.Errorf("backup database: %w", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "tierforge-*.db"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(matches)
+	for keep > 0 && len(matches) > keep {
+		if err := os.Remove(matches[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		matches = matches[1:]
+	}
+	return nil
+}
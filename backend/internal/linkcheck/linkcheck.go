@@ -0,0 +1,233 @@
+// Package linkcheck finds catalog links that no longer resolve: the wiki
+// pages items point to and their icon images. Wiki hosts rename and drop
+// pages often, so the check runs as a command and a scheduled job.
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+// DataFields are the item Data keys that hold links
+var DataFields = []string{"wiki_url", "wiki_url_en", "wiki_url_ru"}
+
+// IconField is the Link.Field of an item's icon
+const IconField = "icon"
+
+const (
+	workers   = 8
+	userAgent = "TierForge link checker"
+)
+
+// Link is a link of an item that did not answer 200
+type Link struct {
+	GameID string `json:"game_id"`
+	ItemID string `json:"item_id"`
+	Name   string `json:"name"`
+	Field  string `json:"field"` // Data key, or IconField
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"` // 0 when the request failed
+	Error  string `json:"error,omitempty"`
+}
+
+// Gone reports whether the link is dead for good rather than failing for
+// the moment, such as on a timeout or server error
+func (l Link) Gone() bool {
+	return l.Status == http.StatusNotFound || l.Status == http.StatusGone
+}
+
+// Checker requests links with a bounded number of connections
+type Checker struct {
+	client *http.Client
+}
+
+// New creates a checker; each request times out after timeout
+func New(timeout time.Duration) *Checker {
+	return &Checker{client: &http.Client{Timeout: timeout}}
+}
+
+// Status returns the status a URL answers with after redirects. Hosts that
+// refuse HEAD are asked with GET.
+func (c *Checker) Status(ctx context.Context, url string) (int, error) {
+	status, err := c.request(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusForbidden || status == http.StatusNotImplemented) {
+		status, err = c.request(ctx, http.MethodGet, url)
+	}
+	return status, err
+}
+
+func (c *Checker) request(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// Dead checks the links of every item of a game, or of all games when gameID
+// is empty. It returns the links that do not answer 200 and the number of
+// URLs requested. Site-relative icons are served by the app itself and are
+// not checked. Each URL is requested once however many items share it.
+func (c *Checker) Dead(ctx context.Context, store *storage.Store, gameID string) ([]Link, int, error) {
+	games := []models.Game{{ID: gameID}}
+	if gameID == "" {
+		var err error
+		if games, err = store.GetGames(); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var links []Link
+	for _, game := range games {
+		items, err := store.GetItems(game.ID, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, item := range items {
+			for _, field := range DataFields {
+				if url, _ := item.Data[field].(string); absolute(url) {
+					links = append(links, Link{GameID: game.ID, ItemID: item.ID, Name: item.Name, Field: field, URL: url})
+				}
+			}
+			if absolute(item.Icon) {
+				links = append(links, Link{GameID: game.ID, ItemID: item.ID, Name: item.Name, Field: IconField, URL: item.Icon})
+			}
+		}
+	}
+
+	type result struct {
+		status int
+		err    error
+	}
+	results := make(map[string]result)
+	urls := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range urls {
+				status, err := c.Status(ctx, url)
+				mu.Lock()
+				results[url] = result{status, err}
+				mu.Unlock()
+			}
+		}()
+	}
+	queued := make(map[string]bool)
+	for _, l := range links {
+		if queued[l.URL] {
+			continue
+		}
+		queued[l.URL] = true
+		select {
+		case urls <- l.URL:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(urls)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	dead := make([]Link, 0)
+	for _, l := range links {
+		res := results[l.URL]
+		if res.err == nil && res.status == http.StatusOK {
+			continue
+		}
+		l.Status = res.status
+		if res.err != nil {
+			l.Error = res.err.Error()
+		}
+		dead = append(dead, l)
+	}
+	return dead, len(queued), nil
+}
+
+// Clear removes the links that are gone from their items, snapshotting each
+// game's catalog first so the change can be rolled back. actor names the
+// caller in snapshots and the audit log. It returns the links removed.
+func Clear(store *storage.Store, links []Link, actor string) (int, error) {
+	byGame := make(map[string]map[string][]Link)
+	for _, l := range links {
+		if !l.Gone() {
+			continue
+		}
+		if byGame[l.GameID] == nil {
+			byGame[l.GameID] = make(map[string][]Link)
+		}
+		byGame[l.GameID][l.ItemID] = append(byGame[l.GameID][l.ItemID], l)
+	}
+
+	cleared := 0
+	for gameID, items := range byGame {
+		if _, err := store.SnapshotItems(gameID, "", actor); err != nil {
+			return cleared, fmt.Errorf("snapshot %s: %w", gameID, err)
+		}
+		for itemID, dead := range items {
+			item, err := store.GetItem(gameID, itemID)
+			if err != nil {
+				return cleared, err
+			}
+			if item == nil {
+				continue
+			}
+			n := 0
+			for _, l := range dead {
+				// Skip links changed since they were checked
+				if l.Field == IconField {
+					if item.Icon == l.URL {
+						item.Icon = ""
+						n++
+					}
+				} else if url, _ := item.Data[l.Field].(string); url == l.URL {
+					delete(item.Data, l.Field)
+					n++
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			if err := store.UpdateItem(item); err != nil {
+				return cleared, fmt.Errorf("update %s: %w", itemID, err)
+			}
+			cleared += n
+		}
+	}
+
+	if cleared > 0 {
+		if err := store.AddAuditEntry(&models.AuditEntry{
+			Actor:      actor,
+			Action:     "items.clear_dead_links",
+			TargetType: "catalog",
+			TargetID:   "*",
+			Details:    fmt.Sprintf("cleared %d dead links", cleared),
+		}); err != nil {
+			return cleared, err
+		}
+	}
+	return cleared, nil
+}
+
+func absolute(url string) bool {
+	return strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ItemAggregate is the community consensus for an item, computed from all
+// public tier lists of a sheet
+type ItemAggregate struct {
+	GameID    string    `json:"game_id"`
+	SheetID   string    `json:"sheet_id"`
+	ItemID    string    `json:"item_id"`
+	Score     float64   `json:"score"`      // 1 = always top tier, 0 = always bottom tier
+	ListCount int       `json:"list_count"` // Number of lists that ranked the item
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Leaderboard boards, each ranking the contributors of a game by one measure.
+// There is no most-helpful-comments board: tier lists have no comments yet, so
+// that board waits on a comments feature.
+const (
+	// LeaderboardMostVoted ranks authors by the community votes cast on
+	// their poll lists, counting each voter once per list
+	LeaderboardMostVoted = "most_voted"
+	// LeaderboardMostForked ranks authors by how often others forked their
+	// public lists
+	LeaderboardMostForked = "most_forked"
+)
+
+// LeaderboardEntry is a contributor's place on a leaderboard board
+type LeaderboardEntry struct {
+	Rank        int       `json:"rank"` // Equal scores share a place
+	UserID      string    `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Score       int       `json:"score"`      // The board's measure, such as votes received or forks
+	ListCount   int       `json:"list_count"` // Lists that earned the score
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Leaderboard is the contributor leaderboards of a game, recomputed by the
+// aggregates job
+type Leaderboard struct {
+	GameID     string             `json:"game_id"`
+	MostVoted  []LeaderboardEntry `json:"most_voted"`
+	MostForked []LeaderboardEntry `json:"most_forked"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// MaxBracketItems caps how many items a bracket can seed
+const MaxBracketItems = 256
+
+// Bracket is a single-elimination tournament over items of one sheet, where
+// the user picks the winner of each match until a champion remains
+type Bracket struct {
+	ID          string         `json:"id"`
+	GameID      string         `json:"game_id"`
+	SheetID     string         `json:"sheet_id"`
+	GameVersion string         `json:"game_version,omitempty"`
+	AuthorID    *string        `json:"author_id,omitempty"`
+	TierListID  *string        `json:"tierlist_id,omitempty"` // Set once a tier list was generated
+	Seeds       []string       `json:"seeds"`                 // Item IDs, top seed first
+	Rounds      int            `json:"rounds"`
+	Champion    *string        `json:"champion,omitempty"`
+	Matches     []BracketMatch `json:"matches"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// BracketCreate is the request body for starting a bracket. Without ItemIDs
+// the whole sheet is seeded in a random order derived from Seed.
+type BracketCreate struct {
+	GameID      string   `json:"game_id"`
+	SheetID     string   `json:"sheet_id"`
+	GameVersion string   `json:"game_version"`
+	ItemIDs     []string `json:"item_ids,omitempty"`
+	Seed        int64    `json:"seed,omitempty"`
+}
+
+// BracketMatch is one pairing of a bracket. A match with a single item is a
+// bye and is decided when the bracket is seeded.
+type BracketMatch struct {
+	ID       int64   `json:"id"`
+	Round    int     `json:"round"` // 1 = first round, Bracket.Rounds = final
+	Slot     int     `json:"slot"`
+	ItemA    *string `json:"item_a,omitempty"`
+	ItemB    *string `json:"item_b,omitempty"`
+	WinnerID *string `json:"winner_id,omitempty"`
+}
+
+// Ready reports whether both items are known and no winner was picked yet
+func (m *BracketMatch) Ready() bool {
+	return m.ItemA != nil && m.ItemB != nil && m.WinnerID == nil
+}
+
+// Has reports whether itemID plays in the match
+func (m *BracketMatch) Has(itemID string) bool {
+	return (m.ItemA != nil && *m.ItemA == itemID) || (m.ItemB != nil && *m.ItemB == itemID)
+}
+
+// BracketPairing is the next match to pick a winner for, with both items
+type BracketPairing struct {
+	Match BracketMatch `json:"match"`
+	A     Item         `json:"a"`
+	B     Item         `json:"b"`
+}
+
+// BracketResult is the request body for picking the winner of a match
+type BracketResult struct {
+	MatchID  int64  `json:"match_id"`
+	WinnerID string `json:"winner_id"`
+}
+
+// BracketStanding is an item's final result in a bracket
+type BracketStanding struct {
+	ItemID string `json:"item_id"`
+	Place  int    `json:"place"` // 1 = champion, 2 = finalist, 3 = semi-finalist, ...
+	Wins   int    `json:"wins"`  // Byes count as wins
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Game bundle format identifiers. Bump GameBundleVersion when the layout
+// changes and add a converter from the previous version to package
+// interchange. Version 1 named the format_version field "version".
+const (
+	GameBundleFormat  = "tierforge.game-bundle"
+	GameBundleVersion = 2
+)
+
+// GameBundle is a portable, self-contained game definition: the game row
+// with its sheets and filters, every catalog item, and item relations
+type GameBundle struct {
+	Format        string         `json:"format"`
+	FormatVersion int            `json:"format_version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Game          Game           `json:"game"`
+	Items         []Item         `json:"items"`
+	Relations     []ItemRelation `json:"relations"`
+}
+
+// Validate checks that a bundle is readable by this server and internally
+// consistent, and points every item and relation at the bundle's game
+func (b *GameBundle) Validate() error {
+	if b.Format != GameBundleFormat {
+		return fmt.Errorf("not a game bundle (format %q)", b.Format)
+	}
+	if b.FormatVersion != GameBundleVersion {
+		return fmt.Errorf("unsupported bundle version %d; this server reads up to %d", b.FormatVersion, GameBundleVersion)
+	}
+	if b.Game.ID == "" || b.Game.Name == "" {
+		return fmt.Errorf("game id and name are required")
+	}
+
+	sheets := make(map[string]bool, len(b.Game.Sheets))
+	for _, sh := range b.Game.Sheets {
+		sheets[sh.ID] = true
+	}
+	items := make(map[string]bool, len(b.Items))
+	for i := range b.Items {
+		item := &b.Items[i]
+		if item.ID == "" || item.Name == "" {
+			return fmt.Errorf("items[%d]: id and name are required", i)
+		}
+		if items[item.ID] {
+			return fmt.Errorf("items[%d]: duplicate id %q", i, item.ID)
+		}
+		if !sheets[item.SheetID] {
+			return fmt.Errorf("item %q: unknown sheet %q", item.ID, item.SheetID)
+		}
+		if item.GameVersion != "" && !b.Game.HasVersion(item.GameVersion) {
+			return fmt.Errorf("item %q: unknown game version %q", item.ID, item.GameVersion)
+		}
+		items[item.ID] = true
+		item.GameID = b.Game.ID
+	}
+	for i := range b.Relations {
+		rel := &b.Relations[i]
+		if !ValidRelationKind(rel.Kind) {
+			return fmt.Errorf("relations[%d]: unknown kind %q", i, rel.Kind)
+		}
+		if !items[rel.ItemID] || !items[rel.RelatedID] {
+			return fmt.Errorf("relations[%d]: items must be part of the bundle", i)
+		}
+		rel.GameID = b.Game.ID
+	}
+	return nil
+}
+
+// BundleImportResult summarizes a game bundle import
+type BundleImportResult struct {
+	GameID     string `json:"game_id"`
+	Created    bool   `json:"created"` // The game did not exist before
+	Items      int    `json:"items"`
+	Relations  int    `json:"relations"`
+	SnapshotID int64  `json:"snapshot_id,omitempty"` // Backup of the previous catalog
+}
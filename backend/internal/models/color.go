@@ -0,0 +1,37 @@
+package models
+
+// Kinds of color check findings
+const (
+	ColorSimilar     = "similar"      // Adjacent tiers are hard to tell apart
+	ColorLowContrast = "low_contrast" // Tier labels are hard to read
+)
+
+// Color vision types simulated by the color check
+const (
+	VisionNormal       = "normal"
+	VisionProtanopia   = "protanopia"
+	VisionDeuteranopia = "deuteranopia"
+	VisionTritanopia   = "tritanopia"
+)
+
+// ColorIssue is one finding of the tier color check
+type ColorIssue struct {
+	Kind    string  `json:"kind"`
+	Vision  string  `json:"vision,omitempty"` // For similar colors, the vision type affected
+	Tiers   []int   `json:"tiers"`            // Indexes of the tiers involved, in tier order
+	Value   float64 `json:"value"`            // ΔE for similar colors, contrast ratio for low contrast
+	Message string  `json:"message"`
+}
+
+// ColorCheckRequest is the request body for checking tier colors. Colors are
+// in tier order; Tiers is used when Colors is empty.
+type ColorCheckRequest struct {
+	Colors []string `json:"colors,omitempty"`
+	Tiers  []Tier   `json:"tiers,omitempty"`
+}
+
+// ColorCheckResult is the outcome of a tier color check
+type ColorCheckResult struct {
+	OK     bool         `json:"ok"`
+	Issues []ColorIssue `json:"issues"`
+}
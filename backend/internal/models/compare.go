@@ -0,0 +1,46 @@
+package models
+
+// How an item's placement changed between two tier lists
+const (
+	ChangeSame  = "same"
+	ChangeUp    = "up"     // Ranked higher in B than in A
+	ChangeDown  = "down"   // Ranked lower in B than in A
+	ChangeOnlyA = "only_a" // Ranked in A only
+	ChangeOnlyB = "only_b" // Ranked in B only
+)
+
+// ItemDelta describes how one item moved between two tier lists
+type ItemDelta struct {
+	ItemID string `json:"item_id"`
+	TierA  string `json:"tier_a,omitempty"` // Tier name in A
+	TierB  string `json:"tier_b,omitempty"` // Tier name in B
+	Delta  int    `json:"delta"`            // Tiers moved up from A to B; negative = down
+	Change string `json:"change"`
+}
+
+// TierListComparison is the per-item diff of two tier lists of the same sheet
+type TierListComparison struct {
+	A     TierListSummary `json:"a"`
+	B     TierListSummary `json:"b"`
+	Items []ItemDelta     `json:"items"`
+	Same  int             `json:"same"`
+	Up    int             `json:"up"`
+	Down  int             `json:"down"`
+	OnlyA int             `json:"only_a"`
+	OnlyB int             `json:"only_b"`
+}
+
+// Strategies for combining several tier lists into one
+const (
+	MergeAverage  = "average"  // Mean tier position of each item
+	MergeMajority = "majority" // Tier most lists placed the item in
+	MergeWeighted = "weighted" // Mean position weighted per source list
+)
+
+// TierListMergeRequest is the request body for combining tier lists
+type TierListMergeRequest struct {
+	IDs      []string           `json:"ids"`
+	Strategy string             `json:"strategy"`
+	Weights  map[string]float64 `json:"weights,omitempty"` // Tier list ID -> weight, for "weighted"; missing = 1
+	Name     string             `json:"name"`
+}
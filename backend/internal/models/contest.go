@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Contest challenges users to rank a sheet within constraints before a
+// deadline. Once it ends, each submission is scored by how closely it agrees
+// with the consensus of all submissions.
+type Contest struct {
+	ID          string       `json:"id"`
+	GameID      string       `json:"game_id"`
+	SheetID     string       `json:"sheet_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Constraints *Constraints `json:"constraints,omitempty"` // Every submission must meet them
+	Deadline    time.Time    `json:"deadline"`
+	Submissions int          `json:"submissions"`
+	CreatedBy   string       `json:"created_by,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// Ended reports whether the contest no longer takes submissions
+func (c *Contest) Ended(now time.Time) bool {
+	return !now.Before(c.Deadline)
+}
+
+// ContestCreate is the request body for creating a contest
+type ContestCreate struct {
+	SheetID     string       `json:"sheet_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Constraints *Constraints `json:"constraints"`
+	Deadline    time.Time    `json:"deadline"` // Must be in the future
+}
+
+// ContestEntry is the request body for submitting a tier list to a contest
+type ContestEntry struct {
+	TierListID string `json:"tier_list_id"`
+}
+
+// ContestSubmission is a tier list entered into a contest. Its tiers are
+// kept as they stood when submitted, so later edits do not change the entry.
+type ContestSubmission struct {
+	TierListID  string    `json:"tier_list_id"`
+	UserID      string    `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Tiers       []Tier    `json:"tiers"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	Agreement   *float64  `json:"agreement,omitempty"` // 1 = matches the consensus exactly; set once the contest ends
+	Rank        int       `json:"rank,omitempty"`      // Leaderboard place by agreement; set once the contest ends
+}
+
+// ContestStandings is a contest with its submissions. Until the deadline
+// they are listed by submission time without scores, so entrants cannot
+// tune their lists to the consensus; afterwards they are ranked.
+type ContestStandings struct {
+	Contest     Contest             `json:"contest"`
+	Ended       bool                `json:"ended"`
+	Consensus   []Tier              `json:"consensus,omitempty"` // Mean placement of each item across submissions
+	Submissions []ContestSubmission `json:"submissions"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// The built-in custom game holds tier lists of users' own images, the way
+// TierMaker does. It has no catalog: every item is uploaded to one list and
+// private to it.
+const (
+	CustomGameID  = "custom"
+	CustomSheetID = "custom"
+)
+
+// CustomGame returns the built-in game custom tier lists belong to
+func CustomGame() Game {
+	return Game{
+		ID:          CustomGameID,
+		Name:        "Custom",
+		Description: "Tier lists of your own images",
+		Sheets: []SheetConfig{{
+			ID:          CustomSheetID,
+			Name:        "Custom",
+			Description: "Images uploaded to the list",
+			ItemFilter:  "sheet_id = '" + CustomSheetID + "'",
+		}},
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Product analytics events. Events never carry a user, session or IP address.
+const (
+	EventTierListCreated = "tierlist.created"     // props: sheet_id, items, source
+	EventItemsMoved      = "tierlist.items_moved" // props: moved
+	EventExportUsed      = "export.used"          // props: format; reported by the frontend
+)
+
+// Event is an anonymized product analytics event
+type Event struct {
+	ID        int64                  `json:"id"`
+	Name      string                 `json:"name"`
+	GameID    string                 `json:"game_id,omitempty"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// EventReport is the request body for events reported by the frontend
+type EventReport struct {
+	Name   string            `json:"name"`
+	GameID string            `json:"game_id"`
+	Props  map[string]string `json:"props"`
+}
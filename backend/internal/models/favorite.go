@@ -0,0 +1,20 @@
+package models
+
+// Favorite target types
+const (
+	FavoriteTierList = "tierlist"
+	FavoriteItem     = "item"
+)
+
+// Favorite is a user's bookmark of a tier list or catalog item
+type Favorite struct {
+	TargetType string `json:"target_type"`
+	TargetID   string `json:"target_id"`
+	GameID     string `json:"game_id,omitempty"` // Set for items, whose IDs are only unique per game
+}
+
+// Favorites is everything a user bookmarked
+type Favorites struct {
+	TierLists []TierListSummary `json:"tierlists"`
+	Items     []Item            `json:"items"`
+}
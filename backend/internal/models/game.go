@@ -15,17 +15,86 @@ type Game struct {
 	Filters      []FilterConfig  `json:"filters"`
 	DefaultTiers []TierConfig    `json:"default_tiers"`
 	Sheets       []SheetConfig   `json:"sheets"`
+	Versions     []GameVersion   `json:"versions,omitempty"` // Oldest first; the last entry is current
+	Theme        *GameTheme      `json:"theme,omitempty"`
+	Spoilers     *SpoilerPolicy  `json:"spoilers,omitempty"`
+	WorkspaceID  string          `json:"workspace_id,omitempty"` // Owning workspace; empty = site-wide
 	CreatedAt    time.Time       `json:"created_at"`
 }
 
+// GameVersion is a game patch or release that items and tier lists can target
+type GameVersion struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ReleasedAt string `json:"released_at,omitempty"` // YYYY-MM-DD
+}
+
+// CurrentVersion returns the ID of the latest version, or "" if the game is unversioned
+func (g *Game) CurrentVersion() string {
+	if len(g.Versions) == 0 {
+		return ""
+	}
+	return g.Versions[len(g.Versions)-1].ID
+}
+
+// HasVersion reports whether id is one of the game's versions
+func (g *Game) HasVersion(id string) bool {
+	for _, v := range g.Versions {
+		if v.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Spoiler modes, deciding how hidden spoiler items are shown
+const (
+	SpoilerBlur    = "blur"    // Items keep their place but lose their details
+	SpoilerExclude = "exclude" // Items are left out of catalogs and tier lists
+)
+
+// SpoilerPolicy is how a game treats items flagged as spoilers. Viewers
+// override the default with ?spoilers=hide or ?spoilers=show.
+type SpoilerPolicy struct {
+	HideByDefault bool   `json:"hide_by_default"`
+	Mode          string `json:"mode,omitempty"` // blur or exclude; defaults to blur
+}
+
+// ThemePalette is the colors of one color scheme of a game theme
+type ThemePalette struct {
+	AccentColor     string `json:"accent_color,omitempty"`     // #rrggbb
+	BackgroundColor string `json:"background_color,omitempty"` // #rrggbb
+	TextColor       string `json:"text_color,omitempty"`       // #rrggbb
+}
+
+// Card shapes for item icons
+const (
+	CardSquare  = "square"
+	CardRounded = "rounded"
+	CardCircle  = "circle"
+)
+
+// GameTheme is a game's branding, applied by the frontend and by rendered
+// share images. Empty fields fall back to the site defaults. The colors are
+// those of the dark scheme the site uses by default.
+type GameTheme struct {
+	AccentColor     string        `json:"accent_color,omitempty"`     // #rrggbb
+	BackgroundColor string        `json:"background_color,omitempty"` // #rrggbb
+	TextColor       string        `json:"text_color,omitempty"`       // #rrggbb
+	Light           *ThemePalette `json:"light,omitempty"`            // Colors of the light scheme
+	BackgroundImage string        `json:"background_image,omitempty"` // Absolute http(s) URL or site-relative path
+	CardShape       string        `json:"card_shape,omitempty"`       // square, rounded or circle
+	Font            string        `json:"font,omitempty"`             // CSS font family name
+}
+
 // FilterConfig defines a filter option for items
 type FilterConfig struct {
-	ID       string   `json:"id"`
-	Name     string   `json:"name"`
-	Field    string   `json:"field"`    // Field in item.Data to filter by
-	Type     string   `json:"type"`     // "select", "multiselect", "toggle"
-	Options  []string `json:"options"`  // For select/multiselect
-	IconMap  map[string]string `json:"icon_map,omitempty"` // Option -> icon URL
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Field   string            `json:"field"`              // Field in item.Data to filter by
+	Type    string            `json:"type"`               // "select", "multiselect", "toggle"
+	Options []string          `json:"options"`            // For select/multiselect
+	IconMap map[string]string `json:"icon_map,omitempty"` // Option -> icon URL
 }
 
 // SheetConfig defines a sheet (sub-tierlist) within a game
@@ -33,25 +102,58 @@ type SheetConfig struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	ItemFilter  string `json:"item_filter"` // Filter expression for items in this sheet
+	ItemFilter  string `json:"item_filter"`       // itemfilter expression selecting the sheet's items
+	Virtual     bool   `json:"virtual,omitempty"` // Items are selected by ItemFilter across the catalog rather than by sheet_id
+	// DefaultTiers overrides the game's default tiers for lists of this sheet
+	DefaultTiers []TierConfig `json:"default_tiers,omitempty"`
+}
+
+// Sheet returns the sheet with the given ID, or nil
+func (g *Game) Sheet(id string) *SheetConfig {
+	for i := range g.Sheets {
+		if g.Sheets[i].ID == id {
+			return &g.Sheets[i]
+		}
+	}
+	return nil
 }
 
 // TierConfig defines default tier setup
 type TierConfig struct {
-	ID    string `json:"id"`
-	Name  string `json:"name"`
-	Color string `json:"color"`
-	Order int    `json:"order"`
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Color  string   `json:"color"`
+	Order  int      `json:"order"`
+	Max    int      `json:"max,omitempty"`    // Capacity copied to new lists; 0 = unlimited
+	Points *float64 `json:"points,omitempty"` // Score copied to new lists
+}
+
+// TierTemplate returns the tiers new lists of a sheet start with: the
+// sheet's own defaults, else the game's, else the standard DefaultTiers
+func (g *Game) TierTemplate(sheetID string) []TierConfig {
+	if sheet := g.Sheet(sheetID); sheet != nil && len(sheet.DefaultTiers) > 0 {
+		return sheet.DefaultTiers
+	}
+	if len(g.DefaultTiers) > 0 {
+		return g.DefaultTiers
+	}
+	return DefaultTiers()
 }
 
-// DefaultTiers returns standard S-F tier configuration
+// DefaultTiers returns the standard S-F tiers in the classic palette. Games
+// and sheets override this through their own DefaultTiers.
 func DefaultTiers() []TierConfig {
-	return []TierConfig{
-		{ID: "s", Name: "S", Color: "#ff7f7f", Order: 0},
-		{ID: "a", Name: "A", Color: "#ffbf7f", Order: 1},
-		{ID: "b", Name: "B", Color: "#ffff7f", Order: 2},
-		{ID: "c", Name: "C", Color: "#7fff7f", Order: 3},
-		{ID: "d", Name: "D", Color: "#7fbfff", Order: 4},
-		{ID: "f", Name: "F", Color: "#ff7fff", Order: 5},
+	classic := BuiltinPalettes()[0]
+	tiers := []TierConfig{
+		{ID: "s", Name: "S", Order: 0},
+		{ID: "a", Name: "A", Order: 1},
+		{ID: "b", Name: "B", Order: 2},
+		{ID: "c", Name: "C", Order: 3},
+		{ID: "d", Name: "D", Order: 4},
+		{ID: "f", Name: "F", Order: 5},
+	}
+	for i := range tiers {
+		tiers[i].Color = classic.Colors[i]
 	}
+	return tiers
 }
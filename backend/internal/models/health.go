@@ -0,0 +1,49 @@
+package models
+
+// Health states
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+)
+
+// Migration states of the database schema relative to the running build
+const (
+	MigrationsCurrent = "current"
+	MigrationsBehind  = "behind"
+	MigrationsAhead   = "ahead" // written by a newer build
+)
+
+// BuildInfo identifies the running binary
+type BuildInfo struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"`
+	GoVersion  string `json:"go_version"`
+}
+
+// DatabaseHealth reports on the SQLite database. The write latency and
+// integrity are only checked by the deep health check.
+type DatabaseHealth struct {
+	OK                    bool     `json:"ok"`
+	Error                 string   `json:"error,omitempty"`
+	LatencyMS             float64  `json:"latency_ms"`
+	WriteLatencyMS        *float64 `json:"write_latency_ms,omitempty"`
+	WALBytes              int64    `json:"wal_bytes"`
+	SchemaVersion         int      `json:"schema_version"`
+	ExpectedSchemaVersion int      `json:"expected_schema_version"`
+	Migrations            string   `json:"migrations"`
+	Integrity             string   `json:"integrity,omitempty"`
+}
+
+// Health is the response of the health endpoints. Jobs and the prerender
+// queue are only reported by the deep health check.
+type Health struct {
+	Status         string         `json:"status"`
+	Build          BuildInfo      `json:"build"`
+	UptimeSeconds  int64          `json:"uptime_seconds"`
+	Database       DatabaseHealth `json:"database"`
+	Jobs           []JobStatus    `json:"jobs,omitempty"`
+	PrerenderQueue *int           `json:"prerender_queue,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// Infobox is the structured content of a scraped wiki infobox
+type Infobox struct {
+	Title       string   `json:"title,omitempty"`
+	Description []string `json:"description,omitempty"` // Paragraphs of plain text
+	Stats       []Stat   `json:"stats"`
+}
+
+// Stat is one labelled value of an infobox, such as a cooldown or AP cost.
+// Keys are stable across items; values are kept as shown on the wiki.
+type Stat struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Stat returns the value of the stat with the given key
+func (ib *Infobox) Stat(key string) (string, bool) {
+	for _, s := range ib.Stats {
+		if s.Key == key {
+			return s.Value, true
+		}
+	}
+	return "", false
+}
+
+// ItemDetails is the data of an item's detail popover
+type ItemDetails struct {
+	ID       string   `json:"id"`
+	GameID   string   `json:"game_id"`
+	SheetID  string   `json:"sheet_id"`
+	Name     string   `json:"name"`
+	NameRu   string   `json:"name_ru,omitempty"`
+	Icon     string   `json:"icon"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags,omitempty"`
+	Spoiler  bool     `json:"spoiler,omitempty"`
+	WikiURL  string   `json:"wiki_url,omitempty"`
+	Infobox  *Infobox `json:"infobox,omitempty"` // nil when the item has no infobox
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Tier list interchange format identifiers. A tier list document refers to
+// its game by slug and to items by name and slug instead of by ID, so it can
+// be imported into another TierForge instance whose catalog uses other IDs.
+const (
+	TierListFormat        = "tierforge.tierlist"
+	TierListFormatVersion = 1
+)
+
+// Takeout format identifiers
+const (
+	TakeoutFormat        = "tierforge.takeout"
+	TakeoutFormatVersion = 1
+)
+
+// GameRef identifies a game across instances
+type GameRef struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// ItemRef identifies an item across instances. Importers match the slug
+// first and fall back to the name.
+type ItemRef struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// TierListDocument is the portable form of a tier list
+type TierListDocument struct {
+	Format        string         `json:"format"`
+	FormatVersion int            `json:"format_version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Game          GameRef        `json:"game"`
+	SheetID       string         `json:"sheet_id"`
+	GameVersion   string         `json:"game_version,omitempty"`
+	Name          string         `json:"name"`
+	Tiers         []DocumentTier `json:"tiers"`
+}
+
+// DocumentTier is one tier of a TierListDocument
+type DocumentTier struct {
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Color  string    `json:"color"`
+	Order  int       `json:"order"`
+	Max    int       `json:"max,omitempty"`
+	Points *float64  `json:"points,omitempty"`
+	Items  []ItemRef `json:"items"`
+}
+
+// TierListImportResult is the tier list created from a document, with the
+// items that matched nothing in this catalog and were left out
+type TierListImportResult struct {
+	TierList   *TierList `json:"tierlist"`
+	Unresolved []ItemRef `json:"unresolved"`
+}
@@ -1,15 +1,64 @@
 package models
 
+import "time"
+
 // Item represents an item that can be ranked in a tier list
 type Item struct {
-	ID       string                 `json:"id"`
-	GameID   string                 `json:"game_id"`
-	SheetID  string                 `json:"sheet_id"`  // Which sheet this item belongs to
-	Name     string                 `json:"name"`
-	NameRu   string                 `json:"name_ru,omitempty"` // Russian localization
-	Icon     string                 `json:"icon"`
-	Category string                 `json:"category"` // Primary category (school, class, etc.)
-	Data     map[string]interface{} `json:"data"`     // Flexible data based on game schema
+	ID          string                 `json:"id"`
+	GameID      string                 `json:"game_id"`
+	SheetID     string                 `json:"sheet_id"` // Which sheet this item belongs to
+	Name        string                 `json:"name"`
+	NameRu      string                 `json:"name_ru,omitempty"` // Russian localization
+	Icon        string                 `json:"icon"`
+	Category    string                 `json:"category"`               // Primary category (school, class, etc.)
+	Data        map[string]interface{} `json:"data"`                   // Flexible data based on game schema
+	GameVersion string                 `json:"game_version,omitempty"` // Restricts the item to one version; empty = all
+	Tags        []string               `json:"tags,omitempty"`         // Curator-defined labels such as "AoE" or "CC"
+	Aliases     []string               `json:"aliases,omitempty"`      // Nicknames players search by, such as "Tele"; set through their own endpoint
+	Spoiler     bool                   `json:"spoiler,omitempty"`      // Late-game content hidden from viewers who opt out of spoilers
+	TierListID  string                 `json:"tierlist_id,omitempty"`  // Set on uploaded items private to one custom tier list
+	UpdatedAt   *time.Time             `json:"updated_at,omitempty"`   // Set by the database on every change
+}
+
+// CompactItem is the part of an item the ranking UI needs to draw it
+type CompactItem struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Icon     string `json:"icon"`
+	Category string `json:"category"`
+}
+
+// Compact returns the compact form of the item
+func (i *Item) Compact() CompactItem {
+	return CompactItem{ID: i.ID, Name: i.Name, Icon: i.Icon, Category: i.Category}
+}
+
+// ItemSuggestion is the compact form of an item offered while typing a search
+type ItemSuggestion struct {
+	ID      string `json:"id"`
+	SheetID string `json:"sheet_id"`
+	Name    string `json:"name"`
+	NameRu  string `json:"name_ru,omitempty"`
+	Icon    string `json:"icon"`
+}
+
+// ItemTombstone records a deleted item for clients syncing changes
+type ItemTombstone struct {
+	ID        string    `json:"id"`
+	SheetID   string    `json:"sheet_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Blur strips everything that identifies a spoiler item, keeping only what
+// the frontend needs to place it
+func (i *Item) Blur() {
+	i.Name = "Spoiler"
+	i.NameRu = ""
+	i.Icon = ""
+	i.Category = ""
+	i.Data = nil
+	i.Tags = nil
+	i.Aliases = nil
 }
 
 // ItemList is a collection of items
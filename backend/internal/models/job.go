@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// JobStatus describes a background job and its most recent run
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Interval     string     `json:"interval"`
+	Running      bool       `json:"running"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// RetentionReport describes the abandoned anonymous tier lists a retention
+// run found: those unchanged since Cutoff and never viewed
+type RetentionReport struct {
+	Cutoff time.Time      `json:"cutoff"`
+	DryRun bool           `json:"dry_run"`
+	Count  int            `json:"count"`
+	ByGame map[string]int `json:"by_game"`
+	Oldest *time.Time     `json:"oldest,omitempty"`
+	// Sample holds the IDs of the oldest of them
+	Sample []string `json:"sample"`
+}
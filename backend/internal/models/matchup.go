@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// MatchupSession is a head-to-head ranking run over one sheet, where the user
+// repeatedly picks the better of two items
+type MatchupSession struct {
+	ID          string    `json:"id"`
+	GameID      string    `json:"game_id"`
+	SheetID     string    `json:"sheet_id"`
+	GameVersion string    `json:"game_version,omitempty"`
+	AuthorID    *string   `json:"author_id,omitempty"`
+	TierListID  *string   `json:"tierlist_id,omitempty"` // Set once a tier list was generated
+	Comparisons int       `json:"comparisons"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MatchupSessionCreate is the request body for starting a matchup session
+type MatchupSessionCreate struct {
+	GameID      string `json:"game_id"`
+	SheetID     string `json:"sheet_id"`
+	GameVersion string `json:"game_version"`
+}
+
+// MatchupPair is the next two items to choose between
+type MatchupPair struct {
+	A Item `json:"a"`
+	B Item `json:"b"`
+}
+
+// MatchupResult records that the user preferred WinnerID over LoserID
+type MatchupResult struct {
+	WinnerID string `json:"winner_id"`
+	LoserID  string `json:"loser_id"`
+}
+
+// MatchupStanding is an item's current strength within a session
+type MatchupStanding struct {
+	ItemID string  `json:"item_id"`
+	Score  float64 `json:"score"` // 1 = strongest item, 0 = weakest
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Report reasons accepted from visitors
+const (
+	ReportReasonSpam      = "spam"
+	ReportReasonOffensive = "offensive"
+	ReportReasonOther     = "other"
+)
+
+// Report is a visitor complaint about a tier list
+type Report struct {
+	ID           string    `json:"id"`
+	TierListID   string    `json:"tierlist_id"`
+	TierListName string    `json:"tierlist_name,omitempty"`
+	Reason       string    `json:"reason"`
+	Details      string    `json:"details,omitempty"`
+	Status       string    `json:"status"` // "open" or "resolved"
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReportCreate is the request body for reporting a tier list
+type ReportCreate struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// Ban kinds
+const (
+	BanKindIP      = "ip"       // An address or CIDR range
+	BanKindAuthor  = "author"   // An account ID
+	BanKindDevice  = "device"   // A device token's ban hash
+	BanKindAllowIP = "allow_ip" // An address or range exempt from IP bans
+)
+
+// Ban blocks an IP address, account or device from writing, or exempts
+// addresses from IP bans. Bans without an expiry are permanent.
+type Ban struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MatchesIP reports whether an IP or allow_ip entry covers addr
+func (b *Ban) MatchesIP(addr netip.Addr) bool {
+	if strings.Contains(b.Value, "/") {
+		prefix, err := netip.ParsePrefix(b.Value)
+		return err == nil && prefix.Contains(addr.Unmap())
+	}
+	ip, err := netip.ParseAddr(b.Value)
+	return err == nil && ip.Unmap() == addr.Unmap()
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// PackIndex is the document served at a pack registry's index URL
+type PackIndex struct {
+	Packs []Pack `json:"packs"`
+}
+
+// Pack is a community-published game pack in a registry
+type Pack struct {
+	ID          string        `json:"id"`
+	GameID      string        `json:"game_id"` // Game the bundle installs
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Author      string        `json:"author,omitempty"`
+	Versions    []PackVersion `json:"versions"` // Oldest first; the last entry is the latest
+
+	Installed *InstalledPack `json:"installed,omitempty"` // Set by this server, not by the registry
+}
+
+// PackVersion is one published release of a pack
+type PackVersion struct {
+	Version    string `json:"version"`
+	URL        string `json:"url"`    // Game bundle location, absolute or relative to the index
+	SHA256     string `json:"sha256"` // Hex checksum of the bundle file
+	Size       int64  `json:"size,omitempty"`
+	ReleasedAt string `json:"released_at,omitempty"` // YYYY-MM-DD
+}
+
+// Latest returns the newest version of the pack, or nil if it has none
+func (p *Pack) Latest() *PackVersion {
+	if len(p.Versions) == 0 {
+		return nil
+	}
+	return &p.Versions[len(p.Versions)-1]
+}
+
+// Version returns the release with the given version string, or nil
+func (p *Pack) Version(v string) *PackVersion {
+	for i := range p.Versions {
+		if p.Versions[i].Version == v {
+			return &p.Versions[i]
+		}
+	}
+	return nil
+}
+
+// InstalledPack records which pack release a game was installed from
+type InstalledPack struct {
+	PackID      string    `json:"pack_id"`
+	GameID      string    `json:"game_id"`
+	Version     string    `json:"version"`
+	SHA256      string    `json:"sha256"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// PackInstallRequest is the request body for installing a pack
+type PackInstallRequest struct {
+	Version string `json:"version,omitempty"` // Defaults to the latest version
+	Replace bool   `json:"replace,omitempty"` // Remove catalog items missing from the bundle
+}
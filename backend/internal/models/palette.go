@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// Built-in palette IDs
+const (
+	PaletteClassic    = "classic"
+	PaletteColorblind = "colorblind-safe"
+	PaletteMonochrome = "monochrome"
+)
+
+// Palette is a named set of tier colors, applied top tier first
+type Palette struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Colors      []string  `json:"colors"` // #rrggbb
+	Builtin     bool      `json:"builtin"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// BuiltinPalettes returns the palettes every installation ships with
+func BuiltinPalettes() []Palette {
+	return []Palette{
+		{
+			ID:          PaletteClassic,
+			Name:        "Classic",
+			Description: "The traditional red-to-purple tier colors",
+			Colors:      []string{"#ff7f7f", "#ffbf7f", "#ffff7f", "#7fff7f", "#7fbfff", "#ff7fff"},
+		},
+		{
+			ID:          PaletteColorblind,
+			Name:        "Colorblind-safe",
+			Description: "Okabe-Ito colors that stay distinct under common color-vision deficiencies",
+			Colors:      []string{"#d55e00", "#e69f00", "#f0e442", "#009e73", "#56b4e9", "#0072b2", "#cc79a7"},
+		},
+		{
+			ID:          PaletteMonochrome,
+			Name:        "Monochrome",
+			Description: "Light-to-dark grays",
+			Colors:      []string{"#f0f0f0", "#c8c8c8", "#a0a0a0", "#787878", "#505050", "#282828"},
+		},
+	}
+}
+
+// Apply recolors tiers in order from the palette. Tiers beyond the end of the
+// palette reuse its last color.
+func (p *Palette) Apply(tiers []Tier) {
+	if len(p.Colors) == 0 {
+		return
+	}
+	for i := range tiers {
+		tiers[i].Color = p.Colors[min(i, len(p.Colors)-1)]
+	}
+}
+
+// ApplyDefaults recolors default tier configs the same way as Apply
+func (p *Palette) ApplyDefaults(tiers []TierConfig) {
+	if len(p.Colors) == 0 {
+		return
+	}
+	for i := range tiers {
+		tiers[i].Color = p.Colors[min(i, len(p.Colors)-1)]
+	}
+}
+
+// DefaultTiersUpdate is the request body for replacing the default tiers of
+// a game or sheet
+type DefaultTiersUpdate struct {
+	Tiers   []TierConfig `json:"tiers"`             // Defaults to the standard S-F tiers; empty clears a sheet's override
+	Palette string       `json:"palette,omitempty"` // Recolors the tiers when set
+}
@@ -0,0 +1,42 @@
+package models
+
+// Poll states of a tier list. An open poll takes votes from visitors with the
+// share code; closing it writes the results into the tiers and freezes them.
+const (
+	PollOpen   = "open"
+	PollClosed = "closed"
+)
+
+// UserVoter is the voter key of a signed-in user's poll votes, which also
+// scopes their idempotency keys
+func UserVoter(userID string) string {
+	return "user:" + userID
+}
+
+// PollVote places one item into one tier of a poll
+type PollVote struct {
+	ItemID string `json:"item_id"`
+	TierID string `json:"tier_id"`
+}
+
+// PollBallot is the request body for voting. A visitor has one vote per
+// item; voting for an item again replaces the earlier vote.
+type PollBallot struct {
+	Votes []PollVote `json:"votes"`
+}
+
+// PollTally is the vote breakdown of one item
+type PollTally struct {
+	ItemID string         `json:"item_id"`
+	TierID string         `json:"tier_id"` // Tier of the median vote
+	Votes  int            `json:"votes"`
+	Counts map[string]int `json:"counts"` // Votes per tier ID
+}
+
+// PollResult is the current outcome of a poll
+type PollResult struct {
+	Status string      `json:"status"`
+	Voters int         `json:"voters"`
+	Tiers  []Tier      `json:"tiers"`
+	Items  []PollTally `json:"items"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Presence is who has a tier list open right now
+type Presence struct {
+	Viewers int              `json:"viewers"` // Everyone with the list open, editors included
+	Editors []PresenceEditor `json:"editors"`
+	Locks   []TierLock       `json:"locks"`
+}
+
+// PresenceEditor is someone editing a tier list. Anonymous editors have no
+// ID and are shown as "Anonymous".
+type PresenceEditor struct {
+	ID          string `json:"id,omitempty"`
+	DisplayName string `json:"display_name"`
+}
+
+// TierLock is an editor's claim on a tier they are reordering. Locks are
+// advisory: clients keep out of tiers others hold, but writes are not
+// refused.
+type TierLock struct {
+	TierID    string         `json:"tier_id"`
+	Editor    PresenceEditor `json:"editor"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
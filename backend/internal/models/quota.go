@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Unlimited is the limit of a quota that is not enforced
+const Unlimited = -1
+
+// QuotaLimits caps what one account may store. Zero allows nothing and a
+// negative limit is unlimited.
+type QuotaLimits struct {
+	TierLists          int   `json:"tierlists"`             // Tier lists authored
+	CustomItemsPerList int   `json:"custom_items_per_list"` // Items uploaded to one custom tier list
+	CustomItems        int   `json:"custom_items"`          // Items uploaded across all lists
+	UploadBytes        int64 `json:"upload_bytes"`          // Image bytes uploaded across all lists
+}
+
+// QuotaUsage is what one account stores
+type QuotaUsage struct {
+	TierLists   int   `json:"tierlists"`
+	CustomItems int   `json:"custom_items"`
+	UploadBytes int64 `json:"upload_bytes"`
+}
+
+// Quota is an account's limits and usage
+type Quota struct {
+	Limits    QuotaLimits    `json:"limits"`
+	Usage     QuotaUsage     `json:"usage"`
+	Override  *QuotaOverride `json:"override,omitempty"` // Set when an admin changed the limits
+	MaxUpload int64          `json:"max_upload"`         // Largest accepted image file, in bytes
+}
+
+// QuotaOverride is an admin's change to one account's limits. Nil limits
+// keep the site defaults.
+type QuotaOverride struct {
+	UserID             string    `json:"user_id"`
+	TierLists          *int      `json:"tierlists,omitempty"`
+	CustomItemsPerList *int      `json:"custom_items_per_list,omitempty"`
+	CustomItems        *int      `json:"custom_items,omitempty"`
+	UploadBytes        *int64    `json:"upload_bytes,omitempty"`
+	Note               string    `json:"note,omitempty"` // Why the limits were changed
+	UpdatedBy          string    `json:"updated_by"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Apply returns limits with the override's limits in place of the defaults
+func (o *QuotaOverride) Apply(limits QuotaLimits) QuotaLimits {
+	if o == nil {
+		return limits
+	}
+	if o.TierLists != nil {
+		limits.TierLists = *o.TierLists
+	}
+	if o.CustomItemsPerList != nil {
+		limits.CustomItemsPerList = *o.CustomItemsPerList
+	}
+	if o.CustomItems != nil {
+		limits.CustomItems = *o.CustomItems
+	}
+	if o.UploadBytes != nil {
+		limits.UploadBytes = *o.UploadBytes
+	}
+	return limits
+}
+
+// exceeds reports whether used plus n goes over limit
+func exceeds(used, n, limit int64) bool {
+	return limit >= 0 && used+n > limit
+}
+
+// CheckTierLists reports why the account may not author n more tier lists,
+// or nil
+func (q *Quota) CheckTierLists(n int) error {
+	if exceeds(int64(q.Usage.TierLists), int64(n), int64(q.Limits.TierLists)) {
+		return fmt.Errorf("accounts can have at most %d tier lists; yours has %d, delete some to add more", q.Limits.TierLists, q.Usage.TierLists)
+	}
+	return nil
+}
+
+// CheckUpload reports why the account may not upload n items of size bytes
+// in all to a custom tier list already holding listUsed uploads, or nil
+func (q *Quota) CheckUpload(listUsed, n int, size int64) error {
+	switch {
+	case exceeds(int64(listUsed), int64(n), int64(q.Limits.CustomItemsPerList)):
+		return fmt.Errorf("a tier list holds at most %d uploaded items; this one has %d", q.Limits.CustomItemsPerList, listUsed)
+	case exceeds(int64(q.Usage.CustomItems), int64(n), int64(q.Limits.CustomItems)):
+		return fmt.Errorf("at most %d items can be uploaded across your tier lists; yours have %d", q.Limits.CustomItems, q.Usage.CustomItems)
+	case exceeds(q.Usage.UploadBytes, size, q.Limits.UploadBytes):
+		return fmt.Errorf("at most %s of images can be uploaded across your tier lists; yours use %s", FormatBytes(q.Limits.UploadBytes), FormatBytes(q.Usage.UploadBytes))
+	}
+	return nil
+}
+
+// FormatBytes writes a byte count the way quota messages show it, as in
+// "1.5 MB"
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// URLRedirect sends an old share code, such as one from another instance
+// or from before a migration, to the share code of a current tier list
+type URLRedirect struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
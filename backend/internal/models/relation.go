@@ -0,0 +1,26 @@
+package models
+
+// Item relation kinds. Edges point from ItemID to RelatedID.
+const (
+	RelationRequires   = "requires"    // ItemID needs RelatedID (e.g. combo skill ingredients)
+	RelationUpgradesTo = "upgrades_to" // ItemID upgrades into RelatedID
+	RelationComboWith  = "combo_with"  // ItemID combines well with RelatedID
+	RelationRelated    = "related"     // Loose association
+)
+
+// ItemRelation is a typed edge between two items of the same game
+type ItemRelation struct {
+	GameID    string `json:"game_id"`
+	ItemID    string `json:"item_id"`
+	RelatedID string `json:"related_id"`
+	Kind      string `json:"kind"`
+}
+
+// ValidRelationKind reports whether kind is a known relation kind
+func ValidRelationKind(kind string) bool {
+	switch kind {
+	case RelationRequires, RelationUpgradesTo, RelationComboWith, RelationRelated:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RenderedImage is a cached PNG of a tier list. It is stale once the tier
+// list's updated_at moves past SourceUpdatedAt.
+type RenderedImage struct {
+	TierListID      string
+	Kind            string
+	SourceUpdatedAt time.Time
+	Data            []byte
+	CreatedAt       time.Time
+}
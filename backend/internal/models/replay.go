@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// ReplayMove is one item placed while a tier list was built. Applying a
+// list's moves in order from an empty list rebuilds it, which share pages
+// animate to replay how the list came together.
+type ReplayMove struct {
+	ItemID  string    `json:"item_id"`
+	TierID  string    `json:"tier_id"` // Empty when the item returned to the pool
+	After   string    `json:"after"`   // Item it was placed after; empty = first in its tier
+	MovedAt time.Time `json:"moved_at"`
+}
+
+// TierListReplay is the recorded build of a tier list, oldest move first
+type TierListReplay struct {
+	Recording bool         `json:"recording"`           // The list records its moves
+	Truncated bool         `json:"truncated,omitempty"` // Recording stopped at the move limit
+	Moves     []ReplayMove `json:"moves"`
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Item data field types
+const (
+	FieldString  = "string"
+	FieldText    = "text" // Long-form string, shown in tooltips rather than filters
+	FieldNumber  = "number"
+	FieldBoolean = "boolean"
+	FieldArray   = "array"
+)
+
+// FieldSchema describes one field of Item.Data
+type FieldSchema struct {
+	Type     string   `json:"type"`
+	Label    string   `json:"label"`
+	Required bool     `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"` // Allowed values of a string field
+}
+
+// ItemSchema describes the Data fields of a game's items. Fields apply to
+// every sheet; Sheets adds or overrides fields for items of one sheet.
+//
+// Game.ItemSchema holds either this form or, for games with a single
+// schema, just the field map.
+type ItemSchema struct {
+	Fields map[string]FieldSchema            `json:"fields"`
+	Sheets map[string]map[string]FieldSchema `json:"sheets,omitempty"`
+}
+
+// ParseItemSchema reads a game's item_schema in either form. An empty
+// schema has no fields.
+func ParseItemSchema(raw json.RawMessage) (*ItemSchema, error) {
+	schema := &ItemSchema{}
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return schema, nil
+	}
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &top); err != nil {
+		return nil, fmt.Errorf("item_schema must be an object: %v", err)
+	}
+	if isSheetedSchema(top) {
+		if err := json.Unmarshal(raw, schema); err != nil {
+			return nil, fmt.Errorf("invalid item_schema: %v", err)
+		}
+	} else if err := json.Unmarshal(raw, &schema.Fields); err != nil {
+		return nil, fmt.Errorf("invalid item_schema: %v", err)
+	}
+	return schema, nil
+}
+
+// isSheetedSchema tells the two forms apart: a field definition always has
+// a type, so "fields" or "sheets" without one are the sheeted form's keys
+func isSheetedSchema(top map[string]json.RawMessage) bool {
+	for _, key := range []string{"fields", "sheets"} {
+		v, ok := top[key]
+		if !ok {
+			continue
+		}
+		var probe struct {
+			Type *string `json:"type"`
+		}
+		if json.Unmarshal(v, &probe) == nil && probe.Type == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ForSheet returns the fields that apply to items of a sheet
+func (s *ItemSchema) ForSheet(sheetID string) map[string]FieldSchema {
+	fields := make(map[string]FieldSchema, len(s.Fields))
+	for name, f := range s.Fields {
+		fields[name] = f
+	}
+	for name, f := range s.Sheets[sheetID] {
+		fields[name] = f
+	}
+	return fields
+}
+
+// Check reports the first problem with the schema: an unknown field type or
+// a per-sheet entry for a sheet the game does not have
+func (s *ItemSchema) Check(sheets []SheetConfig) error {
+	if err := checkFields("", s.Fields); err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(sheets))
+	for _, sheet := range sheets {
+		known[sheet.ID] = true
+	}
+	for _, sheetID := range sortedKeys(s.Sheets) {
+		if !known[sheetID] {
+			return fmt.Errorf("item_schema: unknown sheet %q", sheetID)
+		}
+		if err := checkFields(sheetID+".", s.Sheets[sheetID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkFields(prefix string, fields map[string]FieldSchema) error {
+	for _, name := range sortedKeys(fields) {
+		switch fields[name].Type {
+		case FieldString, FieldText, FieldNumber, FieldBoolean, FieldArray:
+		default:
+			return fmt.Errorf("item_schema: field %s%s has unknown type %q", prefix, name, fields[name].Type)
+		}
+	}
+	return nil
+}
+
+// ValidateData checks an item's data against the fields of its sheet.
+// Fields the schema does not describe are allowed. Numbers may be given as
+// numeric strings, matching how item filters compare them.
+func (s *ItemSchema) ValidateData(sheetID string, data map[string]interface{}) error {
+	fields := s.ForSheet(sheetID)
+	for _, name := range sortedKeys(fields) {
+		f := fields[name]
+		v, ok := data[name]
+		if !ok || v == nil {
+			if f.Required {
+				return fmt.Errorf("data.%s is required", name)
+			}
+			continue
+		}
+		if !f.accepts(v) {
+			return fmt.Errorf("data.%s must be a %s", name, f.Type)
+		}
+		if str, isString := v.(string); isString && len(f.Options) > 0 && !contains(f.Options, str) {
+			return fmt.Errorf("data.%s must be one of %v", name, f.Options)
+		}
+	}
+	return nil
+}
+
+func (f FieldSchema) accepts(v interface{}) bool {
+	switch f.Type {
+	case FieldString, FieldText:
+		_, ok := v.(string)
+		return ok
+	case FieldNumber:
+		switch n := v.(type) {
+		case float64, int, int64:
+			return true
+		case string:
+			_, err := strconv.ParseFloat(n, 64)
+			return err == nil
+		}
+		return false
+	case FieldBoolean:
+		_, ok := v.(bool)
+		return ok
+	case FieldArray:
+		_, ok := v.([]interface{})
+		return ok
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NamedField is a field schema together with its Data key
+type NamedField struct {
+	Name string `json:"name"`
+	FieldSchema
+}
+
+// SheetSchema lists the resolved Data fields of one sheet, for API docs
+type SheetSchema struct {
+	SheetID string       `json:"sheet_id"`
+	Name    string       `json:"name"`
+	Fields  []NamedField `json:"fields"`
+}
+
+// Describe resolves the fields of every sheet, sorted by name
+func (s *ItemSchema) Describe(sheets []SheetConfig) []SheetSchema {
+	out := make([]SheetSchema, 0, len(sheets))
+	for _, sheet := range sheets {
+		fields := s.ForSheet(sheet.ID)
+		doc := SheetSchema{SheetID: sheet.ID, Name: sheet.Name, Fields: make([]NamedField, 0, len(fields))}
+		for _, name := range sortedKeys(fields) {
+			doc.Fields = append(doc.Fields, NamedField{Name: name, FieldSchema: fields[name]})
+		}
+		out = append(out, doc)
+	}
+	return out
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// GameScript is the Lua script attached to a game. cmd/import runs it to
+// turn raw import records into items and to derive their Data fields.
+type GameScript struct {
+	GameID    string    `json:"game_id"`
+	Source    string    `json:"source"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
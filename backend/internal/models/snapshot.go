@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ImportSnapshot is a copy of a game's (or sheet's) items taken before a bulk
+// import, so the import can be rolled back
+type ImportSnapshot struct {
+	ID         int64      `json:"id"`
+	GameID     string     `json:"game_id"`
+	SheetID    string     `json:"sheet_id,omitempty"` // Empty = every sheet of the game
+	Source     string     `json:"source"`             // What triggered the snapshot
+	ItemCount  int        `json:"item_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RestoredAt *time.Time `json:"restored_at,omitempty"`
+	Items      []Item     `json:"items,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// ItemSource is an upstream JSON API a sheet's items are pulled from on a
+// schedule, such as a community wiki's database export
+type ItemSource struct {
+	ID      int64  `json:"id"`
+	GameID  string `json:"game_id"`
+	SheetID string `json:"sheet_id"`
+	URL     string `json:"url"`
+	// AuthHeader and AuthValue are sent with every request, as in
+	// "Authorization: Bearer ...". AuthValue is never returned by the API.
+	AuthHeader string `json:"auth_header,omitempty"`
+	AuthValue  string `json:"auth_value,omitempty"`
+	// RecordsPath is the JSONPath of the records in the response, such as
+	// $.data.items[*]; empty means the response is the array of records
+	RecordsPath string `json:"records_path,omitempty"`
+	// Fields maps item fields to JSONPaths within a record. Keys are id,
+	// name, name_ru, icon, category, game_version, spoiler, tags and
+	// data.<key>; name is required.
+	Fields          map[string]string `json:"fields"`
+	IntervalMinutes int               `json:"interval_minutes"`
+	// RemoveMissing deletes items of the sheet the upstream no longer lists
+	RemoveMissing bool              `json:"remove_missing,omitempty"`
+	Enabled       bool              `json:"enabled"`
+	CreatedBy     string            `json:"created_by,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	LastSyncAt    *time.Time        `json:"last_sync_at,omitempty"`
+	LastError     string            `json:"last_error,omitempty"`
+	LastResult    *SourceSyncResult `json:"last_result,omitempty"`
+}
+
+// SourceSyncResult counts what a sync of an item source changed, or would
+// change on a dry run
+type SourceSyncResult struct {
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Removed   int      `json:"removed"`
+	Skipped   int      `json:"skipped"`           // Records without a name
+	Changed   []string `json:"changed,omitempty"` // IDs created, updated or removed
+	DryRun    bool     `json:"dry_run,omitempty"`
+}
+
+// Redact clears the source's secret so it can be returned by the API
+func (s *ItemSource) Redact() {
+	s.AuthValue = ""
+}
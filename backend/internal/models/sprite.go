@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SpriteSheet packs the item icons of a sheet into one image. Items without
+// an icon, spoiler items and icons that failed to load have no cell and are
+// loaded individually.
+type SpriteSheet struct {
+	GameID    string                `json:"game_id"`
+	SheetID   string                `json:"sheet_id"`
+	Version   string                `json:"version"` // Hash of the item icons the sheet was built from
+	CellSize  int                   `json:"cell_size"`
+	Width     int                   `json:"width"`
+	Height    int                   `json:"height"`
+	Cells     map[string]SpriteCell `json:"cells"` // Item ID -> position
+	ImageURL  string                `json:"image_url"`
+	Stale     bool                  `json:"stale,omitempty"` // A rebuild is pending; cells of changed items were dropped
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// SpriteCell is the top-left corner of an item's icon in a sprite sheet
+type SpriteCell struct {
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	Icon string `json:"icon"` // Source URL, to reuse the cell when rebuilding
+}
@@ -0,0 +1,138 @@
+package models
+
+import "time"
+
+// CacheStats counts the lookups of an in-process cache since startup
+type CacheStats struct {
+	Hits    uint64  `json:"hits"`
+	Misses  uint64  `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // 0 before the first lookup
+}
+
+// NewCacheStats computes the hit rate of a cache
+func NewCacheStats(hits, misses uint64) CacheStats {
+	stats := CacheStats{Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
+
+// DatabaseStats describes the size and connection pool of the database
+type DatabaseStats struct {
+	FileBytes       int64            `json:"file_bytes"`
+	WALBytes        int64            `json:"wal_bytes"`
+	Tables          map[string]int64 `json:"tables"`
+	OpenConnections int              `json:"open_connections"`
+	InUse           int              `json:"in_use"`
+	WaitCount       int64            `json:"wait_count"`
+	WaitMS          int64            `json:"wait_ms"`
+}
+
+// RuntimeStats describes the Go runtime of the server process
+type RuntimeStats struct {
+	Goroutines     int     `json:"goroutines"`
+	HeapAllocBytes uint64  `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64  `json:"heap_sys_bytes"`
+	SysBytes       uint64  `json:"sys_bytes"`
+	NumGC          uint32  `json:"num_gc"`
+	GCPauseTotalMS float64 `json:"gc_pause_total_ms"`
+}
+
+// AdminStats is the operator overview served at /api/admin/stats
+type AdminStats struct {
+	Build          BuildInfo             `json:"build"`
+	UptimeSeconds  int64                 `json:"uptime_seconds"`
+	Database       DatabaseStats         `json:"database"`
+	Caches         map[string]CacheStats `json:"caches"`
+	PrerenderQueue int                   `json:"prerender_queue"`
+	Runtime        RuntimeStats          `json:"runtime"`
+}
+
+// GameStats is the curator overview of one game, served at
+// /api/admin/games/{gameID}/stats to show where the catalog has gaps
+type GameStats struct {
+	GameID           string            `json:"game_id"`
+	Sheets           []SheetStats      `json:"sheets"`
+	ListsPerWeek     []WeekCount       `json:"lists_per_week"`
+	MostRanked       []RankedItem      `json:"most_ranked"`
+	UnmatchedImports []UnmatchedImport `json:"unmatched_imports"`
+}
+
+// SheetStats counts the catalog items of a sheet. Name is empty for sheets
+// with items that the game no longer lists.
+type SheetStats struct {
+	SheetID      string `json:"sheet_id"`
+	Name         string `json:"name"`
+	Items        int    `json:"items"`
+	MissingIcons int    `json:"missing_icons"`
+}
+
+// WeekCount counts the tier lists created in the week starting on Week, a
+// Monday formatted as YYYY-MM-DD
+type WeekCount struct {
+	Week  string `json:"week"`
+	Lists int    `json:"lists"`
+}
+
+// RankedItem is an item with how many public lists ranked it and its
+// community score, as of the last aggregates run
+type RankedItem struct {
+	ItemID    string  `json:"item_id"`
+	SheetID   string  `json:"sheet_id"`
+	Name      string  `json:"name"`
+	ListCount int     `json:"list_count"`
+	Score     float64 `json:"score"`
+}
+
+// UnmatchedImport is an item of imported tier lists that matched nothing in
+// the catalog, with how often it was left out
+type UnmatchedImport struct {
+	SheetID    string    `json:"sheet_id"`
+	Slug       string    `json:"slug"`
+	Name       string    `json:"name"`
+	Count      int       `json:"count"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// Kinds of item metadata the missing metadata report checks
+const (
+	MetadataIcon         = "icon"
+	MetadataDescription  = "description" // A description or wiki infobox
+	MetadataLocalization = "localization"
+)
+
+// MetadataReport lists the catalog items of a game missing metadata, by
+// sheet, served at /api/admin/games/{gameID}/missing-metadata
+type MetadataReport struct {
+	GameID string              `json:"game_id"`
+	Sheets []SheetMetadataGaps `json:"sheets"`
+}
+
+// SheetMetadataGaps lists the items of a sheet missing metadata, those
+// missing the most first. Missing counts the items lacking each kind.
+type SheetMetadataGaps struct {
+	SheetID string         `json:"sheet_id"`
+	Name    string         `json:"name"`
+	Items   int            `json:"items"`
+	Missing map[string]int `json:"missing"`
+	Gaps    []ItemGaps     `json:"gaps"`
+	// UnmatchedInfoboxes are scraped infoboxes the last infobox update
+	// found no item for, often items named differently in the catalog
+	UnmatchedInfoboxes []UnmatchedInfobox `json:"unmatched_infoboxes"`
+}
+
+// ItemGaps names the kinds of metadata an item lacks
+type ItemGaps struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Missing []string `json:"missing"`
+}
+
+// UnmatchedInfobox is a scraped infobox that matched no item of its sheet
+type UnmatchedInfobox struct {
+	SheetID string    `json:"sheet_id"`
+	Name    string    `json:"name"`
+	URL     string    `json:"url,omitempty"`
+	SeenAt  time.Time `json:"seen_at"`
+}
@@ -0,0 +1,113 @@
+package models
+
+import "time"
+
+// ServerReplica is the replica that edits made through the regular tier list
+// endpoints are counted under
+const ServerReplica = "server"
+
+// VectorClock counts the edits each replica has made to a tier
+type VectorClock map[string]int64
+
+// Clock orderings returned by VectorClock.Compare
+const (
+	ClockEqual = iota
+	ClockBefore
+	ClockAfter
+	ClockConcurrent
+)
+
+// Compare orders c against o: ClockAfter when c has seen every edit o has
+// and more, ClockBefore for the reverse and ClockConcurrent when each has
+// edits the other has not seen
+func (c VectorClock) Compare(o VectorClock) int {
+	ahead, behind := false, false
+	for replica, n := range c {
+		if n > o[replica] {
+			ahead = true
+		} else if n < o[replica] {
+			behind = true
+		}
+	}
+	for replica, n := range o {
+		if _, ok := c[replica]; !ok && n > 0 {
+			behind = true
+		}
+	}
+	switch {
+	case ahead && behind:
+		return ClockConcurrent
+	case ahead:
+		return ClockAfter
+	case behind:
+		return ClockBefore
+	}
+	return ClockEqual
+}
+
+// Merge returns the pointwise maximum of c and o
+func (c VectorClock) Merge(o VectorClock) VectorClock {
+	merged := make(VectorClock, len(c)+len(o))
+	for replica, n := range c {
+		merged[replica] = n
+	}
+	for replica, n := range o {
+		if n > merged[replica] {
+			merged[replica] = n
+		}
+	}
+	return merged
+}
+
+// TierClock is the sync state of one tier of a list
+type TierClock struct {
+	Vector     VectorClock `json:"vector"`
+	ModifiedAt time.Time   `json:"modified_at"`       // When the winning edit was made
+	Replica    string      `json:"replica,omitempty"` // Replica that made the winning edit
+	Deleted    bool        `json:"deleted,omitempty"` // The tier was removed
+}
+
+// TierChange is one tier edited on a client while it may have been offline
+type TierChange struct {
+	Tier    Tier      `json:"tier"`              // Only the id is needed for deletions
+	Deleted bool      `json:"deleted,omitempty"` // Removes the tier; its items return to the pool
+	Clock   TierClock `json:"clock"`             // Vector after the edit and when it was made
+}
+
+// ItemPosition is where an item sits in a tier list. Items of a tier are
+// ordered by their fractional position keys, so an item can be placed
+// between two others without renumbering the rest and concurrent inserts
+// merge the same way everywhere.
+type ItemPosition struct {
+	TierID     string    `json:"tier_id"`           // Empty once the item returned to the pool
+	Position   string    `json:"position"`          // Base-62 fraction; see ranking.PositionBetween
+	ModifiedAt time.Time `json:"modified_at"`       // When the item was placed here
+	Replica    string    `json:"replica,omitempty"` // Replica that placed it
+}
+
+// ItemMove is one item a client placed while it may have been offline
+type ItemMove struct {
+	ItemID     string    `json:"item_id"`
+	TierID     string    `json:"tier_id"`  // Empty returns the item to the pool
+	Position   string    `json:"position"` // Key between its new neighbours' keys
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// TierListSync is the request body of a tier list sync
+type TierListSync struct {
+	Replica string       `json:"replica"`         // Stable ID of the client device
+	Changes []TierChange `json:"changes"`         // Empty to just fetch the current state
+	Moves   []ItemMove   `json:"moves,omitempty"` // Applied after the changes
+}
+
+// TierListSyncResult is the merged state a client replaces its copy with
+type TierListSyncResult struct {
+	TierList *TierList            `json:"tier_list"`
+	Clocks   map[string]TierClock `json:"clocks"`
+	Applied  []string             `json:"applied"`  // Tiers whose change won
+	Rejected []string             `json:"rejected"` // Tiers whose change lost to a newer or later edit
+
+	Positions     map[string]ItemPosition `json:"positions"`      // By item ID
+	MovesApplied  []string                `json:"moves_applied"`  // Items whose move won
+	MovesRejected []string                `json:"moves_rejected"` // Items placed again since
+}
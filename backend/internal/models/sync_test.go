@@ -0,0 +1,66 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVectorClockCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		c, o VectorClock
+		want int
+	}{
+		{"both empty", nil, VectorClock{}, ClockEqual},
+		{"same edits", VectorClock{"a": 2, "b": 1}, VectorClock{"a": 2, "b": 1}, ClockEqual},
+		{"zero counts are absent", VectorClock{"a": 0}, nil, ClockEqual},
+		{"missing zero count", VectorClock{"a": 1}, VectorClock{"a": 1, "b": 0}, ClockEqual},
+		{"one more edit", VectorClock{"a": 2}, VectorClock{"a": 1}, ClockAfter},
+		{"edit from a new replica", VectorClock{"a": 1, "b": 1}, VectorClock{"a": 1}, ClockAfter},
+		{"against nothing", VectorClock{"a": 1}, nil, ClockAfter},
+		{"one edit behind", VectorClock{"a": 1}, VectorClock{"a": 2}, ClockBefore},
+		{"replica not yet seen", VectorClock{"a": 1}, VectorClock{"a": 1, "b": 1}, ClockBefore},
+		{"nothing against edits", nil, VectorClock{"a": 1}, ClockBefore},
+		{"disjoint replicas", VectorClock{"a": 1}, VectorClock{"b": 1}, ClockConcurrent},
+		{"crossed counts", VectorClock{"a": 2, "b": 1}, VectorClock{"a": 1, "b": 2}, ClockConcurrent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Compare(tt.o); got != tt.want {
+				t.Errorf("%v.Compare(%v) = %d, want %d", tt.c, tt.o, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVectorClockMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		c, o VectorClock
+		want VectorClock
+	}{
+		{"both empty", nil, nil, VectorClock{}},
+		{"into nothing", nil, VectorClock{"a": 1}, VectorClock{"a": 1}},
+		{"nothing in", VectorClock{"a": 1}, nil, VectorClock{"a": 1}},
+		{"pointwise maximum", VectorClock{"a": 3, "b": 1}, VectorClock{"a": 1, "b": 2, "c": 1},
+			VectorClock{"a": 3, "b": 2, "c": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := VectorClock{}
+			for r, n := range tt.c {
+				before[r] = n
+			}
+			got := tt.c.Merge(tt.o)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%v.Merge(%v) = %v, want %v", tt.c, tt.o, got, tt.want)
+			}
+			if len(tt.c) > 0 && !reflect.DeepEqual(tt.c, before) {
+				t.Errorf("Merge changed its receiver to %v", tt.c)
+			}
+			if got.Compare(tt.c) == ClockBefore || got.Compare(tt.o) == ClockBefore {
+				t.Errorf("merged clock %v has not seen every edit of %v and %v", got, tt.c, tt.o)
+			}
+		})
+	}
+}
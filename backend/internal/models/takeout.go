@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Takeout is a copy of everything a user created or saved, for data
+// portability and as a backup before deleting an account
+type Takeout struct {
+	Format        string            `json:"format"`
+	FormatVersion int               `json:"format_version"`
+	ExportedAt    time.Time         `json:"exported_at"`
+	User          *User             `json:"user"`
+	TierLists     []TakeoutTierList `json:"tierlists"`
+	Favorites     Favorites         `json:"favorites"`
+	Workspaces    []Workspace       `json:"workspaces"`
+	Matchups      []TakeoutMatchup  `json:"matchups"`
+	Brackets      []Bracket         `json:"brackets"`
+	PollVotes     []TakeoutPollVote `json:"poll_votes"`
+}
+
+// TakeoutTierList is an owned tier list with its unsaved changes
+type TakeoutTierList struct {
+	TierList
+	Autosave *TierListAutosave `json:"autosave,omitempty"`
+}
+
+// TakeoutMatchup is a matchup session with every pick made in it
+type TakeoutMatchup struct {
+	MatchupSession
+	Results []MatchupResult `json:"results"`
+}
+
+// TakeoutPollVote is one vote the user cast in a tier list poll
+type TakeoutPollVote struct {
+	TierListID string    `json:"tierlist_id"`
+	ItemID     string    `json:"item_id"`
+	TierID     string    `json:"tier_id"`
+	VotedAt    time.Time `json:"voted_at"`
+}
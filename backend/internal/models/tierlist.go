@@ -1,54 +1,313 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
+// Tier list lifecycle states. Only published lists appear in public listings
+// and only the owner can open a draft by share code.
+const (
+	TierListDraft     = "draft"
+	TierListPublished = "published"
+	TierListArchived  = "archived"
+)
+
+// tierListTransitions lists the states each state may move to
+var tierListTransitions = map[string][]string{
+	TierListDraft:     {TierListPublished, TierListArchived},
+	TierListPublished: {TierListArchived},
+	TierListArchived:  {TierListPublished},
+}
+
+// CanTransition reports whether a tier list may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range tierListTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidTierListStatus reports whether status is a known lifecycle state
+func ValidTierListStatus(status string) bool {
+	_, ok := tierListTransitions[status]
+	return ok
+}
+
+// Tier list visibility levels. Public lists appear in listings, unlisted
+// lists are reachable by share code, and private lists only by their owner.
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
+// ValidVisibility reports whether v is a known visibility level
+func ValidVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityUnlisted || v == VisibilityPrivate
+}
+
+// Unranked pool orders. A random pool is shuffled with the list's seed, so
+// everyone opening the same list sees the same order.
+const (
+	PoolAlphabetical = "alphabetical"
+	PoolCategory     = "category"
+	PoolRandom       = "random"
+)
+
+// MaxPoolSeed keeps seeds exact in JavaScript numbers
+const MaxPoolSeed = 1<<53 - 1
+
+// ValidPoolOrder reports whether order is a known pool order
+func ValidPoolOrder(order string) bool {
+	return order == PoolAlphabetical || order == PoolCategory || order == PoolRandom
+}
+
 // TierList represents a user's tier list
 type TierList struct {
-	ID        string    `json:"id"`
-	GameID    string    `json:"game_id"`
-	SheetID   string    `json:"sheet_id"`
-	Name      string    `json:"name"`
-	AuthorID  *string   `json:"author_id,omitempty"` // nil = anonymous
-	Tiers     []Tier    `json:"tiers"`
-	ShareCode string    `json:"share_code"`
-	IsPublic  bool      `json:"is_public"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string       `json:"id"`
+	GameID        string       `json:"game_id"`
+	SheetID       string       `json:"sheet_id"`
+	Name          string       `json:"name"`
+	AuthorID      *string      `json:"author_id,omitempty"` // nil = anonymous
+	Tiers         []Tier       `json:"tiers"`
+	ShareCode     string       `json:"share_code"`
+	IsPublic      bool         `json:"is_public"`
+	Visibility    string       `json:"visibility"`
+	Status        string       `json:"status"`
+	Hidden        bool         `json:"is_hidden,omitempty"` // Hidden by a moderator
+	ViewCount     int          `json:"view_count"`
+	GameVersion   string       `json:"game_version,omitempty"` // Patch the ranking applies to; empty = unversioned
+	Palette       string       `json:"palette,omitempty"`      // Palette the tier colors came from
+	WorkspaceID   string       `json:"workspace_id,omitempty"` // Workspace that jointly owns the list
+	PoolOrder     string       `json:"pool_order"`
+	PoolSeed      int64        `json:"pool_seed,omitempty"` // Shuffle seed of a random pool
+	Constraints   *Constraints `json:"constraints,omitempty"`
+	Poll          string       `json:"poll,omitempty"`        // PollOpen or PollClosed for community-voted lists
+	RecordReplay  bool         `json:"record_replay"`         // Item moves are recorded for replay on share pages
+	LockedAt      *time.Time   `json:"locked_at,omitempty"`   // Set while the owner has locked the list read-only
+	ForkedFrom    string       `json:"forked_from,omitempty"` // ID of the list this one was forked from
+	Score         *float64     `json:"score,omitempty"`       // Build score of the returned items, when tiers have points
+	Tags          []string     `json:"tags"`
+	ColorWarnings []ColorIssue `json:"color_warnings,omitempty"` // Set on save responses when COLOR_CHECK_MODE=warn
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
 }
 
 // Tier represents a single tier in a tier list
 type Tier struct {
-	ID    string   `json:"id"`
-	Name  string   `json:"name"`
-	Color string   `json:"color"`
-	Order int      `json:"order"`
-	Items []string `json:"items"` // Item IDs in order
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Color  string   `json:"color"`
+	Order  int      `json:"order"`
+	Max    int      `json:"max,omitempty"`    // Capacity; 0 = unlimited
+	Points *float64 `json:"points,omitempty"` // Score of each item in the tier; nil = unscored
+	Items  []string `json:"items"`            // Item IDs in order
+}
+
+// Score is a tier list's build score: the sum of the points of every item's
+// tier. Items in unscored tiers do not count.
+type Score struct {
+	Total  float64     `json:"total"`
+	Scored int         `json:"scored"` // Items in tiers with points
+	Tiers  []TierScore `json:"tiers"`
+}
+
+// TierScore is one scored tier's share of a Score
+type TierScore struct {
+	TierID   string  `json:"tier_id"`
+	Points   float64 `json:"points"`
+	Items    int     `json:"items"`
+	Subtotal float64 `json:"subtotal"`
+}
+
+// ScoreTiers scores tiers, returning nil when no tier has points
+func ScoreTiers(tiers []Tier) *Score {
+	var score *Score
+	for _, tier := range tiers {
+		if tier.Points == nil {
+			continue
+		}
+		if score == nil {
+			score = &Score{Tiers: []TierScore{}}
+		}
+		subtotal := *tier.Points * float64(len(tier.Items))
+		score.Tiers = append(score.Tiers, TierScore{TierID: tier.ID, Points: *tier.Points, Items: len(tier.Items), Subtotal: subtotal})
+		score.Total += subtotal
+		score.Scored += len(tier.Items)
+	}
+	return score
+}
+
+// Constraints are list-wide rules for challenge formats such as "pick only
+// 10 skills". Per-tier capacities live on each Tier.
+type Constraints struct {
+	MaxRanked  int  `json:"max_ranked,omitempty"`  // Items placed across all tiers; 0 = unlimited
+	RequireAll bool `json:"require_all,omitempty"` // Published lists must rank every non-spoiler item of the sheet
+}
+
+// Violations lists every way tiers break the tier capacities and c, which
+// may be nil. unranked is the number of sheet items left in the pool and
+// is only consulted for published lists.
+func (c *Constraints) Violations(tiers []Tier, unranked int, published bool) []string {
+	var out []string
+	ranked := 0
+	for _, tier := range tiers {
+		ranked += len(tier.Items)
+		if tier.Max > 0 && len(tier.Items) > tier.Max {
+			out = append(out, fmt.Sprintf("tier %s holds %d items but allows at most %d", tier.Name, len(tier.Items), tier.Max))
+		}
+	}
+	if c == nil {
+		return out
+	}
+	if c.MaxRanked > 0 && ranked > c.MaxRanked {
+		out = append(out, fmt.Sprintf("%d items are ranked but at most %d may be", ranked, c.MaxRanked))
+	}
+	if c.RequireAll && published && unranked > 0 {
+		out = append(out, fmt.Sprintf("every item must be ranked before publishing; %d left", unranked))
+	}
+	return out
+}
+
+// Check reports constraint values that make no sense
+func (c *Constraints) Check() error {
+	if c.MaxRanked < 0 {
+		return fmt.Errorf("constraints.max_ranked must not be negative")
+	}
+	if c.MaxRanked > 0 && c.RequireAll {
+		return fmt.Errorf("constraints.max_ranked and require_all cannot be combined")
+	}
+	return nil
 }
 
 // TierListCreate is the request body for creating a tier list
 type TierListCreate struct {
-	GameID  string `json:"game_id"`
-	SheetID string `json:"sheet_id"`
-	Name    string `json:"name"`
-	Tiers   []Tier `json:"tiers"`
+	GameID        string       `json:"game_id"`
+	SheetID       string       `json:"sheet_id"`
+	Name          string       `json:"name"`
+	Tiers         []Tier       `json:"tiers"`
+	GameVersion   string       `json:"game_version"` // Defaults to the game's current version
+	Status        string       `json:"status"`       // draft or published; defaults to published
+	Visibility    string       `json:"visibility"`   // Defaults to unlisted; private requires an account
+	Palette       string       `json:"palette"`      // Recolors the tiers when set
+	Tags          []string     `json:"tags"`
+	WorkspaceID   string       `json:"workspace_id"` // Requires membership; defaults to the game's workspace
+	PoolOrder     string       `json:"pool_order"`   // Defaults to alphabetical
+	PoolSeed      int64        `json:"pool_seed"`    // For a random pool; generated when zero
+	Constraints   *Constraints `json:"constraints"`
+	RecordReplay  bool         `json:"record_replay"`
+	AuthorID      *string      `json:"-"` // Set from the session, nil = anonymous
+	CreatorIP     string       `json:"-"` // Recorded for moderation only
+	CreatorDevice string       `json:"-"` // Ban hash of the creating device, for moderation only
+	ForkedFrom    string       `json:"-"` // Set when forking another list
+}
+
+// TierListFork is the request body for forking a tier list
+type TierListFork struct {
+	Name string `json:"name"` // Defaults to the source list's name
 }
 
 // TierListUpdate is the request body for updating a tier list
 type TierListUpdate struct {
-	Name     *string `json:"name,omitempty"`
-	Tiers    []Tier  `json:"tiers,omitempty"`
-	IsPublic *bool   `json:"is_public,omitempty"`
+	Name         *string      `json:"name,omitempty"`
+	Tiers        []Tier       `json:"tiers,omitempty"`
+	IsPublic     *bool        `json:"is_public,omitempty"`     // Shorthand for public/unlisted; ignored when visibility is set
+	Visibility   *string      `json:"visibility,omitempty"`    // Private requires an account
+	Status       *string      `json:"status,omitempty"`        // Must be a valid transition from the current status
+	Tags         *[]string    `json:"tags,omitempty"`          // Replaces all tags when set
+	Palette      *string      `json:"palette,omitempty"`       // Recolors the tiers when set
+	Constraints  *Constraints `json:"constraints,omitempty"`   // Replaces the constraints when set; {} clears them
+	RecordReplay *bool        `json:"record_replay,omitempty"` // Starting records the current placement; stopping clears the moves
 }
 
 // TierListSummary is a lightweight version for listings
 type TierListSummary struct {
-	ID        string    `json:"id"`
-	GameID    string    `json:"game_id"`
-	SheetID   string    `json:"sheet_id"`
-	Name      string    `json:"name"`
-	ShareCode string    `json:"share_code"`
-	ItemCount int       `json:"item_count"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            string    `json:"id"`
+	GameID        string    `json:"game_id"`
+	SheetID       string    `json:"sheet_id"`
+	Name          string    `json:"name"`
+	ShareCode     string    `json:"share_code"`
+	ItemCount     int       `json:"item_count"`
+	ViewCount     int       `json:"view_count"`
+	FavoriteCount int       `json:"favorite_count"`
+	IsPublic      bool      `json:"is_public"`
+	Visibility    string    `json:"visibility"`
+	Status        string    `json:"status"`
+	GameVersion   string    `json:"game_version,omitempty"`
+	Tags          []string  `json:"tags"`
+	ThumbnailURL  string    `json:"thumbnail_url,omitempty"` // Set for lists that may be shared
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Summary returns the listing representation of the tier list
+func (tl *TierList) Summary() TierListSummary {
+	count := 0
+	for _, t := range tl.Tiers {
+		count += len(t.Items)
+	}
+	tags := tl.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	thumbnail := ""
+	if !tl.Hidden && tl.Status == TierListPublished && tl.Visibility != VisibilityPrivate {
+		// The version parameter lets clients cache thumbnails until the list changes
+		thumbnail = fmt.Sprintf("/api/tierlists/%s/image?kind=thumb&v=%d", tl.ID, tl.UpdatedAt.Unix())
+	}
+	return TierListSummary{
+		ID:           tl.ID,
+		GameID:       tl.GameID,
+		SheetID:      tl.SheetID,
+		Name:         tl.Name,
+		ShareCode:    tl.ShareCode,
+		ItemCount:    count,
+		ViewCount:    tl.ViewCount,
+		IsPublic:     tl.IsPublic,
+		Visibility:   tl.Visibility,
+		Status:       tl.Status,
+		GameVersion:  tl.GameVersion,
+		Tags:         tags,
+		ThumbnailURL: thumbnail,
+		UpdatedAt:    tl.UpdatedAt,
+	}
+}
+
+// TagCount is a tag and the number of public tier lists using it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TierListBulkRequest is the request body for bulk operations on a user's
+// own tier lists
+type TierListBulkRequest struct {
+	IDs        []string `json:"ids"`
+	IsPublic   *bool    `json:"is_public,omitempty"`   // For bulk publish/unpublish
+	Visibility string   `json:"visibility,omitempty"`  // For bulk visibility changes; overrides is_public
+	AddTags    []string `json:"add_tags,omitempty"`    // For bulk retag
+	RemoveTags []string `json:"remove_tags,omitempty"` // For bulk retag
+}
+
+// TierListAutosave is unsaved, in-progress state of a tier list
+type TierListAutosave struct {
+	Name    *string   `json:"name,omitempty"`
+	Tiers   []Tier    `json:"tiers,omitempty"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// RankingQueue is the unranked pool of a tier list, served one item at a
+// time for keyboard-driven ranking
+type RankingQueue struct {
+	Next      []Item `json:"next"`      // The next unranked items, in pool order
+	Remaining int    `json:"remaining"` // Unranked items, including Next
+	Ranked    int    `json:"ranked"`
+}
+
+// TierAssignment is the request body for placing one item in a tier
+type TierAssignment struct {
+	ItemID string `json:"item_id"`
+	TierID string `json:"tier_id"` // Empty returns the item to the pool
 }
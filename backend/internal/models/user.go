@@ -0,0 +1,132 @@
+package models
+
+import "time"
+
+// DeletedUserID replaces the author of content left behind by a deleted
+// account. Unlike a nil author it keeps the content locked to admins.
+const DeletedUserID = "deleted"
+
+// Account roles
+const (
+	RoleAdmin   = "admin"   // Full access to everything
+	RoleCurator = "curator" // Manages the catalog of specific games
+	RoleUser    = "user"    // Regular account
+)
+
+// User is a registered account
+type User struct {
+	ID              string                `json:"id"`
+	Email           string                `json:"email"`
+	DisplayName     string                `json:"display_name"`
+	Role            string                `json:"role"`
+	GameIDs         []string              `json:"game_ids,omitempty"` // Games a curator may manage
+	Workspaces      []WorkspaceMembership `json:"workspaces,omitempty"`
+	EmailVerified   bool                  `json:"email_verified"`
+	WeeklyDigest    bool                  `json:"weekly_digest"`     // Opted in to the weekly activity email
+	AnalyticsOptOut bool                  `json:"analytics_opt_out"` // Excluded from product analytics events
+	CreatedAt       time.Time             `json:"created_at"`
+}
+
+// HasRole reports whether the user has any of the given roles
+func (u *User) HasRole(roles ...string) bool {
+	for _, r := range roles {
+		if u.Role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageGame reports whether the user may edit a game's catalog
+func (u *User) CanManageGame(gameID string) bool {
+	if u.Role == RoleAdmin {
+		return true
+	}
+	if u.Role != RoleCurator {
+		return false
+	}
+	for _, id := range u.GameIDs {
+		if id == gameID {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterRequest is the request body for creating an account
+type RegisterRequest struct {
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	DisplayName string `json:"display_name"`
+}
+
+// LoginRequest is the request body for signing in
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginResponse carries a new session token
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+}
+
+// VerifyEmailRequest is the request body for confirming an email token
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// UserPreferences is the request body for updating account preferences
+type UserPreferences struct {
+	WeeklyDigest    *bool `json:"weekly_digest,omitempty"`
+	AnalyticsOptOut *bool `json:"analytics_opt_out,omitempty"`
+}
+
+// PasswordForgotRequest is the request body for requesting a reset link
+type PasswordForgotRequest struct {
+	Email string `json:"email"`
+}
+
+// PasswordResetRequest is the request body for setting a new password from
+// a reset link
+type PasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// EmailChangeRequest is the request body for changing the account email.
+// The change applies once the new address is confirmed.
+type EmailChangeRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// AccountDeleteRequest is the request body for deleting the current account
+type AccountDeleteRequest struct {
+	Password string `json:"password"`
+}
+
+// PurgeResult counts the rows a purge of a user's data deleted, and the rows
+// kept with the user's ID replaced by DeletedUserID, by table
+type PurgeResult struct {
+	Removed    map[string]int64 `json:"removed"`
+	Anonymized map[string]int64 `json:"anonymized"`
+}
+
+// Session is an active login of a user
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// RoleUpdate is the request body for changing a user's role
+type RoleUpdate struct {
+	Role    string   `json:"role"`
+	GameIDs []string `json:"game_ids"`
+}
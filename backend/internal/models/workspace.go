@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Workspace member roles
+const (
+	WorkspaceOwner  = "owner"  // Manages members and everything the workspace owns
+	WorkspaceEditor = "editor" // Manages the workspace's games and edits its tier lists
+	WorkspaceMember = "member" // Creates and views the workspace's tier lists
+)
+
+// ValidWorkspaceRole reports whether role is a known workspace role
+func ValidWorkspaceRole(role string) bool {
+	switch role {
+	case WorkspaceOwner, WorkspaceEditor, WorkspaceMember:
+		return true
+	}
+	return false
+}
+
+// Workspace is a community or team that jointly owns games and tier lists
+type Workspace struct {
+	ID          string    `json:"id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Role        string    `json:"role,omitempty"` // The caller's role, when a member
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceCreate is the request body for creating a workspace
+type WorkspaceCreate struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// WorkspaceMembership is one workspace a user belongs to
+type WorkspaceMembership struct {
+	WorkspaceID string `json:"workspace_id"`
+	Role        string `json:"role"`
+}
+
+// WorkspaceMemberInfo is a member as listed to other members
+type WorkspaceMemberInfo struct {
+	UserID      string    `json:"user_id"`
+	DisplayName string    `json:"display_name"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// WorkspaceMemberUpdate is the request body for adding a member or changing
+// their role. Email identifies the user when adding.
+type WorkspaceMemberUpdate struct {
+	Email string `json:"email,omitempty"`
+	Role  string `json:"role"`
+}
+
+// WorkspaceRole returns the user's role in a workspace, or "" if they are not
+// a member
+func (u *User) WorkspaceRole(workspaceID string) string {
+	for _, m := range u.Workspaces {
+		if m.WorkspaceID == workspaceID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// CanManageWorkspace reports whether the user may manage the workspace's
+// games and edit its tier lists
+func (u *User) CanManageWorkspace(workspaceID string) bool {
+	role := u.WorkspaceRole(workspaceID)
+	return role == WorkspaceOwner || role == WorkspaceEditor
+}
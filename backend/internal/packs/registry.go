@@ -0,0 +1,150 @@
+// Package packs fetches game packs from a community registry. A registry is a
+// JSON index listing packs and their released versions; each version points
+// at a game bundle file together with its SHA-256 checksum.
+package packs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meur/tierforge/internal/interchange"
+	"github.com/meur/tierforge/internal/models"
+)
+
+const (
+	// indexTTL is how long a fetched index is reused
+	indexTTL = 10 * time.Minute
+	// maxIndexSize and maxBundleSize cap downloads, in bytes
+	maxIndexSize  = 4 << 20
+	maxBundleSize = 64 << 20
+)
+
+// Registry reads packs from one index URL
+type Registry struct {
+	indexURL *url.URL
+	client   *http.Client
+
+	mu        sync.Mutex
+	index     *models.PackIndex
+	fetchedAt time.Time
+}
+
+// New returns a registry for indexURL. http, https and file URLs are
+// supported; file URLs let self-hosters point at a local mirror.
+func New(indexURL string) (*Registry, error) {
+	u, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pack index URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "file":
+	default:
+		return nil, fmt.Errorf("pack index URL must be http, https or file, got %q", u.Scheme)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return &Registry{
+		indexURL: u,
+		client:   &http.Client{Transport: transport, Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// Index returns the registry index, fetching it again once the cached copy
+// is older than indexTTL
+func (r *Registry) Index(ctx context.Context) (*models.PackIndex, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index != nil && time.Since(r.fetchedAt) < indexTTL {
+		return r.index, nil
+	}
+
+	data, err := r.get(ctx, r.indexURL, maxIndexSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetch pack index: %w", err)
+	}
+	var index models.PackIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("parse pack index: %w", err)
+	}
+	r.index, r.fetchedAt = &index, time.Now()
+	return r.index, nil
+}
+
+// Pack returns a pack from the index by ID, or nil if the registry has none
+func (r *Registry) Pack(ctx context.Context, id string) (*models.Pack, error) {
+	index, err := r.Index(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range index.Packs {
+		if index.Packs[i].ID == id {
+			pack := index.Packs[i]
+			return &pack, nil
+		}
+	}
+	return nil, nil
+}
+
+// Download fetches a pack release, verifies its checksum and decodes it as a
+// game bundle
+func (r *Registry) Download(ctx context.Context, v *models.PackVersion) (*models.GameBundle, error) {
+	ref, err := url.Parse(v.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle URL %q: %w", v.URL, err)
+	}
+	target := r.indexURL.ResolveReference(ref)
+	if target.Scheme == "file" && r.indexURL.Scheme != "file" {
+		// A remote index must not make the server read local files
+		return nil, fmt.Errorf("bundle URL %q is not allowed for a remote index", v.URL)
+	}
+	data, err := r.get(ctx, target, maxBundleSize)
+	if err != nil {
+		return nil, fmt.Errorf("download bundle: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, v.SHA256) {
+		return nil, fmt.Errorf("checksum mismatch: index says %s, download is %s", v.SHA256, got)
+	}
+
+	bundle, err := interchange.DecodeGameBundle(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+// get reads a URL, failing on non-200 responses and bodies over limit
+func (r *Registry) get(ctx context.Context, u *url.URL, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", u, limit)
+	}
+	return data, nil
+}
@@ -0,0 +1,108 @@
+// Package bg3 holds the Baldur's Gate 3 plugins: importers of the spells,
+// classes, subclasses and feats of the 5e-database community data set, and
+// grouping of items by class
+package bg3
+
+import (
+	"strings"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+)
+
+// GameID is the ID of Baldur's Gate 3
+const GameID = "bg3"
+
+// classOrder lists the classes in the order the game does
+var classOrder = []string{
+	"Barbarian", "Bard", "Cleric", "Druid", "Fighter", "Monk",
+	"Paladin", "Ranger", "Rogue", "Sorcerer", "Warlock", "Wizard",
+}
+
+func init() {
+	plugins.Register(classes{})
+	plugins.Register(srdImporter{name: "bg3-spells", sheetID: "spells", read: readSpells})
+	plugins.Register(srdImporter{name: "bg3-classes", sheetID: "classes", read: readClasses})
+	plugins.Register(srdImporter{name: "bg3-subclasses", sheetID: "subclasses", read: readSubclasses})
+	plugins.Register(srdImporter{name: "bg3-feats", sheetID: "feats", read: readFeats})
+}
+
+// classes groups items by the classes in their Data["classes"]
+type classes struct{}
+
+func (classes) GameID() string { return GameID }
+
+// Enrich spells the classes of an item the way the class filter does, in
+// game order without repeats, and files a subclass under its class
+func (classes) Enrich(item *models.Item) {
+	list := itemClasses(*item)
+	if list == nil {
+		return
+	}
+	normalized := make([]interface{}, len(list))
+	for i, class := range list {
+		normalized[i] = class
+	}
+	item.Data["classes"] = normalized
+	if item.SheetID == "subclasses" && len(list) == 1 {
+		item.Category = list[0]
+	}
+}
+
+// ExportLabel lists the classes of an item
+func (classes) ExportLabel(item models.Item) string {
+	return strings.Join(itemClasses(item), ", ")
+}
+
+// itemClasses returns the canonical, ordered classes of an item. Classes the
+// game does not have are kept after the known ones. It returns nil when the
+// item has no classes field.
+func itemClasses(item models.Item) []string {
+	var raw []string
+	switch v := item.Data["classes"].(type) {
+	case []interface{}:
+		for _, c := range v {
+			if s, ok := c.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	case []string:
+		raw = v
+	case string:
+		raw = strings.Split(v, ",")
+	default:
+		return nil
+	}
+
+	seen := make(map[string]bool, len(raw))
+	var unknown []string
+	for _, c := range raw {
+		c = strings.TrimSpace(c)
+		name := canonicalClass(c)
+		if name == "" {
+			name = c
+			if name != "" && !seen[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		seen[name] = true
+	}
+
+	list := make([]string, 0, len(seen))
+	for _, class := range classOrder {
+		if seen[class] {
+			list = append(list, class)
+		}
+	}
+	return append(list, unknown...)
+}
+
+// canonicalClass returns the class named c in any case, or ""
+func canonicalClass(c string) string {
+	for _, class := range classOrder {
+		if strings.EqualFold(c, class) {
+			return class
+		}
+	}
+	return ""
+}
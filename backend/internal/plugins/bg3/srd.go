@@ -0,0 +1,257 @@
+package bg3
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/slug"
+)
+
+// The importers read the JSON files of the open 5e-database community data
+// set (5e-SRD-Spells.json, 5e-SRD-Classes.json and so on), keeping what BG3
+// has: spells up to level 6 and the twelve classes. Existing items are
+// matched by name, so curated icons and tags survive a re-import.
+
+// maxSpellLevel is the highest spell level in BG3
+const maxSpellLevel = 6
+
+// featLevel is the first level that grants a feat
+const featLevel = 4
+
+// subclassLevels is the level each class picks its subclass at; the rest
+// pick it at 3
+var subclassLevels = map[string]int{"Cleric": 1, "Sorcerer": 1, "Warlock": 1, "Druid": 2, "Wizard": 2}
+
+type apiRef struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+}
+
+type srdSpell struct {
+	Index         string   `json:"index"`
+	Name          string   `json:"name"`
+	Desc          []string `json:"desc"`
+	Range         string   `json:"range"`
+	Components    []string `json:"components"`
+	Ritual        bool     `json:"ritual"`
+	Duration      string   `json:"duration"`
+	Concentration bool     `json:"concentration"`
+	CastingTime   string   `json:"casting_time"`
+	Level         int      `json:"level"`
+	School        apiRef   `json:"school"`
+	Classes       []apiRef `json:"classes"`
+}
+
+type srdClass struct {
+	Index        string          `json:"index"`
+	Name         string          `json:"name"`
+	HitDie       int             `json:"hit_die"`
+	SavingThrows []apiRef        `json:"saving_throws"`
+	Spellcasting json.RawMessage `json:"spellcasting"`
+}
+
+type srdSubclass struct {
+	Index string   `json:"index"`
+	Name  string   `json:"name"`
+	Class apiRef   `json:"class"`
+	Desc  []string `json:"desc"`
+}
+
+type srdFeat struct {
+	Index         string `json:"index"`
+	Name          string `json:"name"`
+	Prerequisites []struct {
+		AbilityScore apiRef `json:"ability_score"`
+		MinimumScore int    `json:"minimum_score"`
+	} `json:"prerequisites"`
+	Desc []string `json:"desc"`
+}
+
+// srdImporter reads one file of the data set into a sheet
+type srdImporter struct {
+	name    string
+	sheetID string
+	read    func(data []byte) ([]models.Item, error)
+}
+
+func (i srdImporter) GameID() string  { return GameID }
+func (i srdImporter) Name() string    { return i.name }
+func (i srdImporter) SheetID() string { return i.sheetID }
+func (i srdImporter) Replace() bool   { return false }
+
+func (i srdImporter) Import(data []byte, existing []models.Item) ([]models.Item, error) {
+	read, err := i.read(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(read) == 0 {
+		return nil, errors.New("no " + i.sheetID + " found")
+	}
+
+	existingByName := make(map[string]models.Item, len(existing))
+	for _, item := range existing {
+		if _, dup := existingByName[item.Name]; !dup {
+			existingByName[item.Name] = item
+		}
+	}
+	items := make([]models.Item, 0, len(read))
+	for _, r := range read {
+		item, ok := existingByName[r.Name]
+		if !ok {
+			item = models.Item{ID: slug.ItemID(GameID, i.sheetID, r.Name)}
+		}
+		item.GameID, item.SheetID = GameID, i.sheetID
+		item.Name, item.Category = r.Name, r.Category
+		fields := make(map[string]interface{}, len(item.Data)+len(r.Data))
+		for k, v := range item.Data {
+			fields[k] = v
+		}
+		for k, v := range r.Data {
+			fields[k] = v
+		}
+		item.Data = fields
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func readSpells(data []byte) ([]models.Item, error) {
+	var spells []srdSpell
+	if err := json.Unmarshal(data, &spells); err != nil {
+		return nil, err
+	}
+	var items []models.Item
+	for _, s := range spells {
+		classes := bg3Classes(s.Classes)
+		if s.Name == "" || s.Level > maxSpellLevel || len(classes) == 0 {
+			continue
+		}
+		components := make([]interface{}, len(s.Components))
+		for i, c := range s.Components {
+			components[i] = c
+		}
+		items = append(items, models.Item{
+			Name:     s.Name,
+			Category: s.School.Name,
+			Data: map[string]interface{}{
+				"level":         s.Level,
+				"school":        s.School.Name,
+				"casting_time":  s.CastingTime,
+				"range":         s.Range,
+				"duration":      s.Duration,
+				"concentration": s.Concentration,
+				"ritual":        s.Ritual,
+				"components":    components,
+				"classes":       classes,
+				"description":   strings.Join(s.Desc, "\n\n"),
+			},
+		})
+	}
+	return items, nil
+}
+
+func readClasses(data []byte) ([]models.Item, error) {
+	var classes []srdClass
+	if err := json.Unmarshal(data, &classes); err != nil {
+		return nil, err
+	}
+	var items []models.Item
+	for _, c := range classes {
+		name := canonicalClass(c.Name)
+		if name == "" {
+			continue
+		}
+		category := "Martial"
+		if len(c.Spellcasting) > 0 && string(c.Spellcasting) != "null" {
+			category = "Spellcaster"
+		}
+		saves := make([]interface{}, len(c.SavingThrows))
+		for i, s := range c.SavingThrows {
+			saves[i] = s.Name
+		}
+		items = append(items, models.Item{
+			Name:     name,
+			Category: category,
+			Data: map[string]interface{}{
+				"classes":       []interface{}{name},
+				"hit_die":       c.HitDie,
+				"saving_throws": saves,
+			},
+		})
+	}
+	return items, nil
+}
+
+func readSubclasses(data []byte) ([]models.Item, error) {
+	var subclasses []srdSubclass
+	if err := json.Unmarshal(data, &subclasses); err != nil {
+		return nil, err
+	}
+	var items []models.Item
+	for _, s := range subclasses {
+		class := canonicalClass(s.Class.Name)
+		if s.Name == "" || class == "" {
+			continue
+		}
+		level := subclassLevels[class]
+		if level == 0 {
+			level = 3
+		}
+		items = append(items, models.Item{
+			Name:     s.Name,
+			Category: class,
+			Data: map[string]interface{}{
+				"classes":     []interface{}{class},
+				"level":       level,
+				"description": strings.Join(s.Desc, "\n\n"),
+			},
+		})
+	}
+	return items, nil
+}
+
+func readFeats(data []byte) ([]models.Item, error) {
+	var feats []srdFeat
+	if err := json.Unmarshal(data, &feats); err != nil {
+		return nil, err
+	}
+	var items []models.Item
+	for _, f := range feats {
+		if f.Name == "" {
+			continue
+		}
+		fields := map[string]interface{}{
+			"level":       featLevel,
+			"description": strings.Join(f.Desc, "\n\n"),
+		}
+		var prereqs []string
+		for _, p := range f.Prerequisites {
+			prereqs = append(prereqs, p.AbilityScore.Name+" "+strconv.Itoa(p.MinimumScore))
+		}
+		if len(prereqs) > 0 {
+			fields["prerequisite"] = strings.Join(prereqs, ", ")
+		}
+		items = append(items, models.Item{Name: f.Name, Category: "Feat", Data: fields})
+	}
+	return items, nil
+}
+
+// bg3Classes returns the BG3 classes among refs, in game order
+func bg3Classes(refs []apiRef) []interface{} {
+	seen := make(map[string]bool, len(refs))
+	for _, r := range refs {
+		if name := canonicalClass(r.Name); name != "" {
+			seen[name] = true
+		}
+	}
+	var list []interface{}
+	for _, class := range classOrder {
+		if seen[class] {
+			list = append(list, class)
+		}
+	}
+	return list
+}
@@ -0,0 +1,65 @@
+// Package dos2 holds the Divinity: Original Sin 2 plugins: the skill and
+// talent importers, stat fields and combo detection for skills, and combo
+// labels in exports.
+package dos2
+
+import (
+	"github.com/meur/tierforge/internal/infobox"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+)
+
+// GameID is the ID of Divinity: Original Sin 2
+const GameID = "dos2"
+
+// comboSchool is the school the wiki files combo skills under; each combo
+// skill is listed in its primary school instead
+const comboSchool = "Комбинированные навыки"
+
+func init() {
+	plugins.Register(skillImporter{})
+	plugins.Register(talentImporter{})
+	plugins.Register(skills{})
+}
+
+// skills enriches and labels skills
+type skills struct{}
+
+func (skills) GameID() string { return GameID }
+
+// Enrich derives a skill's stat fields from its infobox and marks combo
+// skills, those with a secondary school, filing them under their primary one
+func (skills) Enrich(item *models.Item) {
+	if item.SheetID != "skills" {
+		return
+	}
+	if item.Data == nil {
+		item.Data = make(map[string]interface{})
+	}
+	if ib := infobox.FromData(item.Data); ib != nil {
+		for key, value := range SkillFields(ib) {
+			item.Data[key] = value
+		}
+	}
+
+	primary, _ := item.Data["primary_school"].(string)
+	secondary, _ := item.Data["secondary_school"].(string)
+	combo, _ := item.Data["is_combo"].(bool)
+	item.Data["is_combo"] = combo || secondary != ""
+	if item.Category == comboSchool && primary != "" {
+		item.Category = primary
+	}
+}
+
+// ExportLabel names the two schools of a combo skill
+func (skills) ExportLabel(item models.Item) string {
+	if item.SheetID != "skills" {
+		return ""
+	}
+	primary, _ := item.Data["primary_school"].(string)
+	secondary, _ := item.Data["secondary_school"].(string)
+	if primary == "" || secondary == "" {
+		return ""
+	}
+	return primary + " + " + secondary
+}
@@ -0,0 +1,72 @@
+package dos2
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/meur/tierforge/internal/infobox"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SkillFields returns the typed Data fields of a skill: ap_cost, source_cost
+// and cooldown as integers, range in metres, and resisted_by and scaling as one of a few fixed names so they can be
+// filtered on. Stats the infobox lacks are left out.
+func SkillFields(ib *models.Infobox) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if ib == nil {
+		return fields
+	}
+	for _, key := range []string{infobox.KeyAPCost, infobox.KeySourceCost, infobox.KeyCooldown} {
+		if v, ok := ib.Stat(key); ok {
+			if n, err := strconv.Atoi(v); err == nil {
+				fields[key] = n
+			}
+		}
+	}
+	if v, ok := ib.Stat(infobox.KeyRange); ok {
+		v = strings.TrimSpace(strings.TrimSuffix(strings.ReplaceAll(v, ",", "."), "m"))
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			fields[infobox.KeyRange] = n
+		}
+	}
+	if v, ok := ib.Stat(infobox.KeyResistedBy); ok {
+		fields[infobox.KeyResistedBy] = resistance(v)
+	}
+	if v, ok := ib.Stat(infobox.KeyScaling); ok {
+		if s := scaling(v); s != "" {
+			fields[infobox.KeyScaling] = s
+		}
+	}
+	return fields
+}
+
+// resistance names the armour that resists a skill
+func resistance(v string) string {
+	lower := strings.ToLower(v)
+	switch {
+	case strings.Contains(lower, "physical"):
+		return "Physical Armour"
+	case strings.Contains(lower, "magic"):
+		return "Magic Armour"
+	}
+	return "None"
+}
+
+// scaling names the attribute a skill's damage scales with. Skills that
+// scale with either weapon attribute count as Weapon.
+func scaling(v string) string {
+	lower := strings.ToLower(v)
+	matched := ""
+	for _, attr := range []string{"Strength", "Finesse", "Intelligence"} {
+		if strings.Contains(lower, strings.ToLower(attr)) {
+			if matched != "" {
+				return "Weapon"
+			}
+			matched = attr
+		}
+	}
+	if matched == "" && strings.Contains(lower, "weapon") {
+		return "Weapon"
+	}
+	return matched
+}
@@ -0,0 +1,108 @@
+package dos2
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/slug"
+)
+
+type spellData struct {
+	RuName          string `json:"ru_name"`
+	EnName          string `json:"en_name"`
+	RuURL           string `json:"ru_url"`
+	EnURL           string `json:"en_url"`
+	Tier            string `json:"tier"`
+	PrimarySchool   string `json:"primary_school"`
+	SecondarySchool string `json:"secondary_school,omitempty"`
+	IsCombo         bool   `json:"is_combo,omitempty"`
+	Icon            string `json:"icon"`
+}
+
+type schoolData struct {
+	EnName string               `json:"en_name"`
+	Spells map[string]spellData `json:"spells"`
+}
+
+type spellsRoot struct {
+	Schools map[string]schoolData `json:"schools"`
+}
+
+// skillImporter reads data/spells.json, the skills scraped from the Russian
+// wiki grouped by school, and replaces the skills sheet with it
+type skillImporter struct{}
+
+func (skillImporter) GameID() string  { return GameID }
+func (skillImporter) Name() string    { return "dos2-skills" }
+func (skillImporter) SheetID() string { return "skills" }
+func (skillImporter) Replace() bool   { return true }
+
+func (skillImporter) Import(data []byte, existing []models.Item) ([]models.Item, error) {
+	var root spellsRoot
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	schools := make([]string, 0, len(root.Schools))
+	for name := range root.Schools {
+		schools = append(schools, name)
+	}
+	sort.Strings(schools)
+
+	var items []models.Item
+	for _, ruSchoolName := range schools {
+		school := root.Schools[ruSchoolName]
+		names := make([]string, 0, len(school.Spells))
+		for name := range school.Spells {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			spell := school.Spells[name]
+			// Use English name for ID if possible, otherwise Russian
+			nameForID := spell.EnName
+			if nameForID == "" {
+				nameForID = spell.RuName
+			}
+
+			// Combo skills are filed under their primary school
+			category := spell.PrimarySchool
+			if category == "" {
+				category = ruSchoolName
+			}
+
+			// Standard skills are [school]-[name] in English; combo skills
+			// take the slug of their primary school instead
+			id := slug.Join(category) + "-" + slug.Join(nameForID)
+			if spell.EnName != "" && school.EnName != "" && !spell.IsCombo {
+				id = slug.Join(school.EnName, spell.EnName)
+			}
+
+			displayName := spell.EnName
+			if displayName == "" {
+				displayName = spell.RuName
+			}
+
+			items = append(items, models.Item{
+				ID:       id,
+				GameID:   GameID,
+				SheetID:  "skills",
+				Name:     displayName,
+				NameRu:   spell.RuName,
+				Category: category,
+				Icon:     spell.Icon,
+				Data: map[string]interface{}{
+					"tier":             spell.Tier,
+					"primary_school":   category,
+					"secondary_school": spell.SecondarySchool,
+					"is_combo":         spell.IsCombo,
+					"wiki_url_ru":      spell.RuURL,
+					"wiki_url_en":      spell.EnURL,
+				},
+			})
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,112 @@
+package dos2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/meur/tierforge/internal/infobox"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/sanitize"
+	"github.com/meur/tierforge/internal/slug"
+)
+
+const wikiBaseURL = "https://divinityoriginalsin2.wiki.fextralife.com/"
+
+var wikiAliases = map[string]string{
+	"Bigger and Better": "Bigger And Better",
+	"Five-star Diner":   "Five-Star Diner",
+	"Walk it Off":       "Walk It Off",
+}
+
+type talentEntry struct {
+	Name        string `json:"name"`
+	Icon        string `json:"icon"`
+	Description string `json:"description"`
+	InfoboxHTML string `json:"infoboxHtml"`
+}
+
+// talentImporter reads the talents.json export of the divinity scraper and
+// updates the talents sheet, matching existing talents by name
+type talentImporter struct{}
+
+func (talentImporter) GameID() string  { return GameID }
+func (talentImporter) Name() string    { return "dos2-talents" }
+func (talentImporter) SheetID() string { return "talents" }
+func (talentImporter) Replace() bool   { return false }
+
+func (t talentImporter) Import(data []byte, existing []models.Item) ([]models.Item, error) {
+	talents := map[string]talentEntry{}
+	if err := json.Unmarshal(data, &talents); err != nil {
+		return nil, err
+	}
+	if len(talents) == 0 {
+		return nil, errors.New("talents JSON is empty")
+	}
+
+	existingByName := make(map[string]models.Item, len(existing))
+	for _, item := range existing {
+		if _, dup := existingByName[item.Name]; !dup {
+			existingByName[item.Name] = item
+		}
+	}
+
+	keys := make([]string, 0, len(talents))
+	for key := range talents {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	items := make([]models.Item, 0, len(talents))
+	for _, key := range keys {
+		entry := talents[key]
+		name := strings.TrimSpace(entry.Name)
+		if name == "" {
+			name = strings.TrimSpace(key)
+		}
+		if name == "" {
+			continue
+		}
+
+		item, exists := existingByName[name]
+		if !exists {
+			item = models.Item{ID: slug.ItemID(GameID, t.SheetID(), name)}
+		}
+		item.GameID = GameID
+		item.SheetID = t.SheetID()
+		item.Name = name
+		if item.Category == "" {
+			item.Category = "Talents"
+		}
+		if entry.Icon != "" {
+			item.Icon = entry.Icon
+		}
+
+		data := make(map[string]interface{}, len(item.Data))
+		for k, v := range item.Data {
+			data[k] = v
+		}
+		if entry.Description != "" {
+			data["description"] = entry.Description
+		}
+		if entry.InfoboxHTML != "" {
+			data["infobox_html"] = sanitize.HTML(entry.InfoboxHTML)
+			data[infobox.DataKey] = infobox.Parse(entry.InfoboxHTML)
+		}
+		data["wiki_url"] = talentWikiURL(name)
+		item.Data = data
+
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func talentWikiURL(name string) string {
+	wikiName := name
+	if alias, ok := wikiAliases[name]; ok {
+		wikiName = alias
+	}
+	return wikiBaseURL + url.QueryEscape(wikiName)
+}
@@ -0,0 +1,8 @@
+// Package games registers the plugins of the built-in games. Binaries that
+// import, edit or export items import it for its side effects.
+package games
+
+import (
+	_ "github.com/meur/tierforge/internal/plugins/bg3"
+	_ "github.com/meur/tierforge/internal/plugins/dos2"
+)
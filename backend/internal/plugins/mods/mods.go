@@ -0,0 +1,274 @@
+// Package mods imports the content of game mods, so modded playthroughs can
+// be tier-listed too. Each mod gets a sheet of its own, and its items are
+// tagged with the mod's name.
+//
+// Mods are read from Larian stats files, for DOS2 skills and BG3 spells and
+// passives, or from a community JSON export of any game: an array of records,
+// or an object of them keyed by ID, with name and optionally id, name_ru,
+// category, icon, description and data.
+package mods
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/meur/tierforge/internal/infobox"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+	"github.com/meur/tierforge/internal/slug"
+)
+
+// maxTagLength matches the limit on curator-defined item tags
+const maxTagLength = 32
+
+// SheetID is the sheet a mod's items go to, as in mod-odinblade
+func SheetID(mod string) string {
+	return "mod-" + slug.Join(mod)
+}
+
+// Tag is the item tag marking a mod's items: its name, shortened to fit
+func Tag(mod string) string {
+	tag := strings.TrimSpace(strings.ReplaceAll(mod, ",", " "))
+	if r := []rune(tag); len(r) > maxTagLength {
+		tag = strings.TrimSpace(string(r[:maxTagLength]))
+	}
+	return tag
+}
+
+// Importer returns an importer of a mod's content for a game. names maps
+// localization handles to text, for stats files whose display names are
+// handles; it may be nil.
+func Importer(gameID, mod string, replace bool, names map[string]string) plugins.ImporterPlugin {
+	return importer{gameID: gameID, mod: strings.TrimSpace(mod), replace: replace, names: names}
+}
+
+type importer struct {
+	gameID  string
+	mod     string
+	replace bool
+	names   map[string]string
+}
+
+func (i importer) GameID() string  { return i.gameID }
+func (i importer) Name() string    { return "mod:" + i.gameID + "/" + slug.Join(i.mod) }
+func (i importer) SheetID() string { return SheetID(i.mod) }
+func (i importer) Replace() bool   { return i.replace }
+
+// Import reads JSON when data starts like JSON, and stats files otherwise.
+// Items the sheet already has keep their curated fields, such as icons.
+func (i importer) Import(data []byte, existing []models.Item) ([]models.Item, error) {
+	if i.mod == "" || SheetID(i.mod) == "mod-" {
+		return nil, errors.New("mod name must have letters or digits")
+	}
+	var records []record
+	var err error
+	switch trimmed := strings.TrimSpace(string(data)); {
+	case strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{"):
+		records, err = jsonRecords(data)
+	case i.gameID == "dos2" || i.gameID == "bg3":
+		records, err = i.statsRecords(data)
+	default:
+		return nil, fmt.Errorf("stats files are only read for dos2 and bg3; use JSON for %s", i.gameID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no importable content found")
+	}
+
+	byID := make(map[string]models.Item, len(existing))
+	for _, item := range existing {
+		byID[item.ID] = item
+	}
+	sheetID, tag := i.SheetID(), Tag(i.mod)
+	items := make([]models.Item, 0, len(records))
+	seen := make(map[string]bool, len(records))
+	for _, rec := range records {
+		id := slug.ItemID(i.gameID, sheetID, rec.ID)
+		if rec.ID == "" {
+			id = slug.ItemID(i.gameID, sheetID, rec.Name)
+		}
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		item, ok := byID[id]
+		if !ok {
+			item = models.Item{ID: id, GameID: i.gameID, SheetID: sheetID}
+		}
+		item.Name = rec.Name
+		if rec.NameRu != "" {
+			item.NameRu = rec.NameRu
+		}
+		if rec.Category != "" || item.Category == "" {
+			item.Category = rec.Category
+		}
+		if rec.Icon != "" {
+			item.Icon = rec.Icon
+		}
+		fields := make(map[string]interface{}, len(item.Data)+len(rec.Data)+2)
+		for k, v := range item.Data {
+			fields[k] = v
+		}
+		for k, v := range rec.Data {
+			fields[k] = v
+		}
+		if rec.Description != "" {
+			fields["description"] = rec.Description
+		}
+		fields["mod"] = i.mod
+		item.Data = fields
+		item.Tags = withTag(item.Tags, tag)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// withTag adds tag to tags unless they have it in any case
+func withTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return tags
+		}
+	}
+	return append(append([]string(nil), tags...), tag)
+}
+
+// record is a piece of mod content on its way to becoming an item
+type record struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	NameRu      string                 `json:"name_ru"`
+	Category    string                 `json:"category"`
+	Icon        string                 `json:"icon"`
+	Description string                 `json:"description"`
+	Data        map[string]interface{} `json:"data"`
+}
+
+// jsonRecords reads a community export. Records of an object are visited in
+// key order and take their key as ID when they have none.
+func jsonRecords(data []byte) ([]record, error) {
+	var list []record
+	if err := json.Unmarshal(data, &list); err == nil {
+		return named(list), nil
+	}
+	var byKey map[string]record
+	if err := json.Unmarshal(data, &byKey); err != nil {
+		return nil, fmt.Errorf("mod JSON must be an array or object of records: %w", err)
+	}
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		rec := byKey[k]
+		if rec.ID == "" {
+			rec.ID = k
+		}
+		list = append(list, rec)
+	}
+	return named(list), nil
+}
+
+// named drops records without a name
+func named(list []record) []record {
+	out := list[:0]
+	for _, rec := range list {
+		if rec.Name = strings.TrimSpace(rec.Name); rec.Name != "" {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// statsRecords maps the entries of a game's stats files worth ranking:
+// DOS2 skills, and BG3 spells and passives. Abstract entries, whose names
+// start with an underscore, are skipped.
+func (i importer) statsRecords(data []byte) ([]record, error) {
+	entries, err := parseStats(data)
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	for _, e := range entries {
+		if strings.HasPrefix(e.name, "_") {
+			continue
+		}
+		rec := record{ID: e.name, Name: displayName(e, i.names), Data: map[string]interface{}{"stats_id": e.name}}
+		if icon := e.fields["Icon"]; icon != "" {
+			rec.Data["icon_name"] = icon
+		}
+		if desc, _, _ := strings.Cut(e.fields["Description"], ";"); i.names[desc] != "" {
+			rec.Description = i.names[desc]
+		}
+		switch {
+		case i.gameID == "dos2" && e.typ == "SkillData":
+			dos2Skill(e, &rec)
+		case i.gameID == "bg3" && e.typ == "SpellData":
+			bg3Spell(e, &rec)
+		case i.gameID == "bg3" && e.typ == "PassiveData":
+			rec.Category = "Passives"
+		default:
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// dos2Schools names the schools of DOS2's skill abilities
+var dos2Schools = map[string]string{
+	"Warrior": "Warfare", "Ranger": "Huntsman", "Rogue": "Scoundrel",
+	"Source": "Sourcery", "Fire": "Pyrokinetic", "Water": "Hydrosophist",
+	"Air": "Aerotheurge", "Earth": "Geomancer", "Death": "Necromancer",
+	"Summoning": "Summoning", "Polymorph": "Polymorph",
+}
+
+// dos2Skill files a skill under its school and keeps the stats the skill
+// filters use, in the same fields the wiki import fills
+func dos2Skill(e entry, rec *record) {
+	rec.Category = dos2Schools[e.fields["Ability"]]
+	if rec.Category == "" {
+		rec.Category = "Special"
+	}
+	rec.Data[infobox.KeySchool] = rec.Category
+	for stat, key := range map[string]string{
+		"ActionPoints": infobox.KeyAPCost,
+		"Magic Cost":   infobox.KeySourceCost,
+		"Cooldown":     infobox.KeyCooldown,
+		"Memory Cost":  infobox.KeyMemoryCost,
+	} {
+		if n, err := strconv.Atoi(e.fields[stat]); err == nil {
+			rec.Data[key] = n
+		}
+	}
+	for stat, key := range map[string]string{"TargetRadius": infobox.KeyRange, "AreaRadius": infobox.KeyExplodeRadius} {
+		if f, err := strconv.ParseFloat(e.fields[stat], 64); err == nil && f > 0 {
+			rec.Data[key] = f
+		}
+	}
+	if t := e.fields["SkillType"]; t != "" {
+		rec.Data["skill_type"] = t
+	}
+}
+
+// bg3Spell files a spell under its school and keeps its level and type
+func bg3Spell(e entry, rec *record) {
+	rec.Category = e.fields["SpellSchool"]
+	if rec.Category == "" || rec.Category == "None" {
+		rec.Category = "Other"
+	}
+	if n, err := strconv.Atoi(e.fields["Level"]); err == nil {
+		rec.Data["level"] = n
+	}
+	if t := e.fields["SpellType"]; t != "" {
+		rec.Data["spell_type"] = t
+	}
+}
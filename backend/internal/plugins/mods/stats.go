@@ -0,0 +1,181 @@
+package mods
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// entry is one "new entry" block of a Larian stats file
+type entry struct {
+	name   string
+	typ    string
+	using  string
+	fields map[string]string
+}
+
+// parseStats reads the entries of Larian stats files, as DOS2 and BG3 mods
+// ship them under Stats/Generated/Data:
+//
+//	new entry "Projectile_Fireball"
+//	type "SkillData"
+//	using "Projectile_BaseFireball"
+//	data "ActionPoints" "2"
+//
+// Entries are returned in file order, with the fields of the entries they
+// use from the same files filled in.
+func parseStats(data []byte) ([]entry, error) {
+	var entries []entry
+	var cur *entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		keyword, rest, _ := strings.Cut(line, " ")
+		args := quoted(rest)
+		switch keyword {
+		case "new":
+			if len(args) != 1 || !strings.HasPrefix(rest, "entry") {
+				return nil, fmt.Errorf("line %d: expected new entry \"Name\"", n)
+			}
+			entries = append(entries, entry{name: args[0], fields: make(map[string]string)})
+			cur = &entries[len(entries)-1]
+		case "type", "using", "data":
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: %s outside an entry", n, keyword)
+			}
+			switch {
+			case keyword == "type" && len(args) == 1:
+				cur.typ = args[0]
+			case keyword == "using" && len(args) == 1:
+				cur.using = args[0]
+			case keyword == "data" && len(args) == 2:
+				cur.fields[args[0]] = args[1]
+			default:
+				return nil, fmt.Errorf("line %d: malformed %s", n, keyword)
+			}
+		default:
+			// Other statements, such as BG3's key lines, carry nothing we map
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no stats entries found")
+	}
+
+	byName := make(map[string]*entry, len(entries))
+	for i := range entries {
+		byName[entries[i].name] = &entries[i]
+	}
+	for i := range entries {
+		inherit(&entries[i], byName, 0)
+	}
+	return entries, nil
+}
+
+// inherit copies the fields and type an entry lacks from the entry it uses.
+// Parents from the base game are not in the mod's files and are skipped.
+func inherit(e *entry, byName map[string]*entry, depth int) {
+	parent := byName[e.using]
+	if parent == nil || parent == e || depth > 16 {
+		return
+	}
+	inherit(parent, byName, depth+1)
+	if e.typ == "" {
+		e.typ = parent.typ
+	}
+	for k, v := range parent.fields {
+		if _, ok := e.fields[k]; !ok {
+			e.fields[k] = v
+		}
+	}
+}
+
+// quoted returns the double-quoted strings of a stats line
+func quoted(s string) []string {
+	var out []string
+	for {
+		start := strings.IndexByte(s, '"')
+		if start < 0 {
+			return out
+		}
+		end := strings.IndexByte(s[start+1:], '"')
+		if end < 0 {
+			return out
+		}
+		out = append(out, s[start+1:start+1+end])
+		s = s[start+end+2:]
+	}
+}
+
+// ParseLocalization reads a Larian localization file, mapping content
+// handles such as h1a2b3c4d... to their text
+func ParseLocalization(data []byte) (map[string]string, error) {
+	var list struct {
+		Content []struct {
+			UID  string `xml:"contentuid,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"content"`
+	}
+	if err := xml.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(list.Content))
+	for _, c := range list.Content {
+		names[c.UID] = strings.TrimSpace(c.Text)
+	}
+	return names, nil
+}
+
+// displayName returns an entry's name: its localized DisplayName, DOS2's
+// DisplayNameRef, or else the entry name without its prefix, as in
+// "Flaming Daggers" for Projectile_FlamingDaggers
+func displayName(e entry, names map[string]string) string {
+	if handle, _, _ := strings.Cut(e.fields["DisplayName"], ";"); handle != "" {
+		if text := names[handle]; text != "" {
+			return text
+		}
+		if !isHandle(handle) && !strings.HasSuffix(handle, "_DisplayName") {
+			return handle
+		}
+	}
+	if ref := strings.TrimSpace(e.fields["DisplayNameRef"]); ref != "" {
+		return ref
+	}
+	name := e.name
+	if _, rest, ok := strings.Cut(name, "_"); ok && rest != "" {
+		name = rest
+	}
+	var b strings.Builder
+	prev := ' '
+	for _, r := range strings.ReplaceAll(name, "_", " ") {
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// isHandle reports whether s looks like a localization handle: h followed
+// by hex digits and g separators
+func isHandle(s string) bool {
+	if len(s) < 16 || s[0] != 'h' {
+		return false
+	}
+	for _, r := range s[1:] {
+		if !strings.ContainsRune("0123456789abcdefg", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+// Package plugins keeps game-specific behaviour out of the importers, API
+// and exports. A game package registers its plugins from init, and binaries
+// enable the built-in games by importing internal/plugins/games.
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Plugin is game-specific behaviour. A plugin implements one or more of
+// ImporterPlugin, ItemEnricher and ExportDecorator.
+type Plugin interface {
+	// GameID is the game the plugin applies to
+	GameID() string
+}
+
+// ImporterPlugin reads a scraped data export into catalog items
+type ImporterPlugin interface {
+	Plugin
+	// Name identifies the importer to cmd/import, as in "dos2-skills"
+	Name() string
+	// SheetID is the sheet the importer fills
+	SheetID() string
+	// Replace reports whether an import replaces the items of the sheet
+	// rather than updating them
+	Replace() bool
+	// Import parses data into items. existing holds the sheet's current
+	// items, so imports can keep their IDs and fields the data lacks.
+	Import(data []byte, existing []models.Item) ([]models.Item, error)
+}
+
+// ItemEnricher derives Data fields of a game's items, such as typed stats
+// parsed from an infobox, whenever items are imported or edited
+type ItemEnricher interface {
+	Plugin
+	Enrich(item *models.Item)
+}
+
+// ExportDecorator adds game-specific detail to exported tier lists
+type ExportDecorator interface {
+	Plugin
+	// ExportLabel returns a short note shown under an item's name, or ""
+	ExportLabel(item models.Item) string
+}
+
+var (
+	mu         sync.RWMutex
+	importers  = make(map[string]ImporterPlugin)
+	enrichers  = make(map[string][]ItemEnricher)
+	decorators = make(map[string][]ExportDecorator)
+)
+
+// Register adds a plugin. It panics when p implements none of the plugin
+// interfaces or an importer of the same name is registered.
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registered := false
+	if imp, ok := p.(ImporterPlugin); ok {
+		if _, dup := importers[imp.Name()]; dup {
+			panic(fmt.Sprintf("plugins: importer %q registered twice", imp.Name()))
+		}
+		importers[imp.Name()] = imp
+		registered = true
+	}
+	if e, ok := p.(ItemEnricher); ok {
+		enrichers[p.GameID()] = append(enrichers[p.GameID()], e)
+		registered = true
+	}
+	if d, ok := p.(ExportDecorator); ok {
+		decorators[p.GameID()] = append(decorators[p.GameID()], d)
+		registered = true
+	}
+	if !registered {
+		panic(fmt.Sprintf("plugins: %T implements no plugin interface", p))
+	}
+}
+
+// Importer returns the importer registered under name, or nil
+func Importer(name string) ImporterPlugin {
+	mu.RLock()
+	defer mu.RUnlock()
+	return importers[name]
+}
+
+// Importers returns every registered importer, sorted by name
+func Importers() []ImporterPlugin {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]ImporterPlugin, 0, len(importers))
+	for _, imp := range importers {
+		list = append(list, imp)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+// Enrich runs the enrichers of item's game on it
+func Enrich(item *models.Item) {
+	mu.RLock()
+	list := enrichers[item.GameID]
+	mu.RUnlock()
+	for _, e := range list {
+		e.Enrich(item)
+	}
+}
+
+// EnrichAll runs Enrich on each item
+func EnrichAll(items []models.Item) {
+	for i := range items {
+		Enrich(&items[i])
+	}
+}
+
+// ExportLabel joins the labels the decorators of item's game give it
+func ExportLabel(item models.Item) string {
+	mu.RLock()
+	list := decorators[item.GameID]
+	mu.RUnlock()
+	var labels []string
+	for _, d := range list {
+		if label := d.ExportLabel(item); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return strings.Join(labels, " · ")
+}
@@ -0,0 +1,137 @@
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/meur/tierforge/internal/models"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// maxDepth bounds how deeply tables returned by scripts may nest, which
+// also stops tables that contain themselves
+const maxDepth = 32
+
+// toLua converts a decoded JSON value to Lua
+func toLua(L *lua.LState, v interface{}) lua.LValue {
+	switch v := v.(type) {
+	case bool:
+		return lua.LBool(v)
+	case float64:
+		return lua.LNumber(v)
+	case string:
+		return lua.LString(v)
+	case []interface{}:
+		t := L.CreateTable(len(v), 0)
+		for i, e := range v {
+			t.RawSetInt(i+1, toLua(L, e))
+		}
+		return t
+	case map[string]interface{}:
+		t := L.CreateTable(0, len(v))
+		for k, e := range v {
+			t.RawSetString(k, toLua(L, e))
+		}
+		return t
+	}
+	return lua.LNil
+}
+
+// fromLua converts a Lua value to the JSON value it stands for. Tables with
+// the keys 1 to n are arrays and other tables objects; empty tables are
+// null, as Lua cannot tell an empty array from an empty object.
+func fromLua(v lua.LValue, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, errors.New("tables nest too deeply")
+	}
+	switch v := v.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(v), nil
+	case lua.LNumber:
+		return float64(v), nil
+	case lua.LString:
+		return string(v), nil
+	case *lua.LTable:
+		keys := 0
+		v.ForEach(func(lua.LValue, lua.LValue) { keys++ })
+		if keys == 0 {
+			return nil, nil
+		}
+		if n := v.MaxN(); n == keys {
+			arr := make([]interface{}, n)
+			for i := range arr {
+				e, err := fromLua(v.RawGetInt(i+1), depth+1)
+				if err != nil {
+					return nil, err
+				}
+				arr[i] = e
+			}
+			return arr, nil
+		}
+		obj := make(map[string]interface{}, keys)
+		var err error
+		v.ForEach(func(key, value lua.LValue) {
+			if err != nil {
+				return
+			}
+			var k string
+			switch key := key.(type) {
+			case lua.LString:
+				k = string(key)
+			case lua.LNumber:
+				k = key.String()
+			default:
+				err = fmt.Errorf("table key of type %s", key.Type())
+				return
+			}
+			obj[k], err = fromLua(value, depth+1)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("value of type %s", v.Type())
+}
+
+// itemToLua converts an item to a table shaped like its JSON
+func itemToLua(L *lua.LState, item models.Item) (lua.LValue, error) {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return toLua(L, v), nil
+}
+
+// toItem converts an item table returned by a script
+func toItem(v lua.LValue) (models.Item, error) {
+	var item models.Item
+	if _, ok := v.(*lua.LTable); !ok {
+		return item, fmt.Errorf("returned a %s instead of an item table", v.Type())
+	}
+	obj, err := fromLua(v, 0)
+	if err != nil {
+		return item, fmt.Errorf("item: %w", err)
+	}
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return item, fmt.Errorf("item: %w", err)
+	}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return item, fmt.Errorf("item: %w", err)
+	}
+	if item.Name == "" {
+		return item, errors.New("item has no name")
+	}
+	if item.Data == nil {
+		item.Data = map[string]interface{}{}
+	}
+	return item, nil
+}
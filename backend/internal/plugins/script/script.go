@@ -0,0 +1,324 @@
+// Package script runs the Lua scripts self-hosters attach to a game, so
+// they can import and enrich its items without rebuilding the server.
+//
+// A script defines either or both of two global functions:
+//
+//	function import(record, key) -- returns an item table, or nil to skip
+//	function enrich(item)        -- edits item, or returns a new one
+//
+// Items are tables shaped like the item JSON: id, name, name_ru, category,
+// icon, game_version, spoiler, tags and data. The helpers slug(...) and
+// existing(id_or_name) build IDs and look up the sheet's current items.
+//
+// Scripts run in a sandbox with only the base, string, table and math
+// libraries, without file, OS or module access, and each call is stopped
+// after CallTimeout.
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/plugins"
+	"github.com/meur/tierforge/internal/slug"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	// MaxSize bounds the source of a script
+	MaxSize = 64 << 10
+	// CallTimeout bounds one call of a script function
+	CallTimeout = 2 * time.Second
+	// maxString bounds the strings string.rep builds
+	maxString = 1 << 20
+)
+
+// unsafeGlobals are base library functions scripts may not use: they load
+// code or files, or reach outside the function environment
+var unsafeGlobals = []string{
+	"collectgarbage", "dofile", "getfenv", "load", "loadfile", "loadstring",
+	"module", "newproxy", "require", "setfenv", "_printregs",
+}
+
+// Script is a compiled game script. It keeps its global state between
+// calls and is not safe for concurrent use.
+type Script struct {
+	gameID    string
+	L         *lua.LState
+	hasImport bool
+	hasEnrich bool
+
+	// existing holds the sheet's items during Import, by ID and by name
+	existing map[string]models.Item
+}
+
+// Compile parses source and runs its top level, which defines the script's
+// functions. Call Close when done with the script.
+func Compile(gameID, source string) (*Script, error) {
+	if len(source) > MaxSize {
+		return nil, fmt.Errorf("script is larger than %d KB", MaxSize>>10)
+	}
+	name := gameID + ".lua"
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, errors.New(strings.TrimSpace(err.Error()))
+	}
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Script{gameID: gameID}
+	s.L = s.newState()
+	if err := s.call(s.L.NewFunctionFromProto(proto), 0); err != nil {
+		s.L.Close()
+		return nil, err
+	}
+	_, s.hasImport = s.L.GetGlobal("import").(*lua.LFunction)
+	_, s.hasEnrich = s.L.GetGlobal("enrich").(*lua.LFunction)
+	if !s.hasImport && !s.hasEnrich {
+		s.L.Close()
+		return nil, errors.New("script defines neither import nor enrich")
+	}
+	return s, nil
+}
+
+// Close releases the script's interpreter
+func (s *Script) Close() {
+	s.L.Close()
+}
+
+// HasImport reports whether the script defines import
+func (s *Script) HasImport() bool { return s.hasImport }
+
+// HasEnrich reports whether the script defines enrich
+func (s *Script) HasEnrich() bool { return s.hasEnrich }
+
+// newState returns an interpreter with the sandboxed libraries and helpers
+func (s *Script) newState() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true, CallStackSize: 256, RegistryMaxSize: 1 << 20})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeGlobals {
+		L.SetGlobal(name, lua.LNil)
+	}
+	str := L.GetGlobal(lua.StringLibName)
+	L.SetField(str, "dump", lua.LNil)
+	L.SetField(str, "rep", L.NewFunction(strRep))
+
+	L.SetGlobal("print", L.NewFunction(s.print))
+	L.SetGlobal("slug", L.NewFunction(luaSlug))
+	L.SetGlobal("existing", L.NewFunction(s.lookupExisting))
+	return L
+}
+
+// call calls fn with args, leaving nret results on the stack. Errors carry
+// the script's message without the interpreter's stack trace.
+func (s *Script) call(fn lua.LValue, nret int, args ...lua.LValue) error {
+	ctx, cancel := context.WithTimeout(context.Background(), CallTimeout)
+	defer cancel()
+	s.L.SetContext(ctx)
+	defer s.L.RemoveContext()
+	err := s.L.CallByParam(lua.P{Fn: fn, NRet: nret, Protect: true}, args...)
+	var apiErr *lua.ApiError
+	if errors.As(err, &apiErr) && apiErr.Object != nil {
+		return errors.New(apiErr.Object.String())
+	}
+	return err
+}
+
+// Import runs the script's import function on each record of data, a JSON
+// array or object of records, and returns the items of sheetID it made.
+// Records of an object are visited in key order and passed their key.
+func (s *Script) Import(sheetID string, data []byte, existing []models.Item) ([]models.Item, error) {
+	if !s.hasImport {
+		return nil, errors.New("script defines no import function")
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	type record struct {
+		key   lua.LValue
+		value interface{}
+	}
+	var records []record
+	switch v := raw.(type) {
+	case []interface{}:
+		for i, r := range v {
+			records = append(records, record{lua.LNumber(i + 1), r})
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			records = append(records, record{lua.LString(k), v[k]})
+		}
+	default:
+		return nil, errors.New("data must be a JSON array or object of records")
+	}
+
+	s.existing = make(map[string]models.Item, 2*len(existing))
+	for _, item := range existing {
+		if _, dup := s.existing[item.Name]; !dup {
+			s.existing[item.Name] = item
+		}
+	}
+	for _, item := range existing {
+		s.existing[item.ID] = item
+	}
+	defer func() { s.existing = nil }()
+
+	fn := s.L.GetGlobal("import")
+	items := make([]models.Item, 0, len(records))
+	for _, rec := range records {
+		if err := s.call(fn, 1, toLua(s.L, rec.value), rec.key); err != nil {
+			return nil, fmt.Errorf("record %s: %w", rec.key, err)
+		}
+		ret := s.L.Get(-1)
+		s.L.Pop(1)
+		if ret == lua.LNil {
+			continue
+		}
+		item, err := toItem(ret)
+		if err != nil {
+			return nil, fmt.Errorf("record %s: %w", rec.key, err)
+		}
+		item.GameID, item.SheetID = s.gameID, sheetID
+		if item.ID == "" {
+			item.ID = slug.ItemID(s.gameID, sheetID, item.Name)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Enrich runs the script's enrich function on item. The item keeps its ID,
+// game and sheet.
+func (s *Script) Enrich(item *models.Item) error {
+	if !s.hasEnrich {
+		return nil
+	}
+	in, err := itemToLua(s.L, *item)
+	if err != nil {
+		return err
+	}
+	if err := s.call(s.L.GetGlobal("enrich"), 1, in); err != nil {
+		return err
+	}
+	ret := s.L.Get(-1)
+	s.L.Pop(1)
+	if ret == lua.LNil {
+		ret = in
+	}
+	out, err := toItem(ret)
+	if err != nil {
+		return err
+	}
+	out.ID, out.GameID, out.SheetID = item.ID, item.GameID, item.SheetID
+	*item = out
+	return nil
+}
+
+// EnrichAll runs Enrich on each item, stopping at the first error
+func (s *Script) EnrichAll(items []models.Item) error {
+	for i := range items {
+		if err := s.Enrich(&items[i]); err != nil {
+			return fmt.Errorf("item %s: %w", items[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// Importer returns an importer running the script's import function for a
+// sheet, for use wherever a registered importer would be
+func (s *Script) Importer(sheetID string, replace bool) plugins.ImporterPlugin {
+	return importer{s: s, sheetID: sheetID, replace: replace}
+}
+
+type importer struct {
+	s       *Script
+	sheetID string
+	replace bool
+}
+
+func (i importer) GameID() string  { return i.s.gameID }
+func (i importer) Name() string    { return "script:" + i.s.gameID + "/" + i.sheetID }
+func (i importer) SheetID() string { return i.sheetID }
+func (i importer) Replace() bool   { return i.replace }
+
+func (i importer) Import(data []byte, existing []models.Item) ([]models.Item, error) {
+	return i.s.Import(i.sheetID, data, existing)
+}
+
+// print logs its arguments, tagged with the game
+func (s *Script) print(L *lua.LState) int {
+	parts := make([]string, L.GetTop())
+	for i := range parts {
+		parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+	}
+	log.Printf("script %s: %s", s.gameID, strings.Join(parts, "\t"))
+	return 0
+}
+
+// lookupExisting returns the current item with the given ID or name
+func (s *Script) lookupExisting(L *lua.LState) int {
+	item, ok := s.existing[L.CheckString(1)]
+	if !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+	lv, err := itemToLua(L, item)
+	if err != nil {
+		L.RaiseError("%s", err.Error())
+	}
+	L.Push(lv)
+	return 1
+}
+
+// luaSlug is slug.Join for scripts
+func luaSlug(L *lua.LState) int {
+	parts := make([]string, L.GetTop())
+	for i := range parts {
+		parts[i] = L.CheckString(i + 1)
+	}
+	L.Push(lua.LString(slug.Join(parts...)))
+	return 1
+}
+
+// strRep is string.rep, refusing to build strings over maxString bytes
+func strRep(L *lua.LState) int {
+	str := L.CheckString(1)
+	n := L.CheckInt(2)
+	if n <= 0 || str == "" {
+		L.Push(lua.LString(""))
+		return 1
+	}
+	if len(str) > maxString/n {
+		L.RaiseError("string.rep result is longer than %d bytes", maxString)
+	}
+	L.Push(lua.LString(strings.Repeat(str, n)))
+	return 1
+}
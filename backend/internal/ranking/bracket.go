@@ -0,0 +1,129 @@
+package ranking
+
+import (
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SeedBracket lays out a single-elimination bracket for seeds, top seed
+// first. The field is padded to a power of two with byes, which go to the top
+// seeds, and seeds are placed so the top two can only meet in the final. All
+// matches of all rounds are returned; byes are already decided and their
+// winners moved into the second round.
+func SeedBracket(seeds []string) (int, []models.BracketMatch) {
+	size, rounds := 1, 0
+	for size < len(seeds) {
+		size *= 2
+		rounds++
+	}
+
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, s := range order {
+			next = append(next, s, len(order)*2+1-s)
+		}
+		order = next
+	}
+
+	matches := make([]models.BracketMatch, 0, size-1)
+	for r, n := 1, size/2; r <= rounds; r, n = r+1, n/2 {
+		for slot := 0; slot < n; slot++ {
+			matches = append(matches, models.BracketMatch{Round: r, Slot: slot})
+		}
+	}
+	seedAt := func(pos int) *string {
+		if order[pos] > len(seeds) {
+			return nil
+		}
+		return &seeds[order[pos]-1]
+	}
+	for slot := 0; slot < size/2; slot++ {
+		m := &matches[slot]
+		m.ItemA, m.ItemB = seedAt(slot*2), seedAt(slot*2+1)
+		if m.ItemB == nil {
+			m.WinnerID = m.ItemA
+			if rounds > 1 {
+				r, s, sideA := NextBracketMatch(m.Round, m.Slot)
+				setBracketSide(&matches[bracketIndex(size, r, s)], m.ItemA, sideA)
+			}
+		}
+	}
+	return rounds, matches
+}
+
+// NextBracketMatch returns the match the winner of a match advances to and
+// whether it plays there as item A
+func NextBracketMatch(round, slot int) (int, int, bool) {
+	return round + 1, slot / 2, slot%2 == 0
+}
+
+// bracketIndex locates a match in SeedBracket's round-by-round layout
+func bracketIndex(size, round, slot int) int {
+	idx, n := 0, size/2
+	for r := 1; r < round; r++ {
+		idx += n
+		n /= 2
+	}
+	return idx + slot
+}
+
+func setBracketSide(m *models.BracketMatch, itemID *string, sideA bool) {
+	if sideA {
+		m.ItemA = itemID
+	} else {
+		m.ItemB = itemID
+	}
+}
+
+// BracketStandings places every item that is out of the bracket, plus the
+// champion once the final is decided. Items still in play are left out.
+func BracketStandings(b *models.Bracket) []models.BracketStanding {
+	wins := make(map[string]int)
+	out := make(map[string]int)
+	for _, m := range b.Matches {
+		if m.WinnerID == nil {
+			continue
+		}
+		wins[*m.WinnerID]++
+		for _, id := range []*string{m.ItemA, m.ItemB} {
+			if id != nil && *id != *m.WinnerID {
+				out[*id] = m.Round
+			}
+		}
+	}
+
+	seedRank := make(map[string]int, len(b.Seeds))
+	for i, id := range b.Seeds {
+		seedRank[id] = i
+	}
+	standings := make([]models.BracketStanding, 0, len(out)+1)
+	for id, round := range out {
+		standings = append(standings, models.BracketStanding{ItemID: id, Place: 1<<(b.Rounds-round) + 1, Wins: wins[id]})
+	}
+	if b.Champion != nil {
+		standings = append(standings, models.BracketStanding{ItemID: *b.Champion, Place: 1, Wins: wins[*b.Champion]})
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Place != standings[j].Place {
+			return standings[i].Place < standings[j].Place
+		}
+		return seedRank[standings[i].ItemID] < seedRank[standings[j].ItemID]
+	})
+	return standings
+}
+
+// BracketScores maps each placed item to the share of rounds it survived,
+// 1 being the champion, for use with Bucket
+func BracketScores(b *models.Bracket) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, st := range BracketStandings(b) {
+		score := 1.0
+		if b.Rounds > 0 {
+			score = float64(st.Wins) / float64(b.Rounds)
+		}
+		scores[st.ItemID] = score
+	}
+	return scores
+}
@@ -0,0 +1,85 @@
+package ranking
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeedBracket(t *testing.T) {
+	// Matches are written round by round as "A v B", "-" standing for an
+	// empty side, followed by " > winner" once decided
+	tests := []struct {
+		name    string
+		seeds   []string
+		rounds  int
+		matches []string
+	}{
+		{"empty", nil, 0, []string{}},
+		{"lone seed", []string{"1"}, 0, []string{}},
+		{"two seeds", []string{"1", "2"}, 1, []string{"1 v 2"}},
+		{"full field", []string{"1", "2", "3", "4"}, 2, []string{"1 v 4", "2 v 3", "- v -"}},
+		{"top seed gets the bye", []string{"1", "2", "3"}, 2, []string{"1 v - > 1", "2 v 3", "1 v -"}},
+		{"byes fill the second round", []string{"1", "2", "3", "4", "5"}, 3, []string{
+			"1 v - > 1", "4 v 5", "2 v - > 2", "3 v - > 3",
+			"1 v -", "2 v 3",
+			"- v -",
+		}},
+		{"top two meet in the final", []string{"1", "2", "3", "4", "5", "6", "7", "8"}, 3, []string{
+			"1 v 8", "4 v 5", "2 v 7", "3 v 6",
+			"- v -", "- v -",
+			"- v -",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rounds, matches := SeedBracket(tt.seeds)
+			got := make([]string, len(matches))
+			for i, m := range matches {
+				side := func(id *string) string {
+					if id == nil {
+						return "-"
+					}
+					return *id
+				}
+				got[i] = side(m.ItemA) + " v " + side(m.ItemB)
+				if m.WinnerID != nil {
+					got[i] += " > " + *m.WinnerID
+				}
+			}
+			if rounds != tt.rounds || !reflect.DeepEqual(got, tt.matches) {
+				t.Errorf("SeedBracket = %d rounds %q; want %d rounds %q", rounds, got, tt.rounds, tt.matches)
+			}
+			// Matches are laid out round by round, slots in order
+			round, slot := 1, 0
+			for _, m := range matches {
+				if m.Round != round {
+					round, slot = m.Round, 0
+				}
+				if m.Slot != slot {
+					t.Errorf("round %d match at slot %d, want %d", m.Round, m.Slot, slot)
+				}
+				slot++
+			}
+		})
+	}
+}
+
+func TestNextBracketMatch(t *testing.T) {
+	tests := []struct {
+		round, slot int
+		next, to    int
+		sideA       bool
+	}{
+		{1, 0, 2, 0, true},
+		{1, 1, 2, 0, false},
+		{1, 6, 2, 3, true},
+		{2, 3, 3, 1, false},
+	}
+	for _, tt := range tests {
+		next, to, sideA := NextBracketMatch(tt.round, tt.slot)
+		if next != tt.next || to != tt.to || sideA != tt.sideA {
+			t.Errorf("NextBracketMatch(%d, %d) = %d, %d, %v; want %d, %d, %v",
+				tt.round, tt.slot, next, to, sideA, tt.next, tt.to, tt.sideA)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package ranking
+
+import "github.com/meur/tierforge/internal/models"
+
+// Agreement scores each list by how closely it agrees with the consensus of
+// all of them: 1 minus the mean distance between an item's score in the list
+// and its mean score across the lists. Items the consensus holds but a list
+// left unranked count as the greatest distance. The consensus scores are
+// returned along with one agreement per list.
+func Agreement(lists []*models.TierList) (map[string]float64, []float64) {
+	consensus := weightedScores(lists, nil)
+	agreement := make([]float64, len(lists))
+	if len(consensus) == 0 {
+		for i := range agreement {
+			agreement[i] = 1
+		}
+		return consensus, agreement
+	}
+	for i, tl := range lists {
+		scores := Scores(tl)
+		distance := 0.0
+		for id, mean := range consensus {
+			score, ok := scores[id]
+			if !ok {
+				distance++
+				continue
+			}
+			if score > mean {
+				distance += score - mean
+			} else {
+				distance += mean - score
+			}
+		}
+		agreement[i] = 1 - distance/float64(len(consensus))
+	}
+	return consensus, agreement
+}
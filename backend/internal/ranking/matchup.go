@@ -0,0 +1,135 @@
+package ranking
+
+import (
+	"math"
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// bradleyTerryIterations bounds the MM solver; it converges well before this
+// for the few hundred comparisons a session produces
+const bradleyTerryIterations = 200
+
+// BradleyTerry fits a Bradley-Terry model to pairwise results and returns
+// each compared item's score scaled to 0 (weakest) .. 1 (strongest). Every
+// item gets one virtual win and loss against an average opponent so items
+// that never lost still get a finite strength.
+func BradleyTerry(results []models.MatchupResult) []models.MatchupStanding {
+	wins := make(map[string]int)
+	losses := make(map[string]int)
+	games := make(map[[2]string]int)
+	for _, r := range results {
+		wins[r.WinnerID]++
+		losses[r.LoserID]++
+		games[pairKey(r.WinnerID, r.LoserID)]++
+	}
+
+	ids := make([]string, 0, len(wins)+len(losses))
+	seen := make(map[string]bool)
+	for _, r := range results {
+		for _, id := range []string{r.WinnerID, r.LoserID} {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return []models.MatchupStanding{}
+	}
+
+	strength := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		strength[id] = 1
+	}
+	for iter := 0; iter < bradleyTerryIterations; iter++ {
+		next := make(map[string]float64, len(ids))
+		logSum := 0.0
+		for _, i := range ids {
+			// Virtual win and loss against an opponent of strength 1
+			denom := 2 / (strength[i] + 1)
+			for _, j := range ids {
+				if n := games[pairKey(i, j)]; n > 0 && i != j {
+					denom += float64(n) / (strength[i] + strength[j])
+				}
+			}
+			next[i] = float64(wins[i]+1) / denom
+			logSum += math.Log(next[i])
+		}
+		// Keep the geometric mean at 1 so strengths stay comparable to the virtual opponent
+		scale := math.Exp(logSum / float64(len(ids)))
+		for _, i := range ids {
+			strength[i] = next[i] / scale
+		}
+	}
+
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, id := range ids {
+		l := math.Log(strength[id])
+		lo, hi = math.Min(lo, l), math.Max(hi, l)
+	}
+
+	standings := make([]models.MatchupStanding, 0, len(ids))
+	for _, id := range ids {
+		score := 1.0
+		if hi > lo {
+			score = (math.Log(strength[id]) - lo) / (hi - lo)
+		}
+		standings = append(standings, models.MatchupStanding{
+			ItemID: id,
+			Score:  score,
+			Wins:   wins[id],
+			Losses: losses[id],
+		})
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		return standings[i].ItemID < standings[j].ItemID
+	})
+	return standings
+}
+
+// pairKey identifies an unordered pair of items
+func pairKey(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// NextPair chooses the two items to compare next: the least compared item
+// against the least compared item it has not met yet. rnd breaks ties and
+// must return a value in [0, n). It returns false if fewer than two items exist.
+func NextPair(itemIDs []string, results []models.MatchupResult, rnd func(n int) int) (string, string, bool) {
+	if len(itemIDs) < 2 {
+		return "", "", false
+	}
+
+	counts := make(map[string]int)
+	met := make(map[[2]string]bool)
+	for _, r := range results {
+		counts[r.WinnerID]++
+		counts[r.LoserID]++
+		met[pairKey(r.WinnerID, r.LoserID)] = true
+	}
+
+	shuffled := make([]string, len(itemIDs))
+	copy(shuffled, itemIDs)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := rnd(i + 1)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	sort.SliceStable(shuffled, func(i, j int) bool { return counts[shuffled[i]] < counts[shuffled[j]] })
+
+	first := shuffled[0]
+	for _, id := range shuffled[1:] {
+		if !met[pairKey(first, id)] {
+			return first, id, true
+		}
+	}
+	// Every pairing with first has been played; repeat the least played one
+	return first, shuffled[1], true
+}
@@ -0,0 +1,97 @@
+package ranking
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// results builds matchup results from winner, loser pairs
+func results(pairs ...string) []models.MatchupResult {
+	out := make([]models.MatchupResult, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		out = append(out, models.MatchupResult{WinnerID: pairs[i], LoserID: pairs[i+1]})
+	}
+	return out
+}
+
+func TestBradleyTerryOrdering(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []models.MatchupResult
+		order   []string
+	}{
+		{"no results", nil, []string{}},
+		{"one result", results("a", "b"), []string{"a", "b"}},
+		{"transitive chain", results("b", "c", "a", "b", "a", "c"), []string{"a", "b", "c"}},
+		{"more wins in a rivalry", results("a", "b", "b", "a", "a", "b", "a", "b"), []string{"a", "b"}},
+		// a and b are both 1-0, but a beat the item that beat everyone else
+		{"stronger opponent", results("c", "d", "c", "e", "c", "d", "a", "c", "b", "d"), []string{"a", "c", "b", "e", "d"}},
+		{"split rivalry ties by ID", results("b", "a", "a", "b"), []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standings := BradleyTerry(tt.results)
+			order := make([]string, len(standings))
+			for i, s := range standings {
+				order[i] = s.ItemID
+				if s.Score < 0 || s.Score > 1 {
+					t.Errorf("%s scored %v, want 0..1", s.ItemID, s.Score)
+				}
+				if i > 0 && s.Score > standings[i-1].Score {
+					t.Errorf("%s scored %v above %s's %v", s.ItemID, s.Score, standings[i-1].ItemID, standings[i-1].Score)
+				}
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("order = %v, want %v", order, tt.order)
+			}
+		})
+	}
+}
+
+func TestBradleyTerryScalesScoresAndCountsRecords(t *testing.T) {
+	standings := BradleyTerry(results("a", "b", "a", "c", "b", "c", "c", "b"))
+	want := map[string][2]int{"a": {2, 0}, "b": {1, 2}, "c": {1, 2}}
+	for _, s := range standings {
+		if rec := [2]int{s.Wins, s.Losses}; rec != want[s.ItemID] {
+			t.Errorf("%s record = %v, want %v", s.ItemID, rec, want[s.ItemID])
+		}
+	}
+	if first, last := standings[0], standings[len(standings)-1]; first.Score != 1 || last.Score != 0 {
+		t.Errorf("scores run %v..%v, want 1..0", first.Score, last.Score)
+	}
+
+	// Equal strengths all score 1
+	for _, s := range BradleyTerry(results("a", "b", "b", "a")) {
+		if s.Score != 1 {
+			t.Errorf("%s scored %v in a split rivalry, want 1", s.ItemID, s.Score)
+		}
+	}
+}
+
+func TestNextPair(t *testing.T) {
+	first := func(int) int { return 0 }
+	tests := []struct {
+		name    string
+		items   []string
+		results []models.MatchupResult
+		want    [2]string
+		ok      bool
+	}{
+		{"no items", nil, nil, [2]string{}, false},
+		{"one item", []string{"a"}, nil, [2]string{}, false},
+		{"fresh session", []string{"a", "b", "c"}, nil, [2]string{"b", "c"}, true},
+		{"least compared first", []string{"a", "b", "c"}, results("a", "b"), [2]string{"c", "b"}, true},
+		{"skips met opponents", []string{"a", "b", "c", "d"}, results("a", "b", "c", "d", "a", "c", "b", "d"), [2]string{"b", "c"}, true},
+		{"repeats once everyone met", []string{"a", "b"}, results("a", "b"), [2]string{"b", "a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b, ok := NextPair(tt.items, tt.results, first)
+			if got := [2]string{a, b}; got != tt.want || ok != tt.ok {
+				t.Errorf("NextPair = %v, %v; want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Merge combines several tier lists into the tiers of template using the
+// given strategy. weights holds one weight per list and is only used by the
+// weighted strategy.
+func Merge(lists []*models.TierList, weights []float64, strategy string, template []models.Tier) ([]models.Tier, error) {
+	switch strategy {
+	case models.MergeAverage:
+		return Bucket(weightedScores(lists, nil), template), nil
+	case models.MergeWeighted:
+		return Bucket(weightedScores(lists, weights), template), nil
+	case models.MergeMajority:
+		return majority(lists, template), nil
+	}
+	return nil, fmt.Errorf("unknown merge strategy %q", strategy)
+}
+
+// weightedScores averages each item's score over the lists that ranked it.
+// A nil weights slice weighs every list equally.
+func weightedScores(lists []*models.TierList, weights []float64) map[string]float64 {
+	sums := make(map[string]float64)
+	totals := make(map[string]float64)
+	for i, tl := range lists {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		for id, score := range Scores(tl) {
+			sums[id] += score * w
+			totals[id] += w
+		}
+	}
+
+	scores := make(map[string]float64, len(sums))
+	for id, sum := range sums {
+		if totals[id] > 0 {
+			scores[id] = sum / totals[id]
+		}
+	}
+	return scores
+}
+
+// majority places each item in the template tier most lists put it in. Lists
+// with a different number of tiers are mapped onto the template by score.
+// Ties go to the tier closest to the item's average placement.
+func majority(lists []*models.TierList, template []models.Tier) []models.Tier {
+	tiers := SortedTiers(template)
+	for i := range tiers {
+		tiers[i].Items = []string{}
+	}
+	if len(tiers) == 0 {
+		return tiers
+	}
+	last := len(tiers) - 1
+
+	votes := make(map[string]map[int]int)
+	for _, tl := range lists {
+		for id, score := range Scores(tl) {
+			if votes[id] == nil {
+				votes[id] = make(map[int]int)
+			}
+			votes[id][int(math.Round((1-score)*float64(last)))]++
+		}
+	}
+	average := weightedScores(lists, nil)
+
+	ids := make([]string, 0, len(votes))
+	for id := range votes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if average[ids[i]] != average[ids[j]] {
+			return average[ids[i]] > average[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	for _, id := range ids {
+		mean := (1 - average[id]) * float64(last)
+		// Tiers are visited top first, so a tie equally far from the mean
+		// goes to the higher tier whatever order the votes came in
+		best, bestVotes := -1, 0
+		for idx := 0; idx <= last; idx++ {
+			n := votes[id][idx]
+			if n == 0 {
+				continue
+			}
+			closer := best >= 0 && math.Abs(float64(idx)-mean) < math.Abs(float64(best)-mean)
+			if n > bestVotes || (n == bestVotes && closer) {
+				best, bestVotes = idx, n
+			}
+		}
+		tiers[best].Items = append(tiers[best].Items, id)
+	}
+	return tiers
+}
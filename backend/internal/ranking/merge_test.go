@@ -0,0 +1,135 @@
+package ranking
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// tierList builds a tier list whose tiers, top first, hold items
+func tierList(items ...[]string) *models.TierList {
+	tl := &models.TierList{}
+	for i, ids := range items {
+		tl.Tiers = append(tl.Tiers, models.Tier{ID: string(rune('a' + i)), Order: i, Items: ids})
+	}
+	return tl
+}
+
+func TestMerge(t *testing.T) {
+	// The template lists its tiers out of order; merged tiers come back
+	// sorted top first
+	template := []models.Tier{
+		{ID: "b", Order: 2}, {ID: "s", Order: 0}, {ID: "c", Order: 3}, {ID: "a", Order: 1, Items: []string{"stale"}},
+	}
+	tests := []struct {
+		name     string
+		strategy string
+		lists    []*models.TierList
+		weights  []float64
+		want     [][]string
+	}{
+		{
+			"average", models.MergeAverage,
+			[]*models.TierList{tierList([]string{"x"}, nil, nil, []string{"y"}), tierList([]string{"x", "y"}, nil, nil, nil)},
+			nil,
+			[][]string{{"x"}, {}, {"y"}, {}},
+		},
+		{
+			"average orders a tier best first", models.MergeAverage,
+			[]*models.TierList{tierList([]string{"z", "y"}, []string{"x"}), tierList([]string{"y", "x"}, []string{"z"})},
+			nil,
+			[][]string{{"y"}, {}, {"x", "z"}, {}},
+		},
+		{
+			"average over the lists that ranked an item", models.MergeAverage,
+			[]*models.TierList{tierList([]string{"x"}, []string{"y"}, nil, nil), tierList(nil, nil, []string{"x"}, nil)},
+			nil,
+			[][]string{{}, {"x", "y"}, {}, {}},
+		},
+		{
+			"weighted", models.MergeWeighted,
+			[]*models.TierList{tierList([]string{"x"}, nil, nil, []string{"y"}), tierList([]string{"y"}, nil, nil, []string{"x"})},
+			[]float64{3, 1},
+			[][]string{{}, {"x"}, {"y"}, {}},
+		},
+		{
+			"weighted ignores zero weights", models.MergeWeighted,
+			[]*models.TierList{tierList([]string{"x"}, nil, nil, []string{"y"}), tierList([]string{"y"}, nil, nil, []string{"x"})},
+			[]float64{1, 0},
+			[][]string{{"x"}, {}, {}, {"y"}},
+		},
+		{
+			"weighted drops items only zero weights ranked", models.MergeWeighted,
+			[]*models.TierList{tierList([]string{"x"}), tierList([]string{"y"})},
+			[]float64{1, 0},
+			[][]string{{"x"}, {}, {}, {}},
+		},
+		{
+			"majority", models.MergeMajority,
+			[]*models.TierList{
+				tierList([]string{"x"}, []string{"y"}, nil, nil),
+				tierList([]string{"x"}, nil, []string{"y"}, nil),
+				tierList(nil, nil, []string{"y"}, []string{"x"}),
+			},
+			nil,
+			[][]string{{"x"}, {}, {"y"}, {}},
+		},
+		{
+			"majority tie goes to the tier nearest the average", models.MergeMajority,
+			[]*models.TierList{
+				tierList([]string{"x"}, nil, nil, nil),
+				tierList(nil, nil, []string{"x"}, nil),
+				tierList(nil, nil, nil, []string{"x"}),
+			},
+			nil,
+			[][]string{{}, {}, {"x"}, {}},
+		},
+		{
+			"majority tie equally far from the average goes up", models.MergeMajority,
+			[]*models.TierList{tierList([]string{"x"}, nil, nil, nil), tierList(nil, nil, nil, []string{"x"})},
+			nil,
+			[][]string{{"x"}, {}, {}, {}},
+		},
+		{
+			"majority maps shorter lists by score", models.MergeMajority,
+			[]*models.TierList{tierList([]string{"x"}, []string{"y"}), tierList([]string{"x"}, []string{"y"})},
+			nil,
+			[][]string{{"x"}, {}, {}, {"y"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers, err := Merge(tt.lists, tt.weights, tt.strategy, template)
+			if err != nil {
+				t.Fatalf("Merge: %v", err)
+			}
+			got := make([][]string, len(tiers))
+			for i, tier := range tiers {
+				got[i] = tier.Items
+				if want := []string{"s", "a", "b", "c"}[i]; tier.ID != want {
+					t.Errorf("tier %d is %s, want %s", i, tier.ID, want)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if template[3].Items[0] != "stale" {
+		t.Error("Merge modified the template's items")
+	}
+}
+
+func TestMergeErrorsAndEmptyTemplates(t *testing.T) {
+	lists := []*models.TierList{tierList([]string{"x"}), tierList([]string{"y"})}
+	if _, err := Merge(lists, nil, "median", []models.Tier{{ID: "s"}}); err == nil {
+		t.Error("Merge with an unknown strategy succeeded")
+	}
+	for _, strategy := range []string{models.MergeAverage, models.MergeWeighted, models.MergeMajority} {
+		tiers, err := Merge(lists, []float64{1, 1}, strategy, nil)
+		if err != nil || len(tiers) != 0 {
+			t.Errorf("%s merge into no tiers = %v, %v; want none", strategy, tiers, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ranking
+
+import (
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// PollResults places every voted item into the tier of its median vote and
+// returns the resulting copy of tiers with each item's tally. With an even
+// number of votes the higher of the two middle tiers wins. Items within a
+// tier are ordered by vote count, then ID; votes for unknown tiers are ignored.
+func PollResults(tiers []models.Tier, votes []models.PollVote) ([]models.Tier, []models.PollTally) {
+	result := SortedTiers(tiers)
+	index := make(map[string]int, len(result))
+	for i := range result {
+		result[i].Items = []string{}
+		index[result[i].ID] = i
+	}
+
+	placed := make(map[string][]int)
+	for _, v := range votes {
+		if i, ok := index[v.TierID]; ok {
+			placed[v.ItemID] = append(placed[v.ItemID], i)
+		}
+	}
+
+	tallies := make([]models.PollTally, 0, len(placed))
+	for itemID, positions := range placed {
+		sort.Ints(positions)
+		median := positions[(len(positions)-1)/2]
+		tally := models.PollTally{
+			ItemID: itemID,
+			TierID: result[median].ID,
+			Votes:  len(positions),
+			Counts: make(map[string]int),
+		}
+		for _, i := range positions {
+			tally.Counts[result[i].ID]++
+		}
+		tallies = append(tallies, tally)
+	}
+	sort.Slice(tallies, func(i, j int) bool {
+		if tallies[i].Votes != tallies[j].Votes {
+			return tallies[i].Votes > tallies[j].Votes
+		}
+		return tallies[i].ItemID < tallies[j].ItemID
+	})
+	for _, t := range tallies {
+		i := index[t.TierID]
+		result[i].Items = append(result[i].Items, t.ItemID)
+	}
+	return result, tallies
+}
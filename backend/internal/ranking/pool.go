@@ -0,0 +1,29 @@
+package ranking
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// OrderPool sorts a sheet's items in place by a tier list's pool order. A
+// random order shuffles the items sorted by ID with the seed, so the order
+// depends only on the seed and the set of items, not on how they were read.
+func OrderPool(items []models.Item, order string, seed int64) {
+	switch order {
+	case models.PoolCategory:
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Category != items[j].Category {
+				return items[i].Category < items[j].Category
+			}
+			return items[i].Name < items[j].Name
+		})
+	case models.PoolRandom:
+		sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	default:
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	}
+}
@@ -0,0 +1,249 @@
+package ranking
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// positionDigits are the digits of position keys in ascending byte order, so
+// keys compare as strings the way they compare as numbers
+const positionDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ValidPosition reports whether key is a position key: base-62 digits of a
+// fraction between 0 and 1, without a trailing zero so every fraction has a
+// single key
+func ValidPosition(key string) bool {
+	if key == "" || key[len(key)-1] == '0' {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(positionDigits, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// PositionBetween returns a key ordered strictly between the position keys a
+// and b. An empty a means the start of the tier and an empty b its end. There
+// is always room between two keys, so placing an item never renumbers its
+// neighbours. When b does not follow a the key is placed after a.
+func PositionBetween(a, b string) string {
+	if b != "" && a >= b {
+		b = ""
+	}
+	return midpoint(a, b)
+}
+
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the digits the keys share; a is padded with zeros
+		n := 0
+		for n < len(b) && positionDigit(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(positionDigits, a[0])
+	}
+	digitB := len(positionDigits)
+	if b != "" {
+		digitB = strings.IndexByte(positionDigits, b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(positionDigits[(digitA+digitB+1)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+	return string(positionDigits[digitA]) + midpoint(rest, "")
+}
+
+func positionDigit(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return '0'
+}
+
+// SpreadPositions returns n ascending keys spread evenly between 0 and 1, so
+// items laid out together leave room for inserts anywhere among them
+func SpreadPositions(n int) []string {
+	width, span := 1, int64(len(positionDigits))
+	for span <= int64(n) {
+		width++
+		span *= int64(len(positionDigits))
+	}
+	keys := make([]string, n)
+	for i := range keys {
+		v := int64(i+1) * span / int64(n+1)
+		digits := make([]byte, width)
+		for d := width - 1; d >= 0; d-- {
+			digits[d] = positionDigits[v%int64(len(positionDigits))]
+			v /= int64(len(positionDigits))
+		}
+		keys[i] = strings.TrimRight(string(digits), "0")
+	}
+	return keys
+}
+
+// ReconcilePositions brings the stored positions of a list's items in line
+// with the item order of its tiers, which edits made without positions may
+// have changed. Items keep their key while it still orders them after the
+// item before; others get a key between their neighbours, dated by the clock
+// of the tier that moved them. Items no tier holds any more are kept with an
+// empty tier, so a stale move cannot bring them back. With no stored
+// positions this lays out a list ranked before positions existed.
+func ReconcilePositions(tiers []models.Tier, stored map[string]models.ItemPosition,
+	clocks map[string]models.TierClock) map[string]models.ItemPosition {
+	positions := make(map[string]models.ItemPosition, len(stored))
+	for _, t := range tiers {
+		kept := func(item string) (models.ItemPosition, bool) {
+			p, ok := stored[item]
+			return p, ok && p.TierID == t.ID && ValidPosition(p.Position)
+		}
+		if len(t.Items) > 0 && !anyKept(t.Items, kept) {
+			// A tier laid out afresh spreads its keys rather than piling
+			// each one after the last
+			for i, key := range SpreadPositions(len(t.Items)) {
+				positions[t.Items[i]] = movedPosition(stored[t.Items[i]], t.ID, key, clocks[t.ID])
+			}
+			continue
+		}
+
+		prev, prevItem := "", ""
+		for i, item := range t.Items {
+			// Items sharing a key are ordered by ID, as OrderTiers does
+			if p, ok := kept(item); ok && (p.Position > prev || p.Position == prev && item > prevItem) {
+				positions[item] = p
+				prev, prevItem = p.Position, item
+				continue
+			}
+			next := ""
+			for _, later := range t.Items[i+1:] {
+				if p, ok := kept(later); ok && p.Position > prev {
+					next = p.Position
+					break
+				}
+			}
+			prev, prevItem = PositionBetween(prev, next), item
+			positions[item] = movedPosition(stored[item], t.ID, prev, clocks[t.ID])
+		}
+	}
+
+	for item, p := range stored {
+		if _, ok := positions[item]; ok {
+			continue
+		}
+		if p.TierID != "" {
+			p = movedPosition(p, "", "", clocks[p.TierID])
+		}
+		positions[item] = p
+	}
+	return positions
+}
+
+func anyKept(items []string, kept func(string) (models.ItemPosition, bool)) bool {
+	for _, item := range items {
+		if _, ok := kept(item); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// movedPosition places an item at a new key, dated by the later of its
+// previous placement and the tier clock that moved it
+func movedPosition(prev models.ItemPosition, tierID, key string, clock models.TierClock) models.ItemPosition {
+	p := models.ItemPosition{TierID: tierID, Position: key, ModifiedAt: prev.ModifiedAt, Replica: prev.Replica}
+	if clock.ModifiedAt.After(p.ModifiedAt) {
+		p.ModifiedAt, p.Replica = clock.ModifiedAt, clock.Replica
+	}
+	return p
+}
+
+// ApplyItemMoves places each moved item at its new key unless its current
+// placement is newer, breaking ties by replica. Moves into tiers the list
+// does not have lose. Moves dated in the future count as made at now. The
+// moved items are returned, with the tiers they left or entered and the time
+// of the latest move touching each.
+func ApplyItemMoves(tiers []models.Tier, positions map[string]models.ItemPosition, replica string,
+	moves []models.ItemMove, now time.Time) (applied, rejected []string, touched map[string]time.Time) {
+	exists := make(map[string]bool, len(tiers))
+	for _, t := range tiers {
+		exists[t.ID] = true
+	}
+	touched = make(map[string]time.Time)
+	touch := func(id string, at time.Time) {
+		if exists[id] && at.After(touched[id]) {
+			touched[id] = at
+		}
+	}
+
+	for _, m := range moves {
+		if m.ModifiedAt.After(now) {
+			m.ModifiedAt = now
+		}
+		if m.TierID == "" {
+			m.Position = ""
+		}
+		current := positions[m.ItemID]
+		wins := m.TierID == "" || exists[m.TierID]
+		wins = wins && (m.ModifiedAt.After(current.ModifiedAt) ||
+			(m.ModifiedAt.Equal(current.ModifiedAt) && replica >= current.Replica))
+		if !wins {
+			rejected = append(rejected, m.ItemID)
+			continue
+		}
+		touch(current.TierID, m.ModifiedAt)
+		touch(m.TierID, m.ModifiedAt)
+		positions[m.ItemID] = models.ItemPosition{
+			TierID:     m.TierID,
+			Position:   m.Position,
+			ModifiedAt: m.ModifiedAt,
+			Replica:    replica,
+		}
+		applied = append(applied, m.ItemID)
+	}
+	return applied, rejected, touched
+}
+
+// OrderTiers rebuilds the item order of each tier from positions. Items with
+// the same key, which replicas inserting at the same spot can make, are
+// ordered by ID so every replica settles on the same order.
+func OrderTiers(tiers []models.Tier, positions map[string]models.ItemPosition) []models.Tier {
+	byTier := make(map[string][]string, len(tiers))
+	for item, p := range positions {
+		if p.TierID != "" {
+			byTier[p.TierID] = append(byTier[p.TierID], item)
+		}
+	}
+	ordered := make([]models.Tier, len(tiers))
+	for i, t := range tiers {
+		items := byTier[t.ID]
+		sort.Slice(items, func(a, b int) bool {
+			pa, pb := positions[items[a]].Position, positions[items[b]].Position
+			if pa != pb {
+				return pa < pb
+			}
+			return items[a] < items[b]
+		})
+		if items == nil {
+			items = []string{}
+		}
+		t.Items = items
+		ordered[i] = t
+	}
+	return ordered
+}
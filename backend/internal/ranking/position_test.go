@@ -0,0 +1,324 @@
+package ranking
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestValidPosition(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"V", true},
+		{"0V", true},
+		{"zzz", true},
+		{"", false},
+		{"V0", false},
+		{"0", false},
+		{"a-b", false},
+		{"é", false},
+	}
+	for _, tt := range tests {
+		if got := ValidPosition(tt.key); got != tt.want {
+			t.Errorf("ValidPosition(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestPositionBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"empty tier", "", "", "V"},
+		{"at the end", "V", "", "l"},
+		{"at the start", "", "V", "G"},
+		{"after the last digit", "z", "", "zV"},
+		{"adjacent digits", "A", "B", "AV"},
+		{"shared prefix", "AV", "AW", "AVV"},
+		{"shorter upper key", "A", "B5", "B"},
+		{"before the smallest digit", "", "1", "0V"},
+		{"before a leading zero", "", "01", "00V"},
+		{"b before a", "V", "G", "l"},
+		{"b equal to a", "V", "V", "l"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PositionBetween(tt.a, tt.b)
+			if got != tt.want {
+				t.Errorf("PositionBetween(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+			if !ValidPosition(got) || got <= tt.a || (tt.b > tt.a && got >= tt.b) {
+				t.Errorf("PositionBetween(%q, %q) = %q, which is not a key between them", tt.a, tt.b, got)
+			}
+		})
+	}
+}
+
+func TestPositionBetweenRepeatedInserts(t *testing.T) {
+	// Inserting again and again at one spot must keep finding room
+	lo, hi := "A", "B"
+	for i := 0; i < 200; i++ {
+		mid := PositionBetween(lo, hi)
+		if !ValidPosition(mid) || mid <= lo || mid >= hi {
+			t.Fatalf("insert %d: PositionBetween(%q, %q) = %q", i, lo, hi, mid)
+		}
+		if i%2 == 0 {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+}
+
+func TestSpreadPositions(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{0, []string{}},
+		{1, []string{"V"}},
+		{2, []string{"K", "f"}},
+		{61, nil},
+		{62, nil},
+		{1000, nil},
+	}
+	for _, tt := range tests {
+		got := SpreadPositions(tt.n)
+		if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SpreadPositions(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+		if len(got) != tt.n || !sort.StringsAreSorted(got) {
+			t.Errorf("SpreadPositions(%d) gave %d keys, sorted %v", tt.n, len(got), sort.StringsAreSorted(got))
+		}
+		for i, key := range got {
+			if !ValidPosition(key) || (i > 0 && key == got[i-1]) {
+				t.Errorf("SpreadPositions(%d)[%d] = %q is not a distinct key", tt.n, i, key)
+			}
+		}
+	}
+}
+
+func TestReconcilePositions(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	clocks := map[string]models.TierClock{"S": {ModifiedAt: t1, Replica: "phone"}}
+	kept := func(tier, key string) models.ItemPosition {
+		return models.ItemPosition{TierID: tier, Position: key, ModifiedAt: t0, Replica: "server"}
+	}
+	moved := func(tier, key string) models.ItemPosition {
+		return models.ItemPosition{TierID: tier, Position: key, ModifiedAt: t1, Replica: "phone"}
+	}
+
+	tests := []struct {
+		name   string
+		items  []string // Items of tier S
+		stored map[string]models.ItemPosition
+		want   map[string]models.ItemPosition
+	}{
+		{
+			name:  "lays out a list without positions",
+			items: []string{"x", "y"},
+			want:  map[string]models.ItemPosition{"x": moved("S", "K"), "y": moved("S", "f")},
+		},
+		{
+			name:   "keeps keys in order",
+			items:  []string{"x", "y"},
+			stored: map[string]models.ItemPosition{"x": kept("S", "A"), "y": kept("S", "B")},
+			want:   map[string]models.ItemPosition{"x": kept("S", "A"), "y": kept("S", "B")},
+		},
+		{
+			name:   "moves an item placed out of order",
+			items:  []string{"y", "x"},
+			stored: map[string]models.ItemPosition{"x": kept("S", "A"), "y": kept("S", "B")},
+			want:   map[string]models.ItemPosition{"x": moved("S", "b"), "y": kept("S", "B")},
+		},
+		{
+			name:   "places a new item between its neighbours",
+			items:  []string{"x", "z", "y"},
+			stored: map[string]models.ItemPosition{"x": kept("S", "A"), "y": kept("S", "B")},
+			want:   map[string]models.ItemPosition{"x": kept("S", "A"), "z": moved("S", "AV"), "y": kept("S", "B")},
+		},
+		{
+			name:   "keeps shared keys ordered by ID",
+			items:  []string{"x", "y"},
+			stored: map[string]models.ItemPosition{"x": kept("S", "A"), "y": kept("S", "A")},
+			want:   map[string]models.ItemPosition{"x": kept("S", "A"), "y": kept("S", "A")},
+		},
+		{
+			name:   "spreads items that came from another tier",
+			items:  []string{"x"},
+			stored: map[string]models.ItemPosition{"x": kept("A", "V")},
+			want:   map[string]models.ItemPosition{"x": moved("S", "V")},
+		},
+		{
+			name:   "returns removed items to the pool",
+			items:  []string{"x"},
+			stored: map[string]models.ItemPosition{"x": kept("S", "A"), "w": kept("S", "B")},
+			want:   map[string]models.ItemPosition{"x": kept("S", "A"), "w": moved("", "")},
+		},
+		{
+			name:   "keeps pooled items",
+			items:  []string{},
+			stored: map[string]models.ItemPosition{"w": kept("", "")},
+			want:   map[string]models.ItemPosition{"w": kept("", "")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers := []models.Tier{{ID: "S", Items: tt.items}, {ID: "A", Items: []string{}}}
+			got := ReconcilePositions(tiers, tt.stored, clocks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReconcilePositions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyItemMoves(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	t0 := now.Add(-time.Hour)
+	t1 := t0.Add(time.Minute)
+	placed := models.ItemPosition{TierID: "S", Position: "V", ModifiedAt: t0, Replica: "server"}
+
+	tests := []struct {
+		name     string
+		replica  string
+		move     models.ItemMove
+		applied  []string
+		rejected []string
+		touched  map[string]time.Time
+		want     models.ItemPosition // Position of the moved item afterwards
+	}{
+		{
+			name:    "newer move wins",
+			move:    models.ItemMove{ItemID: "x", TierID: "A", Position: "G", ModifiedAt: t1},
+			applied: []string{"x"},
+			touched: map[string]time.Time{"S": t1, "A": t1},
+			want:    models.ItemPosition{TierID: "A", Position: "G", ModifiedAt: t1, Replica: "phone"},
+		},
+		{
+			name:     "older move loses",
+			move:     models.ItemMove{ItemID: "x", TierID: "A", Position: "G", ModifiedAt: t0.Add(-time.Minute)},
+			rejected: []string{"x"},
+			touched:  map[string]time.Time{},
+			want:     placed,
+		},
+		{
+			name:     "simultaneous move from a lesser replica loses",
+			move:     models.ItemMove{ItemID: "x", TierID: "A", Position: "G", ModifiedAt: t0},
+			rejected: []string{"x"},
+			touched:  map[string]time.Time{},
+			want:     placed,
+		},
+		{
+			name:    "simultaneous move from a greater replica wins",
+			replica: "tablet",
+			move:    models.ItemMove{ItemID: "x", TierID: "A", Position: "G", ModifiedAt: t0},
+			applied: []string{"x"},
+			touched: map[string]time.Time{"S": t0, "A": t0},
+			want:    models.ItemPosition{TierID: "A", Position: "G", ModifiedAt: t0, Replica: "tablet"},
+		},
+		{
+			name:     "move into a missing tier loses",
+			move:     models.ItemMove{ItemID: "x", TierID: "Z", Position: "G", ModifiedAt: t1},
+			rejected: []string{"x"},
+			touched:  map[string]time.Time{},
+			want:     placed,
+		},
+		{
+			name:    "move to the pool drops the key",
+			move:    models.ItemMove{ItemID: "x", Position: "G", ModifiedAt: t1},
+			applied: []string{"x"},
+			touched: map[string]time.Time{"S": t1},
+			want:    models.ItemPosition{ModifiedAt: t1, Replica: "phone"},
+		},
+		{
+			name:    "future move counts as made now",
+			move:    models.ItemMove{ItemID: "x", TierID: "A", Position: "G", ModifiedAt: now.Add(time.Hour)},
+			applied: []string{"x"},
+			touched: map[string]time.Time{"S": now, "A": now},
+			want:    models.ItemPosition{TierID: "A", Position: "G", ModifiedAt: now, Replica: "phone"},
+		},
+		{
+			name:    "first placement of an item",
+			move:    models.ItemMove{ItemID: "y", TierID: "S", Position: "l", ModifiedAt: t0},
+			applied: []string{"y"},
+			touched: map[string]time.Time{"S": t0},
+			want:    models.ItemPosition{TierID: "S", Position: "l", ModifiedAt: t0, Replica: "phone"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replica := tt.replica
+			if replica == "" {
+				replica = "phone"
+			}
+			tiers := []models.Tier{{ID: "S"}, {ID: "A"}}
+			positions := map[string]models.ItemPosition{"x": placed}
+
+			applied, rejected, touched := ApplyItemMoves(tiers, positions, replica, []models.ItemMove{tt.move}, now)
+			if !reflect.DeepEqual(applied, tt.applied) || !reflect.DeepEqual(rejected, tt.rejected) {
+				t.Errorf("applied %q, rejected %q; want %q and %q", applied, rejected, tt.applied, tt.rejected)
+			}
+			if !reflect.DeepEqual(touched, tt.touched) {
+				t.Errorf("touched = %v, want %v", touched, tt.touched)
+			}
+			if got := positions[tt.move.ItemID]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("position of %s = %+v, want %+v", tt.move.ItemID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderTiers(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions map[string]models.ItemPosition
+		want      [][]string // Items of S and A
+	}{
+		{"no positions", nil, [][]string{{}, {}}},
+		{
+			name: "by key",
+			positions: map[string]models.ItemPosition{
+				"x": {TierID: "S", Position: "l"}, "y": {TierID: "S", Position: "G"}, "z": {TierID: "A", Position: "V"},
+			},
+			want: [][]string{{"y", "x"}, {"z"}},
+		},
+		{
+			name: "shared keys by ID",
+			positions: map[string]models.ItemPosition{
+				"y": {TierID: "S", Position: "V"}, "x": {TierID: "S", Position: "V"}, "w": {TierID: "S", Position: "l"},
+			},
+			want: [][]string{{"x", "y", "w"}, {}},
+		},
+		{
+			name: "leaves out pooled items and missing tiers",
+			positions: map[string]models.ItemPosition{
+				"x": {TierID: "S", Position: "V"}, "pool": {}, "gone": {TierID: "Z", Position: "V"},
+			},
+			want: [][]string{{"x"}, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers := []models.Tier{{ID: "S", Name: "Top", Items: []string{"stale"}}, {ID: "A"}}
+			got := OrderTiers(tiers, tt.positions)
+			if len(got) != 2 || got[0].ID != "S" || got[0].Name != "Top" || got[1].ID != "A" {
+				t.Fatalf("OrderTiers = %+v, want tiers S and A in their order", got)
+			}
+			if items := [][]string{got[0].Items, got[1].Items}; !reflect.DeepEqual(items, tt.want) {
+				t.Errorf("items = %q, want %q", items, tt.want)
+			}
+			if !reflect.DeepEqual(tiers[0].Items, []string{"stale"}) {
+				t.Errorf("OrderTiers changed its input to %q", tiers[0].Items)
+			}
+		})
+	}
+}
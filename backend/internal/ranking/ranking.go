@@ -0,0 +1,133 @@
+// Package ranking converts between tier placements and numeric scores so
+// tier lists can be compared, combined and synthesized.
+package ranking
+
+import (
+	"math"
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SortedTiers returns a copy of tiers ordered from top to bottom
+func SortedTiers(tiers []models.Tier) []models.Tier {
+	sorted := make([]models.Tier, len(tiers))
+	copy(sorted, tiers)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+	return sorted
+}
+
+// Positions maps each ranked item to the index of its tier, 0 being the top
+func Positions(tl *models.TierList) map[string]int {
+	positions := make(map[string]int)
+	for i, t := range SortedTiers(tl.Tiers) {
+		for _, itemID := range t.Items {
+			positions[itemID] = i
+		}
+	}
+	return positions
+}
+
+// Scores maps each ranked item to a score between 0 (bottom tier) and 1
+// (top tier), based on tier order
+func Scores(tl *models.TierList) map[string]float64 {
+	tiers := SortedTiers(tl.Tiers)
+	scores := make(map[string]float64)
+	for i, t := range tiers {
+		score := 1.0
+		if len(tiers) > 1 {
+			score = 1 - float64(i)/float64(len(tiers)-1)
+		}
+		for _, itemID := range t.Items {
+			scores[itemID] = score
+		}
+	}
+	return scores
+}
+
+// Bucket places scored items into empty copies of template, mapping a score
+// of 1 to the top tier and 0 to the bottom one. Items within a tier are
+// ordered best first.
+func Bucket(scores map[string]float64, template []models.Tier) []models.Tier {
+	tiers := SortedTiers(template)
+	for i := range tiers {
+		tiers[i].Items = []string{}
+	}
+	if len(tiers) == 0 {
+		return tiers
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	last := len(tiers) - 1
+	for _, id := range ids {
+		idx := int(math.Round((1 - scores[id]) * float64(last)))
+		idx = max(0, min(last, idx))
+		tiers[idx].Items = append(tiers[idx].Items, id)
+	}
+	return tiers
+}
+
+// Compare diffs two tier lists item by item. Deltas count tiers by position,
+// so lists with different tier counts are compared by tier index.
+func Compare(a, b *models.TierList) *models.TierListComparison {
+	posA, posB := Positions(a), Positions(b)
+	tiersA, tiersB := SortedTiers(a.Tiers), SortedTiers(b.Tiers)
+
+	ids := make([]string, 0, len(posA)+len(posB))
+	for id := range posA {
+		ids = append(ids, id)
+	}
+	for id := range posB {
+		if _, ok := posA[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	cmp := &models.TierListComparison{A: a.Summary(), B: b.Summary(), Items: make([]models.ItemDelta, 0, len(ids))}
+	for _, id := range ids {
+		d := models.ItemDelta{ItemID: id}
+		ia, inA := posA[id]
+		ib, inB := posB[id]
+		if inA {
+			d.TierA = tiersA[ia].Name
+		}
+		if inB {
+			d.TierB = tiersB[ib].Name
+		}
+
+		switch {
+		case !inB:
+			d.Change = models.ChangeOnlyA
+			cmp.OnlyA++
+		case !inA:
+			d.Change = models.ChangeOnlyB
+			cmp.OnlyB++
+		default:
+			d.Delta = ia - ib
+			switch {
+			case d.Delta > 0:
+				d.Change = models.ChangeUp
+				cmp.Up++
+			case d.Delta < 0:
+				d.Change = models.ChangeDown
+				cmp.Down++
+			default:
+				d.Change = models.ChangeSame
+				cmp.Same++
+			}
+		}
+		cmp.Items = append(cmp.Items, d)
+	}
+	return cmp
+}
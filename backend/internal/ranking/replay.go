@@ -0,0 +1,138 @@
+package ranking
+
+import (
+	"sort"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// ReplayMoves returns the item moves that turn before into after, for
+// replaying how a list was built. Items returning to the pool come first;
+// the rest are placed tier by tier in their final order, each after the item
+// preceding it, so applying the moves in order rebuilds after. Items that
+// kept their tier and their order relative to each other are not moved.
+func ReplayMoves(before, after []models.Tier) []models.ReplayMove {
+	tierOf := make(map[string]string)
+	index := make(map[string]int)
+	for _, t := range before {
+		for i, item := range t.Items {
+			tierOf[item], index[item] = t.ID, i
+		}
+	}
+
+	var moves []models.ReplayMove
+	ranked := make(map[string]bool)
+	for _, t := range after {
+		for _, item := range t.Items {
+			ranked[item] = true
+		}
+	}
+	for _, t := range before {
+		for _, item := range t.Items {
+			if !ranked[item] {
+				moves = append(moves, models.ReplayMove{ItemID: item})
+			}
+		}
+	}
+
+	for _, t := range after {
+		var kept []string
+		for _, item := range t.Items {
+			if tierOf[item] == t.ID {
+				kept = append(kept, item)
+			}
+		}
+		stays := longestIncreasing(kept, index)
+		prev := ""
+		for _, item := range t.Items {
+			if !stays[item] {
+				moves = append(moves, models.ReplayMove{ItemID: item, TierID: t.ID, After: prev})
+			}
+			prev = item
+		}
+	}
+	return moves
+}
+
+// longestIncreasing returns the largest set of items whose indexes increase
+// in the order given: the items that can stay while the others move around
+// them
+func longestIncreasing(items []string, index map[string]int) map[string]bool {
+	// tails[k] is the position in items of the smallest tail of an
+	// increasing run of length k+1
+	var tails []int
+	prevOf := make([]int, len(items))
+	for i, item := range items {
+		k := sort.Search(len(tails), func(k int) bool { return index[items[tails[k]]] >= index[item] })
+		prevOf[i] = -1
+		if k > 0 {
+			prevOf[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	stays := make(map[string]bool, len(tails))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = prevOf[i] {
+			stays[items[i]] = true
+		}
+	}
+	return stays
+}
+
+// HideReplayMoves drops the moves of hidden items from a replay. Moves placed
+// after a hidden item are placed after the nearest visible item before it
+// instead, so the replay still rebuilds the list without the hidden items.
+func HideReplayMoves(moves []models.ReplayMove, hidden map[string]bool) []models.ReplayMove {
+	tiers := make(map[string][]string)
+	tierOf := make(map[string]string)
+	visible := make([]models.ReplayMove, 0, len(moves))
+	for _, m := range moves {
+		if from, ok := tierOf[m.ItemID]; ok {
+			tiers[from] = removeItem(tiers[from], m.ItemID)
+			delete(tierOf, m.ItemID)
+		}
+		if m.TierID == "" {
+			if !hidden[m.ItemID] {
+				visible = append(visible, m)
+			}
+			continue
+		}
+
+		items := tiers[m.TierID]
+		at := 0
+		for i, item := range items {
+			if item == m.After {
+				at = i + 1
+				break
+			}
+		}
+		items = append(items[:at], append([]string{m.ItemID}, items[at:]...)...)
+		tiers[m.TierID], tierOf[m.ItemID] = items, m.TierID
+		if hidden[m.ItemID] {
+			continue
+		}
+		m.After = ""
+		for i := at - 1; i >= 0; i-- {
+			if !hidden[items[i]] {
+				m.After = items[i]
+				break
+			}
+		}
+		visible = append(visible, m)
+	}
+	return visible
+}
+
+func removeItem(items []string, item string) []string {
+	for i, id := range items {
+		if id == item {
+			return append(items[:i], items[i+1:]...)
+		}
+	}
+	return items
+}
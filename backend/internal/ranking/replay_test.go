@@ -0,0 +1,136 @@
+package ranking
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// replay applies moves to tiers the way a client playing them back does
+func replay(tiers []models.Tier, moves []models.ReplayMove) map[string][]string {
+	items := make(map[string][]string)
+	for _, t := range tiers {
+		items[t.ID] = append([]string{}, t.Items...)
+	}
+	for _, m := range moves {
+		for id := range items {
+			items[id] = removeItem(items[id], m.ItemID)
+		}
+		if m.TierID == "" {
+			continue
+		}
+		at := 0
+		for i, item := range items[m.TierID] {
+			if item == m.After {
+				at = i + 1
+				break
+			}
+		}
+		tier := items[m.TierID]
+		items[m.TierID] = append(tier[:at], append([]string{m.ItemID}, tier[at:]...)...)
+	}
+	return items
+}
+
+func TestReplayMoves(t *testing.T) {
+	tiers := func(s, a []string) []models.Tier {
+		return []models.Tier{{ID: "S", Items: s}, {ID: "A", Items: a}}
+	}
+	tests := []struct {
+		name          string
+		before, after []models.Tier
+		want          []models.ReplayMove
+	}{
+		{"unchanged", tiers([]string{"x", "y"}, nil), tiers([]string{"x", "y"}, nil), nil},
+		{"ranked from the pool", tiers(nil, nil), tiers([]string{"x", "y"}, nil),
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "y", TierID: "S", After: "x"}}},
+		{"returned to the pool first", tiers([]string{"x", "y"}, nil), tiers(nil, []string{"z", "y"}),
+			[]models.ReplayMove{{ItemID: "x"}, {ItemID: "z", TierID: "A"}, {ItemID: "y", TierID: "A", After: "z"}}},
+		{"one item moved up", tiers([]string{"x", "y", "z"}, nil), tiers([]string{"z", "x", "y"}, nil),
+			[]models.ReplayMove{{ItemID: "z", TierID: "S"}}},
+		{"swapped", tiers([]string{"x", "y"}, nil), tiers([]string{"y", "x"}, nil),
+			[]models.ReplayMove{{ItemID: "y", TierID: "S"}}},
+		{"exchanged between tiers", tiers([]string{"x"}, []string{"y"}), tiers([]string{"y"}, []string{"x"}),
+			[]models.ReplayMove{{ItemID: "y", TierID: "S"}, {ItemID: "x", TierID: "A"}}},
+		{"new tier", tiers([]string{"x"}, nil), []models.Tier{{ID: "S", Items: []string{"x"}}, {ID: "B", Items: []string{"y"}}},
+			[]models.ReplayMove{{ItemID: "y", TierID: "B"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ReplayMoves(tt.before, tt.after)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReplayMoves = %+v, want %+v", got, tt.want)
+			}
+			rebuilt := replay(tt.before, got)
+			for _, tier := range tt.after {
+				if items := rebuilt[tier.ID]; len(items) != len(tier.Items) || (len(items) > 0 && !reflect.DeepEqual(items, tier.Items)) {
+					t.Errorf("replaying gives %s %q, want %q", tier.ID, items, tier.Items)
+				}
+			}
+		})
+	}
+}
+
+func TestLongestIncreasing(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []string
+		index map[string]int
+		want  map[string]bool
+	}{
+		{"empty", nil, nil, map[string]bool{}},
+		{"in order", []string{"a", "b", "c"}, map[string]int{"a": 0, "b": 1, "c": 2},
+			map[string]bool{"a": true, "b": true, "c": true}},
+		{"last moved to the front", []string{"c", "a", "b"}, map[string]int{"a": 0, "b": 1, "c": 2},
+			map[string]bool{"a": true, "b": true}},
+		{"reversed", []string{"d", "c", "b", "a"}, map[string]int{"a": 0, "b": 1, "c": 2, "d": 3},
+			map[string]bool{"a": true}},
+		{"interleaved", []string{"b", "a", "d", "c", "e"}, map[string]int{"a": 0, "b": 1, "c": 2, "d": 3, "e": 4},
+			map[string]bool{"a": true, "c": true, "e": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longestIncreasing(tt.items, tt.index); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("longestIncreasing(%q) = %v, want %v", tt.items, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHideReplayMoves(t *testing.T) {
+	hidden := map[string]bool{"h": true}
+	tests := []struct {
+		name  string
+		moves []models.ReplayMove
+		want  []models.ReplayMove
+	}{
+		{"nothing hidden",
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "y", TierID: "S", After: "x"}},
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "y", TierID: "S", After: "x"}}},
+		{"placed after a hidden item",
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "h", TierID: "S", After: "x"}, {ItemID: "y", TierID: "S", After: "h"}},
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "y", TierID: "S", After: "x"}}},
+		{"hidden item first in its tier",
+			[]models.ReplayMove{{ItemID: "h", TierID: "S"}, {ItemID: "y", TierID: "S", After: "h"}},
+			[]models.ReplayMove{{ItemID: "y", TierID: "S"}}},
+		{"pool moves",
+			[]models.ReplayMove{{ItemID: "h"}, {ItemID: "x"}},
+			[]models.ReplayMove{{ItemID: "x"}}},
+		{"item moved away from a hidden neighbour",
+			[]models.ReplayMove{{ItemID: "h", TierID: "S"}, {ItemID: "x", TierID: "S", After: "h"},
+				{ItemID: "x", TierID: "A"}, {ItemID: "y", TierID: "S", After: "h"}},
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "x", TierID: "A"}, {ItemID: "y", TierID: "S"}}},
+		{"hidden item moved between tiers",
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "h", TierID: "S"}, {ItemID: "y", TierID: "S", After: "h"},
+				{ItemID: "h", TierID: "A"}},
+			[]models.ReplayMove{{ItemID: "x", TierID: "S"}, {ItemID: "y", TierID: "S"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HideReplayMoves(tt.moves, hidden); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HideReplayMoves = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image/color"
+	"time"
+
+	"github.com/meur/tierforge/internal/colors"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+)
+
+// htmlPage is the data of the static export template
+type htmlPage struct {
+	Title      string
+	Game       string
+	Background template.CSS
+	Tiers      []htmlTier
+	Exported   string
+	Locked     string // When the owner locked the list; empty when not locked
+}
+
+type htmlTier struct {
+	Name  string
+	Color template.CSS
+	Text  template.CSS
+	Items []htmlItem
+}
+
+type htmlItem struct {
+	Name  string
+	Label string       // Game-specific note shown under the name
+	Icon  template.URL // data: URI, empty when the item has no icon
+	Color template.CSS // Placeholder color when there is no icon
+}
+
+var htmlTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;padding:24px;background:{{.Background}};color:#eee;font-family:system-ui,-apple-system,"Segoe UI",sans-serif}
+h1{margin:0 0 4px;font-size:24px}
+.game{margin:0 0 16px;color:#aaa}
+.tiers{display:flex;flex-direction:column;gap:4px}
+.tier{display:flex;min-height:72px;background:#26262d}
+.label{flex:0 0 96px;display:flex;align-items:center;justify-content:center;padding:4px;font-weight:700;font-size:18px;text-align:center;word-break:break-word}
+.items{display:flex;flex-wrap:wrap;gap:4px;padding:4px;align-content:flex-start}
+.item{width:64px;text-align:center;font-size:11px;line-height:1.2}
+.item img,.item .tile{display:block;width:64px;height:64px;margin-bottom:2px}
+.item .tile{border-radius:4px}
+.item .note{display:block;color:#aaa;font-size:10px}
+footer{margin-top:16px;font-size:12px;color:#888}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Game}}<p class="game">{{.Game}}</p>{{end}}
+<div class="tiers">
+{{range .Tiers}}<div class="tier">
+<div class="label" style="background:{{.Color}};color:{{.Text}}">{{.Name}}</div>
+<div class="items">{{range .Items}}<div class="item" title="{{.Name}}">{{if .Icon}}<img src="{{.Icon}}" alt="{{.Name}}">{{else}}<span class="tile" style="background:{{.Color}}"></span>{{end}}{{.Name}}{{if .Label}}<span class="note">{{.Label}}</span>{{end}}</div>{{end}}</div>
+</div>
+{{end}}</div>
+<footer>Exported from TierForge on {{.Exported}}{{if .Locked}} · Locked on {{.Locked}}{{end}}</footer>
+</body>
+</html>
+`))
+
+// HTML writes tl as a self-contained HTML page with inline CSS and icons
+// embedded as data URIs, for archiving or posting where iframes are not
+// allowed. Items without an icon are drawn as colored tiles, and labels
+// holds notes shown under item names, by item ID.
+func HTML(tl *models.TierList, gameName string, items map[string]models.Item, icons map[string][]byte, labels map[string]string) ([]byte, error) {
+	page := htmlPage{
+		Title:      tl.Name,
+		Game:       gameName,
+		Background: cssColor(background),
+		Exported:   time.Now().UTC().Format("January 2, 2006"),
+	}
+	if tl.LockedAt != nil {
+		page.Locked = tl.LockedAt.UTC().Format("January 2, 2006")
+	}
+	for _, t := range ranking.SortedTiers(tl.Tiers) {
+		c := tierColor(t.Color)
+		tier := htmlTier{Name: t.Name, Color: cssColor(c), Text: cssColor(colors.LabelColor(c)), Items: make([]htmlItem, 0, len(t.Items))}
+		for _, id := range t.Items {
+			item := htmlItem{Name: id, Label: labels[id], Color: cssColor(itemColor(id))}
+			if it, ok := items[id]; ok {
+				item.Name = it.Name
+			}
+			if icon, ok := icons[id]; ok {
+				item.Icon = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(icon))
+			}
+			tier.Items = append(tier.Items, item)
+		}
+		page.Tiers = append(page.Tiers, tier)
+	}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func cssColor(c color.RGBA) template.CSS {
+	return template.CSS(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+}
@@ -0,0 +1,299 @@
+// Package render draws tier lists as PNG images for share links and link
+// unfurls, in dark, light or transparent styles to match where they are
+// posted, caching them in the database and pre-rendering them in the
+// background so the first unfurl does not wait on a render.
+// It also writes tier lists as static HTML pages for export.
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/meur/tierforge/internal/imaging"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+	"github.com/meur/tierforge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Image kinds
+const (
+	KindShare = "share" // The whole tier list, as tall as it needs to be
+	KindCard  = "og"    // A fixed 1200x630 Open Graph card
+	KindThumb = "thumb" // A small preview for listings
+)
+
+// Thumbnails are scaled down share images, cut off below ThumbHeight
+const (
+	ThumbWidth  = 400
+	ThumbHeight = 300
+)
+
+// cacheHits and cacheMisses count lookups made by Cached
+var cacheHits, cacheMisses atomic.Uint64
+
+// CacheStats returns the hits and misses of the rendered image cache since
+// the process started
+func CacheStats() (hits, misses uint64) {
+	return cacheHits.Load(), cacheMisses.Load()
+}
+
+// Kinds lists every image kind pre-rendered for a tier list
+var Kinds = []string{KindShare, KindCard, KindThumb}
+
+// ValidKind reports whether kind is a known image kind
+func ValidKind(kind string) bool {
+	return kind == KindShare || kind == KindCard || kind == KindThumb
+}
+
+const (
+	shareWidth = 960
+	cardWidth  = 1200
+	cardHeight = 630
+	labelWidth = 96
+	tileSize   = 56
+	gap        = 4
+)
+
+var (
+	background      = color.RGBA{0x1a, 0x1a, 0x1f, 0xff}
+	lightBackground = color.RGBA{0xf4, 0xf4, 0xf6, 0xff}
+	defaultColor    = color.RGBA{0x80, 0x80, 0x80, 0xff}
+)
+
+// Color schemes of rendered images
+const (
+	ThemeDark  = "dark"
+	ThemeLight = "light"
+)
+
+// ValidTheme reports whether theme is a known color scheme
+func ValidTheme(theme string) bool {
+	return theme == ThemeDark || theme == ThemeLight
+}
+
+// Style is how an image is drawn to suit where it will be posted
+type Style struct {
+	Background  color.RGBA
+	Transparent bool // Leaves the background see-through; Background is ignored
+}
+
+// DefaultStyle is the style of share images and link unfurls
+var DefaultStyle = Style{Background: background}
+
+// ThemeStyle returns the style of a color scheme, taking the background from
+// the game's theme when it sets one for the scheme
+func ThemeStyle(theme *models.GameTheme, scheme string, transparent bool) Style {
+	style := Style{Background: background, Transparent: transparent}
+	if scheme == ThemeLight {
+		style.Background = lightBackground
+	}
+	if theme == nil {
+		return style
+	}
+	custom := theme.BackgroundColor
+	if scheme == ThemeLight {
+		custom = ""
+		if theme.Light != nil {
+			custom = theme.Light.BackgroundColor
+		}
+	}
+	if c, ok := parseColor(custom); ok {
+		style.Background = c
+	}
+	return style
+}
+
+// CacheKind identifies images of kind drawn in s in caches. Images in the
+// default style keep the plain kind.
+func (s Style) CacheKind(kind string) string {
+	switch {
+	case s == DefaultStyle:
+		return kind
+	case s.Transparent:
+		return kind + ":transparent"
+	}
+	return fmt.Sprintf("%s:%02x%02x%02x", kind, s.Background.R, s.Background.G, s.Background.B)
+}
+
+func (s Style) fill() color.Color {
+	if s.Transparent {
+		return color.Transparent
+	}
+	return s.Background
+}
+
+// Shareable reports whether a tier list may be rendered for share links
+func Shareable(tl *models.TierList) bool {
+	return !tl.Hidden && tl.Status == models.TierListPublished && tl.Visibility != models.VisibilityPrivate
+}
+
+// PNG draws tl as the given kind in style and encodes it
+func PNG(tl *models.TierList, kind string, style Style) ([]byte, error) {
+	var img image.Image
+	switch kind {
+	case KindCard:
+		img = drawCard(tl.Tiers, style.fill())
+	case KindThumb:
+		img = thumbnail(drawShare(tl.Tiers, style.fill()))
+	default:
+		img = drawShare(tl.Tiers, style.fill())
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Cached returns the image of tl, rendering and storing it when the cached
+// copy is missing or older than the tier list
+func Cached(ctx context.Context, store *storage.Store, tl *models.TierList, kind string, style Style) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "render "+kind, trace.SpanKindInternal,
+		attribute.String("tierlist.id", tl.ID),
+		attribute.String("render.kind", style.CacheKind(kind)))
+	defer span.End()
+	store = store.WithContext(ctx)
+
+	cached, err := store.GetRenderedImage(tl.ID, style.CacheKind(kind))
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	if cached != nil && !cached.SourceUpdatedAt.Before(tl.UpdatedAt) {
+		cacheHits.Add(1)
+		span.SetAttributes(attribute.Bool("render.cache_hit", true))
+		return cached.Data, nil
+	}
+	cacheMisses.Add(1)
+	span.SetAttributes(attribute.Bool("render.cache_hit", false))
+	data, err := PNG(tl, kind, style)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int64("render.bytes", int64(len(data))))
+	err = store.SaveRenderedImage(&models.RenderedImage{
+		TierListID:      tl.ID,
+		Kind:            style.CacheKind(kind),
+		SourceUpdatedAt: tl.UpdatedAt,
+		Data:            data,
+	})
+	tracing.RecordError(span, err)
+	return data, err
+}
+
+// thumbnail scales a share image to ThumbWidth and keeps its top tiers
+func thumbnail(share image.Image) image.Image {
+	b := share.Bounds()
+	h := max(b.Dy()*ThumbWidth/b.Dx(), 1)
+	img := imaging.Resize(share, ThumbWidth, h)
+	if h > ThumbHeight {
+		return img.SubImage(image.Rect(0, 0, ThumbWidth, ThumbHeight))
+	}
+	return img
+}
+
+// drawShare lays out every tier as a labelled row of wrapped item tiles
+func drawShare(tiers []models.Tier, bg color.Color) image.Image {
+	perRow := (shareWidth - labelWidth - gap) / (tileSize + gap)
+	height := gap
+	for _, tier := range tiers {
+		height += rowHeight(len(tier.Items), perRow, tileSize) + gap
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, shareWidth, height))
+	fill(img, img.Bounds(), bg)
+	y := gap
+	for _, tier := range tiers {
+		h := rowHeight(len(tier.Items), perRow, tileSize)
+		fill(img, image.Rect(gap, y, labelWidth, y+h), tierColor(tier.Color))
+		for i, id := range tier.Items {
+			x := labelWidth + gap + (i%perRow)*(tileSize+gap)
+			ty := y + (i/perRow)*(tileSize+gap)
+			fill(img, image.Rect(x, ty, x+tileSize, ty+tileSize), itemColor(id))
+		}
+		y += h + gap
+	}
+	return img
+}
+
+// drawCard squeezes the tiers into equal bands of a fixed-size card; items
+// that do not fit on a band's single row are dropped
+func drawCard(tiers []models.Tier, bg color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	fill(img, img.Bounds(), bg)
+	if len(tiers) == 0 {
+		return img
+	}
+
+	band := (cardHeight - gap) / len(tiers)
+	tile := band - gap
+	if tile < 1 {
+		tile = 1
+	}
+	perRow := (cardWidth - labelWidth - gap) / (tile + gap)
+	for i, tier := range tiers {
+		y := gap + i*band
+		fill(img, image.Rect(gap, y, labelWidth, y+tile), tierColor(tier.Color))
+		for j, id := range tier.Items {
+			if j == perRow {
+				break
+			}
+			x := labelWidth + gap + j*(tile+gap)
+			fill(img, image.Rect(x, y, x+tile, y+tile), itemColor(id))
+		}
+	}
+	return img
+}
+
+// rowHeight is the height of a tier row holding n items, at least one tile
+func rowHeight(n, perRow, tile int) int {
+	rows := (n + perRow - 1) / perRow
+	if rows == 0 {
+		rows = 1
+	}
+	return rows*tile + (rows-1)*gap
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// tierColor parses a #rrggbb tier color, falling back to grey
+func tierColor(hex string) color.RGBA {
+	if c, ok := parseColor(hex); ok {
+		return c
+	}
+	return defaultColor
+}
+
+// parseColor parses a #rrggbb color
+func parseColor(hex string) (color.RGBA, bool) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return color.RGBA{}, false
+	}
+	v, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, false
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, true
+}
+
+// itemColor gives each item a stable muted color so the same item is
+// recognizable across images without drawing its icon
+func itemColor(id string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	v := h.Sum32()
+	return color.RGBA{0x40 + uint8(v)%0x80, 0x40 + uint8(v>>8)%0x80, 0x40 + uint8(v>>16)%0x80, 0xff}
+}
@@ -0,0 +1,107 @@
+package render
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/meur/tierforge/internal/storage"
+	"github.com/meur/tierforge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Worker pre-renders tier list images from a bounded queue with a fixed
+// number of goroutines. Enqueueing never blocks; lists that do not fit are
+// dropped and rendered on first request instead.
+type Worker struct {
+	store   *storage.Store
+	workers int
+	queue   chan string
+
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+// NewWorker creates a worker with the given queue size and concurrency. A
+// worker with no goroutines accepts nothing.
+func NewWorker(store *storage.Store, queueSize, workers int) *Worker {
+	return &Worker{
+		store:   store,
+		workers: workers,
+		queue:   make(chan string, queueSize),
+		pending: make(map[string]bool),
+	}
+}
+
+// Pending returns the number of lists waiting to be rendered
+func (w *Worker) Pending() int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Start launches the worker goroutines, which run until ctx is cancelled
+func (w *Worker) Start(ctx context.Context) {
+	for i := 0; i < w.workers; i++ {
+		go w.loop(ctx)
+	}
+}
+
+// Enqueue schedules a tier list for pre-rendering, reporting whether it was
+// queued. Lists already waiting are not queued twice.
+func (w *Worker) Enqueue(id string) bool {
+	if w == nil || w.workers == 0 {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending[id] {
+		return true
+	}
+	select {
+	case w.queue <- id:
+		w.pending[id] = true
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-w.queue:
+			w.mu.Lock()
+			delete(w.pending, id)
+			w.mu.Unlock()
+			if err := w.render(ctx, id); err != nil {
+				log.Printf("ERROR: Failed to pre-render tier list %s: %v", id, err)
+			}
+		}
+	}
+}
+
+func (w *Worker) render(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "prerender", trace.SpanKindInternal, attribute.String("tierlist.id", id))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	tl, err := w.store.WithContext(ctx).GetTierList(id)
+	if err != nil || tl == nil || !Shareable(tl) {
+		return err
+	}
+	for _, kind := range Kinds {
+		if _, err := Cached(ctx, w.store, tl, kind, DefaultStyle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
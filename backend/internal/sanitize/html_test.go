@@ -0,0 +1,112 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLStripsScriptVectors(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		banned []string
+	}{
+		{"script element", `<p>hi</p><script>alert(1)</script>`, []string{"<script", "alert"}},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, []string{"javascript"}},
+		{"mixed case scheme", `<a href="JaVaScRiPt:alert(1)">x</a>`, []string{"javascript", "alert"}},
+		{"scheme behind whitespace", `<a href="  javascript:alert(1)">x</a>`, []string{"javascript", "alert"}},
+		{"decimal entity scheme", `<a href="&#106;avascript:alert(1)">x</a>`, []string{"avascript", "alert"}},
+		{"hex entity scheme", `<a href="&#x6A;&#x61;vascript:alert(1)">x</a>`, []string{"vascript", "alert"}},
+		{"tab inside scheme", `<a href="jav&#x09;ascript:alert(1)">x</a>`, []string{"ascript", "alert"}},
+		{"vbscript", `<a href="vbscript:msgbox(1)">x</a>`, []string{"vbscript"}},
+		{"data url image", `<img src="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">`, []string{"data:"}},
+		{"onerror", `<img src="x.png" onerror="alert(1)">`, []string{"onerror", "alert"}},
+		{"onclick on allowed element", `<div onclick="alert(1)">x</div>`, []string{"onclick", "alert"}},
+		{"uppercase handler", `<p ONMOUSEOVER="alert(1)">x</p>`, []string{"onmouseover", "alert"}},
+		{"svg onload", `<svg onload="alert(1)"><circle r="1"/></svg>`, []string{"<svg", "onload", "alert"}},
+		{"svg script", `<svg><script>alert(1)</script></svg>`, []string{"<svg", "<script", "alert"}},
+		{"svg animate href", `<svg><a><animate attributeName="href" values="javascript:alert(1)"/><text>x</text></a></svg>`, []string{"animate", "javascript"}},
+		{"math href", `<math><mi xlink:href="javascript:alert(1)">x</mi></math>`, []string{"<math", "javascript"}},
+		{"nested comments", `<!-- <!-- --><script>alert(1)</script> -->`, []string{"<script"}},
+		{"comment hiding a tag", `<!--<img src="--><img src=x onerror=alert(1)//">`, []string{"onerror"}},
+		{"conditional comment", `<!--[if IE]><script>alert(1)</script><![endif]-->`, []string{"<script", "<!--"}},
+		{"split script tag", `<scr<script>ipt>alert(1)</script>`, []string{"<script"}},
+		{"style expression", `<div style="width: expression(alert(1))">x</div>`, []string{"expression", "alert"}},
+		{"style url", `<div style="background-color: url(javascript:alert(1))">x</div>`, []string{"url(", "javascript"}},
+		{"style element", `<style>body{background:url(javascript:alert(1))}</style>`, []string{"<style", "javascript"}},
+		{"iframe srcdoc", `<iframe srcdoc="<script>alert(1)</script>"></iframe>`, []string{"<iframe", "srcdoc", "<script"}},
+		{"form action", `<form action="javascript:alert(1)"><button>x</button></form>`, []string{"<form", "javascript"}},
+		{"meta refresh", `<meta http-equiv="refresh" content="0;url=javascript:alert(1)">`, []string{"<meta", "javascript"}},
+		{"base href", `<base href="javascript:/">`, []string{"<base", "javascript"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.ToLower(HTML(tt.in))
+			for _, banned := range tt.banned {
+				if strings.Contains(got, banned) {
+					t.Errorf("HTML(%q) = %q, still contains %q", tt.in, got, banned)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLKeepsInfoboxMarkup(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"table with styles", `<table class="infobox"><tr><td style="color: red; text-align: center">x</td></tr></table>`,
+			`<table class="infobox"><tr><td style="color: red; text-align: center">x</td></tr></table>`},
+		{"disallowed style property", `<span style="position: fixed; color: blue">x</span>`, `<span style="color: blue">x</span>`},
+		{"external link", `<a href="https://example.com/wiki">wiki</a>`,
+			`<a href="https://example.com/wiki" rel="nofollow noreferrer noopener" target="_blank">wiki</a>`},
+		{"relative image", `<img src="/icons/a.png" alt="A">`, `<img src="/icons/a.png" alt="A">`},
+		{"text is escaped", `a < b & c`, `a &lt; b &amp; c`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.in); got != tt.want {
+				t.Errorf("HTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"https://example.com/bg.png", true},
+		{"http://example.com/bg.png", true},
+		{"/static/bg.png", true},
+		{"bg.png", true},
+		{"//evil.example/bg.png", false},
+		{"javascript:alert(1)", false},
+		{" JAVASCRIPT:alert(1)", false},
+		{"data:image/png;base64,AAAA", false},
+	}
+	for _, tt := range tests {
+		if got := URL(tt.raw); got != tt.want {
+			t.Errorf("URL(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestItemData(t *testing.T) {
+	data := map[string]interface{}{
+		"infobox_html": `<b onclick="x()">Bold</b>`,
+		"power":        3,
+	}
+	if !ItemData(data) {
+		t.Fatal("ItemData reported no change for unsafe HTML")
+	}
+	if got := data["infobox_html"]; got != "<b>Bold</b>" {
+		t.Errorf("infobox_html = %q, want %q", got, "<b>Bold</b>")
+	}
+	if ItemData(data) {
+		t.Error("ItemData changed already clean HTML")
+	}
+}
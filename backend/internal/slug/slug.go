@@ -0,0 +1,69 @@
+// Package slug derives URL-safe references and catalog item IDs from names,
+// so importers and the admin API agree on one ID scheme.
+package slug
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var separators = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// Make lowercases s and joins its runs of letters and digits with "-"
+func Make(s string) string {
+	return strings.Trim(separators.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// Join builds an ID from parts: each is transliterated to Latin letters and
+// slugged, and the non-empty ones are joined with "-"
+func Join(parts ...string) string {
+	slugs := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if s := Make(Transliterate(part)); s != "" {
+			slugs = append(slugs, s)
+		}
+	}
+	return strings.Join(slugs, "-")
+}
+
+// ItemID is the ID of a new catalog item with no natural prefix of its own.
+// Item IDs are unique across games, so the game and sheet are part of it.
+func ItemID(gameID, sheetID, name string) string {
+	return Join(gameID, sheetID, name)
+}
+
+// cyrillic maps lowercase Cyrillic letters to Latin after GOST 7.79-2000
+// system B, leaving out its apostrophes, which slugs drop anyway
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "x", 'ц': "cz", 'ч': "ch", 'ш': "sh", 'щ': "shh", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// Transliterate spells Russian text in Latin letters. Other characters are
+// kept as they are, and capitalized letters stay capitalized.
+func Transliterate(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		lower := unicode.ToLower(r)
+		latin, ok := cyrillic[lower]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+		// GOST writes ц as c before е, и, ы and й
+		if lower == 'ц' && i+1 < len(runes) && strings.ContainsRune("еиый", unicode.ToLower(runes[i+1])) {
+			latin = "c"
+		}
+		if r != lower && latin != "" {
+			latin = strings.ToUpper(latin[:1]) + latin[1:]
+		}
+		b.WriteString(latin)
+	}
+	return b.String()
+}
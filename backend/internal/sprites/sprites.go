@@ -0,0 +1,298 @@
+// Package sprites packs the item icons of a sheet into a single sprite sheet
+// image with a coordinate map, so the item pool loads one image instead of
+// hundreds. Sheets are rebuilt in the background when their icons change,
+// reusing the cells of icons that did not.
+package sprites
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/draw"
+	_ "image/gif"  // Icon formats
+	_ "image/jpeg" // Icon formats
+	"image/png"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/meur/tierforge/internal/icons"
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/storage"
+)
+
+const (
+	// CellSize is the edge length of each icon in a sprite sheet, in pixels
+	CellSize = 64
+	// columns is the number of cells per sprite sheet row
+	columns = 32
+	// fetchers is how many icons are downloaded at once
+	fetchers = 8
+	// queueSize bounds pending rebuilds
+	queueSize = 64
+)
+
+type key struct{ gameID, sheetID string }
+
+// Builder rebuilds sprite sheets from a queue on one goroutine
+type Builder struct {
+	store  *storage.Store
+	client *http.Client
+	base   *url.URL // Resolves site-relative icon paths
+	queue  chan key
+
+	mu      sync.Mutex
+	pending map[key]bool
+}
+
+// NewBuilder creates a builder; site-relative icons are fetched from publicURL
+func NewBuilder(store *storage.Store, publicURL string) *Builder {
+	base, _ := url.Parse(publicURL + "/")
+	return &Builder{
+		store:   store,
+		client:  &http.Client{Timeout: 15 * time.Second},
+		base:    base,
+		queue:   make(chan key, queueSize),
+		pending: make(map[key]bool),
+	}
+}
+
+// Start runs queued rebuilds until ctx is cancelled
+func (b *Builder) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case k := <-b.queue:
+				b.mu.Lock()
+				delete(b.pending, k)
+				b.mu.Unlock()
+				if err := b.Build(ctx, k.gameID, k.sheetID); err != nil {
+					log.Printf("ERROR: Failed to build sprite sheet %s/%s: %v", k.gameID, k.sheetID, err)
+				}
+			}
+		}
+	}()
+}
+
+// Enqueue schedules a rebuild of one sheet. It never blocks; when the queue
+// is full the rebuild happens on the next request for the sheet instead.
+func (b *Builder) Enqueue(gameID, sheetID string) {
+	k := key{gameID, sheetID}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending[k] {
+		return
+	}
+	select {
+	case b.queue <- k:
+		b.pending[k] = true
+	default:
+	}
+}
+
+// EnqueueGame schedules a rebuild of every sheet of a game
+func (b *Builder) EnqueueGame(game *models.Game) {
+	for _, sheet := range game.Sheets {
+		b.Enqueue(game.ID, sheet.ID)
+	}
+}
+
+// Spritable returns the items of a sheet that belong in its sprite sheet
+func Spritable(items []models.Item) []models.Item {
+	var out []models.Item
+	for _, item := range items {
+		if item.Icon != "" && !item.Spoiler {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Version identifies the set of icons a sprite sheet is built from
+func Version(items []models.Item) string {
+	pairs := make([]string, len(items))
+	for i, item := range items {
+		pairs[i] = item.ID + "\x00" + item.Icon
+	}
+	sort.Strings(pairs)
+	h := sha256.New()
+	for _, p := range pairs {
+		io.WriteString(h, p+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Build regenerates the sprite sheet of a sheet unless it is already current
+func (b *Builder) Build(ctx context.Context, gameID, sheetID string) error {
+	all, err := b.store.GetItems(gameID, sheetID)
+	if err != nil {
+		return err
+	}
+	items := Spritable(all)
+	version := Version(items)
+
+	prev, err := b.store.GetSpriteSheet(gameID, sheetID)
+	if err != nil {
+		return err
+	}
+	if prev != nil && prev.Version == version {
+		return nil
+	}
+	var prevImg image.Image
+	if prev != nil {
+		data, _, err := b.store.GetSpriteImage(gameID, sheetID)
+		if err != nil {
+			return err
+		}
+		if prevImg, err = png.Decode(bytes.NewReader(data)); err != nil {
+			prevImg = nil
+		}
+	}
+
+	width := columns
+	if len(items) < columns {
+		width = len(items)
+	}
+	rows := (len(items) + columns - 1) / columns
+	img := image.NewRGBA(image.Rect(0, 0, max(width, 1)*CellSize, max(rows, 1)*CellSize))
+
+	// Reuse cells of unchanged icons and download the rest
+	icons := make([]image.Image, len(items))
+	var fetch []int
+	for i, item := range items {
+		if prevImg != nil && prev.CellSize == CellSize {
+			if cell, ok := prev.Cells[item.ID]; ok && cell.Icon == item.Icon {
+				icons[i] = subImage(prevImg, image.Rect(cell.X, cell.Y, cell.X+CellSize, cell.Y+CellSize))
+				continue
+			}
+		}
+		fetch = append(fetch, i)
+	}
+	b.fetchAll(ctx, items, fetch, icons)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sheet := &models.SpriteSheet{
+		GameID:   gameID,
+		SheetID:  sheetID,
+		Version:  version,
+		CellSize: CellSize,
+		Width:    img.Bounds().Dx(),
+		Height:   img.Bounds().Dy(),
+		Cells:    make(map[string]models.SpriteCell, len(items)),
+	}
+	failed := 0
+	for i, item := range items {
+		if icons[i] == nil {
+			failed++
+			continue
+		}
+		x, y := (i%columns)*CellSize, (i/columns)*CellSize
+		fit(img, image.Rect(x, y, x+CellSize, y+CellSize), icons[i])
+		sheet.Cells[item.ID] = models.SpriteCell{X: x, Y: y, Icon: item.Icon}
+	}
+	if failed > 0 {
+		log.Printf("WARNING: Sprite sheet %s/%s: %d of %d icons could not be loaded", gameID, sheetID, failed, len(items))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return b.store.SaveSpriteSheet(sheet, buf.Bytes())
+}
+
+// Icons cuts the cells of items out of a sprite sheet image and encodes each
+// as a PNG. Items whose icon changed since the sheet was built are skipped.
+func Icons(sheet *models.SpriteSheet, data []byte, items []models.Item) (map[string][]byte, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	icons := make(map[string][]byte)
+	for _, item := range items {
+		cell, ok := sheet.Cells[item.ID]
+		if !ok || cell.Icon != item.Icon {
+			continue
+		}
+		var buf bytes.Buffer
+		icon := subImage(img, image.Rect(cell.X, cell.Y, cell.X+sheet.CellSize, cell.Y+sheet.CellSize))
+		if err := png.Encode(&buf, icon); err != nil {
+			return nil, err
+		}
+		icons[item.ID] = buf.Bytes()
+	}
+	return icons, nil
+}
+
+// fetchAll downloads the icons of items[i] for each i in indexes into icons,
+// leaving nil for icons that fail
+func (b *Builder) fetchAll(ctx context.Context, items []models.Item, indexes []int, icons []image.Image) {
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < fetchers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				icon, err := b.fetch(ctx, items[i].Icon)
+				if err != nil {
+					log.Printf("Sprite icon %s: %v", items[i].ID, err)
+					continue
+				}
+				icons[i] = icon
+			}
+		}()
+	}
+	for _, i := range indexes {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+}
+
+// fetch downloads and decodes one icon
+func (b *Builder) fetch(ctx context.Context, icon string) (image.Image, error) {
+	return icons.Fetch(ctx, b.client, b.base, icon)
+}
+
+// subImage copies r out of img so it stays valid after img is dropped
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	out := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(out, out.Bounds(), img, r.Min, draw.Src)
+	return out
+}
+
+// fit scales src into cell with nearest-neighbour sampling, preserving its
+// aspect ratio and centering it
+func fit(dst *image.RGBA, cell image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	if sb.Dx() == 0 || sb.Dy() == 0 {
+		return
+	}
+	w, h := cell.Dx(), cell.Dy()
+	if sb.Dx() > sb.Dy() {
+		h = max(h*sb.Dy()/sb.Dx(), 1)
+	} else {
+		w = max(w*sb.Dx()/sb.Dy(), 1)
+	}
+	ox, oy := cell.Min.X+(cell.Dx()-w)/2, cell.Min.Y+(cell.Dy()-h)/2
+	for y := 0; y < h; y++ {
+		sy := sb.Min.Y + y*sb.Dy()/h
+		for x := 0; x < w; x++ {
+			dst.Set(ox+x, oy+y, src.At(sb.Min.X+x*sb.Dx()/w, sy))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+)
+
+type aggregateKey struct {
+	gameID, sheetID, itemID string
+}
+
+// RecomputeCommunityAggregates rebuilds the community consensus table from
+// all public tier lists and returns the number of aggregate rows written
+func (s *Store) RecomputeCommunityAggregates() (int, error) {
+	rows, err := s.db.Query(`SELECT ` + tierListColumns + ` FROM tierlists WHERE is_public = 1 AND is_hidden = 0 AND status = 'published'`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	sums := make(map[aggregateKey]float64)
+	counts := make(map[aggregateKey]int)
+	for rows.Next() {
+		tl, err := scanTierList(rows)
+		if err != nil {
+			return 0, err
+		}
+		for itemID, score := range ranking.Scores(tl) {
+			key := aggregateKey{tl.GameID, tl.SheetID, itemID}
+			sums[key] += score
+			counts[key]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM community_aggregates`); err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO community_aggregates (game_id, sheet_id, item_id, score, list_count, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for key, sum := range sums {
+		count := counts[key]
+		if _, err := stmt.Exec(key.gameID, key.sheetID, key.itemID, sum/float64(count), count, now); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(sums), tx.Commit()
+}
+
+// GetCommunityAggregates returns the community consensus for a sheet, best first
+func (s *Store) GetCommunityAggregates(gameID, sheetID string) ([]models.ItemAggregate, error) {
+	rows, err := s.db.Query(`
+		SELECT game_id, sheet_id, item_id, score, list_count, updated_at
+		FROM community_aggregates WHERE game_id = ? AND sheet_id = ?
+		ORDER BY score DESC, list_count DESC
+	`, gameID, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregates := make([]models.ItemAggregate, 0)
+	for rows.Next() {
+		var a models.ItemAggregate
+		if err := rows.Scan(&a.GameID, &a.SheetID, &a.ItemID, &a.Score, &a.ListCount, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, rows.Err()
+}
+
+// RefreshTrendingScores sets each tier list's trending score to the number of
+// distinct visitors seen since the given time, decayed by list age in days
+func (s *Store) RefreshTrendingScores(since time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+		UPDATE tierlists SET trending_score = (
+			SELECT COUNT(*) FROM tierlist_views v
+			WHERE v.tierlist_id = tierlists.id AND v.viewed_at >= ?
+		) / (1.0 + (julianday('now') - julianday(updated_at)) / 7.0)
+	`, since)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,60 @@
+package storage
+
+// setItemAliases replaces the aliases of a catalog item
+func setItemAliases(db execer, gameID, itemID string, aliases []string) error {
+	if _, err := db.Exec(`DELETE FROM item_aliases WHERE game_id = ? AND item_id = ?`, gameID, itemID); err != nil {
+		return err
+	}
+	for _, alias := range aliases {
+		if _, err := db.Exec(`
+			INSERT OR IGNORE INTO item_aliases (game_id, item_id, alias) VALUES (?, ?, ?)
+		`, gameID, itemID, alias); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetItemAliases replaces the aliases of a catalog item. Item writes leave
+// aliases alone, so imports never drop the ones curators added.
+func (s *Store) SetItemAliases(gameID, itemID string, aliases []string) error {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setItemAliases(tx, gameID, itemID, aliases); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadItemAliases returns the aliases of a game's items keyed by item ID. An
+// empty itemID loads the whole game.
+func (s *Store) loadItemAliases(gameID, itemID string) (map[string][]string, error) {
+	query := `SELECT item_id, alias FROM item_aliases WHERE game_id = ?`
+	args := []interface{}{gameID}
+	if itemID != "" {
+		query += ` AND item_id = ?`
+		args = append(args, itemID)
+	}
+	query += ` ORDER BY alias`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string][]string)
+	for rows.Next() {
+		var id, alias string
+		if err := rows.Scan(&id, &alias); err != nil {
+			return nil, err
+		}
+		aliases[id] = append(aliases[id], alias)
+	}
+	return aliases, rows.Err()
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+const auditColumns = `id, actor, action, target_type, target_id, game_id, details, before_json, after_json, created_at`
+
+func scanAuditEntry(row rowScanner) (*models.AuditEntry, error) {
+	var e models.AuditEntry
+	var gameID, details, before, after sql.NullString
+	err := row.Scan(&e.ID, &e.Actor, &e.Action, &e.TargetType, &e.TargetID,
+		&gameID, &details, &before, &after, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.GameID = gameID.String
+	e.Details = details.String
+	if before.Valid {
+		e.Before = []byte(before.String)
+	}
+	if after.Valid {
+		e.After = []byte(after.String)
+	}
+	return &e, nil
+}
+
+// nullableJSON stores empty snapshots as NULL
+func nullableJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+// AddAuditEntry appends an entry to the audit log
+func (s *Store) AddAuditEntry(e *models.AuditEntry) error {
+	e.CreatedAt = time.Now()
+	res, err := s.db.Exec(`
+		INSERT INTO audit_log (actor, action, target_type, target_id, game_id, details, before_json, after_json, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.Actor, e.Action, e.TargetType, e.TargetID, e.GameID, e.Details,
+		nullableJSON(e.Before), nullableJSON(e.After), e.CreatedAt)
+	if err != nil {
+		return err
+	}
+	e.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetAuditEntry returns a single audit entry, or nil if not found
+func (s *Store) GetAuditEntry(id int64) (*models.AuditEntry, error) {
+	e, err := scanAuditEntry(s.db.QueryRow(`SELECT `+auditColumns+` FROM audit_log WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return e, err
+}
+
+// GetAuditEntries returns audit entries matching the filter, newest first
+func (s *Store) GetAuditEntries(f models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `SELECT ` + auditColumns + ` FROM audit_log WHERE 1 = 1`
+	args := []interface{}{}
+	for _, cond := range []struct{ column, value string }{
+		{"actor", f.Actor},
+		{"action", f.Action},
+		{"target_type", f.TargetType},
+		{"target_id", f.TargetID},
+		{"game_id", f.GameID},
+	} {
+		if cond.value != "" {
+			query += ` AND ` + cond.column + ` = ?`
+			args = append(args, cond.value)
+		}
+	}
+	if !f.Since.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, f.Since)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
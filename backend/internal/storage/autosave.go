@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SaveAutosave stores in-progress state for a tier list without changing the
+// saved tiers or updated_at
+func (s *Store) SaveAutosave(tierListID string, state *models.TierListAutosave) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`UPDATE tierlists SET autosave = ? WHERE id = ?`, data, tierListID)
+	return err
+}
+
+// GetAutosave returns the stored autosave of a tier list, or nil if none
+func (s *Store) GetAutosave(tierListID string) (*models.TierListAutosave, error) {
+	var data sql.NullString
+	err := s.db.QueryRow(`SELECT autosave FROM tierlists WHERE id = ?`, tierListID).Scan(&data)
+	if err == sql.ErrNoRows || (err == nil && !data.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state models.TierListAutosave
+	if err := json.Unmarshal([]byte(data.String), &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// ClearAutosave removes the stored autosave of a tier list
+func (s *Store) ClearAutosave(tierListID string) error {
+	_, err := s.db.Exec(`UPDATE tierlists SET autosave = NULL WHERE id = ?`, tierListID)
+	return err
+}
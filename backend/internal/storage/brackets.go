@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// CreateBracket stores a seeded bracket and its matches, filling in their IDs
+func (s *Store) CreateBracket(b *models.Bracket) error {
+	b.ID = uuid.New().String()
+	b.CreatedAt = time.Now()
+	seeds, _ := json.Marshal(b.Seeds)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO brackets (id, game_id, sheet_id, game_version, author_id, seeds, rounds, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, b.ID, b.GameID, b.SheetID, b.GameVersion, b.AuthorID, string(seeds), b.Rounds, b.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for i := range b.Matches {
+		m := &b.Matches[i]
+		var decidedAt *time.Time
+		if m.WinnerID != nil {
+			decidedAt = &b.CreatedAt
+		}
+		res, err := tx.Exec(`
+			INSERT INTO bracket_matches (bracket_id, round, slot, item_a, item_b, winner_id, decided_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, b.ID, m.Round, m.Slot, m.ItemA, m.ItemB, m.WinnerID, decidedAt)
+		if err != nil {
+			return err
+		}
+		if m.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBracket returns a bracket with its matches, or nil if not found
+func (s *Store) GetBracket(id string) (*models.Bracket, error) {
+	var b models.Bracket
+	var authorID, tierListID sql.NullString
+	var seeds string
+	err := s.db.QueryRow(`
+		SELECT id, game_id, sheet_id, game_version, author_id, tierlist_id, seeds, rounds, created_at
+		FROM brackets WHERE id = ?
+	`, id).Scan(&b.ID, &b.GameID, &b.SheetID, &b.GameVersion, &authorID, &tierListID, &seeds, &b.Rounds, &b.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authorID.Valid {
+		b.AuthorID = &authorID.String
+	}
+	if tierListID.Valid {
+		b.TierListID = &tierListID.String
+	}
+	if err := decodeColumn("bracket", b.ID, "seeds", seeds, &b.Seeds); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, round, slot, item_a, item_b, winner_id
+		FROM bracket_matches WHERE bracket_id = ? ORDER BY round, slot
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	b.Matches = make([]models.BracketMatch, 0)
+	for rows.Next() {
+		var m models.BracketMatch
+		var itemA, itemB, winnerID sql.NullString
+		if err := rows.Scan(&m.ID, &m.Round, &m.Slot, &itemA, &itemB, &winnerID); err != nil {
+			return nil, err
+		}
+		if itemA.Valid {
+			m.ItemA = &itemA.String
+		}
+		if itemB.Valid {
+			m.ItemB = &itemB.String
+		}
+		if winnerID.Valid {
+			m.WinnerID = &winnerID.String
+			if m.Round == b.Rounds {
+				b.Champion = m.WinnerID
+			}
+		}
+		b.Matches = append(b.Matches, m)
+	}
+	return &b, rows.Err()
+}
+
+// SetBracketWinner records the winner of a match and moves it into the next
+// round. It returns ErrMatchDecided if the match already has a winner.
+func (s *Store) SetBracketWinner(b *models.Bracket, m *models.BracketMatch, winnerID string, next *models.BracketMatch, sideA bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE bracket_matches SET winner_id = ?, decided_at = ?
+		WHERE id = ? AND bracket_id = ? AND winner_id IS NULL
+	`, winnerID, time.Now(), m.ID, b.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrMatchDecided
+	}
+
+	if next != nil {
+		column := "item_b"
+		if sideA {
+			column = "item_a"
+		}
+		if _, err := tx.Exec(`UPDATE bracket_matches SET `+column+` = ? WHERE id = ?`, winnerID, next.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetBracketTierList links a bracket to the tier list generated from it
+func (s *Store) SetBracketTierList(bracketID, tierListID string) error {
+	_, err := s.db.Exec(`UPDATE brackets SET tierlist_id = ? WHERE id = ?`, tierListID, bracketID)
+	return err
+}
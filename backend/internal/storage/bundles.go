@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// ExportGameBundle collects a game, its catalog and item relations into a
+// bundle, or returns nil if the game does not exist
+func (s *Store) ExportGameBundle(gameID string) (*models.GameBundle, error) {
+	game, err := s.GetGame(gameID)
+	if err != nil || game == nil {
+		return nil, err
+	}
+	items, err := s.GetItems(gameID, "")
+	if err != nil {
+		return nil, err
+	}
+	relations, err := s.GetItemRelations(gameID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &models.GameBundle{
+		Format:        models.GameBundleFormat,
+		FormatVersion: models.GameBundleVersion,
+		ExportedAt:    time.Now().UTC(),
+		Game:          *game,
+		Items:         items,
+		Relations:     relations,
+	}, nil
+}
+
+// ImportGameBundle writes a validated bundle in one transaction. With replace
+// the game's existing catalog and relations are removed first; otherwise
+// bundle items are upserted next to existing ones.
+func (s *Store) ImportGameBundle(b *models.GameBundle, replace bool) error {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	gameID := b.Game.ID
+	owner, err := tx.Prepare(`SELECT game_id FROM items WHERE id = ?`)
+	if err != nil {
+		return err
+	}
+	defer owner.Close()
+	for _, item := range b.Items {
+		var otherGame string
+		err := owner.QueryRow(item.ID).Scan(&otherGame)
+		if err == nil && otherGame != gameID {
+			return fmt.Errorf("%w: %q is in %s", ErrItemIDConflict, item.ID, otherGame)
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+	}
+
+	if err := upsertGame(tx, &b.Game); err != nil {
+		return err
+	}
+	if replace {
+		for _, table := range []string{"items", "item_tags", "item_aliases", "item_relations"} {
+			if _, err := tx.Exec(`DELETE FROM `+table+` WHERE game_id = ?`, gameID); err != nil {
+				return err
+			}
+		}
+	}
+	if err := insertItems(tx, b.Items); err != nil {
+		return err
+	}
+	for _, item := range b.Items {
+		if err := setItemTags(tx, gameID, item.ID, item.Tags); err != nil {
+			return err
+		}
+		if item.Aliases != nil {
+			if err := setItemAliases(tx, gameID, item.ID, item.Aliases); err != nil {
+				return err
+			}
+		}
+	}
+	for _, rel := range b.Relations {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO item_relations (game_id, item_id, related_id, kind) VALUES (?, ?, ?, ?)
+		`, gameID, rel.ItemID, rel.RelatedID, rel.Kind); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
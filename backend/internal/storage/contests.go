@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/meur/tierforge/internal/models"
+)
+
+const contestColumns = `id, game_id, sheet_id, name, description, constraints, deadline, created_by, created_at,
+	(SELECT COUNT(*) FROM contest_submissions WHERE contest_id = contests.id)`
+
+func scanContest(row rowScanner) (*models.Contest, error) {
+	var c models.Contest
+	var constraints sql.NullString
+	err := row.Scan(&c.ID, &c.GameID, &c.SheetID, &c.Name, &c.Description, &constraints, &c.Deadline,
+		&c.CreatedBy, &c.CreatedAt, &c.Submissions)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeColumn("contest", c.ID, "constraints", constraints.String, &c.Constraints); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateContest stores a contest, filling in its ID and creation time
+func (s *Store) CreateContest(c *models.Contest) error {
+	c.ID = uuid.New().String()
+	c.CreatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO contests (id, game_id, sheet_id, name, description, constraints, deadline, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.ID, c.GameID, c.SheetID, c.Name, c.Description, constraintsJSON(c.Constraints), c.Deadline.UTC(), c.CreatedBy, c.CreatedAt)
+	return err
+}
+
+// GetContest returns a contest, or nil if not found
+func (s *Store) GetContest(id string) (*models.Contest, error) {
+	c, err := scanContest(s.db.QueryRow(`SELECT `+contestColumns+` FROM contests WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return c, err
+}
+
+// GetContests returns the contests of a game, or of every game when gameID
+// is empty, latest deadline first
+func (s *Store) GetContests(gameID string, limit int) ([]models.Contest, error) {
+	query := `SELECT ` + contestColumns + ` FROM contests`
+	var args []interface{}
+	if gameID != "" {
+		query += ` WHERE game_id = ?`
+		args = append(args, gameID)
+	}
+	query += ` ORDER BY deadline DESC, id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contests := make([]models.Contest, 0)
+	for rows.Next() {
+		c, err := scanContest(rows)
+		if err != nil {
+			return nil, err
+		}
+		contests = append(contests, *c)
+	}
+	return contests, rows.Err()
+}
+
+// DeleteContest deletes a contest and its submissions
+func (s *Store) DeleteContest(id string) error {
+	_, err := s.db.Exec(`DELETE FROM contests WHERE id = ?`, id)
+	return err
+}
+
+// SubmitToContest enters a tier list into a contest, replacing the user's
+// earlier submission
+func (s *Store) SubmitToContest(contestID string, sub *models.ContestSubmission) error {
+	tiers, _ := json.Marshal(sub.Tiers)
+	_, err := s.db.Exec(`
+		INSERT INTO contest_submissions (contest_id, user_id, tierlist_id, tiers, submitted_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (contest_id, user_id) DO UPDATE SET
+			tierlist_id = excluded.tierlist_id, tiers = excluded.tiers, submitted_at = excluded.submitted_at
+	`, contestID, sub.UserID, sub.TierListID, string(tiers), sub.SubmittedAt.UTC())
+	return err
+}
+
+// GetContestSubmissions returns the submissions to a contest, earliest first
+func (s *Store) GetContestSubmissions(contestID string) ([]models.ContestSubmission, error) {
+	rows, err := s.db.Query(`
+		SELECT cs.tierlist_id, cs.user_id, u.display_name, cs.tiers, cs.submitted_at
+		FROM contest_submissions cs JOIN users u ON u.id = cs.user_id
+		WHERE cs.contest_id = ? ORDER BY cs.submitted_at, cs.user_id
+	`, contestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := make([]models.ContestSubmission, 0)
+	for rows.Next() {
+		var sub models.ContestSubmission
+		var tiers string
+		if err := rows.Scan(&sub.TierListID, &sub.UserID, &sub.DisplayName, &tiers, &sub.SubmittedAt); err != nil {
+			return nil, err
+		}
+		if err := decodeColumn("contest submission", contestID+"/"+sub.UserID, "tiers", tiers, &sub.Tiers); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// Cursor is a position in a listing ordered by updated_at and then ID, both
+// descending. Resuming after a cursor rather than skipping an offset keeps
+// deep pages fast, and rows written meanwhile cannot shift others between
+// pages.
+type Cursor struct {
+	UpdatedAt time.Time
+	ID        string
+}
+
+// String encodes the cursor for clients, who pass it back unchanged
+func (c Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.UpdatedAt.Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+// ParseCursor decodes a cursor made by Cursor.String
+func ParseCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+	updatedAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{UpdatedAt: updatedAt, ID: id}, nil
+}
+
+// after returns the condition selecting the rows that follow c. The time is
+// bound as a time.Time so the driver formats it the way updated_at was
+// written, keeping the text comparison exact.
+func (c *Cursor) after() (string, []interface{}) {
+	return `(updated_at, id) < (?, ?)`, []interface{}{c.UpdatedAt, c.ID}
+}
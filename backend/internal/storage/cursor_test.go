@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{UpdatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), ID: "a"},
+		{UpdatedAt: time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.FixedZone("", 3*3600)), ID: "0b7e6a0c-5d1f-4f4e-9a53-1d0c1e2f3a4b"},
+		// Only the first separator splits the time from the ID
+		{UpdatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), ID: "a|b"},
+	}
+	for _, c := range tests {
+		got, err := ParseCursor(c.String())
+		if err != nil {
+			t.Errorf("ParseCursor(%v): %v", c, err)
+			continue
+		}
+		if !got.UpdatedAt.Equal(c.UpdatedAt) || got.ID != c.ID {
+			t.Errorf("ParseCursor(%v.String()) = %v", c, got)
+		}
+	}
+}
+
+func TestParseCursorErrors(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	for _, s := range []string{
+		"",
+		"not base64!",
+		encode("2026-03-01T12:00:00Z") + "==",
+		encode("2026-03-01T12:00:00Z"),
+		encode("2026-03-01T12:00:00Z|"),
+		encode("yesterday|a"),
+		encode("2026-03-01 12:00:00|a"),
+	} {
+		if _, err := ParseCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("ParseCursor(%q) = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestGetPublicTierListsPages(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.CreateGame(&models.Game{ID: "g", Name: "G"}); err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-time.Hour)
+	create := func(name, sheet, visibility, status string, updatedAt time.Time) string {
+		t.Helper()
+		tl, err := s.CreateTierList(&models.TierListCreate{
+			GameID: "g", SheetID: sheet, Name: name, Visibility: visibility, Status: status, Tags: []string{"meta"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.db.Exec(`UPDATE tierlists SET updated_at = ? WHERE id = ?`, updatedAt, tl.ID); err != nil {
+			t.Fatal(err)
+		}
+		return tl.ID
+	}
+	// Three lists share a timestamp, so pages must split ties by ID
+	var want []string
+	for i := range 5 {
+		at := base.Add(-time.Duration(min(i, 2)) * time.Minute)
+		want = append(want, create(fmt.Sprint("list ", i), "main", models.VisibilityPublic, models.TierListPublished, at))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(want[2:])))
+	create("unlisted", "main", models.VisibilityUnlisted, models.TierListPublished, base)
+	create("draft", "main", models.VisibilityPublic, models.TierListDraft, base)
+	hidden := create("hidden", "main", models.VisibilityPublic, models.TierListPublished, base)
+	if err := s.SetTierListHidden(hidden, true); err != nil {
+		t.Fatal(err)
+	}
+	create("other sheet", "other", models.VisibilityPublic, models.TierListPublished, base)
+
+	for _, limit := range []int{1, 2, 3, 5, 6} {
+		var got []string
+		var after *Cursor
+		pages := 0
+		for {
+			page, next, err := s.GetPublicTierLists(TierListQuery{GameID: "g", SheetID: "main", Tag: "meta", Limit: limit, After: after})
+			if err != nil {
+				t.Fatalf("limit %d: %v", limit, err)
+			}
+			if len(page) > limit || next != nil && len(page) != limit {
+				t.Fatalf("limit %d: page of %d, next %v", limit, len(page), next)
+			}
+			for _, summary := range page {
+				got = append(got, summary.ID)
+			}
+			pages++
+			if next == nil {
+				break
+			}
+			// Cursors survive the trip through a client
+			if after, err = ParseCursor(next.String()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("limit %d: paged through %v, want %v", limit, got, want)
+		}
+		if wantPages := (len(want) + limit - 1) / limit; pages != wantPages {
+			t.Errorf("limit %d: %d pages, want %d", limit, pages, wantPages)
+		}
+	}
+}
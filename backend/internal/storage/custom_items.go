@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Items uploaded to a custom tier list carry its ID in tierlist_id. Catalog
+// reads leave them out, and they go away with their list.
+var customItemMigrations = []string{
+	`CREATE INDEX IF NOT EXISTS idx_items_tierlist ON items(tierlist_id)`,
+	`CREATE TRIGGER IF NOT EXISTS tierlists_delete_custom_items AFTER DELETE ON tierlists BEGIN
+		DELETE FROM items WHERE tierlist_id = old.id;
+	END`,
+}
+
+// migrateCustomItems indexes uploaded items and installs the built-in
+// custom game, leaving it alone once present so admins can rename it
+func (s *Store) migrateCustomItems() error {
+	for _, m := range customItemMigrations {
+		if _, err := s.db.Exec(m); err != nil {
+			return err
+		}
+	}
+	game, err := s.GetGame(models.CustomGameID)
+	if err != nil || game != nil {
+		return err
+	}
+	custom := models.CustomGame()
+	return upsertGame(s.db, &custom)
+}
+
+// CreateCustomItem stores an item uploaded to a tier list, giving it a new
+// ID. The item must have its GameID, SheetID and TierListID set; size is
+// the bytes uploaded for it, counted against its author's quota.
+func (s *Store) CreateCustomItem(item *models.Item, size int64) error {
+	item.ID = uuid.New().String()
+	data, _ := json.Marshal(item.Data)
+	_, err := s.db.Exec(`
+		INSERT INTO items (id, game_id, sheet_id, name, icon, category, data, tierlist_id, upload_bytes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, item.ID, item.GameID, item.SheetID, item.Name, item.Icon, item.Category, data, item.TierListID, size)
+	return err
+}
+
+// GetCustomItems returns the items uploaded to a tier list, oldest first
+func (s *Store) GetCustomItems(tierListID string) ([]models.Item, error) {
+	rows, err := s.db.Query(`SELECT `+itemColumns+` FROM items WHERE tierlist_id = ? ORDER BY rowid`, tierListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.Item, 0)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// CountCustomItems returns the number of items uploaded to a tier list
+func (s *Store) CountCustomItems(tierListID string) (int, error) {
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM items WHERE tierlist_id = ?`, tierListID).Scan(&n)
+	return n, err
+}
+
+// DeleteCustomItem deletes an item uploaded to a tier list, reporting
+// whether it existed. Its image stays stored, as other items may share it.
+func (s *Store) DeleteCustomItem(tierListID, itemID string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM items WHERE tierlist_id = ? AND id = ?`, tierListID, itemID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,346 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meur/tierforge/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options tunes the SQLite connections behind a Store
+type Options struct {
+	// BusyTimeout is how long a connection waits for a lock before failing
+	// with SQLITE_BUSY; 0 keeps the driver default of 5s
+	BusyTimeout time.Duration
+	// CacheSizeKB is the page cache of each connection; 0 keeps the default
+	CacheSizeKB int
+	// Synchronous is OFF, NORMAL, FULL or EXTRA; empty keeps the default
+	Synchronous string
+	// MaxOpenConns caps the reader pool; 0 means unlimited
+	MaxOpenConns int
+	// SerializeWrites funnels all writes through one connection owned by a
+	// writer goroutine, so concurrent saves queue instead of failing
+	SerializeWrites bool
+}
+
+// DefaultOptions returns the settings used by New
+func DefaultOptions() Options {
+	return Options{BusyTimeout: 5 * time.Second, SerializeWrites: true}
+}
+
+// dsn builds the go-sqlite3 connection string for the options
+func (o Options) dsn(path string, writer bool) (string, error) {
+	params := url.Values{}
+	params.Set("_foreign_keys", "on")
+	params.Set("_journal_mode", "WAL")
+	params.Set("_recursive_triggers", "on")
+	if o.BusyTimeout > 0 {
+		params.Set("_busy_timeout", fmt.Sprint(o.BusyTimeout.Milliseconds()))
+	}
+	if o.CacheSizeKB > 0 {
+		// Negative cache sizes are in KiB rather than pages
+		params.Set("_cache_size", fmt.Sprint(-o.CacheSizeKB))
+	}
+	if o.Synchronous != "" {
+		switch strings.ToUpper(o.Synchronous) {
+		case "OFF", "NORMAL", "FULL", "EXTRA":
+			params.Set("_synchronous", strings.ToUpper(o.Synchronous))
+		default:
+			return "", fmt.Errorf("synchronous must be OFF, NORMAL, FULL or EXTRA, got %q", o.Synchronous)
+		}
+	}
+	if writer {
+		// Take the write lock up front; upgrading a read transaction fails
+		// immediately when another connection has written in between
+		params.Set("_txlock", "immediate")
+	}
+	return path + "?" + params.Encode(), nil
+}
+
+// database is the Store's handle. Reads use the embedded pool; with
+// serialized writes, Exec runs on the writer goroutine and Begin on the
+// single writer connection. The handle of a Store made by WithTx runs
+// everything in its transaction instead.
+type database struct {
+	*sql.DB
+	writes *sql.DB // nil unless writes are serialized
+	jobs   chan writeJob
+	wg     sync.WaitGroup
+
+	tx         *sql.Tx // set for handles bound to a transaction
+	savepoints int
+
+	// ctx is set for handles made by WithContext or WithTx; their
+	// statements are traced as children of its span
+	ctx context.Context
+}
+
+// txn is a write transaction: a *sql.Tx, or a savepoint when a transaction
+// is begun on a handle already bound to one
+type txn interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+	Prepare(query string) (*sql.Stmt, error)
+	Commit() error
+	Rollback() error
+}
+
+type writeJob struct {
+	query string
+	args  []interface{}
+	done  chan writeResult
+}
+
+type writeResult struct {
+	res sql.Result
+	err error
+}
+
+func openDatabase(path string, opts Options) (*database, error) {
+	dsn, err := opts.dsn(path, false)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	d := &database{DB: db}
+	if !opts.SerializeWrites {
+		return d, nil
+	}
+
+	dsn, _ = opts.dsn(path, true)
+	writes, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	writes.SetMaxOpenConns(1)
+	writes.SetMaxIdleConns(1)
+	writes.SetConnMaxLifetime(0)
+	d.writes = writes
+	d.jobs = make(chan writeJob)
+	d.wg.Add(1)
+	go d.writer()
+	return d, nil
+}
+
+// writer executes queued statements one at a time. Transactions share the
+// same connection, so a statement waits for any open transaction to finish.
+func (d *database) writer() {
+	defer d.wg.Done()
+	for job := range d.jobs {
+		res, err := d.writes.Exec(job.query, job.args...)
+		job.done <- writeResult{res, err}
+	}
+}
+
+// withContext returns a handle sharing d's connections whose statements are
+// traced under ctx
+func (d *database) withContext(ctx context.Context) *database {
+	return &database{DB: d.DB, writes: d.writes, jobs: d.jobs, ctx: ctx}
+}
+
+// trace starts a span for a statement when the handle has a context, and
+// returns a no-op span otherwise
+func (d *database) trace(query string) trace.Span {
+	if d.ctx == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	query = strings.Join(strings.Fields(query), " ")
+	op, _, _ := strings.Cut(query, " ")
+	if len(query) > maxTracedQuery {
+		query = query[:maxTracedQuery] + "..."
+	}
+	_, span := tracing.Start(d.ctx, strings.ToUpper(op), trace.SpanKindClient,
+		attribute.String("db.system.name", "sqlite"),
+		attribute.String("db.operation.name", strings.ToUpper(op)),
+		attribute.String("db.query.text", query))
+	return span
+}
+
+// maxTracedQuery caps the statement text recorded on spans
+const maxTracedQuery = 2000
+
+// Exec runs a write statement, retrying while the database is busy
+func (d *database) Exec(query string, args ...interface{}) (res sql.Result, err error) {
+	span := d.trace(query)
+	defer span.End()
+	if d.tx != nil {
+		res, err = d.tx.Exec(query, args...)
+		tracing.RecordError(span, err)
+		return res, err
+	}
+	err = retryBusy(func() error {
+		res, err = d.exec(query, args...)
+		return err
+	})
+	tracing.RecordError(span, err)
+	return res, err
+}
+
+func (d *database) exec(query string, args ...interface{}) (sql.Result, error) {
+	if d.jobs == nil {
+		return d.DB.Exec(query, args...)
+	}
+	done := make(chan writeResult, 1)
+	d.jobs <- writeJob{query: query, args: args, done: done}
+	r := <-done
+	return r.res, r.err
+}
+
+// Query runs a read query. Its span ends when the query has started, so
+// it does not include reading the rows.
+func (d *database) Query(query string, args ...interface{}) (rows *sql.Rows, err error) {
+	span := d.trace(query)
+	defer span.End()
+	if d.tx != nil {
+		rows, err = d.tx.Query(query, args...)
+	} else {
+		rows, err = d.DB.Query(query, args...)
+	}
+	tracing.RecordError(span, err)
+	return rows, err
+}
+
+// QueryRow runs a read query returning at most one row
+func (d *database) QueryRow(query string, args ...interface{}) *sql.Row {
+	span := d.trace(query)
+	defer span.End()
+	if d.tx != nil {
+		return d.tx.QueryRow(query, args...)
+	}
+	return d.DB.QueryRow(query, args...)
+}
+
+// QueryRowContext runs a read query returning at most one row
+func (d *database) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	span := d.trace(query)
+	defer span.End()
+	if d.tx != nil {
+		return d.tx.QueryRowContext(ctx, query, args...)
+	}
+	return d.DB.QueryRowContext(ctx, query, args...)
+}
+
+// Begin starts a write transaction, retrying while the write lock is taken.
+// Statements inside the transaction are not retried.
+func (d *database) Begin() (txn, error) {
+	if d.tx != nil {
+		d.savepoints++
+		sp := &savepoint{tx: d.tx, name: fmt.Sprintf("sp%d", d.savepoints)}
+		if _, err := d.tx.Exec("SAVEPOINT " + sp.name); err != nil {
+			return nil, err
+		}
+		return sp, nil
+	}
+	return d.beginTx(context.Background())
+}
+
+func (d *database) beginTx(ctx context.Context) (tx *sql.Tx, err error) {
+	err = retryBusy(func() error {
+		if d.writes == nil {
+			tx, err = d.DB.BeginTx(ctx, nil)
+		} else {
+			tx, err = d.writes.BeginTx(ctx, nil)
+		}
+		return err
+	})
+	return tx, err
+}
+
+// savepoint nests a transaction inside one already open, so that store
+// methods running their own transaction compose under WithTx
+type savepoint struct {
+	tx   *sql.Tx
+	name string
+	done bool
+}
+
+func (sp *savepoint) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return sp.tx.Exec(query, args...)
+}
+
+func (sp *savepoint) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return sp.tx.Query(query, args...)
+}
+
+func (sp *savepoint) QueryRow(query string, args ...interface{}) *sql.Row {
+	return sp.tx.QueryRow(query, args...)
+}
+
+func (sp *savepoint) Prepare(query string) (*sql.Stmt, error) {
+	return sp.tx.Prepare(query)
+}
+
+// Commit releases the savepoint; its changes commit with the transaction
+func (sp *savepoint) Commit() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+	_, err := sp.tx.Exec("RELEASE " + sp.name)
+	return err
+}
+
+// Rollback undoes the changes made since the savepoint
+func (sp *savepoint) Rollback() error {
+	if sp.done {
+		return sql.ErrTxDone
+	}
+	sp.done = true
+	if _, err := sp.tx.Exec("ROLLBACK TO " + sp.name); err != nil {
+		return err
+	}
+	_, err := sp.tx.Exec("RELEASE " + sp.name)
+	return err
+}
+
+const (
+	// busyRetries is how many times a busy write is retried
+	busyRetries = 5
+	// busyBackoff is the first delay between retries; it doubles each time
+	busyBackoff = 25 * time.Millisecond
+)
+
+// retryBusy runs op until it succeeds, fails with an error other than BUSY
+// or LOCKED, or runs out of retries. Delays back off exponentially with
+// jitter so contending writers do not retry in lockstep. Contention that
+// outlasts the retries is logged and returned wrapped in ErrBusy.
+func retryBusy(op func() error) error {
+	backoff := busyBackoff
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if err == nil || !isBusy(err) {
+			return err
+		}
+		if attempt == busyRetries {
+			log.Printf("WARNING: Database still busy after %d retries: %v", busyRetries, err)
+			return fmt.Errorf("%w: %v", ErrBusy, err)
+		}
+		time.Sleep(backoff/2 + rand.N(backoff))
+		backoff *= 2
+	}
+}
+
+// Close stops the writer and closes all connections
+func (d *database) Close() error {
+	if d.jobs != nil {
+		close(d.jobs)
+		d.wg.Wait()
+		d.writes.Close()
+	}
+	return d.DB.Close()
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// DigestActivity is new activity on one tier list for the weekly digest
+type DigestActivity struct {
+	TierListID   string
+	Name         string
+	ShareCode    string
+	NewViews     int
+	NewFavorites int
+}
+
+// GetDigestRecipients returns verified users who opted in to the weekly digest
+// and have not received one since the given time
+func (s *Store) GetDigestRecipients(notSince time.Time) ([]models.User, error) {
+	rows, err := s.db.Query(`
+		SELECT `+userColumns+` FROM users
+		WHERE weekly_digest = 1 AND email_verified_at IS NOT NULL
+			AND (digest_sent_at IS NULL OR digest_sent_at < ?)
+		ORDER BY created_at
+	`, notSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+// GetDigestActivity returns the user's tier lists that gained views or
+// favorites since the given time, busiest first. Views count distinct
+// visitors, matching how view_count is debounced.
+func (s *Store) GetDigestActivity(userID string, since time.Time) ([]DigestActivity, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.name, t.share_code,
+			(SELECT COUNT(*) FROM tierlist_views v WHERE v.tierlist_id = t.id AND v.viewed_at >= ?1) AS views,
+			(SELECT COUNT(*) FROM favorites f
+				WHERE f.target_type = 'tierlist' AND f.target_id = t.id AND f.created_at >= ?1) AS favorites
+		FROM tierlists t
+		WHERE t.author_id = ?2
+		GROUP BY t.id
+		HAVING views > 0 OR favorites > 0
+		ORDER BY views + favorites DESC, t.updated_at DESC
+	`, since, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	activity := make([]DigestActivity, 0)
+	for rows.Next() {
+		var a DigestActivity
+		if err := rows.Scan(&a.TierListID, &a.Name, &a.ShareCode, &a.NewViews, &a.NewFavorites); err != nil {
+			return nil, err
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
+
+// MarkDigestSent records when a user last received the weekly digest
+func (s *Store) MarkDigestSent(userID string, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE users SET digest_sent_at = ? WHERE id = ?`, at, userID)
+	return err
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Purposes of single-use email tokens
+const (
+	EmailTokenVerify      = "verify"
+	EmailTokenReset       = "reset"
+	EmailTokenEmailChange = "email_change"
+)
+
+// CreateEmailToken stores a single-use token for a user, replacing any
+// earlier unused token with the same purpose
+func (s *Store) CreateEmailToken(tokenHash, userID, purpose, email string, expiresAt time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM email_tokens WHERE user_id = ? AND purpose = ?`, userID, purpose); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO email_tokens (token_hash, user_id, purpose, email, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, tokenHash, userID, purpose, email, time.Now(), expiresAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeEmailToken deletes an unexpired token and returns its user ID and
+// email address, or empty strings if the token is unknown or expired
+func (s *Store) ConsumeEmailToken(tokenHash, purpose string) (userID, email string, err error) {
+	var expiresAt time.Time
+	err = s.db.QueryRow(`
+		DELETE FROM email_tokens WHERE token_hash = ? AND purpose = ?
+		RETURNING user_id, email, expires_at
+	`, tokenHash, purpose).Scan(&userID, &email, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if time.Now().After(expiresAt) {
+		return "", "", nil
+	}
+	return userID, email, nil
+}
+
+// MarkEmailVerified records that a user confirmed the given address. It is a
+// no-op if the account's email changed since the token was issued.
+func (s *Store) MarkEmailVerified(userID, email string) (bool, error) {
+	res, err := s.db.Exec(`
+		UPDATE users SET email_verified_at = ? WHERE id = ? AND email = ?
+	`, time.Now(), userID, email)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SetUserPreferences applies the non-nil preferences to a user
+func (s *Store) SetUserPreferences(userID string, prefs models.UserPreferences) error {
+	if prefs.WeeklyDigest != nil {
+		if _, err := s.db.Exec(`UPDATE users SET weekly_digest = ? WHERE id = ?`, *prefs.WeeklyDigest, userID); err != nil {
+			return err
+		}
+	}
+	if prefs.AnalyticsOptOut != nil {
+		if _, err := s.db.Exec(`UPDATE users SET analytics_opt_out = ? WHERE id = ?`, *prefs.AnalyticsOptOut, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
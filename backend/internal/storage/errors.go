@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrDuplicate is returned when an insert violates a uniqueness constraint
+var ErrDuplicate = errors.New("duplicate record")
+
+// ErrTooManyTags is returned when a retag would exceed the per-list tag limit
+var ErrTooManyTags = errors.New("too many tags")
+
+// ErrItemIDConflict is returned when an import reuses an item ID that
+// belongs to another game. Item IDs are unique across games.
+var ErrItemIDConflict = errors.New("item id belongs to another game")
+
+// ErrMatchDecided is returned when a bracket match already has a winner
+var ErrMatchDecided = errors.New("match already decided")
+
+// ErrInvalidCursor is returned for a page cursor that was not made by
+// Cursor.String
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrBusy is returned when a write still finds the database busy or locked
+// after retrying
+var ErrBusy = errors.New("database busy")
+
+// CorruptJSONError is returned when a stored JSON column does not decode
+type CorruptJSONError struct {
+	Table  string
+	ID     string
+	Column string
+	Err    error
+}
+
+func (e *CorruptJSONError) Error() string {
+	return fmt.Sprintf("%s %s: corrupt %s: %v", e.Table, e.ID, e.Column, e.Err)
+}
+
+func (e *CorruptJSONError) Unwrap() error {
+	return e.Err
+}
+
+// decodeColumn unmarshals the JSON stored in a column into v, naming the row
+// when it is corrupt. Empty columns leave v untouched.
+func decodeColumn(table, id, column, data string, v interface{}) error {
+	if data == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		return &CorruptJSONError{Table: table, ID: id, Column: column, Err: err}
+	}
+	return nil
+}
+
+// isBusy reports whether err is a SQLite BUSY or LOCKED failure, which a
+// later attempt may not hit
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	return false
+}
+
+// isUniqueViolation reports whether err is a SQLite UNIQUE/PRIMARY KEY failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// AddEvent records a product analytics event
+func (s *Store) AddEvent(e *models.Event) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	var props interface{}
+	if len(e.Props) > 0 {
+		b, _ := json.Marshal(e.Props)
+		props = string(b)
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO events (name, game_id, props, created_at) VALUES (?, ?, ?, ?)
+	`, e.Name, e.GameID, props, e.CreatedAt)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// EachEvent calls fn for every event recorded in [since, until), oldest
+// first, stopping at the first error
+func (s *Store) EachEvent(since, until time.Time, fn func(models.Event) error) error {
+	rows, err := s.db.Query(`
+		SELECT id, name, game_id, props, created_at FROM events
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY id
+	`, since, until)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.Event
+		var props sql.NullString
+		if err := rows.Scan(&e.ID, &e.Name, &e.GameID, &props, &e.CreatedAt); err != nil {
+			return err
+		}
+		if err := decodeColumn("event", strconv.FormatInt(e.ID, 10), "props", props.String, &e.Props); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// PruneEvents deletes events recorded before cutoff
+func (s *Store) PruneEvents(cutoff time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM events WHERE created_at < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// AddFavorite bookmarks a tier list or item for a user. Adding an existing
+// favorite is a no-op.
+func (s *Store) AddFavorite(userID string, fav *models.Favorite) error {
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO favorites (user_id, target_type, target_id, game_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, fav.TargetType, fav.TargetID, fav.GameID, time.Now())
+	return err
+}
+
+// RemoveFavorite deletes a bookmark and reports whether it existed
+func (s *Store) RemoveFavorite(userID string, fav *models.Favorite) (bool, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM favorites WHERE user_id = ? AND target_type = ? AND target_id = ? AND game_id = ?
+	`, userID, fav.TargetType, fav.TargetID, fav.GameID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetFavoriteTierLists returns the tier lists a user bookmarked that are still
+// visible, most recently bookmarked first
+func (s *Store) GetFavoriteTierLists(userID string) ([]models.TierListSummary, error) {
+	return s.querySummaries(`
+		SELECT `+prefixColumns("t", tierListColumns)+`
+		FROM favorites f JOIN tierlists t ON t.id = f.target_id
+		WHERE f.user_id = ? AND f.target_type = ? AND t.is_hidden = 0 AND t.status != ?
+		ORDER BY f.created_at DESC
+	`, userID, models.FavoriteTierList, models.TierListDraft)
+}
+
+// GetFavoriteItems returns the items a user bookmarked, most recent first
+func (s *Store) GetFavoriteItems(userID string) ([]models.Item, error) {
+	rows, err := s.db.Query(`
+		SELECT `+prefixColumns("i", itemColumns)+`
+		FROM favorites f JOIN items i ON i.game_id = f.game_id AND i.id = f.target_id
+		WHERE f.user_id = ? AND f.target_type = ?
+		ORDER BY f.created_at DESC
+	`, userID, models.FavoriteItem)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.Item, 0)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// loadFavoriteCounts returns how many users bookmarked each of the given tier lists
+func (s *Store) loadFavoriteCounts(ids []string) (map[string]int, error) {
+	counts := make(map[string]int, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	placeholders, args := idPlaceholders(ids)
+	rows, err := s.db.Query(`
+		SELECT target_id, COUNT(*) FROM favorites
+		WHERE target_type = ? AND target_id IN (`+placeholders+`)
+		GROUP BY target_id
+	`, append([]interface{}{models.FavoriteTierList}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	return counts, rows.Err()
+}
+
+// prefixColumns qualifies a comma separated column list with a table alias
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, c := range parts {
+		parts[i] = alias + "." + c
+	}
+	return strings.Join(parts, ", ")
+}
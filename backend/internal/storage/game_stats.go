@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/slug"
+)
+
+// RecordUnmatchedImports counts the items of an imported tier list that
+// matched nothing in a sheet, keyed by slug
+func (s *Store) RecordUnmatchedImports(gameID, sheetID string, refs []models.ItemRef) error {
+	now := time.Now()
+	for _, ref := range refs {
+		key := ref.Slug
+		if key == "" {
+			key = slug.Make(ref.Name)
+		}
+		if key == "" {
+			continue
+		}
+		name := ref.Name
+		if name == "" {
+			name = key
+		}
+		if _, err := s.db.Exec(`
+			INSERT INTO unmatched_imports (game_id, sheet_id, slug, name, count, last_seen_at)
+			VALUES (?, ?, ?, ?, 1, ?)
+			ON CONFLICT (game_id, sheet_id, slug) DO UPDATE SET
+				name = excluded.name, count = count + 1, last_seen_at = excluded.last_seen_at
+		`, gameID, sheetID, key, name, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetGameStats collects the curator overview of a game: catalog items per
+// sheet, tier lists created per week for the given number of weeks, and up
+// to limit most-ranked items and unmatched imports. Virtual sheets select
+// items of other sheets and are left out; imports matching an item added
+// since are dropped.
+func (s *Store) GetGameStats(game *models.Game, weeks, limit int) (*models.GameStats, error) {
+	stats := &models.GameStats{
+		GameID:           game.ID,
+		Sheets:           make([]models.SheetStats, 0, len(game.Sheets)),
+		MostRanked:       make([]models.RankedItem, 0),
+		UnmatchedImports: make([]models.UnmatchedImport, 0),
+	}
+
+	counts := make(map[string]models.SheetStats)
+	var extra []string
+	rows, err := s.db.Query(`
+		SELECT sheet_id, COUNT(*), SUM(icon = '') FROM items
+		WHERE game_id = ? AND tierlist_id = '' GROUP BY sheet_id ORDER BY sheet_id
+	`, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sheet models.SheetStats
+		if err := rows.Scan(&sheet.SheetID, &sheet.Items, &sheet.MissingIcons); err != nil {
+			return nil, err
+		}
+		counts[sheet.SheetID] = sheet
+		if game.Sheet(sheet.SheetID) == nil {
+			extra = append(extra, sheet.SheetID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, sheet := range game.Sheets {
+		if !sheet.Virtual {
+			stat := counts[sheet.ID]
+			stat.SheetID, stat.Name = sheet.ID, sheet.Name
+			stats.Sheets = append(stats.Sheets, stat)
+		}
+	}
+	for _, id := range extra {
+		stats.Sheets = append(stats.Sheets, counts[id])
+	}
+
+	if stats.ListsPerWeek, err = s.listsPerWeek(game.ID, weeks); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`
+		SELECT a.item_id, a.sheet_id, COALESCE(i.name, ''), a.list_count, a.score
+		FROM community_aggregates a
+		LEFT JOIN items i ON i.id = a.item_id AND i.game_id = a.game_id
+		WHERE a.game_id = ? ORDER BY a.list_count DESC, a.score DESC LIMIT ?
+	`, game.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item models.RankedItem
+		if err := rows.Scan(&item.ItemID, &item.SheetID, &item.Name, &item.ListCount, &item.Score); err != nil {
+			return nil, err
+		}
+		stats.MostRanked = append(stats.MostRanked, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := s.queryIDs(`SELECT name FROM items WHERE game_id = ? AND tierlist_id = ''`, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool, len(names))
+	for _, name := range names {
+		matched[slug.Make(name)] = true
+	}
+	rows, err = s.db.Query(`
+		SELECT sheet_id, slug, name, count, last_seen_at FROM unmatched_imports
+		WHERE game_id = ? ORDER BY count DESC, last_seen_at DESC
+	`, game.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() && len(stats.UnmatchedImports) < limit {
+		var u models.UnmatchedImport
+		if err := rows.Scan(&u.SheetID, &u.Slug, &u.Name, &u.Count, &u.LastSeenAt); err != nil {
+			return nil, err
+		}
+		if !matched[u.Slug] {
+			stats.UnmatchedImports = append(stats.UnmatchedImports, u)
+		}
+	}
+	return stats, rows.Err()
+}
+
+// listsPerWeek counts the tier lists of a game created in each of the last
+// weeks calendar weeks, oldest first, including weeks without any
+func (s *Store) listsPerWeek(gameID string, weeks int) ([]models.WeekCount, error) {
+	now := time.Now().UTC()
+	monday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monday = monday.AddDate(0, 0, -(int(monday.Weekday())+6)%7)
+	start := monday.AddDate(0, 0, -7*(weeks-1))
+
+	rows, err := s.db.Query(`
+		SELECT date(created_at, '-6 days', 'weekday 1') AS week, COUNT(*) FROM tierlists
+		WHERE game_id = ? AND created_at >= ? GROUP BY week
+	`, gameID, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byWeek := make(map[string]int)
+	for rows.Next() {
+		var week string
+		var n int
+		if err := rows.Scan(&week, &n); err != nil {
+			return nil, err
+		}
+		byWeek[week] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := make([]models.WeekCount, 0, weeks)
+	for week := start; !week.After(monday); week = week.AddDate(0, 0, 7) {
+		key := week.Format("2006-01-02")
+		counts = append(counts, models.WeekCount{Week: key, Lists: byWeek[key]})
+	}
+	return counts, nil
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Ping runs a trivial query on the read pool and returns how long it took
+func (s *Store) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	var one int
+	err := s.db.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+	return time.Since(start), err
+}
+
+// PingWriter runs a trivial statement through the write path, which waits
+// behind queued writes and open transactions when writes are serialized
+func (s *Store) PingWriter() (time.Duration, error) {
+	start := time.Now()
+	_, err := s.db.Exec(`SELECT 1`)
+	return time.Since(start), err
+}
+
+// SchemaVersion returns the schema version recorded in the database and the
+// version this build migrates to. A database written by a newer build
+// reports a higher applied version.
+func (s *Store) SchemaVersion() (applied, expected int, err error) {
+	applied, err = s.appliedSchemaVersion()
+	return applied, s.schemaVersion, err
+}
+
+func (s *Store) appliedSchemaVersion() (int, error) {
+	var v int
+	err := s.db.QueryRow(`PRAGMA user_version`).Scan(&v)
+	return v, err
+}
+
+// WALSize returns the size of the write-ahead log in bytes, or 0 when there
+// is none
+func (s *Store) WALSize() int64 {
+	info, err := os.Stat(s.path + "-wal")
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// QuickCheck runs SQLite's quick integrity check, returning "ok" or the first
+// problem found. It reads the whole database.
+func (s *Store) QuickCheck(ctx context.Context) (string, error) {
+	var result string
+	err := s.db.QueryRowContext(ctx, `PRAGMA quick_check(1)`).Scan(&result)
+	return result, err
+}
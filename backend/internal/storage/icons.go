@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SaveIcon stores a normalized icon under its content hash. An icon already
+// stored under the hash is kept, along with the URL it first came from.
+func (s *Store) SaveIcon(hash string, data []byte, sourceURL string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO icons (hash, data, source_url, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(hash) DO NOTHING
+	`, hash, data, sourceURL, time.Now())
+	return err
+}
+
+// GetIcon returns the image of a normalized icon, or nil
+func (s *Store) GetIcon(hash string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM icons WHERE hash = ?`, hash).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
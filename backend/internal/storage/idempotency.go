@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotentResponse is the stored outcome of a request made with an
+// Idempotency-Key. Status is 0 while the first request is still running.
+type IdempotentResponse struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// ReserveIdempotencyKey claims a key for a new request. It returns nil when
+// the key was free or expired, and the earlier request's record otherwise.
+func (s *Store) ReserveIdempotencyKey(scope, key, fingerprint string, expiresAt time.Time) (*IdempotentResponse, error) {
+	now := time.Now()
+	res, err := s.db.Exec(`
+		INSERT INTO idempotency_keys (scope, key, fingerprint, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(scope, key) DO UPDATE SET
+			fingerprint = excluded.fingerprint, status = 0, content_type = '', body = NULL,
+			created_at = excluded.created_at, expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= ?
+	`, scope, key, fingerprint, now, expiresAt, now)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return nil, err
+	}
+
+	var stored IdempotentResponse
+	err = s.db.QueryRow(`
+		SELECT fingerprint, status, content_type, body FROM idempotency_keys WHERE scope = ? AND key = ?
+	`, scope, key).Scan(&stored.Fingerprint, &stored.Status, &stored.ContentType, &stored.Body)
+	if err == sql.ErrNoRows {
+		// Released between the insert and the lookup; the caller may retry
+		return &IdempotentResponse{Fingerprint: fingerprint}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// CompleteIdempotencyKey stores the response of a reserved key for replay
+func (s *Store) CompleteIdempotencyKey(scope, key string, status int, contentType string, body []byte) error {
+	_, err := s.db.Exec(`
+		UPDATE idempotency_keys SET status = ?, content_type = ?, body = ? WHERE scope = ? AND key = ?
+	`, status, contentType, body, scope, key)
+	return err
+}
+
+// ReleaseIdempotencyKey frees a reserved key whose request did not succeed,
+// so that a retry runs again
+func (s *Store) ReleaseIdempotencyKey(scope, key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE scope = ? AND key = ? AND status = 0`, scope, key)
+	return err
+}
+
+// PruneIdempotencyKeys deletes keys that expired before now
+func (s *Store) PruneIdempotencyKeys(now time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
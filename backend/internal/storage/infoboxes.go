@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SetUnmatchedInfoboxes replaces the infoboxes of a sheet that matched no
+// item, as found by the latest infobox update
+func (s *Store) SetUnmatchedInfoboxes(gameID, sheetID string, unmatched []models.UnmatchedInfobox) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM unmatched_infoboxes WHERE game_id = ? AND sheet_id = ?`, gameID, sheetID); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, u := range unmatched {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO unmatched_infoboxes (game_id, sheet_id, name, url, seen_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, gameID, sheetID, u.Name, u.URL, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetUnmatchedInfoboxes returns the infoboxes of a game's sheets that
+// matched no item, by name
+func (s *Store) GetUnmatchedInfoboxes(gameID string) ([]models.UnmatchedInfobox, error) {
+	rows, err := s.db.Query(`
+		SELECT sheet_id, name, url, seen_at FROM unmatched_infoboxes
+		WHERE game_id = ? ORDER BY sheet_id, name
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	unmatched := make([]models.UnmatchedInfobox, 0)
+	for rows.Next() {
+		var u models.UnmatchedInfobox
+		if err := rows.Scan(&u.SheetID, &u.Name, &u.URL, &u.SeenAt); err != nil {
+			return nil, err
+		}
+		unmatched = append(unmatched, u)
+	}
+	return unmatched, rows.Err()
+}
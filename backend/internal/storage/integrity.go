@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// jsonColumn is a JSON column checked by CheckJSON. id is the SQL expression
+// naming a row and decode reads the column into its model type.
+type jsonColumn struct {
+	table  string
+	id     string
+	column string
+	decode func(data string) error
+}
+
+func decodeAs[T any](data string) error {
+	var v T
+	return json.Unmarshal([]byte(data), &v)
+}
+
+var jsonColumns = []jsonColumn{
+	{"games", "id", "item_schema", decodeAs[json.RawMessage]},
+	{"games", "id", "filters", decodeAs[[]models.FilterConfig]},
+	{"games", "id", "default_tiers", decodeAs[[]models.TierConfig]},
+	{"games", "id", "sheets", decodeAs[[]models.SheetConfig]},
+	{"games", "id", "versions", decodeAs[[]models.GameVersion]},
+	{"games", "id", "theme", decodeAs[models.GameTheme]},
+	{"games", "id", "spoilers", decodeAs[models.SpoilerPolicy]},
+	{"items", "game_id || '/' || id", "data", decodeAs[map[string]interface{}]},
+	{"tierlists", "id", "tiers", decodeAs[[]models.Tier]},
+	{"tierlists", "id", "constraints", decodeAs[models.Constraints]},
+	{"tierlists", "id", "autosave", decodeAs[models.TierListAutosave]},
+	{"tier_clocks", "tierlist_id || '/' || tier_id", "vector", decodeAs[models.VectorClock]},
+	{"brackets", "id", "seeds", decodeAs[[]string]},
+	{"palettes", "id", "colors", decodeAs[[]string]},
+	{"events", "id", "props", decodeAs[map[string]interface{}]},
+	{"audit_log", "id", "before_json", decodeAs[json.RawMessage]},
+	{"audit_log", "id", "after_json", decodeAs[json.RawMessage]},
+	{"import_snapshots", "id", "items", decodeAs[[]models.Item]},
+	{"sprite_sheets", "game_id || '/' || sheet_id", "cells", decodeAs[map[string]models.SpriteCell]},
+}
+
+// CheckJSON decodes every stored JSON column into its model type and returns
+// the rows that fail. Empty and NULL columns are not reported.
+func (s *Store) CheckJSON() ([]CorruptJSONError, error) {
+	var problems []CorruptJSONError
+	for _, c := range jsonColumns {
+		rows, err := s.db.Query(`SELECT ` + c.id + `, ` + c.column + ` FROM ` + c.table + `
+			WHERE ` + c.column + ` IS NOT NULL AND ` + c.column + ` != ''`)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id, data sql.NullString
+			if err := rows.Scan(&id, &data); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if err := c.decode(data.String); err != nil {
+				problems = append(problems, CorruptJSONError{Table: c.table, ID: id.String, Column: c.column, Err: err})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return problems, nil
+}
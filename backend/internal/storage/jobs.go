@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// JobRun is the persisted record of a job's most recent execution
+type JobRun struct {
+	Name     string
+	LastRun  time.Time
+	Duration time.Duration
+	Error    string
+}
+
+// GetJobRun returns the last recorded run of a job, or nil if it never ran
+func (s *Store) GetJobRun(name string) (*JobRun, error) {
+	var run JobRun
+	var durationMS int64
+	var lastError sql.NullString
+	err := s.db.QueryRow(`
+		SELECT name, last_run_at, duration_ms, last_error FROM job_runs WHERE name = ?
+	`, name).Scan(&run.Name, &run.LastRun, &durationMS, &lastError)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	run.Duration = time.Duration(durationMS) * time.Millisecond
+	run.Error = lastError.String
+	return &run, nil
+}
+
+// RecordJobRun stores the outcome of a job execution
+func (s *Store) RecordJobRun(run *JobRun) error {
+	_, err := s.db.Exec(`
+		INSERT INTO job_runs (name, last_run_at, duration_ms, last_error)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			last_run_at = excluded.last_run_at,
+			duration_ms = excluded.duration_ms,
+			last_error = excluded.last_error
+	`, run.Name, run.LastRun, run.Duration.Milliseconds(), run.Error)
+	return err
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// RecomputeLeaderboards rebuilds the contributor leaderboards of every game
+// from the public lists of signed-in authors and returns the number of
+// entries written
+func (s *Store) RecomputeLeaderboards() (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM leaderboard_entries`); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	var total int64
+	for _, board := range []struct{ name, query string }{
+		// Each voter counts once per list
+		{models.LeaderboardMostVoted, `
+			SELECT t.game_id, ?, t.author_id, COUNT(DISTINCT t.id || '/' || v.voter_hash), COUNT(DISTINCT t.id), ?
+			FROM poll_votes v
+			JOIN tierlists t ON t.id = v.tierlist_id
+			JOIN users u ON u.id = t.author_id
+			WHERE t.is_public = 1 AND t.is_hidden = 0 AND t.status = 'published'
+			GROUP BY t.game_id, t.author_id`},
+		// Authors forking their own lists do not count
+		{models.LeaderboardMostForked, `
+			SELECT t.game_id, ?, t.author_id, COUNT(f.id), COUNT(DISTINCT t.id), ?
+			FROM tierlists f
+			JOIN tierlists t ON t.id = f.forked_from
+			JOIN users u ON u.id = t.author_id
+			WHERE t.is_public = 1 AND t.is_hidden = 0 AND t.status = 'published'
+				AND (f.author_id IS NULL OR f.author_id != t.author_id)
+			GROUP BY t.game_id, t.author_id`},
+	} {
+		res, err := tx.Exec(`
+			INSERT INTO leaderboard_entries (game_id, board, user_id, score, list_count, updated_at)
+		`+board.query, board.name, now)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, tx.Commit()
+}
+
+// GetLeaderboard returns the top entries of a game's leaderboard board
+func (s *Store) GetLeaderboard(gameID, board string, limit int) ([]models.LeaderboardEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT e.user_id, u.display_name, e.score, e.list_count, e.updated_at
+		FROM leaderboard_entries e JOIN users u ON u.id = e.user_id
+		WHERE e.game_id = ? AND e.board = ?
+		ORDER BY e.score DESC, e.list_count, e.user_id
+		LIMIT ?
+	`, gameID, board, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.LeaderboardEntry, 0)
+	for rows.Next() {
+		var e models.LeaderboardEntry
+		if err := rows.Scan(&e.UserID, &e.DisplayName, &e.Score, &e.ListCount, &e.UpdatedAt); err != nil {
+			return nil, err
+		}
+		e.Rank = len(entries) + 1
+		if prev := len(entries) - 1; prev >= 0 && entries[prev].Score == e.Score {
+			e.Rank = entries[prev].Rank
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
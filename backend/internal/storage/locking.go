@@ -0,0 +1,16 @@
+package storage
+
+import "time"
+
+// LockTierList makes a tier list read-only from now on. Locking a list
+// already locked keeps its original locked_at.
+func (s *Store) LockTierList(id string) error {
+	_, err := s.db.Exec(`UPDATE tierlists SET locked_at = COALESCE(locked_at, ?) WHERE id = ?`, time.Now(), id)
+	return err
+}
+
+// UnlockTierList makes a locked tier list editable again
+func (s *Store) UnlockTierList(id string) error {
+	_, err := s.db.Exec(`UPDATE tierlists SET locked_at = NULL WHERE id = ?`, id)
+	return err
+}
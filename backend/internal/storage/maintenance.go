@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// CleanupOrphans removes view debounce records older than the cutoff and
+// aggregates that reference items no longer in the catalog
+func (s *Store) CleanupOrphans(cutoff time.Time) (int64, error) {
+	var total int64
+
+	res, err := s.db.Exec(`DELETE FROM tierlist_views WHERE viewed_at < ?`, cutoff)
+	if err != nil {
+		return total, err
+	}
+	n, _ := res.RowsAffected()
+	total += n
+
+	res, err = s.db.Exec(`
+		DELETE FROM community_aggregates
+		WHERE item_id NOT IN (SELECT id FROM items WHERE items.game_id = community_aggregates.game_id)
+	`)
+	if err != nil {
+		return total, err
+	}
+	n, _ = res.RowsAffected()
+	total += n
+
+	return total, nil
+}
+
+// Backup writes a consistent copy of the database to path
+func (s *Store) Backup(path string) error {
+	_, err := s.db.Exec(`VACUUM INTO ?`, path)
+	return err
+}
+
+// abandonedTierLists matches anonymous tier lists unchanged since a cutoff
+// and never viewed
+const abandonedTierLists = `author_id IS NULL AND view_count = 0 AND updated_at < ?`
+
+// GetAbandonedTierLists reports the anonymous tier lists unchanged since
+// cutoff and never viewed, with the IDs of up to sample of the oldest
+func (s *Store) GetAbandonedTierLists(cutoff time.Time, sample int) (*models.RetentionReport, error) {
+	report := &models.RetentionReport{Cutoff: cutoff, ByGame: make(map[string]int), Sample: make([]string, 0)}
+	rows, err := s.db.Query(`SELECT game_id, COUNT(*) FROM tierlists WHERE `+abandonedTierLists+` GROUP BY game_id`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var gameID string
+		var n int
+		if err := rows.Scan(&gameID, &n); err != nil {
+			return nil, err
+		}
+		report.ByGame[gameID] = n
+		report.Count += n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query(`SELECT id, updated_at FROM tierlists WHERE `+abandonedTierLists+` ORDER BY updated_at LIMIT ?`, cutoff, sample)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		var updatedAt time.Time
+		if err := rows.Scan(&id, &updatedAt); err != nil {
+			return nil, err
+		}
+		if report.Oldest == nil {
+			report.Oldest = &updatedAt
+		}
+		report.Sample = append(report.Sample, id)
+	}
+	return report, rows.Err()
+}
+
+// DeleteAbandonedTierLists deletes the anonymous tier lists unchanged since
+// cutoff and never viewed, with everything that cascades from them
+func (s *Store) DeleteAbandonedTierLists(cutoff time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM tierlists WHERE `+abandonedTierLists, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// CreateMatchupSession starts a head-to-head ranking session
+func (s *Store) CreateMatchupSession(req *models.MatchupSessionCreate, authorID *string) (*models.MatchupSession, error) {
+	session := &models.MatchupSession{
+		ID:          uuid.New().String(),
+		GameID:      req.GameID,
+		SheetID:     req.SheetID,
+		GameVersion: req.GameVersion,
+		AuthorID:    authorID,
+		CreatedAt:   time.Now(),
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO matchup_sessions (id, game_id, sheet_id, game_version, author_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, session.ID, session.GameID, session.SheetID, session.GameVersion, session.AuthorID, session.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetMatchupSession returns a session with its comparison count, or nil if not found
+func (s *Store) GetMatchupSession(id string) (*models.MatchupSession, error) {
+	var session models.MatchupSession
+	var authorID, tierListID sql.NullString
+	err := s.db.QueryRow(`
+		SELECT id, game_id, sheet_id, game_version, author_id, tierlist_id, created_at,
+			(SELECT COUNT(*) FROM matchup_results WHERE session_id = matchup_sessions.id)
+		FROM matchup_sessions WHERE id = ?
+	`, id).Scan(&session.ID, &session.GameID, &session.SheetID, &session.GameVersion,
+		&authorID, &tierListID, &session.CreatedAt, &session.Comparisons)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authorID.Valid {
+		session.AuthorID = &authorID.String
+	}
+	if tierListID.Valid {
+		session.TierListID = &tierListID.String
+	}
+	return &session, nil
+}
+
+// AddMatchupResult records one pick of a session
+func (s *Store) AddMatchupResult(sessionID string, result *models.MatchupResult) error {
+	_, err := s.db.Exec(`
+		INSERT INTO matchup_results (session_id, winner_id, loser_id, created_at)
+		VALUES (?, ?, ?, ?)
+	`, sessionID, result.WinnerID, result.LoserID, time.Now())
+	return err
+}
+
+// GetMatchupResults returns the picks of a session in the order they were made
+func (s *Store) GetMatchupResults(sessionID string) ([]models.MatchupResult, error) {
+	rows, err := s.db.Query(`
+		SELECT winner_id, loser_id FROM matchup_results WHERE session_id = ? ORDER BY id
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.MatchupResult, 0)
+	for rows.Next() {
+		var r models.MatchupResult
+		if err := rows.Scan(&r.WinnerID, &r.LoserID); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// SetMatchupTierList links a session to the tier list generated from it
+func (s *Store) SetMatchupTierList(sessionID, tierListID string) error {
+	_, err := s.db.Exec(`UPDATE matchup_sessions SET tierlist_id = ? WHERE id = ?`, tierListID, sessionID)
+	return err
+}
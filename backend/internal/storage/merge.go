@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+	"unicode"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/slug"
+)
+
+// MergeItems folds dropID into keepID: tier lists referencing dropID are
+// rewritten to keepID, tags, aliases and relations are carried over and the
+// dropped item is deleted. It returns the number of tier lists rewritten.
+func (s *Store) MergeItems(gameID, keepID, dropID string) (int, error) {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rewritten, err := rewriteTierListItems(tx, gameID, keepID, dropID)
+	if err != nil {
+		return 0, err
+	}
+
+	statements := []string{
+		`INSERT OR IGNORE INTO item_tags (game_id, item_id, tag)
+			SELECT game_id, ?, tag FROM item_tags WHERE game_id = ? AND item_id = ?`,
+		`INSERT OR IGNORE INTO item_aliases (game_id, item_id, alias)
+			SELECT game_id, ?, alias FROM item_aliases WHERE game_id = ? AND item_id = ?`,
+		`UPDATE OR IGNORE item_relations SET item_id = ? WHERE game_id = ? AND item_id = ?`,
+		`UPDATE OR IGNORE item_relations SET related_id = ? WHERE game_id = ? AND related_id = ?`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, keepID, gameID, dropID); err != nil {
+			return 0, err
+		}
+	}
+
+	cleanup := []string{
+		`DELETE FROM item_tags WHERE game_id = ? AND item_id = ?`,
+		`DELETE FROM item_aliases WHERE game_id = ? AND item_id = ?`,
+		`DELETE FROM item_relations WHERE game_id = ?1 AND (item_id = ?2 OR related_id = ?2)`,
+		`DELETE FROM community_aggregates WHERE game_id = ? AND item_id = ?`,
+		`DELETE FROM items WHERE game_id = ? AND id = ?`,
+	}
+	for _, stmt := range cleanup {
+		if _, err := tx.Exec(stmt, gameID, dropID); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM item_relations WHERE game_id = ? AND item_id = related_id
+	`, gameID); err != nil {
+		return 0, err
+	}
+
+	return rewritten, tx.Commit()
+}
+
+// rewriteTierListItems replaces dropID with keepID in the tiers of every list
+// of the game and returns how many lists changed
+func rewriteTierListItems(tx txn, gameID, keepID, dropID string) (int, error) {
+	rows, err := tx.Query(`
+		SELECT id, tiers FROM tierlists WHERE game_id = ? AND instr(tiers, ?) > 0
+	`, gameID, `"`+dropID+`"`)
+	if err != nil {
+		return 0, err
+	}
+	updates := make(map[string][]models.Tier)
+	for rows.Next() {
+		var id, tiersStr string
+		if err := rows.Scan(&id, &tiersStr); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		var tiers []models.Tier
+		if err := json.Unmarshal([]byte(tiersStr), &tiers); err != nil {
+			continue
+		}
+		if replaceTierItem(tiers, keepID, dropID) {
+			updates[id] = tiers
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for id, tiers := range updates {
+		data, _ := json.Marshal(tiers)
+		if _, err := tx.Exec(`UPDATE tierlists SET tiers = ? WHERE id = ?`, data, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(updates), nil
+}
+
+// replaceTierItem swaps dropID for keepID in place. If keepID is already
+// ranked the dropped entry is removed instead. It reports whether anything changed.
+func replaceTierItem(tiers []models.Tier, keepID, dropID string) bool {
+	hasKeep := false
+	for _, t := range tiers {
+		for _, id := range t.Items {
+			if id == keepID {
+				hasKeep = true
+			}
+		}
+	}
+
+	changed := false
+	for i := range tiers {
+		items := tiers[i].Items[:0]
+		for _, id := range tiers[i].Items {
+			if id == dropID {
+				changed = true
+				if hasKeep {
+					continue
+				}
+				id = keepID
+				hasKeep = true
+			}
+			items = append(items, id)
+		}
+		tiers[i].Items = items
+	}
+	return changed
+}
+
+// RenameItem changes the ID of an item, rewriting the tier lists, tags,
+// relations, votes and favorites that reference it, and records oldID as a
+// redirect so GetItem keeps finding the item by it. It returns the number of
+// tier lists rewritten, ErrDuplicate when newID is taken and sql.ErrNoRows
+// when the game has no item oldID.
+func (s *Store) RenameItem(gameID, oldID, newID string) (int, error) {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE items SET id = ? WHERE game_id = ? AND id = ?`, newID, gameID, oldID)
+	if isUniqueViolation(err) {
+		return 0, ErrDuplicate
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+
+	rewritten, err := rewriteTierListItems(tx, gameID, newID, oldID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Item IDs are unique across games, so references need no game filter
+	statements := []string{
+		`UPDATE item_tags SET item_id = ?1 WHERE item_id = ?2`,
+		`UPDATE item_aliases SET item_id = ?1 WHERE item_id = ?2`,
+		`UPDATE item_relations SET item_id = ?1 WHERE item_id = ?2`,
+		`UPDATE item_relations SET related_id = ?1 WHERE related_id = ?2`,
+		`UPDATE community_aggregates SET item_id = ?1 WHERE item_id = ?2`,
+		`UPDATE poll_votes SET item_id = ?1 WHERE item_id = ?2`,
+		`UPDATE tier_positions SET item_id = ?1 WHERE item_id = ?2`,
+		`UPDATE matchup_results SET winner_id = ?1 WHERE winner_id = ?2`,
+		`UPDATE matchup_results SET loser_id = ?1 WHERE loser_id = ?2`,
+		`UPDATE favorites SET target_id = ?1 WHERE target_type = '` + models.FavoriteItem + `' AND target_id = ?2`,
+		`UPDATE item_id_redirects SET new_id = ?1 WHERE new_id = ?2`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, newID, oldID); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.Exec(`
+		INSERT OR REPLACE INTO item_id_redirects (game_id, old_id, new_id, created_at) VALUES (?, ?, ?, ?)
+	`, gameID, oldID, newID, time.Now()); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`DELETE FROM item_id_redirects WHERE old_id = new_id`); err != nil {
+		return 0, err
+	}
+
+	return rewritten, tx.Commit()
+}
+
+// migrateItemIDs renames items whose IDs still contain Cyrillic letters to
+// the transliterated IDs importers generate, so a re-import updates them
+// instead of adding Latin duplicates. An item whose Latin ID is taken keeps
+// its ID and is logged. It runs once, on databases from before it shipped.
+func (s *Store) migrateItemIDs() error {
+	rows, err := s.db.Query(`SELECT game_id, id FROM items`)
+	if err != nil {
+		return err
+	}
+	type itemRef struct{ gameID, id string }
+	var pending []itemRef
+	for rows.Next() {
+		var ref itemRef
+		if err := rows.Scan(&ref.gameID, &ref.id); err != nil {
+			rows.Close()
+			return err
+		}
+		if hasCyrillic(ref.id) {
+			pending = append(pending, ref)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	renamed := 0
+	for _, ref := range pending {
+		newID := slug.Join(ref.id)
+		if newID == "" || newID == ref.id {
+			log.Printf("WARNING: No Latin ID for item %s of %s", ref.id, ref.gameID)
+			continue
+		}
+		_, err := s.RenameItem(ref.gameID, ref.id, newID)
+		if err == ErrDuplicate {
+			log.Printf("WARNING: Item ID %s is taken, keeping %s of %s", newID, ref.id, ref.gameID)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		renamed++
+	}
+	if renamed > 0 {
+		log.Printf("Transliterated %d Cyrillic item IDs", renamed)
+	}
+	return nil
+}
+
+// hasCyrillic reports whether s contains a Cyrillic letter
+func hasCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
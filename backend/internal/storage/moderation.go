@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"database/sql"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/meur/tierforge/internal/models"
+)
+
+// CreateReport files a report against a tier list. Each reporter can report a
+// list once; duplicates are ignored and reported as false.
+func (s *Store) CreateReport(tierListID, reporterHash string, req *models.ReportCreate) (bool, error) {
+	res, err := s.db.Exec(`
+		INSERT OR IGNORE INTO reports (id, tierlist_id, reporter_hash, reason, details, status, created_at)
+		VALUES (?, ?, ?, ?, ?, 'open', ?)
+	`, uuid.New().String(), tierListID, reporterHash, req.Reason, req.Details, time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetReports returns reports with the given status, newest first
+func (s *Store) GetReports(status string) ([]models.Report, error) {
+	rows, err := s.db.Query(`
+		SELECT r.id, r.tierlist_id, COALESCE(t.name, ''), r.reason, COALESCE(r.details, ''), r.status, r.created_at
+		FROM reports r LEFT JOIN tierlists t ON t.id = r.tierlist_id
+		WHERE r.status = ? ORDER BY r.created_at DESC
+	`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]models.Report, 0)
+	for rows.Next() {
+		var rp models.Report
+		if err := rows.Scan(&rp.ID, &rp.TierListID, &rp.TierListName, &rp.Reason,
+			&rp.Details, &rp.Status, &rp.CreatedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, rp)
+	}
+	return reports, rows.Err()
+}
+
+// ResolveReports closes all open reports for a tier list
+func (s *Store) ResolveReports(tierListID string) error {
+	_, err := s.db.Exec(`UPDATE reports SET status = 'resolved' WHERE tierlist_id = ? AND status = 'open'`, tierListID)
+	return err
+}
+
+// SetTierListHidden hides or restores a tier list from public views
+func (s *Store) SetTierListHidden(id string, hidden bool) error {
+	_, err := s.db.Exec(`UPDATE tierlists SET is_hidden = ? WHERE id = ?`, hidden, id)
+	return err
+}
+
+// GetTierListCreator returns the IP address and device ban hash a tier
+// list was created from; either may be empty
+func (s *Store) GetTierListCreator(id string) (ip, device string, err error) {
+	var ipValue, deviceValue sql.NullString
+	err = s.db.QueryRow(`SELECT creator_ip, creator_device FROM tierlists WHERE id = ?`, id).Scan(&ipValue, &deviceValue)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return ipValue.String, deviceValue.String, err
+}
+
+// --- Bans ---
+
+const banColumns = `id, kind, value, COALESCE(reason, ''), created_by, expires_at, created_at`
+
+func scanBan(row rowScanner) (models.Ban, error) {
+	var b models.Ban
+	var expires sql.NullTime
+	err := row.Scan(&b.ID, &b.Kind, &b.Value, &b.Reason, &b.CreatedBy, &expires, &b.CreatedAt)
+	if expires.Valid {
+		b.ExpiresAt = &expires.Time
+	}
+	return b, err
+}
+
+// CreateBan adds a ban. Banning the same kind/value again replaces the
+// reason and expiry of the existing ban, whose ID is set on ban.
+func (s *Store) CreateBan(ban *models.Ban) error {
+	if ban.ID == "" {
+		ban.ID = uuid.New().String()
+	}
+	ban.CreatedAt = time.Now()
+	err := s.db.QueryRow(`
+		INSERT INTO bans (id, kind, value, reason, created_by, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(kind, value) DO UPDATE SET
+			reason = excluded.reason, created_by = excluded.created_by,
+			expires_at = excluded.expires_at, created_at = excluded.created_at
+		RETURNING id
+	`, ban.ID, ban.Kind, ban.Value, ban.Reason, ban.CreatedBy, ban.ExpiresAt, ban.CreatedAt).Scan(&ban.ID)
+	return err
+}
+
+// GetBans returns all bans, expired ones included until they are pruned,
+// newest first
+func (s *Store) GetBans() ([]models.Ban, error) {
+	rows, err := s.db.Query(`SELECT ` + banColumns + ` FROM bans ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := make([]models.Ban, 0)
+	for rows.Next() {
+		b, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// DeleteBan lifts a ban by ID
+func (s *Store) DeleteBan(id string) error {
+	_, err := s.db.Exec(`DELETE FROM bans WHERE id = ?`, id)
+	return err
+}
+
+// MatchBan returns the unexpired ban covering a request from ip by the
+// given account and device ban hash, either of which may be empty, or nil.
+// IP bans do not apply to addresses on the allow list.
+func (s *Store) MatchBan(ip, authorID, device string) (*models.Ban, error) {
+	rows, err := s.db.Query(`
+		SELECT `+banColumns+` FROM bans
+		WHERE (expires_at IS NULL OR expires_at > ?)
+			AND (kind IN (?, ?) OR (kind = ? AND value = ?) OR (kind = ? AND value = ?))
+	`, time.Now(), models.BanKindIP, models.BanKindAllowIP, models.BanKindAuthor, authorID, models.BanKindDevice, device)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addr, addrErr := netip.ParseAddr(ip)
+	var match *models.Ban
+	allowed := false
+	for rows.Next() {
+		b, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		switch b.Kind {
+		case models.BanKindAuthor, models.BanKindDevice:
+			if b.Value != "" {
+				return &b, nil
+			}
+		case models.BanKindAllowIP:
+			allowed = allowed || (addrErr == nil && b.MatchesIP(addr))
+		case models.BanKindIP:
+			if match == nil && addrErr == nil && b.MatchesIP(addr) {
+				match = &b
+			}
+		}
+	}
+	if err := rows.Err(); err != nil || allowed {
+		return nil, err
+	}
+	return match, nil
+}
+
+// PruneExpiredBans deletes bans that expired before now and returns them
+func (s *Store) PruneExpiredBans(now time.Time) ([]models.Ban, error) {
+	rows, err := s.db.Query(`
+		DELETE FROM bans WHERE expires_at IS NOT NULL AND expires_at <= ? RETURNING `+banColumns, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pruned := make([]models.Ban, 0)
+	for rows.Next() {
+		b, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		pruned = append(pruned, b)
+	}
+	return pruned, rows.Err()
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// idPlaceholders returns "?,?,..." and the matching arguments for an IN clause
+func idPlaceholders(ids []string) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
+// GetTierListsByAuthor returns summaries of all tier lists owned by a user,
+// optionally limited to one game, most recently updated first
+func (s *Store) GetTierListsByAuthor(authorID, gameID string) ([]models.TierListSummary, error) {
+	query := `SELECT ` + tierListColumns + ` FROM tierlists WHERE author_id = ?`
+	args := []interface{}{authorID}
+	if gameID != "" {
+		query += ` AND game_id = ?`
+		args = append(args, gameID)
+	}
+	query += ` ORDER BY updated_at DESC`
+
+	return s.querySummaries(query, args...)
+}
+
+// BulkDeleteTierLists deletes the given tier lists owned by authorID and
+// returns how many were deleted. IDs owned by someone else are skipped.
+func (s *Store) BulkDeleteTierLists(authorID string, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	in, args := idPlaceholders(ids)
+	res, err := s.db.Exec(`DELETE FROM tierlists WHERE author_id = ? AND id IN (`+in+`)`,
+		append([]interface{}{authorID}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ClaimTierLists assigns the given anonymous tier lists to authorID and
+// returns how many were claimed. Only lists created from the caller's device,
+// or from its IP when the list recorded no device, are claimed: list IDs are
+// public, so knowing one proves nothing. Other lists are skipped.
+func (s *Store) ClaimTierLists(authorID string, ids []string, device, ip string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	in, args := idPlaceholders(ids)
+	res, err := s.db.Exec(`
+		UPDATE tierlists SET author_id = ?, creator_ip = NULL, creator_device = NULL
+		WHERE author_id IS NULL AND (
+			(? <> '' AND creator_device = ?) OR
+			(COALESCE(creator_device, '') = '' AND ? <> '' AND creator_ip = ?)
+		) AND id IN (`+in+`)
+	`, append([]interface{}{authorID, device, device, ip, ip}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// BulkSetTierListsVisibility sets the visibility of the given tier lists
+// owned by authorID and returns how many were updated. Locked lists are
+// left alone.
+func (s *Store) BulkSetTierListsVisibility(authorID string, ids []string, visibility string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	in, args := idPlaceholders(ids)
+	res, err := s.db.Exec(`UPDATE tierlists SET is_public = ?, is_private = ?, updated_at = ? WHERE author_id = ? AND locked_at IS NULL AND id IN (`+in+`)`,
+		append([]interface{}{visibility == models.VisibilityPublic, visibility == models.VisibilityPrivate, time.Now(), authorID}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// BulkRetagTierLists adds and removes tags on the given tier lists owned by
+// authorID and returns how many lists were touched, leaving locked lists
+// alone. It fails with ErrTooManyTags if any list would end up with more
+// than maxTags tags.
+func (s *Store) BulkRetagTierLists(authorID string, ids, add, remove []string, maxTags int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	in, args := idPlaceholders(ids)
+	rows, err := tx.Query(`SELECT id FROM tierlists WHERE author_id = ? AND locked_at IS NULL AND id IN (`+in+`)`,
+		append([]interface{}{authorID}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	owned := make([]string, 0, len(ids))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		owned = append(owned, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range owned {
+		for _, tag := range remove {
+			if _, err := tx.Exec(`DELETE FROM tierlist_tags WHERE tierlist_id = ? AND tag = ?`, id, tag); err != nil {
+				return 0, err
+			}
+		}
+		for _, tag := range add {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO tierlist_tags (tierlist_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+				return 0, err
+			}
+		}
+
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM tierlist_tags WHERE tierlist_id = ?`, id).Scan(&count); err != nil {
+			return 0, err
+		}
+		if count > maxTags {
+			return 0, ErrTooManyTags
+		}
+	}
+	return int64(len(owned)), tx.Commit()
+}
+
+// TierListNames returns the names of a user's tier lists for one sheet
+func (s *Store) TierListNames(authorID, gameID, sheetID string) ([]string, error) {
+	return s.queryIDs(`SELECT name FROM tierlists WHERE author_id = ? AND game_id = ? AND sheet_id = ?`,
+		authorID, gameID, sheetID)
+}
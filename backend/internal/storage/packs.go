@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"github.com/meur/tierforge/internal/models"
+)
+
+// RecordPackInstall remembers which pack release a game was installed from
+func (s *Store) RecordPackInstall(p *models.InstalledPack) error {
+	_, err := s.db.Exec(`
+		INSERT INTO installed_packs (game_id, pack_id, version, sha256, installed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(game_id) DO UPDATE SET
+			pack_id = excluded.pack_id,
+			version = excluded.version,
+			sha256 = excluded.sha256,
+			installed_at = excluded.installed_at
+	`, p.GameID, p.PackID, p.Version, p.SHA256, p.InstalledAt)
+	return err
+}
+
+// GetInstalledPacks returns installed packs keyed by pack ID
+func (s *Store) GetInstalledPacks() (map[string]*models.InstalledPack, error) {
+	rows, err := s.db.Query(`SELECT game_id, pack_id, version, sha256, installed_at FROM installed_packs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	installed := make(map[string]*models.InstalledPack)
+	for rows.Next() {
+		var p models.InstalledPack
+		if err := rows.Scan(&p.GameID, &p.PackID, &p.Version, &p.SHA256, &p.InstalledAt); err != nil {
+			return nil, err
+		}
+		installed[p.PackID] = &p
+	}
+	return installed, rows.Err()
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+const paletteColumns = `id, name, description, colors, builtin, created_at`
+
+func scanPalette(row rowScanner) (*models.Palette, error) {
+	var p models.Palette
+	var colors string
+	if err := row.Scan(&p.ID, &p.Name, &p.Description, &colors, &p.Builtin, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := decodeColumn("palette", p.ID, "colors", colors, &p.Colors); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// seedPalettes installs the built-in palettes, refreshing their colors so
+// upgrades pick up changes
+func (s *Store) seedPalettes() error {
+	for _, p := range models.BuiltinPalettes() {
+		colors, _ := json.Marshal(p.Colors)
+		_, err := s.db.Exec(`
+			INSERT INTO palettes (id, name, description, colors, builtin, created_at)
+			VALUES (?, ?, ?, ?, 1, ?)
+			ON CONFLICT(id) DO UPDATE SET
+				name = excluded.name,
+				description = excluded.description,
+				colors = excluded.colors,
+				builtin = 1
+		`, p.ID, p.Name, p.Description, string(colors), time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPalettes returns all palettes, built-ins first
+func (s *Store) GetPalettes() ([]models.Palette, error) {
+	rows, err := s.db.Query(`SELECT ` + paletteColumns + ` FROM palettes ORDER BY builtin DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	palettes := make([]models.Palette, 0)
+	for rows.Next() {
+		p, err := scanPalette(rows)
+		if err != nil {
+			return nil, err
+		}
+		palettes = append(palettes, *p)
+	}
+	return palettes, rows.Err()
+}
+
+// GetPalette returns a palette by ID, or nil if not found
+func (s *Store) GetPalette(id string) (*models.Palette, error) {
+	p, err := scanPalette(s.db.QueryRow(`SELECT `+paletteColumns+` FROM palettes WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// SavePalette creates or replaces a custom palette. Built-in palettes are
+// never overwritten; it reports false if id names one.
+func (s *Store) SavePalette(p *models.Palette) (bool, error) {
+	colors, _ := json.Marshal(p.Colors)
+	res, err := s.db.Exec(`
+		INSERT INTO palettes (id, name, description, colors, builtin, created_at)
+		VALUES (?, ?, ?, ?, 0, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			description = excluded.description,
+			colors = excluded.colors
+		WHERE builtin = 0
+	`, p.ID, p.Name, p.Description, string(colors), time.Now())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeletePalette removes a custom palette. It reports false if the palette
+// does not exist or is built in. Tier lists keep the colors they were given.
+func (s *Store) DeletePalette(id string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM palettes WHERE id = ? AND builtin = 0`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
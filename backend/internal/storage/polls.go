@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SetTierListPoll changes the poll state of a tier list. Tiers replace the
+// list's tiers when not nil, as when a poll is closed with its results.
+func (s *Store) SetTierListPoll(id, poll string, tiers []models.Tier) error {
+	if tiers == nil {
+		_, err := s.db.Exec(`UPDATE tierlists SET poll = ?, updated_at = ? WHERE id = ?`, poll, time.Now(), id)
+		return err
+	}
+	encoded, _ := json.Marshal(tiers)
+	_, err := s.db.Exec(`
+		UPDATE tierlists SET poll = ?, tiers = ?, autosave = NULL, updated_at = ? WHERE id = ?
+	`, poll, encoded, time.Now(), id)
+	return err
+}
+
+// AddPollVotes records a visitor's votes, replacing their earlier votes for
+// the same items
+func (s *Store) AddPollVotes(tierListID, voterHash string, votes []models.PollVote) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, v := range votes {
+		_, err := tx.Exec(`
+			INSERT INTO poll_votes (tierlist_id, voter_hash, item_id, tier_id, voted_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(tierlist_id, voter_hash, item_id) DO UPDATE SET tier_id = excluded.tier_id, voted_at = excluded.voted_at
+		`, tierListID, voterHash, v.ItemID, v.TierID, now)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPollVotes returns every vote of a poll and the number of distinct voters
+func (s *Store) GetPollVotes(tierListID string) ([]models.PollVote, int, error) {
+	rows, err := s.db.Query(`
+		SELECT voter_hash, item_id, tier_id FROM poll_votes WHERE tierlist_id = ?
+	`, tierListID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	votes := make([]models.PollVote, 0)
+	voters := make(map[string]bool)
+	for rows.Next() {
+		var voter string
+		var v models.PollVote
+		if err := rows.Scan(&voter, &v.ItemID, &v.TierID); err != nil {
+			return nil, 0, err
+		}
+		voters[voter] = true
+		votes = append(votes, v)
+	}
+	return votes, len(voters), rows.Err()
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"log"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+)
+
+// GetItemPositions returns the positions of a list's items by item ID.
+// Order is kept twice: the tiers' item arrays are the order the API serves,
+// and positions are the keys sync merges offline moves by. Every tier edit
+// reconciles the positions with the arrays in the same write, so the two
+// agree between writes.
+func (s *Store) GetItemPositions(tierListID string) (map[string]models.ItemPosition, error) {
+	rows, err := s.db.Query(`
+		SELECT item_id, tier_id, position, modified_at, replica FROM tier_positions WHERE tierlist_id = ?
+	`, tierListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := make(map[string]models.ItemPosition)
+	for rows.Next() {
+		var itemID string
+		var p models.ItemPosition
+		if err := rows.Scan(&itemID, &p.TierID, &p.Position, &p.ModifiedAt, &p.Replica); err != nil {
+			return nil, err
+		}
+		positions[itemID] = p
+	}
+	return positions, rows.Err()
+}
+
+// SaveItemPositions replaces the positions of a list's items
+func (s *Store) SaveItemPositions(tierListID string, positions map[string]models.ItemPosition) error {
+	if _, err := s.db.Exec(`DELETE FROM tier_positions WHERE tierlist_id = ?`, tierListID); err != nil {
+		return err
+	}
+	for itemID, p := range positions {
+		if _, err := s.db.Exec(`
+			INSERT INTO tier_positions (tierlist_id, item_id, tier_id, position, modified_at, replica)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, tierListID, itemID, p.TierID, p.Position, p.ModifiedAt.UTC(), p.Replica); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migratePositions lays out positions from the item arrays of the lists
+// ranked before positions existed. A list whose tiers do not decode is
+// logged and left without positions; sync lays it out once it is repaired.
+func (s *Store) migratePositions() error {
+	rows, err := s.db.Query(`SELECT id, tiers FROM tierlists`)
+	if err != nil {
+		return err
+	}
+	laidOut := make(map[string]map[string]models.ItemPosition)
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			rows.Close()
+			return err
+		}
+		var tiers []models.Tier
+		if err := decodeColumn("tierlists", id, "tiers", data, &tiers); err != nil {
+			log.Printf("WARNING: Skipping positions for tier list %s: %v", id, err)
+			continue
+		}
+		if positions := ranking.ReconcilePositions(tiers, nil, nil); len(positions) > 0 {
+			laidOut[id] = positions
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(laidOut) == 0 {
+		return err
+	}
+
+	return s.WithTx(context.Background(), func(tx *Store) error {
+		for id, positions := range laidOut {
+			if err := tx.SaveItemPositions(id, positions); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package storage
+
+import "github.com/meur/tierforge/internal/models"
+
+// purgeStep is one statement of a user purge, counted under table
+type purgeStep struct {
+	table string
+	query string
+}
+
+// purgeRemovals delete everything tied to a user, given the user ID as ?1
+// and the user's voter key as ?2. The uploads, tags and reports of the
+// user's tier lists, and the picks of their matchups and brackets, go first
+// so they are counted; views and rendered images cascade uncounted.
+var purgeRemovals = []purgeStep{
+	{"items", `DELETE FROM items WHERE tierlist_id IN (SELECT id FROM tierlists WHERE author_id = ?1)`},
+	{"tierlist_tags", `DELETE FROM tierlist_tags WHERE tierlist_id IN (SELECT id FROM tierlists WHERE author_id = ?1)`},
+	{"reports", `DELETE FROM reports WHERE tierlist_id IN (SELECT id FROM tierlists WHERE author_id = ?1)`},
+	{"tierlists", `DELETE FROM tierlists WHERE author_id = ?1`},
+	{"matchup_results", `DELETE FROM matchup_results WHERE session_id IN (SELECT id FROM matchup_sessions WHERE author_id = ?1)`},
+	{"matchup_sessions", `DELETE FROM matchup_sessions WHERE author_id = ?1`},
+	{"bracket_matches", `DELETE FROM bracket_matches WHERE bracket_id IN (SELECT id FROM brackets WHERE author_id = ?1)`},
+	{"brackets", `DELETE FROM brackets WHERE author_id = ?1`},
+	{"poll_votes", `DELETE FROM poll_votes WHERE voter_hash = ?2`},
+	{"idempotency_keys", `DELETE FROM idempotency_keys WHERE scope = ?2`},
+	{"favorites", `DELETE FROM favorites WHERE user_id = ?1`},
+	{"sessions", `DELETE FROM sessions WHERE user_id = ?1`},
+	{"email_tokens", `DELETE FROM email_tokens WHERE user_id = ?1`},
+	{"curator_games", `DELETE FROM curator_games WHERE user_id = ?1`},
+	{"workspace_members", `DELETE FROM workspace_members WHERE user_id = ?1`},
+	{"quota_overrides", `DELETE FROM quota_overrides WHERE user_id = ?1`},
+	{"users", `DELETE FROM users WHERE id = ?1`},
+}
+
+// purgeAnonymizations keep records other data or the audit trail needs,
+// replacing the user's ID with the placeholder in ?3. Audit entries about
+// the user lose their details and snapshots, which may hold the email.
+var purgeAnonymizations = []purgeStep{
+	{"audit_log", `UPDATE audit_log SET actor = ?3 WHERE actor = ?1`},
+	{"audit_log", `UPDATE audit_log SET target_id = ?3, details = NULL, before_json = NULL, after_json = NULL WHERE target_type = 'user' AND target_id = ?1`},
+	{"bans", `UPDATE bans SET created_by = ?3 WHERE created_by = ?1`},
+	{"item_sources", `UPDATE item_sources SET created_by = ?3 WHERE created_by = ?1`},
+	{"url_redirects", `UPDATE url_redirects SET created_by = ?3 WHERE created_by = ?1`},
+	{"game_scripts", `UPDATE game_scripts SET updated_by = ?3 WHERE updated_by = ?1`},
+	{"quota_overrides", `UPDATE quota_overrides SET updated_by = ?3 WHERE updated_by = ?1`},
+}
+
+// PurgeUser erases a user for a data deletion request. Unlike DeleteUser it
+// also deletes the tier lists, matchups, brackets and votes the user made,
+// and takes the user's ID out of the audit log and the records of admin
+// actions. With dryRun nothing is changed, but the counts are the same.
+func (s *Store) PurgeUser(userID string, dryRun bool) (*models.PurgeResult, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &models.PurgeResult{Removed: make(map[string]int64), Anonymized: make(map[string]int64)}
+	args := []interface{}{userID, models.UserVoter(userID), models.DeletedUserID}
+	run := func(steps []purgeStep, counts map[string]int64) error {
+		for _, step := range steps {
+			res, err := tx.Exec(step.query, args...)
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				counts[step.table] += n
+			}
+		}
+		return nil
+	}
+	if err := run(purgeAnonymizations, result.Anonymized); err != nil {
+		return nil, err
+	}
+	if err := run(purgeRemovals, result.Removed); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return result, nil
+	}
+	return result, tx.Commit()
+}
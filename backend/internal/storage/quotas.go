@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// GetQuotaUsage returns what a user stores: the tier lists they author and
+// the items and bytes uploaded to them
+func (s *Store) GetQuotaUsage(userID string) (models.QuotaUsage, error) {
+	var u models.QuotaUsage
+	err := s.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM tierlists WHERE author_id = ?1),
+			COUNT(i.id), COALESCE(SUM(i.upload_bytes), 0)
+		FROM tierlists t JOIN items i ON i.tierlist_id = t.id
+		WHERE t.author_id = ?1
+	`, userID).Scan(&u.TierLists, &u.CustomItems, &u.UploadBytes)
+	return u, err
+}
+
+// GetQuotaOverride returns the admin override of a user's limits, or nil
+func (s *Store) GetQuotaOverride(userID string) (*models.QuotaOverride, error) {
+	var o models.QuotaOverride
+	var tierLists, perList, items, bytes sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT user_id, tierlists, custom_items_per_list, custom_items, upload_bytes, note, updated_by, updated_at
+		FROM quota_overrides WHERE user_id = ?
+	`, userID).Scan(&o.UserID, &tierLists, &perList, &items, &bytes, &o.Note, &o.UpdatedBy, &o.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.TierLists, o.CustomItemsPerList, o.CustomItems = nullInt(tierLists), nullInt(perList), nullInt(items)
+	if bytes.Valid {
+		o.UploadBytes = &bytes.Int64
+	}
+	return &o, nil
+}
+
+// SetQuotaOverride creates or replaces the override of a user's limits
+func (s *Store) SetQuotaOverride(o *models.QuotaOverride) error {
+	o.UpdatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO quota_overrides (user_id, tierlists, custom_items_per_list, custom_items, upload_bytes, note, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			tierlists = excluded.tierlists,
+			custom_items_per_list = excluded.custom_items_per_list,
+			custom_items = excluded.custom_items,
+			upload_bytes = excluded.upload_bytes,
+			note = excluded.note,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`, o.UserID, o.TierLists, o.CustomItemsPerList, o.CustomItems, o.UploadBytes, o.Note, o.UpdatedBy, o.UpdatedAt)
+	return err
+}
+
+// DeleteQuotaOverride restores a user's default limits, reporting whether
+// they had an override
+func (s *Store) DeleteQuotaOverride(userID string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM quota_overrides WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func nullInt(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
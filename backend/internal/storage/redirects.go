@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SaveURLRedirect creates a redirect or points an existing one elsewhere
+func (s *Store) SaveURLRedirect(rd *models.URLRedirect) error {
+	rd.CreatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO url_redirects (from_code, to_code, created_by, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(from_code) DO UPDATE SET
+			to_code = excluded.to_code, created_by = excluded.created_by, created_at = excluded.created_at
+	`, rd.From, rd.To, rd.CreatedBy, rd.CreatedAt)
+	return err
+}
+
+// GetURLRedirect returns the redirect of an old share code, or nil
+func (s *Store) GetURLRedirect(from string) (*models.URLRedirect, error) {
+	rd := &models.URLRedirect{From: from}
+	err := s.db.QueryRow(`
+		SELECT to_code, created_by, created_at FROM url_redirects WHERE from_code = ?
+	`, from).Scan(&rd.To, &rd.CreatedBy, &rd.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rd, nil
+}
+
+// GetURLRedirects returns every redirect, newest first
+func (s *Store) GetURLRedirects() ([]models.URLRedirect, error) {
+	rows, err := s.db.Query(`
+		SELECT from_code, to_code, created_by, created_at FROM url_redirects ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redirects := make([]models.URLRedirect, 0)
+	for rows.Next() {
+		var rd models.URLRedirect
+		if err := rows.Scan(&rd.From, &rd.To, &rd.CreatedBy, &rd.CreatedAt); err != nil {
+			return nil, err
+		}
+		redirects = append(redirects, rd)
+	}
+	return redirects, rows.Err()
+}
+
+// DeleteURLRedirect removes a redirect, reporting whether it existed
+func (s *Store) DeleteURLRedirect(from string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM url_redirects WHERE from_code = ?`, from)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ShareCodeTaken reports whether any tier list, private ones included, has
+// the share code
+func (s *Store) ShareCodeTaken(code string) (bool, error) {
+	var exists int
+	err := s.db.QueryRow(`SELECT 1 FROM tierlists WHERE share_code = ?`, code).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
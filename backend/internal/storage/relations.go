@@ -0,0 +1,57 @@
+package storage
+
+import "github.com/meur/tierforge/internal/models"
+
+// CreateItemRelation adds an edge between two items. Returns ErrDuplicate if
+// the edge already exists.
+func (s *Store) CreateItemRelation(rel *models.ItemRelation) error {
+	_, err := s.db.Exec(`
+		INSERT INTO item_relations (game_id, item_id, related_id, kind)
+		VALUES (?, ?, ?, ?)
+	`, rel.GameID, rel.ItemID, rel.RelatedID, rel.Kind)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// DeleteItemRelation removes an edge and reports whether it existed
+func (s *Store) DeleteItemRelation(rel *models.ItemRelation) (bool, error) {
+	res, err := s.db.Exec(`
+		DELETE FROM item_relations
+		WHERE game_id = ? AND item_id = ? AND related_id = ? AND kind = ?
+	`, rel.GameID, rel.ItemID, rel.RelatedID, rel.Kind)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetItemRelations returns the edges of a game, optionally limited to those
+// touching itemID in either direction
+func (s *Store) GetItemRelations(gameID, itemID string) ([]models.ItemRelation, error) {
+	query := `SELECT game_id, item_id, related_id, kind FROM item_relations WHERE game_id = ?`
+	args := []interface{}{gameID}
+	if itemID != "" {
+		query += ` AND (item_id = ? OR related_id = ?)`
+		args = append(args, itemID, itemID)
+	}
+	query += ` ORDER BY item_id, kind, related_id`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relations := make([]models.ItemRelation, 0)
+	for rows.Next() {
+		var rel models.ItemRelation
+		if err := rows.Scan(&rel.GameID, &rel.ItemID, &rel.RelatedID, &rel.Kind); err != nil {
+			return nil, err
+		}
+		relations = append(relations, rel)
+	}
+	return relations, rows.Err()
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// GetRenderedImage returns the cached image of a tier list, or nil
+func (s *Store) GetRenderedImage(tierListID, kind string) (*models.RenderedImage, error) {
+	img := models.RenderedImage{TierListID: tierListID, Kind: kind}
+	err := s.db.QueryRow(`
+		SELECT source_updated_at, data, created_at FROM rendered_images
+		WHERE tierlist_id = ? AND kind = ?
+	`, tierListID, kind).Scan(&img.SourceUpdatedAt, &img.Data, &img.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// SaveRenderedImage stores or replaces the cached image of a tier list
+func (s *Store) SaveRenderedImage(img *models.RenderedImage) error {
+	img.CreatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO rendered_images (tierlist_id, kind, source_updated_at, data, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(tierlist_id, kind) DO UPDATE SET
+			source_updated_at = excluded.source_updated_at,
+			data = excluded.data,
+			created_at = excluded.created_at
+	`, img.TierListID, img.Kind, img.SourceUpdatedAt, img.Data, img.CreatedAt)
+	return err
+}
+
+// GetRecentlySharedTierListIDs returns published, non-private tier lists
+// updated since the given time, most recent first
+func (s *Store) GetRecentlySharedTierListIDs(since time.Time, limit int) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT id FROM tierlists
+		WHERE updated_at >= ? AND status = 'published' AND is_private = 0 AND is_hidden = 0
+		ORDER BY updated_at DESC LIMIT ?
+	`, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
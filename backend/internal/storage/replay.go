@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/ranking"
+)
+
+// maxReplayMoves bounds the moves recorded for one tier list. Recording
+// stops there rather than dropping the oldest, which the replay starts from.
+const maxReplayMoves = 10000
+
+// GetReplay returns the recorded moves of a tier list, oldest first, and
+// whether recording stopped at the move limit
+func (s *Store) GetReplay(tierListID string) ([]models.ReplayMove, bool, error) {
+	rows, err := s.db.Query(`
+		SELECT item_id, tier_id, after_item, moved_at FROM tierlist_moves
+		WHERE tierlist_id = ? ORDER BY seq
+	`, tierListID)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	moves := make([]models.ReplayMove, 0)
+	for rows.Next() {
+		var m models.ReplayMove
+		if err := rows.Scan(&m.ItemID, &m.TierID, &m.After, &m.MovedAt); err != nil {
+			return nil, false, err
+		}
+		moves = append(moves, m)
+	}
+	return moves, len(moves) >= maxReplayMoves, rows.Err()
+}
+
+// recordReplay appends moves to a tier list's replay, up to maxReplayMoves
+func recordReplay(tx txn, tierListID string, moves []models.ReplayMove, at time.Time) error {
+	if len(moves) == 0 {
+		return nil
+	}
+	var recorded int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM tierlist_moves WHERE tierlist_id = ?`, tierListID).Scan(&recorded); err != nil {
+		return err
+	}
+	if room := maxReplayMoves - recorded; len(moves) > room {
+		moves = moves[:max(room, 0)]
+	}
+	for _, m := range moves {
+		if _, err := tx.Exec(`
+			INSERT INTO tierlist_moves (tierlist_id, item_id, tier_id, after_item, moved_at) VALUES (?, ?, ?, ?, ?)
+		`, tierListID, m.ItemID, m.TierID, m.After, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startReplay records placing every item of tiers, the state a replay starts
+// from
+func startReplay(tx txn, tierListID string, tiers []models.Tier, at time.Time) error {
+	return recordReplay(tx, tierListID, ranking.ReplayMoves(nil, tiers), at)
+}
+
+// updateReplay records the moves a tier list update makes when the list is
+// recording. Starting to record lays down the placement the list will have,
+// so replays begin from it; stopping clears the moves.
+func updateReplay(tx txn, id string, update *models.TierListUpdate, at time.Time) error {
+	var data string
+	var recording bool
+	err := tx.QueryRow(`SELECT tiers, record_replay FROM tierlists WHERE id = ?`, id).Scan(&data, &recording)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var before []models.Tier
+	if err := decodeColumn("tier list", id, "tiers", data, &before); err != nil {
+		return err
+	}
+	after := update.Tiers
+	if after == nil {
+		after = before
+	}
+
+	switch {
+	case update.RecordReplay != nil && !*update.RecordReplay:
+		_, err = tx.Exec(`DELETE FROM tierlist_moves WHERE tierlist_id = ?`, id)
+		return err
+	case update.RecordReplay != nil && !recording:
+		return startReplay(tx, id, after, at)
+	case recording && update.Tiers != nil:
+		return recordReplay(tx, id, ranking.ReplayMoves(before, after), at)
+	}
+	return nil
+}
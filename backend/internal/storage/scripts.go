@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// SaveGameScript attaches a script to a game, replacing any it had
+func (s *Store) SaveGameScript(gs *models.GameScript) error {
+	gs.UpdatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO game_scripts (game_id, source, updated_by, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(game_id) DO UPDATE SET
+			source = excluded.source, updated_by = excluded.updated_by, updated_at = excluded.updated_at
+	`, gs.GameID, gs.Source, gs.UpdatedBy, gs.UpdatedAt)
+	return err
+}
+
+// GetGameScript returns the script attached to a game, or nil
+func (s *Store) GetGameScript(gameID string) (*models.GameScript, error) {
+	gs := &models.GameScript{GameID: gameID}
+	err := s.db.QueryRow(`
+		SELECT source, updated_by, updated_at FROM game_scripts WHERE game_id = ?
+	`, gameID).Scan(&gs.Source, &gs.UpdatedBy, &gs.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// DeleteGameScript detaches a game's script, reporting whether it had one
+func (s *Store) DeleteGameScript(gameID string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM game_scripts WHERE game_id = ?`, gameID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"log"
+	"strings"
+)
+
+// The item search index is an FTS5 table whose rowids mirror items.rowid.
+// Triggers on items, item_tags and item_aliases keep it in step with every catalog write,
+// including INSERT OR REPLACE (hence recursive_triggers in the DSN). FTS5 is
+// only compiled in with the sqlite_fts5 build tag; without it the index is
+// skipped and SearchEnabled reports false.
+
+// itemSearchFields selects the indexed text of the item aliased i
+const itemSearchFields = `i.name, i.name_ru, i.category,
+	COALESCE((SELECT group_concat(tag, ' ') FROM item_tags t WHERE t.game_id = i.game_id AND t.item_id = i.id), ''),
+	COALESCE((SELECT group_concat(alias, ' ') FROM item_aliases a WHERE a.game_id = i.game_id AND a.item_id = i.id), ''),
+	CASE WHEN json_valid(i.data) THEN COALESCE(json_extract(i.data, '$.description'), '') ELSE '' END`
+
+var searchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS items_fts_insert AFTER INSERT ON items BEGIN
+		INSERT INTO items_fts (rowid, name, name_ru, category, tags, aliases, description)
+		SELECT i.rowid, ` + itemSearchFields + ` FROM items i WHERE i.rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS items_fts_delete AFTER DELETE ON items BEGIN
+		DELETE FROM items_fts WHERE rowid = old.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS items_fts_update AFTER UPDATE ON items BEGIN
+		DELETE FROM items_fts WHERE rowid = old.rowid;
+		INSERT INTO items_fts (rowid, name, name_ru, category, tags, aliases, description)
+		SELECT i.rowid, ` + itemSearchFields + ` FROM items i WHERE i.rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS item_tags_fts_insert AFTER INSERT ON item_tags BEGIN
+		UPDATE items_fts SET tags = (
+			SELECT group_concat(tag, ' ') FROM item_tags WHERE game_id = new.game_id AND item_id = new.item_id
+		) WHERE rowid = (SELECT rowid FROM items WHERE game_id = new.game_id AND id = new.item_id);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS item_tags_fts_delete AFTER DELETE ON item_tags BEGIN
+		UPDATE items_fts SET tags = COALESCE((
+			SELECT group_concat(tag, ' ') FROM item_tags WHERE game_id = old.game_id AND item_id = old.item_id
+		), '') WHERE rowid = (SELECT rowid FROM items WHERE game_id = old.game_id AND id = old.item_id);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS item_aliases_fts_insert AFTER INSERT ON item_aliases BEGIN
+		UPDATE items_fts SET aliases = (
+			SELECT group_concat(alias, ' ') FROM item_aliases WHERE game_id = new.game_id AND item_id = new.item_id
+		) WHERE rowid = (SELECT rowid FROM items WHERE game_id = new.game_id AND id = new.item_id);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS item_aliases_fts_delete AFTER DELETE ON item_aliases BEGIN
+		UPDATE items_fts SET aliases = COALESCE((
+			SELECT group_concat(alias, ' ') FROM item_aliases WHERE game_id = old.game_id AND item_id = old.item_id
+		), '') WHERE rowid = (SELECT rowid FROM items WHERE game_id = old.game_id AND id = old.item_id);
+	END`,
+}
+
+var searchTriggerNames = []string{
+	"items_fts_insert", "items_fts_delete", "items_fts_update", "item_tags_fts_insert", "item_tags_fts_delete",
+	"item_aliases_fts_insert", "item_aliases_fts_delete",
+}
+
+// SearchEnabled reports whether the item and tier list search indexes are
+// maintained
+func (s *Store) SearchEnabled() bool {
+	return s.search
+}
+
+// migrateSearch creates the search index and its triggers, rebuilding the
+// index when it may have drifted from the catalog
+func (s *Store) migrateSearch() error {
+	var fts5 bool
+	if err := s.db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return err
+	}
+	if !fts5 {
+		// Triggers left by an FTS5 build would fail every item write
+		for _, name := range append(searchTriggerNames, tierListSearchTriggerNames...) {
+			if _, err := s.db.Exec(`DROP TRIGGER IF EXISTS ` + name); err != nil {
+				return err
+			}
+		}
+		log.Printf("WARNING: SQLite was built without FTS5; search indexes disabled (build with -tags sqlite_fts5)")
+		return nil
+	}
+
+	// An index from before aliases were searchable lacks their column; FTS5
+	// tables cannot be altered, so drop it and let the rebuild below refill it
+	var outdated bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE name = 'items_fts')
+			AND NOT EXISTS (SELECT 1 FROM pragma_table_info('items_fts') WHERE name = 'aliases')
+	`).Scan(&outdated)
+	if err != nil {
+		return err
+	}
+	if outdated {
+		for _, name := range searchTriggerNames {
+			if _, err := s.db.Exec(`DROP TRIGGER IF EXISTS ` + name); err != nil {
+				return err
+			}
+		}
+		if _, err := s.db.Exec(`DROP TABLE items_fts`); err != nil {
+			return err
+		}
+	}
+
+	var triggers int
+	err = s.db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ('` + strings.Join(searchTriggerNames, "', '") + `')
+	`).Scan(&triggers)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS items_fts USING fts5(
+			name, name_ru, category, tags, aliases, description,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)
+	`); err != nil {
+		return err
+	}
+	for _, stmt := range searchTriggers {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	s.search = true
+
+	// Writes made while the triggers were missing, and VACUUM renumbering
+	// item rowids, both leave the index out of step
+	stale := triggers < len(searchTriggers)
+	if !stale {
+		stale, err = s.searchIndexStale()
+		if err != nil {
+			return err
+		}
+	}
+	if stale {
+		n, err := s.RebuildSearchIndex()
+		if err != nil {
+			return err
+		}
+		log.Printf("Rebuilt item search index (%d items)", n)
+	}
+	return s.migrateTierListSearch()
+}
+
+// searchIndexStale reports whether the index and items disagree on rowids
+func (s *Store) searchIndexStale() (bool, error) {
+	var items, indexed, missing int
+	err := s.db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM items), (SELECT COUNT(*) FROM items_fts),
+			(SELECT COUNT(*) FROM items i WHERE NOT EXISTS (SELECT 1 FROM items_fts f WHERE f.rowid = i.rowid))
+	`).Scan(&items, &indexed, &missing)
+	if err != nil {
+		return false, err
+	}
+	return items != indexed || missing > 0, nil
+}
+
+// RebuildSearchIndex repopulates the item search index from the catalog and
+// returns the number of indexed items
+func (s *Store) RebuildSearchIndex() (int64, error) {
+	if !s.search {
+		return 0, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM items_fts`); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO items_fts (rowid, name, name_ru, category, tags, aliases, description)
+		SELECT i.rowid, ` + itemSearchFields + ` FROM items i
+	`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	if _, err := tx.Exec(`INSERT INTO items_fts (items_fts) VALUES ('optimize')`); err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/meur/tierforge/internal/itemfilter"
+)
+
+// sheetCacheTTL bounds how stale a virtual sheet can get when the catalog is
+// changed by another process, such as the seed or bundle commands
+const sheetCacheTTL = 5 * time.Minute
+
+// sheetCache memoizes the item IDs matched by virtual sheets. Entries are
+// dropped whenever this process changes the catalog.
+type sheetCache struct {
+	gen     atomic.Uint64
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	mu      sync.Mutex
+	entries map[string]sheetEntry
+}
+
+type sheetEntry struct {
+	gen     uint64
+	ids     string // JSON array of item IDs
+	expires time.Time
+}
+
+// catalogChanged invalidates cached virtual sheets and suggestion indexes.
+// Inside WithTx the caches are invalidated again after commit, since they
+// may have been refilled from the data the transaction replaced.
+func (s *Store) catalogChanged() {
+	s.sheets.gen.Add(1)
+	if s.db.tx != nil {
+		s.catalogDirty = true
+	}
+}
+
+// virtualSheetItems returns the IDs of the items in a virtual sheet as a JSON
+// array. ok is false when the sheet is not virtual.
+func (s *Store) virtualSheetItems(gameID, sheetID string) (ids string, ok bool, err error) {
+	game, err := s.GetGame(gameID)
+	if err != nil || game == nil {
+		return "", false, err
+	}
+	sheet := game.Sheet(sheetID)
+	if sheet == nil || !sheet.Virtual {
+		return "", false, nil
+	}
+
+	key := gameID + "/" + sheetID
+	gen := s.sheets.gen.Load()
+	s.sheets.mu.Lock()
+	entry, found := s.sheets.entries[key]
+	s.sheets.mu.Unlock()
+	if found && entry.gen == gen && time.Now().Before(entry.expires) {
+		s.sheets.hits.Add(1)
+		return entry.ids, true, nil
+	}
+	s.sheets.misses.Add(1)
+
+	filter, err := itemfilter.Parse(sheet.ItemFilter)
+	if err != nil {
+		return "", false, err
+	}
+	items, err := s.QueryItems(ItemQuery{GameID: gameID})
+	if err != nil {
+		return "", false, err
+	}
+	matched := make([]string, 0)
+	for i := range items {
+		if filter.Match(&items[i]) {
+			matched = append(matched, items[i].ID)
+		}
+	}
+	b, _ := json.Marshal(matched)
+
+	s.sheets.mu.Lock()
+	if s.sheets.entries == nil {
+		s.sheets.entries = make(map[string]sheetEntry)
+	}
+	s.sheets.entries[key] = sheetEntry{gen: gen, ids: string(b), expires: time.Now().Add(sheetCacheTTL)}
+	s.sheets.mu.Unlock()
+	return string(b), true, nil
+}
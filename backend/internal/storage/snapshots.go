@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// snapshotsKept is how many snapshots are retained per game
+const snapshotsKept = 20
+
+const snapshotColumns = `id, game_id, sheet_id, source, item_count, created_at, restored_at`
+
+func scanSnapshot(row rowScanner, extra ...interface{}) (*models.ImportSnapshot, error) {
+	var snap models.ImportSnapshot
+	var restoredAt sql.NullTime
+	dest := append([]interface{}{&snap.ID, &snap.GameID, &snap.SheetID, &snap.Source,
+		&snap.ItemCount, &snap.CreatedAt, &restoredAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if restoredAt.Valid {
+		snap.RestoredAt = &restoredAt.Time
+	}
+	return &snap, nil
+}
+
+// SnapshotItems stores a copy of the current items of a game, or of one sheet
+// when sheetID is set, and prunes old snapshots of that game
+func (s *Store) SnapshotItems(gameID, sheetID, source string) (*models.ImportSnapshot, error) {
+	items, err := s.GetItems(gameID, sheetID)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &models.ImportSnapshot{
+		GameID:    gameID,
+		SheetID:   sheetID,
+		Source:    source,
+		ItemCount: len(items),
+		CreatedAt: time.Now(),
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO import_snapshots (game_id, sheet_id, source, item_count, items, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, snap.GameID, snap.SheetID, snap.Source, snap.ItemCount, payload, snap.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if snap.ID, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		DELETE FROM import_snapshots WHERE game_id = ? AND id NOT IN (
+			SELECT id FROM import_snapshots WHERE game_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`, gameID, gameID, snapshotsKept)
+	return snap, err
+}
+
+// GetSnapshots lists the snapshots of a game without their items, newest first
+func (s *Store) GetSnapshots(gameID string) ([]models.ImportSnapshot, error) {
+	rows, err := s.db.Query(`
+		SELECT `+snapshotColumns+` FROM import_snapshots WHERE game_id = ? ORDER BY id DESC
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snaps := make([]models.ImportSnapshot, 0)
+	for rows.Next() {
+		snap, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, *snap)
+	}
+	return snaps, rows.Err()
+}
+
+// GetSnapshot returns a snapshot including its items, or nil if not found
+func (s *Store) GetSnapshot(id int64) (*models.ImportSnapshot, error) {
+	var payload string
+	snap, err := scanSnapshot(s.db.QueryRow(`
+		SELECT `+snapshotColumns+`, items FROM import_snapshots WHERE id = ?
+	`, id), &payload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(payload), &snap.Items); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// RestoreSnapshot replaces the items covered by a snapshot with its contents
+func (s *Store) RestoreSnapshot(snap *models.ImportSnapshot) error {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if snap.SheetID != "" {
+		_, err = tx.Exec(`DELETE FROM items WHERE game_id = ? AND sheet_id = ?`, snap.GameID, snap.SheetID)
+	} else {
+		_, err = tx.Exec(`DELETE FROM items WHERE game_id = ?`, snap.GameID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertItems(tx, snap.Items); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE import_snapshots SET restored_at = ? WHERE id = ?`, now, snap.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	snap.RestoredAt = &now
+	return nil
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+const sourceColumns = `id, game_id, sheet_id, url, auth_header, auth_value, records_path, fields,
+	interval_minutes, remove_missing, enabled, created_by, created_at, last_sync_at, last_error, last_result`
+
+func scanSource(row rowScanner) (*models.ItemSource, error) {
+	var src models.ItemSource
+	var fields, lastResult string
+	var lastSync sql.NullTime
+	err := row.Scan(&src.ID, &src.GameID, &src.SheetID, &src.URL, &src.AuthHeader, &src.AuthValue,
+		&src.RecordsPath, &fields, &src.IntervalMinutes, &src.RemoveMissing, &src.Enabled,
+		&src.CreatedBy, &src.CreatedAt, &lastSync, &src.LastError, &lastResult)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.FormatInt(src.ID, 10)
+	if err := decodeColumn("item_sources", id, "fields", fields, &src.Fields); err != nil {
+		return nil, err
+	}
+	if lastResult != "" {
+		src.LastResult = &models.SourceSyncResult{}
+		if err := decodeColumn("item_sources", id, "last_result", lastResult, src.LastResult); err != nil {
+			return nil, err
+		}
+	}
+	if lastSync.Valid {
+		src.LastSyncAt = &lastSync.Time
+	}
+	return &src, nil
+}
+
+// CreateItemSource adds an item source, setting its ID
+func (s *Store) CreateItemSource(src *models.ItemSource) error {
+	fields, _ := json.Marshal(src.Fields)
+	src.CreatedAt = time.Now()
+	res, err := s.db.Exec(`
+		INSERT INTO item_sources (game_id, sheet_id, url, auth_header, auth_value, records_path, fields,
+			interval_minutes, remove_missing, enabled, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, src.GameID, src.SheetID, src.URL, src.AuthHeader, src.AuthValue, src.RecordsPath, string(fields),
+		src.IntervalMinutes, src.RemoveMissing, src.Enabled, src.CreatedBy, src.CreatedAt)
+	if err != nil {
+		return err
+	}
+	src.ID, err = res.LastInsertId()
+	return err
+}
+
+// UpdateItemSource replaces the settings of an item source, keeping its
+// sync history
+func (s *Store) UpdateItemSource(src *models.ItemSource) error {
+	fields, _ := json.Marshal(src.Fields)
+	_, err := s.db.Exec(`
+		UPDATE item_sources
+		SET sheet_id = ?, url = ?, auth_header = ?, auth_value = ?, records_path = ?, fields = ?,
+			interval_minutes = ?, remove_missing = ?, enabled = ?
+		WHERE id = ?
+	`, src.SheetID, src.URL, src.AuthHeader, src.AuthValue, src.RecordsPath, string(fields),
+		src.IntervalMinutes, src.RemoveMissing, src.Enabled, src.ID)
+	return err
+}
+
+// GetItemSource returns an item source of a game, or nil
+func (s *Store) GetItemSource(gameID string, id int64) (*models.ItemSource, error) {
+	src, err := scanSource(s.db.QueryRow(`
+		SELECT `+sourceColumns+` FROM item_sources WHERE game_id = ? AND id = ?
+	`, gameID, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return src, err
+}
+
+// GetItemSources returns the item sources of a game, or of every game when
+// gameID is empty, oldest first
+func (s *Store) GetItemSources(gameID string) ([]models.ItemSource, error) {
+	query := `SELECT ` + sourceColumns + ` FROM item_sources`
+	var args []interface{}
+	if gameID != "" {
+		query += ` WHERE game_id = ?`
+		args = append(args, gameID)
+	}
+	rows, err := s.db.Query(query+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := make([]models.ItemSource, 0)
+	for rows.Next() {
+		src, err := scanSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, *src)
+	}
+	return sources, rows.Err()
+}
+
+// DeleteItemSource removes an item source, reporting whether it existed.
+// Items it imported stay in the catalog.
+func (s *Store) DeleteItemSource(gameID string, id int64) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM item_sources WHERE game_id = ? AND id = ?`, gameID, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RecordItemSourceSync stores the outcome of a sync. A failed sync passes a
+// nil result, keeping the last successful one.
+func (s *Store) RecordItemSourceSync(id int64, at time.Time, result *models.SourceSyncResult, syncErr error) error {
+	var resultJSON, errText string
+	if result != nil {
+		data, _ := json.Marshal(result)
+		resultJSON = string(data)
+	}
+	if syncErr != nil {
+		errText = syncErr.Error()
+	}
+	_, err := s.db.Exec(`
+		UPDATE item_sources
+		SET last_sync_at = ?1, last_error = ?2, last_result = CASE WHEN ?3 = '' THEN last_result ELSE ?3 END
+		WHERE id = ?4
+	`, at, errText, resultJSON, id)
+	return err
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// GetSpriteSheet returns the sprite sheet of a sheet without its image, or nil
+func (s *Store) GetSpriteSheet(gameID, sheetID string) (*models.SpriteSheet, error) {
+	sheet := models.SpriteSheet{GameID: gameID, SheetID: sheetID}
+	var cells string
+	err := s.db.QueryRow(`
+		SELECT version, cell_size, width, height, cells, created_at FROM sprite_sheets
+		WHERE game_id = ? AND sheet_id = ?
+	`, gameID, sheetID).Scan(&sheet.Version, &sheet.CellSize, &sheet.Width, &sheet.Height, &cells, &sheet.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(cells), &sheet.Cells); err != nil {
+		return nil, err
+	}
+	return &sheet, nil
+}
+
+// GetSpriteImage returns the PNG of a sprite sheet and its version, or nil
+func (s *Store) GetSpriteImage(gameID, sheetID string) ([]byte, string, error) {
+	var data []byte
+	var version string
+	err := s.db.QueryRow(`
+		SELECT data, version FROM sprite_sheets WHERE game_id = ? AND sheet_id = ?
+	`, gameID, sheetID).Scan(&data, &version)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	return data, version, err
+}
+
+// SaveSpriteSheet stores or replaces a sprite sheet and its PNG
+func (s *Store) SaveSpriteSheet(sheet *models.SpriteSheet, data []byte) error {
+	cells, err := json.Marshal(sheet.Cells)
+	if err != nil {
+		return err
+	}
+	sheet.CreatedAt = time.Now()
+	_, err = s.db.Exec(`
+		INSERT INTO sprite_sheets (game_id, sheet_id, version, cell_size, width, height, cells, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(game_id, sheet_id) DO UPDATE SET
+			version = excluded.version,
+			cell_size = excluded.cell_size,
+			width = excluded.width,
+			height = excluded.height,
+			cells = excluded.cells,
+			data = excluded.data,
+			created_at = excluded.created_at
+	`, sheet.GameID, sheet.SheetID, sheet.Version, sheet.CellSize, sheet.Width, sheet.Height, string(cells), data, sheet.CreatedAt)
+	return err
+}
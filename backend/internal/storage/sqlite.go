@@ -4,26 +4,42 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/meur/tierforge/internal/models"
+	"github.com/meur/tierforge/internal/sanitize"
 )
 
 // Store handles all database operations
 type Store struct {
-	db *sql.DB
+	db      *database
+	path    string
+	sheets  *sheetCache
+	suggest *suggestCache
+	search  bool // FTS5 item search index is available
+	// schemaVersion is the schema this build migrates to, see SchemaVersion
+	schemaVersion int
+	// catalogDirty records catalog writes made inside WithTx
+	catalogDirty bool
 }
 
-// New creates a new Store with SQLite
+// New creates a new Store with SQLite and the default connection options
 func New(dbPath string) (*Store, error) {
-	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_journal_mode=WAL")
+	return Open(dbPath, DefaultOptions())
+}
+
+// Open creates a new Store with SQLite, tuned by opts
+func Open(dbPath string, opts Options) (*Store, error) {
+	db, err := openDatabase(dbPath, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	store := &Store{db: db}
+	store := &Store{db: db, path: dbPath, sheets: &sheetCache{}, suggest: &suggestCache{}}
 	if err := store.migrate(); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
@@ -38,6 +54,13 @@ func (s *Store) Close() error {
 
 // migrate runs database migrations
 func (s *Store) migrate() error {
+	// Read before migrating, as the data migrations below run only on
+	// databases older than the schema they shipped with
+	applied, err := s.appliedSchemaVersion()
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
 	migrations := []string{
 		`CREATE TABLE IF NOT EXISTS games (
 			id TEXT PRIMARY KEY,
@@ -77,6 +100,388 @@ func (s *Store) migrate() error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_tierlists_share ON tierlists(share_code)`,
 		`CREATE INDEX IF NOT EXISTS idx_tierlists_game ON tierlists(game_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_tierlists_gallery ON tierlists(game_id, updated_at DESC, id DESC)`,
+		`CREATE TABLE IF NOT EXISTS tierlist_views (
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			visitor_hash TEXT NOT NULL,
+			viewed_at DATETIME NOT NULL,
+			PRIMARY KEY (tierlist_id, visitor_hash)
+		)`,
+		`CREATE TABLE IF NOT EXISTS job_runs (
+			name TEXT PRIMARY KEY,
+			last_run_at DATETIME NOT NULL,
+			duration_ms INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS community_aggregates (
+			game_id TEXT NOT NULL,
+			sheet_id TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			score REAL NOT NULL,
+			list_count INTEGER NOT NULL,
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (game_id, sheet_id, item_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS reports (
+			id TEXT PRIMARY KEY,
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			reporter_hash TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			details TEXT,
+			status TEXT NOT NULL DEFAULT 'open',
+			created_at DATETIME NOT NULL,
+			UNIQUE(tierlist_id, reporter_hash)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status)`,
+		`CREATE TABLE IF NOT EXISTS bans (
+			id TEXT PRIMARY KEY,
+			kind TEXT NOT NULL,
+			value TEXT NOT NULL,
+			reason TEXT,
+			created_at DATETIME NOT NULL,
+			UNIQUE(kind, value)
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			actor TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_type TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			details TEXT,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_target ON audit_log(target_type, target_id)`,
+		`CREATE TABLE IF NOT EXISTS users (
+			id TEXT PRIMARY KEY,
+			email TEXT UNIQUE NOT NULL,
+			display_name TEXT NOT NULL,
+			password_hash TEXT NOT NULL,
+			role TEXT NOT NULL DEFAULT 'user',
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS curator_games (
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			game_id TEXT NOT NULL,
+			PRIMARY KEY (user_id, game_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			token_hash TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			user_agent TEXT,
+			created_at DATETIME NOT NULL,
+			last_seen_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_tierlists_author ON tierlists(author_id)`,
+		`CREATE TABLE IF NOT EXISTS import_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			game_id TEXT NOT NULL,
+			sheet_id TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL,
+			item_count INTEGER NOT NULL,
+			items TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			restored_at DATETIME
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_import_snapshots_game ON import_snapshots(game_id)`,
+		`CREATE TABLE IF NOT EXISTS tierlist_tags (
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (tierlist_id, tag)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_tierlist_tags_tag ON tierlist_tags(tag)`,
+		`CREATE TABLE IF NOT EXISTS item_tags (
+			game_id TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			tag TEXT NOT NULL COLLATE NOCASE,
+			PRIMARY KEY (game_id, item_id, tag)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_item_tags_tag ON item_tags(game_id, tag)`,
+		`CREATE TABLE IF NOT EXISTS item_aliases (
+			game_id TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			alias TEXT NOT NULL COLLATE NOCASE,
+			PRIMARY KEY (game_id, item_id, alias)
+		)`,
+		`CREATE TABLE IF NOT EXISTS item_relations (
+			game_id TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			related_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			PRIMARY KEY (game_id, item_id, related_id, kind)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_item_relations_related ON item_relations(game_id, related_id)`,
+		`CREATE TABLE IF NOT EXISTS matchup_sessions (
+			id TEXT PRIMARY KEY,
+			game_id TEXT NOT NULL REFERENCES games(id),
+			sheet_id TEXT NOT NULL,
+			game_version TEXT NOT NULL DEFAULT '',
+			author_id TEXT,
+			tierlist_id TEXT,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS matchup_results (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id TEXT NOT NULL REFERENCES matchup_sessions(id) ON DELETE CASCADE,
+			winner_id TEXT NOT NULL,
+			loser_id TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_matchup_results_session ON matchup_results(session_id)`,
+		`CREATE TABLE IF NOT EXISTS poll_votes (
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			voter_hash TEXT NOT NULL,
+			item_id TEXT NOT NULL,
+			tier_id TEXT NOT NULL,
+			voted_at DATETIME NOT NULL,
+			PRIMARY KEY (tierlist_id, voter_hash, item_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_poll_votes_voter ON poll_votes(voter_hash)`,
+		`CREATE TABLE IF NOT EXISTS brackets (
+			id TEXT PRIMARY KEY,
+			game_id TEXT NOT NULL REFERENCES games(id),
+			sheet_id TEXT NOT NULL,
+			game_version TEXT NOT NULL DEFAULT '',
+			author_id TEXT,
+			tierlist_id TEXT,
+			seeds TEXT NOT NULL,
+			rounds INTEGER NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS bracket_matches (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			bracket_id TEXT NOT NULL REFERENCES brackets(id) ON DELETE CASCADE,
+			round INTEGER NOT NULL,
+			slot INTEGER NOT NULL,
+			item_a TEXT,
+			item_b TEXT,
+			winner_id TEXT,
+			decided_at DATETIME,
+			UNIQUE (bracket_id, round, slot)
+		)`,
+		`CREATE TABLE IF NOT EXISTS favorites (
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			target_type TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			game_id TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (user_id, target_type, target_id, game_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_favorites_target ON favorites(target_type, target_id)`,
+		`CREATE TABLE IF NOT EXISTS email_tokens (
+			token_hash TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			purpose TEXT NOT NULL,
+			email TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_email_tokens_user ON email_tokens(user_id, purpose)`,
+		`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			scope TEXT NOT NULL,
+			key TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			status INTEGER NOT NULL DEFAULT 0,
+			content_type TEXT NOT NULL DEFAULT '',
+			body BLOB,
+			created_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL,
+			PRIMARY KEY (scope, key)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at)`,
+		`CREATE TABLE IF NOT EXISTS installed_packs (
+			game_id TEXT PRIMARY KEY,
+			pack_id TEXT NOT NULL,
+			version TEXT NOT NULL,
+			sha256 TEXT NOT NULL,
+			installed_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS sprite_sheets (
+			game_id TEXT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+			sheet_id TEXT NOT NULL,
+			version TEXT NOT NULL,
+			cell_size INTEGER NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			cells TEXT NOT NULL,
+			data BLOB NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (game_id, sheet_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS rendered_images (
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			kind TEXT NOT NULL,
+			source_updated_at DATETIME NOT NULL,
+			data BLOB NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (tierlist_id, kind)
+		)`,
+		`CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			game_id TEXT NOT NULL DEFAULT '',
+			props TEXT,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_events_created ON events(created_at)`,
+		`CREATE TABLE IF NOT EXISTS workspaces (
+			id TEXT PRIMARY KEY,
+			slug TEXT NOT NULL UNIQUE,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS workspace_members (
+			workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			role TEXT NOT NULL,
+			joined_at DATETIME NOT NULL,
+			PRIMARY KEY (workspace_id, user_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_workspace_members_user ON workspace_members(user_id)`,
+		`CREATE TABLE IF NOT EXISTS palettes (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			colors TEXT NOT NULL,
+			builtin INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS item_id_redirects (
+			game_id TEXT NOT NULL,
+			old_id TEXT NOT NULL,
+			new_id TEXT NOT NULL,
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (game_id, old_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS item_tombstones (
+			item_id TEXT PRIMARY KEY,
+			game_id TEXT NOT NULL,
+			sheet_id TEXT NOT NULL,
+			deleted_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_item_tombstones_game ON item_tombstones(game_id, deleted_at)`,
+		`CREATE TABLE IF NOT EXISTS tier_clocks (
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			tier_id TEXT NOT NULL,
+			vector TEXT NOT NULL,
+			modified_at DATETIME NOT NULL,
+			replica TEXT NOT NULL DEFAULT '',
+			deleted INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (tierlist_id, tier_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS icons (
+			hash TEXT PRIMARY KEY,
+			data BLOB NOT NULL,
+			source_url TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS url_redirects (
+			from_code TEXT PRIMARY KEY,
+			to_code TEXT NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS game_scripts (
+			game_id TEXT PRIMARY KEY REFERENCES games(id) ON DELETE CASCADE,
+			source TEXT NOT NULL,
+			updated_by TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS item_sources (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			game_id TEXT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+			sheet_id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			auth_header TEXT NOT NULL DEFAULT '',
+			auth_value TEXT NOT NULL DEFAULT '',
+			records_path TEXT NOT NULL DEFAULT '',
+			fields TEXT NOT NULL,
+			interval_minutes INTEGER NOT NULL,
+			remove_missing INTEGER NOT NULL DEFAULT 0,
+			enabled INTEGER NOT NULL DEFAULT 1,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			last_sync_at DATETIME,
+			last_error TEXT NOT NULL DEFAULT '',
+			last_result TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_item_sources_game ON item_sources(game_id)`,
+		`CREATE TABLE IF NOT EXISTS quota_overrides (
+			user_id TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			tierlists INTEGER,
+			custom_items_per_list INTEGER,
+			custom_items INTEGER,
+			upload_bytes INTEGER,
+			note TEXT NOT NULL DEFAULT '',
+			updated_by TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS unmatched_imports (
+			game_id TEXT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+			sheet_id TEXT NOT NULL,
+			slug TEXT NOT NULL,
+			name TEXT NOT NULL,
+			count INTEGER NOT NULL,
+			last_seen_at DATETIME NOT NULL,
+			PRIMARY KEY (game_id, sheet_id, slug)
+		)`,
+		`CREATE TABLE IF NOT EXISTS unmatched_infoboxes (
+			game_id TEXT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+			sheet_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			url TEXT NOT NULL DEFAULT '',
+			seen_at DATETIME NOT NULL,
+			PRIMARY KEY (game_id, sheet_id, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS tier_positions (
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			item_id TEXT NOT NULL,
+			tier_id TEXT NOT NULL,
+			position TEXT NOT NULL,
+			modified_at DATETIME NOT NULL,
+			replica TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (tierlist_id, item_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS tierlist_moves (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			tierlist_id TEXT NOT NULL REFERENCES tierlists(id) ON DELETE CASCADE,
+			item_id TEXT NOT NULL,
+			tier_id TEXT NOT NULL,
+			after_item TEXT NOT NULL,
+			moved_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_tierlist_moves_list ON tierlist_moves(tierlist_id, seq)`,
+		`CREATE TABLE IF NOT EXISTS contests (
+			id TEXT PRIMARY KEY,
+			game_id TEXT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+			sheet_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			constraints TEXT,
+			deadline DATETIME NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_contests_game ON contests(game_id, deadline)`,
+		`CREATE TABLE IF NOT EXISTS contest_submissions (
+			contest_id TEXT NOT NULL REFERENCES contests(id) ON DELETE CASCADE,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			tierlist_id TEXT NOT NULL,
+			tiers TEXT NOT NULL,
+			submitted_at DATETIME NOT NULL,
+			PRIMARY KEY (contest_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS leaderboard_entries (
+			game_id TEXT NOT NULL,
+			board TEXT NOT NULL,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			score INTEGER NOT NULL,
+			list_count INTEGER NOT NULL,
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (game_id, board, user_id)
+		)`,
 	}
 
 	for _, m := range migrations {
@@ -85,17 +490,123 @@ func (s *Store) migrate() error {
 		}
 	}
 
+	columns := []struct{ table, column, definition string }{
+		{"tierlists", "view_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"tierlists", "trending_score", "REAL NOT NULL DEFAULT 0"},
+		{"tierlists", "is_hidden", "INTEGER NOT NULL DEFAULT 0"},
+		{"tierlists", "creator_ip", "TEXT"},
+		{"audit_log", "game_id", "TEXT"},
+		{"audit_log", "before_json", "TEXT"},
+		{"audit_log", "after_json", "TEXT"},
+		{"games", "versions", "TEXT"},
+		{"items", "game_version", "TEXT NOT NULL DEFAULT ''"},
+		{"tierlists", "game_version", "TEXT NOT NULL DEFAULT ''"},
+		{"tierlists", "status", "TEXT NOT NULL DEFAULT 'published'"},
+		{"tierlists", "autosave", "TEXT"},
+		{"users", "email_verified_at", "DATETIME"},
+		{"users", "weekly_digest", "INTEGER NOT NULL DEFAULT 0"},
+		{"users", "digest_sent_at", "DATETIME"},
+		{"games", "theme", "TEXT"},
+		{"tierlists", "palette", "TEXT NOT NULL DEFAULT ''"},
+		{"games", "workspace_id", "TEXT NOT NULL DEFAULT ''"},
+		{"tierlists", "workspace_id", "TEXT NOT NULL DEFAULT ''"},
+		{"tierlists", "is_private", "INTEGER NOT NULL DEFAULT 0"},
+		{"items", "spoiler", "INTEGER NOT NULL DEFAULT 0"},
+		{"games", "spoilers", "TEXT"},
+		{"users", "analytics_opt_out", "INTEGER NOT NULL DEFAULT 0"},
+		{"tierlists", "pool_order", "TEXT NOT NULL DEFAULT 'alphabetical'"},
+		{"tierlists", "pool_seed", "INTEGER NOT NULL DEFAULT 0"},
+		{"tierlists", "constraints", "TEXT"},
+		{"tierlists", "poll", "TEXT NOT NULL DEFAULT ''"},
+		{"items", "updated_at", "DATETIME"},
+		{"bans", "expires_at", "DATETIME"},
+		{"bans", "created_by", "TEXT NOT NULL DEFAULT ''"},
+		{"tierlists", "creator_device", "TEXT"},
+		{"items", "tierlist_id", "TEXT NOT NULL DEFAULT ''"},
+		{"items", "upload_bytes", "INTEGER NOT NULL DEFAULT 0"},
+		{"tierlists", "record_replay", "INTEGER NOT NULL DEFAULT 0"},
+		{"tierlists", "locked_at", "DATETIME"},
+		{"tierlists", "forked_from", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, c := range columns {
+		if err := s.addColumn(c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	}
+
+	if err := s.seedPalettes(); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	if err := s.migrateSearch(); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	if err := s.migrateSync(); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	if err := s.migrateCustomItems(); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	// Data migrations rewrite existing rows once. Each is listed with the
+	// schema version it shipped with and is only ever appended.
+	dataMigrations := []struct {
+		version int
+		run     func() error
+	}{
+		{98, s.migratePositions},
+		{107, s.migrateItemIDs},
+	}
+	for _, m := range dataMigrations {
+		if applied >= m.version {
+			continue
+		}
+		if err := m.run(); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	}
+
+	// Migrations are only ever appended, so their count versions the schema
+	s.schemaVersion = len(migrations) + len(columns)
+	if applied < s.schemaVersion {
+		if _, err := s.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", s.schemaVersion)); err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	}
 	return nil
 }
 
+// addColumn adds a column to an existing table unless it is already present
+func (s *Store) addColumn(table, column, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
 // --- Games ---
 
 // GetGames returns all games
 func (s *Store) GetGames() ([]models.Game, error) {
-	rows, err := s.db.Query(`
-		SELECT id, name, description, icon_url, item_schema, filters, default_tiers, sheets, created_at
-		FROM games ORDER BY name
-	`)
+	rows, err := s.db.Query(`SELECT ` + gameColumns + ` FROM games ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
@@ -103,54 +614,90 @@ func (s *Store) GetGames() ([]models.Game, error) {
 
 	games := make([]models.Game, 0)
 	for rows.Next() {
-		var g models.Game
-		var itemSchema, filters, defaultTiers, sheets string
-		err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.IconURL,
-			&itemSchema, &filters, &defaultTiers, &sheets, &g.CreatedAt)
+		g, err := scanGame(rows)
 		if err != nil {
 			return nil, err
 		}
-		json.Unmarshal([]byte(itemSchema), &g.ItemSchema)
-		json.Unmarshal([]byte(filters), &g.Filters)
-		json.Unmarshal([]byte(defaultTiers), &g.DefaultTiers)
-		json.Unmarshal([]byte(sheets), &g.Sheets)
-		games = append(games, g)
+		games = append(games, *g)
 	}
-	return games, nil
+	return games, rows.Err()
 }
 
 // GetGame returns a game by ID
 func (s *Store) GetGame(id string) (*models.Game, error) {
-	var g models.Game
-	var itemSchema, filters, defaultTiers, sheets string
-	err := s.db.QueryRow(`
-		SELECT id, name, description, icon_url, item_schema, filters, default_tiers, sheets, created_at
-		FROM games WHERE id = ?
-	`, id).Scan(&g.ID, &g.Name, &g.Description, &g.IconURL,
-		&itemSchema, &filters, &defaultTiers, &sheets, &g.CreatedAt)
+	g, err := scanGame(s.db.QueryRow(`SELECT `+gameColumns+` FROM games WHERE id = ?`, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	json.Unmarshal([]byte(itemSchema), &g.ItemSchema)
-	json.Unmarshal([]byte(filters), &g.Filters)
-	json.Unmarshal([]byte(defaultTiers), &g.DefaultTiers)
-	json.Unmarshal([]byte(sheets), &g.Sheets)
+	return g, nil
+}
+
+// gameColumns is the column list shared by all game reads
+const gameColumns = `id, name, description, icon_url, item_schema, filters, default_tiers, sheets, versions, theme, spoilers, workspace_id, created_at`
+
+// scanGame reads a game selected with gameColumns. Columns left NULL, as by
+// hand-edited databases, read as empty values.
+func scanGame(row rowScanner) (*models.Game, error) {
+	var g models.Game
+	var description, iconURL, itemSchema, filters, defaultTiers, sheets sql.NullString
+	var versions, theme, spoilers sql.NullString
+	var createdAt sql.NullTime
+	err := row.Scan(&g.ID, &g.Name, &description, &iconURL,
+		&itemSchema, &filters, &defaultTiers, &sheets, &versions, &theme, &spoilers, &g.WorkspaceID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	g.Description, g.IconURL, g.CreatedAt = description.String, iconURL.String, createdAt.Time
+	for _, c := range []struct {
+		column string
+		data   sql.NullString
+		v      interface{}
+	}{
+		{"item_schema", itemSchema, &g.ItemSchema},
+		{"filters", filters, &g.Filters},
+		{"default_tiers", defaultTiers, &g.DefaultTiers},
+		{"sheets", sheets, &g.Sheets},
+		{"versions", versions, &g.Versions},
+		{"theme", theme, &g.Theme},
+		{"spoilers", spoilers, &g.Spoilers},
+	} {
+		if err := decodeColumn("game", g.ID, c.column, c.data.String, c.v); err != nil {
+			return nil, err
+		}
+	}
 	return &g, nil
 }
 
 // CreateGame creates a new game
 func (s *Store) CreateGame(g *models.Game) error {
+	defer s.catalogChanged()
+	return upsertGame(s.db, g)
+}
+
+// upsertGame creates or replaces a game row
+func upsertGame(db execer, g *models.Game) error {
 	itemSchema, _ := json.Marshal(g.ItemSchema)
 	filters, _ := json.Marshal(g.Filters)
 	defaultTiers, _ := json.Marshal(g.DefaultTiers)
 	sheets, _ := json.Marshal(g.Sheets)
+	versions, _ := json.Marshal(g.Versions)
+	var theme interface{}
+	if g.Theme != nil {
+		b, _ := json.Marshal(g.Theme)
+		theme = string(b)
+	}
+	var spoilers interface{}
+	if g.Spoilers != nil {
+		b, _ := json.Marshal(g.Spoilers)
+		spoilers = string(b)
+	}
 
-	_, err := s.db.Exec(`
-		INSERT INTO games (id, name, description, icon_url, item_schema, filters, default_tiers, sheets)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	_, err := db.Exec(`
+		INSERT INTO games (id, name, description, icon_url, item_schema, filters, default_tiers, sheets, versions, theme, spoilers, workspace_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			description = excluded.description,
@@ -158,35 +705,239 @@ func (s *Store) CreateGame(g *models.Game) error {
 			item_schema = excluded.item_schema,
 			filters = excluded.filters,
 			default_tiers = excluded.default_tiers,
-			sheets = excluded.sheets
-	`, g.ID, g.Name, g.Description, g.IconURL, itemSchema, filters, defaultTiers, sheets)
+			sheets = excluded.sheets,
+			versions = excluded.versions,
+			theme = excluded.theme,
+			spoilers = excluded.spoilers,
+			workspace_id = excluded.workspace_id
+	`, g.ID, g.Name, g.Description, g.IconURL, itemSchema, filters, defaultTiers, sheets, versions, theme, spoilers, g.WorkspaceID)
 	return err
 }
 
 // --- Items ---
 
+// itemColumns is the column list shared by all item reads
+const itemColumns = `id, game_id, sheet_id, name, name_ru, icon, category, data, game_version, spoiler, updated_at, tierlist_id`
+
+// scanItem reads an item selected with itemColumns. Columns left NULL read
+// as empty values, and missing data as an empty object. Every item read goes
+// through here, so the HTML in its data is sanitized here too: rows imported
+// before sanitizing was added may still hold raw HTML.
+func scanItem(row rowScanner) (*models.Item, error) {
+	item, err := scanStoredItem(row)
+	if err != nil {
+		return nil, err
+	}
+	sanitize.ItemData(item.Data)
+	return item, nil
+}
+
+// scanStoredItem reads an item like scanItem but leaves its data as stored
+func scanStoredItem(row rowScanner) (*models.Item, error) {
+	var item models.Item
+	var nameRu, icon, category, data sql.NullString
+	var updatedAt sql.NullTime
+	err := row.Scan(&item.ID, &item.GameID, &item.SheetID, &item.Name,
+		&nameRu, &icon, &category, &data, &item.GameVersion, &item.Spoiler, &updatedAt, &item.TierListID)
+	if err != nil {
+		return nil, err
+	}
+	item.NameRu, item.Icon, item.Category = nameRu.String, icon.String, category.String
+	if updatedAt.Valid {
+		item.UpdatedAt = &updatedAt.Time
+	}
+	if err := decodeColumn("item", item.GameID+"/"+item.ID, "data", data.String, &item.Data); err != nil {
+		return nil, err
+	}
+	if item.Data == nil {
+		item.Data = map[string]interface{}{}
+	}
+	return &item, nil
+}
+
+// ItemQuery selects catalog items; empty fields are not filtered on
+type ItemQuery struct {
+	GameID  string
+	SheetID string
+	// Version limits results to items of that game version plus items that
+	// apply to every version
+	Version string
+	// Tags limits results to items carrying all of the given tags
+	Tags []string
+	// Sort orders results by name, name_ru, category or a data field written
+	// as data.<key>, with empty values last. It defaults to name.
+	Sort string
+	Desc bool
+	// Since limits results to items changed after it
+	Since time.Time
+	// TierListID adds the items uploaded to that tier list; without it
+	// uploaded items are left out
+	TierListID string
+}
+
+// dataSortPattern matches the data fields items can be sorted by
+var dataSortPattern = regexp.MustCompile(`^data\.[A-Za-z0-9_]{1,64}$`)
+
+// ValidItemSort reports whether sort can be used as ItemQuery.Sort
+func ValidItemSort(sort string) bool {
+	switch sort {
+	case "", "name", "name_ru", "category":
+		return true
+	}
+	return dataSortPattern.MatchString(sort)
+}
+
+// itemOrder returns the ORDER BY clause of q and its arguments
+func itemOrder(q ItemQuery) (string, []interface{}) {
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	expr, args := q.Sort, []interface{}(nil)
+	switch {
+	case q.Sort == "" || q.Sort == "name":
+		return ` ORDER BY name ` + dir + `, id`, nil
+	case strings.HasPrefix(q.Sort, "data."):
+		// Corrupt data must not fail the whole listing
+		expr = `CASE WHEN json_valid(data) THEN json_extract(data, ?) END`
+		path := `$."` + strings.TrimPrefix(q.Sort, "data.") + `"`
+		args = []interface{}{path, path}
+	}
+	return ` ORDER BY NULLIF(` + expr + `, '') IS NULL, ` + expr + ` ` + dir + `, name, id`, args
+}
+
+// GetItem returns a single item of a game, or nil if not found. IDs the item
+// was renamed from with RenameItem still find it.
+func (s *Store) GetItem(gameID, itemID string) (*models.Item, error) {
+	item, err := scanItem(s.db.QueryRow(`
+		SELECT `+itemColumns+`
+		FROM items WHERE game_id = ?1 AND id = ?2
+		UNION ALL
+		SELECT `+prefixColumns("i", itemColumns)+`
+		FROM item_id_redirects r JOIN items i ON i.game_id = r.game_id AND i.id = r.new_id
+		WHERE r.game_id = ?1 AND r.old_id = ?2
+		LIMIT 1
+	`, gameID, itemID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.loadItemTags(gameID, item.ID)
+	if err != nil {
+		return nil, err
+	}
+	item.Tags = tags[item.ID]
+	aliases, err := s.loadItemAliases(gameID, item.ID)
+	if err != nil {
+		return nil, err
+	}
+	item.Aliases = aliases[item.ID]
+	return item, nil
+}
+
+// DeleteItem deletes a single item of a game
+func (s *Store) DeleteItem(gameID, itemID string) error {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM items WHERE game_id = ? AND id = ?", gameID, itemID); err != nil {
+		return err
+	}
+	if err := setItemTags(tx, gameID, itemID, nil); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM item_aliases WHERE game_id = ? AND item_id = ?`, gameID, itemID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM item_relations WHERE game_id = ? AND (item_id = ? OR related_id = ?)
+	`, gameID, itemID, itemID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // DeleteItemsByGame deletes all items for a specific game
 func (s *Store) DeleteItemsByGame(gameID string) error {
+	defer s.catalogChanged()
 	_, err := s.db.Exec("DELETE FROM items WHERE game_id = ?", gameID)
 	return err
 }
 
-// GetItems returns items for a game, optionally filtered by sheet
+// DeleteItemsBySheet deletes the items of one sheet of a game
+func (s *Store) DeleteItemsBySheet(gameID, sheetID string) error {
+	defer s.catalogChanged()
+	_, err := s.db.Exec("DELETE FROM items WHERE game_id = ? AND sheet_id = ?", gameID, sheetID)
+	return err
+}
+
+// GetItems returns the catalog items of a game, optionally filtered by sheet
 func (s *Store) GetItems(gameID, sheetID string) ([]models.Item, error) {
-	var rows *sql.Rows
-	var err error
-
-	if sheetID != "" {
-		rows, err = s.db.Query(`
-			SELECT id, game_id, sheet_id, name, name_ru, icon, category, data
-			FROM items WHERE game_id = ? AND sheet_id = ? ORDER BY name
-		`, gameID, sheetID)
-	} else {
-		rows, err = s.db.Query(`
-			SELECT id, game_id, sheet_id, name, name_ru, icon, category, data
-			FROM items WHERE game_id = ? ORDER BY name
-		`, gameID)
+	return s.QueryItems(ItemQuery{GameID: gameID, SheetID: sheetID})
+}
+
+// GetStoredItems returns the catalog items of a game with their data as
+// stored, skipping the sanitizing every other read applies. It is for tools
+// that find and rewrite rows still holding raw HTML.
+func (s *Store) GetStoredItems(gameID string) ([]models.Item, error) {
+	rows, err := s.db.Query(`SELECT `+itemColumns+` FROM items WHERE game_id = ? AND tierlist_id = '' ORDER BY name`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.Item, 0)
+	for rows.Next() {
+		item, err := scanStoredItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+	return items, rows.Err()
+}
+
+// QueryItems returns the items matching q, ordered by name
+func (s *Store) QueryItems(q ItemQuery) ([]models.Item, error) {
+	query := `SELECT ` + itemColumns + ` FROM items WHERE game_id = ? AND tierlist_id IN ('', ?)`
+	args := []interface{}{q.GameID, q.TierListID}
+	if q.SheetID != "" {
+		ids, virtual, err := s.virtualSheetItems(q.GameID, q.SheetID)
+		if err != nil {
+			return nil, err
+		}
+		if virtual {
+			query += ` AND id IN (SELECT value FROM json_each(?))`
+			args = append(args, ids)
+		} else {
+			query += ` AND sheet_id = ?`
+			args = append(args, q.SheetID)
+		}
+	}
+	if q.Version != "" {
+		query += ` AND (game_version = '' OR game_version = ?)`
+		args = append(args, q.Version)
+	}
+	for _, tag := range q.Tags {
+		query += ` AND id IN (SELECT item_id FROM item_tags WHERE game_id = items.game_id AND tag = ?)`
+		args = append(args, tag)
 	}
+	if !q.Since.IsZero() {
+		query += ` AND updated_at > ?`
+		args = append(args, q.Since.UTC())
+	}
+	order, orderArgs := itemOrder(q)
+	query += order
+	args = append(args, orderArgs...)
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -194,51 +945,131 @@ func (s *Store) GetItems(gameID, sheetID string) ([]models.Item, error) {
 
 	items := make([]models.Item, 0)
 	for rows.Next() {
-		var item models.Item
-		var dataStr string
-		err := rows.Scan(&item.ID, &item.GameID, &item.SheetID, &item.Name,
-			&item.NameRu, &item.Icon, &item.Category, &dataStr)
+		item, err := scanItem(rows)
 		if err != nil {
 			return nil, err
 		}
-		json.Unmarshal([]byte(dataStr), &item.Data)
-		items = append(items, item)
+		items = append(items, *item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tags, err := s.loadItemTags(q.GameID, "")
+	if err != nil {
+		return nil, err
+	}
+	aliases, err := s.loadItemAliases(q.GameID, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Tags = tags[items[i].ID]
+		items[i].Aliases = aliases[items[i].ID]
 	}
 	return items, nil
 }
 
-// CreateItem creates a new item
+// GetSpoilerItemIDs returns the IDs of a game's spoiler items
+func (s *Store) GetSpoilerItemIDs(gameID string) (map[string]bool, error) {
+	rows, err := s.db.Query(`SELECT id FROM items WHERE game_id = ? AND spoiler = 1`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// CreateItem creates a new item. Returns ErrDuplicate if the ID is taken.
 func (s *Store) CreateItem(item *models.Item) error {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	data, _ := json.Marshal(item.Data)
-	_, err := s.db.Exec(`
-		INSERT INTO items (id, game_id, sheet_id, name, name_ru, icon, category, data)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, item.ID, item.GameID, item.SheetID, item.Name, item.NameRu, item.Icon, item.Category, data)
-	return err
+	_, err = tx.Exec(`
+		INSERT INTO items (id, game_id, sheet_id, name, name_ru, icon, category, data, game_version, spoiler)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, item.ID, item.GameID, item.SheetID, item.Name, item.NameRu, item.Icon, item.Category, data, item.GameVersion, item.Spoiler)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	if err := setItemTags(tx, item.GameID, item.ID, item.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// UpdateItem updates an existing item.
+// UpdateItem updates an existing item, replacing its tags.
 func (s *Store) UpdateItem(item *models.Item) error {
+	defer s.catalogChanged()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	data, _ := json.Marshal(item.Data)
-	_, err := s.db.Exec(`
+	_, err = tx.Exec(`
 		UPDATE items
-		SET game_id = ?, sheet_id = ?, name = ?, name_ru = ?, icon = ?, category = ?, data = ?
+		SET game_id = ?, sheet_id = ?, name = ?, name_ru = ?, icon = ?, category = ?, data = ?, game_version = ?, spoiler = ?
 		WHERE id = ?
-	`, item.GameID, item.SheetID, item.Name, item.NameRu, item.Icon, item.Category, data, item.ID)
-	return err
+	`, item.GameID, item.SheetID, item.Name, item.NameRu, item.Icon, item.Category, data, item.GameVersion, item.Spoiler, item.ID)
+	if err != nil {
+		return err
+	}
+	if err := setItemTags(tx, item.GameID, item.ID, item.Tags); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// BulkCreateItems creates multiple items in a transaction
+// BulkCreateItems creates multiple items in a transaction. Items with tags
+// have their tags replaced; items without keep the ones they have.
 func (s *Store) BulkCreateItems(items []models.Item) error {
+	defer s.catalogChanged()
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := insertItems(tx, items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Tags == nil {
+			continue
+		}
+		if err := setItemTags(tx, item.GameID, item.ID, item.Tags); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertItems upserts items within an open transaction
+func insertItems(tx txn, items []models.Item) error {
 	stmt, err := tx.Prepare(`
-		INSERT OR REPLACE INTO items (id, game_id, sheet_id, name, name_ru, icon, category, data)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT OR REPLACE INTO items (id, game_id, sheet_id, name, name_ru, icon, category, data, game_version, spoiler)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return err
@@ -248,13 +1079,12 @@ func (s *Store) BulkCreateItems(items []models.Item) error {
 	for _, item := range items {
 		data, _ := json.Marshal(item.Data)
 		_, err := stmt.Exec(item.ID, item.GameID, item.SheetID, item.Name,
-			item.NameRu, item.Icon, item.Category, data)
+			item.NameRu, item.Icon, item.Category, data, item.GameVersion, item.Spoiler)
 		if err != nil {
 			return err
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
 // --- TierLists ---
@@ -271,41 +1101,98 @@ func (s *Store) CreateTierList(tl *models.TierListCreate) (*models.TierList, err
 	shareCode := generateShareCode()
 	tiers, _ := json.Marshal(tl.Tiers)
 	now := time.Now()
+	visibility := tl.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityUnlisted
+	}
+	poolOrder := tl.PoolOrder
+	if poolOrder == "" {
+		poolOrder = models.PoolAlphabetical
+	}
 
-	_, err := s.db.Exec(`
-		INSERT INTO tierlists (id, game_id, sheet_id, name, tiers, share_code, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, id, tl.GameID, tl.SheetID, tl.Name, tiers, shareCode, now, now)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO tierlists (id, game_id, sheet_id, name, author_id, tiers, share_code, creator_ip, creator_device, game_version, status, palette, workspace_id, is_public, is_private, pool_order, pool_seed, constraints, record_replay, forked_from, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, tl.GameID, tl.SheetID, tl.Name, tl.AuthorID, tiers, shareCode, tl.CreatorIP, tl.CreatorDevice, tl.GameVersion, tl.Status, tl.Palette, tl.WorkspaceID,
+		visibility == models.VisibilityPublic, visibility == models.VisibilityPrivate, poolOrder, tl.PoolSeed, constraintsJSON(tl.Constraints), tl.RecordReplay, tl.ForkedFrom, now, now)
 	if err != nil {
 		return nil, err
 	}
+	if tl.RecordReplay {
+		if err := startReplay(tx, id, tl.Tiers, now); err != nil {
+			return nil, err
+		}
+	}
+	if err := setTierListTags(tx, id, tl.Tags); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	tags := tl.Tags
+	if tags == nil {
+		tags = []string{}
+	}
 
 	return &models.TierList{
-		ID:        id,
-		GameID:    tl.GameID,
-		SheetID:   tl.SheetID,
-		Name:      tl.Name,
-		Tiers:     tl.Tiers,
-		ShareCode: shareCode,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:           id,
+		GameID:       tl.GameID,
+		SheetID:      tl.SheetID,
+		Name:         tl.Name,
+		AuthorID:     tl.AuthorID,
+		Tiers:        tl.Tiers,
+		ShareCode:    shareCode,
+		IsPublic:     visibility == models.VisibilityPublic,
+		Visibility:   visibility,
+		GameVersion:  tl.GameVersion,
+		Status:       tl.Status,
+		Palette:      tl.Palette,
+		WorkspaceID:  tl.WorkspaceID,
+		PoolOrder:    poolOrder,
+		PoolSeed:     tl.PoolSeed,
+		Constraints:  tl.Constraints,
+		RecordReplay: tl.RecordReplay,
+		ForkedFrom:   tl.ForkedFrom,
+		Tags:         tags,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}, nil
 }
 
-// GetTierList returns a tier list by ID
-func (s *Store) GetTierList(id string) (*models.TierList, error) {
+// constraintsJSON encodes tier list constraints, storing NULL when there are none
+func constraintsJSON(c *models.Constraints) interface{} {
+	if c == nil || *c == (models.Constraints{}) {
+		return nil
+	}
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+// tierListColumns is the column list shared by all tier list reads
+const tierListColumns = `id, game_id, sheet_id, name, author_id, tiers, share_code, is_public, is_private, status, is_hidden, view_count, game_version, palette, workspace_id, pool_order, pool_seed, constraints, poll, record_replay, locked_at, forked_from, created_at, updated_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTierList reads a tier list selected with tierListColumns
+func scanTierList(row rowScanner) (*models.TierList, error) {
 	var tl models.TierList
 	var tiersStr string
-	var authorID sql.NullString
+	var authorID, constraints sql.NullString
+	var lockedAt sql.NullTime
+	var private bool
 
-	err := s.db.QueryRow(`
-		SELECT id, game_id, sheet_id, name, author_id, tiers, share_code, is_public, created_at, updated_at
-		FROM tierlists WHERE id = ?
-	`, id).Scan(&tl.ID, &tl.GameID, &tl.SheetID, &tl.Name, &authorID,
-		&tiersStr, &tl.ShareCode, &tl.IsPublic, &tl.CreatedAt, &tl.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	err := row.Scan(&tl.ID, &tl.GameID, &tl.SheetID, &tl.Name, &authorID,
+		&tiersStr, &tl.ShareCode, &tl.IsPublic, &private, &tl.Status, &tl.Hidden, &tl.ViewCount, &tl.GameVersion, &tl.Palette, &tl.WorkspaceID, &tl.PoolOrder, &tl.PoolSeed, &constraints, &tl.Poll, &tl.RecordReplay, &lockedAt, &tl.ForkedFrom, &tl.CreatedAt, &tl.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -313,40 +1200,80 @@ func (s *Store) GetTierList(id string) (*models.TierList, error) {
 	if authorID.Valid {
 		tl.AuthorID = &authorID.String
 	}
-	json.Unmarshal([]byte(tiersStr), &tl.Tiers)
+	if lockedAt.Valid {
+		tl.LockedAt = &lockedAt.Time
+	}
+	switch {
+	case private:
+		tl.Visibility = models.VisibilityPrivate
+	case tl.IsPublic:
+		tl.Visibility = models.VisibilityPublic
+	default:
+		tl.Visibility = models.VisibilityUnlisted
+	}
+	if err := decodeColumn("tier list", tl.ID, "tiers", tiersStr, &tl.Tiers); err != nil {
+		return nil, err
+	}
+	if err := decodeColumn("tier list", tl.ID, "constraints", constraints.String, &tl.Constraints); err != nil {
+		return nil, err
+	}
 	return &tl, nil
 }
 
+// GetTierList returns a tier list by ID
+func (s *Store) GetTierList(id string) (*models.TierList, error) {
+	tl, err := scanTierList(s.db.QueryRow(`
+		SELECT `+tierListColumns+`
+		FROM tierlists WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(tl); err != nil {
+		return nil, err
+	}
+	return tl, nil
+}
+
 // GetTierListByShareCode returns a tier list by share code
 func (s *Store) GetTierListByShareCode(code string) (*models.TierList, error) {
-	var tl models.TierList
-	var tiersStr string
-	var authorID sql.NullString
-
-	err := s.db.QueryRow(`
-		SELECT id, game_id, sheet_id, name, author_id, tiers, share_code, is_public, created_at, updated_at
-		FROM tierlists WHERE share_code = ?
-	`, code).Scan(&tl.ID, &tl.GameID, &tl.SheetID, &tl.Name, &authorID,
-		&tiersStr, &tl.ShareCode, &tl.IsPublic, &tl.CreatedAt, &tl.UpdatedAt)
+	tl, err := scanTierList(s.db.QueryRow(`
+		SELECT `+tierListColumns+`
+		FROM tierlists WHERE share_code = ? AND is_private = 0
+	`, code))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-
-	if authorID.Valid {
-		tl.AuthorID = &authorID.String
+	if err := s.attachTags(tl); err != nil {
+		return nil, err
 	}
-	json.Unmarshal([]byte(tiersStr), &tl.Tiers)
-	return &tl, nil
+	return tl, nil
 }
 
 // UpdateTierList updates an existing tier list
 func (s *Store) UpdateTierList(id string, update *models.TierListUpdate) error {
-	// Build dynamic update query
-	sets := []string{"updated_at = ?"}
-	args := []interface{}{time.Now()}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if update.Tiers != nil || update.RecordReplay != nil {
+		if err := updateReplay(tx, id, update, now); err != nil {
+			return err
+		}
+	}
+
+	// Build dynamic update query. A real save supersedes any autosave.
+	sets := []string{"updated_at = ?", "autosave = NULL"}
+	args := []interface{}{now}
 
 	if update.Name != nil {
 		sets = append(sets, "name = ?")
@@ -357,17 +1284,45 @@ func (s *Store) UpdateTierList(id string, update *models.TierListUpdate) error {
 		sets = append(sets, "tiers = ?")
 		args = append(args, tiers)
 	}
-	if update.IsPublic != nil {
-		sets = append(sets, "is_public = ?")
-		args = append(args, *update.IsPublic)
+	switch {
+	case update.Visibility != nil:
+		sets = append(sets, "is_public = ?", "is_private = ?")
+		args = append(args, *update.Visibility == models.VisibilityPublic, *update.Visibility == models.VisibilityPrivate)
+	case update.IsPublic != nil && *update.IsPublic:
+		sets = append(sets, "is_public = 1", "is_private = 0")
+	case update.IsPublic != nil:
+		sets = append(sets, "is_public = 0")
+	}
+	if update.Status != nil {
+		sets = append(sets, "status = ?")
+		args = append(args, *update.Status)
+	}
+	if update.Palette != nil {
+		sets = append(sets, "palette = ?")
+		args = append(args, *update.Palette)
+	}
+	if update.Constraints != nil {
+		sets = append(sets, "constraints = ?")
+		args = append(args, constraintsJSON(update.Constraints))
+	}
+	if update.RecordReplay != nil {
+		sets = append(sets, "record_replay = ?")
+		args = append(args, *update.RecordReplay)
 	}
 
 	args = append(args, id)
 	query := fmt.Sprintf("UPDATE tierlists SET %s WHERE id = ?",
 		stringJoin(sets, ", "))
 
-	_, err := s.db.Exec(query, args...)
-	return err
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if update.Tags != nil {
+		if err := setTierListTags(tx, id, *update.Tags); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // DeleteTierList deletes a tier list by ID
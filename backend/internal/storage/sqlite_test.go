@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestStore opens a fresh database and loads the named fixtures from
+// testdata into it
+func newTestStore(t *testing.T, fixtures ...string) *Store {
+	t.Helper()
+	s, err := New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	for _, name := range fixtures {
+		sql, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("read fixture %s: %v", name, err)
+		}
+		if _, err := s.db.Exec(string(sql)); err != nil {
+			t.Fatalf("load fixture %s: %v", name, err)
+		}
+	}
+	return s
+}
+
+func TestGetGameWithNullColumns(t *testing.T) {
+	s := newTestStore(t, "null_columns.sql")
+
+	g, err := s.GetGame("bare")
+	if err != nil {
+		t.Fatalf("GetGame: %v", err)
+	}
+	if g == nil {
+		t.Fatal("GetGame returned no game")
+	}
+	if g.Name != "Bare Game" || g.Description != "" || g.IconURL != "" {
+		t.Errorf("got name %q, description %q, icon %q; want the name and empty defaults", g.Name, g.Description, g.IconURL)
+	}
+	if !g.CreatedAt.IsZero() {
+		t.Errorf("CreatedAt = %v, want zero", g.CreatedAt)
+	}
+	if len(g.Sheets) != 0 || len(g.DefaultTiers) != 0 || g.Theme != nil || g.Spoilers != nil {
+		t.Errorf("JSON columns decoded to %+v, want empty", g)
+	}
+
+	games, err := s.GetGames()
+	if err != nil {
+		t.Fatalf("GetGames: %v", err)
+	}
+	found := false
+	for _, game := range games {
+		found = found || game.ID == "bare"
+	}
+	if !found {
+		t.Errorf("GetGames = %+v, want the bare game among them", games)
+	}
+}
+
+func TestGetItemsWithNullColumns(t *testing.T) {
+	s := newTestStore(t, "null_columns.sql")
+
+	items, err := s.GetItems("bare", "main")
+	if err != nil {
+		t.Fatalf("GetItems: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("GetItems returned %d items, want 2", len(items))
+	}
+	byID := make(map[string]int)
+	for i, item := range items {
+		byID[item.ID] = i
+	}
+
+	plain := items[byID["plain"]]
+	if plain.NameRu != "" || plain.Icon != "" || plain.Category != "" {
+		t.Errorf("plain item = %+v, want empty name_ru, icon and category", plain)
+	}
+	if plain.Data == nil || len(plain.Data) != 0 {
+		t.Errorf("plain item data = %#v, want an empty object", plain.Data)
+	}
+
+	filled := items[byID["filled"]]
+	if filled.NameRu != "Заполненный" || filled.Icon != "filled.png" || filled.Category != "weapon" {
+		t.Errorf("filled item = %+v, want its stored columns", filled)
+	}
+	if filled.Data["power"] != float64(3) {
+		t.Errorf("filled item data = %#v, want power 3", filled.Data)
+	}
+
+	item, err := s.GetItem("bare", "plain")
+	if err != nil || item == nil {
+		t.Fatalf("GetItem = %v, %v", item, err)
+	}
+	if item.Data == nil {
+		t.Error("GetItem data is nil, want an empty object")
+	}
+}
+
+func TestItemReadsSanitizeStoredHTML(t *testing.T) {
+	s := newTestStore(t, "raw_infobox.sql")
+	const clean = "<b>Bold</b>"
+
+	item, err := s.GetItem("wiki", "raw")
+	if err != nil || item == nil {
+		t.Fatalf("GetItem = %v, %v", item, err)
+	}
+	if got := item.Data["infobox_html"]; got != clean {
+		t.Errorf("GetItem infobox_html = %q, want %q", got, clean)
+	}
+	if item.Data["power"] != float64(3) {
+		t.Errorf("GetItem data = %v, want the other fields untouched", item.Data)
+	}
+
+	items, err := s.QueryItems(ItemQuery{GameID: "wiki", SheetID: "main"})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("QueryItems = %v, %v", items, err)
+	}
+	if got := items[0].Data["infobox_html"]; got != clean {
+		t.Errorf("QueryItems infobox_html = %q, want %q", got, clean)
+	}
+
+	stored, err := s.GetStoredItems("wiki")
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("GetStoredItems = %v, %v", stored, err)
+	}
+	if got, _ := stored[0].Data["infobox_html"].(string); got == clean {
+		t.Error("GetStoredItems sanitized the data it should return as stored")
+	}
+}
+
+func TestOpenTransliteratesCyrillicItemIDs(t *testing.T) {
+	s := newTestStore(t, "cyrillic_ids.sql")
+	// Opening the database again runs the migrations over the fixture
+	reopened, err := New(s.path)
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer reopened.Close()
+
+	tests := []struct {
+		id       string
+		resolves string
+	}{
+		{"ognennyj-shar", "ognennyj-shar"},
+		{"огненный-шар", "ognennyj-shar"},
+		{"щит", "щит"},
+		{"shhit", "shhit"},
+	}
+	for _, tt := range tests {
+		item, err := reopened.GetItem("ru", tt.id)
+		if err != nil || item == nil || item.ID != tt.resolves {
+			t.Errorf("GetItem(%q) = %+v, %v; want item %s", tt.id, item, err, tt.resolves)
+		}
+	}
+
+	tl, err := reopened.GetTierList("list")
+	if err != nil || tl == nil {
+		t.Fatalf("GetTierList = %v, %v", tl, err)
+	}
+	if got := tl.Tiers[0].Items; len(got) != 2 || got[0] != "ognennyj-shar" || got[1] != "щит" {
+		t.Errorf("tier items = %q, want the renamed ID and the kept one", got)
+	}
+	positions, err := reopened.GetItemPositions("list")
+	if err != nil {
+		t.Fatalf("GetItemPositions: %v", err)
+	}
+	if _, ok := positions["ognennyj-shar"]; !ok || len(positions) != 1 {
+		t.Errorf("positions = %v, want the renamed item's", positions)
+	}
+
+	// The rename runs once; later opens leave the kept ID alone
+	if _, err := reopened.db.Exec(`INSERT INTO items (id, game_id, sheet_id, name) VALUES ('меч', 'ru', 'main', 'Меч')`); err != nil {
+		t.Fatalf("insert item: %v", err)
+	}
+	again, err := New(s.path)
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer again.Close()
+	if item, err := again.GetItem("ru", "меч"); err != nil || item == nil || item.ID != "меч" {
+		t.Errorf("GetItem(меч) after another open = %+v, %v; want it untouched", item, err)
+	}
+}
+
+func TestOpenLaysOutLegacyPositionsOnce(t *testing.T) {
+	s := newTestStore(t, "legacy_positions.sql")
+	// Opening the database again runs the migrations over the fixture
+	reopened, err := New(s.path)
+	if err != nil {
+		t.Fatalf("reopen store despite a corrupt list: %v", err)
+	}
+
+	tests := []struct {
+		list  string
+		items map[string]string
+	}{
+		{"ranked", map[string]string{"a": "s", "b": "s", "c": "a"}},
+		{"corrupt", map[string]string{}},
+	}
+	for _, tt := range tests {
+		positions, err := reopened.GetItemPositions(tt.list)
+		if err != nil {
+			t.Fatalf("GetItemPositions(%s): %v", tt.list, err)
+		}
+		if len(positions) != len(tt.items) {
+			t.Errorf("%s positions = %v, want %d", tt.list, positions, len(tt.items))
+		}
+		for item, tier := range tt.items {
+			if positions[item].TierID != tier {
+				t.Errorf("%s: %s at %+v, want tier %s", tt.list, item, positions[item], tier)
+			}
+		}
+	}
+	if positions, _ := reopened.GetItemPositions("ranked"); positions["a"].Position >= positions["b"].Position {
+		t.Errorf("positions = %v, want a before b", positions)
+	}
+
+	// A list left without positions is not laid out again on the next open
+	if err := reopened.SaveItemPositions("ranked", nil); err != nil {
+		t.Fatalf("SaveItemPositions: %v", err)
+	}
+	reopened.Close()
+	again, err := New(s.path)
+	if err != nil {
+		t.Fatalf("reopen store: %v", err)
+	}
+	defer again.Close()
+	if positions, err := again.GetItemPositions("ranked"); err != nil || len(positions) != 0 {
+		t.Errorf("positions after another open = %v, %v; want none", positions, err)
+	}
+}
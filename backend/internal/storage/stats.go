@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TableCounts returns the number of rows in every table. Shadow tables of
+// full-text indexes are left out.
+func (s *Store) TableCounts() (map[string]int64, error) {
+	rows, err := s.db.Query(`
+		SELECT name, sql FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var names, virtual []string
+	for rows.Next() {
+		var name string
+		var ddl sql.NullString
+		if err := rows.Scan(&name, &ddl); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if strings.HasPrefix(strings.ToUpper(ddl.String), "CREATE VIRTUAL") {
+			virtual = append(virtual, name+"_")
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(names))
+tables:
+	for _, name := range names {
+		for _, prefix := range virtual {
+			if strings.HasPrefix(name, prefix) {
+				continue tables
+			}
+		}
+		var n int64
+		if err := s.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, name)).Scan(&n); err != nil {
+			return nil, err
+		}
+		counts[name] = n
+	}
+	return counts, nil
+}
+
+// FileSize returns the size of the database file in bytes, not counting the
+// write-ahead log
+func (s *Store) FileSize() int64 {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// PoolStats returns the statistics of the read connection pool
+func (s *Store) PoolStats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// SheetCacheStats returns the hits and misses of the virtual sheet cache
+func (s *Store) SheetCacheStats() (hits, misses uint64) {
+	return s.sheets.hits.Load(), s.sheets.misses.Load()
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Ranks of suggestion keys; lower ranks are listed first
+const (
+	suggestRankName  = iota // A name starts with the query
+	suggestRankAlias        // An alias starts with the query
+	suggestRankWord         // A later word of a name or alias does
+)
+
+// suggestCache holds a prefix index of each game's catalog for typeahead.
+// An index is rebuilt on first use after this process changes the catalog,
+// or after sheetCacheTTL for changes made by other processes.
+type suggestCache struct {
+	mu    sync.Mutex
+	games map[string]*suggestIndex
+}
+
+type suggestIndex struct {
+	gen      uint64
+	expires  time.Time
+	items    []models.ItemSuggestion
+	spoilers []bool
+	keys     []suggestKey // Sorted by key
+}
+
+type suggestKey struct {
+	key  string // Lowercased name, alias or word
+	item int    // Index into items
+	rank int
+}
+
+// SuggestItems returns up to limit catalog items of a game whose names or
+// aliases start with q, or have a word that does. Matches on a name come
+// first, then on an alias, then on a later word, each by name.
+func (s *Store) SuggestItems(gameID, q string, limit int, spoilers bool) ([]models.ItemSuggestion, error) {
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	out := []models.ItemSuggestion{}
+	if q == "" || limit <= 0 {
+		return out, nil
+	}
+	idx, err := s.suggestIndex(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	best := make(map[int]int)
+	for i := sort.Search(len(idx.keys), func(i int) bool { return idx.keys[i].key >= q }); i < len(idx.keys); i++ {
+		k := idx.keys[i]
+		if !strings.HasPrefix(k.key, q) {
+			break
+		}
+		if idx.spoilers[k.item] && !spoilers {
+			continue
+		}
+		if rank, ok := best[k.item]; !ok || k.rank < rank {
+			best[k.item] = k.rank
+		}
+	}
+	matched := make([]int, 0, len(best))
+	for item := range best {
+		matched = append(matched, item)
+	}
+	// Items are indexed by name, so comparing indexes orders ties by name
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if best[a] != best[b] {
+			return best[a] < best[b]
+		}
+		return a < b
+	})
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	for _, item := range matched {
+		out = append(out, idx.items[item])
+	}
+	return out, nil
+}
+
+// suggestIndex returns the prefix index of a game's catalog, building it
+// when missing or stale
+func (s *Store) suggestIndex(gameID string) (*suggestIndex, error) {
+	gen := s.sheets.gen.Load()
+	s.suggest.mu.Lock()
+	idx := s.suggest.games[gameID]
+	s.suggest.mu.Unlock()
+	if idx != nil && idx.gen == gen && time.Now().Before(idx.expires) {
+		return idx, nil
+	}
+
+	items, err := s.QueryItems(ItemQuery{GameID: gameID})
+	if err != nil {
+		return nil, err
+	}
+	idx = &suggestIndex{
+		gen:      gen,
+		expires:  time.Now().Add(sheetCacheTTL),
+		items:    make([]models.ItemSuggestion, len(items)),
+		spoilers: make([]bool, len(items)),
+	}
+	for i, item := range items {
+		idx.items[i] = models.ItemSuggestion{ID: item.ID, SheetID: item.SheetID, Name: item.Name, NameRu: item.NameRu, Icon: item.Icon}
+		idx.spoilers[i] = item.Spoiler
+		idx.add(i, suggestRankName, item.Name, item.NameRu)
+		idx.add(i, suggestRankAlias, item.Aliases...)
+	}
+	sort.Slice(idx.keys, func(i, j int) bool { return idx.keys[i].key < idx.keys[j].key })
+
+	s.suggest.mu.Lock()
+	if s.suggest.games == nil {
+		s.suggest.games = make(map[string]*suggestIndex)
+	}
+	s.suggest.games[gameID] = idx
+	s.suggest.mu.Unlock()
+	return idx, nil
+}
+
+// add indexes names of an item under rank, and each of their later words
+// under suggestRankWord
+func (idx *suggestIndex) add(item, rank int, names ...string) {
+	for _, name := range names {
+		words := strings.Fields(strings.ToLower(name))
+		for i := range words {
+			r := rank
+			if i > 0 {
+				r = suggestRankWord
+			}
+			idx.keys = append(idx.keys, suggestKey{key: strings.Join(words[i:], " "), item: item, rank: r})
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// Items carry an updated_at stamp and deleted items leave a tombstone, so
+// clients can sync the changes since their last download. Both are kept by
+// triggers, which also cover INSERT OR REPLACE and snapshot restores. The
+// stamps use the text layout the driver writes UTC times in, so they compare
+// correctly against time.Time arguments.
+
+// ItemTombstoneRetention is how long deleted items are remembered. Clients
+// that last synced earlier must download the whole catalog again.
+const ItemTombstoneRetention = 90 * 24 * time.Hour
+
+// sqlNow is the current UTC time in the driver's layout
+const sqlNow = `strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')`
+
+var syncTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS items_sync_insert AFTER INSERT ON items BEGIN
+		UPDATE items SET updated_at = ` + sqlNow + ` WHERE rowid = new.rowid;
+		DELETE FROM item_tombstones WHERE item_id = new.id;
+	END`,
+	// Only writes that leave updated_at alone are stamped, which also stops
+	// the stamp itself from firing the trigger again
+	`CREATE TRIGGER IF NOT EXISTS items_sync_update AFTER UPDATE ON items
+	WHEN new.updated_at IS old.updated_at BEGIN
+		UPDATE items SET updated_at = ` + sqlNow + ` WHERE rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS items_sync_rename AFTER UPDATE OF id ON items
+	WHEN new.id != old.id BEGIN
+		INSERT OR REPLACE INTO item_tombstones (item_id, game_id, sheet_id, deleted_at)
+		VALUES (old.id, old.game_id, old.sheet_id, ` + sqlNow + `);
+		DELETE FROM item_tombstones WHERE item_id = new.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS items_sync_delete AFTER DELETE ON items BEGIN
+		INSERT OR REPLACE INTO item_tombstones (item_id, game_id, sheet_id, deleted_at)
+		VALUES (old.id, old.game_id, old.sheet_id, ` + sqlNow + `);
+	END`,
+	// Items already stamped this millisecond are skipped: restamping them
+	// with the same value would look to items_sync_update like a write that
+	// left updated_at alone and recurse. Earlier versions lacked the check,
+	// so the triggers are recreated.
+	`DROP TRIGGER IF EXISTS item_tags_sync_insert`,
+	`DROP TRIGGER IF EXISTS item_tags_sync_delete`,
+	`CREATE TRIGGER item_tags_sync_insert AFTER INSERT ON item_tags BEGIN
+		UPDATE items SET updated_at = ` + sqlNow + `
+		WHERE game_id = new.game_id AND id = new.item_id AND updated_at IS NOT ` + sqlNow + `;
+	END`,
+	`CREATE TRIGGER item_tags_sync_delete AFTER DELETE ON item_tags BEGIN
+		UPDATE items SET updated_at = ` + sqlNow + `
+		WHERE game_id = old.game_id AND id = old.item_id AND updated_at IS NOT ` + sqlNow + `;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS item_aliases_sync_insert AFTER INSERT ON item_aliases BEGIN
+		UPDATE items SET updated_at = ` + sqlNow + `
+		WHERE game_id = new.game_id AND id = new.item_id AND updated_at IS NOT ` + sqlNow + `;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS item_aliases_sync_delete AFTER DELETE ON item_aliases BEGIN
+		UPDATE items SET updated_at = ` + sqlNow + `
+		WHERE game_id = old.game_id AND id = old.item_id AND updated_at IS NOT ` + sqlNow + `;
+	END`,
+}
+
+// migrateSync creates the sync triggers and stamps items written before
+// updated_at existed
+func (s *Store) migrateSync() error {
+	for _, t := range syncTriggers {
+		if _, err := s.db.Exec(t); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.Exec(`UPDATE items SET updated_at = ` + sqlNow + ` WHERE updated_at IS NULL`)
+	return err
+}
+
+// GetItemTombstones returns the items of a game deleted after since,
+// optionally limited to one sheet
+func (s *Store) GetItemTombstones(gameID, sheetID string, since time.Time) ([]models.ItemTombstone, error) {
+	query := `SELECT item_id, sheet_id, deleted_at FROM item_tombstones WHERE game_id = ? AND deleted_at > ?`
+	args := []interface{}{gameID, since.UTC()}
+	if sheetID != "" {
+		query += ` AND sheet_id = ?`
+		args = append(args, sheetID)
+	}
+	query += ` ORDER BY deleted_at`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tombstones := make([]models.ItemTombstone, 0)
+	for rows.Next() {
+		var t models.ItemTombstone
+		if err := rows.Scan(&t.ID, &t.SheetID, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, t)
+	}
+	return tombstones, rows.Err()
+}
+
+// PruneItemTombstones deletes tombstones of items deleted before cutoff
+func (s *Store) PruneItemTombstones(cutoff time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM item_tombstones WHERE deleted_at < ?`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
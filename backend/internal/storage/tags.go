@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// setTierListTags replaces the tags of a tier list within tx
+func setTierListTags(tx txn, tierListID string, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM tierlist_tags WHERE tierlist_id = ?`, tierListID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO tierlist_tags (tierlist_id, tag) VALUES (?, ?)
+		`, tierListID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTierListTags returns the tags of the given tier lists keyed by tier list ID
+func (s *Store) loadTierListTags(ids []string) (map[string][]string, error) {
+	tags := make(map[string][]string, len(ids))
+	if len(ids) == 0 {
+		return tags, nil
+	}
+
+	placeholders, args := idPlaceholders(ids)
+
+	rows, err := s.db.Query(`
+		SELECT tierlist_id, tag FROM tierlist_tags
+		WHERE tierlist_id IN (`+placeholders+`)
+		ORDER BY tag
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], tag)
+	}
+	return tags, rows.Err()
+}
+
+// attachTags fills in the tags of a single tier list
+func (s *Store) attachTags(tl *models.TierList) error {
+	tags, err := s.loadTierListTags([]string{tl.ID})
+	if err != nil {
+		return err
+	}
+	tl.Tags = tags[tl.ID]
+	if tl.Tags == nil {
+		tl.Tags = []string{}
+	}
+	return nil
+}
+
+// GetTagCloud returns the most used tags on a game's public tier lists
+func (s *Store) GetTagCloud(gameID string, limit int) ([]models.TagCount, error) {
+	rows, err := s.db.Query(`
+		SELECT t.tag, COUNT(*) AS uses
+		FROM tierlist_tags t
+		JOIN tierlists tl ON tl.id = t.tierlist_id
+		WHERE tl.game_id = ? AND tl.is_public = 1 AND tl.is_hidden = 0 AND tl.status = 'published'
+		GROUP BY t.tag
+		ORDER BY uses DESC, t.tag
+		LIMIT ?
+	`, gameID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cloud := make([]models.TagCount, 0)
+	for rows.Next() {
+		var tc models.TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
+		}
+		cloud = append(cloud, tc)
+	}
+	return cloud, rows.Err()
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// setItemTags replaces the tags of a catalog item
+func setItemTags(db execer, gameID, itemID string, tags []string) error {
+	if _, err := db.Exec(`DELETE FROM item_tags WHERE game_id = ? AND item_id = ?`, gameID, itemID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := db.Exec(`
+			INSERT OR IGNORE INTO item_tags (game_id, item_id, tag) VALUES (?, ?, ?)
+		`, gameID, itemID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadItemTags returns the tags of a game's items keyed by item ID. An empty
+// itemID loads the whole game.
+func (s *Store) loadItemTags(gameID, itemID string) (map[string][]string, error) {
+	query := `SELECT item_id, tag FROM item_tags WHERE game_id = ?`
+	args := []interface{}{gameID}
+	if itemID != "" {
+		query += ` AND item_id = ?`
+		args = append(args, itemID)
+	}
+	query += ` ORDER BY tag`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetItemTags returns every tag used in a game's catalog with its item count
+func (s *Store) GetItemTags(gameID string) ([]models.TagCount, error) {
+	rows, err := s.db.Query(`
+		SELECT tag, COUNT(*) AS uses
+		FROM item_tags
+		WHERE game_id = ?
+		GROUP BY tag
+		ORDER BY tag
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagCount, 0)
+	for rows.Next() {
+		var tc models.TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// GetTakeout collects everything a user created or saved, for export.
+// Unsaved changes are as last flushed to the database.
+func (s *Store) GetTakeout(user *models.User) (*models.Takeout, error) {
+	t := &models.Takeout{
+		Format:        models.TakeoutFormat,
+		FormatVersion: models.TakeoutFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		User:          user,
+	}
+
+	lists, err := s.GetTierListsByAuthorFull(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.TierLists = make([]models.TakeoutTierList, 0, len(lists))
+	for _, tl := range lists {
+		autosave, err := s.GetAutosave(tl.ID)
+		if err != nil {
+			return nil, err
+		}
+		t.TierLists = append(t.TierLists, models.TakeoutTierList{TierList: tl, Autosave: autosave})
+	}
+
+	if t.Favorites.TierLists, err = s.GetFavoriteTierLists(user.ID); err != nil {
+		return nil, err
+	}
+	if t.Favorites.Items, err = s.GetFavoriteItems(user.ID); err != nil {
+		return nil, err
+	}
+	if t.Workspaces, err = s.GetUserWorkspaces(user.ID); err != nil {
+		return nil, err
+	}
+
+	sessionIDs, err := s.GetMatchupSessionIDsByAuthor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.Matchups = make([]models.TakeoutMatchup, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		session, err := s.GetMatchupSession(id)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			continue
+		}
+		results, err := s.GetMatchupResults(id)
+		if err != nil {
+			return nil, err
+		}
+		t.Matchups = append(t.Matchups, models.TakeoutMatchup{MatchupSession: *session, Results: results})
+	}
+
+	bracketIDs, err := s.GetBracketIDsByAuthor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.Brackets = make([]models.Bracket, 0, len(bracketIDs))
+	for _, id := range bracketIDs {
+		bracket, err := s.GetBracket(id)
+		if err != nil {
+			return nil, err
+		}
+		if bracket == nil {
+			continue
+		}
+		t.Brackets = append(t.Brackets, *bracket)
+	}
+
+	if t.PollVotes, err = s.GetPollVotesByVoter(models.UserVoter(user.ID)); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTierListsByAuthorFull returns every tier list owned by a user with its
+// tiers and tags, oldest first
+func (s *Store) GetTierListsByAuthorFull(authorID string) ([]models.TierList, error) {
+	rows, err := s.db.Query(`
+		SELECT `+tierListColumns+` FROM tierlists WHERE author_id = ? ORDER BY created_at
+	`, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]models.TierList, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		tl, err := scanTierList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, *tl)
+		ids = append(ids, tl.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tags, err := s.loadTierListTags(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lists {
+		lists[i].Tags = tags[lists[i].ID]
+		if lists[i].Tags == nil {
+			lists[i].Tags = []string{}
+		}
+	}
+	return lists, nil
+}
+
+// GetMatchupSessionIDsByAuthor returns the IDs of a user's matchup sessions
+func (s *Store) GetMatchupSessionIDsByAuthor(authorID string) ([]string, error) {
+	return s.queryIDs(`SELECT id FROM matchup_sessions WHERE author_id = ? ORDER BY created_at`, authorID)
+}
+
+// GetBracketIDsByAuthor returns the IDs of a user's brackets
+func (s *Store) GetBracketIDsByAuthor(authorID string) ([]string, error) {
+	return s.queryIDs(`SELECT id FROM brackets WHERE author_id = ? ORDER BY created_at`, authorID)
+}
+
+func (s *Store) queryIDs(query string, args ...interface{}) ([]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetPollVotesByVoter returns every poll vote cast under a voter key
+func (s *Store) GetPollVotesByVoter(voterHash string) ([]models.TakeoutPollVote, error) {
+	rows, err := s.db.Query(`
+		SELECT tierlist_id, item_id, tier_id, voted_at FROM poll_votes
+		WHERE voter_hash = ? ORDER BY voted_at
+	`, voterHash)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	votes := make([]models.TakeoutPollVote, 0)
+	for rows.Next() {
+		var v models.TakeoutPollVote
+		if err := rows.Scan(&v.TierListID, &v.ItemID, &v.TierID, &v.VotedAt); err != nil {
+			return nil, err
+		}
+		votes = append(votes, v)
+	}
+	return votes, rows.Err()
+}
@@ -0,0 +1,15 @@
+-- Items imported before item IDs were transliterated. The Latin ID of щит
+-- is already taken by a later import.
+PRAGMA user_version = 106;
+INSERT INTO games (id, name, sheets) VALUES ('ru', 'Russian Game', '[{"id":"main","name":"Main"}]');
+
+INSERT INTO items (id, game_id, sheet_id, name) VALUES
+	('огненный-шар', 'ru', 'main', 'Огненный шар'),
+	('щит', 'ru', 'main', 'Щит'),
+	('shhit', 'ru', 'main', 'Щит');
+
+INSERT INTO tierlists (id, game_id, sheet_id, name, tiers, share_code)
+VALUES ('list', 'ru', 'main', 'List', '[{"id":"s","name":"S","color":"#ff7f7f","items":["огненный-шар","щит"]}]', 'share');
+
+INSERT INTO tier_positions (tierlist_id, item_id, tier_id, position, modified_at)
+VALUES ('list', 'огненный-шар', 's', 'a', '2026-01-01 00:00:00');
@@ -0,0 +1,9 @@
+-- Lists ranked before item positions existed, one of them with tiers a
+-- crashed write left undecodable
+PRAGMA user_version = 97;
+
+INSERT INTO games (id, name, sheets) VALUES ('legacy', 'Legacy Game', '[{"id":"main","name":"Main"}]');
+
+INSERT INTO tierlists (id, game_id, sheet_id, name, tiers, share_code) VALUES
+	('ranked', 'legacy', 'main', 'Ranked', '[{"id":"s","name":"S","color":"#ff7f7f","items":["a","b"]},{"id":"a","name":"A","color":"#ffbf7f","items":["c"]}]', 'ranked'),
+	('corrupt', 'legacy', 'main', 'Corrupt', '[{"id":"s","items":', 'corrupt');
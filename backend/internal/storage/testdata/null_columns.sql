@@ -0,0 +1,9 @@
+-- Rows as a hand-edited database leaves them: every nullable column NULL
+INSERT INTO games (id, name, description, icon_url, item_schema, filters, default_tiers, sheets, created_at)
+VALUES ('bare', 'Bare Game', NULL, NULL, NULL, NULL, NULL, NULL, NULL);
+
+INSERT INTO items (id, game_id, sheet_id, name, name_ru, icon, category, data)
+VALUES ('plain', 'bare', 'main', 'Plain', NULL, NULL, NULL, NULL);
+
+INSERT INTO items (id, game_id, sheet_id, name, name_ru, icon, category, data)
+VALUES ('filled', 'bare', 'main', 'Filled', 'Заполненный', 'filled.png', 'weapon', '{"power": 3}');
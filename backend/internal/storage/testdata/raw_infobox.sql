@@ -0,0 +1,5 @@
+-- An item imported before infobox HTML was sanitized
+INSERT INTO games (id, name, sheets) VALUES ('wiki', 'Wiki Game', '[{"id":"main","name":"Main"}]');
+
+INSERT INTO items (id, game_id, sheet_id, name, data)
+VALUES ('raw', 'wiki', 'main', 'Raw', '{"infobox_html": "<b onclick=\"steal()\">Bold</b><script>alert(1)</script>", "power": 3}');
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// GetTierClocks returns the sync state of every tier of a list that has been
+// edited since syncing began, by tier ID
+func (s *Store) GetTierClocks(tierListID string) (map[string]models.TierClock, error) {
+	rows, err := s.db.Query(`
+		SELECT tier_id, vector, modified_at, replica, deleted FROM tier_clocks WHERE tierlist_id = ?
+	`, tierListID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clocks := make(map[string]models.TierClock)
+	for rows.Next() {
+		var tierID, vector string
+		var clock models.TierClock
+		if err := rows.Scan(&tierID, &vector, &clock.ModifiedAt, &clock.Replica, &clock.Deleted); err != nil {
+			return nil, err
+		}
+		if err := decodeColumn("tier_clocks", tierListID+"/"+tierID, "vector", vector, &clock.Vector); err != nil {
+			return nil, err
+		}
+		clocks[tierID] = clock
+	}
+	return clocks, rows.Err()
+}
+
+// SaveTierClocks stores the sync state of the given tiers of a list
+func (s *Store) SaveTierClocks(tierListID string, clocks map[string]models.TierClock) error {
+	for tierID, clock := range clocks {
+		vector, _ := json.Marshal(clock.Vector)
+		if _, err := s.db.Exec(`
+			INSERT INTO tier_clocks (tierlist_id, tier_id, vector, modified_at, replica, deleted)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (tierlist_id, tier_id) DO UPDATE SET
+				vector = excluded.vector, modified_at = excluded.modified_at,
+				replica = excluded.replica, deleted = excluded.deleted
+		`, tierListID, tierID, string(vector), clock.ModifiedAt.UTC(), clock.Replica, clock.Deleted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdvanceTierClocks counts an edit by replica to each of the given tiers, so
+// offline changes made without seeing it are treated as concurrent
+func (s *Store) AdvanceTierClocks(tierListID string, tierIDs []string, replica string, deleted map[string]bool) error {
+	if len(tierIDs) == 0 {
+		return nil
+	}
+	clocks, err := s.GetTierClocks(tierListID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	changed := make(map[string]models.TierClock, len(tierIDs))
+	for _, id := range tierIDs {
+		clock := clocks[id]
+		clock.Vector = clock.Vector.Merge(models.VectorClock{replica: clock.Vector[replica] + 1})
+		clock.ModifiedAt, clock.Replica, clock.Deleted = now, replica, deleted[id]
+		changed[id] = clock
+	}
+	return s.SaveTierClocks(tierListID, changed)
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// The tier list search index is an FTS5 table like the item index, with
+// rowids mirroring tierlists.rowid. It holds each list's name, tags and
+// author name; triggers on tierlists, tierlist_tags and users keep it
+// current. View counts and other frequent updates do not touch it.
+
+// Tier list search orders
+const (
+	SearchSortRelevance = "relevance"
+	SearchSortVotes     = "votes" // Most favorited first
+)
+
+// maxSearchTerms caps the words of a search query that are matched
+const maxSearchTerms = 8
+
+// tierListSearchFields selects the indexed text of the tier list aliased t
+const tierListSearchFields = `t.name,
+	COALESCE((SELECT group_concat(tag, ' ') FROM tierlist_tags g WHERE g.tierlist_id = t.id), ''),
+	COALESCE((SELECT display_name FROM users u WHERE u.id = t.author_id), '')`
+
+var tierListSearchTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS tierlists_fts_insert AFTER INSERT ON tierlists BEGIN
+		INSERT INTO tierlists_fts (rowid, name, tags, author)
+		SELECT t.rowid, ` + tierListSearchFields + ` FROM tierlists t WHERE t.rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tierlists_fts_delete AFTER DELETE ON tierlists BEGIN
+		DELETE FROM tierlists_fts WHERE rowid = old.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tierlists_fts_update AFTER UPDATE OF name, author_id ON tierlists BEGIN
+		DELETE FROM tierlists_fts WHERE rowid = old.rowid;
+		INSERT INTO tierlists_fts (rowid, name, tags, author)
+		SELECT t.rowid, ` + tierListSearchFields + ` FROM tierlists t WHERE t.rowid = new.rowid;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tierlist_tags_fts_insert AFTER INSERT ON tierlist_tags BEGIN
+		UPDATE tierlists_fts SET tags = (
+			SELECT group_concat(tag, ' ') FROM tierlist_tags WHERE tierlist_id = new.tierlist_id
+		) WHERE rowid = (SELECT rowid FROM tierlists WHERE id = new.tierlist_id);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS tierlist_tags_fts_delete AFTER DELETE ON tierlist_tags BEGIN
+		UPDATE tierlists_fts SET tags = COALESCE((
+			SELECT group_concat(tag, ' ') FROM tierlist_tags WHERE tierlist_id = old.tierlist_id
+		), '') WHERE rowid = (SELECT rowid FROM tierlists WHERE id = old.tierlist_id);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS users_fts_update AFTER UPDATE OF display_name ON users BEGIN
+		UPDATE tierlists_fts SET author = new.display_name
+		WHERE rowid IN (SELECT rowid FROM tierlists WHERE author_id = new.id);
+	END`,
+}
+
+var tierListSearchTriggerNames = []string{
+	"tierlists_fts_insert", "tierlists_fts_delete", "tierlists_fts_update",
+	"tierlist_tags_fts_insert", "tierlist_tags_fts_delete", "users_fts_update",
+}
+
+// migrateTierListSearch creates the tier list search index and its triggers,
+// rebuilding the index when triggers were missing. It runs only on builds
+// with FTS5.
+func (s *Store) migrateTierListSearch() error {
+	var triggers int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN ('` + strings.Join(tierListSearchTriggerNames, "', '") + `')
+	`).Scan(&triggers)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`
+		CREATE VIRTUAL TABLE IF NOT EXISTS tierlists_fts USING fts5(
+			name, tags, author,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)
+	`); err != nil {
+		return err
+	}
+	for _, stmt := range tierListSearchTriggers {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	stale := triggers < len(tierListSearchTriggers)
+	if !stale {
+		var lists, indexed int
+		err := s.db.QueryRow(`SELECT (SELECT COUNT(*) FROM tierlists), (SELECT COUNT(*) FROM tierlists_fts)`).Scan(&lists, &indexed)
+		if err != nil {
+			return err
+		}
+		stale = lists != indexed
+	}
+	if stale {
+		if _, err := s.RebuildTierListSearchIndex(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildTierListSearchIndex repopulates the tier list search index and
+// returns the number of indexed tier lists
+func (s *Store) RebuildTierListSearchIndex() (int64, error) {
+	if !s.search {
+		return 0, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM tierlists_fts`); err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(`
+		INSERT INTO tierlists_fts (rowid, name, tags, author)
+		SELECT t.rowid, ` + tierListSearchFields + ` FROM tierlists t
+	`)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, tx.Commit()
+}
+
+// TierListSearch is a search of public tier lists; empty filters are not
+// applied
+type TierListSearch struct {
+	Query   string
+	GameID  string
+	SheetID string
+	Sort    string // SearchSortRelevance or SearchSortVotes
+	Limit   int
+}
+
+// SearchTerms splits a search query into the words that are matched
+func SearchTerms(query string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// SearchTierLists returns summaries of public tier lists whose name, tags or
+// author name contain every word of the query, each word matching as a
+// prefix. Without FTS5 the words are matched as substrings and relevance
+// falls back to the most recently updated first.
+func (s *Store) SearchTierLists(q TierListSearch) ([]models.TierListSummary, error) {
+	terms := SearchTerms(q.Query)
+	if len(terms) == 0 {
+		return []models.TierListSummary{}, nil
+	}
+
+	var query, relevance string
+	var args []interface{}
+	if s.search {
+		match := make([]string, len(terms))
+		for i, term := range terms {
+			match[i] = `"` + term + `"*`
+		}
+		// Name matches outweigh tag matches, which outweigh author matches
+		query = `SELECT ` + tierListColumns + ` FROM tierlists JOIN (
+			SELECT rowid AS match_rowid, bm25(tierlists_fts, 10.0, 4.0, 2.0) AS match_rank
+			FROM tierlists_fts WHERE tierlists_fts MATCH ?
+		) ON match_rowid = tierlists.rowid WHERE 1 = 1`
+		args = append(args, strings.Join(match, " "))
+		relevance = `match_rank, updated_at DESC`
+	} else {
+		query = `SELECT ` + tierListColumns + ` FROM tierlists WHERE 1 = 1`
+		// Terms are letters and digits only, so need no escaping
+		for _, term := range terms {
+			like := "%" + term + "%"
+			query += ` AND (name LIKE ?
+				OR id IN (SELECT tierlist_id FROM tierlist_tags WHERE tag LIKE ?)
+				OR author_id IN (SELECT id FROM users WHERE display_name LIKE ?))`
+			args = append(args, like, like, like)
+		}
+		relevance = `updated_at DESC`
+	}
+
+	query += ` AND is_public = 1 AND is_hidden = 0 AND status = 'published'`
+	if q.GameID != "" {
+		query += ` AND game_id = ?`
+		args = append(args, q.GameID)
+	}
+	if q.SheetID != "" {
+		query += ` AND sheet_id = ?`
+		args = append(args, q.SheetID)
+	}
+	if q.Sort == SearchSortVotes {
+		query += ` ORDER BY (SELECT COUNT(*) FROM favorites f WHERE f.target_type = ? AND f.target_id = tierlists.id) DESC, ` + relevance
+		args = append(args, models.FavoriteTierList)
+	} else {
+		query += ` ORDER BY ` + relevance
+	}
+	query += ` LIMIT ?`
+	args = append(args, q.Limit)
+
+	return s.querySummaries(query, args...)
+}
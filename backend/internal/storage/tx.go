@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/meur/tierforge/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTx runs fn with a Store whose reads and writes all go through one
+// transaction, committing when fn returns nil and rolling back otherwise.
+// Store methods that open their own transaction run as savepoints inside it.
+// Nested calls share the outer transaction.
+//
+// Statements inside a transaction are not retried individually, so when fn
+// fails because the database was busy the whole transaction is retried; fn
+// may therefore run more than once and should only touch the database. fn
+// must write through tx only: writes through s wait for the transaction.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.db.tx != nil {
+		return s.withSavepoint(fn)
+	}
+	ctx, span := tracing.Start(ctx, "transaction", trace.SpanKindInternal)
+	defer span.End()
+	// Statements are traced only inside a recording span
+	var traced context.Context
+	if span.IsRecording() {
+		traced = ctx
+	}
+	err := retryBusy(func() error {
+		sqlTx, err := s.db.beginTx(ctx)
+		if err != nil {
+			return err
+		}
+		defer sqlTx.Rollback()
+
+		tx := &Store{
+			db:            &database{DB: s.db.DB, tx: sqlTx, ctx: traced},
+			path:          s.path,
+			sheets:        s.sheets,
+			suggest:       s.suggest,
+			search:        s.search,
+			schemaVersion: s.schemaVersion,
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if err := sqlTx.Commit(); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+		if tx.catalogDirty {
+			s.catalogChanged()
+		}
+		return nil
+	})
+	tracing.RecordError(span, err)
+	return err
+}
+
+// WithContext returns a Store sharing s's connections whose statements are
+// traced as children of the span in ctx. Without a recording span, and for stores
+// already bound to a transaction, it returns s.
+func (s *Store) WithContext(ctx context.Context) *Store {
+	if s.db.tx != nil || !trace.SpanFromContext(ctx).IsRecording() {
+		return s
+	}
+	bound := *s
+	bound.db = s.db.withContext(ctx)
+	return &bound
+}
+
+// withSavepoint runs fn inside a savepoint of the transaction s is bound to
+func (s *Store) withSavepoint(fn func(tx *Store) error) error {
+	sp, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(s); err != nil {
+		if rbErr := sp.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+	return sp.Commit()
+}
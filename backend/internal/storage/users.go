@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/meur/tierforge/internal/models"
+)
+
+const userColumns = `id, email, display_name, role, email_verified_at, weekly_digest, analytics_opt_out, created_at`
+
+func scanUser(row rowScanner, extra ...interface{}) (*models.User, error) {
+	var u models.User
+	var verifiedAt sql.NullTime
+	dest := append([]interface{}{&u.ID, &u.Email, &u.DisplayName, &u.Role, &verifiedAt, &u.WeeklyDigest, &u.AnalyticsOptOut, &u.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	u.EmailVerified = verifiedAt.Valid
+	return &u, nil
+}
+
+// CreateUser inserts a new account. Returns ErrDuplicate if the email is taken.
+func (s *Store) CreateUser(u *models.User, passwordHash string) error {
+	u.ID = uuid.New().String()
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	u.CreatedAt = time.Now()
+	if u.Role == "" {
+		u.Role = models.RoleUser
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO users (id, email, display_name, password_hash, role, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, u.ID, u.Email, u.DisplayName, passwordHash, u.Role, u.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// GetUser returns a user by ID, or nil if not found
+func (s *Store) GetUser(id string) (*models.User, error) {
+	u, err := scanUser(s.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u, s.loadMemberships(u)
+}
+
+// GetUserByEmail returns a user and their password hash, or nil if not found
+func (s *Store) GetUserByEmail(email string) (*models.User, string, error) {
+	var hash string
+	u, err := scanUser(s.db.QueryRow(`
+		SELECT `+userColumns+`, password_hash FROM users WHERE email = ?
+	`, strings.ToLower(strings.TrimSpace(email))), &hash)
+	if err == sql.ErrNoRows {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return u, hash, s.loadMemberships(u)
+}
+
+// GetUsers returns all accounts ordered by creation time
+func (s *Store) GetUsers() ([]models.User, error) {
+	rows, err := s.db.Query(`SELECT ` + userColumns + ` FROM users ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range users {
+		if err := s.loadMemberships(&users[i]); err != nil {
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+// SetUserRole changes a user's role and, for curators, the games they manage
+func (s *Store) SetUserRole(userID, role string, gameIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM curator_games WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if role == models.RoleCurator {
+		for _, gameID := range gameIDs {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO curator_games (user_id, game_id) VALUES (?, ?)`, userID, gameID); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// loadMemberships loads the games a curator manages and the user's workspaces
+func (s *Store) loadMemberships(u *models.User) error {
+	if err := s.loadCuratorGames(u); err != nil {
+		return err
+	}
+	return s.loadWorkspaces(u)
+}
+
+func (s *Store) loadCuratorGames(u *models.User) error {
+	rows, err := s.db.Query(`SELECT game_id FROM curator_games WHERE user_id = ? ORDER BY game_id`, u.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var gameID string
+		if err := rows.Scan(&gameID); err != nil {
+			return err
+		}
+		u.GameIDs = append(u.GameIDs, gameID)
+	}
+	return rows.Err()
+}
+
+// --- Sessions ---
+
+// CreateSession stores a new session for a user under the hashed token
+func (s *Store) CreateSession(tokenHash, userID, userAgent string, expiresAt time.Time) error {
+	now := time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO sessions (token_hash, user_id, user_agent, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, tokenHash, userID, userAgent, now, now, expiresAt)
+	return err
+}
+
+// sessionSeenInterval is how stale a session's last_seen_at may get before a
+// request refreshes it, so signed-in traffic doesn't write on every request
+const sessionSeenInterval = 5 * time.Minute
+
+// GetSessionUser returns the user owning an unexpired session, or nil
+func (s *Store) GetSessionUser(tokenHash string) (*models.User, error) {
+	var userID string
+	var lastSeenAt, expiresAt time.Time
+	err := s.db.QueryRow(`
+		SELECT user_id, last_seen_at, expires_at FROM sessions WHERE token_hash = ?
+	`, tokenHash).Scan(&userID, &lastSeenAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(expiresAt) {
+		return nil, s.DeleteSession(tokenHash)
+	}
+
+	if time.Since(lastSeenAt) >= sessionSeenInterval {
+		if _, err := s.db.Exec(`UPDATE sessions SET last_seen_at = ? WHERE token_hash = ?`, time.Now(), tokenHash); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetUser(userID)
+}
+
+// DeleteSession removes a session, signing it out
+func (s *Store) DeleteSession(tokenHash string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE token_hash = ?`, tokenHash)
+	return err
+}
+
+// GetSessions returns a user's unexpired sessions, most recently used first
+func (s *Store) GetSessions(userID string) ([]models.Session, error) {
+	rows, err := s.db.Query(`
+		SELECT token_hash, COALESCE(user_agent, ''), created_at, last_seen_at, expires_at
+		FROM sessions WHERE user_id = ? AND expires_at > ?
+		ORDER BY last_seen_at DESC
+	`, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]models.Session, 0)
+	for rows.Next() {
+		var sess models.Session
+		if err := rows.Scan(&sess.ID, &sess.UserAgent, &sess.CreatedAt, &sess.LastSeenAt, &sess.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteUserSession revokes one of a user's sessions. It reports whether the
+// session existed.
+func (s *Store) DeleteUserSession(userID, tokenHash string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = ? AND token_hash = ?`, userID, tokenHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteOtherSessions revokes all of a user's sessions except keepHash and
+// returns how many were removed. An empty keepHash revokes every session.
+func (s *Store) DeleteOtherSessions(userID, keepHash string) (int, error) {
+	res, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = ? AND token_hash != ?`, userID, keepHash)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// --- Account management ---
+
+// SetPassword replaces a user's password hash and revokes all their sessions
+func (s *Store) SetPassword(userID, passwordHash string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET password_hash = ? WHERE id = ?`, passwordHash, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM email_tokens WHERE user_id = ? AND purpose = ?`, userID, EmailTokenReset); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ChangeEmail sets a confirmed new email address on a user. Returns
+// ErrDuplicate if another account already uses it.
+func (s *Store) ChangeEmail(userID, email string) error {
+	_, err := s.db.Exec(`
+		UPDATE users SET email = ?, email_verified_at = ? WHERE id = ?
+	`, strings.ToLower(strings.TrimSpace(email)), time.Now(), userID)
+	if isUniqueViolation(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// DeleteUser removes an account and its personal data. Tier lists and
+// matchup sessions the user authored stay published but are reassigned to
+// models.DeletedUserID; sessions, tokens, favorites and curator grants are
+// removed with the user row.
+func (s *Store) DeleteUser(userID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE tierlists SET author_id = ?, creator_ip = NULL, creator_device = NULL WHERE author_id = ?
+	`, models.DeletedUserID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE matchup_sessions SET author_id = ? WHERE author_id = ?
+	`, models.DeletedUserID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE brackets SET author_id = ? WHERE author_id = ?
+	`, models.DeletedUserID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = ?`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+func TestGetSessionUserThrottlesLastSeen(t *testing.T) {
+	s := newTestStore(t)
+	u := &models.User{Email: "seen@example.com", DisplayName: "Seen"}
+	if err := s.CreateUser(u, "hash"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := s.CreateSession("token", u.ID, "test", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	lastSeen := func() time.Time {
+		t.Helper()
+		var at time.Time
+		if err := s.db.QueryRow(`SELECT last_seen_at FROM sessions WHERE token_hash = 'token'`).Scan(&at); err != nil {
+			t.Fatal(err)
+		}
+		return at
+	}
+	tests := []struct {
+		name    string
+		age     time.Duration
+		refresh bool
+	}{
+		{"just seen", time.Second, false},
+		{"inside the interval", sessionSeenInterval - time.Minute, false},
+		{"stale", sessionSeenInterval + time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now().Add(-tt.age)
+			if _, err := s.db.Exec(`UPDATE sessions SET last_seen_at = ? WHERE token_hash = 'token'`, before); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.GetSessionUser("token")
+			if err != nil || got == nil || got.ID != u.ID {
+				t.Fatalf("GetSessionUser = %+v, %v; want user %s", got, err, u.ID)
+			}
+			if refreshed := lastSeen().After(before); refreshed != tt.refresh {
+				t.Errorf("last_seen_at refreshed = %v, want %v", refreshed, tt.refresh)
+			}
+		})
+	}
+}
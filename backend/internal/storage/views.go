@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/meur/tierforge/internal/models"
+)
+
+// RecordView counts a view of a tier list unless the same visitor already
+// viewed it within the debounce window. It reports whether the view counted.
+func (s *Store) RecordView(tierListID, visitorHash string, window time.Duration) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var lastViewed time.Time
+	err = tx.QueryRow(`
+		SELECT viewed_at FROM tierlist_views WHERE tierlist_id = ? AND visitor_hash = ?
+	`, tierListID, visitorHash).Scan(&lastViewed)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	if err == nil && now.Sub(lastViewed) < window {
+		return false, nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO tierlist_views (tierlist_id, visitor_hash, viewed_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(tierlist_id, visitor_hash) DO UPDATE SET viewed_at = excluded.viewed_at
+	`, tierListID, visitorHash, now)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(`UPDATE tierlists SET view_count = view_count + 1 WHERE id = ?`, tierListID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// TierListQuery selects public tier lists; empty fields are not filtered on
+type TierListQuery struct {
+	GameID  string
+	SheetID string
+	Version string
+	Tag     string
+	Limit   int
+	After   *Cursor // Continues from the end of a previous page
+}
+
+// GetPublicTierLists returns a page of summaries of public tier lists
+// matching q, most recently updated first, and the cursor of the next page,
+// which is nil on the last one
+func (s *Store) GetPublicTierLists(q TierListQuery) ([]models.TierListSummary, *Cursor, error) {
+	query := `SELECT ` + tierListColumns + ` FROM tierlists WHERE game_id = ? AND is_public = 1 AND is_hidden = 0 AND status = 'published'`
+	args := []interface{}{q.GameID}
+	if q.SheetID != "" {
+		query += ` AND sheet_id = ?`
+		args = append(args, q.SheetID)
+	}
+	if q.Version != "" {
+		query += ` AND game_version = ?`
+		args = append(args, q.Version)
+	}
+	if q.Tag != "" {
+		query += ` AND id IN (SELECT tierlist_id FROM tierlist_tags WHERE tag = ?)`
+		args = append(args, q.Tag)
+	}
+	if q.After != nil {
+		cond, condArgs := q.After.after()
+		query += ` AND ` + cond
+		args = append(args, condArgs...)
+	}
+	// One row past the page tells whether another page follows
+	query += ` ORDER BY updated_at DESC, id DESC LIMIT ?`
+	args = append(args, q.Limit+1)
+
+	summaries, err := s.querySummaries(query, args...)
+	if err != nil || len(summaries) <= q.Limit {
+		return summaries, nil, err
+	}
+	summaries = summaries[:q.Limit]
+	last := summaries[len(summaries)-1]
+	return summaries, &Cursor{UpdatedAt: last.UpdatedAt, ID: last.ID}, nil
+}
+
+// querySummaries runs a tier list query selecting tierListColumns and returns
+// the rows as summaries with their tags and favorite counts
+func (s *Store) querySummaries(query string, args ...interface{}) ([]models.TierListSummary, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]models.TierListSummary, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		tl, err := scanTierList(rows)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, tl.Summary())
+		ids = append(ids, tl.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tags, err := s.loadTierListTags(ids)
+	if err != nil {
+		return nil, err
+	}
+	favorites, err := s.loadFavoriteCounts(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		if t, ok := tags[summaries[i].ID]; ok {
+			summaries[i].Tags = t
+		}
+		summaries[i].FavoriteCount = favorites[summaries[i].ID]
+	}
+	return summaries, nil
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/meur/tierforge/internal/models"
+)
+
+const workspaceColumns = `w.id, w.slug, w.name, w.description, w.created_at`
+
+func scanWorkspace(row rowScanner, extra ...interface{}) (*models.Workspace, error) {
+	var ws models.Workspace
+	dest := append([]interface{}{&ws.ID, &ws.Slug, &ws.Name, &ws.Description, &ws.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// CreateWorkspace creates a workspace owned by ownerID. Returns ErrDuplicate
+// if the slug is taken.
+func (s *Store) CreateWorkspace(req *models.WorkspaceCreate, ownerID string) (*models.Workspace, error) {
+	ws := &models.Workspace{
+		ID:          uuid.New().String(),
+		Slug:        req.Slug,
+		Name:        req.Name,
+		Description: req.Description,
+		Role:        models.WorkspaceOwner,
+		CreatedAt:   time.Now(),
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO workspaces (id, slug, name, description, created_at) VALUES (?, ?, ?, ?, ?)
+	`, ws.ID, ws.Slug, ws.Name, ws.Description, ws.CreatedAt)
+	if isUniqueViolation(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO workspace_members (workspace_id, user_id, role, joined_at) VALUES (?, ?, ?, ?)
+	`, ws.ID, ownerID, models.WorkspaceOwner, ws.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return ws, tx.Commit()
+}
+
+// GetWorkspaceBySlug returns a workspace, or nil if not found
+func (s *Store) GetWorkspaceBySlug(slug string) (*models.Workspace, error) {
+	ws, err := scanWorkspace(s.db.QueryRow(`SELECT `+workspaceColumns+` FROM workspaces w WHERE w.slug = ?`, slug))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return ws, err
+}
+
+// GetUserWorkspaces returns the workspaces a user belongs to, with their role
+func (s *Store) GetUserWorkspaces(userID string) ([]models.Workspace, error) {
+	rows, err := s.db.Query(`
+		SELECT `+workspaceColumns+`, m.role FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.user_id = ?
+		ORDER BY w.name
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := make([]models.Workspace, 0)
+	for rows.Next() {
+		var role string
+		ws, err := scanWorkspace(rows, &role)
+		if err != nil {
+			return nil, err
+		}
+		ws.Role = role
+		workspaces = append(workspaces, *ws)
+	}
+	return workspaces, rows.Err()
+}
+
+func (s *Store) loadWorkspaces(u *models.User) error {
+	rows, err := s.db.Query(`SELECT workspace_id, role FROM workspace_members WHERE user_id = ?`, u.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.WorkspaceMembership
+		if err := rows.Scan(&m.WorkspaceID, &m.Role); err != nil {
+			return err
+		}
+		u.Workspaces = append(u.Workspaces, m)
+	}
+	return rows.Err()
+}
+
+// GetWorkspaceMembers lists a workspace's members, owners first
+func (s *Store) GetWorkspaceMembers(workspaceID string) ([]models.WorkspaceMemberInfo, error) {
+	rows, err := s.db.Query(`
+		SELECT m.user_id, u.display_name, m.role, m.joined_at
+		FROM workspace_members m JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = ?
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, u.display_name
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.WorkspaceMemberInfo, 0)
+	for rows.Next() {
+		var m models.WorkspaceMemberInfo
+		if err := rows.Scan(&m.UserID, &m.DisplayName, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SetWorkspaceMember adds a user to a workspace or changes their role
+func (s *Store) SetWorkspaceMember(workspaceID, userID, role string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO workspace_members (workspace_id, user_id, role, joined_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(workspace_id, user_id) DO UPDATE SET role = excluded.role
+	`, workspaceID, userID, role, time.Now())
+	return err
+}
+
+// RemoveWorkspaceMember removes a user from a workspace. It reports whether
+// they were a member.
+func (s *Store) RemoveWorkspaceMember(workspaceID, userID string) (bool, error) {
+	res, err := s.db.Exec(`DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?`, workspaceID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CountWorkspaceOwners returns how many owners a workspace has
+func (s *Store) CountWorkspaceOwners(workspaceID string) (int, error) {
+	var n int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM workspace_members WHERE workspace_id = ? AND role = ?
+	`, workspaceID, models.WorkspaceOwner).Scan(&n)
+	return n, err
+}
+
+// GetGamesInWorkspace returns the games owned by a workspace, or the
+// site-wide games when workspaceID is empty
+func (s *Store) GetGamesInWorkspace(workspaceID string) ([]models.Game, error) {
+	rows, err := s.db.Query(`SELECT `+gameColumns+` FROM games WHERE workspace_id = ? ORDER BY name`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	games := make([]models.Game, 0)
+	for rows.Next() {
+		g, err := scanGame(rows)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, *g)
+	}
+	return games, rows.Err()
+}
+
+// GetWorkspaceTierLists returns the workspace's published tier lists, most
+// recently updated first. Non-public lists are included for members.
+func (s *Store) GetWorkspaceTierLists(workspaceID string, member bool, limit int) ([]models.TierListSummary, error) {
+	query := `SELECT ` + tierListColumns + ` FROM tierlists
+		WHERE workspace_id = ? AND is_private = 0 AND is_hidden = 0 AND status = 'published'`
+	if !member {
+		query += ` AND is_public = 1`
+	}
+	query += ` ORDER BY updated_at DESC LIMIT ?`
+	return s.querySummaries(query, workspaceID, limit)
+}
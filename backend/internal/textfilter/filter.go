@@ -0,0 +1,138 @@
+// Package textfilter cleans user-provided text before it is stored and
+// rendered on public pages.
+package textfilter
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mode controls what happens when disallowed words are found
+type Mode string
+
+const (
+	// ModeReject refuses text containing disallowed words
+	ModeReject Mode = "reject"
+	// ModeMask replaces disallowed words with asterisks
+	ModeMask Mode = "mask"
+)
+
+var (
+	// ErrEmpty is returned when nothing is left after cleaning
+	ErrEmpty = errors.New("text is empty")
+	// ErrTooLong is returned when text exceeds the length limit
+	ErrTooLong = errors.New("text is too long")
+	// ErrDisallowed is returned in reject mode when a disallowed word is found
+	ErrDisallowed = errors.New("text contains disallowed language")
+)
+
+// DefaultWords is used when no word list is configured. An entry ending in
+// "*" matches any word starting with it; other entries match whole words.
+var DefaultWords = []string{
+	"fuck*", "shit*", "cunt*", "bitch*", "asshole*", "motherfuck*",
+	"хуй*", "хуе*", "хуё*", "пизд*", "бляд*", "ебан*", "ебат*", "уеб*", "сука", "суки",
+}
+
+var (
+	tagPattern   = regexp.MustCompile(`<[^>]*>`)
+	spacePattern = regexp.MustCompile(`\s+`)
+)
+
+// Filter validates and cleans short user-provided strings
+type Filter struct {
+	mode     Mode
+	exact    map[string]bool
+	prefixes []string
+}
+
+// New creates a filter for the given word list
+func New(words []string, mode Mode) *Filter {
+	f := &Filter{mode: mode, exact: make(map[string]bool)}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || strings.HasPrefix(w, "#") {
+			continue
+		}
+		if strings.HasSuffix(w, "*") {
+			f.prefixes = append(f.prefixes, strings.TrimSuffix(w, "*"))
+		} else {
+			f.exact[w] = true
+		}
+	}
+	return f
+}
+
+// Clean strips markup and control characters, collapses whitespace, enforces
+// maxLen (in characters) and applies the word filter
+func (f *Filter) Clean(s string, maxLen int) (string, error) {
+	s = tagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimSpace(spacePattern.ReplaceAllString(s, " "))
+	s = strings.NewReplacer("<", "", ">", "").Replace(s)
+
+	if s == "" {
+		return "", ErrEmpty
+	}
+	if maxLen > 0 && utf8.RuneCountInString(s) > maxLen {
+		return "", ErrTooLong
+	}
+
+	return f.filterWords(s)
+}
+
+// filterWords checks each word against the list, masking or rejecting matches
+func (f *Filter) filterWords(s string) (string, error) {
+	runes := []rune(s)
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			out = append(out, runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && isWordRune(runes[j]) {
+			j++
+		}
+		word := runes[i:j]
+		if f.matches(strings.ToLower(string(word))) {
+			if f.mode == ModeReject {
+				return "", ErrDisallowed
+			}
+			out = append(out, word[0])
+			for range word[1:] {
+				out = append(out, '*')
+			}
+		} else {
+			out = append(out, word...)
+		}
+		i = j
+	}
+	return string(out), nil
+}
+
+func (f *Filter) matches(word string) bool {
+	if f.exact[word] {
+		return true
+	}
+	for _, p := range f.prefixes {
+		if strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,82 @@
+package textfilter
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	f := New([]string{" Darn ", "heck*", "", "# comment", "#gosh*"}, ModeMask)
+	if len(f.exact) != 1 || !f.exact["darn"] {
+		t.Errorf("exact words = %v, want [darn]", f.exact)
+	}
+	if len(f.prefixes) != 1 || f.prefixes[0] != "heck" {
+		t.Errorf("prefixes = %v, want [heck]", f.prefixes)
+	}
+}
+
+func TestClean(t *testing.T) {
+	words := []string{"darn", "heck*", "сука"}
+	tests := []struct {
+		in     string
+		maxLen int
+		mask   string
+		// reject is the reject mode result; ErrDisallowed when empty
+		reject string
+	}{
+		{"Best builds", 0, "Best builds", "Best builds"},
+		{"  spaced \t\n  out  ", 0, "spaced out", "spaced out"},
+		{"<b>bold</b> <script>x</script>", 0, "bold x", "bold x"},
+		{"fish &amp; chips", 0, "fish & chips", "fish & chips"},
+		{"a &lt;b&gt; c", 0, "a b c", "a b c"},
+		{"ctrl\x00\x07chars", 0, "ctrlchars", "ctrlchars"},
+		// Exact words match whole words only, in any case
+		{"Darn it", 0, "D*** it", ""},
+		{"darned", 0, "darned", "darned"},
+		{"darn-darn", 0, "d***-d***", ""},
+		// Prefixes match the start of words
+		{"HECKIN good", 0, "H***** good", ""},
+		{"oheck", 0, "oheck", "oheck"},
+		{"ну сука.", 0, "ну с***.", ""},
+		{"Сукам", 0, "Сукам", "Сукам"},
+		// The length limit counts characters after cleaning
+		{"<i>абвгд</i>", 5, "абвгд", "абвгд"},
+		{"heck2", 5, "h****", ""},
+	}
+	mask, reject := New(words, ModeMask), New(words, ModeReject)
+	for _, tt := range tests {
+		if got, err := mask.Clean(tt.in, tt.maxLen); err != nil || got != tt.mask {
+			t.Errorf("mask Clean(%q) = %q, %v; want %q", tt.in, got, err, tt.mask)
+		}
+		got, err := reject.Clean(tt.in, tt.maxLen)
+		if tt.reject == "" {
+			if !errors.Is(err, ErrDisallowed) {
+				t.Errorf("reject Clean(%q) = %q, %v; want ErrDisallowed", tt.in, got, err)
+			}
+		} else if err != nil || got != tt.reject {
+			t.Errorf("reject Clean(%q) = %q, %v; want %q", tt.in, got, err, tt.reject)
+		}
+	}
+}
+
+func TestCleanErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		maxLen int
+		err    error
+	}{
+		{"", 0, ErrEmpty},
+		{" \t\n ", 0, ErrEmpty},
+		{"<p></p>", 0, ErrEmpty},
+		{"&lt;&gt;", 0, ErrEmpty},
+		{"\x01\x02", 0, ErrEmpty},
+		{"abcdef", 5, ErrTooLong},
+		{"абвгде", 5, ErrTooLong},
+	}
+	f := New(DefaultWords, ModeMask)
+	for _, tt := range tests {
+		if _, err := f.Clean(tt.in, tt.maxLen); !errors.Is(err, tt.err) {
+			t.Errorf("Clean(%q, %d) = %v, want %v", tt.in, tt.maxLen, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// exportQueue bounds finished spans waiting for export; spans beyond it
+	// are dropped rather than slowing requests down
+	exportQueue = 4096
+	// exportBatch is the most spans sent in one request
+	exportBatch = 512
+	// exportInterval is how long finished spans wait for a batch to fill
+	exportInterval = 5 * time.Second
+)
+
+// Config configures the exporter installed by Setup
+type Config struct {
+	// Endpoint is the collector's OTLP/HTTP traces URL, such as
+	// http://localhost:4318/v1/traces
+	Endpoint string
+	// Headers are sent with every export, for collector authentication
+	Headers        map[string]string
+	ServiceName    string
+	ServiceVersion string
+	// SampleRatio is the share of new traces recorded, from 0 to 1. Traces
+	// continued from a traceparent header keep the caller's decision.
+	SampleRatio float64
+}
+
+// Setup makes a tracer provider exporting to cfg.Endpoint the global one
+// and returns its Shutdown, which exports the spans still queued
+func Setup(cfg Config) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(cfg.Endpoint),
+		otlptracehttp.WithHeaders(cfg.Headers))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxQueueSize(exportQueue),
+			sdktrace.WithMaxExportBatchSize(exportBatch),
+			sdktrace.WithBatchTimeout(exportInterval)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", cfg.ServiceName),
+			attribute.String("service.version", cfg.ServiceVersion))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider.Shutdown, nil
+}
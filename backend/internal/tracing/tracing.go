@@ -0,0 +1,36 @@
+// Package tracing records spans for requests, storage calls and background
+// jobs through OpenTelemetry. Setup installs a tracer provider exporting to
+// a collector over OTLP/HTTP with parent-based ratio sampling and W3C trace
+// context propagation; until it runs, every span is a non-recording no-op.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scope names the instrumentation the server's own spans come from
+const scope = "github.com/meur/tierforge"
+
+// tracer follows the global provider, so spans started before Setup and
+// while tracing is off are no-ops
+var tracer = otel.Tracer(scope)
+
+// Start starts a span as a child of the span in ctx, or the root of a new
+// trace when ctx has none, and returns a context carrying it
+func Start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// RecordError marks span as failed with err when err is not nil
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
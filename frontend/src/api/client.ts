@@ -1,4 +1,4 @@
-import type { Game, ItemList, TierList, TierListCreate, TierListUpdate, SheetConfig } from '@/types';
+import type { CompactItem, Contest, ContestStandings, ContestSubmission, Game, Leaderboard, ItemList, ItemSuggestion, Presence, TierList, TierListReplay, TierLock, TierListCreate, TierListUpdate, SheetConfig } from '@/types';
 
 const API_BASE = '/api';
 
@@ -41,10 +41,24 @@ export async function getItems(gameId: string, sheetId?: string): Promise<ItemLi
     return request<ItemList>(`/games/${gameId}/items${query}`);
 }
 
+export async function getCompactItems(gameId: string, sheetId: string): Promise<CompactItem[]> {
+    const params = new URLSearchParams({ sheet: sheetId, view: 'compact' });
+    return request<CompactItem[]>(`/games/${gameId}/items?${params}`);
+}
+
+export async function suggestItems(gameId: string, query: string, limit = 10): Promise<ItemSuggestion[]> {
+    const params = new URLSearchParams({ q: query, limit: String(limit) });
+    return request<ItemSuggestion[]>(`/games/${gameId}/items/suggest?${params}`);
+}
+
 export async function getSheets(gameId: string): Promise<SheetConfig[]> {
     return request<SheetConfig[]>(`/games/${gameId}/sheets`);
 }
 
+export async function getLeaderboard(gameId: string): Promise<Leaderboard> {
+    return request<Leaderboard>(`/games/${gameId}/leaderboard`);
+}
+
 // --- TierLists ---
 
 export async function createTierList(data: TierListCreate): Promise<TierList> {
@@ -71,10 +85,70 @@ export async function deleteTierList(id: string): Promise<void> {
     });
 }
 
+export async function forkTierList(id: string, name?: string): Promise<TierList> {
+    return request<TierList>(`/tierlists/${id}/fork`, {
+        method: 'POST',
+        body: JSON.stringify({ name }),
+    });
+}
+
 export async function getTierListByCode(code: string): Promise<TierList> {
     return request<TierList>(`/s/${code}`);
 }
 
+export async function lockTierList(id: string): Promise<TierList> {
+    return request<TierList>(`/tierlists/${id}/lock`, { method: 'PUT' });
+}
+
+export async function unlockTierList(id: string): Promise<TierList> {
+    return request<TierList>(`/tierlists/${id}/lock`, { method: 'DELETE' });
+}
+
+export async function getReplay(id: string): Promise<TierListReplay> {
+    return request<TierListReplay>(`/tierlists/${id}/replay`);
+}
+
+// --- Contests ---
+
+export async function getContests(gameId: string): Promise<Contest[]> {
+    return request<Contest[]>(`/games/${gameId}/contests`);
+}
+
+export async function getContest(id: string): Promise<ContestStandings> {
+    return request<ContestStandings>(`/contests/${id}`);
+}
+
+export async function submitToContest(id: string, tierListId: string): Promise<ContestSubmission> {
+    return request<ContestSubmission>(`/contests/${id}/submissions`, {
+        method: 'POST',
+        body: JSON.stringify({ tier_list_id: tierListId }),
+    });
+}
+
+// --- Presence ---
+
+export async function getPresence(id: string): Promise<Presence> {
+    return request<Presence>(`/tierlists/${id}/presence`);
+}
+
+export async function beatPresence(id: string, editing: boolean): Promise<Presence> {
+    return request<Presence>(`/tierlists/${id}/presence`, {
+        method: 'POST',
+        body: JSON.stringify({ editing }),
+    });
+}
+
+export async function claimTierLock(id: string, tierId: string): Promise<TierLock> {
+    return request<TierLock>(`/tierlists/${id}/tiers/${encodeURIComponent(tierId)}/lock`, {
+        method: 'PUT',
+    });
+}
+
+// Sent with keepalive so it still goes out while the page unloads
+export function leavePresence(id: string): void {
+    void fetch(`${API_BASE}/tierlists/${id}/presence`, { method: 'DELETE', keepalive: true }).catch(() => undefined);
+}
+
 // --- Utility ---
 
 export { APIError };
@@ -0,0 +1,88 @@
+import { stateManager } from './StateManager';
+import * as api from '@/api/client';
+import { eventBus } from './EventBus';
+import type { AppEvent } from '@/types';
+
+const PRESENCE_INTERVAL_MS = 15000;
+
+/**
+ * Presence module - heartbeats telling the server who has a tier list open,
+ * and claims on the tiers being reordered so other editors keep out of them
+ */
+class Presence {
+    private tierListId: string | null = null;
+    private interval: ReturnType<typeof setInterval> | null = null;
+    private unsubscribe: (() => void) | null = null;
+    private eventUnsubscribes: Array<() => void> = [];
+    private readonly leaveOnUnload = (): void => this.leave();
+
+    start(): void {
+        this.unsubscribe = stateManager.subscribe(
+            (state) => state.tierList?.id,
+            (id) => {
+                if (id !== this.tierListId) {
+                    this.leave();
+                    this.tierListId = id ?? null;
+                    void this.beat();
+                }
+            },
+            { immediate: true }
+        );
+        this.eventUnsubscribes = [
+            eventBus.on('DRAG_START', (event: Extract<AppEvent, { type: 'DRAG_START' }>) => {
+                void this.claim(event.containerId);
+            }),
+            eventBus.on('ITEM_MOVED', (event: Extract<AppEvent, { type: 'ITEM_MOVED' }>) => {
+                void this.claim(event.toTier);
+            }),
+        ];
+        this.interval = setInterval(() => void this.beat(), PRESENCE_INTERVAL_MS);
+        window.addEventListener('pagehide', this.leaveOnUnload);
+    }
+
+    stop(): void {
+        if (this.unsubscribe) {
+            this.unsubscribe();
+            this.unsubscribe = null;
+        }
+        if (this.interval) {
+            clearInterval(this.interval);
+            this.interval = null;
+        }
+        this.eventUnsubscribes.forEach((unsubscribe) => unsubscribe());
+        this.eventUnsubscribes = [];
+        window.removeEventListener('pagehide', this.leaveOnUnload);
+        this.leave();
+    }
+
+    private async beat(): Promise<void> {
+        if (!this.tierListId || document.visibilityState === 'hidden') return;
+
+        try {
+            // The server only counts us as editing when we may edit the list
+            const presence = await api.beatPresence(this.tierListId, true);
+            eventBus.emit({ type: 'presence:update', presence });
+        } catch (error) {
+            console.error('[Presence] Heartbeat failed:', error);
+        }
+    }
+
+    // Locks expire on their own shortly after the last claim
+    private async claim(tierId: string): Promise<void> {
+        if (!this.tierListId || tierId === 'unranked') return;
+
+        try {
+            await api.claimTierLock(this.tierListId, tierId);
+        } catch (error) {
+            console.warn('[Presence] Tier is locked:', error);
+        }
+    }
+
+    private leave(): void {
+        if (!this.tierListId) return;
+        api.leavePresence(this.tierListId);
+        this.tierListId = null;
+    }
+}
+
+export const presence = new Presence();
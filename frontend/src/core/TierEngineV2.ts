@@ -15,6 +15,7 @@ import { stateManager } from './StateManager';
 import * as api from '@/api/client';
 import { Header, TierList as TierListComponent, Sidebar, Tooltip } from '@/components';
 import { autoSave } from './AutoSave';
+import { presence } from './Presence';
 import { createEmptyPresetState, loadPresetState, savePresetState } from './presetStorage';
 import { extractTierLevel, matchesActiveFilters } from './itemFilters';
 
@@ -135,8 +136,9 @@ export class TierEngineV2 {
                 this.renderNoGames();
             }
 
-            // Start autosave
+            // Start autosave and presence heartbeats
             autoSave.start();
+            presence.start();
         } catch (error) {
             console.error('Failed to initialize TierEngine:', error);
             this.errorMessage = error instanceof Error ? error.message : 'Unknown error';
@@ -162,11 +164,26 @@ export class TierEngineV2 {
         this.renderFromState(this.buildViewModel());
     }
 
+    // Compact items are enough to draw the sheet; the full items, which
+    // filters and tooltips need, replace them once they arrive
     private async loadItems(): Promise<Item[]> {
         if (!this.game || !this.sheet) return [];
 
-        const result = await api.getItems(this.game.id, this.sheet.id);
-        return result.items || [];
+        const game = this.game;
+        const sheet = this.sheet;
+        const compact = await api.getCompactItems(game.id, sheet.id);
+        void this.loadFullItems(game.id, sheet);
+        return compact.map((item) => ({ ...item, game_id: game.id, sheet_id: sheet.id, data: {} }));
+    }
+
+    private async loadFullItems(gameId: string, sheet: SheetConfig): Promise<void> {
+        try {
+            const result = await api.getItems(gameId, sheet.id);
+            if (this.game?.id !== gameId || this.sheet !== sheet) return;
+            eventBus.emit({ type: 'ITEMS_LOADED', items: result.items || [] });
+        } catch (error) {
+            console.error('Failed to load item details:', error);
+        }
     }
 
     private async createTierListForSheet(name?: string): Promise<TierList | null> {
@@ -956,6 +973,7 @@ export class TierEngineV2 {
         this.eventUnsubscribes = [];
 
         autoSave.stop();
+        presence.stop();
 
         if (this.stateUnsubscribe) {
             this.stateUnsubscribe();
@@ -64,5 +64,7 @@ export const matchesSearchQuery = (item: Item, query: string): boolean => {
     if (name.includes(normalized)) return true;
 
     const nameRu = item.name_ru?.toLowerCase();
-    return Boolean(nameRu && nameRu.includes(normalized));
+    if (nameRu && nameRu.includes(normalized)) return true;
+
+    return (item.aliases ?? []).some((alias) => alias.toLowerCase().includes(normalized));
 };
@@ -46,11 +46,28 @@ export interface Item {
     sheet_id: string;
     name: string;
     name_ru?: string;
+    aliases?: string[];
     icon: string;
     category: string;
     data: Record<string, unknown>;
 }
 
+// The part of an item the ranking UI draws before the full items arrive
+export interface CompactItem {
+    id: string;
+    name: string;
+    icon: string;
+    category: string;
+}
+
+export interface ItemSuggestion {
+    id: string;
+    sheet_id: string;
+    name: string;
+    name_ru?: string;
+    icon: string;
+}
+
 export interface ItemList {
     items: Item[];
     total_count: number;
@@ -67,6 +84,9 @@ export interface TierList {
     tiers: Tier[];
     share_code: string;
     is_public: boolean;
+    record_replay?: boolean;
+    locked_at?: string; // Set while the owner has locked the list read-only
+    forked_from?: string; // ID of the list this one was forked from
     created_at: string;
     updated_at: string;
 }
@@ -84,12 +104,14 @@ export interface TierListCreate {
     sheet_id: string;
     name: string;
     tiers?: Tier[];
+    record_replay?: boolean;
 }
 
 export interface TierListUpdate {
     name?: string;
     tiers?: Tier[];
     is_public?: boolean;
+    record_replay?: boolean;
 }
 
 export interface TierListPreset {
@@ -125,6 +147,87 @@ export interface HistoryEntry {
     action: string;
 }
 
+// --- Replay ---
+
+export interface ReplayMove {
+    item_id: string;
+    tier_id: string; // Empty when the item returned to the pool
+    after: string; // Item placed after; empty = first in its tier
+    moved_at: string;
+}
+
+export interface TierListReplay {
+    recording: boolean;
+    truncated?: boolean;
+    moves: ReplayMove[];
+}
+
+// --- Contests ---
+
+export interface Contest {
+    id: string;
+    game_id: string;
+    sheet_id: string;
+    name: string;
+    description?: string;
+    deadline: string;
+    submissions: number;
+    created_at: string;
+}
+
+export interface ContestSubmission {
+    tier_list_id: string;
+    user_id: string;
+    display_name: string;
+    tiers: Tier[];
+    submitted_at: string;
+    agreement?: number; // Set once the contest ends
+    rank?: number;
+}
+
+export interface ContestStandings {
+    contest: Contest;
+    ended: boolean;
+    consensus?: Tier[];
+    submissions: ContestSubmission[];
+}
+
+// --- Leaderboards ---
+
+export interface LeaderboardEntry {
+    rank: number; // Equal scores share a place
+    user_id: string;
+    display_name: string;
+    score: number;
+    list_count: number;
+    updated_at: string;
+}
+
+export interface Leaderboard {
+    game_id: string;
+    most_voted: LeaderboardEntry[];
+    most_forked: LeaderboardEntry[];
+}
+
+// --- Presence ---
+
+export interface PresenceEditor {
+    id?: string;
+    display_name: string;
+}
+
+export interface TierLock {
+    tier_id: string;
+    editor: PresenceEditor;
+    expires_at: string;
+}
+
+export interface Presence {
+    viewers: number;
+    editors: PresenceEditor[];
+    locks: TierLock[];
+}
+
 // --- Events ---
 
 export type AppEvent =
@@ -142,6 +245,7 @@ export type AppEvent =
     | { type: 'autosave:start' }
     | { type: 'autosave:success' }
     | { type: 'autosave:error'; error: unknown }
+    | { type: 'presence:update'; presence: Presence }
     | { type: 'TIER_ADD_REQUESTED' }
     | { type: 'TIER_ADDED'; tier: Tier }
     | { type: 'TIER_REMOVED'; tierId: string }
@@ -23,7 +23,7 @@ go run cmd/seed/main.go --db "$DB_PATH" --seeds "$BACKEND_DIR/seeds"
 
 # 4. Import new spells
 echo "--- Importing spells into database ---"
-go run cmd/import_spells/main.go --db "$DB_PATH" --spells "$DATA_DIR/spells.json"
+go run ./cmd/import --db "$DB_PATH" --seeds "$BACKEND_DIR/seeds" --importer dos2-skills --in "$DATA_DIR/spells.json"
 
 # 5. Enrich spells with infoboxes and icons
 echo "--- Updating infoboxes and icons ---"